@@ -0,0 +1,197 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlertKind is the kind of an alert.
+type AlertKind string
+
+const (
+	// AlertReloadFailed is sent on every failed reload, once the retries are
+	// exhausted (see WithReloadRetry).
+	AlertReloadFailed AlertKind = "reload_failed"
+	// AlertReloadRecovered is sent on the first successful reload after a
+	// failed reload or a skipped reloads alert.
+	AlertReloadRecovered AlertKind = "reload_recovered"
+	// AlertReloadsSkipped is sent when the consecutive skipped reloads reach
+	// the threshold (see AlerterConfig.SkipThreshold), e.g: when the reloads
+	// are always in progress or rejected. The triggers coalesced into other
+	// are not skips.
+	AlertReloadsSkipped AlertKind = "reloads_skipped"
+)
+
+// Alert is an alert of the reload mechanism.
+type Alert struct {
+	// Kind is the kind of the alert.
+	Kind AlertKind
+	// Time is when the alert happened.
+	Time time.Time
+	// Trigger is the trigger of the reload that caused the alert.
+	Trigger TriggerEvent
+	// Pipelines are the pipelines of the reload that caused the alert (see
+	// Event.Pipelines), the alerts are tracked per pipelines.
+	Pipelines []string
+	// Err is the error of the failed reload, only on failed alerts.
+	Err error
+	// FailedReloaders are the reloaders that failed or timed out, only on
+	// failed alerts.
+	FailedReloaders []ReloaderReport
+	// Failures is the number of consecutive failed reloads, on failed and
+	// recovered alerts.
+	Failures int
+	// Skips is the number of consecutive skipped reloads, on skipped and
+	// recovered alerts.
+	Skips int
+}
+
+// AlertSink knows how to send the alerts of the reload mechanism (e.g: to
+// PagerDuty or Opsgenie), see Alerter.
+type AlertSink interface {
+	SendAlert(ctx context.Context, a Alert) error
+}
+
+// AlertSinkFunc is a helper to create alert sinks from functions.
+type AlertSinkFunc func(ctx context.Context, a Alert) error
+
+// SendAlert satisfies AlertSink interface.
+func (a AlertSinkFunc) SendAlert(ctx context.Context, al Alert) error { return a(ctx, al) }
+
+// AlerterConfig is the configuration of the Alerter.
+type AlerterConfig struct {
+	// Sinks are the sinks where the alerts are sent.
+	Sinks []AlertSink
+	// SkipThreshold is the number of consecutive skipped reloads that sends
+	// an AlertReloadsSkipped.
+	// By default 3.
+	SkipThreshold int
+	// OnError is called when a sink fails sending an alert, optional.
+	OnError func(ctx context.Context, err error)
+}
+
+func (c *AlerterConfig) defaults() error {
+	if len(c.Sinks) == 0 {
+		return fmt.Errorf("at least one sink is required")
+	}
+
+	if c.SkipThreshold <= 0 {
+		c.SkipThreshold = 3
+	}
+
+	if c.OnError == nil {
+		c.OnError = func(context.Context, error) {}
+	}
+
+	return nil
+}
+
+// Alerter is a Subscriber that sends alerts to the sinks when the reloads
+// fail, recover or are skipped repeatedly (see AlertKind). The consecutive
+// failures and skips are tracked per pipelines, so the reloads of a pipeline
+// don't recover the alerts of other. The sinks are called synchronously in
+// order, so they should be fast.
+type Alerter struct {
+	cfg AlerterConfig
+
+	mu     sync.Mutex
+	states map[string]*alertState
+}
+
+// alertState is the alert tracking of the reloads of the same pipelines.
+type alertState struct {
+	failures int
+	skips    int
+	// skipAlerted is true once the skipped reloads alert has been sent.
+	skipAlerted bool
+}
+
+var _ Subscriber = &Alerter{}
+
+// NewAlerter returns a new Alerter.
+func NewAlerter(cfg AlerterConfig) (*Alerter, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Alerter{cfg: cfg, states: map[string]*alertState{}}, nil
+}
+
+// HandleEvent satisfies Subscriber interface.
+func (a *Alerter) HandleEvent(ctx context.Context, e Event) {
+	alert, ok := a.alert(e)
+	if !ok {
+		return
+	}
+
+	for _, s := range a.cfg.Sinks {
+		err := s.SendAlert(ctx, alert)
+		if err != nil {
+			a.cfg.OnError(ctx, fmt.Errorf("could not send %q alert: %w", alert.Kind, err))
+		}
+	}
+}
+
+// alert tracks the reload outcomes and returns the alert of the event, if any.
+func (a *Alerter) alert(e Event) (Alert, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Only the skips that didn't coalesce into other trigger and the final
+	// outcome of the retried reloads count.
+	switch {
+	case e.Type == EventReloadSkipped && e.Err != nil:
+	case e.Type == EventReloadFinished && !e.Retrying:
+	default:
+		return Alert{}, false
+	}
+
+	key := strings.Join(e.Pipelines, ",")
+	s, ok := a.states[key]
+	if !ok {
+		s = &alertState{}
+		a.states[key] = s
+	}
+
+	alert := Alert{Time: e.Time, Trigger: e.Trigger, Pipelines: e.Pipelines}
+	if e.Type == EventReloadSkipped {
+		s.skips++
+		if s.skips < a.cfg.SkipThreshold || s.skipAlerted {
+			return Alert{}, false
+		}
+		s.skipAlerted = true
+		alert.Kind = AlertReloadsSkipped
+		alert.Skips = s.skips
+		return alert, true
+	}
+
+	skips, skipAlerted := s.skips, s.skipAlerted
+	s.skips, s.skipAlerted = 0, false
+
+	if e.Err != nil {
+		s.failures++
+		alert.Kind = AlertReloadFailed
+		alert.Err = e.Err
+		alert.Failures = s.failures
+		var rErr *ReloadError
+		if errors.As(e.Err, &rErr) {
+			alert.FailedReloaders = rErr.Failed()
+		}
+		return alert, true
+	}
+
+	if s.failures == 0 && !skipAlerted {
+		return Alert{}, false
+	}
+	alert.Kind = AlertReloadRecovered
+	alert.Failures = s.failures
+	alert.Skips = skips
+	s.failures = 0
+
+	return alert, true
+}
@@ -0,0 +1,145 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestAlerter(t *testing.T) {
+	finished := func(id string, err error) reload.Event {
+		return reload.Event{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: id}, Err: err}
+	}
+	skipped := func(id string) reload.Event {
+		return reload.Event{Type: reload.EventReloadSkipped, Trigger: reload.TriggerEvent{ID: id}, Err: reload.ErrReloadInProgress}
+	}
+	errTest := fmt.Errorf("something")
+
+	tests := map[string]struct {
+		cfg       reload.AlerterConfig
+		events    []reload.Event
+		expAlerts []reload.Alert
+	}{
+		"The successful reloads should not alert.": {
+			events: []reload.Event{finished("t1", nil), finished("t2", nil)},
+		},
+
+		"The failed reloads should alert and the first successful reload should recover.": {
+			events: []reload.Event{finished("t1", errTest), finished("t2", errTest), finished("t3", nil), finished("t4", nil)},
+			expAlerts: []reload.Alert{
+				{Kind: reload.AlertReloadFailed, Trigger: reload.TriggerEvent{ID: "t1"}, Err: errTest, Failures: 1},
+				{Kind: reload.AlertReloadFailed, Trigger: reload.TriggerEvent{ID: "t2"}, Err: errTest, Failures: 2},
+				{Kind: reload.AlertReloadRecovered, Trigger: reload.TriggerEvent{ID: "t3"}, Failures: 2},
+			},
+		},
+
+		"The consecutive skipped reloads should alert once on the threshold.": {
+			cfg:    reload.AlerterConfig{SkipThreshold: 2},
+			events: []reload.Event{skipped("t1"), finished("t2", nil), skipped("t3"), skipped("t4"), skipped("t5"), finished("t6", nil)},
+			expAlerts: []reload.Alert{
+				{Kind: reload.AlertReloadsSkipped, Trigger: reload.TriggerEvent{ID: "t4"}, Skips: 2},
+				{Kind: reload.AlertReloadRecovered, Trigger: reload.TriggerEvent{ID: "t6"}, Skips: 3},
+			},
+		},
+
+		"The coalesced skipped reloads should not count.": {
+			cfg: reload.AlerterConfig{SkipThreshold: 2},
+			events: []reload.Event{
+				skipped("t1"),
+				{Type: reload.EventReloadSkipped, Trigger: reload.TriggerEvent{ID: "t2"}},
+				{Type: reload.EventReloadSkipped, Trigger: reload.TriggerEvent{ID: "t3"}},
+			},
+		},
+
+		"The failed reloads that will be retried should not alert.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "t1"}, Err: errTest, Retrying: true},
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "t1"}, Err: errTest, Retrying: true},
+				finished("t1", errTest),
+			},
+			expAlerts: []reload.Alert{
+				{Kind: reload.AlertReloadFailed, Trigger: reload.TriggerEvent{ID: "t1"}, Err: errTest, Failures: 1},
+			},
+		},
+
+		"The failures should be tracked per pipelines.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "t1"}, Err: errTest, Pipelines: []string{"a"}},
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "t2"}, Pipelines: []string{"b"}},
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "t3"}, Err: errTest, Pipelines: []string{"a"}},
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "t4"}, Pipelines: []string{"a"}},
+			},
+			expAlerts: []reload.Alert{
+				{Kind: reload.AlertReloadFailed, Trigger: reload.TriggerEvent{ID: "t1"}, Pipelines: []string{"a"}, Err: errTest, Failures: 1},
+				{Kind: reload.AlertReloadFailed, Trigger: reload.TriggerEvent{ID: "t3"}, Pipelines: []string{"a"}, Err: errTest, Failures: 2},
+				{Kind: reload.AlertReloadRecovered, Trigger: reload.TriggerEvent{ID: "t4"}, Pipelines: []string{"a"}, Failures: 2},
+			},
+		},
+
+		"The other events should not alert.": {
+			events: []reload.Event{{Type: reload.EventReloaderFinished, Err: errTest}, {Type: reload.EventGroupFinished, Err: errTest}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var gotAlerts []reload.Alert
+			test.cfg.Sinks = []reload.AlertSink{reload.AlertSinkFunc(func(ctx context.Context, a reload.Alert) error {
+				gotAlerts = append(gotAlerts, a)
+				return nil
+			})}
+			a, err := reload.NewAlerter(test.cfg)
+			require.NoError(err)
+
+			// Execute.
+			for _, e := range test.events {
+				a.HandleEvent(context.TODO(), e)
+			}
+
+			// Check.
+			assert.Equal(test.expAlerts, gotAlerts)
+		})
+	}
+}
+
+func TestAlerterFailedReloaders(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	var gotAlerts []reload.Alert
+	var gotErrs []error
+	a, err := reload.NewAlerter(reload.AlerterConfig{
+		Sinks: []reload.AlertSink{
+			reload.AlertSinkFunc(func(ctx context.Context, a reload.Alert) error { return fmt.Errorf("sink failed") }),
+			reload.AlertSinkFunc(func(ctx context.Context, a reload.Alert) error {
+				gotAlerts = append(gotAlerts, a)
+				return nil
+			}),
+		},
+		OnError: func(ctx context.Context, err error) { gotErrs = append(gotErrs, err) },
+	})
+	require.NoError(err)
+	m := reload.NewManager(reload.WithSubscriber(a))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }), reload.WithReloaderName("config"))
+
+	// Execute.
+	_ = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	require.Len(gotAlerts, 1)
+	require.Len(gotAlerts[0].FailedReloaders, 1)
+	assert.Equal("config", gotAlerts[0].FailedReloaders[0].Name)
+	assert.Equal(reload.ReloaderFailed, gotAlerts[0].FailedReloaders[0].Status)
+	require.Len(gotErrs, 1)
+	assert.EqualError(gotErrs[0], `could not send "reload_failed" alert: sink failed`)
+}
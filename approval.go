@@ -0,0 +1,196 @@
+package reload
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownApproval is returned when approving or rejecting a trigger
+	// that is not pending approval.
+	ErrUnknownApproval = errors.New("unknown approval")
+	// ErrApprovalRejected is the reason of the rejected approvals.
+	ErrApprovalRejected = errors.New("approval rejected")
+	// ErrApprovalExpired is the reason of the approvals that expired without
+	// being approved.
+	ErrApprovalExpired = errors.New("approval expired")
+)
+
+// Approval is the configuration of the manual approval of the reloads (see
+// WithApproval).
+type Approval struct {
+	// Expiration is the time a trigger waits for the approval.
+	// By default 1h.
+	Expiration time.Duration
+	// ApproveOnExpiration approves the triggers that expire instead of
+	// rejecting them.
+	ApproveOnExpiration bool
+}
+
+// StatusApproval is a trigger pending approval.
+type StatusApproval struct {
+	// Token identifies the approval request, the decisions are matched on it
+	// so they don't apply to other request of the same trigger (see
+	// Manager.Approve).
+	Token string
+	// Trigger is the trigger waiting for the approval.
+	Trigger TriggerEvent
+	// Since is when the trigger started waiting for the approval.
+	Since time.Time
+	// ExpiresAt is when the approval expires.
+	ExpiresAt time.Time
+}
+
+// approvals tracks the trigger pending approval.
+type approvals struct {
+	mu       sync.Mutex
+	pending  *StatusApproval
+	decision chan error
+}
+
+func (a *approvals) set(s *StatusApproval) <-chan error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pending = s
+	a.decision = make(chan error, 1)
+	return a.decision
+}
+
+func (a *approvals) clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending = nil
+}
+
+// decide sets the decision of the pending approval with the token.
+func (a *approvals) decide(token string, reason error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.pending == nil || a.pending.Token != token {
+		return fmt.Errorf("%w with %q token", ErrUnknownApproval, token)
+	}
+	a.pending = nil
+	a.decision <- reason
+
+	return nil
+}
+
+func (a *approvals) status() *StatusApproval {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.pending == nil {
+		return nil
+	}
+	s := *a.pending
+	return &s
+}
+
+// Approve approves the reload of the trigger pending approval (see
+// WithApproval) with the approval request token (see StatusApproval.Token and
+// Event.ApprovalToken), returns ErrUnknownApproval if the request is not
+// pending approval.
+func (m *Manager) Approve(ctx context.Context, token string) error {
+	return m.approvals.decide(token, nil)
+}
+
+// Reject rejects the reload of the trigger pending approval (see
+// WithApproval) with the approval request token (see StatusApproval.Token and
+// Event.ApprovalToken), returns ErrUnknownApproval if the request is not
+// pending approval.
+func (m *Manager) Reject(ctx context.Context, token string) error {
+	return m.approvals.decide(token, ErrApprovalRejected)
+}
+
+// waitApproval waits until the trigger is approved, rejected or the approval
+// expires. The triggers of the same route received meanwhile replace the
+// pending one with a new approval request (see triggerRoute), the triggers of
+// other routes are coalesced per route and scheduled after it to wait for their
+// own approval. Once the trigger queue size routes are scheduled, the queue is
+// not drained anymore so the notifiers queue overflow policy applies (see
+// WithTriggerQueue). Returns the rejection reason if the trigger has not been
+// approved.
+func (m *Manager) waitApproval(ctx context.Context, signal <-chan notifierResult, scheduled *[]notifierResult, res notifierResult, lastEnd time.Time) (notifierResult, error, error) {
+	if m.cfg.approval == nil {
+		return res, nil, nil
+	}
+	defer m.approvals.clear()
+
+	var later []notifierResult
+	defer func() { *scheduled = append(*scheduled, later...) }()
+
+	for {
+		now := m.cfg.clock.Now()
+		token := newApprovalToken()
+		decision := m.approvals.set(&StatusApproval{Token: token, Trigger: res.Trigger, Since: now, ExpiresAt: now.Add(m.cfg.approval.Expiration)})
+		m.emit(ctx, Event{Type: EventApprovalPending, Trigger: res.Trigger, ApprovalToken: token})
+
+		t := m.cfg.clock.NewTimer(m.cfg.approval.Expiration)
+	wait:
+		for {
+			queue := signal
+			if len(later) >= cap(signal) {
+				queue = nil
+			}
+
+			select {
+			case reason := <-decision:
+				t.Stop()
+				if reason != nil {
+					m.emit(ctx, Event{Type: EventApprovalRejected, Trigger: res.Trigger, Err: reason, ApprovalToken: token})
+					return res, reason, nil
+				}
+				m.emit(ctx, Event{Type: EventApprovalApproved, Trigger: res.Trigger, ApprovalToken: token})
+				return res, nil, nil
+			case <-t.C():
+				if !m.cfg.approval.ApproveOnExpiration {
+					m.emit(ctx, Event{Type: EventApprovalRejected, Trigger: res.Trigger, Err: ErrApprovalExpired, ApprovalToken: token})
+					return res, ErrApprovalExpired, nil
+				}
+				m.emit(ctx, Event{Type: EventApprovalApproved, Trigger: res.Trigger, ApprovalToken: token})
+				return res, nil, nil
+			case queued := <-queue:
+				if queued.Err != nil {
+					t.Stop()
+					return res, nil, fmt.Errorf("notifier failed: %w", queued.Err)
+				}
+
+				m.receiveTrigger(ctx, &queued, lastEnd)
+				if m.triggerRoute(queued.Trigger) != m.triggerRoute(res.Trigger) {
+					var err error
+					later, err = m.coalesceRoute(ctx, later, queued)
+					if err != nil {
+						t.Stop()
+						return res, nil, err
+					}
+					continue
+				}
+
+				t.Stop()
+				pending, err := m.coalesceRoute(ctx, []notifierResult{res}, queued)
+				if err != nil {
+					return res, nil, err
+				}
+				res = pending[0]
+				break wait
+			case <-ctx.Done():
+				t.Stop()
+				return res, ctx.Err(), nil
+			}
+		}
+	}
+}
+
+// newApprovalToken returns a random approval request token.
+func newApprovalToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
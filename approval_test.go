@@ -0,0 +1,280 @@
+package reload_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerApproval(t *testing.T) {
+	tests := map[string]struct {
+		approval reload.Approval
+		decide   func(m *reload.Manager, clock *reloadtest.Clock, notifierC chan<- string, pending <-chan struct{}) error
+		expTrail []string
+	}{
+		"An approved trigger should be reloaded.": {
+			decide: func(m *reload.Manager, clock *reloadtest.Clock, notifierC chan<- string, pending <-chan struct{}) error {
+				return m.Approve(context.TODO(), m.Status().PendingApproval.Token)
+			},
+			expTrail: []string{
+				"trigger_received id=t1 source=test",
+				"approval_pending id=t1",
+				"approval_approved id=t1",
+				"reload_started id=t1",
+				"group_started id=t1 priority=0",
+				"reloader_finished id=t1 priority=0 reloader=r0",
+				"group_finished id=t1 priority=0",
+				"reload_finished id=t1",
+			},
+		},
+
+		"A rejected trigger should be skipped.": {
+			decide: func(m *reload.Manager, clock *reloadtest.Clock, notifierC chan<- string, pending <-chan struct{}) error {
+				return m.Reject(context.TODO(), m.Status().PendingApproval.Token)
+			},
+			expTrail: []string{
+				"trigger_received id=t1 source=test",
+				"approval_pending id=t1",
+				"approval_rejected id=t1 err=approval rejected",
+				"reload_skipped id=t1 err=approval rejected",
+			},
+		},
+
+		"An expired approval should be rejected.": {
+			approval: reload.Approval{Expiration: time.Minute},
+			decide: func(m *reload.Manager, clock *reloadtest.Clock, notifierC chan<- string, pending <-chan struct{}) error {
+				clock.WaitWaiters(1, time.Second)
+				clock.Advance(time.Minute)
+				return nil
+			},
+			expTrail: []string{
+				"trigger_received id=t1 source=test",
+				"approval_pending id=t1",
+				"approval_rejected id=t1 err=approval expired",
+				"reload_skipped id=t1 err=approval expired",
+			},
+		},
+
+		"An expired approval should be approved when approving on expiration.": {
+			approval: reload.Approval{Expiration: time.Minute, ApproveOnExpiration: true},
+			decide: func(m *reload.Manager, clock *reloadtest.Clock, notifierC chan<- string, pending <-chan struct{}) error {
+				clock.WaitWaiters(1, time.Second)
+				clock.Advance(time.Minute)
+				return nil
+			},
+			expTrail: []string{
+				"trigger_received id=t1 source=test",
+				"approval_pending id=t1",
+				"approval_approved id=t1",
+				"reload_started id=t1",
+				"group_started id=t1 priority=0",
+				"reloader_finished id=t1 priority=0 reloader=r0",
+				"group_finished id=t1 priority=0",
+				"reload_finished id=t1",
+			},
+		},
+
+		"A new trigger should replace the pending one.": {
+			decide: func(m *reload.Manager, clock *reloadtest.Clock, notifierC chan<- string, pending <-chan struct{}) error {
+				replaced := m.Status().PendingApproval.Token
+				notifierC <- "t2"
+				<-pending
+				if err := m.Approve(context.TODO(), replaced); !errors.Is(err, reload.ErrUnknownApproval) {
+					return fmt.Errorf("replaced trigger should not be pending approval: %v", err)
+				}
+				return m.Approve(context.TODO(), m.Status().PendingApproval.Token)
+			},
+			expTrail: []string{
+				"trigger_received id=t1 source=test",
+				"approval_pending id=t1",
+				"trigger_received id=t2 source=test",
+				"reload_skipped id=t1",
+				"approval_pending id=t2",
+				"approval_approved id=t2",
+				"reload_started id=t2",
+				"group_started id=t2 priority=0",
+				"reloader_finished id=t2 priority=0 reloader=r0",
+				"group_finished id=t2 priority=0",
+				"reload_finished id=t2",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Now())
+			rec := reloadtest.NewRecorder()
+			pending := make(chan struct{}, 2)
+			m := reload.NewManager(
+				reload.WithClock(clock),
+				reload.WithApproval(test.approval),
+				reload.WithSubscriber(rec),
+				reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+					if e.Type == reload.EventApprovalPending {
+						pending <- struct{}{}
+					}
+				})),
+			)
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("r0"))
+			notifierC := make(chan string)
+			m.On(reload.NotifierChan(notifierC), reload.WithNotifierName("test"))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runErr := make(chan error)
+			go func() { runErr <- m.Run(ctx) }()
+			notifierC <- "t1"
+			<-pending
+			status := m.Status()
+			require.NotNil(status.PendingApproval)
+			assert.Equal("t1", status.PendingApproval.Trigger.ID)
+			require.NoError(test.decide(&m, clock, notifierC, pending))
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			// Check.
+			assert.NoError(<-runErr)
+			assert.Nil(m.Status().PendingApproval)
+			rec.AssertTrail(t, test.expTrail...)
+		})
+	}
+}
+
+func TestManagerApprovalRoutes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	rec := reloadtest.NewRecorder()
+	pending := make(chan string, 2)
+	m := reload.NewManager(
+		reload.WithApproval(reload.Approval{}),
+		reload.WithSubscriber(rec),
+		reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+			if e.Type == reload.EventApprovalPending {
+				pending <- e.ApprovalToken
+			}
+		})),
+	)
+	noop := reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil })
+	m.Add(0, noop, reload.WithReloaderName("config"), reload.WithTriggerSources("file"))
+	m.Add(0, noop, reload.WithReloaderName("certs"), reload.WithTriggerSources("certs"))
+	fileC := make(chan string)
+	m.On(reload.NotifierChan(fileC), reload.WithNotifierName("file"))
+	certsC := make(chan string)
+	m.On(reload.NotifierChan(certsC), reload.WithNotifierName("certs"))
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	fileC <- "t1"
+	token1 := <-pending
+	certsC <- "t2"
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(m.Reject(context.TODO(), token1))
+	token2 := <-pending
+	require.NoError(m.Approve(context.TODO(), token2))
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// Check.
+	assert.NoError(<-runErr)
+	assert.NotEqual(token1, token2)
+	rec.AssertTrail(t,
+		"trigger_received id=t1 source=file",
+		"approval_pending id=t1",
+		"trigger_received id=t2 source=certs",
+		"approval_rejected id=t1 err=approval rejected",
+		"reload_skipped id=t1 err=approval rejected",
+		"approval_pending id=t2",
+		"approval_approved id=t2",
+		"reload_started id=t2",
+		"group_started id=t2 priority=0",
+		"reloader_finished id=t2 priority=0 reloader=certs",
+		"group_finished id=t2 priority=0",
+		"reload_finished id=t2",
+	)
+}
+
+func TestManagerApprovalQueueBound(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	pending := make(chan string, 10)
+	dropped := make(chan string, 10)
+	m := reload.NewManager(
+		reload.WithApproval(reload.Approval{}),
+		reload.WithTriggerQueue(2, reload.QueueOverflowDropNewest),
+		reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+			switch e.Type {
+			case reload.EventApprovalPending:
+				pending <- e.ApprovalToken
+			case reload.EventTriggerDropped:
+				dropped <- e.Trigger.ID
+			}
+		})),
+	)
+	reloaded := make(chan string, 10)
+	r := reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		t, _ := reload.TriggerEventFromContext(ctx)
+		reloaded <- t.ID
+		return nil
+	})
+	notifiers := map[string]chan string{}
+	for _, name := range []string{"file", "certs", "tls"} {
+		m.Add(0, r, reload.WithReloaderName(name), reload.WithTriggerSources(name))
+		notifiers[name] = make(chan string)
+		m.On(reload.NotifierChan(notifiers[name]), reload.WithNotifierName(name))
+	}
+
+	// Execute: while the first trigger waits for approval, the triggers of
+	// the same route are coalesced and the queue overflow drops the rest.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	notifiers["file"] <- "t1"
+	token := <-pending
+	for _, t := range []struct{ source, id string }{
+		{"certs", "t2"}, {"certs", "t3"}, {"tls", "t4"}, {"tls", "t5"}, {"tls", "t6"}, {"tls", "t7"},
+	} {
+		notifiers[t.source] <- t.id
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(m.Approve(context.TODO(), token))
+	go func() {
+		for token := range pending {
+			_ = m.Approve(context.TODO(), token)
+		}
+	}()
+
+	// Check.
+	var gotReloaded []string
+	for range 4 {
+		select {
+		case id := <-reloaded:
+			gotReloaded = append(gotReloaded, id)
+		case <-time.After(time.Second):
+			require.Fail("trigger was not reloaded")
+		}
+	}
+	assert.Equal([]string{"t1", "t3", "t4", "t6"}, gotReloaded)
+	require.Len(dropped, 1)
+	assert.Equal("t7", <-dropped)
+	cancel()
+	assert.NoError(<-runErr)
+}
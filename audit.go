@@ -0,0 +1,231 @@
+package reload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditOutcome is the outcome of a reload attempt.
+type AuditOutcome string
+
+const (
+	// AuditOutcomeSuccess is used when all the reloaders of the reload attempt succeeded.
+	AuditOutcomeSuccess AuditOutcome = "success"
+	// AuditOutcomeFailure is used when the reload attempt failed.
+	AuditOutcomeFailure AuditOutcome = "failure"
+	// AuditOutcomeSkipped is used when the reload attempt didn't execute the reloaders.
+	AuditOutcomeSkipped AuditOutcome = "skipped"
+)
+
+// AuditGroupRecord is the audit information of a reloader priority group
+// executed on a reload attempt.
+type AuditGroupRecord struct {
+	Priority int
+	Duration time.Duration
+	Error    string
+}
+
+// AuditRecord is the audit information of a reload attempt.
+//
+// The records are immutable, they are built for each sink call and
+// they don't share memory with the manager.
+type AuditRecord struct {
+	// TriggerID is the ID of the trigger that started the reload attempt.
+	TriggerID string
+	// TriggerSource is the name of the notifier that started the reload attempt.
+	TriggerSource string
+	// TriggerReason is the reason of the trigger that started the reload
+	// attempt, if any.
+	TriggerReason string
+	// TriggerPaths are the paths that changed and started the reload attempt, if any.
+	TriggerPaths []string
+	// TriggerKeys are the configuration keys that changed and started the reload attempt, if known.
+	TriggerKeys []string
+	// TriggerMetadata is the metadata of the trigger that started the reload
+	// attempt (e.g: the reason and the requester of the admin triggers), if any.
+	TriggerMetadata map[string]string
+	// Outcome is the result of the reload attempt.
+	Outcome AuditOutcome
+	// Error is the error message of the failed reload attempt.
+	Error string
+	// StartedAt is when the reload attempt started.
+	StartedAt time.Time
+	// Duration is the total duration of the reload attempt.
+	Duration time.Duration
+	// Groups are the executed priority groups of the reload attempt, in execution order.
+	Groups []AuditGroupRecord
+}
+
+// AuditSink knows how to store audit records of the reload attempts.
+//
+// If the sink returns an error the manager will end its execution, so
+// reloads don't happen without being audited.
+type AuditSink interface {
+	WriteAuditRecord(ctx context.Context, r AuditRecord) error
+}
+
+// AuditSinkFunc is a helper to create audit sinks from functions.
+type AuditSinkFunc func(ctx context.Context, r AuditRecord) error
+
+// WriteAuditRecord satisfies AuditSink interface.
+func (a AuditSinkFunc) WriteAuditRecord(ctx context.Context, r AuditRecord) error { return a(ctx, r) }
+
+type jsonAuditGroupRecord struct {
+	Priority        int     `json:"priority"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+type jsonAuditRecord struct {
+	TriggerID       string                 `json:"trigger_id"`
+	TriggerSource   string                 `json:"trigger_source"`
+	TriggerReason   string                 `json:"trigger_reason,omitempty"`
+	TriggerPaths    []string               `json:"trigger_paths,omitempty"`
+	TriggerKeys     []string               `json:"trigger_keys,omitempty"`
+	TriggerMetadata map[string]string      `json:"trigger_metadata,omitempty"`
+	Outcome         AuditOutcome           `json:"outcome"`
+	Error           string                 `json:"error,omitempty"`
+	StartedAt       time.Time              `json:"started_at"`
+	DurationSeconds float64                `json:"duration_seconds"`
+	Groups          []jsonAuditGroupRecord `json:"groups,omitempty"`
+}
+
+type jsonLinesAuditSink struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewJSONLinesAuditSink returns an AuditSink that writes each audit record
+// as a JSON line on the writer. Safe for concurrent use.
+func NewJSONLinesAuditSink(w io.Writer) AuditSink {
+	return &jsonLinesAuditSink{w: w}
+}
+
+func (j *jsonLinesAuditSink) WriteAuditRecord(_ context.Context, r AuditRecord) error {
+	jr := jsonAuditRecord{
+		TriggerID:       r.TriggerID,
+		TriggerSource:   r.TriggerSource,
+		TriggerReason:   r.TriggerReason,
+		TriggerPaths:    r.TriggerPaths,
+		TriggerKeys:     r.TriggerKeys,
+		TriggerMetadata: r.TriggerMetadata,
+		Outcome:         r.Outcome,
+		Error:           r.Error,
+		StartedAt:       r.StartedAt.UTC(),
+		DurationSeconds: r.Duration.Seconds(),
+	}
+	for _, g := range r.Groups {
+		jr.Groups = append(jr.Groups, jsonAuditGroupRecord{
+			Priority:        g.Priority,
+			DurationSeconds: g.Duration.Seconds(),
+			Error:           g.Error,
+		})
+	}
+
+	data, err := json.Marshal(jr)
+	if err != nil {
+		return fmt.Errorf("could not marshal audit record: %w", err)
+	}
+	data = append(data, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err = j.w.Write(data)
+	if err != nil {
+		return fmt.Errorf("could not write audit record: %w", err)
+	}
+
+	return nil
+}
+
+// FileAuditSink is an AuditSink that appends the audit records as JSON lines
+// to a file. Every record is synced to disk before returning.
+type FileAuditSink struct {
+	f    *os.File
+	sink AuditSink
+}
+
+// NewFileAuditSink returns a new FileAuditSink, the file will be created if
+// doesn't exist.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit file: %w", err)
+	}
+
+	return &FileAuditSink{
+		f:    f,
+		sink: NewJSONLinesAuditSink(f),
+	}, nil
+}
+
+// WriteAuditRecord satisfies AuditSink interface.
+func (f *FileAuditSink) WriteAuditRecord(ctx context.Context, r AuditRecord) error {
+	err := f.sink.WriteAuditRecord(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	err = f.f.Sync()
+	if err != nil {
+		return fmt.Errorf("could not sync audit file: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the audit file.
+func (f *FileAuditSink) Close() error {
+	return f.f.Close()
+}
+
+// newAuditRecord creates an audit record from a reload attempt, copying the
+// data so the record doesn't share memory.
+func newAuditRecord(a reloadAttempt) AuditRecord {
+	r := AuditRecord{
+		TriggerID:     a.trigger.ID,
+		TriggerSource: a.trigger.Source,
+		TriggerReason: a.trigger.Reason,
+		Outcome:       AuditOutcomeSuccess,
+		StartedAt:     a.start,
+		Duration:      a.duration,
+	}
+
+	if len(a.trigger.Paths) > 0 {
+		r.TriggerPaths = append([]string{}, a.trigger.Paths...)
+	}
+
+	if len(a.trigger.Keys) > 0 {
+		r.TriggerKeys = append([]string{}, a.trigger.Keys...)
+	}
+
+	if len(a.trigger.Metadata) > 0 {
+		r.TriggerMetadata = make(map[string]string, len(a.trigger.Metadata))
+		for k, v := range a.trigger.Metadata {
+			r.TriggerMetadata[k] = v
+		}
+	}
+
+	switch {
+	case a.skipped:
+		r.Outcome = AuditOutcomeSkipped
+	case a.err != nil:
+		r.Outcome = AuditOutcomeFailure
+		r.Error = a.err.Error()
+	}
+
+	for _, g := range a.groups {
+		gr := AuditGroupRecord{Priority: g.priority, Duration: g.duration}
+		if g.err != nil {
+			gr.Error = g.err.Error()
+		}
+		r.Groups = append(r.Groups, gr)
+	}
+
+	return r
+}
@@ -0,0 +1,201 @@
+package reload_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerAuditSink(t *testing.T) {
+	tests := map[string]struct {
+		reloaders  map[int]error
+		sinkErr    error
+		expErr     bool
+		expRecords []reload.AuditRecord
+	}{
+		"A successful reload should be audited.": {
+			reloaders: map[int]error{0: nil, 10: nil},
+			expRecords: []reload.AuditRecord{
+				{
+					TriggerID:     "test-id",
+					TriggerSource: "test",
+					Outcome:       reload.AuditOutcomeSuccess,
+					Groups:        []reload.AuditGroupRecord{{Priority: 0}, {Priority: 10}},
+				},
+			},
+		},
+
+		"A failed reload should be audited.": {
+			reloaders: map[int]error{0: nil, 10: fmt.Errorf("something"), 20: nil},
+			expErr:    true,
+			expRecords: []reload.AuditRecord{
+				{
+					TriggerID:     "test-id",
+					TriggerSource: "test",
+					Outcome:       reload.AuditOutcomeFailure,
+					Error:         `reload "test-id" failed: group (priority 10): reloader "r10" (1/1): something`,
+					Groups:        []reload.AuditGroupRecord{{Priority: 0}, {Priority: 10, Error: `reloader "r10" (1/1): something`}},
+				},
+			},
+		},
+
+		"A failing audit sink should end the execution with an error.": {
+			reloaders: map[int]error{0: nil},
+			sinkErr:   fmt.Errorf("something"),
+			expErr:    true,
+			expRecords: []reload.AuditRecord{
+				{
+					TriggerID:     "test-id",
+					TriggerSource: "test",
+					Outcome:       reload.AuditOutcomeSuccess,
+					Groups:        []reload.AuditGroupRecord{{Priority: 0}},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var mu sync.Mutex
+			var gotRecords []reload.AuditRecord
+			sink := reload.AuditSinkFunc(func(ctx context.Context, r reload.AuditRecord) error {
+				mu.Lock()
+				defer mu.Unlock()
+				gotRecords = append(gotRecords, r)
+				return test.sinkErr
+			})
+
+			m := reload.NewManager(reload.WithAuditSink(sink))
+			for priority, err := range test.reloaders {
+				err := err
+				m.Add(priority, reload.ReloaderFunc(func(ctx context.Context, id string) error { return err }), reload.WithReloaderName(fmt.Sprintf("r%d", priority)))
+			}
+			notifierC := make(chan string)
+			m.On(reload.NotifierChan(notifierC), reload.WithNotifierName("test"))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			notifierC <- "test-id"
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			// Check.
+			err := <-runFinished
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			// Remove the non deterministic data.
+			mu.Lock()
+			defer mu.Unlock()
+			for i := range gotRecords {
+				assert.False(gotRecords[i].StartedAt.IsZero())
+				gotRecords[i].StartedAt = time.Time{}
+				gotRecords[i].Duration = 0
+				for j := range gotRecords[i].Groups {
+					gotRecords[i].Groups[j].Duration = 0
+				}
+			}
+			assert.Equal(test.expRecords, gotRecords)
+		})
+	}
+}
+
+func TestManagerAuditTriggerMetadata(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	var got reload.AuditRecord
+	m := reload.NewManager(reload.WithAuditSink(reload.AuditSinkFunc(func(ctx context.Context, r reload.AuditRecord) error {
+		got = r
+		return nil
+	})))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }))
+	md := map[string]string{"reason": "deploy-123", "requester": "alice"}
+
+	// Execute.
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1", Reason: "deploy-123", Metadata: md})
+	require.NoError(err)
+	md["reason"] = "changed"
+
+	// Check.
+	assert.Equal("deploy-123", got.TriggerReason)
+	assert.Equal(map[string]string{"reason": "deploy-123", "requester": "alice"}, got.TriggerMetadata)
+}
+
+func TestJSONLinesAuditSink(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var b bytes.Buffer
+	sink := reload.NewJSONLinesAuditSink(&b)
+	err := sink.WriteAuditRecord(context.TODO(), reload.AuditRecord{
+		TriggerID:     "test-id",
+		TriggerSource: "file",
+		TriggerPaths:  []string{"/tmp/a.json"},
+		Outcome:       reload.AuditOutcomeFailure,
+		Error:         "something",
+		StartedAt:     time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+		Duration:      1500 * time.Millisecond,
+		Groups:        []reload.AuditGroupRecord{{Priority: 10, Duration: 500 * time.Millisecond, Error: "something"}},
+	})
+	require.NoError(err)
+	err = sink.WriteAuditRecord(context.TODO(), reload.AuditRecord{
+		TriggerID:       "test-id2",
+		TriggerSource:   "http",
+		TriggerReason:   "deploy-123",
+		TriggerMetadata: map[string]string{"reason": "deploy-123"},
+		Outcome:         reload.AuditOutcomeSkipped,
+		StartedAt:       time.Date(2021, 7, 19, 10, 0, 1, 0, time.UTC),
+	})
+	require.NoError(err)
+
+	exp := `{"trigger_id":"test-id","trigger_source":"file","trigger_paths":["/tmp/a.json"],"outcome":"failure","error":"something","started_at":"2021-07-19T10:00:00Z","duration_seconds":1.5,"groups":[{"priority":10,"duration_seconds":0.5,"error":"something"}]}
+{"trigger_id":"test-id2","trigger_source":"http","trigger_reason":"deploy-123","trigger_metadata":{"reason":"deploy-123"},"outcome":"skipped","started_at":"2021-07-19T10:00:01Z","duration_seconds":0}
+`
+	assert.Equal(exp, b.String())
+}
+
+func TestFileAuditSink(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(os.WriteFile(path, []byte("{}\n"), 0o600))
+
+	sink, err := reload.NewFileAuditSink(path)
+	require.NoError(err)
+	err = sink.WriteAuditRecord(context.TODO(), reload.AuditRecord{
+		TriggerID:     "test-id",
+		TriggerSource: "file",
+		Outcome:       reload.AuditOutcomeSuccess,
+		StartedAt:     time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+	})
+	require.NoError(err)
+	require.NoError(sink.Close())
+
+	got, err := os.ReadFile(path)
+	require.NoError(err)
+	exp := `{}
+{"trigger_id":"test-id","trigger_source":"file","outcome":"success","started_at":"2021-07-19T10:00:00Z","duration_seconds":0}
+`
+	assert.Equal(exp, string(got))
+}
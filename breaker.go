@@ -0,0 +1,134 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownCircuitBreaker is returned when resetting the circuit breaker of a
+// reloader that doesn't have one.
+var ErrUnknownCircuitBreaker = errors.New("unknown circuit breaker")
+
+// circuitBreaker skips a reloader after consecutive failures for a cool-down
+// period. Once the cool-down ends the reloader is reloaded again (half-open),
+// a success closes the circuit and a failure opens it for another cool-down.
+type circuitBreaker struct {
+	failures int
+	coolDown time.Duration
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+}
+
+// open returns if the circuit is open, the reloader needs to be skipped.
+func (c *circuitBreaker) open(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return now.Before(c.openUntil)
+}
+
+// tripped returns if the circuit has been opened and not closed yet, even if
+// the cool-down has ended.
+func (c *circuitBreaker) tripped() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.openUntil.IsZero()
+}
+
+// record records the reloader result, returns if the circuit state changed
+// and the new state.
+func (c *circuitBreaker) record(err error, now time.Time) (changed, open bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wasOpen := !c.openUntil.IsZero()
+	if err == nil {
+		c.consecutiveFailures = 0
+		c.openUntil = time.Time{}
+		return wasOpen, false
+	}
+
+	c.consecutiveFailures++
+	if c.consecutiveFailures < c.failures {
+		return false, wasOpen
+	}
+	c.openUntil = now.Add(c.coolDown)
+
+	return !wasOpen, true
+}
+
+func (c *circuitBreaker) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consecutiveFailures = 0
+	c.openUntil = time.Time{}
+}
+
+// skipOpenCircuit returns if the reloader needs to be skipped because its
+// circuit is open.
+func (m *Manager) skipOpenCircuit(ctx context.Context, r registeredReloader, priority int, t TriggerEvent) bool {
+	if r.breaker == nil || !r.breaker.open(m.cfg.clock.Now()) {
+		return false
+	}
+
+	m.emit(ctx, Event{Type: EventReloaderSkipped, Trigger: t, Priority: priority, Reloader: r.name})
+	return true
+}
+
+// recordCircuit records the reloader result on its circuit breaker, if any.
+func (m *Manager) recordCircuit(ctx context.Context, r registeredReloader, err error) {
+	if r.breaker == nil {
+		return
+	}
+
+	changed, open := r.breaker.record(err, m.cfg.clock.Now())
+	if changed {
+		m.cfg.metricsRecorder.SetCircuitOpen(ctx, r.name, open)
+	}
+}
+
+// ResetCircuitBreaker closes the circuit breaker of the reloaders with the
+// name (see WithCircuitBreaker), so they are reloaded on the next reload
+// process without waiting for the cool-down.
+func (m *Manager) ResetCircuitBreaker(ctx context.Context, reloader string) error {
+	found := false
+	for _, rg := range m.reloaders {
+		for _, r := range rg.reloaders {
+			if r.name != reloader || r.breaker == nil {
+				continue
+			}
+			found = true
+			if r.breaker.tripped() {
+				r.breaker.reset()
+				m.cfg.metricsRecorder.SetCircuitOpen(ctx, r.name, false)
+			}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w for %q reloader", ErrUnknownCircuitBreaker, reloader)
+	}
+
+	return nil
+}
+
+// openCircuits returns the sorted names of the reloaders with a tripped
+// circuit breaker.
+func (m *Manager) openCircuits() []string {
+	var names []string
+	for _, rg := range m.reloaders {
+		for _, r := range rg.reloaders {
+			if r.breaker != nil && r.breaker.tripped() {
+				names = append(names, r.name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
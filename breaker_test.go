@@ -0,0 +1,118 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Now())
+	rec := reloadtest.NewRecorder()
+	m := reload.NewManager(reload.WithClock(clock), reload.WithSubscriber(rec))
+	var fail bool
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		if fail {
+			return fmt.Errorf("something")
+		}
+		return nil
+	}), reload.WithReloaderName("config"), reload.WithCircuitBreaker(2, time.Minute))
+	trigger := func(id string) error { return m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: id}) }
+
+	// Two consecutive failures open the circuit.
+	fail = true
+	require.Error(trigger("t1"))
+	assert.Empty(m.Status().OpenCircuits)
+	require.Error(trigger("t2"))
+	assert.Equal([]string{"config"}, m.Status().OpenCircuits)
+
+	// While open the reloader is skipped.
+	require.NoError(trigger("t3"))
+
+	// After the cool-down a failure opens it again.
+	clock.Advance(time.Minute)
+	require.Error(trigger("t4"))
+	require.NoError(trigger("t5"))
+
+	// After the cool-down a success closes it.
+	clock.Advance(time.Minute)
+	fail = false
+	require.NoError(trigger("t6"))
+	assert.Empty(m.Status().OpenCircuits)
+
+	// Check.
+	exp := []string{
+		"reloader_finished id=t1 priority=0 reloader=config err=something",
+		"reloader_finished id=t2 priority=0 reloader=config err=something",
+		"reloader_skipped id=t3 priority=0 reloader=config",
+		"reloader_finished id=t4 priority=0 reloader=config err=something",
+		"reloader_skipped id=t5 priority=0 reloader=config",
+		"reloader_finished id=t6 priority=0 reloader=config",
+	}
+	var got []string
+	for _, e := range rec.Events() {
+		if e.Type == reload.EventReloaderFinished || e.Type == reload.EventReloaderSkipped {
+			got = append(got, reloadtest.TrailLine(e))
+		}
+	}
+	assert.Equal(exp, got)
+}
+
+func TestManagerResetCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	m := reload.NewManager()
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }),
+		reload.WithReloaderName("config"), reload.WithCircuitBreaker(1, time.Hour))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("other"))
+	require.Error(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"}))
+	require.Equal([]string{"config"}, m.Status().OpenCircuits)
+
+	// Execute.
+	err := m.ResetCircuitBreaker(context.TODO(), "config")
+	unknownErr := m.ResetCircuitBreaker(context.TODO(), "other")
+
+	// Check.
+	assert.NoError(err)
+	assert.ErrorIs(unknownErr, reload.ErrUnknownCircuitBreaker)
+	assert.Empty(m.Status().OpenCircuits)
+	assert.Error(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t2"}))
+}
+
+func TestManagerCircuitBreakerCancelledReloader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	m := reload.NewManager()
+	started := make(chan struct{})
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		<-started
+		return fmt.Errorf("something")
+	}), reload.WithReloaderName("config"))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}), reload.WithReloaderName("server"), reload.WithCircuitBreaker(1, time.Hour))
+
+	// Execute.
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	require.Error(err)
+	assert.Empty(m.Status().OpenCircuits)
+}
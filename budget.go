@@ -0,0 +1,87 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrReloadBudgetExceeded is returned when the reloader groups of a reload
+// don't finish before the reload budget (see WithReloadBudget).
+var ErrReloadBudgetExceeded = errors.New("reload budget exceeded")
+
+// reloadBudget is the time budget shared by the reloader groups of a reload
+// process.
+//
+// A nil reloadBudget is valid and doesn't limit the groups.
+type reloadBudget struct {
+	clock  Clock
+	budget time.Duration
+	end    time.Time
+}
+
+// newReloadBudget starts the reload budget, if the budget is not enabled it
+// returns nil.
+func (m *Manager) newReloadBudget(budget time.Duration) *reloadBudget {
+	if budget <= 0 {
+		return nil
+	}
+
+	return &reloadBudget{clock: m.cfg.clock, budget: budget, end: m.cfg.clock.Now().Add(budget)}
+}
+
+// groupContext returns the context of the next group with the remaining budget
+// as deadline, it fails if there is no budget left. The returned function ends
+// the group, wrapping the group error with ErrReloadBudgetExceeded when the
+// budget expired while running.
+func (b *reloadBudget) groupContext(ctx context.Context) (context.Context, func(error) error, error) {
+	if b == nil {
+		return ctx, func(err error) error { return err }, nil
+	}
+
+	remaining := b.end.Sub(b.clock.Now())
+	if remaining <= 0 {
+		return nil, nil, fmt.Errorf("%w (%s)", ErrReloadBudgetExceeded, b.budget)
+	}
+
+	groupCtx, cancel := context.WithCancelCause(ctx)
+	timer := b.clock.NewTimer(remaining)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case <-timer.C():
+			cancel(fmt.Errorf("%w (%s)", ErrReloadBudgetExceeded, b.budget))
+		}
+	}()
+
+	finish := func(err error) error {
+		close(done)
+		timer.Stop()
+		cancel(nil)
+
+		cause := context.Cause(groupCtx)
+		if err != nil && errors.Is(cause, ErrReloadBudgetExceeded) {
+			return fmt.Errorf("%w: %w", cause, err)
+		}
+		return err
+	}
+
+	return budgetContext{Context: groupCtx, deadline: b.end}, finish, nil
+}
+
+// budgetContext is a context with the reload budget end as deadline, the
+// cancellation is driven by the manager clock.
+type budgetContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c budgetContext) Deadline() (time.Time, bool) {
+	if d, ok := c.Context.Deadline(); ok && d.Before(c.deadline) {
+		return d, true
+	}
+
+	return c.deadline, true
+}
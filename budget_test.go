@@ -0,0 +1,94 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerReloadBudget(t *testing.T) {
+	tests := map[string]struct {
+		firstGroupTakes time.Duration
+		expDeadline     time.Duration
+		expErr          string
+		expReloaded     []string
+	}{
+		"The later groups should have the remaining budget as deadline.": {
+			firstGroupTakes: 40 * time.Second,
+			expDeadline:     20 * time.Second,
+			expReloaded:     []string{"first", "second"},
+		},
+
+		"The groups without budget left should not run.": {
+			firstGroupTakes: 90 * time.Second,
+			expErr:          `reload "t1" failed: group (priority 10): reload budget exceeded (1m0s)`,
+			expReloaded:     []string{"first"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Now())
+			m := reload.NewManager(reload.WithClock(clock), reload.WithReloadBudget(time.Minute))
+			var gotReloaded []string
+			var gotDeadline time.Duration
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotReloaded = append(gotReloaded, "first")
+				clock.Advance(test.firstGroupTakes)
+				return nil
+			}))
+			m.Add(10, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotReloaded = append(gotReloaded, "second")
+				deadline, _ := ctx.Deadline()
+				gotDeadline = deadline.Sub(clock.Now())
+				return nil
+			}))
+
+			// Execute.
+			err := m.TriggerReload(context.Background(), reload.TriggerEvent{ID: "t1"})
+
+			// Check.
+			if test.expErr != "" {
+				assert.ErrorIs(err, reload.ErrReloadBudgetExceeded)
+				assert.EqualError(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expReloaded, gotReloaded)
+			assert.Equal(test.expDeadline, gotDeadline)
+		})
+	}
+}
+
+func TestManagerReloadBudgetExpired(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Now())
+	m := reload.NewManager(reload.WithClock(clock), reload.WithReloadBudget(time.Minute))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), reload.WithReloaderName("slow"))
+
+	// Execute.
+	reloadErr := make(chan error)
+	go func() { reloadErr <- m.TriggerReload(context.Background(), reload.TriggerEvent{ID: "t1"}) }()
+	require.True(clock.WaitWaiters(1, time.Second))
+	clock.Advance(time.Minute)
+
+	// Check.
+	err := <-reloadErr
+	assert.ErrorIs(err, reload.ErrReloadBudgetExceeded)
+	assert.EqualError(err, `reload "t1" failed: group (priority 0): reload budget exceeded (1m0s): reloader "slow" (1/1): context canceled`)
+}
@@ -0,0 +1,68 @@
+package reload
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time of the time-based features (intervals, timeouts,
+// grace periods...), so they can be controlled on tests (e.g: `reloadtest.Clock`).
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer returns a timer that fires once after d.
+	NewTimer(d time.Duration) Timer
+	// NewTicker returns a ticker that fires every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a Clock timer, the same as `time.Timer`.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is a Clock ticker, the same as `time.Ticker`.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock is the Clock of the system time, used by default.
+var RealClock Clock = realClock{}
+
+// ClockFromContext returns the clock of the context (see ContextWithClock),
+// the manager sets its clock on the context received by the trigger
+// verifiers. By default RealClock.
+func ClockFromContext(ctx context.Context) Clock {
+	c, ok := ctx.Value(clockContextKey).(Clock)
+	if !ok {
+		return RealClock
+	}
+
+	return c
+}
+
+// ContextWithClock returns a context with the clock (e.g: to sign triggers with
+// a `reloadtest.Clock` on tests), see ClockFromContext.
+func ContextWithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey, c)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (r realTimer) C() <-chan time.Time { return r.t.C }
+func (r realTimer) Stop() bool          { return r.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
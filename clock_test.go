@@ -0,0 +1,49 @@
+package reload_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerClock(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	start := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	clock := reloadtest.NewClock(start)
+	var mu sync.Mutex
+	var finished []reload.Event
+	m := reload.NewManager(
+		reload.WithClock(clock),
+		reload.WithSubscriber(reload.SubscriberFunc(func(_ context.Context, e reload.Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			if e.Type == reload.EventReloadFinished {
+				finished = append(finished, e)
+			}
+		})),
+	)
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		clock.Advance(2 * time.Second)
+		return nil
+	}))
+
+	// Execute.
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"}))
+
+	// Check.
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(finished, 1)
+	assert.Equal(start.Add(2*time.Second), finished[0].Time)
+	assert.Equal(2*time.Second, finished[0].Duration)
+}
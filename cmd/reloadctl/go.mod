@@ -0,0 +1,27 @@
+module github.com/slok/reload/cmd/reloadctl
+
+go 1.23
+
+require (
+	github.com/slok/reload v0.0.0
+	github.com/slok/reload/reloadgrpc v0.0.0
+	github.com/stretchr/testify v1.9.0
+	google.golang.org/grpc v1.66.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.25.0 // indirect
+	golang.org/x/text v0.18.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240604185151-ef581f913117 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	github.com/slok/reload => ../../
+	github.com/slok/reload/reloadgrpc => ../../reloadgrpc
+)
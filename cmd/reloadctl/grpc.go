@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/slok/reload/reloadgrpc"
+)
+
+// grpcClient is an admin service gRPC client, the admin service only streams
+// the lifecycle events (see reloadgrpc.AdminServer).
+type grpcClient struct {
+	conn  *grpc.ClientConn
+	token string
+}
+
+func newGRPCClient(target, token string, secure bool) (client, error) {
+	creds := insecure.NewCredentials()
+	if secure {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+
+	return grpcClient{conn: conn, token: token}, nil
+}
+
+func (c grpcClient) do(_ context.Context, _, _ string, _, _ any) ([]byte, error) {
+	return nil, fmt.Errorf("not supported by the gRPC admin service, only the events can be watched")
+}
+
+func (c grpcClient) watch(ctx context.Context, types []string, f func(raw []byte) error) error {
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+	}
+
+	stream, err := reloadgrpc.WatchEvents(ctx, c.conn)
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		// The admin service doesn't filter the events.
+		if len(types) > 0 {
+			var e struct {
+				Type string `json:"type"`
+			}
+			err := json.Unmarshal(raw, &e)
+			if err != nil {
+				return fmt.Errorf("could not decode event: %w", err)
+			}
+			if !slices.Contains(types, e.Type) {
+				continue
+			}
+		}
+
+		// The protobuf JSON output is not stable.
+		var b bytes.Buffer
+		err = json.Compact(&b, raw)
+		if err != nil {
+			return fmt.Errorf("could not decode event: %w", err)
+		}
+
+		err = f(b.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c grpcClient) close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,698 @@
+// reloadctl is a command-line tool to trigger and inspect the reloads of an
+// application using the `reloadhttp` admin handler, over HTTP or a unix socket,
+// or to watch its lifecycle events using the `reloadgrpc` admin service over
+// gRPC (the other commands are not served by the gRPC admin service).
+//
+// Usage:
+//
+//	reloadctl [flags] <command> [command flags]
+//
+// Commands:
+//
+//	trigger                Triggers a reload (e.g: `reloadctl trigger --reason deploy-123`).
+//	validate               Validates the configuration without reloading (e.g: `reloadctl validate`).
+//	rollback               Rolls back to a previous generation (e.g: `reloadctl rollback 42`).
+//	reset-circuit-breaker  Closes the circuit breaker of a reloader (e.g: `reloadctl reset-circuit-breaker config`).
+//	enable-reloader        Enables a disabled reloader (e.g: `reloadctl enable-reloader config`).
+//	disable-reloader       Disables a reloader, it's skipped on the reloads (e.g: `reloadctl disable-reloader config`).
+//	approve                Approves the reload pending approval with its token (e.g: `reloadctl approve 9f86d0`).
+//	reject                 Rejects the reload pending approval with its token (e.g: `reloadctl reject 9f86d0`).
+//	status                 Shows the current, the pending approval and the last reload.
+//	history                Shows the last reloads.
+//	watch                  Streams the lifecycle events (e.g: `reloadctl --addr grpc://127.0.0.1:9090 watch`).
+//
+// The address and the token can be set with `RELOADCTL_ADDR` and
+// `RELOADCTL_TOKEN` env vars.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/slok/reload/reloadhttp"
+)
+
+const usage = `Usage: reloadctl [flags] <command> [command flags]
+
+Commands:
+  trigger                Triggers a reload.
+  validate               Validates the configuration without reloading.
+  rollback               Rolls back to a previous generation.
+  reset-circuit-breaker  Closes the circuit breaker of a reloader.
+  enable-reloader        Enables a disabled reloader.
+  disable-reloader       Disables a reloader, it's skipped on the reloads.
+  approve                Approves the reload pending approval with its token.
+  reject                 Rejects the reload pending approval with its token.
+  status                 Shows the current, the pending approval and the last reload.
+  history                Shows the last reloads.
+  watch                  Streams the lifecycle events.
+
+Flags:
+`
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("reloadctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", envOr("RELOADCTL_ADDR", "http://127.0.0.1:8081"), "Admin handler address, `http(s)://host:port/path` or `unix:///path/to/socket`, or admin service address `grpc(s)://host:port`.")
+	token := fs.String("token", os.Getenv("RELOADCTL_TOKEN"), "Admin handler bearer token.")
+	timeout := fs.Duration("timeout", 10*time.Second, "Request timeout.")
+	jsonOut := fs.Bool("json", false, "Print the raw JSON responses.")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("command is required")
+	}
+
+	c, err := newClient(*addr, *token, *timeout)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	cmd, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "trigger":
+		return runTrigger(ctx, c, cmdArgs, stdout, stderr, *jsonOut)
+	case "validate":
+		return runValidate(ctx, c, cmdArgs, stdout, stderr, *jsonOut)
+	case "rollback":
+		return runRollback(ctx, c, cmdArgs, stdout, stderr, *jsonOut)
+	case "reset-circuit-breaker":
+		return runResetCircuitBreaker(ctx, c, cmdArgs, stdout, stderr, *jsonOut)
+	case "enable-reloader":
+		return runToggleReloader(ctx, c, "enable", cmdArgs, stdout, stderr, *jsonOut)
+	case "disable-reloader":
+		return runToggleReloader(ctx, c, "disable", cmdArgs, stdout, stderr, *jsonOut)
+	case "approve":
+		return runApproval(ctx, c, "approve", cmdArgs, stdout, stderr, *jsonOut)
+	case "reject":
+		return runApproval(ctx, c, "reject", cmdArgs, stdout, stderr, *jsonOut)
+	case "status":
+		return runStatus(ctx, c, cmdArgs, stdout, stderr, *jsonOut)
+	case "history":
+		return runHistory(ctx, c, cmdArgs, stdout, stderr, *jsonOut)
+	case "watch":
+		return runWatch(ctx, c, cmdArgs, stdout, stderr, *jsonOut)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown %q command", cmd)
+	}
+}
+
+func runTrigger(ctx context.Context, c client, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet("trigger", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	id := fs.String("id", "", "Trigger ID, by default a random one.")
+	reason := fs.String("reason", "", "Reason of the reload (e.g: deploy-123).")
+	keys := fs.String("keys", "", "Comma separated configuration keys that changed.")
+	tags := fs.String("tags", "", "Tag `expression` that selects the reloaders (e.g: tls && !expensive).")
+	var metadata metadataFlag
+	fs.Var(&metadata, "metadata", "Trigger metadata as `key=value`, can be repeated.")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	req := reloadhttp.AdminTriggerRequest{ID: *id, Reason: *reason, Metadata: metadata, Tags: *tags}
+	if *keys != "" {
+		req.Keys = strings.Split(*keys, ",")
+	}
+
+	var resp reloadhttp.AdminTriggerResponse
+	raw, err := c.do(ctx, http.MethodPost, "/trigger", req, &resp)
+	var respErr *responseError
+	if errors.As(err, &respErr) && respErr.status == http.StatusConflict && json.Unmarshal(respErr.body, &resp) == nil && resp.InProgressStartedAt != nil {
+		return fmt.Errorf("could not trigger reload: reload in progress: trigger %q started at %s", resp.InProgressTriggerID, resp.InProgressStartedAt.Format(time.RFC3339))
+	}
+	if err != nil {
+		return fmt.Errorf("could not trigger reload: %w", err)
+	}
+
+	if jsonOut {
+		_, err := stdout.Write(raw)
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Reload triggered: %s\n", resp.ID)
+
+	return nil
+}
+
+func runValidate(ctx context.Context, c client, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	id := fs.String("id", "", "Trigger ID, by default a random one.")
+	keys := fs.String("keys", "", "Comma separated configuration keys that changed.")
+	tags := fs.String("tags", "", "Tag `expression` that selects the reloaders (e.g: tls && !expensive).")
+	var metadata metadataFlag
+	fs.Var(&metadata, "metadata", "Trigger metadata as `key=value`, can be repeated.")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	req := reloadhttp.AdminTriggerRequest{ID: *id, Metadata: metadata, Tags: *tags}
+	if *keys != "" {
+		req.Keys = strings.Split(*keys, ",")
+	}
+
+	var resp reloadhttp.AdminValidateResponse
+	raw, err := c.do(ctx, http.MethodPost, "/validate", req, &resp)
+	var respErr *responseError
+	if errors.As(err, &respErr) && respErr.status == http.StatusUnprocessableEntity && json.Unmarshal(respErr.body, &resp) == nil {
+		raw, err = respErr.body, nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not validate: %w", err)
+	}
+
+	if jsonOut {
+		_, err := stdout.Write(raw)
+		if err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RELOADER\tPRIORITY\tSTATUS\tERROR")
+		for _, r := range resp.Reloaders {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", r.Name, r.Priority, r.Status, orDash(r.Error))
+		}
+		err := w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	if !resp.Valid {
+		return fmt.Errorf("invalid configuration: %s", resp.Error)
+	}
+
+	return nil
+}
+
+func runRollback(ctx context.Context, c client, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("generation is required")
+	}
+	generation, err := strconv.ParseUint(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid generation: %w", err)
+	}
+
+	var resp reloadhttp.AdminTriggerResponse
+	raw, err := c.do(ctx, http.MethodPost, "/rollback", reloadhttp.AdminRollbackRequest{Generation: generation}, &resp)
+	var respErr *responseError
+	if errors.As(err, &respErr) && respErr.status == http.StatusConflict && json.Unmarshal(respErr.body, &resp) == nil && resp.InProgressStartedAt != nil {
+		return fmt.Errorf("could not roll back: reload in progress: trigger %q started at %s", resp.InProgressTriggerID, resp.InProgressStartedAt.Format(time.RFC3339))
+	}
+	if err != nil {
+		return fmt.Errorf("could not roll back: %w", err)
+	}
+
+	if jsonOut {
+		_, err := stdout.Write(raw)
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Rolled back to generation %d: %s\n", generation, resp.ID)
+
+	return nil
+}
+
+func runResetCircuitBreaker(ctx context.Context, c client, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet("reset-circuit-breaker", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("reloader is required")
+	}
+
+	var resp reloadhttp.AdminResetCircuitBreakerResponse
+	raw, err := c.do(ctx, http.MethodPost, "/circuit-breaker/reset", reloadhttp.AdminResetCircuitBreakerRequest{Reloader: fs.Arg(0)}, &resp)
+	if err != nil {
+		return fmt.Errorf("could not reset circuit breaker: %w", err)
+	}
+
+	if jsonOut {
+		_, err := stdout.Write(raw)
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Circuit breaker reset: %s\n", resp.Reloader)
+
+	return nil
+}
+
+func runToggleReloader(ctx context.Context, c client, action string, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet(action+"-reloader", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("reloader is required")
+	}
+
+	var resp reloadhttp.AdminReloaderToggleResponse
+	raw, err := c.do(ctx, http.MethodPost, "/reloaders/"+action, reloadhttp.AdminReloaderToggleRequest{Reloader: fs.Arg(0)}, &resp)
+	if err != nil {
+		return fmt.Errorf("could not %s reloader: %w", action, err)
+	}
+
+	if jsonOut {
+		_, err := stdout.Write(raw)
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Reloader %sd: %s\n", action, resp.Reloader)
+
+	return nil
+}
+
+func runApproval(ctx context.Context, c client, decision string, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet(decision, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("approval token is required")
+	}
+
+	var resp reloadhttp.AdminApprovalResponse
+	raw, err := c.do(ctx, http.MethodPost, "/"+decision, reloadhttp.AdminApprovalRequest{Token: fs.Arg(0)}, &resp)
+	if err != nil {
+		return fmt.Errorf("could not %s: %w", decision, err)
+	}
+
+	if jsonOut {
+		_, err := stdout.Write(raw)
+		return err
+	}
+
+	if decision == "approve" {
+		fmt.Fprintf(stdout, "Reload approved: %s\n", resp.Token)
+	} else {
+		fmt.Fprintf(stdout, "Reload rejected: %s\n", resp.Token)
+	}
+
+	return nil
+}
+
+func runStatus(ctx context.Context, c client, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var status reloadhttp.AdminStatus
+	raw, err := c.do(ctx, http.MethodGet, "/status", nil, &status)
+	if err != nil {
+		return fmt.Errorf("could not get status: %w", err)
+	}
+
+	if jsonOut {
+		_, err := stdout.Write(raw)
+		return err
+	}
+
+	// The reloads of different pipelines can be in progress concurrently.
+	reloads := status.Reloads
+	if len(reloads) == 0 && status.Current != nil {
+		reloads = []reloadhttp.AdminReload{*status.Current}
+	}
+	for _, r := range reloads {
+		fmt.Fprintf(stdout, "In progress: %s (started %s)\n", describe(r), r.StartedAt.Format(time.RFC3339))
+	}
+	if len(reloads) == 0 {
+		fmt.Fprintln(stdout, "In progress: none")
+	}
+
+	if p := status.PendingApproval; p != nil {
+		fmt.Fprintf(stdout, "Pending approval: %s from %s (token %s, since %s)\n", p.TriggerID, p.TriggerSource, p.Token, p.Since.Format(time.RFC3339))
+	}
+
+	if status.Last != nil {
+		fmt.Fprintf(stdout, "Last reload: %s (finished %s in %s): %s\n", describe(*status.Last), status.Last.FinishedAt.Format(time.RFC3339), duration(*status.Last), result(*status.Last))
+	} else {
+		fmt.Fprintln(stdout, "Last reload: none")
+	}
+
+	return nil
+}
+
+func runHistory(ctx context.Context, c client, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	limit := fs.Int("limit", 10, "Maximum number of reloads.")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var history []reloadhttp.AdminReload
+	raw, err := c.do(ctx, http.MethodGet, "/history?limit="+strconv.Itoa(*limit), nil, &history)
+	if err != nil {
+		return fmt.Errorf("could not get history: %w", err)
+	}
+
+	if jsonOut {
+		_, err := stdout.Write(raw)
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FINISHED\tTRIGGER\tSOURCE\tREASON\tREQUESTER\tDURATION\tRESULT")
+	for _, r := range history {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.FinishedAt.Format(time.RFC3339), r.TriggerID, orDash(r.TriggerSource), orDash(r.Reason), orDash(r.Requester), duration(r), result(r))
+	}
+
+	return w.Flush()
+}
+
+func runWatch(ctx context.Context, c client, args []string, stdout, stderr io.Writer, jsonOut bool) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	types := fs.String("types", "", "Comma separated event types (e.g: reload_started,reload_finished), by default all.")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var filter []string
+	if *types != "" {
+		filter = strings.Split(*types, ",")
+	}
+
+	err = c.watch(ctx, filter, func(raw []byte) error {
+		if jsonOut {
+			_, err := fmt.Fprintf(stdout, "%s\n", raw)
+			return err
+		}
+
+		var e watchEvent
+		err := json.Unmarshal(raw, &e)
+		if err != nil {
+			return fmt.Errorf("could not decode event: %w", err)
+		}
+		_, err = fmt.Fprintln(stdout, e.String())
+		return err
+	})
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("could not watch events: %w", err)
+	}
+
+	return nil
+}
+
+// watchEvent is the lifecycle event JSON (see reload.MarshalEventJSON).
+type watchEvent struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	TriggerID string    `json:"trigger_id"`
+	Reloader  string    `json:"reloader"`
+	Notifier  string    `json:"notifier"`
+	Error     string    `json:"error"`
+}
+
+func (w watchEvent) String() string {
+	s := w.Time.Format(time.RFC3339) + " " + w.Type
+	for _, v := range []string{w.TriggerID, w.Reloader, w.Notifier} {
+		if v != "" {
+			s += " " + v
+		}
+	}
+	if w.Error != "" {
+		s += ": failed: " + w.Error
+	}
+
+	return s
+}
+
+// client is an admin client.
+type client interface {
+	// do makes the request and decodes the response, it returns the raw
+	// response.
+	do(ctx context.Context, method, path string, in, out any) ([]byte, error)
+	// watch calls f with the JSON of every lifecycle event of the types (all
+	// if empty) until the context is cancelled or the stream ends.
+	watch(ctx context.Context, types []string, f func(raw []byte) error) error
+	close() error
+}
+
+func newClient(addr, token string, timeout time.Duration) (client, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+
+	c := httpClient{token: token, http: &http.Client{Timeout: timeout}}
+	switch u.Scheme {
+	case "http", "https":
+		c.baseURL = strings.TrimSuffix(addr, "/")
+	case "unix":
+		socket := u.Path
+		c.baseURL = "http://unix"
+		c.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+	case "grpc", "grpcs":
+		return newGRPCClient(u.Host, token, u.Scheme == "grpcs")
+	default:
+		return nil, fmt.Errorf("invalid address: unsupported %q scheme", u.Scheme)
+	}
+
+	return c, nil
+}
+
+// httpClient is an admin handler HTTP client.
+type httpClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// do makes the request and decodes the response, it returns the raw response.
+func (c httpClient) do(ctx context.Context, method, path string, in, out any) ([]byte, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &responseError{status: resp.StatusCode, body: raw}
+	}
+
+	err = json.Unmarshal(raw, out)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return raw, nil
+}
+
+func (c httpClient) watch(ctx context.Context, types []string, f func(raw []byte) error) error {
+	path := "/events"
+	if len(types) > 0 {
+		path += "?types=" + url.QueryEscape(strings.Join(types, ","))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	c.authorize(req)
+
+	// The stream doesn't end, only the request timeout is not applied.
+	hc := *c.http
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		raw, _ := io.ReadAll(resp.Body)
+		return &responseError{status: resp.StatusCode, body: raw}
+	}
+
+	// Server-sent events, only the data of the events is required.
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		err := f([]byte(data))
+		if err != nil {
+			return err
+		}
+	}
+	err = sc.Err()
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("events stream closed by the server")
+}
+
+func (c httpClient) close() error {
+	c.http.CloseIdleConnections()
+	return nil
+}
+
+func (c httpClient) authorize(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+// responseError is the error of the non successful responses.
+type responseError struct {
+	status int
+	body   []byte
+}
+
+func (r *responseError) Error() string {
+	return fmt.Sprintf("unexpected %d status code: %s", r.status, strings.TrimSpace(string(r.body)))
+}
+
+type metadataFlag map[string]string
+
+func (m *metadataFlag) String() string { return fmt.Sprint(map[string]string(*m)) }
+
+func (m *metadataFlag) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("invalid %q metadata, must be key=value", v)
+	}
+	if *m == nil {
+		*m = map[string]string{}
+	}
+	(*m)[k] = val
+
+	return nil
+}
+
+func describe(r reloadhttp.AdminReload) string {
+	s := r.TriggerID
+	if r.TriggerSource != "" {
+		s += " from " + r.TriggerSource
+	}
+	if r.Reason != "" {
+		s += " (" + r.Reason + ")"
+	}
+	if r.Requester != "" {
+		s += " by " + r.Requester
+	}
+
+	return s
+}
+
+func duration(r reloadhttp.AdminReload) time.Duration {
+	return time.Duration(r.DurationSeconds * float64(time.Second)).Round(time.Millisecond)
+}
+
+func result(r reloadhttp.AdminReload) string {
+	if r.Error != "" {
+		return "failed: " + r.Error
+	}
+
+	return "ok"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return def
+}
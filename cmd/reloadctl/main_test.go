@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadgrpc"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func TestRun(t *testing.T) {
+	at := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	t1 := reload.TriggerEvent{ID: "t1", Source: "admin", Metadata: map[string]string{"reason": "deploy-123", "requester": "alice"}}
+	t2 := reload.TriggerEvent{ID: "t2", Source: "file"}
+	events := []reload.Event{
+		{Type: reload.EventReloadFinished, Trigger: t1, Time: at, Duration: 1500 * time.Millisecond},
+		{Type: reload.EventReloadFinished, Trigger: t2, Time: at.Add(time.Minute), Duration: time.Second, Err: fmt.Errorf("something")},
+	}
+
+	tests := map[string]struct {
+		args   []string
+		expOut string
+		expErr bool
+	}{
+		"Status should show the last reload.": {
+			args: []string{"status"},
+			expOut: "In progress: none\n" +
+				"Last reload: t2 from file (finished 2021-07-19T10:01:00Z in 1s): failed: something\n",
+		},
+
+		"History should show the last reloads.": {
+			args: []string{"history"},
+			expOut: "FINISHED              TRIGGER  SOURCE  REASON      REQUESTER  DURATION  RESULT\n" +
+				"2021-07-19T10:01:00Z  t2       file    -           -          1s        failed: something\n" +
+				"2021-07-19T10:00:00Z  t1       admin   deploy-123  alice      1.5s      ok\n",
+		},
+
+		"History should be limited.": {
+			args:   []string{"--json", "history", "--limit", "1"},
+			expOut: `[{"trigger_id":"t2","trigger_source":"file","started_at":"2021-07-19T10:00:59Z","finished_at":"2021-07-19T10:01:00Z","duration_seconds":1,"error":"something"}]` + "\n",
+		},
+
+		"Trigger should trigger a reload.": {
+			args:   []string{"trigger", "--id", "t3", "--reason", "deploy-123"},
+			expOut: "Reload triggered: t3\n",
+		},
+
+		"Validate should show the reloaders validation.": {
+			args: []string{"validate", "--id", "t3"},
+			expOut: "RELOADER  PRIORITY  STATUS     ERROR\n" +
+				"config    0         succeeded  -\n" +
+				"cache     1         skipped    -\n",
+		},
+
+		"Validate of an invalid configuration should fail.": {
+			args:   []string{"validate", "--id", "invalid"},
+			expErr: true,
+		},
+
+		"Reset circuit breaker should reset the reloader circuit breaker.": {
+			args:   []string{"reset-circuit-breaker", "config"},
+			expOut: "Circuit breaker reset: config\n",
+		},
+
+		"Reset circuit breaker of an unknown reloader should fail.": {
+			args:   []string{"reset-circuit-breaker", "nope"},
+			expErr: true,
+		},
+
+		"Disable reloader should disable the reloader.": {
+			args:   []string{"disable-reloader", "config"},
+			expOut: "Reloader disabled: config\n",
+		},
+
+		"Enable reloader should enable the reloader.": {
+			args:   []string{"enable-reloader", "config"},
+			expOut: "Reloader enabled: config\n",
+		},
+
+		"Disable reloader of an unknown reloader should fail.": {
+			args:   []string{"disable-reloader", "nope"},
+			expErr: true,
+		},
+
+		"Approve should approve the pending reload.": {
+			args:   []string{"approve", "tk3"},
+			expOut: "Reload approved: tk3\n",
+		},
+
+		"Reject should reject the pending reload.": {
+			args:   []string{"reject", "tk3"},
+			expOut: "Reload rejected: tk3\n",
+		},
+
+		"Approve an unknown approval token should fail.": {
+			args:   []string{"approve", "nope"},
+			expErr: true,
+		},
+
+		"Invalid token should fail.": {
+			args:   []string{"--token", "other", "status"},
+			expErr: true,
+		},
+
+		"Unknown commands should fail.": {
+			args:   []string{"nope"},
+			expErr: true,
+		},
+
+		"Missing command should fail.": {
+			args:   []string{},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{
+				Token: "secret",
+				ResetCircuitBreaker: func(ctx context.Context, reloader string) error {
+					if reloader != "config" {
+						return reload.ErrUnknownCircuitBreaker
+					}
+					return nil
+				},
+				EnableReloader:  toggleReloader,
+				DisableReloader: toggleReloader,
+				Approve:         pendingApproval,
+				Reject:          pendingApproval,
+				Validate:        validate,
+			})
+			require.NoError(err)
+			for _, e := range events {
+				h.HandleEvent(context.TODO(), e)
+			}
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+
+			// Execute.
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"--addr", srv.URL, "--token", "secret"}, test.args...)
+			err = run(context.TODO(), args, &stdout, &stderr)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expOut, stdout.String())
+			}
+		})
+	}
+}
+
+func toggleReloader(ctx context.Context, reloader string) error {
+	if reloader != "config" {
+		return reload.ErrUnknownReloader
+	}
+	return nil
+}
+
+func pendingApproval(ctx context.Context, token string) error {
+	if token != "tk3" {
+		return reload.ErrUnknownApproval
+	}
+	return nil
+}
+
+func validate(ctx context.Context, t reload.TriggerEvent) ([]reload.ReloaderReport, error) {
+	reports := []reload.ReloaderReport{
+		{Name: "config", Priority: 0, Status: reload.ReloaderSucceeded},
+		{Name: "cache", Priority: 1, Status: reload.ReloaderSkipped},
+	}
+	if t.ID == "invalid" {
+		reports[0].Status = reload.ReloaderFailed
+		reports[0].Err = fmt.Errorf("something")
+		return reports, reload.ErrValidationFailed
+	}
+	return reports, nil
+}
+
+func TestRunUnixSocket(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{})
+	require.NoError(err)
+	socket := filepath.Join(t.TempDir(), "admin.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(err)
+	srv := &http.Server{Handler: h}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	// Execute.
+	var stdout, stderr bytes.Buffer
+	err = run(context.TODO(), []string{"--addr", "unix://" + socket, "trigger", "--id", "t1", "--keys", "a,b", "--metadata", "user=ops"}, &stdout, &stderr)
+
+	// Check.
+	require.NoError(err)
+	assert.Equal("Reload triggered: t1\n", stdout.String())
+	got, err := h.NotifyTrigger(context.TODO())
+	require.NoError(err)
+	assert.Equal(reload.TriggerEvent{ID: "t1", Keys: []string{"a", "b"}, Metadata: map[string]string{"user": "ops"}}, got)
+}
+
+func TestRunTriggerInProgress(t *testing.T) {
+	h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{
+		Trigger: func(ctx context.Context, t reload.TriggerEvent) error {
+			return &reload.ReloadInProgressError{TriggerID: "t0", StartedAt: time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)}
+		},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	err = run(context.TODO(), []string{"--addr", srv.URL, "trigger"}, &stdout, &stderr)
+	assert.EqualError(t, err, `could not trigger reload: reload in progress: trigger "t0" started at 2021-07-19T10:00:00Z`)
+}
+
+func TestRunWatch(t *testing.T) {
+	at := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	started := reload.Event{Type: reload.EventReloadStarted, Trigger: reload.TriggerEvent{ID: "t1"}, Time: at}
+	finished := reload.Event{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "t1"}, Time: at, Err: fmt.Errorf("something")}
+
+	tests := map[string]struct {
+		server func(t *testing.T) (addr string, s reload.Subscriber)
+		args   []string
+		expOut string
+		expErr bool
+	}{
+		"Watch over HTTP should stream the events.": {
+			server: newTestHTTPAdmin,
+			args:   []string{"watch", "--types", "reload_finished"},
+			expOut: "2021-07-19T10:00:00Z reload_finished t1: failed: something\n",
+		},
+
+		"Watch over gRPC should stream the events.": {
+			server: newTestGRPCAdmin,
+			args:   []string{"watch", "--types", "reload_finished"},
+			expOut: "2021-07-19T10:00:00Z reload_finished t1: failed: something\n",
+		},
+
+		"Watch over gRPC should stream the JSON events.": {
+			server: newTestGRPCAdmin,
+			args:   []string{"--json", "watch", "--types", "reload_started"},
+			expOut: `{"time":"2021-07-19T10:00:00Z","trigger_id":"t1","trigger_source":"","type":"reload_started"}` + "\n",
+		},
+
+		"Watch over gRPC with an invalid token should fail.": {
+			server: newTestGRPCAdmin,
+			args:   []string{"--token", "other", "watch"},
+			expErr: true,
+		},
+
+		"Other commands over gRPC should fail.": {
+			server: newTestGRPCAdmin,
+			args:   []string{"status"},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			addr, s := test.server(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The events are only streamed to the watchers, so keep emitting
+			// them until the watch starts.
+			go func() {
+				for ctx.Err() == nil {
+					s.HandleEvent(ctx, started)
+					s.HandleEvent(ctx, finished)
+					time.Sleep(10 * time.Millisecond)
+				}
+			}()
+
+			// Execute.
+			pr, pw := io.Pipe()
+			var stderr bytes.Buffer
+			errC := make(chan error, 1)
+			go func() {
+				args := append([]string{"--addr", addr}, test.args...)
+				if test.args[0] != "--token" {
+					args = append([]string{"--token", "secret"}, args...)
+				}
+				err := run(ctx, args, pw, &stderr)
+				_ = pw.CloseWithError(err)
+				errC <- err
+			}()
+
+			// Check.
+			if test.expErr {
+				assert.Error(<-errC)
+				return
+			}
+			line, err := bufio.NewReader(pr).ReadString('\n')
+			require.NoError(err)
+			assert.Equal(test.expOut, line)
+			cancel()
+			_ = pr.Close()
+			<-errC
+		})
+	}
+}
+
+func newTestHTTPAdmin(t *testing.T) (string, reload.Subscriber) {
+	h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{Token: "secret"})
+	require.NoError(t, err)
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	return srv.URL, h
+}
+
+func newTestGRPCAdmin(t *testing.T) (string, reload.Subscriber) {
+	a, err := reloadgrpc.NewAdminServer(reloadgrpc.AdminServerConfig{Token: "secret"})
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	reloadgrpc.RegisterAdminServer(s, a)
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	return "grpc://" + l.Addr().String(), a
+}
@@ -0,0 +1,269 @@
+package reload
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventType is the type of a manager lifecycle event.
+type EventType string
+
+const (
+	// EventTriggerReceived is emitted when a notifier triggers a reload.
+	EventTriggerReceived EventType = "trigger_received"
+	// EventTriggerDropped is emitted when a notifier trigger is dropped
+	// because the trigger queue is full (see WithTriggerQueue), it failed the
+	// verification (see WithNotifierVerifier), it exceeded the notifier rate
+	// limit (see WithNotifierRateLimit) or it has been filtered (see
+	// WithTriggerFilter), with the reason as the error.
+	EventTriggerDropped EventType = "trigger_dropped"
+	// EventReloadStarted is emitted when the reload process starts.
+	EventReloadStarted EventType = "reload_started"
+	// EventReloadDeferred is emitted when a trigger waits for the reload window
+	// to open (see WithReloadWindow).
+	EventReloadDeferred EventType = "reload_deferred"
+	// EventApprovalPending is emitted when a trigger starts waiting for the
+	// approval (see WithApproval).
+	EventApprovalPending EventType = "approval_pending"
+	// EventApprovalApproved is emitted when the reload of a trigger pending
+	// approval is approved.
+	EventApprovalApproved EventType = "approval_approved"
+	// EventApprovalRejected is emitted when the reload of a trigger pending
+	// approval is rejected or the approval expires, with the reason as the
+	// error.
+	EventApprovalRejected EventType = "approval_rejected"
+	// EventReloadSkipped is emitted when the reload process is not executed.
+	EventReloadSkipped EventType = "reload_skipped"
+	// EventReloadFinished is emitted when the reload process ends, with or without error.
+	// The errors of the failed reloads are ReloadError.
+	EventReloadFinished EventType = "reload_finished"
+	// EventGroupStarted is emitted when a reloader priority group starts its reload.
+	EventGroupStarted EventType = "group_started"
+	// EventGroupFinished is emitted when a reloader priority group ends its reload, with or without error.
+	EventGroupFinished EventType = "group_finished"
+	// EventReloaderFinished is emitted when a reloader ends its reload, with or without error.
+	EventReloaderFinished EventType = "reloader_finished"
+	// EventReloaderSkipped is emitted when a reloader is not reloaded because
+	// its circuit breaker is open or it's disabled.
+	EventReloaderSkipped EventType = "reloader_skipped"
+	// EventNotifierQuarantined is emitted when a notifier breaker quarantines
+	// the notifier, with the reason as the error.
+	EventNotifierQuarantined EventType = "notifier_quarantined"
+	// EventNotifierReleased is emitted when a notifier quarantine ends.
+	EventNotifierReleased EventType = "notifier_released"
+	// EventNotifierStale is emitted when a notifier doesn't trigger or
+	// heartbeat within the liveness period (see WithNotifierLiveness), with
+	// the reason as the error.
+	EventNotifierStale EventType = "notifier_stale"
+	// EventNotifierRestarted is emitted when a stale notifier is restarted.
+	EventNotifierRestarted EventType = "notifier_restarted"
+	// EventNotifierAlive is emitted when a stale notifier triggers or
+	// heartbeats again.
+	EventNotifierAlive EventType = "notifier_alive"
+	// EventAbandonedReloaderReturned is emitted when a reloader that exceeded
+	// its timeout returns, with the total duration and its error.
+	EventAbandonedReloaderReturned EventType = "abandoned_reloader_returned"
+	// EventReloadRetrying is emitted when a failed reload is going to be
+	// retried (see WithReloadRetry), with the attempt number of the retry, the
+	// backoff as the duration and the failed attempt error.
+	EventReloadRetrying EventType = "reload_retrying"
+)
+
+// Event is a manager lifecycle event.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+	// Time is when the event happened.
+	Time time.Time
+	// Trigger is the trigger that started the reload process.
+	Trigger TriggerEvent
+	// Priority is the priority of the reloader group, only on group and
+	// reloader events.
+	Priority int
+	// Reloader is the name of the reloader, only on reloader events.
+	Reloader string
+	// Notifier is the name of the notifier, only on notifier events.
+	Notifier string
+	// Reloaders are the names of the group reloaders that will be reloaded,
+	// only on group started events.
+	Reloaders []string
+	// Duration is the duration of the process, only on finished events.
+	Duration time.Duration
+	// Err is the error of the process, only on finished events. On skipped
+	// events it's the reason the reload was skipped (e.g: a reload in progress
+	// or a rejected approval), nil when the trigger was coalesced into other.
+	Err error
+	// Attempt is the attempt number of the reload, only on retrying events.
+	Attempt int
+	// ApprovalToken is the token of the approval request, only on approval
+	// events (see Manager.Approve).
+	ApprovalToken string
+	// Pipelines are the pipelines of the reload (see WithPipeline), only on
+	// reload started, finished and skipped events. Empty when the reload only
+	// has reloaders of the default pipeline.
+	Pipelines []string
+	// Retrying is true when the failed reload will be retried (see
+	// WithReloadRetry), only on reload finished events.
+	Retrying bool
+}
+
+// Subscriber knows how to handle the manager lifecycle events.
+//
+// Subscribers are called synchronously by the manager, so they should be fast
+// and safe for concurrent use.
+type Subscriber interface {
+	HandleEvent(ctx context.Context, e Event)
+}
+
+// SubscriberFunc is a helper to create subscribers from functions.
+type SubscriberFunc func(ctx context.Context, e Event)
+
+// HandleEvent satisfies Subscriber interface.
+func (s SubscriberFunc) HandleEvent(ctx context.Context, e Event) { s(ctx, e) }
+
+// Subscribe adds a subscriber that will receive the manager lifecycle events
+// once the manager has been created (e.g: to stream the events to a client),
+// the returned function removes it. The subscribers added with WithSubscriber
+// receive the events first.
+func (m *Manager) Subscribe(s Subscriber) (unsubscribe func()) {
+	return m.subscriptions.add(s)
+}
+
+// subscriptions are the subscribers added once the manager has been created.
+type subscriptions struct {
+	mu   sync.RWMutex
+	next int
+	subs map[int]Subscriber
+}
+
+func (s *subscriptions) add(sub Subscriber) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subs == nil {
+		s.subs = map[int]Subscriber{}
+	}
+	id := s.next
+	s.next++
+	s.subs[id] = sub
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.subs, id)
+		})
+	}
+}
+
+// list returns the subscribers in subscription order.
+func (s *subscriptions) list() []Subscriber {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]int, 0, len(s.subs))
+	for id := range s.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	subs := make([]Subscriber, 0, len(ids))
+	for _, id := range ids {
+		subs = append(subs, s.subs[id])
+	}
+
+	return subs
+}
+
+type jsonEvent struct {
+	Type               EventType         `json:"type"`
+	Time               time.Time         `json:"time"`
+	TriggerID          string            `json:"trigger_id"`
+	TriggerSource      string            `json:"trigger_source"`
+	TriggerReason      string            `json:"trigger_reason,omitempty"`
+	TriggerPaths       []string          `json:"trigger_paths,omitempty"`
+	TriggerKeys        []string          `json:"trigger_keys,omitempty"`
+	TriggerMetadata    map[string]string `json:"trigger_metadata,omitempty"`
+	TriggerUrgency     TriggerUrgency    `json:"trigger_urgency,omitempty"`
+	TriggerTagSelector string            `json:"trigger_tag_selector,omitempty"`
+	Priority           *int              `json:"priority,omitempty"`
+	Reloader           string            `json:"reloader,omitempty"`
+	Notifier           string            `json:"notifier,omitempty"`
+	Reloaders          []string          `json:"reloaders,omitempty"`
+	DurationSeconds    *float64          `json:"duration_seconds,omitempty"`
+	Error              string            `json:"error,omitempty"`
+	Attempt            int               `json:"attempt,omitempty"`
+	ApprovalToken      string            `json:"approval_token,omitempty"`
+	Pipelines          []string          `json:"pipelines,omitempty"`
+	Retrying           bool              `json:"retrying,omitempty"`
+}
+
+func newJSONEvent(e Event) jsonEvent {
+	je := jsonEvent{
+		Type:               e.Type,
+		Time:               e.Time.UTC(),
+		TriggerID:          e.Trigger.ID,
+		TriggerSource:      e.Trigger.Source,
+		TriggerReason:      e.Trigger.Reason,
+		TriggerPaths:       e.Trigger.Paths,
+		TriggerKeys:        e.Trigger.Keys,
+		TriggerMetadata:    e.Trigger.Metadata,
+		TriggerUrgency:     e.Trigger.Urgency,
+		TriggerTagSelector: e.Trigger.TagSelector,
+		Reloader:           e.Reloader,
+		Notifier:           e.Notifier,
+		Reloaders:          e.Reloaders,
+		Attempt:            e.Attempt,
+		ApprovalToken:      e.ApprovalToken,
+		Pipelines:          e.Pipelines,
+		Retrying:           e.Retrying,
+	}
+
+	switch e.Type {
+	case EventGroupStarted, EventGroupFinished, EventReloaderFinished, EventReloaderSkipped, EventAbandonedReloaderReturned:
+		priority := e.Priority
+		je.Priority = &priority
+	}
+
+	switch e.Type {
+	case EventReloadFinished, EventGroupFinished, EventReloaderFinished, EventAbandonedReloaderReturned, EventReloadRetrying:
+		seconds := e.Duration.Seconds()
+		je.DurationSeconds = &seconds
+	}
+
+	if e.Err != nil {
+		je.Error = e.Err.Error()
+	}
+
+	return je
+}
+
+// MarshalEventJSON returns the JSON representation of the event, the same
+// written by NewJSONEventEncoder (e.g: to stream the events to other systems).
+func MarshalEventJSON(e Event) ([]byte, error) {
+	return json.Marshal(newJSONEvent(e))
+}
+
+type jsonEventEncoder struct {
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+// NewJSONEventEncoder returns a Subscriber that writes every event as a JSON
+// line on the writer. Safe for concurrent use.
+//
+// Write errors are ignored, the events are best-effort.
+func NewJSONEventEncoder(w io.Writer) Subscriber {
+	return &jsonEventEncoder{enc: json.NewEncoder(w)}
+}
+
+func (j *jsonEventEncoder) HandleEvent(_ context.Context, e Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_ = j.enc.Encode(newJSONEvent(e))
+}
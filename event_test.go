@@ -0,0 +1,235 @@
+package reload_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerEvents(t *testing.T) {
+	tests := map[string]struct {
+		reloaders map[int]error
+		expEvents []reload.Event
+	}{
+		"A successful reload should emit the lifecycle events.": {
+			reloaders: map[int]error{0: nil, 10: nil},
+			expEvents: []reload.Event{
+				{Type: reload.EventTriggerReceived, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}},
+				{Type: reload.EventReloadStarted, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}},
+				{Type: reload.EventGroupStarted, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 0, Reloaders: []string{"r0"}},
+				{Type: reload.EventReloaderFinished, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 0, Reloader: "r0"},
+				{Type: reload.EventGroupFinished, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 0},
+				{Type: reload.EventGroupStarted, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 10, Reloaders: []string{"r10"}},
+				{Type: reload.EventReloaderFinished, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 10, Reloader: "r10"},
+				{Type: reload.EventGroupFinished, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 10},
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}},
+			},
+		},
+
+		"A failed reload should emit the lifecycle events with the error.": {
+			reloaders: map[int]error{0: fmt.Errorf("something"), 10: nil},
+			expEvents: []reload.Event{
+				{Type: reload.EventTriggerReceived, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}},
+				{Type: reload.EventReloadStarted, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}},
+				{Type: reload.EventGroupStarted, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 0, Reloaders: []string{"r0"}},
+				{Type: reload.EventReloaderFinished, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 0, Reloader: "r0", Err: fmt.Errorf("something")},
+				{Type: reload.EventGroupFinished, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Priority: 0, Err: fmt.Errorf(`reloader "r0" (1/1): %w`, fmt.Errorf("something"))},
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "test-id", Source: "test"}, Err: fmt.Errorf(`reload "test-id" failed: group (priority 0): %w`, fmt.Errorf(`reloader "r0" (1/1): %w`, fmt.Errorf("something")))},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var mu sync.Mutex
+			var gotEvents []reload.Event
+			s := reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				gotEvents = append(gotEvents, e)
+			})
+
+			m := reload.NewManager(reload.WithSubscriber(s))
+			for priority, err := range test.reloaders {
+				err := err
+				m.Add(priority, reload.ReloaderFunc(func(ctx context.Context, id string) error { return err }), reload.WithReloaderName(fmt.Sprintf("r%d", priority)))
+			}
+			notifierC := make(chan string)
+			m.On(reload.NotifierChan(notifierC), reload.WithNotifierName("test"))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			notifierC <- "test-id"
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+			<-runFinished
+
+			// Check.
+			mu.Lock()
+			defer mu.Unlock()
+			for i := range gotEvents {
+				assert.False(gotEvents[i].Time.IsZero())
+				gotEvents[i].Time = time.Time{}
+				gotEvents[i].Duration = 0
+				// The reloaders report durations are not deterministic.
+				var rErr *reload.ReloadError
+				if errors.As(gotEvents[i].Err, &rErr) {
+					gotEvents[i].Err = rErr.Unwrap()
+				}
+			}
+			assert.Equal(test.expEvents, gotEvents)
+		})
+	}
+}
+
+func TestManagerGroupProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	var got []string
+	m := reload.NewManager(reload.WithGroupProgress(reload.GroupProgress{
+		OnGroupStart: func(ctx context.Context, priority int, reloaders []string) {
+			got = append(got, fmt.Sprintf("start %d %v", priority, reloaders))
+		},
+		OnGroupEnd: func(ctx context.Context, priority int, err error, duration time.Duration) {
+			got = append(got, fmt.Sprintf("end %d %v", priority, err))
+		},
+	}))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("config"))
+	m.Add(10, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("cache"))
+	m.Add(10, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }), reload.WithReloaderName("server"))
+
+	// Execute.
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	assert.Error(err)
+	assert.Equal([]string{
+		"start 0 [config]",
+		"end 0 <nil>",
+		"start 10 [cache server]",
+		`end 10 reloader "server" (2/2): something`,
+	}, got)
+}
+
+func TestManagerSubscribe(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	var optionTriggers, subscribedTriggers []string
+	m := reload.NewManager(reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+		if e.Type == reload.EventReloadFinished {
+			optionTriggers = append(optionTriggers, e.Trigger.ID)
+		}
+	})))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }))
+	unsubscribe := m.Subscribe(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+		if e.Type == reload.EventReloadFinished {
+			subscribedTriggers = append(subscribedTriggers, e.Trigger.ID)
+		}
+	}))
+
+	// Execute.
+	_ = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+	unsubscribe()
+	unsubscribe()
+	_ = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t2"})
+
+	// Check.
+	assert.Equal([]string{"t1", "t2"}, optionTriggers)
+	assert.Equal([]string{"t1"}, subscribedTriggers)
+}
+
+func TestJSONEventEncoder(t *testing.T) {
+	tests := map[string]struct {
+		event  reload.Event
+		expOut string
+	}{
+		"A trigger event should be encoded without group or duration information.": {
+			event: reload.Event{
+				Type:    reload.EventTriggerReceived,
+				Time:    time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+				Trigger: reload.TriggerEvent{ID: "test-id", Source: "file", Paths: []string{"/tmp/a.json"}},
+			},
+			expOut: `{"type":"trigger_received","time":"2021-07-19T10:00:00Z","trigger_id":"test-id","trigger_source":"file","trigger_paths":["/tmp/a.json"]}` + "\n",
+		},
+
+		"A trigger event with changed keys should be encoded with the keys.": {
+			event: reload.Event{
+				Type:    reload.EventTriggerReceived,
+				Time:    time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+				Trigger: reload.TriggerEvent{ID: "test-id", Source: "openfeature", Keys: []string{"flag-a"}},
+			},
+			expOut: `{"type":"trigger_received","time":"2021-07-19T10:00:00Z","trigger_id":"test-id","trigger_source":"openfeature","trigger_keys":["flag-a"]}` + "\n",
+		},
+
+		"A group started event should be encoded with the priority and reloaders.": {
+			event: reload.Event{
+				Type:      reload.EventGroupStarted,
+				Time:      time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+				Trigger:   reload.TriggerEvent{ID: "test-id", Source: "file"},
+				Priority:  10,
+				Reloaders: []string{"cache", "server"},
+			},
+			expOut: `{"type":"group_started","time":"2021-07-19T10:00:00Z","trigger_id":"test-id","trigger_source":"file","priority":10,"reloaders":["cache","server"]}` + "\n",
+		},
+
+		"A group finished event should be encoded with the priority, duration and error.": {
+			event: reload.Event{
+				Type:     reload.EventGroupFinished,
+				Time:     time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+				Trigger:  reload.TriggerEvent{ID: "test-id", Source: "file"},
+				Priority: 0,
+				Duration: 250 * time.Millisecond,
+				Err:      fmt.Errorf("something"),
+			},
+			expOut: `{"type":"group_finished","time":"2021-07-19T10:00:00Z","trigger_id":"test-id","trigger_source":"file","priority":0,"duration_seconds":0.25,"error":"something"}` + "\n",
+		},
+
+		"A reloader finished event should be encoded with the priority, reloader and duration.": {
+			event: reload.Event{
+				Type:     reload.EventReloaderFinished,
+				Time:     time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+				Trigger:  reload.TriggerEvent{ID: "test-id", Source: "file"},
+				Priority: 10,
+				Reloader: "cache",
+				Duration: 250 * time.Millisecond,
+			},
+			expOut: `{"type":"reloader_finished","time":"2021-07-19T10:00:00Z","trigger_id":"test-id","trigger_source":"file","priority":10,"reloader":"cache","duration_seconds":0.25}` + "\n",
+		},
+
+		"A reload finished event should be encoded with the duration.": {
+			event: reload.Event{
+				Type:     reload.EventReloadFinished,
+				Time:     time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+				Trigger:  reload.TriggerEvent{ID: "test-id", Source: "file"},
+				Duration: time.Second,
+			},
+			expOut: `{"type":"reload_finished","time":"2021-07-19T10:00:00Z","trigger_id":"test-id","trigger_source":"file","duration_seconds":1}` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var b bytes.Buffer
+			reload.NewJSONEventEncoder(&b).HandleEvent(context.TODO(), test.event)
+
+			assert.Equal(test.expOut, b.String())
+		})
+	}
+}
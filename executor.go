@@ -0,0 +1,112 @@
+package reload
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ExecutorTask is the reload of a reloader of a priority group, executed by an
+// Executor.
+type ExecutorTask struct {
+	// Name is the reloader name.
+	Name string
+	// Weight is the reloader weight (see WithReloaderWeight).
+	Weight int
+	// Run reloads the reloader with the context, and returns its error. It
+	// reports the reloader result (events, metrics, circuit breaker...), so
+	// it needs to be called once.
+	Run func(ctx context.Context) error
+}
+
+// Executor knows how to run the reloaders of a priority group (see
+// WithExecutor). The tasks are received in weight and registration order,
+// and the executor returns when all the started tasks have returned, with the
+// group error (normally the first task error).
+type Executor interface {
+	Execute(ctx context.Context, tasks []ExecutorTask) error
+}
+
+// ExecutorFunc is a helper to create executors from functions.
+type ExecutorFunc func(ctx context.Context, tasks []ExecutorTask) error
+
+// Execute satisfies Executor interface.
+func (e ExecutorFunc) Execute(ctx context.Context, tasks []ExecutorTask) error { return e(ctx, tasks) }
+
+// ParallelExecutor returns an Executor that runs all the tasks in parallel,
+// started in order. The first error cancels the context of the rest.
+//
+// This is the default executor.
+func ParallelExecutor() Executor {
+	return PoolExecutor(0)
+}
+
+// PoolExecutor returns an Executor that runs the tasks in parallel with at
+// most size tasks running at the same time, started in order. The first error
+// cancels the context of the rest, and the tasks not started yet return the
+// context error without running. A size <= 0 means no limit.
+func PoolExecutor(size int) Executor {
+	return ExecutorFunc(func(ctx context.Context, tasks []ExecutorTask) error {
+		g, ctx := errgroup.WithContext(ctx)
+		if size > 0 {
+			g.SetLimit(size)
+		}
+
+		for _, t := range tasks {
+			g.Go(func() error {
+				// The pending tasks don't start after a failure.
+				if size > 0 && ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return t.Run(ctx)
+			})
+		}
+
+		return g.Wait()
+	})
+}
+
+// SequentialExecutor returns an Executor that runs the tasks one at a time in
+// order, stopping on the first error (see WithOrderedReloaders).
+func SequentialExecutor() Executor {
+	return PoolExecutor(1)
+}
+
+// WeightedExecutor returns an Executor that runs the tasks with the same weight
+// in parallel, and the weights one after the other in weight order, stopping
+// on the first failed weight.
+func WeightedExecutor() Executor {
+	return ExecutorFunc(func(ctx context.Context, tasks []ExecutorTask) error {
+		for start := 0; start < len(tasks); {
+			end := start + 1
+			for end < len(tasks) && tasks[end].Weight == tasks[start].Weight {
+				end++
+			}
+
+			err := ParallelExecutor().Execute(ctx, tasks[start:end])
+			if err != nil {
+				return err
+			}
+			start = end
+		}
+
+		return nil
+	})
+}
+
+// ShuffledExecutor returns an Executor that shuffles the tasks before running
+// them with the executor (e.g: to find hidden dependencies between the
+// reloaders of a group), if nil SequentialExecutor is used.
+func ShuffledExecutor(e Executor) Executor {
+	if e == nil {
+		e = SequentialExecutor()
+	}
+
+	return ExecutorFunc(func(ctx context.Context, tasks []ExecutorTask) error {
+		shuffled := append([]ExecutorTask(nil), tasks...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		return e.Execute(ctx, shuffled)
+	})
+}
@@ -0,0 +1,170 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+func TestExecutors(t *testing.T) {
+	errTest := fmt.Errorf("something")
+
+	tests := map[string]struct {
+		executor      reload.Executor
+		weights       []int
+		fail          string
+		expErr        error
+		expRun        []string
+		expRunOrdered bool
+		expMaxRunning int32
+	}{
+		"The parallel executor should run all the tasks at the same time.": {
+			executor:      reload.ParallelExecutor(),
+			weights:       []int{0, 0, 0},
+			expRun:        []string{"r0", "r1", "r2"},
+			expMaxRunning: 3,
+		},
+
+		"The pool executor should limit the running tasks.": {
+			executor:      reload.PoolExecutor(2),
+			weights:       []int{0, 0, 0, 0},
+			expRun:        []string{"r0", "r1", "r2", "r3"},
+			expMaxRunning: 2,
+		},
+
+		"The sequential executor should run the tasks in order.": {
+			executor:      reload.SequentialExecutor(),
+			weights:       []int{0, 0, 0},
+			expRun:        []string{"r0", "r1", "r2"},
+			expRunOrdered: true,
+			expMaxRunning: 1,
+		},
+
+		"The sequential executor should stop on the first error.": {
+			executor:      reload.SequentialExecutor(),
+			weights:       []int{0, 0, 0},
+			fail:          "r1",
+			expErr:        errTest,
+			expRun:        []string{"r0", "r1"},
+			expRunOrdered: true,
+			expMaxRunning: 1,
+		},
+
+		"The weighted executor should run the same weights in parallel.": {
+			executor:      reload.WeightedExecutor(),
+			weights:       []int{0, 0, 5, 10, 10},
+			expRun:        []string{"r0", "r1", "r2", "r3", "r4"},
+			expMaxRunning: 2,
+		},
+
+		"The weighted executor should stop on the first failed weight.": {
+			executor:      reload.WeightedExecutor(),
+			weights:       []int{0, 0, 5, 10},
+			fail:          "r2",
+			expErr:        errTest,
+			expRun:        []string{"r0", "r1", "r2"},
+			expMaxRunning: 2,
+		},
+
+		"The shuffled executor should run all the tasks.": {
+			executor:      reload.ShuffledExecutor(nil),
+			weights:       []int{0, 0, 0, 0},
+			expRun:        []string{"r0", "r1", "r2", "r3"},
+			expMaxRunning: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var mu sync.Mutex
+			var gotRun []string
+			var running, maxRunning atomic.Int32
+			var tasks []reload.ExecutorTask
+			for i, w := range test.weights {
+				name := fmt.Sprintf("r%d", i)
+				tasks = append(tasks, reload.ExecutorTask{Name: name, Weight: w, Run: func(ctx context.Context) error {
+					n := running.Add(1)
+					defer running.Add(-1)
+					for {
+						m := maxRunning.Load()
+						if n <= m || maxRunning.CompareAndSwap(m, n) {
+							break
+						}
+					}
+
+					mu.Lock()
+					gotRun = append(gotRun, name)
+					mu.Unlock()
+
+					// Let the parallel tasks overlap.
+					time.Sleep(20 * time.Millisecond)
+					if name == test.fail {
+						return errTest
+					}
+					return nil
+				}})
+			}
+
+			// Execute.
+			err := test.executor.Execute(context.TODO(), tasks)
+
+			// Check.
+			if test.expErr != nil {
+				assert.ErrorIs(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+			if test.expRunOrdered {
+				assert.Equal(test.expRun, gotRun)
+			} else {
+				assert.ElementsMatch(test.expRun, gotRun)
+			}
+			assert.Equal(test.expMaxRunning, maxRunning.Load())
+		})
+	}
+}
+
+func TestManagerWithExecutor(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	var gotTasks []string
+	m := reload.NewManager(reload.WithExecutor(reload.ExecutorFunc(func(ctx context.Context, tasks []reload.ExecutorTask) error {
+		for _, t := range tasks {
+			gotTasks = append(gotTasks, t.Name)
+			err := t.Run(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})))
+	var reloaded []string
+	ok := func(name string) reload.Reloader {
+		return reload.ReloaderFunc(func(ctx context.Context, id string) error {
+			reloaded = append(reloaded, name)
+			return nil
+		})
+	}
+	m.Add(0, ok("b"), reload.WithReloaderName("b"), reload.WithReloaderWeight(10))
+	m.Add(0, ok("a"), reload.WithReloaderName("a"))
+	m.Add(10, ok("c"), reload.WithReloaderName("c"))
+
+	// Execute.
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	assert.NoError(err)
+	assert.Equal([]string{"a", "b", "c"}, gotTasks)
+	assert.Equal([]string{"a", "b", "c"}, reloaded)
+}
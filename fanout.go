@@ -0,0 +1,104 @@
+package reload
+
+import (
+	"context"
+	"sync"
+)
+
+// EventFanout is a Subscriber that fans out the lifecycle events to multiple
+// watchers (e.g: the event streams of the admin endpoints), every watcher has
+// its own buffer and the watchers that don't keep up are disconnected, so
+// they don't block the reloads.
+type EventFanout struct {
+	bufferSize int
+
+	mu       sync.Mutex
+	seq      uint64
+	watchers map[*EventWatcher]struct{}
+}
+
+var _ Subscriber = &EventFanout{}
+
+// NewEventFanout returns a new EventFanout with the buffer size of the
+// watchers.
+// By default (0) 100.
+func NewEventFanout(bufferSize int) *EventFanout {
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+
+	return &EventFanout{bufferSize: bufferSize, watchers: map[*EventWatcher]struct{}{}}
+}
+
+// WatchedEvent is an event received by an EventWatcher.
+type WatchedEvent struct {
+	// Seq is the sequence number of the event on the fan-out, the events
+	// not received by the watcher (e.g: filtered) also increase it.
+	Seq   uint64
+	Event Event
+}
+
+// EventWatcher receives the events of an EventFanout, it needs to be stopped
+// once done.
+type EventWatcher struct {
+	fanout *EventFanout
+	types  map[EventType]bool
+	events chan WatchedEvent
+	// lagging is closed when the watcher didn't keep up with the events.
+	lagging chan struct{}
+}
+
+// HandleEvent satisfies Subscriber interface.
+func (f *EventFanout) HandleEvent(_ context.Context, e Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	we := WatchedEvent{Seq: f.seq, Event: e}
+	for w := range f.watchers {
+		if len(w.types) > 0 && !w.types[e.Type] {
+			continue
+		}
+
+		select {
+		case w.events <- we:
+		default:
+			close(w.lagging)
+			delete(f.watchers, w)
+		}
+	}
+}
+
+// Watch returns a new watcher of the events of the types, all the events if
+// no types.
+func (f *EventFanout) Watch(types ...EventType) *EventWatcher {
+	w := &EventWatcher{
+		fanout:  f,
+		types:   map[EventType]bool{},
+		events:  make(chan WatchedEvent, f.bufferSize),
+		lagging: make(chan struct{}),
+	}
+	for _, t := range types {
+		w.types[t] = true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.watchers[w] = struct{}{}
+
+	return w
+}
+
+// Events returns the watched events.
+func (w *EventWatcher) Events() <-chan WatchedEvent { return w.events }
+
+// Lagging is closed when the watcher didn't keep up with the events, it
+// doesn't receive more events.
+func (w *EventWatcher) Lagging() <-chan struct{} { return w.lagging }
+
+// Stop stops watching the events.
+func (w *EventWatcher) Stop() {
+	w.fanout.mu.Lock()
+	defer w.fanout.mu.Unlock()
+	delete(w.fanout.watchers, w)
+}
@@ -0,0 +1,80 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+func TestEventFanout(t *testing.T) {
+	tests := map[string]struct {
+		types     []reload.EventType
+		events    []reload.EventType
+		expEvents []reload.WatchedEvent
+	}{
+		"All the events should be received without types.": {
+			events: []reload.EventType{reload.EventReloadStarted, reload.EventReloadFinished},
+			expEvents: []reload.WatchedEvent{
+				{Seq: 1, Event: reload.Event{Type: reload.EventReloadStarted}},
+				{Seq: 2, Event: reload.Event{Type: reload.EventReloadFinished}},
+			},
+		},
+
+		"Only the events of the types should be received.": {
+			types:  []reload.EventType{reload.EventReloadFinished},
+			events: []reload.EventType{reload.EventReloadStarted, reload.EventReloadFinished},
+			expEvents: []reload.WatchedEvent{
+				{Seq: 2, Event: reload.Event{Type: reload.EventReloadFinished}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			f := reload.NewEventFanout(10)
+			w := f.Watch(test.types...)
+			defer w.Stop()
+
+			// Execute.
+			for _, typ := range test.events {
+				f.HandleEvent(context.TODO(), reload.Event{Type: typ})
+			}
+
+			// Check.
+			var got []reload.WatchedEvent
+			for len(w.Events()) > 0 {
+				got = append(got, <-w.Events())
+			}
+			assert.Equal(test.expEvents, got)
+		})
+	}
+}
+
+func TestEventFanoutLagging(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	f := reload.NewEventFanout(1)
+	lagging := f.Watch()
+	stopped := f.Watch()
+	stopped.Stop()
+
+	// Execute.
+	f.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadStarted})
+	f.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadFinished})
+
+	// Check.
+	select {
+	case <-lagging.Lagging():
+	default:
+		assert.Fail("watcher should be lagging")
+	}
+	assert.Len(lagging.Events(), 1)
+	assert.Empty(stopped.Events())
+}
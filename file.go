@@ -0,0 +1,253 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// FileNotifierConfig is the configuration of the FileNotifier.
+type FileNotifierConfig struct {
+	// Paths are the files that will be watched.
+	Paths []string
+	// Dirs are the directories that will be watched, including their
+	// subdirectories (e.g: a templates directory). Any created, modified or
+	// removed file in them will trigger a reload.
+	Dirs []string
+	// Interval is the interval used to check for file changes.
+	// By default 1s.
+	Interval time.Duration
+	// TriggerID is the ID used on the triggers.
+	// By default `file`.
+	TriggerID string
+	// Clock is the clock of the interval.
+	// By default RealClock.
+	Clock Clock
+	// FS is the file system of the paths and directories, the paths are
+	// names of the file system (e.g: an `fstest.MapFS` on the tests).
+	// By default the OS file system.
+	FS fs.FS
+}
+
+func (c *FileNotifierConfig) defaults() error {
+	if len(c.Paths) == 0 && len(c.Dirs) == 0 {
+		return fmt.Errorf("at least one path or directory is required")
+	}
+
+	if c.Interval <= 0 {
+		c.Interval = time.Second
+	}
+
+	if c.TriggerID == "" {
+		c.TriggerID = "file"
+	}
+
+	if c.Clock == nil {
+		c.Clock = RealClock
+	}
+
+	return nil
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime int64
+}
+
+// FileNotifier is a notifier that will trigger a reload when any of the
+// watched files change (created, modified or removed).
+//
+// The returned trigger has the paths of the files that changed, so
+// reloaders can get them using TriggerEventFromContext.
+type FileNotifier struct {
+	cfg      FileNotifierConfig
+	state    map[string]fileState
+	dirState map[string]map[string]fileState
+}
+
+// NewFileNotifier returns a new FileNotifier. The state of the files is
+// taken when created, so any change after this will trigger a reload.
+func NewFileNotifier(cfg FileNotifierConfig) (*FileNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	f := &FileNotifier{
+		cfg:      cfg,
+		state:    map[string]fileState{},
+		dirState: map[string]map[string]fileState{},
+	}
+	for _, p := range cfg.Paths {
+		st, err := statFile(cfg.FS, p)
+		if err != nil {
+			return nil, err
+		}
+		f.state[p] = st
+	}
+	for _, d := range cfg.Dirs {
+		st, err := statDir(cfg.FS, d)
+		if err != nil {
+			return nil, err
+		}
+		f.dirState[d] = st
+	}
+
+	return f, nil
+}
+
+// Notify satisfies Notifier interface.
+func (f *FileNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := f.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies TriggerNotifier interface.
+func (f *FileNotifier) NotifyTrigger(ctx context.Context) (TriggerEvent, error) {
+	t := f.cfg.Clock.NewTicker(f.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return TriggerEvent{}, ctx.Err()
+		case <-t.C():
+		}
+
+		changed, err := f.changedPaths()
+		if err != nil {
+			return TriggerEvent{}, err
+		}
+
+		if len(changed) > 0 {
+			return TriggerEvent{ID: f.cfg.TriggerID, Paths: changed}, nil
+		}
+	}
+}
+
+// changedPaths returns the paths that changed since the last check, in the
+// same order they were configured, followed by the changed files of the
+// directories in lexical order.
+func (f *FileNotifier) changedPaths() ([]string, error) {
+	var changed []string
+	for _, p := range f.cfg.Paths {
+		st, err := statFile(f.cfg.FS, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if st != f.state[p] {
+			f.state[p] = st
+			changed = append(changed, p)
+		}
+	}
+
+	for _, d := range f.cfg.Dirs {
+		st, err := statDir(f.cfg.FS, d)
+		if err != nil {
+			return nil, err
+		}
+
+		old := f.dirState[d]
+		f.dirState[d] = st
+		for _, p := range sortedKeys(st, old) {
+			if st[p] != old[p] {
+				changed = append(changed, p)
+			}
+		}
+	}
+
+	return changed, nil
+}
+
+// statFile returns the state of a file of the file system, the OS file system
+// if nil.
+func statFile(fsys fs.FS, path string) (fileState, error) {
+	var info fs.FileInfo
+	var err error
+	if fsys == nil {
+		info, err = os.Stat(path)
+	} else {
+		info, err = fs.Stat(fsys, path)
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fileState{}, nil
+		}
+		return fileState{}, fmt.Errorf("could not stat %q file: %w", path, err)
+	}
+
+	return fileState{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime().UnixNano(),
+	}, nil
+}
+
+// statDir returns the state of all the files of a directory tree, a missing
+// directory doesn't have files.
+func statDir(fsys fs.FS, dir string) (map[string]fileState, error) {
+	state := map[string]fileState{}
+	walk := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			// Removed while walking.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		state[path] = fileState{
+			exists:  true,
+			size:    info.Size(),
+			modTime: info.ModTime().UnixNano(),
+		}
+
+		return nil
+	}
+
+	var err error
+	if fsys == nil {
+		err = filepath.WalkDir(dir, walk)
+	} else {
+		err = fs.WalkDir(fsys, dir, walk)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not walk %q directory: %w", dir, err)
+	}
+
+	return state, nil
+}
+
+// sortedKeys returns the keys of both states sorted.
+func sortedKeys(a, b map[string]fileState) []string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,277 @@
+package reload_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestFileNotifier(t *testing.T) {
+	tests := map[string]struct {
+		files    []string
+		change   func(t *testing.T, dir string)
+		expPaths []string
+	}{
+		"A modified file should trigger with its path.": {
+			files: []string{"a.json", "b.json"},
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte("changed-content"), 0o600))
+			},
+			expPaths: []string{"b.json"},
+		},
+
+		"A removed file should trigger with its path.": {
+			files: []string{"a.json", "b.json"},
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.Remove(filepath.Join(dir, "a.json")))
+			},
+			expPaths: []string{"a.json"},
+		},
+
+		"A created file should trigger with its path.": {
+			files: []string{"a.json"},
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte("c"), 0o600))
+			},
+			expPaths: []string{"c.json"},
+		},
+
+		"Multiple changed files should trigger with all the paths.": {
+			files: []string{"a.json", "b.json"},
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte("changed-content"), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte("changed-content"), 0o600))
+			},
+			expPaths: []string{"a.json", "b.json"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			dir := t.TempDir()
+			for _, f := range test.files {
+				require.NoError(os.WriteFile(filepath.Join(dir, f), []byte(f), 0o600))
+			}
+			paths := []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), filepath.Join(dir, "c.json")}
+			n, err := reload.NewFileNotifier(reload.FileNotifierConfig{
+				Paths:    paths,
+				Interval: 5 * time.Millisecond,
+			})
+			require.NoError(err)
+
+			// Execute.
+			test.change(t, dir)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			gotTrigger, err := n.NotifyTrigger(ctx)
+
+			// Check.
+			require.NoError(err)
+			expPaths := []string{}
+			for _, p := range test.expPaths {
+				expPaths = append(expPaths, filepath.Join(dir, p))
+			}
+			assert.Equal(reload.TriggerEvent{ID: "file", Paths: expPaths}, gotTrigger)
+		})
+	}
+}
+
+func TestFileNotifierDirs(t *testing.T) {
+	tests := map[string]struct {
+		change   func(t *testing.T, dir string)
+		expPaths []string
+	}{
+		"A modified file should trigger with its path.": {
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "a.tmpl"), []byte("changed-content"), 0o600))
+			},
+			expPaths: []string{"a.tmpl"},
+		},
+
+		"A created file on a subdirectory should trigger with its path.": {
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "c.tmpl"), []byte("c"), 0o600))
+			},
+			expPaths: []string{"sub/c.tmpl"},
+		},
+
+		"Removed files should trigger with their paths.": {
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.RemoveAll(filepath.Join(dir, "sub")))
+				require.NoError(t, os.Remove(filepath.Join(dir, "a.tmpl")))
+			},
+			expPaths: []string{"a.tmpl", "sub/b.tmpl"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			dir := t.TempDir()
+			require.NoError(os.Mkdir(filepath.Join(dir, "sub"), 0o700))
+			require.NoError(os.WriteFile(filepath.Join(dir, "a.tmpl"), []byte("a"), 0o600))
+			require.NoError(os.WriteFile(filepath.Join(dir, "sub", "b.tmpl"), []byte("b"), 0o600))
+			n, err := reload.NewFileNotifier(reload.FileNotifierConfig{
+				Dirs:     []string{dir},
+				Interval: 5 * time.Millisecond,
+			})
+			require.NoError(err)
+
+			// Execute.
+			test.change(t, dir)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			gotTrigger, err := n.NotifyTrigger(ctx)
+
+			// Check.
+			require.NoError(err)
+			expPaths := []string{}
+			for _, p := range test.expPaths {
+				expPaths = append(expPaths, filepath.Join(dir, p))
+			}
+			assert.Equal(reload.TriggerEvent{ID: "file", Paths: expPaths}, gotTrigger)
+		})
+	}
+}
+
+func TestFileNotifierContextCancel(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "a.json")
+	n, err := reload.NewFileNotifier(reload.FileNotifierConfig{Paths: []string{path}, Interval: 5 * time.Millisecond})
+	require.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = n.Notify(ctx)
+
+	assert.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func TestFileNotifierClock(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "a.json")
+	require.NoError(os.WriteFile(path, []byte("content"), 0o600))
+	clock := reloadtest.NewClock(time.Now())
+	n, err := reload.NewFileNotifier(reload.FileNotifierConfig{Paths: []string{path}, Interval: time.Hour, Clock: clock})
+	require.NoError(err)
+
+	// Execute.
+	res := make(chan reload.TriggerEvent)
+	go func() {
+		t, _ := n.NotifyTrigger(context.TODO())
+		res <- t
+	}()
+	require.True(clock.WaitWaiters(1, time.Second))
+	require.NoError(os.WriteFile(path, []byte("changed-content"), 0o600))
+	clock.Advance(time.Hour)
+
+	// Check.
+	select {
+	case got := <-res:
+		assert.Equal([]string{path}, got.Paths)
+	case <-time.After(time.Second):
+		assert.Fail("notifier did not trigger")
+	}
+}
+
+func TestFileNotifierFS(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	fsys := fstest.MapFS{
+		"config/a.json":    {Data: []byte("a")},
+		"templates/b.tmpl": {Data: []byte("b")},
+	}
+	n, err := reload.NewFileNotifier(reload.FileNotifierConfig{
+		Paths:    []string{"config/a.json"},
+		Dirs:     []string{"templates"},
+		Interval: 5 * time.Millisecond,
+		FS:       fsys,
+	})
+	require.NoError(err)
+
+	// Execute.
+	fsys["config/a.json"] = &fstest.MapFile{Data: []byte("changed-content")}
+	fsys["templates/c.tmpl"] = &fstest.MapFile{Data: []byte("c")}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	gotTrigger, err := n.NotifyTrigger(ctx)
+
+	// Check.
+	require.NoError(err)
+	assert.Equal(reload.TriggerEvent{ID: "file", Paths: []string{"config/a.json", "templates/c.tmpl"}}, gotTrigger)
+}
+
+func TestFileNotifierSavePatterns(t *testing.T) {
+	savePatterns := map[string]func(t *testing.T, path, content string){
+		"in place": func(t *testing.T, path, content string) {
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		},
+		"atomic write": func(t *testing.T, path, content string) {
+			tmp := path + ".tmp"
+			require.NoError(t, os.WriteFile(tmp, []byte(content), 0o600))
+			require.NoError(t, os.Rename(tmp, path))
+		},
+		"vim backup": func(t *testing.T, path, content string) {
+			backup := path + "~"
+			require.NoError(t, os.Rename(path, backup))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			require.NoError(t, os.Remove(backup))
+		},
+	}
+
+	for name, save := range savePatterns {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(os.WriteFile(path, []byte("v0"), 0o600))
+			clock := reloadtest.NewClock(time.Now())
+			n, err := reload.NewFileNotifier(reload.FileNotifierConfig{Paths: []string{path}, Interval: time.Second, Clock: clock})
+			require.NoError(err)
+
+			// Execute and check every save triggers.
+			for i, content := range []string{"v11", "v222", "v3333"} {
+				save(t, path, content)
+				res := make(chan reload.TriggerEvent)
+				go func() {
+					t, _ := n.NotifyTrigger(context.TODO())
+					res <- t
+				}()
+				require.True(clock.WaitWaiters(1, time.Second))
+				clock.Advance(time.Second)
+
+				select {
+				case got := <-res:
+					assert.Equal([]string{path}, got.Paths, "save %d", i)
+				case <-time.After(time.Second):
+					require.Fail("notifier did not trigger", "save %d", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+)
+
+// FileGroupNotifierConfig is the configuration of the FileGroupNotifier.
+type FileGroupNotifierConfig struct {
+	// Files are the watched files with the trigger ID of each file (e.g:
+	// `tls.crt` to `certs` and `config.yaml` to `config`).
+	Files map[string]string
+	// Interval is the interval used to check for file changes.
+	// By default 1s.
+	Interval time.Duration
+	// Clock is the clock of the interval.
+	// By default RealClock.
+	Clock Clock
+	// FS is the file system of the files, the files are names of the file
+	// system (e.g: an `fstest.MapFS` on the tests).
+	// By default the OS file system.
+	FS fs.FS
+}
+
+func (c *FileGroupNotifierConfig) defaults() error {
+	if len(c.Files) == 0 {
+		return fmt.Errorf("at least one file is required")
+	}
+
+	for path, id := range c.Files {
+		if id == "" {
+			return fmt.Errorf("%q file trigger ID is required", path)
+		}
+	}
+
+	return nil
+}
+
+// FileGroupNotifier is a notifier that watches multiple files and triggers
+// with the trigger ID of the changed files, so the reloaders can be routed by
+// the changed file (e.g: with WithGroupTriggerIDs).
+//
+// When files of different trigger IDs change at the same time, a trigger is
+// returned for each trigger ID on consecutive calls, in the lexical order of
+// the first changed path of each trigger ID. The returned triggers have the
+// changed paths of their trigger ID.
+type FileGroupNotifier struct {
+	cfg     FileGroupNotifierConfig
+	files   *FileNotifier
+	pending []TriggerEvent
+}
+
+// NewFileGroupNotifier returns a new FileGroupNotifier. The state of the files
+// is taken when created, so any change after this will trigger a reload.
+func NewFileGroupNotifier(cfg FileGroupNotifierConfig) (*FileGroupNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	paths := make([]string, 0, len(cfg.Files))
+	for path := range cfg.Files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	files, err := NewFileNotifier(FileNotifierConfig{Paths: paths, Interval: cfg.Interval, Clock: cfg.Clock, FS: cfg.FS})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileGroupNotifier{cfg: cfg, files: files}, nil
+}
+
+// Notify satisfies Notifier interface.
+func (f *FileGroupNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := f.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies TriggerNotifier interface.
+func (f *FileGroupNotifier) NotifyTrigger(ctx context.Context) (TriggerEvent, error) {
+	if len(f.pending) == 0 {
+		t, err := f.files.NotifyTrigger(ctx)
+		if err != nil {
+			return TriggerEvent{}, err
+		}
+		f.pending = f.splitByID(t.Paths)
+	}
+
+	t := f.pending[0]
+	f.pending = f.pending[1:]
+
+	return t, nil
+}
+
+// splitByID returns a trigger for each trigger ID of the changed paths.
+func (f *FileGroupNotifier) splitByID(paths []string) []TriggerEvent {
+	var triggers []TriggerEvent
+	index := map[string]int{}
+	for _, p := range paths {
+		id := f.cfg.Files[p]
+		i, ok := index[id]
+		if !ok {
+			i = len(triggers)
+			index[id] = i
+			triggers = append(triggers, TriggerEvent{ID: id})
+		}
+		triggers[i].Paths = append(triggers[i].Paths, p)
+	}
+
+	return triggers
+}
@@ -0,0 +1,97 @@
+package reload_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestFileGroupNotifier(t *testing.T) {
+	tests := map[string]struct {
+		change      func(t *testing.T, dir string)
+		expTriggers []reload.TriggerEvent
+	}{
+		"A modified file should trigger with its trigger ID.": {
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), []byte("changed-content"), 0o600))
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "certs", Paths: []string{"tls.crt"}},
+			},
+		},
+
+		"Modified files of the same trigger ID should trigger once with all the paths.": {
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), []byte("changed-content"), 0o600))
+				require.NoError(t, os.Remove(filepath.Join(dir, "tls.key")))
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "certs", Paths: []string{"tls.crt", "tls.key"}},
+			},
+		},
+
+		"Modified files of different trigger IDs should trigger once for every trigger ID.": {
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), []byte("changed-content"), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("changed-content"), 0o600))
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "config", Paths: []string{"config.yaml"}},
+				{ID: "certs", Paths: []string{"tls.key"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			dir := t.TempDir()
+			files := map[string]string{"tls.crt": "certs", "tls.key": "certs", "config.yaml": "config"}
+			cfgFiles := map[string]string{}
+			for f, id := range files {
+				require.NoError(os.WriteFile(filepath.Join(dir, f), []byte(f), 0o600))
+				cfgFiles[filepath.Join(dir, f)] = id
+			}
+			n, err := reload.NewFileGroupNotifier(reload.FileGroupNotifierConfig{
+				Files:    cfgFiles,
+				Interval: 5 * time.Millisecond,
+			})
+			require.NoError(err)
+
+			// Execute.
+			test.change(t, dir)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			var gotTriggers []reload.TriggerEvent
+			for range test.expTriggers {
+				got, err := n.NotifyTrigger(ctx)
+				require.NoError(err)
+				gotTriggers = append(gotTriggers, got)
+			}
+
+			// Check.
+			for i := range test.expTriggers {
+				for j, p := range test.expTriggers[i].Paths {
+					test.expTriggers[i].Paths[j] = filepath.Join(dir, p)
+				}
+			}
+			assert.Equal(test.expTriggers, gotTriggers)
+		})
+	}
+}
+
+func TestFileGroupNotifierInvalidConfig(t *testing.T) {
+	_, err := reload.NewFileGroupNotifier(reload.FileGroupNotifierConfig{Files: map[string]string{"a.json": ""}})
+
+	assert.Error(t, err)
+}
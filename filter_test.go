@@ -0,0 +1,86 @@
+package reload_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerTriggerFilter(t *testing.T) {
+	ignoreTmp := reload.WithTriggerFilter(func(t reload.TriggerEvent) bool { return !strings.HasPrefix(t.ID, "tmp-") })
+	trimPrefix := reload.WithTriggerTransform(func(t reload.TriggerEvent) reload.TriggerEvent {
+		t.ID = strings.TrimPrefix(t.ID, "ci-")
+		return t
+	})
+
+	tests := map[string]struct {
+		opts       []reload.ManagerOption
+		triggers   []string
+		expIDs     []string
+		expDropped int
+	}{
+		"Without filters all the triggers should be reloaded.": {
+			triggers: []string{"t1", "tmp-t2"},
+			expIDs:   []string{"t1", "tmp-t2"},
+		},
+
+		"Filtered triggers should be dropped.": {
+			opts:       []reload.ManagerOption{ignoreTmp},
+			triggers:   []string{"t1", "tmp-t2", "t3"},
+			expIDs:     []string{"t1", "t3"},
+			expDropped: 1,
+		},
+
+		"Transformed triggers should be reloaded with the transformation.": {
+			opts:     []reload.ManagerOption{trimPrefix},
+			triggers: []string{"ci-t1", "t2"},
+			expIDs:   []string{"t1", "t2"},
+		},
+
+		"Filters and transforms should be applied in registration order.": {
+			opts:       []reload.ManagerOption{trimPrefix, ignoreTmp},
+			triggers:   []string{"ci-tmp-t1", "ci-t2"},
+			expIDs:     []string{"t2"},
+			expDropped: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			mr := &testMetricsRecorder{}
+			m := reload.NewManager(append(test.opts, reload.WithMetricsRecorder(mr))...)
+			var gotIDs []string
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotIDs = append(gotIDs, id)
+				return nil
+			}))
+			notifierC := make(chan string)
+			m.On(reload.NotifierChan(notifierC))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runErr := make(chan error)
+			go func() { runErr <- m.Run(ctx) }()
+			for _, id := range test.triggers {
+				notifierC <- id
+			}
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			// Check.
+			require.NoError(<-runErr)
+			assert.Equal(test.expIDs, gotIDs)
+			assert.Equal(test.expDropped, mr.droppedTriggers[reload.DropReasonFiltered])
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Gate is consulted by the manager before running the reloaders of a reload
+// process (see WithGate), so the disruptive reloads only happen when the
+// service is ready for them (e.g: idle).
+type Gate interface {
+	// Wait blocks until the reload can run, if it returns an error the reload
+	// fails without running the reloaders. The context has the reload trigger
+	// (see TriggerEventFromContext).
+	Wait(ctx context.Context) error
+}
+
+// GateFunc is a helper to create gates from functions.
+type GateFunc func(ctx context.Context) error
+
+// Wait satisfies Gate interface.
+func (g GateFunc) Wait(ctx context.Context) error {
+	return g(ctx)
+}
+
+// ErrGateTimeout is returned by InFlightGate when the service doesn't become
+// idle before the timeout.
+var ErrGateTimeout = errors.New("gate timeout")
+
+// InFlightGateConfig is the configuration of InFlightGate.
+type InFlightGateConfig struct {
+	// Timeout is the maximum time to wait for the in-flight requests to end.
+	// By default 30s.
+	Timeout time.Duration
+	// Clock is the clock used for the timeout, by default RealClock.
+	Clock Clock
+}
+
+func (c *InFlightGateConfig) defaults() error {
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+
+	if c.Clock == nil {
+		c.Clock = RealClock
+	}
+
+	return nil
+}
+
+// InFlightGate is a Gate that tracks the in-flight requests of the service and
+// waits until there are none, or fails with ErrGateTimeout if they don't end
+// before the timeout.
+type InFlightGate struct {
+	cfg InFlightGateConfig
+
+	mu       sync.Mutex
+	inFlight int
+	idle     chan struct{}
+}
+
+// NewInFlightGate returns a new InFlightGate.
+func NewInFlightGate(cfg InFlightGateConfig) (*InFlightGate, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	idle := make(chan struct{})
+	close(idle)
+	return &InFlightGate{cfg: cfg, idle: idle}, nil
+}
+
+// Start tracks a new in-flight request, the returned function must be called
+// when the request ends.
+func (g *InFlightGate) Start() (done func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.inFlight == 0 {
+		g.idle = make(chan struct{})
+	}
+	g.inFlight++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+
+			g.inFlight--
+			if g.inFlight == 0 {
+				close(g.idle)
+			}
+		})
+	}
+}
+
+// InFlight returns the number of in-flight requests.
+func (g *InFlightGate) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.inFlight
+}
+
+// Wait satisfies Gate interface.
+func (g *InFlightGate) Wait(ctx context.Context) error {
+	g.mu.Lock()
+	idle := g.idle
+	g.mu.Unlock()
+
+	t := g.cfg.Clock.NewTimer(g.cfg.Timeout)
+	defer t.Stop()
+
+	select {
+	case <-idle:
+		return nil
+	case <-t.C():
+		return fmt.Errorf("%w: %d requests in flight after %s", ErrGateTimeout, g.InFlight(), g.cfg.Timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,130 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestInFlightGate(t *testing.T) {
+	tests := map[string]struct {
+		exec   func(g *reload.InFlightGate, clock *reloadtest.Clock) error
+		expErr error
+	}{
+		"Without in-flight requests the gate should be open.": {
+			exec: func(g *reload.InFlightGate, clock *reloadtest.Clock) error {
+				g.Start()()
+				return g.Wait(context.TODO())
+			},
+		},
+
+		"With in-flight requests the gate should wait until they end.": {
+			exec: func(g *reload.InFlightGate, clock *reloadtest.Clock) error {
+				done1, done2 := g.Start(), g.Start()
+				go func() {
+					clock.WaitWaiters(1, time.Second)
+					done1()
+					done1() // Calling it twice should not count twice.
+					done2()
+				}()
+				return g.Wait(context.TODO())
+			},
+		},
+
+		"In-flight requests that don't end should timeout the gate.": {
+			exec: func(g *reload.InFlightGate, clock *reloadtest.Clock) error {
+				g.Start()
+				go func() {
+					clock.WaitWaiters(1, time.Second)
+					clock.Advance(time.Minute)
+				}()
+				return g.Wait(context.TODO())
+			},
+			expErr: reload.ErrGateTimeout,
+		},
+
+		"A cancelled context should stop waiting.": {
+			exec: func(g *reload.InFlightGate, clock *reloadtest.Clock) error {
+				g.Start()
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return g.Wait(ctx)
+			},
+			expErr: context.Canceled,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			clock := reloadtest.NewClock(time.Now())
+			g, err := reload.NewInFlightGate(reload.InFlightGateConfig{Timeout: time.Minute, Clock: clock})
+			require.NoError(err)
+
+			err = test.exec(g, clock)
+
+			if test.expErr != nil {
+				assert.ErrorIs(err, test.expErr)
+			} else {
+				assert.NoError(err)
+				assert.Equal(0, g.InFlight())
+			}
+		})
+	}
+}
+
+func TestManagerGate(t *testing.T) {
+	tests := map[string]struct {
+		gateErr     error
+		expErr      string
+		expReloaded bool
+	}{
+		"An open gate should run the reloaders.": {
+			expReloaded: true,
+		},
+
+		"A failed gate should fail the reload without running the reloaders.": {
+			gateErr: fmt.Errorf("something"),
+			expErr:  `reload "t1" failed: gate: something`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var gotTrigger reload.TriggerEvent
+			m := reload.NewManager(reload.WithGate(reload.GateFunc(func(ctx context.Context) error {
+				gotTrigger, _ = reload.TriggerEventFromContext(ctx)
+				return test.gateErr
+			})))
+			reloaded := false
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				reloaded = true
+				return nil
+			}))
+
+			// Execute.
+			err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+
+			// Check.
+			if test.expErr != "" {
+				assert.EqualError(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expReloaded, reloaded)
+			assert.Equal("t1", gotTrigger.ID)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Package backoff has the exponential backoff used by the notifiers that
+// reconnect to remote sources.
+package backoff
+
+import (
+	"context"
+	"time"
+)
+
+// Backoff is an exponential backoff, not safe for concurrent use.
+type Backoff struct {
+	min     time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+// New returns a new exponential backoff that starts waiting min and doubles
+// the wait on each call up to max.
+func New(min, max time.Duration) *Backoff {
+	return &Backoff{
+		min:     min,
+		max:     max,
+		current: min,
+	}
+}
+
+// Wait waits the current backoff or until the context is done, and increases
+// the backoff for the next time.
+func (b *Backoff) Wait(ctx context.Context) {
+	t := time.NewTimer(b.current)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+
+	b.current *= 2
+	if b.current > b.max {
+		b.current = b.max
+	}
+}
+
+// Reset resets the backoff to the initial wait.
+func (b *Backoff) Reset() {
+	b.current = b.min
+}
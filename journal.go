@@ -0,0 +1,334 @@
+package reload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FileJournalConfig is the configuration of the FileJournal.
+type FileJournalConfig struct {
+	// Path is the path of the journal file, the rotated files have the `.1`
+	// (newest) to `.N` (oldest) suffixes.
+	Path string
+	// MaxSize is the size in bytes the journal file can reach before it's
+	// rotated.
+	// By default 1MiB.
+	MaxSize int64
+	// MaxFiles is the number of rotated files kept, the oldest ones are removed.
+	// By default 3.
+	MaxFiles int
+	// Events are the event types that will be journaled.
+	// By default the trigger and reload process events (trigger received and
+	// dropped, reload started, deferred, skipped, finished and retrying).
+	Events []EventType
+	// Sync syncs every journaled event to disk, so the events survive the
+	// machine crashes and not only the process crashes, at the cost of
+	// slower event handling.
+	// By default the events are not synced.
+	Sync bool
+	// OnError is called when an event could not be journaled, optional.
+	OnError func(ctx context.Context, err error)
+}
+
+func (c *FileJournalConfig) defaults() error {
+	if c.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	if c.MaxSize <= 0 {
+		c.MaxSize = 1 << 20
+	}
+
+	if c.MaxFiles <= 0 {
+		c.MaxFiles = 3
+	}
+
+	if len(c.Events) == 0 {
+		c.Events = []EventType{
+			EventTriggerReceived,
+			EventTriggerDropped,
+			EventReloadStarted,
+			EventReloadDeferred,
+			EventReloadSkipped,
+			EventReloadFinished,
+			EventReloadRetrying,
+		}
+	}
+
+	if c.OnError == nil {
+		c.OnError = func(context.Context, error) {}
+	}
+
+	return nil
+}
+
+// FileJournal is a Subscriber that appends the trigger and reload events to a
+// local file as JSON lines (see MarshalEventJSON), so the reload activity that
+// preceded a crash can be reconstructed with ReadJournal. The file is rotated
+// when it reaches the maximum size.
+//
+// The journal is best-effort, the errors are reported with OnError and the
+// journal file is reopened on the next event (e.g: after a failed rotation).
+type FileJournal struct {
+	cfg    FileJournalConfig
+	events map[EventType]bool
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	closed bool
+}
+
+var _ Subscriber = &FileJournal{}
+
+// NewFileJournal returns a new FileJournal, the journal file will be created if
+// doesn't exist.
+func NewFileJournal(cfg FileJournalConfig) (*FileJournal, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	j := &FileJournal{cfg: cfg, events: map[EventType]bool{}}
+	for _, t := range cfg.Events {
+		j.events[t] = true
+	}
+
+	err = j.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// HandleEvent satisfies Subscriber interface.
+func (j *FileJournal) HandleEvent(ctx context.Context, e Event) {
+	if !j.events[e.Type] {
+		return
+	}
+
+	data, err := MarshalEventJSON(e)
+	if err != nil {
+		j.cfg.OnError(ctx, fmt.Errorf("could not marshal journal event: %w", err))
+		return
+	}
+	data = append(data, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	err = j.write(data)
+	if err != nil {
+		j.cfg.OnError(ctx, err)
+	}
+}
+
+// write appends the data to the journal file, the journal needs to be locked.
+func (j *FileJournal) write(data []byte) error {
+	if j.closed {
+		return nil
+	}
+
+	if j.f == nil {
+		err := j.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	if j.size > 0 && j.size+int64(len(data)) > j.cfg.MaxSize {
+		err := j.rotate()
+		if err != nil {
+			return fmt.Errorf("could not rotate journal file: %w", err)
+		}
+	}
+
+	n, err := j.f.Write(data)
+	j.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("could not write journal file: %w", err)
+	}
+
+	if j.cfg.Sync {
+		err := j.f.Sync()
+		if err != nil {
+			return fmt.Errorf("could not sync journal file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the journal file.
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.closed = true
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+
+	return err
+}
+
+func (j *FileJournal) open() error {
+	f, err := os.OpenFile(j.cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open journal file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("could not stat journal file: %w", err)
+	}
+
+	j.f = f
+	j.size = info.Size()
+
+	return nil
+}
+
+// rotate shifts the rotated files, removing the oldest one, and starts a new
+// journal file. If it fails the journal file is closed, to be reopened on the
+// next write.
+func (j *FileJournal) rotate() error {
+	err := j.f.Close()
+	j.f = nil
+	if err != nil {
+		return err
+	}
+
+	for i := j.cfg.MaxFiles; i > 0; i-- {
+		src := rotatedJournalPath(j.cfg.Path, i-1)
+		err := os.Rename(src, rotatedJournalPath(j.cfg.Path, i))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return j.open()
+}
+
+// rotatedJournalPath returns the path of the n rotated journal file, 0 is the
+// current journal file.
+func rotatedJournalPath(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return path + "." + strconv.Itoa(n)
+}
+
+// ReadJournal reads the events of a FileJournal journal, including the rotated
+// files, from the oldest to the newest. The events errors are read as plain
+// errors with the same message.
+//
+// The journal can be read while it's being written, and the partially written
+// last line of a crashed process is ignored.
+func ReadJournal(path string) ([]Event, error) {
+	var files []string
+	for i := 1; ; i++ {
+		p := rotatedJournalPath(path, i)
+		_, err := os.Stat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		files = append([]string{p}, files...)
+	}
+	files = append(files, path)
+
+	var events []Event
+	for _, p := range files {
+		fileEvents, err := readJournalFile(p)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, fileEvents...)
+	}
+
+	return events, nil
+}
+
+func readJournalFile(path string) ([]Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read journal file: %w", err)
+	}
+
+	var events []Event
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for line := 1; s.Scan(); line++ {
+		var je jsonEvent
+		err := json.Unmarshal(s.Bytes(), &je)
+		if err != nil {
+			// The last line without new line was being written.
+			if !bytes.HasSuffix(data, []byte("\n")) && bytes.HasSuffix(data, s.Bytes()) {
+				break
+			}
+			return nil, fmt.Errorf("invalid %q journal file line %d: %w", path, line, err)
+		}
+		events = append(events, je.event())
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("could not read journal file: %w", err)
+	}
+
+	return events, nil
+}
+
+// event returns the event of the JSON representation.
+func (je jsonEvent) event() Event {
+	e := Event{
+		Type: je.Type,
+		Time: je.Time,
+		Trigger: TriggerEvent{
+			ID:          je.TriggerID,
+			Source:      je.TriggerSource,
+			Reason:      je.TriggerReason,
+			Paths:       je.TriggerPaths,
+			Keys:        je.TriggerKeys,
+			Metadata:    je.TriggerMetadata,
+			Urgency:     je.TriggerUrgency,
+			TagSelector: je.TriggerTagSelector,
+		},
+		Reloader:      je.Reloader,
+		Notifier:      je.Notifier,
+		Reloaders:     je.Reloaders,
+		Attempt:       je.Attempt,
+		ApprovalToken: je.ApprovalToken,
+		Pipelines:     je.Pipelines,
+		Retrying:      je.Retrying,
+	}
+
+	if je.Priority != nil {
+		e.Priority = *je.Priority
+	}
+
+	if je.DurationSeconds != nil {
+		e.Duration = time.Duration(math.Round(*je.DurationSeconds * float64(time.Second)))
+	}
+
+	if je.Error != "" {
+		e.Err = errors.New(je.Error)
+	}
+
+	return e
+}
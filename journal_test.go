@@ -0,0 +1,155 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestFileJournal(t *testing.T) {
+	at := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	t1 := reload.TriggerEvent{ID: "t1", Source: "file", Paths: []string{"/tmp/a.json"}, Metadata: map[string]string{"reason": "deploy-123"}, Urgency: reload.TriggerUrgencyUrgent}
+	t2 := reload.TriggerEvent{ID: "t2", Source: "signal"}
+	events := []reload.Event{
+		{Type: reload.EventTriggerReceived, Time: at, Trigger: t1},
+		{Type: reload.EventReloadStarted, Time: at, Trigger: t1},
+		{Type: reload.EventReloaderFinished, Time: at, Trigger: t1, Reloader: "config", Duration: time.Second},
+		{Type: reload.EventReloadFinished, Time: at.Add(time.Second), Trigger: t1, Duration: 1500 * time.Millisecond, Err: fmt.Errorf("something")},
+		{Type: reload.EventTriggerReceived, Time: at.Add(time.Minute), Trigger: t2},
+		{Type: reload.EventReloadFinished, Time: at.Add(time.Minute), Trigger: t2, Duration: time.Second},
+	}
+
+	tests := map[string]struct {
+		cfg       reload.FileJournalConfig
+		expEvents []reload.Event
+		expFiles  []string
+	}{
+		"The trigger and reload events should be journaled.": {
+			cfg: reload.FileJournalConfig{},
+			expEvents: []reload.Event{
+				{Type: reload.EventTriggerReceived, Time: at, Trigger: t1},
+				{Type: reload.EventReloadStarted, Time: at, Trigger: t1},
+				{Type: reload.EventReloadFinished, Time: at.Add(time.Second), Trigger: t1, Duration: 1500 * time.Millisecond, Err: fmt.Errorf("something")},
+				{Type: reload.EventTriggerReceived, Time: at.Add(time.Minute), Trigger: t2},
+				{Type: reload.EventReloadFinished, Time: at.Add(time.Minute), Trigger: t2, Duration: time.Second},
+			},
+			expFiles: []string{"journal"},
+		},
+
+		"The journaled events should be configurable.": {
+			cfg: reload.FileJournalConfig{Events: []reload.EventType{reload.EventReloaderFinished}},
+			expEvents: []reload.Event{
+				{Type: reload.EventReloaderFinished, Time: at, Trigger: t1, Reloader: "config", Duration: time.Second},
+			},
+			expFiles: []string{"journal"},
+		},
+
+		"The journal should be rotated keeping the newest events.": {
+			cfg: reload.FileJournalConfig{MaxSize: 1, MaxFiles: 2},
+			expEvents: []reload.Event{
+				{Type: reload.EventReloadFinished, Time: at.Add(time.Second), Trigger: t1, Duration: 1500 * time.Millisecond, Err: fmt.Errorf("something")},
+				{Type: reload.EventTriggerReceived, Time: at.Add(time.Minute), Trigger: t2},
+				{Type: reload.EventReloadFinished, Time: at.Add(time.Minute), Trigger: t2, Duration: time.Second},
+			},
+			expFiles: []string{"journal", "journal.1", "journal.2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			dir := t.TempDir()
+			test.cfg.Path = filepath.Join(dir, "journal")
+			j, err := reload.NewFileJournal(test.cfg)
+			require.NoError(err)
+
+			// Execute.
+			for _, e := range events {
+				j.HandleEvent(context.TODO(), e)
+			}
+			require.NoError(j.Close())
+
+			// Check.
+			got, err := reload.ReadJournal(test.cfg.Path)
+			require.NoError(err)
+			assert.Equal(test.expEvents, got)
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(err)
+			var files []string
+			for _, e := range entries {
+				files = append(files, e.Name())
+			}
+			assert.Equal(test.expFiles, files)
+		})
+	}
+}
+
+func TestFileJournalRotateError(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	at := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "journal")
+	var gotErrs []error
+	j, err := reload.NewFileJournal(reload.FileJournalConfig{
+		Path:     path,
+		MaxSize:  1,
+		MaxFiles: 1,
+		OnError:  func(ctx context.Context, err error) { gotErrs = append(gotErrs, err) },
+	})
+	require.NoError(err)
+	defer j.Close()
+
+	// A non empty directory where the journal is rotated makes the rotation fail.
+	require.NoError(os.MkdirAll(filepath.Join(path+".1", "blocked"), 0o700))
+
+	// Execute.
+	j.HandleEvent(context.TODO(), reload.Event{Type: reload.EventTriggerReceived, Time: at, Trigger: reload.TriggerEvent{ID: "t1"}})
+	j.HandleEvent(context.TODO(), reload.Event{Type: reload.EventTriggerReceived, Time: at, Trigger: reload.TriggerEvent{ID: "t2"}})
+	require.NoError(os.RemoveAll(path + ".1"))
+	j.HandleEvent(context.TODO(), reload.Event{Type: reload.EventTriggerReceived, Time: at, Trigger: reload.TriggerEvent{ID: "t3"}})
+
+	// Check.
+	require.Len(gotErrs, 1)
+	assert.ErrorContains(gotErrs[0], "could not rotate journal file")
+	got, err := reload.ReadJournal(path)
+	require.NoError(err)
+	assert.Equal([]reload.Event{
+		{Type: reload.EventTriggerReceived, Time: at, Trigger: reload.TriggerEvent{ID: "t1"}},
+		{Type: reload.EventTriggerReceived, Time: at, Trigger: reload.TriggerEvent{ID: "t3"}},
+	}, got)
+}
+
+func TestReadJournalCrashed(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "journal")
+	data := `{"type":"trigger_received","time":"2021-07-19T10:00:00Z","trigger_id":"t1","trigger_source":"file"}` + "\n" +
+		`{"type":"reload_started","time":"2021-07-19T10:00:00Z","trigger_id":"t1","trigger_sou`
+	require.NoError(os.WriteFile(path, []byte(data), 0o600))
+
+	// Execute.
+	got, err := reload.ReadJournal(path)
+
+	// Check.
+	require.NoError(err)
+	assert.Equal([]reload.Event{
+		{Type: reload.EventTriggerReceived, Time: time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC), Trigger: reload.TriggerEvent{ID: "t1", Source: "file"}},
+	}, got)
+}
@@ -0,0 +1,81 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+)
+
+// LeaderChecker knows if the current process holds the leadership (e.g: a
+// leader lease).
+type LeaderChecker interface {
+	IsLeader() bool
+}
+
+// LeaderCheckerFunc is a helper to create leader checkers from functions.
+type LeaderCheckerFunc func() bool
+
+// IsLeader satisfies LeaderChecker interface.
+func (l LeaderCheckerFunc) IsLeader() bool { return l() }
+
+// LeaderNotifierConfig is the configuration of the LeaderNotifier.
+type LeaderNotifierConfig struct {
+	// Notifier is the wrapped notifier.
+	Notifier Notifier
+	// Leader is the checker used to know if the process is the leader.
+	Leader LeaderChecker
+}
+
+func (c *LeaderNotifierConfig) defaults() error {
+	if c.Notifier == nil {
+		return fmt.Errorf("notifier is required")
+	}
+
+	if c.Leader == nil {
+		return fmt.Errorf("leader checker is required")
+	}
+
+	return nil
+}
+
+// LeaderNotifier is a notifier that wraps a notifier and suppresses its
+// triggers unless the process is the leader when the trigger happens. Useful
+// on deployments where only the leader should execute an expensive reload (e.g:
+// reload and propagate the configuration to the rest of the replicas).
+type LeaderNotifier struct {
+	cfg LeaderNotifierConfig
+}
+
+// NewLeaderNotifier returns a new LeaderNotifier.
+func NewLeaderNotifier(cfg LeaderNotifierConfig) (*LeaderNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &LeaderNotifier{cfg: cfg}, nil
+}
+
+// Notify satisfies Notifier interface.
+func (l *LeaderNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := l.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies TriggerNotifier interface.
+func (l *LeaderNotifier) NotifyTrigger(ctx context.Context) (TriggerEvent, error) {
+	for {
+		t, err := notifyTrigger(ctx, l.cfg.Notifier)
+		if err != nil {
+			return TriggerEvent{}, err
+		}
+
+		if l.cfg.Leader.IsLeader() {
+			return t, nil
+		}
+
+		// Not the leader, drop the trigger and wait for the next one.
+		if ctx.Err() != nil {
+			return TriggerEvent{}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package reload_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestLeaderNotifier(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	var leader atomic.Bool
+	notifierC := make(chan reload.TriggerEvent)
+	n, err := reload.NewLeaderNotifier(reload.LeaderNotifierConfig{
+		Notifier: testTriggerNotifier{c: notifierC},
+		Leader:   reload.LeaderCheckerFunc(leader.Load),
+	})
+	require.NoError(err)
+
+	var gotIDs []string
+	m := reload.NewManager()
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		gotIDs = append(gotIDs, id)
+		return nil
+	}))
+	m.On(n)
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runFinished := make(chan error)
+	go func() { runFinished <- m.Run(ctx) }()
+	notifierC <- reload.TriggerEvent{ID: "not-leader-1"}
+	leader.Store(true)
+	notifierC <- reload.TriggerEvent{ID: "leader-1"}
+	time.Sleep(10 * time.Millisecond)
+	leader.Store(false)
+	notifierC <- reload.TriggerEvent{ID: "not-leader-2"}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// Check.
+	assert.NoError(<-runFinished)
+	assert.Equal([]string{"leader-1"}, gotIDs)
+}
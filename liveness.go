@@ -0,0 +1,210 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotifierStale is set on EventNotifierStale when a notifier doesn't
+// trigger or heartbeat within the liveness period.
+var ErrNotifierStale = errors.New("notifier stale")
+
+// NotifierLiveness is the configuration of the notifier liveness detection
+// (see WithNotifierLiveness).
+type NotifierLiveness struct {
+	// Period is the maximum time the notifier can be waiting without a trigger
+	// or a heartbeat (see NotifierHeartbeat) before being flagged as stale.
+	// By default 5m.
+	Period time.Duration
+	// Restart restarts the stale notifiers, the context of the waiting notifier
+	// is cancelled, its result discarded and the notifier called again with a
+	// new context. Otherwise the notifier calls share the same context, so the
+	// streams bound to it are kept between the triggers.
+	Restart bool
+}
+
+func (n *NotifierLiveness) defaults() {
+	if n.Period <= 0 {
+		n.Period = 5 * time.Minute
+	}
+}
+
+// NotifierHeartbeat signals the manager that the notifier is alive even if it
+// doesn't trigger (e.g: a watch stream received a keepalive), so it's not
+// flagged as stale (see WithNotifierLiveness). The context is the one received
+// by the notifier, it's a no-op on other contexts.
+func NotifierHeartbeat(ctx context.Context) {
+	if beat, ok := ctx.Value(heartbeatContextKey).(func()); ok {
+		beat()
+	}
+}
+
+// notifierLiveness tracks the liveness of a running notifier.
+type notifierLiveness struct {
+	cfg  NotifierLiveness
+	m    *Manager
+	name string
+
+	mu        sync.Mutex
+	last      time.Time
+	waiting   bool
+	stale     bool
+	notifyCtx context.Context
+	cancel    context.CancelFunc
+	restarted bool
+}
+
+func (m *Manager) newNotifierLiveness(n registeredNotifier) *notifierLiveness {
+	if n.liveness == nil {
+		return nil
+	}
+
+	return &notifierLiveness{cfg: *n.liveness, m: m, name: n.name}
+}
+
+// start starts the liveness detection, the returned function stops it.
+func (l *notifierLiveness) start(ctx context.Context) (stop func()) {
+	if l == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.cancel != nil {
+			l.cancel()
+		}
+	}
+}
+
+// wait returns the context for a notifier call. The context lives for the
+// notifier lifetime, it's only replaced when the notifier is restarted, so the
+// notifiers that bind their streams to it (e.g: a server-sent events
+// connection) keep them between calls.
+func (l *notifierLiveness) wait(ctx context.Context) context.Context {
+	if l == nil {
+		return ctx
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.notifyCtx == nil {
+		notifyCtx, cancel := context.WithCancel(ctx)
+		l.notifyCtx = context.WithValue(notifyCtx, heartbeatContextKey, func() { l.beat(ctx) })
+		l.cancel = cancel
+	}
+	l.last = l.m.cfg.clock.Now()
+	l.waiting = true
+	l.restarted = false
+
+	return l.notifyCtx
+}
+
+// done ends a notifier call, returns true if the notifier has been restarted
+// and its result needs to be discarded.
+func (l *notifierLiveness) done(ctx context.Context) (restarted bool) {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	l.waiting = false
+	restarted = l.restarted
+	if restarted {
+		// The restart cancelled the context, the next call needs a new one.
+		l.notifyCtx = nil
+	}
+	l.mu.Unlock()
+
+	if !restarted {
+		l.beat(ctx)
+	}
+
+	return restarted
+}
+
+// beat records the notifier is alive.
+func (l *notifierLiveness) beat(ctx context.Context) {
+	l.mu.Lock()
+	l.last = l.m.cfg.clock.Now()
+	wasStale := l.stale
+	l.stale = false
+	l.mu.Unlock()
+
+	if wasStale {
+		l.m.stale.remove(l.name)
+		l.m.cfg.metricsRecorder.SetNotifierStale(ctx, l.name, false)
+		l.m.emit(ctx, Event{Type: EventNotifierAlive, Notifier: l.name})
+	}
+}
+
+// run checks the notifier liveness until the context ends.
+func (l *notifierLiveness) run(ctx context.Context) {
+	defer func() {
+		l.mu.Lock()
+		wasStale := l.stale
+		l.stale = false
+		l.mu.Unlock()
+		if wasStale {
+			l.m.stale.remove(l.name)
+			l.m.cfg.metricsRecorder.SetNotifierStale(context.WithoutCancel(ctx), l.name, false)
+		}
+	}()
+
+	for {
+		l.mu.Lock()
+		// Once flagged, only the restarts need to be checked.
+		wait := l.cfg.Period
+		if l.waiting && (!l.stale || l.cfg.Restart) {
+			wait -= l.m.cfg.clock.Now().Sub(l.last)
+		}
+		l.mu.Unlock()
+
+		if wait > 0 {
+			t := l.m.cfg.clock.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C():
+			}
+		}
+
+		l.mu.Lock()
+		if !l.waiting || (l.stale && !l.cfg.Restart) || l.m.cfg.clock.Now().Sub(l.last) < l.cfg.Period {
+			l.mu.Unlock()
+			continue
+		}
+		flag := !l.stale
+		l.stale = true
+		if l.cfg.Restart {
+			l.restarted = true
+			l.cancel()
+			// Wait a full period for the restarted notifier.
+			l.last = l.m.cfg.clock.Now()
+		}
+		l.mu.Unlock()
+
+		if flag {
+			l.m.stale.add(l.name)
+			l.m.cfg.metricsRecorder.SetNotifierStale(ctx, l.name, true)
+			l.m.emit(ctx, Event{Type: EventNotifierStale, Notifier: l.name, Err: fmt.Errorf("%w: no triggers or heartbeats in %s", ErrNotifierStale, l.cfg.Period)})
+		}
+		if l.cfg.Restart {
+			l.m.emit(ctx, Event{Type: EventNotifierRestarted, Notifier: l.name})
+		}
+	}
+}
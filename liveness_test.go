@@ -0,0 +1,103 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerNotifierLiveness(t *testing.T) {
+	tests := map[string]struct {
+		liveness reload.NotifierLiveness
+		expTrail []string
+	}{
+		"A notifier without triggers or heartbeats should be flagged as stale until it triggers.": {
+			liveness: reload.NotifierLiveness{Period: time.Minute},
+			expTrail: []string{
+				"notifier_stale id= notifier=watch err=notifier stale: no triggers or heartbeats in 1m0s",
+				"notifier_alive id= notifier=watch",
+			},
+		},
+
+		"A stale notifier should be restarted if enabled until it heartbeats.": {
+			liveness: reload.NotifierLiveness{Period: time.Minute, Restart: true},
+			expTrail: []string{
+				"notifier_stale id= notifier=watch err=notifier stale: no triggers or heartbeats in 1m0s",
+				"notifier_restarted id= notifier=watch",
+				"notifier_alive id= notifier=watch",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Now())
+			rec := reloadtest.NewRecorder()
+			stale := make(chan struct{})
+			alive := make(chan struct{})
+			m := reload.NewManager(
+				reload.WithClock(clock),
+				reload.WithSubscriber(rec),
+				reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+					switch e.Type {
+					case reload.EventNotifierStale:
+						close(stale)
+					case reload.EventNotifierAlive:
+						close(alive)
+					}
+				})),
+			)
+			release := make(chan struct{})
+			calls := 0
+			m.On(reload.NotifierFunc(func(ctx context.Context) (string, error) {
+				calls++
+				if calls == 1 {
+					select {
+					case <-ctx.Done():
+						return "", ctx.Err()
+					case <-release:
+						return "t0", nil
+					}
+				}
+				<-release
+				reload.NotifierHeartbeat(ctx)
+				<-ctx.Done()
+				return "", ctx.Err()
+			}), reload.WithNotifierName("watch"), reload.WithNotifierLiveness(test.liveness))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			require.True(clock.WaitWaiters(1, time.Second))
+			clock.Advance(time.Minute)
+			<-stale
+			assert.Equal([]string{"watch"}, m.Status().StaleNotifiers)
+			close(release)
+			<-alive
+			cancel()
+
+			// Check.
+			assert.NoError(<-runFinished)
+			assert.Empty(m.Status().StaleNotifiers)
+			var gotTrail []string
+			for _, e := range rec.Events() {
+				switch e.Type {
+				case reload.EventNotifierStale, reload.EventNotifierRestarted, reload.EventNotifierAlive:
+					gotTrail = append(gotTrail, reloadtest.TrailLine(e))
+				}
+			}
+			assert.Equal(test.expTrail, gotTrail)
+		})
+	}
+}
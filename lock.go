@@ -0,0 +1,14 @@
+package reload
+
+import (
+	"context"
+)
+
+// DistributedLocker knows how to acquire and release a lock shared by all the
+// replicas of a service (e.g: etcd, Consul or a Kubernetes lease).
+type DistributedLocker interface {
+	// Lock blocks until the lock is acquired or the context is cancelled.
+	Lock(ctx context.Context) error
+	// Unlock releases the lock.
+	Unlock(ctx context.Context) error
+}
@@ -0,0 +1,131 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+// testLocker is a distributed lock shared by multiple managers.
+type testLocker struct {
+	mu      sync.Mutex
+	held    bool
+	lockErr error
+	calls   []string
+}
+
+func (t *testLocker) Lock(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		if t.lockErr != nil {
+			t.mu.Unlock()
+			return t.lockErr
+		}
+		if !t.held {
+			t.held = true
+			t.calls = append(t.calls, "lock")
+			t.mu.Unlock()
+			return nil
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (t *testLocker) Unlock(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.held = false
+	t.calls = append(t.calls, "unlock")
+	return nil
+}
+
+func TestManagerDistributedLocker(t *testing.T) {
+	tests := map[string]struct {
+		lockErr    error
+		expCalls   []string
+		expReloads int
+		expErr     bool
+	}{
+		"The reloads of the replicas should be serialized.": {
+			expCalls:   []string{"lock", "unlock", "lock", "unlock"},
+			expReloads: 2,
+		},
+
+		"If the lock can't be acquired, the reload should fail.": {
+			lockErr:  fmt.Errorf("something"),
+			expErr:   true,
+			expCalls: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			locker := &testLocker{lockErr: test.lockErr}
+			var mu sync.Mutex
+			running, reloads, overlapped := 0, 0, false
+			reloader := reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				mu.Lock()
+				running++
+				overlapped = overlapped || running > 1
+				mu.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				reloads++
+				mu.Unlock()
+				return nil
+			})
+
+			// Two replicas sharing the lock.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var wg sync.WaitGroup
+			errs := make(chan error, 2)
+			for range 2 {
+				m := reload.NewManager(reload.WithDistributedLocker(locker))
+				m.Add(0, reloader)
+				notifierC := make(chan string, 1)
+				notifierC <- "test-id"
+				m.On(reload.NotifierChan(notifierC))
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- m.Run(ctx)
+				}()
+			}
+
+			// Execute.
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+			wg.Wait()
+			close(errs)
+
+			// Check.
+			gotErr := false
+			for err := range errs {
+				gotErr = gotErr || err != nil
+			}
+			assert.Equal(test.expErr, gotErr)
+			assert.False(overlapped)
+			assert.Equal(test.expReloads, reloads)
+			assert.Equal(test.expCalls, locker.calls)
+		})
+	}
+}
@@ -2,22 +2,99 @@ package reload
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
 	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
-
-	"golang.org/x/sync/errgroup"
+	"time"
 )
 
 type reloaderGroup struct {
 	priority  int
-	reloaders []Reloader
+	reloaders []registeredReloader
+}
+
+type registeredReloader struct {
+	reloader Reloader
+	name     string
+	sources  []string
+	timeout  time.Duration
+	breaker  *circuitBreaker
+	pipeline string
+	weight   int
+	tags     []string
+}
+
+// matchesSource returns if the reloader needs to be reloaded by the trigger source.
+func (r registeredReloader) matchesSource(source string) bool {
+	return len(r.sources) == 0 || matchesAny(r.sources, source)
+}
+
+// matchesAny returns if any of the `path.Match` patterns matches the value.
+func matchesAny(patterns []string, v string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, v); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forSource returns the group with only the reloaders of the trigger source.
+func (rg reloaderGroup) forSource(source string) reloaderGroup {
+	res := reloaderGroup{priority: rg.priority}
+	for _, r := range rg.reloaders {
+		if r.matchesSource(source) {
+			res.reloaders = append(res.reloaders, r)
+		}
+	}
+
+	return res
+}
+
+// forTags returns the group with only the reloaders selected by the tag
+// expression.
+func (rg reloaderGroup) forTags(selector TagExpression) reloaderGroup {
+	res := reloaderGroup{priority: rg.priority}
+	for _, r := range rg.reloaders {
+		if selector.Match(r.tags) {
+			res.reloaders = append(res.reloaders, r)
+		}
+	}
+
+	return res
+}
+
+// names returns the names of the group reloaders in execution order.
+func (rg reloaderGroup) names() []string {
+	names := make([]string, 0, len(rg.reloaders))
+	for _, r := range rg.reloaders {
+		names = append(names, r.name)
+	}
+
+	return names
 }
 
 // NewManager returns a new manager.
-func NewManager() Manager {
+func NewManager(opts ...ManagerOption) Manager {
+	cfg := newManagerConfig(opts)
 	return Manager{
-		reloaders: map[int]reloaderGroup{},
+		cfg:           cfg,
+		reloaders:     map[int]reloaderGroup{},
+		abandoned:     newNameCounter(),
+		quarantined:   newNameCounter(),
+		stale:         newNameCounter(),
+		locks:         newPipelineLocks(),
+		settings:      newSettings(cfg),
+		approvals:     &approvals{},
+		subscriptions: &subscriptions{},
+		pending:       newPendingMarker(cfg.pendingTriggerFile),
+		stats:         newStatsTracker(),
+		toggles:       newReloaderToggles(),
 	}
 }
 
@@ -26,9 +103,52 @@ func NewManager() Manager {
 // when this process is triggered it will call to all the reloaders
 // based on the priority groups.
 type Manager struct {
+	cfg       managerConfig
 	reloaders map[int]reloaderGroup
-	notifiers []Notifier
-	lock      uint32 // Mutex based on atomic integer.
+	notifiers []registeredNotifier
+	running   uint32 // Run state based on atomic integer.
+	// locks are the in-progress locks of the pipelines.
+	locks *pipelineLocks
+	// generation is the generation of the last successful reload, and
+	// reserved the last generation reserved by a reload process, changed
+	// atomically as the reloads of different pipelines can run concurrently.
+	generation uint64
+	reserved   uint64
+	// abandoned are the reloaders that exceeded their timeout and didn't
+	// return yet.
+	abandoned *nameCounter
+	// quarantined are the notifiers quarantined by their breaker.
+	quarantined *nameCounter
+	// stale are the notifiers flagged as stale by their liveness check.
+	stale *nameCounter
+	// settings are the runtime tunable settings.
+	settings *atomic.Pointer[Settings]
+	// approvals track the trigger pending approval.
+	approvals *approvals
+	// subscriptions are the subscribers added with Subscribe.
+	subscriptions *subscriptions
+	// pending persists the pending notifier triggers, nil if disabled.
+	pending *pendingMarker
+	// stats track the cumulative stats.
+	stats *statsTracker
+	// toggles are the reloaders disabled at runtime.
+	toggles *reloaderToggles
+}
+
+type registeredNotifier struct {
+	notifier  Notifier
+	name      string
+	breaker   *NotifierBreaker
+	metadata  map[string]string
+	verifier  TriggerVerifier
+	rateLimit *NotifierRateLimit
+	liveness  *NotifierLiveness
+	// queueOverflow is the trigger queue overflow policy, if empty the
+	// manager one.
+	queueOverflow QueueOverflowPolicy
+	urgency       TriggerUrgency
+	trustUrgency  bool
+	reason        string
 }
 
 // On registers a notifier that will execute all reloaders when
@@ -42,8 +162,13 @@ type Manager struct {
 // already waiting.
 //
 // This process will be repeated forever until the manager stops.
-func (m *Manager) On(n Notifier) {
-	m.notifiers = append(m.notifiers, n)
+func (m *Manager) On(n Notifier, opts ...NotifierOption) {
+	cfg := notifierConfig{name: fmt.Sprintf("notifier-%d", len(m.notifiers))}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	m.notifiers = append(m.notifiers, registeredNotifier{notifier: n, name: cfg.name, breaker: cfg.breaker, metadata: cfg.metadata, verifier: cfg.verifier, rateLimit: cfg.rateLimit, liveness: cfg.liveness, queueOverflow: cfg.queueOverflow, urgency: cfg.urgency, trustUrgency: cfg.trustUrgency, reason: cfg.reason})
 }
 
 // Add a reloader to the manager.
@@ -57,20 +182,107 @@ func (m *Manager) On(n Notifier) {
 // executed.
 //
 // The priority order is ascendant (e.g 0, 42, 100, 250, 999...).
-func (m *Manager) Add(priority int, r Reloader) {
+func (m *Manager) Add(priority int, r Reloader, opts ...ReloaderOption) {
+	cfg := reloaderConfig{name: fmt.Sprintf("reloader-%d", m.reloaderCount())}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	rg, ok := m.reloaders[priority]
 	if !ok {
 		rg = reloaderGroup{priority: priority}
 	}
-	rg.reloaders = append(rg.reloaders, r)
+	rg.reloaders = append(rg.reloaders, registeredReloader{reloader: r, name: cfg.name, sources: cfg.sources, timeout: cfg.timeout, breaker: cfg.breaker(), pipeline: cfg.pipeline, weight: cfg.weight, tags: cfg.tags})
+	// Keep the group in execution order, by weight and then registration order.
+	sort.SliceStable(rg.reloaders, func(i, j int) bool { return rg.reloaders[i].weight < rg.reloaders[j].weight })
 	m.reloaders[priority] = rg
 }
 
+// sortedGroups returns the reloader groups in execution order.
+func (m *Manager) sortedGroups() []reloaderGroup {
+	groups := make([]reloaderGroup, 0, len(m.reloaders))
+	for _, rg := range m.reloaders {
+		groups = append(groups, rg)
+	}
+	sort.Slice(groups, func(x, y int) bool { return groups[x].priority < groups[y].priority })
+
+	return groups
+}
+
+func (m *Manager) reloaderCount() int {
+	n := 0
+	for _, rg := range m.reloaders {
+		n += len(rg.reloaders)
+	}
+
+	return n
+}
+
 type notifierResult struct {
-	Result string
-	Err    error
+	Trigger TriggerEvent
+	Err     error
+	// At is when the notifier triggered.
+	At time.Time
+	// VerifyErr is the trigger verification error (see WithNotifierVerifier).
+	VerifyErr error
+	// Received is set once the trigger reception has been handled, so the
+	// triggers that are scheduled again (e.g: deferred by the reload window)
+	// are not received twice.
+	Received bool
+	// Pending are the pending marker numbers of the trigger and the triggers
+	// collapsed into it (see WithPendingTriggerFile).
+	Pending []uint64
+}
+
+// ErrAlreadyRunning is returned by Run when the manager is already running.
+var ErrAlreadyRunning = errors.New("manager already running")
+
+// ErrReloadInProgress is returned when a reload can't start because other
+// reload is in progress, the returned errors are ReloadInProgressError.
+var ErrReloadInProgress = errors.New("reload in progress")
+
+// ReloadInProgressError is the error returned when a reload can't start
+// because other reload is in progress, it has the in-flight reload information
+// so the callers can decide to retry or wait.
+type ReloadInProgressError struct {
+	// TriggerID is the trigger ID of the in-flight reload.
+	TriggerID string
+	// StartedAt is when the in-flight reload started.
+	StartedAt time.Time
+}
+
+func (e *ReloadInProgressError) Error() string {
+	return fmt.Sprintf("%s: trigger %q started at %s", ErrReloadInProgress, e.TriggerID, e.StartedAt.Format(time.RFC3339Nano))
+}
+
+// Is satisfies errors.Is, so ErrReloadInProgress can be used to check the error.
+func (e *ReloadInProgressError) Is(target error) bool { return target == ErrReloadInProgress }
+
+// TriggerReload triggers a reload process manually and waits until it ends,
+// it can be used with the manager running or not. If the trigger source is
+// empty, `manual` will be used.
+//
+// If other reload of the same pipelines is in progress (see WithPipeline), the
+// reload is not executed and it returns a ReloadInProgressError
+// (ErrReloadInProgress). If the reload fails, the error is a ReloadError with
+// the status of the reloaders.
+func (m *Manager) TriggerReload(ctx context.Context, t TriggerEvent) error {
+	if t.Source == "" {
+		t.Source = "manual"
+	}
+
+	m.emit(ctx, Event{Type: EventTriggerReceived, Trigger: t})
+	m.cfg.metricsRecorder.IncTriggerReceived(ctx, t.Source)
+
+	return m.reloadGroups(ctx, t, false)
 }
 
+// ErrNotifierStopTimeout is returned by Run when the notifiers don't stop
+// before the stop timeout after the manager stops (see
+// WithNotifierStopTimeout), normally because they don't handle the context
+// cancellation.
+var ErrNotifierStopTimeout = errors.New("notifiers did not stop")
+
 // Run will start the manager. This starts all the notifiers and wait until
 // any of them returns a result, then it will call the notifiers in priority
 // batches. All the triggered notifiers will start again.
@@ -80,55 +292,390 @@ type notifierResult struct {
 //
 // If the context is cancelled, the manager Run will end without error.
 // If any of the reloaders reload process ends with an error, run will
-// end its execution and return an error.
-func (m *Manager) Run(ctx context.Context) error {
-	signal := make(chan notifierResult, len(m.notifiers))
+// end its execution and return an error wrapping a ReloadError.
+//
+// Before returning, Run stops all the notifiers cancelling their context. With
+// a notifier stop timeout (see WithNotifierStopTimeout) it also waits until
+// they end, so no notifier goroutine is left running, and if they don't end
+// before the timeout Run will return an error wrapping ErrNotifierStopTimeout
+// instead of ending without error.
+//
+// The manager can be run again once Run returns (e.g: restarted by a
+// supervisor), the notifiers and reloaders are reused. Calling Run while the
+// manager is running returns ErrAlreadyRunning.
+func (m *Manager) Run(ctx context.Context) (err error) {
+	if !atomic.CompareAndSwapUint32(&m.running, unlockedState, lockedState) {
+		return ErrAlreadyRunning
+	}
+	defer atomic.StoreUint32(&m.running, unlockedState)
+
+	// Reload the pending triggers of the previous run first.
+	recovered, dropped, err := m.pending.recover(m.cfg.clock.Now())
+	if err != nil {
+		return err
+	}
+	for _, t := range dropped {
+		m.dropTrigger(ctx, t, DropReasonRecoveryLimit, ErrPendingTriggerRecoveryLimit)
+	}
+
+	queueSize := m.cfg.queueSize
+	if queueSize <= 0 {
+		queueSize = len(m.notifiers)
+	}
+	queueSize += len(recovered)
+	signal := make(chan notifierResult, queueSize)
+	for _, res := range recovered {
+		signal <- res
+	}
 	ctx, cancel := context.WithCancel(ctx)
-	defer cancel() // This will stop all running notifiers.
+	var wg sync.WaitGroup
+	var runningNotifiers atomic.Int64
+	defer func() {
+		// Stop all running notifiers and wait for them.
+		cancel()
+		if m.cfg.notifierStopTimeout <= 0 {
+			return
+		}
+		stopErr := waitNotifiers(m.cfg.clock, &wg, &runningNotifiers, m.cfg.notifierStopTimeout)
+		if stopErr != nil {
+			err = errors.Join(err, stopErr)
+		}
+	}()
 
 	// Run all notifiers and wait for any of them sends a signal signals.
 	for _, n := range m.notifiers {
-		go func(n Notifier) {
+		wg.Add(1)
+		runningNotifiers.Add(1)
+		go func(n registeredNotifier) {
+			defer func() {
+				runningNotifiers.Add(-1)
+				wg.Done()
+			}()
+
 			// Prepare notifier to be executed and map results to
 			// our internal notification result.
 			fn := func(ctx context.Context) notifierResult {
-				res, err := n.Notify(ctx)
-				return notifierResult{Result: res, Err: err}
+				t, err := notifyTrigger(ctx, n.notifier)
+				t.Source = n.name
+				// Verify the trigger as sent, without the notifier urgency,
+				// reason and metadata.
+				var verifyErr error
+				if err == nil && n.verifier != nil {
+					verifyErr = n.verifier.VerifyTrigger(ContextWithClock(ctx, m.cfg.clock), t)
+				}
+				if !n.trustUrgency || t.Urgency == TriggerUrgencyNormal {
+					t.Urgency = n.urgency
+				}
+				if t.Reason == "" {
+					t.Reason = n.reason
+				}
+				t.Metadata = mergeMetadata(n.metadata, t.Metadata)
+				return notifierResult{Trigger: t, Err: err, At: m.cfg.clock.Now(), VerifyErr: verifyErr}
 			}
 			// Notifiers will rerun once they end executing and
 			// notify. This will be forever or until the context
 			// ends.
+			breaker := newNotifierBreaker(n.breaker)
+			limiter := newNotifierRateLimiter(n.rateLimit)
+			// The limited triggers trail the notifier until it ends.
+			trailCtx, stopTrail := context.WithCancel(ctx)
+			trailDone := make(chan struct{})
+			go func() {
+				defer close(trailDone)
+				limiter.trail(trailCtx, m.cfg.clock, func(res notifierResult) bool {
+					res.Pending = m.pending.mark(res.Trigger, res.At)
+					return m.enqueue(trailCtx, signal, res, n.queueOverflow)
+				})
+			}()
+			defer func() {
+				stopTrail()
+				<-trailDone
+			}()
+			live := m.newNotifierLiveness(n)
+			stopLive := live.start(ctx)
+			defer stopLive()
 			for {
-				select {
-				case signal <- fn(ctx):
-				case <-ctx.Done():
+				res := fn(live.wait(ctx))
+
+				// Notifiers that end because the context has been cancelled
+				// are not triggers.
+				if ctx.Err() != nil {
+					live.done(ctx)
+					return
+				}
+
+				// The results of the restarted stale notifiers are discarded.
+				if live.done(ctx) {
+					continue
+				}
+
+				// Unverified triggers are dropped before they count on the
+				// notifier breaker, so they can't quarantine the notifier.
+				if res.VerifyErr != nil {
+					m.dropTrigger(ctx, res.Trigger, DropReasonUnverified, res.VerifyErr)
+					continue
+				}
+				if res.Err == nil {
+					var ok bool
+					res.Trigger, ok = m.filterTrigger(res.Trigger)
+					if !ok {
+						m.dropTrigger(ctx, res.Trigger, DropReasonFiltered, ErrTriggerFiltered)
+						continue
+					}
+				}
+				// The triggers that can't select the reloaders are dropped
+				// before being queued, so they don't stop the manager.
+				if res.Err == nil {
+					_, err := ParseTagExpression(res.Trigger.TagSelector)
+					if err != nil {
+						m.dropTrigger(ctx, res.Trigger, DropReasonInvalid, err)
+						continue
+					}
+				}
+				if res.Err == nil && res.Trigger.Urgency != TriggerUrgencyUrgent {
+					allowed, dropped := limiter.limit(res)
+					if dropped != nil {
+						m.dropTrigger(ctx, dropped.Trigger, DropReasonRateLimited, ErrNotifierRateLimited)
+					}
+					if !allowed {
+						continue
+					}
+				}
+
+				discard, quarantine := breaker.check(res)
+				if quarantine != nil && !m.quarantineNotifier(ctx, n, breaker, quarantine) {
+					return
+				}
+				if discard {
+					continue
+				}
+
+				if res.Err == nil {
+					res.Pending = m.pending.mark(res.Trigger, res.At)
+				}
+				if !m.enqueue(ctx, signal, res, n.queueOverflow) {
 					return // End notifier.
 				}
 			}
 		}(n)
 	}
 
-	// Wait until the context ends or we receive a signal from a notifier.
+	// Wait until the context ends or we receive a signal from a notifier, if
+	// signal has an error then stop everything.
+	var lastStart, lastEnd time.Time
+	var scheduled []notifierResult
 	for {
+		notifierSignal, err := m.nextTrigger(ctx, signal, &scheduled, lastEnd)
+		if err != nil {
+			return err
+		}
+
+		// Don't start new reloads once stopped.
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		m.receiveTrigger(ctx, &notifierSignal, lastEnd)
+
+		// Wait until the reloads are allowed.
+		notifierSignal, err = m.waitReloadWindow(ctx, signal, &scheduled, notifierSignal, lastEnd)
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// Wait until the reload is approved.
+		var rejected error
+		notifierSignal, rejected, err = m.waitApproval(ctx, signal, &scheduled, notifierSignal, lastEnd)
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if rejected != nil {
+			err := m.skipTrigger(ctx, notifierSignal.Trigger, rejected)
+			m.pending.done(notifierSignal.Pending)
+			if err != nil {
+				return fmt.Errorf("reload process failed: %w", err)
+			}
+			continue
+		}
+
+		// Handle the triggers that were queued while reloading.
+		switch m.Settings().StaleTriggerPolicy {
+		case StaleTriggerDrop:
+			if notifierSignal.At.Before(lastStart) {
+				m.cfg.metricsRecorder.IncDroppedTrigger(ctx, notifierSignal.Trigger.Source, DropReasonStale)
+				err := m.skipTrigger(ctx, notifierSignal.Trigger, nil)
+				m.pending.done(notifierSignal.Pending)
+				if err != nil {
+					return fmt.Errorf("reload process failed: %w", err)
+				}
+				continue
+			}
+		case StaleTriggerCollapse:
+			notifierSignal, err = m.collapseQueued(ctx, signal, notifierSignal, lastEnd)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Start reload process.
+		lastStart = m.cfg.clock.Now()
+		err = m.retryReload(ctx, notifierSignal.Trigger)
+		lastEnd = m.cfg.clock.Now()
+		// The reloads interrupted by the stop or not started are pending.
+		if ctx.Err() == nil && !errors.Is(err, ErrReloadInProgress) {
+			m.pending.done(notifierSignal.Pending)
+		}
+		if err != nil && !errors.Is(err, ErrReloadInProgress) {
+			return fmt.Errorf("reload process failed: %w", err)
+		}
+	}
+}
+
+// enqueue queues the notifier result using the overflow policy when the queue
+// is full, returns false if the context ends before queueing it.
+func (m *Manager) enqueue(ctx context.Context, signal chan notifierResult, res notifierResult, policy QueueOverflowPolicy) bool {
+	if policy == "" {
+		policy = m.Settings().QueueOverflow
+	}
+
+	for res.Err == nil && policy != QueueOverflowBlock {
+		select {
+		case signal <- res:
+			return true
+		default:
+		}
+
+		// The urgent triggers drop the oldest instead.
+		if policy == QueueOverflowDropNewest && res.Trigger.Urgency != TriggerUrgencyUrgent {
+			m.dropTrigger(ctx, res.Trigger, DropReasonQueueFull, nil)
+			m.pending.done(res.Pending)
+			return true
+		}
+
+		// Drop the oldest, the errors are never dropped so it's queued again
+		// in place of the new trigger.
 		select {
-		case notifierSignal := <-signal:
-			// If signal has an error then stop everything.
-			if notifierSignal.Err != nil {
-				return fmt.Errorf("notifier failed: %w", notifierSignal.Err)
+		case old := <-signal:
+			if old.Err != nil {
+				m.dropTrigger(ctx, res.Trigger, DropReasonQueueFull, nil)
+				m.pending.done(res.Pending)
+				res = old
+				continue
 			}
+			m.dropTrigger(ctx, old.Trigger, DropReasonQueueFull, nil)
+			m.pending.done(old.Pending)
+		default:
+		}
+	}
+
+	select {
+	case signal <- res:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
 
-			// Start reload process.
-			err := m.reloadGroups(ctx, notifierSignal.Result)
+// ErrTriggerFiltered is set on EventTriggerDropped when a trigger is dropped by
+// a trigger filter (see WithTriggerFilter).
+var ErrTriggerFiltered = errors.New("trigger filtered")
+
+// filterTrigger applies the trigger filters and transforms, returns false if
+// the trigger needs to be dropped.
+func (m *Manager) filterTrigger(t TriggerEvent) (TriggerEvent, bool) {
+	for _, f := range m.cfg.triggerFilters {
+		var ok bool
+		t, ok = f(t)
+		if !ok {
+			return t, false
+		}
+	}
+
+	return t, true
+}
+
+// dropTrigger discards a notifier trigger before queueing it.
+func (m *Manager) dropTrigger(ctx context.Context, t TriggerEvent, reason DropReason, err error) {
+	m.emit(ctx, Event{Type: EventTriggerDropped, Trigger: t, Err: err})
+	m.cfg.metricsRecorder.IncDroppedTrigger(ctx, t.Source, reason)
+}
+
+// collapseQueued drains the queued notifier signals, only the latest one is kept
+// and the rest are skipped.
+func (m *Manager) collapseQueued(ctx context.Context, signal <-chan notifierResult, res notifierResult, lastEnd time.Time) (notifierResult, error) {
+	for {
+		select {
+		case next := <-signal:
+			if next.Err != nil {
+				return res, fmt.Errorf("notifier failed: %w", next.Err)
+			}
+
+			m.receiveTrigger(ctx, &next, lastEnd)
+			m.cfg.metricsRecorder.IncCoalescedTrigger(ctx, res.Trigger.Source)
+			err := m.skipTrigger(ctx, res.Trigger, nil)
 			if err != nil {
-				return fmt.Errorf("reload process failed: %w", err)
+				return res, fmt.Errorf("reload process failed: %w", err)
 			}
-		case <-ctx.Done():
-			// We need to end.
-			return nil
+			// The collapsed triggers are pending until the next one is reloaded.
+			next.Pending = append(next.Pending, res.Pending...)
+			res = next
+		default:
+			return res, nil
 		}
 	}
 }
 
+// receiveTrigger handles a notifier trigger reception once, the triggers
+// received before the last reload ended have been queued.
+func (m *Manager) receiveTrigger(ctx context.Context, res *notifierResult, lastEnd time.Time) {
+	if res.Received {
+		return
+	}
+	res.Received = true
+
+	m.emit(ctx, Event{Type: EventTriggerReceived, Trigger: res.Trigger})
+	m.cfg.metricsRecorder.IncTriggerReceived(ctx, res.Trigger.Source)
+	if res.At.Before(lastEnd) {
+		m.cfg.metricsRecorder.IncQueuedTrigger(ctx, res.Trigger.Source)
+	}
+}
+
+// skipTrigger discards a trigger without starting the reload process, the
+// reason is nil when the trigger has been coalesced into other.
+func (m *Manager) skipTrigger(ctx context.Context, t TriggerEvent, reason error) error {
+	var pipelines []string
+	if plan, err := m.reloadPlan(t); err == nil {
+		pipelines = eventPipelines(planPipelines(plan))
+	}
+	m.emit(ctx, Event{Type: EventReloadSkipped, Trigger: t, Err: reason, Pipelines: pipelines})
+
+	return m.audit(ctx, reloadAttempt{trigger: t, start: m.cfg.clock.Now(), skipped: true})
+}
+
+// waitNotifiers waits until the notifiers end or the timeout.
+func waitNotifiers(clock Clock, wg *sync.WaitGroup, running *atomic.Int64, timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	t := clock.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-t.C():
+		return fmt.Errorf("%d %w after %s", running.Load(), ErrNotifierStopTimeout, timeout)
+	}
+}
+
 const (
 	unlockedState uint32 = 0
 	lockedState   uint32 = 1
@@ -144,45 +691,348 @@ const (
 // stop the reload process and end with an error.
 //
 // Reload process can be triggered any number of times.
-func (m *Manager) reloadGroups(ctx context.Context, id string) error {
-	if len(m.reloaders) == 0 {
-		return nil
+func (m *Manager) reloadGroups(ctx context.Context, t TriggerEvent, retry bool) (err error) {
+	attempt := reloadAttempt{trigger: t, start: m.cfg.clock.Now()}
+	var pipelines []string
+	defer func() {
+		attempt.duration = m.cfg.clock.Now().Sub(attempt.start)
+		attempt.err = err
+		if !attempt.skipped {
+			retrying := retry && m.cfg.reloadRetry.retryable(ctx, err)
+			m.emit(ctx, Event{Type: EventReloadFinished, Trigger: t, Duration: attempt.duration, Err: err, Pipelines: eventPipelines(pipelines), Retrying: retrying})
+		}
+
+		auditErr := m.audit(ctx, attempt)
+		if auditErr != nil {
+			err = errors.Join(err, auditErr)
+		}
+	}()
+
+	// Attach the reloaders report to the errors of the failed reloads, before
+	// they are emitted and audited.
+	var report *reloadReport
+	defer func() {
+		if err != nil && report != nil {
+			err = report.error(t, err)
+		}
+	}()
+
+	plan, err := m.reloadPlan(t)
+	if err != nil {
+		// The finished event of the failed reload needs its started event.
+		m.emit(ctx, Event{Type: EventReloadStarted, Trigger: t})
+		return fmt.Errorf("reload %s failed: %w", triggerLabel(t), err)
 	}
 
-	// Are we already in a reload process?
-	if !atomic.CompareAndSwapUint32(&m.lock, unlockedState, lockedState) {
-		return nil
+	// Are we already in a reload process of the same pipelines?
+	pipelines = planPipelines(plan)
+	inFlight, ok := m.locks.lock(pipelines, &ReloadInProgressError{TriggerID: t.ID, StartedAt: attempt.start})
+	if !ok {
+		attempt.skipped = true
+		inProgressErr := *inFlight
+		m.emit(ctx, Event{Type: EventReloadSkipped, Trigger: t, Err: &inProgressErr, Pipelines: eventPipelines(pipelines)})
+		m.cfg.metricsRecorder.IncDroppedTrigger(ctx, t.Source, DropReasonReloadInProgress)
+		return &inProgressErr
 	}
-	defer atomic.StoreUint32(&m.lock, unlockedState)
+	defer m.locks.unlock(pipelines)
+	report = newReloadReport(plan, m.cfg.groupNames)
+	generation := atomic.AddUint64(&m.reserved, 1)
 
-	// Sort groups.
-	reloderGroups := make([]reloaderGroup, 0, len(m.reloaders))
-	for _, rg := range m.reloaders {
-		reloderGroups = append(reloderGroups, rg)
+	m.emit(ctx, Event{Type: EventReloadStarted, Trigger: t, Pipelines: eventPipelines(pipelines)})
+	m.cfg.metricsRecorder.AddReloadsInProgress(ctx, 1)
+	defer func() {
+		m.cfg.metricsRecorder.AddReloadsInProgress(ctx, -1)
+		m.cfg.metricsRecorder.ObserveReloadDuration(contextWithTriggerEvent(ctx, t), t.Source, err == nil, m.cfg.clock.Now().Sub(attempt.start))
+		if err != nil {
+			m.cfg.metricsRecorder.IncReloadFailure(ctx, t.Source)
+			return
+		}
+		m.cfg.metricsRecorder.SetLastSuccessfulReload(ctx, m.applyGeneration(generation), m.cfg.clock.Now())
+	}()
+
+	if m.cfg.locker != nil {
+		err := m.cfg.locker.Lock(ctx)
+		if err != nil {
+			return fmt.Errorf("could not acquire distributed lock: %w", err)
+		}
+		defer func() {
+			unlockErr := m.cfg.locker.Unlock(context.WithoutCancel(ctx))
+			if unlockErr != nil {
+				err = errors.Join(err, fmt.Errorf("could not release distributed lock: %w", unlockErr))
+			}
+		}()
+	}
+
+	// Wait until the service is ready to be reloaded.
+	if m.cfg.gate != nil {
+		err := m.cfg.gate.Wait(contextWithTriggerEvent(ctx, t))
+		if err != nil {
+			return fmt.Errorf("reload %s failed: gate: %w", triggerLabel(t), err)
+		}
 	}
-	sort.SliceStable(reloderGroups, func(x, y int) bool { return reloderGroups[x].priority < reloderGroups[y].priority })
+
+	// Give the in-flight reload a grace period to finish when the manager stops.
+	settings := m.Settings()
+	ctx, grace := m.newReloadGrace(ctx, settings.ShutdownGracePeriod)
+	defer func() {
+		graceErr := grace.finish()
+		if graceErr != nil {
+			err = errors.Join(err, graceErr)
+		}
+	}()
 
 	// Reload all groups secuentially.
-	for _, rg := range reloderGroups {
-		err := m.reloadGroup(ctx, rg, id)
+	ctx = contextWithTriggerEvent(ctx, t)
+	ctx = contextWithGeneration(ctx, generation)
+	budget := m.newReloadBudget(m.cfg.reloadBudget)
+	for i, rg := range plan {
+		groupCtx, finishGroup, err := budget.groupContext(ctx)
 		if err != nil {
-			return fmt.Errorf("error on priority %d group reload: %w", rg.priority, err)
+			report.recordGroup(i, 0, true, err)
+			return fmt.Errorf("reload %s failed: %s: %w", triggerLabel(t), m.groupLabel(rg.priority), err)
+		}
+
+		m.emit(ctx, Event{Type: EventGroupStarted, Trigger: t, Priority: rg.priority, Reloaders: rg.names()})
+		groupStart := m.cfg.clock.Now()
+		err = finishGroup(m.reloadGroup(groupCtx, rg, t, grace, report, settings.OrderedReloaders))
+		groupDuration := m.cfg.clock.Now().Sub(groupStart)
+		interrupted := err != nil && (ctx.Err() != nil || errors.Is(err, ErrReloadBudgetExceeded))
+		report.recordGroup(i, groupDuration, interrupted, err)
+		attempt.groups = append(attempt.groups, groupResult{priority: rg.priority, duration: groupDuration, err: err})
+		m.emit(ctx, Event{Type: EventGroupFinished, Trigger: t, Priority: rg.priority, Duration: groupDuration, Err: err})
+		if err != nil {
+			return fmt.Errorf("reload %s failed: %s: %w", triggerLabel(t), m.groupLabel(rg.priority), err)
 		}
 	}
 
 	return nil
 }
 
-func (m *Manager) reloadGroup(ctx context.Context, rg reloaderGroup, id string) error {
-	g, ctx := errgroup.WithContext(ctx)
+// applyGeneration sets the generation of a successful reload as the current
+// one, unless a concurrent reload already applied a newer one, and returns the
+// current generation.
+func (m *Manager) applyGeneration(generation uint64) uint64 {
+	for {
+		current := atomic.LoadUint64(&m.generation)
+		if current >= generation {
+			return current
+		}
+		if atomic.CompareAndSwapUint64(&m.generation, current, generation) {
+			return generation
+		}
+	}
+}
+
+// groupLabel returns the group description used on the errors.
+func (m *Manager) groupLabel(priority int) string {
+	if name, ok := m.cfg.groupNames[priority]; ok {
+		return fmt.Sprintf("group %q (priority %d)", name, priority)
+	}
+
+	return fmt.Sprintf("group (priority %d)", priority)
+}
+
+// reloadPlan returns the reloader groups in execution order with the
+// reloaders of the trigger.
+func (m *Manager) reloadPlan(t TriggerEvent) ([]reloaderGroup, error) {
+	selector, err := ParseTagExpression(t.TagSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []reloaderGroup
+	for _, rg := range m.sortedGroups() {
+		if ids, ok := m.cfg.groupTriggerIDs[rg.priority]; ok && !matchesAny(ids, t.ID) {
+			continue
+		}
+
+		rg = rg.forSource(t.Source).forTags(selector)
+		if len(rg.reloaders) == 0 {
+			continue
+		}
+		plan = append(plan, rg)
+	}
+
+	return plan, nil
+}
+
+type groupResult struct {
+	priority int
+	duration time.Duration
+	err      error
+}
+
+type reloadAttempt struct {
+	trigger  TriggerEvent
+	start    time.Time
+	duration time.Duration
+	skipped  bool
+	groups   []groupResult
+	err      error
+}
+
+func (m *Manager) emit(ctx context.Context, e Event) {
+	if e.Time.IsZero() {
+		e.Time = m.cfg.clock.Now()
+	}
+
+	m.stats.track(e)
+	for _, s := range m.cfg.subscribers {
+		s.HandleEvent(ctx, e)
+	}
+	for _, s := range m.subscriptions.list() {
+		s.HandleEvent(ctx, e)
+	}
+}
+
+func (m *Manager) audit(ctx context.Context, a reloadAttempt) error {
+	if m.cfg.auditSink == nil {
+		return nil
+	}
+
+	err := m.cfg.auditSink.WriteAuditRecord(ctx, newAuditRecord(a))
+	if err != nil {
+		return fmt.Errorf("audit sink failed: %w", err)
+	}
 
+	return nil
+}
+
+func (m *Manager) reloadGroup(groupCtx context.Context, rg reloaderGroup, t TriggerEvent, grace *reloadGrace, report *reloadReport, ordered bool) error {
 	reloaders := rg.reloaders
-	for _, r := range reloaders {
-		r := r
-		g.Go(func() error {
-			return r.Reload(ctx, id)
-		})
+	tasks := make([]ExecutorTask, 0, len(reloaders))
+	for i, r := range reloaders {
+		tasks = append(tasks, ExecutorTask{Name: r.name, Weight: r.weight, Run: func(ctx context.Context) error {
+			if m.skipDisabled(ctx, r, rg.priority, t) {
+				report.disable(rg.priority, i)
+				return nil
+			}
+			if m.skipOpenCircuit(ctx, r, rg.priority, t) {
+				return nil
+			}
+			grace.started(r.name)
+			defer grace.finished(r.name)
+
+			start := m.cfg.clock.Now()
+			err := m.runReloader(ctx, r, rg.priority, t)
+			duration := m.cfg.clock.Now().Sub(start)
+			// The reloaders cancelled because another reloader of the group
+			// failed are not failing, unless they failed with their own error.
+			cancelled := errors.Is(err, context.Canceled) && ctx.Err() != nil && groupCtx.Err() == nil
+			report.record(rg.priority, i, duration, err, cancelled)
+			if !cancelled {
+				m.recordCircuit(ctx, r, err)
+			}
+			m.cfg.metricsRecorder.ObserveReloaderDuration(ctx, r.name, rg.priority, err == nil, duration)
+			m.emit(ctx, Event{Type: EventReloaderFinished, Trigger: t, Priority: rg.priority, Reloader: r.name, Duration: duration, Err: err})
+			if err != nil {
+				return fmt.Errorf("reloader %q (%d/%d): %w", r.name, i+1, len(reloaders), err)
+			}
+			return nil
+		}})
+	}
+
+	// When ordered, the reloaders run one at a time in weight and registration
+	// order.
+	executor := m.cfg.executor
+	if ordered {
+		executor = SequentialExecutor()
+	}
+
+	return executor.Execute(groupCtx, tasks)
+}
+
+// ErrShutdownGracePeriodExceeded is returned when the in-flight reloaders don't
+// finish before the shutdown grace period (see WithShutdownGracePeriod).
+var ErrShutdownGracePeriodExceeded = errors.New("shutdown grace period exceeded")
+
+// reloadGrace detaches a reload process from the manager context, so when the
+// manager stops, the in-flight reloaders have a grace period to finish before
+// being cancelled. It tracks the running reloaders to report the ones that
+// didn't finish in time.
+//
+// A nil reloadGrace is valid and doesn't track anything.
+type reloadGrace struct {
+	period time.Duration
+	cancel context.CancelFunc
+	stop   func() bool
+	done   chan struct{}
+
+	mu      sync.Mutex
+	running map[string]struct{}
+	expired []string
+}
+
+// newReloadGrace returns the context for the reload process, if the grace
+// period is not enabled the manager context will be used as is.
+func (m *Manager) newReloadGrace(ctx context.Context, period time.Duration) (context.Context, *reloadGrace) {
+	if period <= 0 {
+		return ctx, nil
+	}
+
+	reloadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	g := &reloadGrace{
+		period:  period,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		running: map[string]struct{}{},
+	}
+	g.stop = context.AfterFunc(ctx, func() {
+		t := m.cfg.clock.NewTimer(g.period)
+		defer t.Stop()
+
+		select {
+		case <-g.done:
+		case <-t.C():
+			g.mu.Lock()
+			for name := range g.running {
+				g.expired = append(g.expired, name)
+			}
+			g.mu.Unlock()
+			cancel()
+		}
+	})
+
+	return reloadCtx, g
+}
+
+func (g *reloadGrace) started(name string) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.running[name] = struct{}{}
+}
+
+func (g *reloadGrace) finished(name string) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.running, name)
+}
+
+// finish ends the grace period tracking, returns an error with the reloaders
+// that were cancelled by the grace period expiration, if any.
+func (g *reloadGrace) finish() error {
+	if g == nil {
+		return nil
+	}
+
+	g.stop()
+	close(g.done)
+	g.cancel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.expired) == 0 {
+		return nil
 	}
 
-	return g.Wait()
+	sort.Strings(g.expired)
+	return fmt.Errorf("%w (%s), reloaders not finished: %s", ErrShutdownGracePeriodExceeded, g.period, strings.Join(g.expired, ", "))
 }
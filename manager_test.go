@@ -3,11 +3,14 @@ package reload_test
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/slok/reload"
 	"github.com/slok/reload/internal/reloadmock"
@@ -221,3 +224,503 @@ func TestManagerWithNotifierChan(t *testing.T) {
 		})
 	}
 }
+
+type testTriggerNotifier struct {
+	c <-chan reload.TriggerEvent
+}
+
+func (t testTriggerNotifier) Notify(ctx context.Context) (string, error) {
+	panic("should not be called")
+}
+func (t testTriggerNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	select {
+	case <-ctx.Done():
+		return reload.TriggerEvent{}, ctx.Err()
+	case te := <-t.c:
+		return te, nil
+	}
+}
+
+func TestManagerWithTriggerNotifier(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	var gotID string
+	var gotTrigger reload.TriggerEvent
+	m := reload.NewManager()
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		gotID = id
+		gotTrigger, _ = reload.TriggerEventFromContext(ctx)
+		return nil
+	}))
+	notifierC := make(chan reload.TriggerEvent)
+	m.On(testTriggerNotifier{c: notifierC}, reload.WithNotifierName("test-notifier"))
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runFinished := make(chan error)
+	go func() { runFinished <- m.Run(ctx) }()
+	notifierC <- reload.TriggerEvent{ID: "test-id", Paths: []string{"/tmp/a", "/tmp/b"}}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// Check.
+	assert.NoError(<-runFinished)
+	assert.Equal("test-id", gotID)
+	assert.Equal(reload.TriggerEvent{ID: "test-id", Source: "test-notifier", Paths: []string{"/tmp/a", "/tmp/b"}}, gotTrigger)
+}
+
+func TestManagerNotifierMetadata(t *testing.T) {
+	tests := map[string]struct {
+		metadata   map[string]string
+		trigger    reload.TriggerEvent
+		expTrigger reload.TriggerEvent
+	}{
+		"Without notifier metadata the trigger metadata should be kept.": {
+			trigger:    reload.TriggerEvent{ID: "test-id", Metadata: map[string]string{"version": "v2"}},
+			expTrigger: reload.TriggerEvent{ID: "test-id", Source: "test", Metadata: map[string]string{"version": "v2"}},
+		},
+
+		"The notifier metadata should be set on the trigger.": {
+			metadata:   map[string]string{"origin": "k8s"},
+			trigger:    reload.TriggerEvent{ID: "test-id"},
+			expTrigger: reload.TriggerEvent{ID: "test-id", Source: "test", Metadata: map[string]string{"origin": "k8s"}},
+		},
+
+		"The trigger metadata should take precedence over the notifier metadata.": {
+			metadata:   map[string]string{"origin": "k8s", "version": "v1"},
+			trigger:    reload.TriggerEvent{ID: "test-id", Metadata: map[string]string{"version": "v2"}},
+			expTrigger: reload.TriggerEvent{ID: "test-id", Source: "test", Metadata: map[string]string{"origin": "k8s", "version": "v2"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var gotTrigger reload.TriggerEvent
+			m := reload.NewManager()
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotTrigger, _ = reload.TriggerEventFromContext(ctx)
+				return nil
+			}))
+			notifierC := make(chan reload.TriggerEvent)
+			m.On(testTriggerNotifier{c: notifierC}, reload.WithNotifierName("test"), reload.WithNotifierMetadata(test.metadata))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			notifierC <- test.trigger
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			// Check.
+			assert.NoError(<-runFinished)
+			assert.Equal(test.expTrigger, gotTrigger)
+		})
+	}
+}
+
+func TestManagerNotifiersStop(t *testing.T) {
+	tests := map[string]struct {
+		stopTimeout time.Duration
+		notifier    func(running *atomic.Int64, release chan struct{}) reload.Notifier
+		reloadErr   error
+		expErr      bool
+		expErrIs    error
+		expRunning  int64
+	}{
+		"Notifiers blocked on a channel should be stopped when the manager stops.": {
+			stopTimeout: time.Second,
+			notifier: func(running *atomic.Int64, _ chan struct{}) reload.Notifier {
+				c := make(chan string)
+				return reload.NotifierFunc(func(ctx context.Context) (string, error) {
+					running.Add(1)
+					defer running.Add(-1)
+					return reload.NotifierChan(c).Notify(ctx)
+				})
+			},
+			expRunning: 0,
+		},
+
+		"Notifiers should be stopped when the manager stops with an error.": {
+			stopTimeout: time.Second,
+			notifier: func(running *atomic.Int64, _ chan struct{}) reload.Notifier {
+				triggered := false
+				return reload.NotifierFunc(func(ctx context.Context) (string, error) {
+					running.Add(1)
+					defer running.Add(-1)
+					if !triggered {
+						triggered = true
+						return "test-id", nil
+					}
+					<-ctx.Done()
+					return "", ctx.Err()
+				})
+			},
+			reloadErr:  fmt.Errorf("something"),
+			expErr:     true,
+			expRunning: 0,
+		},
+
+		"Notifiers that don't stop should be reported.": {
+			stopTimeout: 20 * time.Millisecond,
+			notifier: func(running *atomic.Int64, release chan struct{}) reload.Notifier {
+				return reload.NotifierFunc(func(ctx context.Context) (string, error) {
+					running.Add(1)
+					defer running.Add(-1)
+					<-release
+					return "", nil
+				})
+			},
+			expErr:     true,
+			expErrIs:   reload.ErrNotifierStopTimeout,
+			expRunning: 1,
+		},
+
+		"Without stop timeout the notifiers that don't stop should not be waited.": {
+			notifier: func(running *atomic.Int64, release chan struct{}) reload.Notifier {
+				return reload.NotifierFunc(func(ctx context.Context) (string, error) {
+					running.Add(1)
+					defer running.Add(-1)
+					<-release
+					return "", nil
+				})
+			},
+			expRunning: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var running atomic.Int64
+			release := make(chan struct{})
+			defer close(release)
+			m := reload.NewManager(reload.WithNotifierStopTimeout(test.stopTimeout))
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return test.reloadErr }))
+			for range 3 {
+				m.On(test.notifier(&running, release))
+			}
+
+			// Execute.
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			err := m.Run(ctx)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+				if test.expErrIs != nil {
+					assert.ErrorIs(err, test.expErrIs)
+				}
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expRunning*3, running.Load())
+		})
+	}
+}
+
+func TestNotifierChanContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := reload.NotifierChan(make(chan string)).Notify(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestManagerStaleTriggerPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy     reload.StaleTriggerPolicy
+		expReloads []string
+		expSkipped []string
+	}{
+		"By default the queued triggers should be reloaded.": {
+			expReloads: []string{"id-0", "id-1", "id-2"},
+		},
+
+		"Keeping the queued triggers should reload all of them.": {
+			policy:     reload.StaleTriggerKeep,
+			expReloads: []string{"id-0", "id-1", "id-2"},
+		},
+
+		"Dropping the queued triggers should skip the ones triggered before the reload start.": {
+			policy:     reload.StaleTriggerDrop,
+			expReloads: []string{"id-0"},
+			expSkipped: []string{"id-1", "id-2"},
+		},
+
+		"Collapsing the queued triggers should reload once with the latest one.": {
+			policy:     reload.StaleTriggerCollapse,
+			expReloads: []string{"id-2"},
+			expSkipped: []string{"id-0", "id-1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var mu sync.Mutex
+			var gotReloads, gotSkipped []string
+
+			// Wait on the first trigger until all the notifiers have queued their
+			// trigger.
+			var queued sync.WaitGroup
+			var once sync.Once
+			sub := reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+				switch e.Type {
+				case reload.EventTriggerReceived:
+					once.Do(queued.Wait)
+				case reload.EventReloadSkipped:
+					mu.Lock()
+					gotSkipped = append(gotSkipped, e.Trigger.ID)
+					mu.Unlock()
+				}
+			})
+
+			m := reload.NewManager(reload.WithStaleTriggerPolicy(test.policy), reload.WithSubscriber(sub))
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				mu.Lock()
+				gotReloads = append(gotReloads, id)
+				mu.Unlock()
+				return nil
+			}))
+
+			// Each notifier triggers once, in order.
+			prev := make(chan struct{})
+			close(prev)
+			for i := range 3 {
+				queued.Add(1)
+				wait, next := prev, make(chan struct{})
+				calls := 0
+				m.On(reload.NotifierFunc(func(ctx context.Context) (string, error) {
+					calls++
+					if calls == 1 {
+						<-wait
+						return fmt.Sprintf("id-%d", i), nil
+					}
+
+					// The trigger has been queued.
+					if calls == 2 {
+						close(next)
+						queued.Done()
+					}
+					<-ctx.Done()
+					return "", ctx.Err()
+				}))
+				prev = next
+			}
+
+			// Execute.
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err := m.Run(ctx)
+
+			// Check.
+			assert.NoError(err)
+			assert.Equal(test.expReloads, gotReloads)
+			assert.Equal(test.expSkipped, gotSkipped)
+		})
+	}
+}
+
+func TestManagerShutdownGracePeriod(t *testing.T) {
+	// slowReloader takes the duration to reload unless its context is cancelled.
+	slowReloader := func(d time.Duration, calls *atomic.Int64) reload.Reloader {
+		return reload.ReloaderFunc(func(ctx context.Context, id string) error {
+			calls.Add(1)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d):
+				return nil
+			}
+		})
+	}
+
+	tests := map[string]struct {
+		gracePeriod time.Duration
+		expCalls    int64
+		expErr      bool
+		expErrIs    error
+		expErrMsg   string
+	}{
+		"Without grace period, the in-flight reloaders should be cancelled.": {
+			expCalls: 1,
+			expErr:   true,
+			expErrIs: context.DeadlineExceeded,
+		},
+
+		"With grace period, the in-flight reload should finish.": {
+			gracePeriod: 500 * time.Millisecond,
+			expCalls:    3,
+		},
+
+		"If the grace period expires, the in-flight reloaders should be cancelled and reported.": {
+			gracePeriod: 20 * time.Millisecond,
+			expCalls:    1,
+			expErr:      true,
+			expErrIs:    reload.ErrShutdownGracePeriodExceeded,
+			expErrMsg:   "reloaders not finished: slow-1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var calls atomic.Int64
+			m := reload.NewManager(reload.WithShutdownGracePeriod(test.gracePeriod))
+			m.Add(0, slowReloader(100*time.Millisecond, &calls), reload.WithReloaderName("slow-1"))
+			m.Add(1, slowReloader(10*time.Millisecond, &calls), reload.WithReloaderName("fast-1"))
+			m.Add(1, slowReloader(10*time.Millisecond, &calls), reload.WithReloaderName("fast-2"))
+			notifierC := make(chan string, 1)
+			notifierC <- "test-id"
+			m.On(reload.NotifierChan(notifierC))
+
+			// Execute.
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+			defer cancel()
+			err := m.Run(ctx)
+
+			// Check.
+			if test.expErr {
+				assert.ErrorIs(err, test.expErrIs)
+				if test.expErrMsg != "" {
+					assert.Contains(err.Error(), test.expErrMsg)
+				}
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expCalls, calls.Load())
+		})
+	}
+}
+
+func TestManagerRestart(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	var mu sync.Mutex
+	var gotIDs []string
+	m := reload.NewManager()
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		gotIDs = append(gotIDs, id)
+		return nil
+	}))
+	notifierC := make(chan string, 1)
+	m.On(reload.NotifierChan(notifierC))
+
+	// Stop-then-restart cycles should reload on every run.
+	for i := range 3 {
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error)
+		go func() { runErr <- m.Run(ctx) }()
+
+		notifierC <- fmt.Sprintf("id-%d", i)
+		time.Sleep(20 * time.Millisecond)
+
+		// While running, it can't be run again.
+		require.ErrorIs(m.Run(context.Background()), reload.ErrAlreadyRunning)
+
+		cancel()
+		require.NoError(<-runErr)
+	}
+
+	assert.Equal([]string{"id-0", "id-1", "id-2"}, gotIDs)
+}
+
+func TestManagerTriggerReload(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	reloading := make(chan struct{})
+	release := make(chan struct{})
+	var gotTriggers []reload.TriggerEvent
+	m := reload.NewManager()
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		t, _ := reload.TriggerEventFromContext(ctx)
+		gotTriggers = append(gotTriggers, t)
+		if id == "slow" {
+			close(reloading)
+			<-release
+		}
+		return nil
+	}))
+
+	// A manual trigger should reload.
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"}))
+
+	// A manual trigger while other reload is in progress should fail.
+	slowErr := make(chan error)
+	start := time.Now()
+	go func() { slowErr <- m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "slow", Source: "admin"}) }()
+	<-reloading
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t2"})
+	require.ErrorIs(err, reload.ErrReloadInProgress)
+	var inProgressErr *reload.ReloadInProgressError
+	require.ErrorAs(err, &inProgressErr)
+	assert.Equal("slow", inProgressErr.TriggerID)
+	assert.WithinDuration(start, inProgressErr.StartedAt, time.Second)
+
+	close(release)
+	require.NoError(<-slowErr)
+
+	// Check.
+	assert.Equal([]reload.TriggerEvent{
+		{ID: "t1", Source: "manual"},
+		{ID: "slow", Source: "admin"},
+	}, gotTriggers)
+}
+
+func TestManagerReloadErrorMessage(t *testing.T) {
+	tests := map[string]struct {
+		opts   []reload.ManagerOption
+		reason string
+		expErr string
+	}{
+		"The error should have the failing group and reloader.": {
+			expErr: `reload "certs" failed: group (priority 200): reloader "grpc-gateway" (2/2): something`,
+		},
+
+		"The error should have the group name.": {
+			opts:   []reload.ManagerOption{reload.WithGroupName(200, "servers")},
+			expErr: `reload "certs" failed: group "servers" (priority 200): reloader "grpc-gateway" (2/2): something`,
+		},
+
+		"The error should have the trigger reason.": {
+			reason: "certificate renewed",
+			expErr: `reload "certs" (reason: certificate renewed) failed: group (priority 200): reloader "grpc-gateway" (2/2): something`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			m := reload.NewManager(test.opts...)
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("config"))
+			m.Add(200, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("http"))
+			m.Add(200, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }), reload.WithReloaderName("grpc-gateway"))
+
+			// Execute.
+			err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "certs", Reason: test.reason})
+
+			// Check.
+			assert.EqualError(err, test.expErr)
+		})
+	}
+}
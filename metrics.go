@@ -0,0 +1,109 @@
+package reload
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsRecorder knows how to record the metrics of the reload mechanism.
+//
+// Recorders are called synchronously by the manager (even concurrently from the
+// reloaders of the same priority group), so they should be fast and safe for
+// concurrent use.
+type MetricsRecorder interface {
+	// ObserveReloadDuration records the duration of a reload process (all the
+	// priority groups), with the source (notifier name) of the trigger.
+	ObserveReloadDuration(ctx context.Context, source string, success bool, duration time.Duration)
+	// AddReloadsInProgress adds the delta to the number of reload processes in
+	// progress.
+	AddReloadsInProgress(ctx context.Context, delta int)
+	// ObserveReloaderDuration records the duration of a reloader reload, with
+	// the reloader name and priority group.
+	ObserveReloaderDuration(ctx context.Context, reloader string, priority int, success bool, duration time.Duration)
+	// SetLastSuccessfulReload records a successful reload, with the current
+	// config generation (see GenerationFromContext) and the time it happened.
+	SetLastSuccessfulReload(ctx context.Context, generation uint64, at time.Time)
+	// IncReloadFailure records a failed reload, with the source (notifier name)
+	// of the trigger that started it.
+	IncReloadFailure(ctx context.Context, source string)
+	// IncTriggerReceived records a received trigger, with the source (notifier
+	// name) of the trigger.
+	IncTriggerReceived(ctx context.Context, source string)
+	// IncQueuedTrigger records a trigger that has been waiting for the previous
+	// reload to end, with the source (notifier name) of the trigger.
+	IncQueuedTrigger(ctx context.Context, source string)
+	// IncCoalescedTrigger records a trigger that has been replaced by a newer
+	// one (see StaleTriggerCollapse), with the source (notifier name) of the
+	// trigger.
+	IncCoalescedTrigger(ctx context.Context, source string)
+	// IncDroppedTrigger records a trigger that has been dropped without
+	// reloading, with the source (notifier name) of the trigger and the reason.
+	IncDroppedTrigger(ctx context.Context, source string, reason DropReason)
+	// AddAbandonedReloaders adds the delta to the number of reloaders that
+	// exceeded their timeout and didn't return yet, with the reloader name.
+	AddAbandonedReloaders(ctx context.Context, reloader string, delta int)
+	// SetCircuitOpen records the circuit breaker state of a reloader, with the
+	// reloader name.
+	SetCircuitOpen(ctx context.Context, reloader string, open bool)
+	// SetNotifierStale records if a notifier is stale (see
+	// WithNotifierLiveness), with the notifier name.
+	SetNotifierStale(ctx context.Context, notifier string, stale bool)
+}
+
+// DropReason is the reason of a dropped trigger.
+type DropReason string
+
+const (
+	// DropReasonReloadInProgress is used when the trigger is dropped because
+	// other reload is in progress.
+	DropReasonReloadInProgress DropReason = "reload_in_progress"
+	// DropReasonStale is used when the trigger is dropped because it was
+	// received before the previous reload started (see StaleTriggerDrop).
+	DropReasonStale DropReason = "stale"
+	// DropReasonQueueFull is used when the trigger is dropped because the
+	// trigger queue is full (see WithTriggerQueue).
+	DropReasonQueueFull DropReason = "queue_full"
+	// DropReasonUnverified is used when the trigger is dropped because it
+	// failed the verification (see WithNotifierVerifier).
+	DropReasonUnverified DropReason = "unverified"
+	// DropReasonRateLimited is used when the trigger is dropped because it
+	// exceeded the notifier rate limit (see WithNotifierRateLimit).
+	DropReasonRateLimited DropReason = "rate_limited"
+	// DropReasonFiltered is used when the trigger is dropped by a trigger
+	// filter (see WithTriggerFilter).
+	DropReasonFiltered DropReason = "filtered"
+	// DropReasonInvalid is used when the trigger is dropped because it's
+	// invalid (e.g: an invalid tag selector, see TriggerEvent.TagSelector).
+	DropReasonInvalid DropReason = "invalid"
+	// DropReasonRecoveryLimit is used when the pending trigger is dropped on
+	// start because it was recovered too many times (see
+	// WithPendingTriggerFile).
+	DropReasonRecoveryLimit DropReason = "recovery_limit"
+)
+
+type noopMetricsRecorder struct{}
+
+func (noopMetricsRecorder) ObserveReloadDuration(context.Context, string, bool, time.Duration) {}
+
+func (noopMetricsRecorder) AddReloadsInProgress(context.Context, int) {}
+
+func (noopMetricsRecorder) ObserveReloaderDuration(context.Context, string, int, bool, time.Duration) {
+}
+
+func (noopMetricsRecorder) SetLastSuccessfulReload(context.Context, uint64, time.Time) {}
+
+func (noopMetricsRecorder) IncReloadFailure(context.Context, string) {}
+
+func (noopMetricsRecorder) IncTriggerReceived(context.Context, string) {}
+
+func (noopMetricsRecorder) IncQueuedTrigger(context.Context, string) {}
+
+func (noopMetricsRecorder) IncCoalescedTrigger(context.Context, string) {}
+
+func (noopMetricsRecorder) IncDroppedTrigger(context.Context, string, DropReason) {}
+
+func (noopMetricsRecorder) AddAbandonedReloaders(context.Context, string, int) {}
+
+func (noopMetricsRecorder) SetCircuitOpen(context.Context, string, bool) {}
+
+func (noopMetricsRecorder) SetNotifierStale(context.Context, string, bool) {}
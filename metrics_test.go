@@ -0,0 +1,212 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+type reloaderObservation struct {
+	reloader string
+	priority int
+	success  bool
+}
+
+type testMetricsRecorder struct {
+	mu                   sync.Mutex
+	reloaderObservations []reloaderObservation
+	generations          []uint64
+	failureSources       []string
+	receivedTriggers     int
+	droppedTriggers      map[reload.DropReason]int
+	reloadObservations   []bool
+	inProgress           int
+	maxInProgress        int
+}
+
+func (t *testMetricsRecorder) ObserveReloadDuration(ctx context.Context, source string, success bool, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reloadObservations = append(t.reloadObservations, success)
+}
+
+func (t *testMetricsRecorder) AddReloadsInProgress(ctx context.Context, delta int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inProgress += delta
+	t.maxInProgress = max(t.maxInProgress, t.inProgress)
+}
+
+func (t *testMetricsRecorder) ObserveReloaderDuration(ctx context.Context, reloader string, priority int, success bool, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reloaderObservations = append(t.reloaderObservations, reloaderObservation{reloader: reloader, priority: priority, success: success})
+}
+
+func (t *testMetricsRecorder) SetLastSuccessfulReload(ctx context.Context, generation uint64, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.generations = append(t.generations, generation)
+}
+
+func (t *testMetricsRecorder) IncReloadFailure(ctx context.Context, source string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failureSources = append(t.failureSources, source)
+}
+
+func (t *testMetricsRecorder) IncTriggerReceived(ctx context.Context, source string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.receivedTriggers++
+}
+
+func (t *testMetricsRecorder) IncQueuedTrigger(ctx context.Context, source string) {}
+
+func (t *testMetricsRecorder) IncCoalescedTrigger(ctx context.Context, source string) {}
+
+func (t *testMetricsRecorder) IncDroppedTrigger(ctx context.Context, source string, reason reload.DropReason) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.droppedTriggers == nil {
+		t.droppedTriggers = map[reload.DropReason]int{}
+	}
+	t.droppedTriggers[reason]++
+}
+
+func (t *testMetricsRecorder) AddAbandonedReloaders(ctx context.Context, reloader string, delta int) {
+}
+
+func (t *testMetricsRecorder) SetCircuitOpen(ctx context.Context, reloader string, open bool) {}
+
+func (t *testMetricsRecorder) SetNotifierStale(ctx context.Context, notifier string, stale bool) {}
+
+func TestManagerMetricsRecorder(t *testing.T) {
+	tests := map[string]struct {
+		addReloaders            func(m *reload.Manager)
+		triggers                int
+		expReloaderObservations []reloaderObservation
+		expGenerations          []uint64
+		expFailureSources       []string
+		expReloadObservations   []bool
+	}{
+		"The reloaders should be observed with their name and priority.": {
+			addReloaders: func(m *reload.Manager) {
+				ok := reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil })
+				m.Add(0, ok, reload.WithReloaderName("config"))
+				m.Add(0, ok, reload.WithReloaderName("secrets"))
+				m.Add(10, ok)
+			},
+			triggers: 1,
+			expReloaderObservations: []reloaderObservation{
+				{reloader: "config", priority: 0, success: true},
+				{reloader: "reloader-2", priority: 10, success: true},
+				{reloader: "secrets", priority: 0, success: true},
+			},
+			expGenerations:        []uint64{1},
+			expReloadObservations: []bool{true},
+		},
+
+		"Every successful reload should increase the config generation.": {
+			addReloaders: func(m *reload.Manager) {
+				m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("config"))
+			},
+			triggers: 3,
+			expReloaderObservations: []reloaderObservation{
+				{reloader: "config", priority: 0, success: true},
+				{reloader: "config", priority: 0, success: true},
+				{reloader: "config", priority: 0, success: true},
+			},
+			expGenerations:        []uint64{1, 2, 3},
+			expReloadObservations: []bool{true, true, true},
+		},
+
+		"The failed reloaders should be observed as failed.": {
+			addReloaders: func(m *reload.Manager) {
+				m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }), reload.WithReloaderName("config"))
+				m.Add(10, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("server"))
+			},
+			triggers: 1,
+			expReloaderObservations: []reloaderObservation{
+				{reloader: "config", priority: 0, success: false},
+			},
+			expFailureSources:     []string{"test-notifier"},
+			expReloadObservations: []bool{false},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			rec := &testMetricsRecorder{}
+			m := reload.NewManager(reload.WithMetricsRecorder(rec))
+			test.addReloaders(&m)
+			notifierC := make(chan string)
+			m.On(reload.NotifierChan(notifierC), reload.WithNotifierName("test-notifier"))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			for range test.triggers {
+				notifierC <- "test-id"
+			}
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+			<-runFinished
+
+			// Check.
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			got := rec.reloaderObservations
+			sort.Slice(got, func(i, j int) bool { return got[i].reloader < got[j].reloader })
+			assert.Equal(test.expReloaderObservations, got)
+			assert.Equal(test.expGenerations, rec.generations)
+			assert.Equal(test.expFailureSources, rec.failureSources)
+			assert.Equal(test.expReloadObservations, rec.reloadObservations)
+			assert.Equal(0, rec.inProgress)
+			assert.Equal(1, rec.maxInProgress)
+			assert.Equal(test.triggers, rec.receivedTriggers)
+		})
+	}
+}
+
+func TestManagerMetricsRecorderDroppedTrigger(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	rec := &testMetricsRecorder{}
+	m := reload.NewManager(reload.WithMetricsRecorder(rec))
+	started, release := make(chan struct{}), make(chan struct{})
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		close(started)
+		<-release
+		return nil
+	}))
+
+	// Execute.
+	firstFinished := make(chan error)
+	go func() {
+		firstFinished <- m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1", Source: "admin"})
+	}()
+	<-started
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t2", Source: "admin"})
+	close(release)
+
+	// Check.
+	assert.Error(err)
+	assert.NoError(<-firstFinished)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	assert.Equal(2, rec.receivedTriggers)
+	assert.Equal(map[reload.DropReason]int{reload.DropReasonReloadInProgress: 1}, rec.droppedTriggers)
+}
@@ -0,0 +1,117 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotifierFlapping is set on EventNotifierQuarantined when a notifier
+// triggers more than the allowed triggers per window.
+var ErrNotifierFlapping = errors.New("notifier flapping")
+
+// NotifierBreaker is the configuration of a notifier circuit breaker (see
+// WithNotifierBreaker).
+type NotifierBreaker struct {
+	// MaxTriggers is the maximum number of triggers of the notifier on the
+	// window, when exceeded the notifier is quarantined. 0 disables the limit.
+	MaxTriggers int
+	// Window is the window of the MaxTriggers.
+	// By default 1m.
+	Window time.Duration
+	// MaxErrors is the number of consecutive errors of the notifier before
+	// quarantining it, the errors below it are ignored and the notifier is
+	// called again. 0 disables it, so the notifier errors stop the manager.
+	MaxErrors int
+	// Quarantine is the duration the notifier is not called after tripping the
+	// breaker, its triggers are discarded.
+	// By default 1m.
+	Quarantine time.Duration
+}
+
+func (n *NotifierBreaker) defaults() {
+	if n.Window <= 0 {
+		n.Window = time.Minute
+	}
+
+	if n.Quarantine <= 0 {
+		n.Quarantine = time.Minute
+	}
+}
+
+// notifierBreaker tracks the triggers and errors of a running notifier, it's
+// not safe for concurrent use.
+type notifierBreaker struct {
+	cfg      NotifierBreaker
+	triggers []time.Time
+	errors   int
+}
+
+func newNotifierBreaker(cfg *NotifierBreaker) *notifierBreaker {
+	if cfg == nil {
+		return nil
+	}
+
+	return &notifierBreaker{cfg: *cfg}
+}
+
+// check records the notifier result, returns if the result needs to be
+// discarded and the reason to quarantine the notifier, if it needs to be.
+func (n *notifierBreaker) check(res notifierResult) (discard bool, quarantine error) {
+	if n == nil {
+		return false, nil
+	}
+
+	if res.Err != nil {
+		if n.cfg.MaxErrors <= 0 {
+			return false, nil
+		}
+
+		n.errors++
+		if n.errors >= n.cfg.MaxErrors {
+			return true, fmt.Errorf("%d consecutive errors: %w", n.errors, res.Err)
+		}
+		return true, nil
+	}
+	n.errors = 0
+
+	if n.cfg.MaxTriggers <= 0 {
+		return false, nil
+	}
+
+	// Keep only the triggers of the window.
+	from := res.At.Add(-n.cfg.Window)
+	i := 0
+	for i < len(n.triggers) && !n.triggers[i].After(from) {
+		i++
+	}
+	n.triggers = append(n.triggers[i:], res.At)
+	if len(n.triggers) > n.cfg.MaxTriggers {
+		return true, fmt.Errorf("%w: %d triggers in %s", ErrNotifierFlapping, len(n.triggers), n.cfg.Window)
+	}
+
+	return false, nil
+}
+
+// quarantineNotifier stops calling the notifier during its quarantine, returns
+// false if the context ends before the quarantine ends.
+func (m *Manager) quarantineNotifier(ctx context.Context, n registeredNotifier, b *notifierBreaker, reason error) bool {
+	b.triggers = nil
+	b.errors = 0
+
+	m.quarantined.add(n.name)
+	defer m.quarantined.remove(n.name)
+	m.emit(ctx, Event{Type: EventNotifierQuarantined, Notifier: n.name, Err: reason})
+
+	t := m.cfg.clock.NewTimer(b.cfg.Quarantine)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C():
+	}
+
+	m.emit(ctx, Event{Type: EventNotifierReleased, Notifier: n.name})
+	return true
+}
@@ -0,0 +1,104 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerNotifierBreaker(t *testing.T) {
+	tests := map[string]struct {
+		breaker    reload.NotifierBreaker
+		results    []error
+		expReloads []string
+		expTrail   []string
+	}{
+		"A notifier that triggers too often should be quarantined.": {
+			breaker:    reload.NotifierBreaker{MaxTriggers: 2, Quarantine: time.Minute},
+			results:    []error{nil, nil, nil},
+			expReloads: []string{"t0", "t1"},
+			expTrail: []string{
+				"notifier_quarantined id= notifier=flapping err=notifier flapping: 3 triggers in 1m0s",
+				"notifier_released id= notifier=flapping",
+			},
+		},
+
+		"A notifier that fails repeatedly should be quarantined.": {
+			breaker:    reload.NotifierBreaker{MaxErrors: 2, Quarantine: time.Minute},
+			results:    []error{fmt.Errorf("something"), nil, fmt.Errorf("something"), fmt.Errorf("something")},
+			expReloads: []string{"t1"},
+			expTrail: []string{
+				"notifier_quarantined id= notifier=flapping err=2 consecutive errors: something",
+				"notifier_released id= notifier=flapping",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Now())
+			rec := reloadtest.NewRecorder()
+			released := make(chan struct{})
+			reloaded := make(chan string, len(test.results))
+			m := reload.NewManager(
+				reload.WithClock(clock),
+				reload.WithSubscriber(rec),
+				reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+					switch e.Type {
+					case reload.EventNotifierReleased:
+						close(released)
+					case reload.EventReloadFinished:
+						reloaded <- e.Trigger.ID
+					}
+				})),
+			)
+			calls := 0
+			m.On(reload.NotifierFunc(func(ctx context.Context) (string, error) {
+				if calls >= len(test.results) {
+					<-ctx.Done()
+					return "", ctx.Err()
+				}
+				i := calls
+				calls++
+				return fmt.Sprintf("t%d", i), test.results[i]
+			}), reload.WithNotifierName("flapping"), reload.WithNotifierBreaker(test.breaker))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			require.True(clock.WaitWaiters(1, time.Second))
+			assert.Equal([]string{"flapping"}, m.Status().QuarantinedNotifiers)
+			clock.Advance(time.Minute)
+			<-released
+			var gotReloads []string
+			for range test.expReloads {
+				gotReloads = append(gotReloads, <-reloaded)
+			}
+			cancel()
+
+			// Check.
+			assert.NoError(<-runFinished)
+			assert.Empty(m.Status().QuarantinedNotifiers)
+			assert.Equal(test.expReloads, gotReloads)
+			var gotTrail []string
+			for _, e := range rec.Events() {
+				if e.Type == reload.EventNotifierQuarantined || e.Type == reload.EventNotifierReleased {
+					gotTrail = append(gotTrail, reloadtest.TrailLine(e))
+				}
+			}
+			assert.Equal(test.expTrail, gotTrail)
+		})
+	}
+}
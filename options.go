@@ -0,0 +1,690 @@
+package reload
+
+import (
+	"context"
+	"time"
+)
+
+// ManagerOption is an option to customize the Manager.
+type ManagerOption func(*managerConfig)
+
+type managerConfig struct {
+	auditSink       AuditSink
+	subscribers     []Subscriber
+	metricsRecorder MetricsRecorder
+	locker          DistributedLocker
+	// notifierStopTimeout is the time Run waits for the notifiers to stop.
+	notifierStopTimeout time.Duration
+	staleTriggerPolicy  StaleTriggerPolicy
+	shutdownGracePeriod time.Duration
+	reloadBudget        time.Duration
+	orderedReloaders    bool
+	clock               Clock
+	groupTriggerIDs     map[int][]string
+	groupNames          map[int]string
+	queueSize           int
+	queueOverflow       QueueOverflowPolicy
+	reloadWindow        ReloadWindow
+	gate                Gate
+	approval            *Approval
+	pendingTriggerFile  string
+	reloadRetry         *ReloadRetry
+	executor            Executor
+	scheduler           Scheduler
+	// triggerFilters are the trigger filters and transforms in registration
+	// order.
+	triggerFilters []func(TriggerEvent) (TriggerEvent, bool)
+}
+
+func newManagerConfig(opts []ManagerOption) managerConfig {
+	cfg := managerConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.metricsRecorder == nil {
+		cfg.metricsRecorder = noopMetricsRecorder{}
+	}
+
+	if cfg.staleTriggerPolicy == "" {
+		cfg.staleTriggerPolicy = StaleTriggerKeep
+	}
+
+	if cfg.clock == nil {
+		cfg.clock = RealClock
+	}
+
+	if cfg.queueOverflow == "" {
+		cfg.queueOverflow = QueueOverflowBlock
+	}
+
+	if cfg.approval != nil && cfg.approval.Expiration <= 0 {
+		cfg.approval.Expiration = time.Hour
+	}
+
+	if cfg.executor == nil {
+		cfg.executor = ParallelExecutor()
+	}
+
+	if cfg.reloadRetry != nil {
+		cfg.reloadRetry.defaults()
+	}
+
+	return cfg
+}
+
+// WithAuditSink sets an audit sink that will receive an audit record for
+// every reload attempt.
+func WithAuditSink(s AuditSink) ManagerOption {
+	return func(c *managerConfig) {
+		c.auditSink = s
+	}
+}
+
+// WithSubscriber adds a subscriber that will receive the manager lifecycle
+// events. Can be used multiple times to add multiple subscribers.
+func WithSubscriber(s Subscriber) ManagerOption {
+	return func(c *managerConfig) {
+		c.subscribers = append(c.subscribers, s)
+	}
+}
+
+// GroupProgress are the callbacks to follow the progress of the reloader
+// priority groups of the reload processes (e.g: to log the progress of long
+// multi-group reloads), any of them can be nil.
+type GroupProgress struct {
+	// OnGroupStart is called when a group starts, with the names of the group
+	// reloaders that will be reloaded.
+	OnGroupStart func(ctx context.Context, priority int, reloaders []string)
+	// OnGroupEnd is called when a group ends, with or without error.
+	OnGroupEnd func(ctx context.Context, priority int, err error, duration time.Duration)
+}
+
+// WithGroupProgress sets callbacks that are called when the reloader priority
+// groups start and end. Like the subscribers, they are called synchronously.
+func WithGroupProgress(p GroupProgress) ManagerOption {
+	return WithSubscriber(SubscriberFunc(func(ctx context.Context, e Event) {
+		switch {
+		case e.Type == EventGroupStarted && p.OnGroupStart != nil:
+			p.OnGroupStart(ctx, e.Priority, e.Reloaders)
+		case e.Type == EventGroupFinished && p.OnGroupEnd != nil:
+			p.OnGroupEnd(ctx, e.Priority, e.Err, e.Duration)
+		}
+	}))
+}
+
+// WithMetricsRecorder sets the recorder that will receive the metrics of the
+// reload mechanism.
+//
+// By default the metrics are not recorded.
+func WithMetricsRecorder(r MetricsRecorder) ManagerOption {
+	return func(c *managerConfig) {
+		c.metricsRecorder = r
+	}
+}
+
+// WithDistributedLocker sets a distributed lock that will be acquired before
+// running the reloaders and released after, so only one replica at a time
+// executes the reload (e.g: a disruptive reload that rebinds shared resources),
+// serializing the rollout across the fleet.
+//
+// If the lock can't be acquired the reload process will fail.
+func WithDistributedLocker(l DistributedLocker) ManagerOption {
+	return func(c *managerConfig) {
+		c.locker = l
+	}
+}
+
+// WithNotifierStopTimeout makes the manager Run wait for the notifiers to stop
+// after the manager stops, up to the timeout. The notifiers should end when
+// their context is cancelled, if they don't Run returns an error wrapping
+// ErrNotifierStopTimeout.
+//
+// By default Run cancels the context of the notifiers without waiting for
+// them.
+func WithNotifierStopTimeout(d time.Duration) ManagerOption {
+	return func(c *managerConfig) {
+		c.notifierStopTimeout = d
+	}
+}
+
+// StaleTriggerPolicy is how the manager handles the triggers that were queued
+// while a reload was in progress.
+type StaleTriggerPolicy string
+
+const (
+	// StaleTriggerKeep reloads once for every queued trigger.
+	StaleTriggerKeep StaleTriggerPolicy = "keep"
+	// StaleTriggerDrop skips the queued triggers that were triggered before the
+	// last reload started, as the reload already has their changes.
+	StaleTriggerDrop StaleTriggerPolicy = "drop"
+	// StaleTriggerCollapse collapses all the queued triggers into a single
+	// reload with the latest trigger.
+	StaleTriggerCollapse StaleTriggerPolicy = "collapse"
+)
+
+// WithStaleTriggerPolicy sets how the triggers queued while reloading are
+// handled once the reload completes, so they don't cause redundant reloads.
+// The skipped triggers are reported as skipped reloads.
+//
+// By default StaleTriggerKeep.
+func WithStaleTriggerPolicy(p StaleTriggerPolicy) ManagerOption {
+	return func(c *managerConfig) {
+		c.staleTriggerPolicy = p
+	}
+}
+
+// QueueOverflowPolicy is how a notifier trigger is handled when the trigger
+// queue is full.
+type QueueOverflowPolicy string
+
+const (
+	// QueueOverflowBlock blocks the notifier until the trigger can be queued.
+	QueueOverflowBlock QueueOverflowPolicy = "block"
+	// QueueOverflowDropNewest drops the new trigger.
+	QueueOverflowDropNewest QueueOverflowPolicy = "drop_newest"
+	// QueueOverflowDropOldest drops the oldest queued trigger to queue the new
+	// one.
+	QueueOverflowDropOldest QueueOverflowPolicy = "drop_oldest"
+)
+
+// WithTriggerQueue sets the size of the queue of the notifier triggers that
+// wait while a reload is in progress, and how the triggers are handled when
+// it's full (see WithNotifierQueueOverflow to set it per notifier). The dropped
+// triggers are reported with EventTriggerDropped and
+// MetricsRecorder.IncDroppedTrigger. The notifier errors are never dropped.
+//
+// By default the size is the number of notifiers and QueueOverflowBlock.
+func WithTriggerQueue(size int, policy QueueOverflowPolicy) ManagerOption {
+	return func(c *managerConfig) {
+		c.queueSize = size
+		c.queueOverflow = policy
+	}
+}
+
+// WithReloadWindow restricts when the notifier triggered reloads can be
+// executed (e.g: DailyReloadWindow), for the services where reconfiguring out
+// of a maintenance window is forbidden. The triggers received while the window
+// is closed are deferred (see EventReloadDeferred) and coalesced into a single
+// reload per route (the triggers that reload the same reloaders) with the
+// latest trigger, the routes are reloaded in order when the window opens.
+//
+// The manual reloads (see Manager.TriggerReload) are not restricted.
+//
+// By default the reloads can be executed at any time.
+func WithReloadWindow(w ReloadWindow) ManagerOption {
+	return func(c *managerConfig) {
+		c.reloadWindow = w
+	}
+}
+
+// WithGate sets a gate that the reload process waits for before running the
+// reloaders (e.g: InFlightGate to wait until the service is idle). If the gate
+// fails, the reload fails without running any reloader. The gate is consulted
+// once the reload holds its in-progress locks, so the triggers received while
+// waiting are handled like the ones received while reloading.
+//
+// By default the reloaders run without waiting.
+func WithGate(g Gate) ManagerOption {
+	return func(c *managerConfig) {
+		c.gate = g
+	}
+}
+
+// WithApproval makes the notifier triggered reloads wait for a manual approval
+// (see Manager.Approve and Manager.Reject), for the services that require a
+// change-management process. The trigger waiting for the approval is on the
+// manager status and the approval lifecycle emits EventApprovalPending,
+// EventApprovalApproved and EventApprovalRejected. The triggers of the same
+// route received while waiting replace the pending one, that is skipped, the
+// ones of other routes are coalesced per route and wait for their own approval
+// next, bounded by the trigger queue (see WithTriggerQueue).
+//
+// The manual reloads (see Manager.TriggerReload) don't need approval.
+//
+// By default the reloads don't need approval.
+func WithApproval(a Approval) ManagerOption {
+	return func(c *managerConfig) {
+		c.approval = &a
+	}
+}
+
+// WithReloadRetry retries the failed notifier triggered reloads as a whole,
+// re-running the reload process with the same trigger on an exponential
+// backoff until it succeeds or the attempts are exhausted, for the failures
+// caused by transient dependencies (see ReloadRetry.IsTransient). Every retry
+// emits EventReloadRetrying, and the manager Run only fails when the last
+// attempt, or one failing with a permanent error, fails. The triggers received
+// meanwhile are queued.
+//
+// The manual reloads (see Manager.TriggerReload) are not retried.
+//
+// By default the failed reloads are not retried.
+func WithReloadRetry(r ReloadRetry) ManagerOption {
+	return func(c *managerConfig) {
+		c.reloadRetry = &r
+	}
+}
+
+// WithPendingTriggerFile persists the notifier triggers pending to be reloaded
+// on the file, so when the process crashes or stops before their reload
+// completes, the manager Run reloads them immediately on the next start and the
+// triggers are not lost across restarts. All the pending triggers are
+// recovered in order, with the RecoveredTriggerMetadataKey metadata.
+//
+// The file is written atomically and synced on every trigger, and removed once
+// the reloads of all the pending triggers complete (successfully or not). The
+// pending triggers are recovered on 3 starts at most, then they are dropped
+// (see DropReasonRecoveryLimit) so a trigger that crashes the process doesn't
+// crash it on every start. The manual reloads (see Manager.TriggerReload) are
+// not persisted.
+//
+// By default the pending triggers are not persisted.
+func WithPendingTriggerFile(path string) ManagerOption {
+	return func(c *managerConfig) {
+		c.pendingTriggerFile = path
+	}
+}
+
+// WithTriggerFilter adds a filter of the notifier triggers, the triggers that
+// the filter returns false for are dropped (see EventTriggerDropped and
+// DropReasonFiltered), e.g: to ignore the triggers of a pattern or during the
+// startup warm-up without wrapping every notifier. Can be used multiple times,
+// the filters and transforms are applied in registration order.
+//
+// The manual reloads (see Manager.TriggerReload) are not filtered.
+func WithTriggerFilter(f func(t TriggerEvent) bool) ManagerOption {
+	return func(c *managerConfig) {
+		c.triggerFilters = append(c.triggerFilters, func(t TriggerEvent) (TriggerEvent, bool) { return t, f(t) })
+	}
+}
+
+// WithTriggerTransform adds a transform of the notifier triggers, e.g: to
+// normalize the trigger IDs or add metadata. Can be used multiple times, the
+// filters and transforms are applied in registration order.
+//
+// The manual reloads (see Manager.TriggerReload) are not transformed.
+func WithTriggerTransform(f func(t TriggerEvent) TriggerEvent) ManagerOption {
+	return func(c *managerConfig) {
+		c.triggerFilters = append(c.triggerFilters, func(t TriggerEvent) (TriggerEvent, bool) { return f(t), true })
+	}
+}
+
+// WithShutdownGracePeriod sets a grace period for the in-flight reload when
+// the manager stops. Instead of cancelling the reloaders context when the Run
+// context is cancelled, the reload process continues with a detached context
+// until it ends or the grace period expires, so the shutdown doesn't leave
+// resources half-reconfigured.
+//
+// If the grace period expires, the reloaders are cancelled and the reload
+// fails with ErrShutdownGracePeriodExceeded reporting the reloaders that
+// didn't finish in time.
+//
+// By default disabled, the reloaders are cancelled when the manager stops.
+func WithShutdownGracePeriod(d time.Duration) ManagerOption {
+	return func(c *managerConfig) {
+		c.shutdownGracePeriod = d
+	}
+}
+
+// WithReloadBudget sets the total time budget of the reloader groups of a
+// reload. Each group runs with the remaining budget as its context deadline, so
+// the slow early groups can't leave the later ones running without time. When
+// the budget expires the running reloaders are cancelled and the reload fails
+// with ErrReloadBudgetExceeded, without running the remaining groups.
+//
+// The budget starts when the first group starts, the time waiting for the
+// gate (see WithGate) or the distributed lock is not counted.
+//
+// By default the reloads don't have a budget.
+func WithReloadBudget(d time.Duration) ManagerOption {
+	return func(c *managerConfig) {
+		c.reloadBudget = d
+	}
+}
+
+// WithOrderedReloaders makes the reloaders of the same priority group run one
+// at a time in weight and registration order (see WithReloaderWeight), instead
+// of in parallel. This makes the reload execution deterministic for debugging
+// and testing, at the cost of slower reloads.
+//
+// By default the reloaders of a group are started in that order but run in
+// parallel, so their execution order is not deterministic.
+func WithOrderedReloaders() ManagerOption {
+	return func(c *managerConfig) {
+		c.orderedReloaders = true
+	}
+}
+
+// WithExecutor sets how the reloaders of a priority group are run (e.g:
+// PoolExecutor to bound the reloaders running in parallel, WeightedExecutor or
+// a custom Executor). WithOrderedReloaders takes precedence, running them with
+// SequentialExecutor.
+//
+// By default ParallelExecutor.
+func WithExecutor(e Executor) ManagerOption {
+	return func(c *managerConfig) {
+		c.executor = e
+	}
+}
+
+// WithScheduler sets the Scheduler that decides which queued trigger is
+// reloaded next and which ones are coalesced into it, e.g: PriorityScheduler to
+// prefer the admin triggers over the file watch ones. The scheduled triggers are
+// taken from the queue, up to the queue size (see WithTriggerQueue), so the queue
+// overflow policy applies to the rest. The triggers are scheduled before the
+// reload window, approval and stale trigger policy are applied.
+//
+// By default the queued triggers are reloaded in FIFO order (see
+// StaleTriggerPolicy to coalesce them).
+func WithScheduler(s Scheduler) ManagerOption {
+	return func(c *managerConfig) {
+		c.scheduler = s
+	}
+}
+
+// WithGroupName sets the name of the priority group, used to identify the
+// group on the errors and the status (e.g: `servers`).
+//
+// By default the groups don't have a name.
+func WithGroupName(priority int, name string) ManagerOption {
+	return func(c *managerConfig) {
+		if c.groupNames == nil {
+			c.groupNames = map[int]string{}
+		}
+		c.groupNames[priority] = name
+	}
+}
+
+// WithGroupTriggerIDs sets the trigger IDs that will reload the priority group,
+// using `path.Match` patterns to match classes of triggers (e.g: `logrotate`
+// or `tls-*`). The group is skipped on the reloads of other trigger IDs, so a
+// cheap trigger (e.g: reopening the log files) doesn't run the expensive groups
+// of the same manager. The invalid patterns don't match any trigger ID.
+//
+// By default the groups are reloaded by all the triggers.
+func WithGroupTriggerIDs(priority int, patterns ...string) ManagerOption {
+	return func(c *managerConfig) {
+		if c.groupTriggerIDs == nil {
+			c.groupTriggerIDs = map[int][]string{}
+		}
+		c.groupTriggerIDs[priority] = append(c.groupTriggerIDs[priority], patterns...)
+	}
+}
+
+// NotifierOption is an option to customize a notifier when registered on the manager.
+type NotifierOption func(*notifierConfig)
+
+type notifierConfig struct {
+	name          string
+	breaker       *NotifierBreaker
+	metadata      map[string]string
+	verifier      TriggerVerifier
+	rateLimit     *NotifierRateLimit
+	liveness      *NotifierLiveness
+	queueOverflow QueueOverflowPolicy
+	urgency       TriggerUrgency
+	trustUrgency  bool
+	reason        string
+}
+
+// WithNotifierName sets the name of the notifier, this name will be set as the
+// source of the triggers of the notifier.
+//
+// By default the name will be `notifier-{index}` based on the registration order.
+func WithNotifierName(name string) NotifierOption {
+	return func(c *notifierConfig) {
+		c.name = name
+	}
+}
+
+// WithNotifierMetadata sets static metadata on the notifier that the manager
+// adds to the metadata of all its triggers, so the reloaders (using
+// TriggerEventFromContext), subscribers and audit sinks can differentiate the
+// trigger sources without parsing the trigger ID. The metadata set by the
+// notifier on the trigger takes precedence over this one.
+func WithNotifierMetadata(md map[string]string) NotifierOption {
+	return func(c *notifierConfig) {
+		c.metadata = make(map[string]string, len(md))
+		for k, v := range md {
+			c.metadata[k] = v
+		}
+	}
+}
+
+// WithNotifierVerifier sets a verifier of the notifier triggers (e.g:
+// NewHMACTriggerVerifier), for the network-facing notifiers so a spoofed
+// trigger can't drive the reloads. The triggers that fail the verification are
+// dropped before being queued (see EventTriggerDropped and
+// DropReasonUnverified).
+//
+// By default the triggers are not verified.
+func WithNotifierVerifier(v TriggerVerifier) NotifierOption {
+	return func(c *notifierConfig) {
+		c.verifier = v
+	}
+}
+
+// WithNotifierBreaker sets a circuit breaker on the notifier that quarantines
+// it when it triggers too often or fails repeatedly (see NotifierBreaker), so
+// a trigger source that went haywire doesn't keep the manager reloading or
+// stop it. EventNotifierQuarantined and EventNotifierReleased are emitted when
+// the notifier enters and leaves the quarantine, and the quarantined notifiers
+// are reported on Status.QuarantinedNotifiers.
+//
+// By default the notifiers don't have a breaker.
+func WithNotifierBreaker(b NotifierBreaker) NotifierOption {
+	return func(c *notifierConfig) {
+		b.defaults()
+		c.breaker = &b
+	}
+}
+
+// WithNotifierRateLimit limits the rate of the notifier triggers using a token
+// bucket (see NotifierRateLimit), e.g: a public webhook can trigger at most
+// once per minute while SIGHUP is unlimited. The last trigger that exceeds the
+// limit is reloaded once the limit refills, the earlier ones are dropped (see
+// EventTriggerDropped and DropReasonRateLimited).
+//
+// By default the notifiers are not rate limited.
+func WithNotifierRateLimit(l NotifierRateLimit) NotifierOption {
+	return func(c *notifierConfig) {
+		l.defaults()
+		c.rateLimit = &l
+	}
+}
+
+// WithNotifierLiveness flags the notifier as stale when it's waiting without
+// triggering or heartbeating (see NotifierHeartbeat) for the liveness period,
+// so the dead trigger sources (e.g: a silently dead watch stream) are detected.
+// The stale notifiers are reported with EventNotifierStale, on the manager
+// status and with MetricsRecorder.SetNotifierStale, and can be restarted (see
+// NotifierLiveness).
+//
+// By default the notifiers liveness is not checked.
+func WithNotifierLiveness(l NotifierLiveness) NotifierOption {
+	return func(c *notifierConfig) {
+		l.defaults()
+		c.liveness = &l
+	}
+}
+
+// WithNotifierQueueOverflow sets how the notifier triggers are handled when
+// the trigger queue is full, so the trigger sources with different loss
+// tolerances can use different policies (see WithTriggerQueue).
+//
+// By default the manager policy.
+func WithNotifierQueueOverflow(p QueueOverflowPolicy) NotifierOption {
+	return func(c *notifierConfig) {
+		c.queueOverflow = p
+	}
+}
+
+// WithNotifierUrgency sets the urgency of the notifier triggers (e.g:
+// TriggerUrgencyUrgent for an admin notifier). The urgency set by the notifier
+// on the trigger is ignored unless the notifier is trusted (see
+// WithNotifierTrustedUrgency).
+//
+// By default TriggerUrgencyNormal.
+func WithNotifierUrgency(u TriggerUrgency) NotifierOption {
+	return func(c *notifierConfig) {
+		c.urgency = u
+	}
+}
+
+// WithNotifierTrustedUrgency honors the urgency set by the notifier on the
+// trigger, it takes precedence over the WithNotifierUrgency one. Only for the
+// notifiers that don't forward the urgency from untrusted sources, as the
+// urgent triggers bypass the rate limits and the reload windows.
+//
+// By default the urgency set by the notifier is ignored.
+func WithNotifierTrustedUrgency() NotifierOption {
+	return func(c *notifierConfig) {
+		c.trustUrgency = true
+	}
+}
+
+// WithNotifierReason sets the reason of the notifier triggers (e.g: `config
+// file changed`), see TriggerEvent.Reason. The reason set by the notifier on
+// the trigger takes precedence over this one.
+//
+// By default no reason.
+func WithNotifierReason(reason string) NotifierOption {
+	return func(c *notifierConfig) {
+		c.reason = reason
+	}
+}
+
+// ReloaderOption is an option to customize a reloader when added to the manager.
+type ReloaderOption func(*reloaderConfig)
+
+type reloaderConfig struct {
+	name     string
+	sources  []string
+	timeout  time.Duration
+	pipeline string
+	weight   int
+	tags     []string
+	// breakerFailures and breakerCoolDown configure the circuit breaker.
+	breakerFailures int
+	breakerCoolDown time.Duration
+}
+
+func (c reloaderConfig) breaker() *circuitBreaker {
+	if c.breakerFailures <= 0 {
+		return nil
+	}
+
+	return &circuitBreaker{failures: c.breakerFailures, coolDown: c.breakerCoolDown}
+}
+
+// WithReloaderName sets the name of the reloader, used to identify the reloader
+// on the metrics.
+//
+// By default the name will be `reloader-{index}` based on the registration order.
+func WithReloaderName(name string) ReloaderOption {
+	return func(c *reloaderConfig) {
+		c.name = name
+	}
+}
+
+// WithTriggerSources sets the trigger sources (notifier names, `manual` and
+// `rollback`) that will reload the reloader, using `path.Match` patterns (e.g:
+// `config-*`). The reloader is skipped on the reloads triggered by other
+// sources, so the same manager can have independent pipelines fed by
+// different notifiers. The invalid patterns don't match any source.
+//
+// A priority group without reloaders for the trigger source is skipped.
+//
+// By default the reloader is reloaded by all the sources.
+func WithTriggerSources(patterns ...string) ReloaderOption {
+	return func(c *reloaderConfig) {
+		c.sources = append(c.sources, patterns...)
+	}
+}
+
+// WithReloaderTags sets tags on the reloader (e.g: `tls`, `expensive` or `db`),
+// so the triggers can select the reloaders to reload with a tag expression (see
+// TriggerEvent.TagSelector) for targeted partial reloads. A priority group
+// without reloaders selected by the trigger is skipped.
+//
+// By default the reloader doesn't have tags, it's only reloaded by the triggers
+// without tag selector or with a selector that matches no tags (e.g: `!tls`).
+func WithReloaderTags(tags ...string) ReloaderOption {
+	return func(c *reloaderConfig) {
+		c.tags = append(c.tags, tags...)
+	}
+}
+
+// WithPipeline sets the pipeline of the reloader. Every pipeline has its own
+// in-progress lock, so a reload process only blocks the reloads of the
+// pipelines of its reloaders (e.g: an in-flight certificates reload doesn't
+// block a log level reload triggered with TriggerReload). Combined with
+// WithTriggerSources, the unrelated reload flows of the same manager don't
+// serialize against each other.
+//
+// The Run loop still handles the notifier triggers one at a time.
+//
+// By default all the reloaders are on the same pipeline.
+func WithPipeline(name string) ReloaderOption {
+	return func(c *reloaderConfig) {
+		c.pipeline = name
+	}
+}
+
+// WithReloaderWeight sets the order of the reloader inside its priority group.
+// The reloaders with a lower weight start first, and the ones with the same
+// weight start in registration order. With WithOrderedReloaders the reloaders
+// run in this order, and in parallel mode it's their deterministic start order,
+// so fine-grained ordering doesn't need a priority group per reloader.
+//
+// By default 0.
+func WithReloaderWeight(w int) ReloaderOption {
+	return func(c *reloaderConfig) {
+		c.weight = w
+	}
+}
+
+// WithReloaderTimeout sets the maximum duration of the reloader reload. When
+// exceeded, the reloader context is cancelled and the reload fails with
+// ErrReloaderTimeout without waiting for the reloader to return.
+//
+// The abandoned reloaders are tracked until they return (see
+// Status.AbandonedReloaders and MetricsRecorder.AddAbandonedReloaders), and
+// EventAbandonedReloaderReturned is emitted when they do, to diagnose stuck
+// reloaders.
+//
+// By default the reloaders don't have a timeout.
+func WithReloaderTimeout(d time.Duration) ReloaderOption {
+	return func(c *reloaderConfig) {
+		c.timeout = d
+	}
+}
+
+// WithCircuitBreaker sets a circuit breaker on the reloader. After the
+// consecutive failures the circuit opens and the reloader is skipped (see
+// EventReloaderSkipped) during the cool-down, instead of failing every reload
+// process. After the cool-down the reloader is reloaded again, if it succeeds
+// the circuit closes, if it fails the circuit opens for another cool-down.
+//
+// The open circuits are reported on Status.OpenCircuits and
+// MetricsRecorder.SetCircuitOpen, and can be closed manually with
+// Manager.ResetCircuitBreaker.
+//
+// By default the reloaders don't have a circuit breaker.
+func WithCircuitBreaker(failures int, coolDown time.Duration) ReloaderOption {
+	return func(c *reloaderConfig) {
+		c.breakerFailures = failures
+		c.breakerCoolDown = coolDown
+	}
+}
+
+// WithClock sets the clock used by the manager timestamps, durations and
+// timeouts (e.g: a `reloadtest.Clock` on tests).
+//
+// By default RealClock.
+func WithClock(c Clock) ManagerOption {
+	return func(cfg *managerConfig) {
+		cfg.clock = c
+	}
+}
@@ -0,0 +1,202 @@
+package reload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// RecoveredTriggerMetadataKey is the trigger metadata key set to `true` on the
+// pending triggers recovered on start (see WithPendingTriggerFile).
+const RecoveredTriggerMetadataKey = "recovered"
+
+// ErrPendingTriggerRecoveryLimit is the reason of the pending triggers dropped
+// on start because they were already recovered too many times (see
+// WithPendingTriggerFile).
+var ErrPendingTriggerRecoveryLimit = errors.New("pending trigger recovered too many times")
+
+// maxPendingRecoveries is the number of starts that recover the same pending
+// triggers before dropping them, so a trigger that crashes the process on
+// every reload doesn't crash it forever.
+const maxPendingRecoveries = 3
+
+// pendingMarker persists the notifier triggers that are pending to be
+// reloaded, so the triggers received before a crash are reloaded on the next
+// start.
+//
+// The triggers are numbered when marked and removed once their reload
+// completes (or they are discarded), the marker file is removed once there are
+// no pending triggers. The marker counts the starts that recovered its
+// triggers, the counter is reset when the marker is removed.
+type pendingMarker struct {
+	path string
+
+	mu         sync.Mutex
+	last       uint64
+	triggers   []pendingTrigger // Sorted by number.
+	recoveries int
+}
+
+type pendingTrigger struct {
+	n    uint64
+	data json.RawMessage
+}
+
+// pendingFile is the persisted marker.
+type pendingFile struct {
+	Recoveries int               `json:"recoveries"`
+	Triggers   []json.RawMessage `json:"triggers"`
+}
+
+func newPendingMarker(path string) *pendingMarker {
+	if path == "" {
+		return nil
+	}
+
+	return &pendingMarker{path: path}
+}
+
+// recover returns the pending triggers of the previous run, if any, as the
+// first marked triggers. When the triggers have been recovered too many times
+// they are returned as dropped instead.
+func (p *pendingMarker) recover(at time.Time) (recovered []notifierResult, dropped []TriggerEvent, err error) {
+	if p == nil {
+		return nil, nil, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("could not read pending trigger file: %w", err)
+	}
+
+	var pf pendingFile
+	err = json.Unmarshal(data, &pf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid pending trigger file: %w", err)
+	}
+
+	triggers := make([]TriggerEvent, 0, len(pf.Triggers))
+	for _, data := range pf.Triggers {
+		var je jsonEvent
+		err := json.Unmarshal(data, &je)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pending trigger file: %w", err)
+		}
+		triggers = append(triggers, je.event().Trigger)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pf.Recoveries >= maxPendingRecoveries {
+		p.triggers = nil
+		p.recoveries = 0
+		_ = os.Remove(p.path)
+		return nil, triggers, nil
+	}
+
+	p.recoveries = pf.Recoveries + 1
+	for i, t := range triggers {
+		t.Metadata = mergeMetadata(t.Metadata, map[string]string{RecoveredTriggerMetadataKey: "true"})
+		p.last++
+		p.triggers = append(p.triggers, pendingTrigger{n: p.last, data: pf.Triggers[i]})
+		recovered = append(recovered, notifierResult{Trigger: t, At: at, Pending: []uint64{p.last}})
+	}
+	err = p.write()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return recovered, nil, nil
+}
+
+// mark persists the trigger as pending and returns its number. The marker is
+// best-effort, the write errors are ignored.
+func (p *pendingMarker) mark(t TriggerEvent, at time.Time) []uint64 {
+	if p == nil {
+		return nil
+	}
+
+	data, err := MarshalEventJSON(Event{Type: EventTriggerReceived, Time: at, Trigger: t})
+	if err != nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last++
+	p.triggers = append(p.triggers, pendingTrigger{n: p.last, data: data})
+	_ = p.write()
+
+	return []uint64{p.last}
+}
+
+// done removes the triggers from the marker once their reload completes, the
+// marker is removed when no triggers are pending.
+func (p *pendingMarker) done(ns []uint64) {
+	if p == nil || len(ns) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	remove := make(map[uint64]bool, len(ns))
+	for _, n := range ns {
+		remove[n] = true
+	}
+	triggers := p.triggers[:0]
+	for _, t := range p.triggers {
+		if !remove[t.n] {
+			triggers = append(triggers, t)
+		}
+	}
+	p.triggers = triggers
+
+	if len(p.triggers) > 0 {
+		_ = p.write()
+		return
+	}
+	p.recoveries = 0
+	_ = os.Remove(p.path)
+}
+
+// write persists the pending triggers, the marker needs to be locked.
+func (p *pendingMarker) write() error {
+	pf := pendingFile{Recoveries: p.recoveries, Triggers: make([]json.RawMessage, 0, len(p.triggers))}
+	for _, t := range p.triggers {
+		pf.Triggers = append(pf.Triggers, t.data)
+	}
+	data, err := json.Marshal(pf)
+	if err != nil {
+		return fmt.Errorf("could not marshal pending triggers: %w", err)
+	}
+
+	// Write atomically so a crash doesn't leave a partial marker.
+	tmp := p.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not write pending trigger file: %w", err)
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err != nil || closeErr != nil {
+		return fmt.Errorf("could not write pending trigger file: %w", errors.Join(err, closeErr))
+	}
+	err = os.Rename(tmp, p.path)
+	if err != nil {
+		return fmt.Errorf("could not write pending trigger file: %w", err)
+	}
+
+	return nil
+}
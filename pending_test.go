@@ -0,0 +1,288 @@
+package reload_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerPendingTriggerFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "pending")
+
+	// Execute: the reload is interrupted by the stop.
+	reloadStarted := make(chan struct{})
+	m1 := reload.NewManager(reload.WithPendingTriggerFile(path))
+	m1.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		close(reloadStarted)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	notifierC := make(chan string, 1)
+	m1.On(reload.NotifierChan(notifierC), reload.WithNotifierMetadata(map[string]string{"k": "v"}))
+	ctx, cancel := context.WithCancel(context.Background())
+	runFinished := make(chan error)
+	go func() { runFinished <- m1.Run(ctx) }()
+	notifierC <- "test-id"
+	<-reloadStarted
+	cancel()
+	<-runFinished
+
+	// Check: the trigger is pending.
+	_, err := os.Stat(path)
+	require.NoError(err)
+
+	// Execute: the next start reloads the pending trigger.
+	gotTriggers := make(chan reload.TriggerEvent, 1)
+	m2 := reload.NewManager(reload.WithPendingTriggerFile(path))
+	m2.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		t, _ := reload.TriggerEventFromContext(ctx)
+		gotTriggers <- t
+		return nil
+	}))
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() { runFinished <- m2.Run(ctx) }()
+
+	// Check.
+	select {
+	case got := <-gotTriggers:
+		assert.Equal("test-id", got.ID)
+		assert.Equal(map[string]string{"k": "v", reload.RecoveredTriggerMetadataKey: "true"}, got.Metadata)
+	case <-time.After(time.Second):
+		require.Fail("pending trigger was not reloaded")
+	}
+	assert.Eventually(func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(<-runFinished)
+}
+
+func TestManagerPendingTriggerFileCompleted(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "pending")
+	reloaded := make(chan struct{}, 2)
+	m := reload.NewManager(
+		reload.WithPendingTriggerFile(path),
+		reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+			if e.Type == reload.EventReloadFinished {
+				reloaded <- struct{}{}
+			}
+		})),
+	)
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }))
+	notifierC := make(chan string, 1)
+	m.On(reload.NotifierChan(notifierC))
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runFinished := make(chan error)
+	go func() { runFinished <- m.Run(ctx) }()
+	notifierC <- "test-id"
+	<-reloaded
+
+	// Check.
+	assert.Eventually(func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(<-runFinished)
+}
+
+func TestManagerPendingTriggerFileMultipleTriggers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "pending")
+
+	// Execute: the reload is interrupted by the stop with other trigger queued.
+	reloadStarted := make(chan struct{})
+	m1 := reload.NewManager(reload.WithPendingTriggerFile(path))
+	m1.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		close(reloadStarted)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	fileC := make(chan string, 1)
+	m1.On(reload.NotifierChan(fileC), reload.WithNotifierName("file"))
+	adminC := make(chan string, 1)
+	m1.On(reload.NotifierChan(adminC), reload.WithNotifierName("admin"))
+	ctx, cancel := context.WithCancel(context.Background())
+	runFinished := make(chan error)
+	go func() { runFinished <- m1.Run(ctx) }()
+	fileC <- "t1"
+	<-reloadStarted
+	adminC <- "t2"
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-runFinished
+
+	// Execute: the next start reloads all the pending triggers.
+	gotTriggers := make(chan string, 2)
+	m2 := reload.NewManager(reload.WithPendingTriggerFile(path))
+	m2.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		t, _ := reload.TriggerEventFromContext(ctx)
+		gotTriggers <- t.Source + "/" + t.ID
+		return nil
+	}))
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() { runFinished <- m2.Run(ctx) }()
+
+	// Check.
+	for _, exp := range []string{"file/t1", "admin/t2"} {
+		select {
+		case got := <-gotTriggers:
+			assert.Equal(exp, got)
+		case <-time.After(time.Second):
+			require.Fail("pending trigger was not reloaded")
+		}
+	}
+	assert.Eventually(func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(<-runFinished)
+}
+
+func TestManagerPendingTriggerFileRecoveryLimit(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "pending")
+	run := func(trigger bool) (reloaded bool, dropped []reload.Event) {
+		reloadStarted := make(chan struct{})
+		m := reload.NewManager(
+			reload.WithPendingTriggerFile(path),
+			reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+				if e.Type == reload.EventTriggerDropped {
+					dropped = append(dropped, e)
+				}
+			})),
+		)
+		m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+			close(reloadStarted)
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+		notifierC := make(chan string, 1)
+		m.On(reload.NotifierChan(notifierC))
+		ctx, cancel := context.WithCancel(context.Background())
+		runFinished := make(chan error)
+		go func() { runFinished <- m.Run(ctx) }()
+		if trigger {
+			notifierC <- "test-id"
+		}
+		select {
+		case <-reloadStarted:
+			reloaded = true
+		case <-time.After(50 * time.Millisecond):
+		}
+		cancel()
+		<-runFinished
+		return reloaded, dropped
+	}
+
+	// Execute: the trigger crashes the process on every start.
+	reloaded, _ := run(true)
+	require.True(reloaded)
+	for range 3 {
+		reloaded, dropped := run(false)
+		require.True(reloaded)
+		require.Empty(dropped)
+	}
+	reloaded, dropped := run(false)
+
+	// Check.
+	assert.False(reloaded)
+	require.Len(dropped, 1)
+	assert.Equal("test-id", dropped[0].Trigger.ID)
+	assert.ErrorIs(dropped[0].Err, reload.ErrPendingTriggerRecoveryLimit)
+	_, err := os.Stat(path)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestManagerPendingTriggerFileQueueOverflow(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "pending")
+
+	// Execute: a trigger is dropped by the queue overflow while reloading.
+	release := make(chan struct{})
+	reloadStarted := make(chan struct{}, 2)
+	reloaded := make(chan struct{}, 2)
+	dropped := make(chan string, 1)
+	m1 := reload.NewManager(
+		reload.WithPendingTriggerFile(path),
+		reload.WithTriggerQueue(1, reload.QueueOverflowDropNewest),
+		reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+			switch e.Type {
+			case reload.EventTriggerDropped:
+				dropped <- e.Trigger.ID
+			case reload.EventReloadFinished:
+				reloaded <- struct{}{}
+			}
+		})),
+	)
+	m1.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		reloadStarted <- struct{}{}
+		<-release
+		return nil
+	}))
+	notifierC := make(chan string, 1)
+	m1.On(reload.NotifierChan(notifierC))
+	ctx, cancel := context.WithCancel(context.Background())
+	runFinished := make(chan error)
+	go func() { runFinished <- m1.Run(ctx) }()
+	notifierC <- "t1"
+	<-reloadStarted
+	notifierC <- "t2"
+	notifierC <- "t3"
+	require.Equal("t3", <-dropped)
+	close(release)
+	<-reloaded
+	<-reloaded
+	cancel()
+	require.NoError(<-runFinished)
+
+	// Check: nothing is pending.
+	_, err := os.Stat(path)
+	assert.True(os.IsNotExist(err))
+
+	// Execute: the next start doesn't recover the dropped trigger.
+	gotTriggers := make(chan string, 1)
+	m2 := reload.NewManager(reload.WithPendingTriggerFile(path))
+	m2.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		gotTriggers <- id
+		return nil
+	}))
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() { runFinished <- m2.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	require.NoError(<-runFinished)
+
+	// Check.
+	assert.Empty(gotTriggers)
+}
@@ -0,0 +1,84 @@
+package reload
+
+import (
+	"sort"
+	"sync"
+)
+
+// pipelineLocks are the in-progress locks of the reload pipelines (see
+// WithPipeline), a reload process holds the locks of all the pipelines of its
+// reloaders, so the reloads of unrelated pipelines don't block each other.
+type pipelineLocks struct {
+	mu       sync.Mutex
+	inFlight map[string]*ReloadInProgressError
+}
+
+func newPipelineLocks() *pipelineLocks {
+	return &pipelineLocks{inFlight: map[string]*ReloadInProgressError{}}
+}
+
+// lock locks all the pipelines, if any of them is locked, nothing is locked
+// and it returns the in-flight reload of the locked pipeline.
+func (p *pipelineLocks) lock(pipelines []string, r *ReloadInProgressError) (*ReloadInProgressError, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, name := range pipelines {
+		if inFlight, ok := p.inFlight[name]; ok {
+			return inFlight, false
+		}
+	}
+
+	for _, name := range pipelines {
+		p.inFlight[name] = r
+	}
+
+	return nil, true
+}
+
+func (p *pipelineLocks) unlock(pipelines []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, name := range pipelines {
+		delete(p.inFlight, name)
+	}
+}
+
+// locked returns if any pipeline is locked.
+func (p *pipelineLocks) locked() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.inFlight) > 0
+}
+
+// planPipelines returns the sorted pipelines of the reload plan reloaders, a
+// plan without reloaders uses the default pipeline.
+func planPipelines(plan []reloaderGroup) []string {
+	set := map[string]struct{}{}
+	for _, rg := range plan {
+		for _, r := range rg.reloaders {
+			set[r.pipeline] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		return []string{""}
+	}
+
+	pipelines := make([]string, 0, len(set))
+	for name := range set {
+		pipelines = append(pipelines, name)
+	}
+	sort.Strings(pipelines)
+
+	return pipelines
+}
+
+// eventPipelines returns the pipelines set on the events, without the default
+// pipeline only.
+func eventPipelines(pipelines []string) []string {
+	if len(pipelines) == 1 && pipelines[0] == "" {
+		return nil
+	}
+	return pipelines
+}
@@ -0,0 +1,72 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerPipelines(t *testing.T) {
+	tests := map[string]struct {
+		source        string
+		expInProgress bool
+	}{
+		"A reload of other pipeline should not be blocked.": {
+			source: "logging",
+		},
+
+		"A reload of the same pipeline should be blocked.": {
+			source:        "certs",
+			expInProgress: true,
+		},
+
+		"A reload of all the pipelines should be blocked.": {
+			source:        "all",
+			expInProgress: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			m := reload.NewManager()
+			started, release := make(chan struct{}), make(chan struct{})
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				if id == "slow" {
+					close(started)
+					<-release
+				}
+				return nil
+			}), reload.WithPipeline("certs"), reload.WithTriggerSources("certs", "all"))
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }),
+				reload.WithPipeline("logging"), reload.WithTriggerSources("logging", "all"))
+
+			// Execute.
+			slowFinished := make(chan error)
+			go func() {
+				slowFinished <- m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "slow", Source: "certs"})
+			}()
+			<-started
+			err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1", Source: test.source})
+			assert.True(m.Status().Reloading)
+			close(release)
+
+			// Check.
+			assert.NoError(<-slowFinished)
+			if test.expInProgress {
+				var inProgressErr *reload.ReloadInProgressError
+				if assert.ErrorAs(err, &inProgressErr) {
+					assert.Equal("slow", inProgressErr.TriggerID)
+				}
+			} else {
+				assert.NoError(err)
+			}
+			assert.False(m.Status().Reloading)
+		})
+	}
+}
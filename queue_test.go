@@ -0,0 +1,112 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerTriggerQueueOverflow(t *testing.T) {
+	tests := map[string]struct {
+		policy         reload.QueueOverflowPolicy
+		notifierPolicy reload.QueueOverflowPolicy
+		urgency        reload.TriggerUrgency
+		expReloads     []string
+		expDropped     []string
+	}{
+		"Blocking the notifier should reload all the triggers.": {
+			policy:     reload.QueueOverflowBlock,
+			expReloads: []string{"t1", "t2", "t3"},
+		},
+
+		"Dropping the newest should drop the new trigger.": {
+			policy:     reload.QueueOverflowDropNewest,
+			expReloads: []string{"t1", "t2"},
+			expDropped: []string{"t3"},
+		},
+
+		"Dropping the oldest should drop the queued trigger.": {
+			policy:     reload.QueueOverflowDropOldest,
+			expReloads: []string{"t1", "t3"},
+			expDropped: []string{"t2"},
+		},
+
+		"The notifier policy should be used over the manager policy.": {
+			policy:         reload.QueueOverflowBlock,
+			notifierPolicy: reload.QueueOverflowDropOldest,
+			expReloads:     []string{"t1", "t3"},
+			expDropped:     []string{"t2"},
+		},
+
+		"Urgent triggers should drop the oldest instead of the newest.": {
+			policy:     reload.QueueOverflowDropNewest,
+			urgency:    reload.TriggerUrgencyUrgent,
+			expReloads: []string{"t1", "t3"},
+			expDropped: []string{"t2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			reloaded := make(chan string, 3)
+			dropped := make(chan string, 3)
+			m := reload.NewManager(
+				reload.WithTriggerQueue(1, test.policy),
+				reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+					switch e.Type {
+					case reload.EventReloadFinished:
+						reloaded <- e.Trigger.ID
+					case reload.EventTriggerDropped:
+						dropped <- e.Trigger.ID
+					}
+				})),
+			)
+			started, release := make(chan struct{}), make(chan struct{})
+			first := true
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				if first {
+					first = false
+					close(started)
+					<-release
+				}
+				return nil
+			}))
+			notifierC := make(chan string)
+			opts := []reload.NotifierOption{reload.WithNotifierUrgency(test.urgency)}
+			if test.notifierPolicy != "" {
+				opts = append(opts, reload.WithNotifierQueueOverflow(test.notifierPolicy))
+			}
+			m.On(reload.NotifierChan(notifierC), opts...)
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			notifierC <- "t1"
+			<-started
+			notifierC <- "t2"
+			notifierC <- "t3"
+			var gotDropped []string
+			for range test.expDropped {
+				gotDropped = append(gotDropped, <-dropped)
+			}
+			close(release)
+			var gotReloads []string
+			for range test.expReloads {
+				gotReloads = append(gotReloads, <-reloaded)
+			}
+			cancel()
+
+			// Check.
+			assert.NoError(<-runFinished)
+			assert.Equal(test.expReloads, gotReloads)
+			assert.Equal(test.expDropped, gotDropped)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrNotifierRateLimited is set on EventTriggerDropped when a notifier trigger
+// exceeds the notifier rate limit.
+var ErrNotifierRateLimited = errors.New("notifier rate limited")
+
+// NotifierRateLimit is the configuration of a notifier rate limit (see
+// WithNotifierRateLimit).
+type NotifierRateLimit struct {
+	// Every is the minimum time between the notifier triggers, once the burst
+	// has been used.
+	// By default 1m.
+	Every time.Duration
+	// Burst is the number of triggers allowed at once.
+	// By default 1.
+	Burst int
+}
+
+func (n *NotifierRateLimit) defaults() {
+	if n.Every <= 0 {
+		n.Every = time.Minute
+	}
+
+	if n.Burst <= 0 {
+		n.Burst = 1
+	}
+}
+
+// notifierRateLimiter is a token bucket rate limiter of a running notifier.
+//
+// The last limited trigger is kept as the trailing trigger and reloaded once
+// the limit refills (see trail), so the latest change is never lost.
+type notifierRateLimiter struct {
+	cfg  NotifierRateLimit
+	wake chan struct{}
+
+	mu       sync.Mutex
+	tokens   float64
+	last     time.Time
+	trailing *notifierResult
+}
+
+func newNotifierRateLimiter(cfg *NotifierRateLimit) *notifierRateLimiter {
+	if cfg == nil {
+		return nil
+	}
+
+	return &notifierRateLimiter{
+		cfg:    *cfg,
+		tokens: float64(cfg.Burst),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// limit returns if the trigger is allowed, consuming a token. A limited trigger
+// replaces the trailing trigger, the replaced one (or the trailing trigger
+// superseded by an allowed trigger) is returned to be dropped.
+func (n *notifierRateLimiter) limit(res notifierResult) (allowed bool, dropped *notifierResult) {
+	if n == nil {
+		return true, nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	dropped = n.trailing
+	n.refill(res.At)
+	if n.tokens >= 1 {
+		n.tokens--
+		n.trailing = nil
+		return true, dropped
+	}
+
+	n.trailing = &res
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+
+	return false, dropped
+}
+
+// trail runs the trailing triggers once the limit refills, until the context
+// ends or run returns false.
+func (n *notifierRateLimiter) trail(ctx context.Context, clock Clock, run func(res notifierResult) bool) {
+	if n == nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.wake:
+		}
+
+		for {
+			res, wait, ok := n.popTrailing(clock.Now())
+			if !ok {
+				break
+			}
+
+			if wait > 0 {
+				t := clock.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return
+				case <-t.C():
+				}
+				continue
+			}
+
+			if !run(res) {
+				return
+			}
+		}
+	}
+}
+
+// popTrailing returns the trailing trigger consuming a token, or the time to
+// wait until the limit refills. Returns false if there is no trailing trigger.
+func (n *notifierRateLimiter) popTrailing(now time.Time) (res notifierResult, wait time.Duration, ok bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.trailing == nil {
+		return notifierResult{}, 0, false
+	}
+
+	n.refill(now)
+	if n.tokens < 1 {
+		return notifierResult{}, time.Duration(math.Ceil((1 - n.tokens) * float64(n.cfg.Every))), true
+	}
+	n.tokens--
+
+	// The trailing trigger is received once the limit refills.
+	res = *n.trailing
+	res.At = now
+	n.trailing = nil
+
+	return res, 0, true
+}
+
+// refill adds the tokens refilled since the last call, the limiter needs to be
+// locked.
+func (n *notifierRateLimiter) refill(now time.Time) {
+	if !n.last.IsZero() && now.After(n.last) {
+		n.tokens += float64(now.Sub(n.last)) / float64(n.cfg.Every)
+		n.tokens = min(n.tokens, float64(n.cfg.Burst))
+	}
+	if now.After(n.last) {
+		n.last = now
+	}
+}
@@ -0,0 +1,104 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerNotifierRateLimit(t *testing.T) {
+	tests := map[string]struct {
+		rateLimit    reload.NotifierRateLimit
+		triggers     []string
+		advance      time.Duration
+		advanceAfter time.Duration
+		expIDs       []string
+		expDropped   int
+	}{
+		"Triggers within the limit should be reloaded.": {
+			rateLimit: reload.NotifierRateLimit{Every: time.Minute, Burst: 2},
+			triggers:  []string{"t1", "t2"},
+			expIDs:    []string{"t1", "t2", "signal"},
+		},
+
+		"Triggers exceeding the limit should be dropped, except the last one that trails.": {
+			rateLimit:  reload.NotifierRateLimit{Every: time.Minute},
+			triggers:   []string{"t1", "t2", "t3"},
+			expIDs:     []string{"t1", "signal"},
+			expDropped: 1,
+		},
+
+		"Triggers should be allowed again once the limit refills.": {
+			rateLimit: reload.NotifierRateLimit{Every: time.Minute},
+			triggers:  []string{"t1", "t2", "t3"},
+			advance:   time.Minute,
+			expIDs:    []string{"t1", "t2", "signal"},
+		},
+
+		"The last limited trigger should be reloaded once the limit refills.": {
+			rateLimit:    reload.NotifierRateLimit{Every: time.Minute},
+			triggers:     []string{"t1", "t2", "t3"},
+			advanceAfter: time.Minute,
+			expIDs:       []string{"t1", "t3", "signal"},
+			expDropped:   1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Now())
+			mr := &testMetricsRecorder{}
+			m := reload.NewManager(reload.WithClock(clock), reload.WithMetricsRecorder(mr))
+			var gotIDs []string
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotIDs = append(gotIDs, id)
+				return nil
+			}))
+			webhookC := make(chan string)
+			m.On(reload.NotifierChan(webhookC), reload.WithNotifierName("webhook"), reload.WithNotifierRateLimit(test.rateLimit))
+			signalC := make(chan string)
+			m.On(reload.NotifierChan(signalC), reload.WithNotifierName("signal"))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runErr := make(chan error)
+			go func() { runErr <- m.Run(ctx) }()
+			for i, id := range test.triggers {
+				// Refill the limit after the first trigger.
+				if i == 1 {
+					clock.Advance(test.advance)
+				}
+				webhookC <- id
+				time.Sleep(5 * time.Millisecond)
+			}
+			if test.advanceAfter > 0 {
+				require.True(clock.WaitWaiters(1, time.Second))
+				clock.Advance(test.advanceAfter)
+				time.Sleep(5 * time.Millisecond)
+			}
+			// Other notifiers should not be limited.
+			signalC <- "signal"
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			// Check.
+			require.NoError(<-runErr)
+			assert.Equal(test.expIDs, gotIDs)
+			if test.expDropped > 0 {
+				assert.Equal(map[reload.DropReason]int{reload.DropReasonRateLimited: test.expDropped}, mr.droppedTriggers)
+			} else {
+				assert.Empty(mr.droppedTriggers)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package reload
+
+// ReloaderRegistration is a reloader with the information to register it on a
+// manager, so the reloaders can be created independently (e.g: by dependency
+// injection) instead of being registered with Manager.Add.
+type ReloaderRegistration struct {
+	// Priority is the priority of the reloader, see Manager.Add.
+	Priority int
+	// Reloader is the reloader.
+	Reloader Reloader
+	// Options are the reloader options (e.g: WithReloaderName).
+	Options []ReloaderOption
+}
+
+// NotifierRegistration is a notifier with the information to register it on a
+// manager, see ReloaderRegistration.
+type NotifierRegistration struct {
+	// Notifier is the notifier.
+	Notifier Notifier
+	// Options are the notifier options (e.g: WithNotifierName).
+	Options []NotifierOption
+}
+
+// Registrations are the reloaders and notifiers of an application component,
+// every component can provide its own registrations.
+type Registrations struct {
+	Reloaders []ReloaderRegistration
+	Notifiers []NotifierRegistration
+}
+
+// NewManagerWithRegistrations returns a new manager with the reloaders and
+// notifiers of the registrations registered in order.
+//
+// It's designed to be used as a dependency injection provider, the
+// registrations and options are regular values without globals.
+func NewManagerWithRegistrations(regs []Registrations, opts []ManagerOption) *Manager {
+	m := NewManager(opts...)
+	for _, r := range regs {
+		for _, rr := range r.Reloaders {
+			m.Add(rr.Priority, rr.Reloader, rr.Options...)
+		}
+		for _, nr := range r.Notifiers {
+			m.On(nr.Notifier, nr.Options...)
+		}
+	}
+
+	return &m
+}
@@ -0,0 +1,46 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+func TestNewManagerWithRegistrations(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	noop := reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil })
+	notifierC := make(chan string)
+	config := reload.Registrations{
+		Reloaders: []reload.ReloaderRegistration{
+			{Priority: 0, Reloader: noop, Options: []reload.ReloaderOption{reload.WithReloaderName("config")}},
+		},
+		Notifiers: []reload.NotifierRegistration{
+			{Notifier: reload.NotifierChan(notifierC), Options: []reload.NotifierOption{reload.WithNotifierName("file")}},
+		},
+	}
+	server := reload.Registrations{
+		Reloaders: []reload.ReloaderRegistration{
+			{Priority: 10, Reloader: noop, Options: []reload.ReloaderOption{reload.WithReloaderName("http")}},
+			{Priority: 0, Reloader: noop},
+		},
+	}
+
+	// Execute.
+	m := reload.NewManagerWithRegistrations([]reload.Registrations{config, server}, []reload.ManagerOption{reload.WithOrderedReloaders()})
+
+	// Check.
+	exp := reload.Status{
+		OrderedReloaders: true,
+		Notifiers:        []string{"file"},
+		Plan: []reload.StatusGroup{
+			{Priority: 0, Reloaders: []string{"config", "reloader-2"}},
+			{Priority: 10, Reloaders: []string{"http"}},
+		},
+	}
+	assert.Equal(exp, m.Status())
+}
@@ -0,0 +1,74 @@
+package reloadcache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slok/reload"
+)
+
+// Cache is a cache that can be invalidated.
+type Cache interface {
+	// Invalidate removes the entries of the keys from the cache.
+	Invalidate(keys ...string)
+	// Flush removes all the entries from the cache.
+	Flush()
+}
+
+// ReloaderConfig is the configuration of the cache invalidation reloader.
+type ReloaderConfig struct {
+	// Cache is the cache that will be invalidated.
+	Cache Cache
+	// Reloader is an optional reloader that will be wrapped, the cache will be
+	// invalidated only if the wrapped reloader reloads successfully.
+	Reloader reload.Reloader
+	// Keys returns the cache keys affected by the changed keys of the trigger,
+	// if it returns no keys, the reload will not invalidate the cache.
+	// By default the changed keys of the trigger.
+	Keys func(changed []string) []string
+}
+
+func (c *ReloaderConfig) defaults() error {
+	if c.Cache == nil {
+		return fmt.Errorf("cache is required")
+	}
+
+	if c.Keys == nil {
+		c.Keys = func(changed []string) []string { return changed }
+	}
+
+	return nil
+}
+
+// NewReloader returns a new reload.Reloader that invalidates a cache on every reload.
+//
+// If the trigger knows the keys that changed (`reload.TriggerEvent.Keys`), only
+// the affected entries are invalidated, otherwise the whole cache is flushed.
+func NewReloader(cfg ReloaderConfig) (reload.Reloader, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		if cfg.Reloader != nil {
+			err := cfg.Reloader.Reload(ctx, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		t, _ := reload.TriggerEventFromContext(ctx)
+		if len(t.Keys) == 0 {
+			cfg.Cache.Flush()
+			return nil
+		}
+
+		keys := cfg.Keys(t.Keys)
+		if len(keys) > 0 {
+			cfg.Cache.Invalidate(keys...)
+		}
+
+		return nil
+	}), nil
+}
@@ -0,0 +1,127 @@
+package reloadcache_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadcache"
+)
+
+type testCache struct {
+	invalidated []string
+	flushed     bool
+}
+
+func (t *testCache) Invalidate(keys ...string) { t.invalidated = append(t.invalidated, keys...) }
+func (t *testCache) Flush()                    { t.flushed = true }
+
+func TestReloader(t *testing.T) {
+	tests := map[string]struct {
+		keys           func(changed []string) []string
+		reloaderErr    error
+		triggerKeys    []string
+		expInvalidated []string
+		expFlushed     bool
+		expErr         bool
+	}{
+		"A trigger without changed keys should flush the cache.": {
+			expFlushed: true,
+		},
+
+		"A trigger with changed keys should invalidate the changed keys.": {
+			triggerKeys:    []string{"flag-a", "flag-b"},
+			expInvalidated: []string{"flag-a", "flag-b"},
+		},
+
+		"A trigger with changed keys should invalidate the mapped keys.": {
+			keys: func(changed []string) []string {
+				var keys []string
+				for _, k := range changed {
+					if strings.HasPrefix(k, "users.") {
+						keys = append(keys, strings.TrimPrefix(k, "users."))
+					}
+				}
+				return keys
+			},
+			triggerKeys:    []string{"users.u1", "limits.qps", "users.u2"},
+			expInvalidated: []string{"u1", "u2"},
+		},
+
+		"A trigger with changed keys that don't affect the cache should not invalidate.": {
+			keys:        func(changed []string) []string { return nil },
+			triggerKeys: []string{"limits.qps"},
+		},
+
+		"If the wrapped reloader fails, the cache should not be invalidated.": {
+			reloaderErr: fmt.Errorf("something"),
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			c := &testCache{}
+			r, err := reloadcache.NewReloader(reloadcache.ReloaderConfig{
+				Cache: c,
+				Keys:  test.keys,
+				Reloader: reload.ReloaderFunc(func(ctx context.Context, id string) error {
+					return test.reloaderErr
+				}),
+			})
+			require.NoError(err)
+
+			// Execute.
+			reloadErr := make(chan error, 1)
+			m := reload.NewManager()
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				err := r.Reload(ctx, id)
+				reloadErr <- err
+				return err
+			}))
+			m.On(&testTriggerNotifier{t: reload.TriggerEvent{ID: "test", Keys: test.triggerKeys}})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { _ = m.Run(ctx) }()
+			err = <-reloadErr
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expInvalidated, c.invalidated)
+			assert.Equal(test.expFlushed, c.flushed)
+		})
+	}
+}
+
+// testTriggerNotifier triggers once with the trigger.
+type testTriggerNotifier struct {
+	t    reload.TriggerEvent
+	done bool
+}
+
+func (n *testTriggerNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := n.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+func (n *testTriggerNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	if n.done {
+		<-ctx.Done()
+		return reload.TriggerEvent{}, ctx.Err()
+	}
+	n.done = true
+	return n.t, nil
+}
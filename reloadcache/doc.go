@@ -0,0 +1,2 @@
+// Package reloadcache has the cache integrations of the reload mechanism.
+package reloadcache
@@ -0,0 +1,113 @@
+package reloadconfig
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/slok/reload"
+)
+
+// ChangeReloader knows how to reload with the previous and the new typed
+// configuration, so it can apply only what changed (e.g: rebind the server
+// only when the listen address changed), see Loader.WithChange.
+type ChangeReloader[T any] interface {
+	ReloadWithChange(ctx context.Context, old, new T) error
+}
+
+// ChangeReloaderFunc is a helper to create change reloaders from functions.
+type ChangeReloaderFunc[T any] func(ctx context.Context, old, new T) error
+
+// ReloadWithChange satisfies ChangeReloader interface.
+func (c ChangeReloaderFunc[T]) ReloadWithChange(ctx context.Context, old, new T) error {
+	return c(ctx, old, new)
+}
+
+// configChange is the last configuration swap of a loader.
+type configChange[T any] struct {
+	old T
+	new T
+	// paths are the changed paths (see Diff), nil on the initial load.
+	paths []string
+}
+
+func newConfigChange[T any](old *T, new T) *configChange[T] {
+	if old == nil {
+		return &configChange[T]{new: new}
+	}
+
+	return &configChange[T]{old: *old, new: new, paths: Diff(*old, new)}
+}
+
+// changedPaths returns the paths changed on the last configuration swap.
+func changedPaths[T any](last *atomic.Pointer[configChange[T]]) []string {
+	return append([]string(nil), last.Load().paths...)
+}
+
+// appliedConfig is the configuration last applied successfully by a
+// dependent reloader of a loader, so its changes are computed against what it
+// applied and not against the last swap of the loader (e.g: a dependent that
+// failed or was skipped on the previous reloads).
+type appliedConfig[T any] struct {
+	last *atomic.Pointer[configChange[T]]
+
+	mu  sync.Mutex
+	cfg T
+}
+
+// newAppliedConfig returns the applied configuration of a new dependent, the
+// current configuration of the loader is the applied one.
+func newAppliedConfig[T any](last *atomic.Pointer[configChange[T]]) *appliedConfig[T] {
+	return &appliedConfig[T]{last: last, cfg: last.Load().new}
+}
+
+// apply calls f with the applied and the current configuration of the loader,
+// the current configuration is the applied one if f succeeds.
+func (a *appliedConfig[T]) apply(f func(old, new T) error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	new := a.last.Load().new
+	err := f(a.cfg, new)
+	if err != nil {
+		return err
+	}
+	a.cfg = new
+
+	return nil
+}
+
+// changeReloader is a reload.Reloader that calls the change reloader with the
+// configuration it last applied and the current one of a loader.
+type changeReloader[T any] struct {
+	applied *appliedConfig[T]
+	r       ChangeReloader[T]
+}
+
+var _ reload.Reloader = changeReloader[any]{}
+
+func (c changeReloader[T]) Reload(ctx context.Context, _ string) error {
+	return c.applied.apply(func(old, new T) error {
+		return c.r.ReloadWithChange(ctx, old, new)
+	})
+}
+
+// interestReloader is a reload.Reloader that reloads the reloader only when
+// the paths of interest changed since the configuration it last applied.
+type interestReloader[T any] struct {
+	applied  *appliedConfig[T]
+	patterns []string
+	r        reload.Reloader
+}
+
+var _ reload.Reloader = interestReloader[any]{}
+
+func (i interestReloader[T]) Reload(ctx context.Context, id string) error {
+	return i.applied.apply(func(old, new T) error {
+		if !matchesPaths(i.patterns, Diff(old, new)) {
+			return nil
+		}
+
+		return i.r.Reload(ctx, id)
+	})
+}
@@ -0,0 +1,296 @@
+package reloadconfig_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadconfig"
+)
+
+func TestLoaderWithChange(t *testing.T) {
+	type change struct{ old, new testConfig }
+
+	tests := map[string]struct {
+		reloads    []string
+		failFirst  bool
+		expChanges []change
+	}{
+		"The change reloader should receive the previous and the new configuration.": {
+			reloads: []string{`{"name":"b"}`, `{"name":"c","workers":8}`},
+			expChanges: []change{
+				{old: testConfig{Name: "a", Workers: 4}, new: testConfig{Name: "b", Workers: 4}},
+				{old: testConfig{Name: "b", Workers: 4}, new: testConfig{Name: "c", Workers: 8}},
+			},
+		},
+
+		"The change reloader should be called when the configuration didn't change.": {
+			reloads: []string{`{"name":"a"}`},
+			expChanges: []change{
+				{old: testConfig{Name: "a", Workers: 4}, new: testConfig{Name: "a", Workers: 4}},
+			},
+		},
+
+		"The change reloader should receive the changes since the configuration it last applied.": {
+			reloads:   []string{`{"name":"b"}`, `{"name":"b"}`},
+			failFirst: true,
+			expChanges: []change{
+				{old: testConfig{Name: "a", Workers: 4}, new: testConfig{Name: "b", Workers: 4}},
+				{old: testConfig{Name: "a", Workers: 4}, new: testConfig{Name: "b", Workers: 4}},
+			},
+		},
+
+		"The change reloader should not be called when the loader fails.": {
+			reloads: []string{`{"name":`, `{"name":"b"}`},
+			expChanges: []change{
+				{old: testConfig{Name: "a", Workers: 4}, new: testConfig{Name: "b", Workers: 4}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(os.WriteFile(path, []byte(`{"name":"a"}`), 0o600))
+			l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+				Load:     reloadconfig.FileLoader(path),
+				Defaults: func() testConfig { return testConfig{Workers: 4} },
+			})
+			require.NoError(err)
+
+			var gotChanges []change
+			m := reload.NewManager()
+			m.Add(0, l)
+			m.Add(10, l.WithChange(reloadconfig.ChangeReloaderFunc[testConfig](func(ctx context.Context, old, new testConfig) error {
+				gotChanges = append(gotChanges, change{old: old, new: new})
+				if test.failFirst && len(gotChanges) == 1 {
+					return fmt.Errorf("something")
+				}
+				return nil
+			})))
+
+			// Execute.
+			for _, data := range test.reloads {
+				require.NoError(os.WriteFile(path, []byte(data), 0o600))
+				_ = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "test"})
+			}
+
+			// Check.
+			assert.Equal(test.expChanges, gotChanges)
+		})
+	}
+}
+
+func TestLoaderWithInterest(t *testing.T) {
+	tests := map[string]struct {
+		patterns   []string
+		reloaded   string
+		expReload  bool
+		expChanged []string
+	}{
+		"A changed path of interest should reload.": {
+			patterns:   []string{"name"},
+			reloaded:   `{"name":"b"}`,
+			expReload:  true,
+			expChanged: []string{"name"},
+		},
+
+		"A change out of the paths of interest should not reload.": {
+			patterns:   []string{"name"},
+			reloaded:   `{"name":"a","workers":8}`,
+			expChanged: []string{"workers"},
+		},
+
+		"Patterns should match the changed paths.": {
+			patterns:   []string{"work*"},
+			reloaded:   `{"name":"a","workers":8}`,
+			expReload:  true,
+			expChanged: []string{"workers"},
+		},
+
+		"Without changes it should not reload.": {
+			patterns: []string{"name", "workers"},
+			reloaded: `{"name":"a"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(os.WriteFile(path, []byte(`{"name":"a"}`), 0o600))
+			l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+				Load:     reloadconfig.FileLoader(path),
+				Defaults: func() testConfig { return testConfig{Workers: 4} },
+			})
+			require.NoError(err)
+
+			gotReload := false
+			m := reload.NewManager()
+			m.Add(0, l)
+			m.Add(10, l.WithInterest(reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotReload = true
+				return nil
+			}), test.patterns...))
+
+			// Execute.
+			require.NoError(os.WriteFile(path, []byte(test.reloaded), 0o600))
+			err = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "test"})
+
+			// Check.
+			require.NoError(err)
+			assert.Equal(test.expReload, gotReload)
+			assert.Equal(test.expChanged, l.ChangedPaths())
+		})
+	}
+}
+
+func TestLoaderWithInterestFailedReload(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	raw := `{"name":"a"}`
+	l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+		Load: func(ctx context.Context) ([]byte, error) { return []byte(raw), nil },
+	})
+	require.NoError(err)
+
+	reloads := 0
+	m := reload.NewManager()
+	m.Add(0, l)
+	m.Add(10, l.WithInterest(reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		reloads++
+		if reloads == 1 {
+			return fmt.Errorf("something")
+		}
+		return nil
+	}), "name"))
+
+	// Execute.
+	raw = `{"name":"b"}`
+	err1 := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "test"})
+	err2 := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "test"})
+	err3 := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "test"})
+
+	// Check: the failed change is reloaded again until it's applied.
+	assert.Error(err1)
+	assert.NoError(err2)
+	assert.NoError(err3)
+	assert.Equal(2, reloads)
+}
+
+func TestLoaderWithInterestPatterns(t *testing.T) {
+	tests := map[string]struct {
+		patterns  []string
+		reloaded  string
+		expReload bool
+	}{
+		"Wildcards should match a single segment.": {
+			patterns: []string{"server.*"},
+			reloaded: `{"server":{"addr":":8080","tls":{"cert":"c2"}}}`,
+		},
+
+		"Wildcards should match their segment.": {
+			patterns:  []string{"server.*"},
+			reloaded:  `{"server":{"addr":":9090","tls":{"cert":"c1"}}}`,
+			expReload: true,
+		},
+
+		"Leading wildcards should not match across segments.": {
+			patterns: []string{"*.cert"},
+			reloaded: `{"server":{"addr":":8080","tls":{"cert":"c2"}}}`,
+		},
+
+		"Wildcards on the middle segments should match.": {
+			patterns:  []string{"server.*.cert"},
+			reloaded:  `{"server":{"addr":":8080","tls":{"cert":"c2"}}}`,
+			expReload: true,
+		},
+
+		"Patterns without wildcards should match the changed children.": {
+			patterns:  []string{"server"},
+			reloaded:  `{"server":{"addr":":8080","tls":{"cert":"c2"}}}`,
+			expReload: true,
+		},
+
+		"Patterns should match the changed parents.": {
+			patterns:  []string{"server.tls.*"},
+			reloaded:  `{"server":{"addr":":8080"}}`,
+			expReload: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			raw := `{"server":{"addr":":8080","tls":{"cert":"c1"}}}`
+			l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[map[string]any]{
+				Load: func(ctx context.Context) ([]byte, error) { return []byte(raw), nil },
+			})
+			require.NoError(err)
+
+			gotReload := false
+			m := reload.NewManager()
+			m.Add(0, l)
+			m.Add(10, l.WithInterest(reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotReload = true
+				return nil
+			}), test.patterns...))
+
+			// Execute.
+			raw = test.reloaded
+			err = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "test"})
+
+			// Check.
+			require.NoError(err)
+			assert.Equal(test.expReload, gotReload)
+		})
+	}
+}
+
+func TestLayeredLoaderWithChange(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(os.WriteFile(path, []byte(`{"server":{"port":8080}}`), 0o600))
+	l, err := reloadconfig.NewLayeredLoader(context.TODO(), reloadconfig.LayeredLoaderConfig[testLayeredConfig]{
+		Layers: []reloadconfig.Layer{reloadconfig.FileLayer(path, reloadconfig.JSONDecoder)},
+	})
+	require.NoError(err)
+
+	var gotOld, gotNew int
+	m := reload.NewManager()
+	m.Add(0, l)
+	m.Add(10, l.WithChange(reloadconfig.ChangeReloaderFunc[testLayeredConfig](func(ctx context.Context, old, new testLayeredConfig) error {
+		gotOld, gotNew = old.Server.Port, new.Server.Port
+		return nil
+	})))
+
+	// Execute.
+	require.NoError(os.WriteFile(path, []byte(`{"server":{"port":9090}}`), 0o600))
+	err = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "test"})
+
+	// Check.
+	require.NoError(err)
+	assert.Equal(8080, gotOld)
+	assert.Equal(9090, gotNew)
+}
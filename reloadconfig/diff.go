@@ -0,0 +1,203 @@
+package reloadconfig
+
+import (
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff returns the sorted paths of the configuration values that differ
+// between the old and the new configuration (e.g: `server.tls.cert`,
+// `limits.qps`), the configurations are expected to be structs or maps.
+//
+// The struct fields are named by their `json` tag, or the lowercase field name
+// without it, the fields tagged with `json:"-"` and the unexported ones are
+// ignored. The maps with string keys are compared by key, and the rest of the
+// values (e.g: slices) are compared as a whole.
+func Diff(old, new any) []string {
+	d := differ{visited: map[visit]bool{}}
+	d.diffValues(reflect.ValueOf(old), reflect.ValueOf(new), "")
+	paths := d.paths
+	sort.Strings(paths)
+
+	return paths
+}
+
+// differ diffs the values, it tracks the pointers being diffed so the
+// self-referencing configurations don't recurse forever.
+type differ struct {
+	paths   []string
+	visited map[visit]bool
+}
+
+// visit is a pair of diffed pointers or maps.
+type visit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+func (d *differ) diffValues(a, b reflect.Value, prefix string) {
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		if a.IsValid() || b.IsValid() {
+			d.paths = append(d.paths, prefix)
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.paths = append(d.paths, prefix)
+			}
+			return
+		}
+		if a.Kind() == reflect.Pointer {
+			if !d.enter(a, b) {
+				return
+			}
+			defer d.leave(a, b)
+		}
+		d.diffValues(a.Elem(), b.Elem(), prefix)
+
+	case reflect.Struct:
+		fields := diffFields(a.Type())
+		if len(fields) == 0 {
+			// Opaque values (e.g: `time.Time`).
+			d.diffLeaf(a, b, prefix)
+			return
+		}
+		for _, f := range fields {
+			name := prefix
+			if f.name != "" {
+				name = joinPath(prefix, f.name)
+			}
+			d.diffValues(a.Field(f.index), b.Field(f.index), name)
+		}
+
+	case reflect.Map:
+		if a.Type().Key().Kind() != reflect.String {
+			d.diffLeaf(a, b, prefix)
+			return
+		}
+		if !d.enter(a, b) {
+			return
+		}
+		defer d.leave(a, b)
+		for _, k := range a.MapKeys() {
+			d.diffValues(a.MapIndex(k), b.MapIndex(k), joinPath(prefix, k.String()))
+		}
+		for _, k := range b.MapKeys() {
+			if !a.MapIndex(k).IsValid() {
+				d.paths = append(d.paths, joinPath(prefix, k.String()))
+			}
+		}
+
+	default:
+		d.diffLeaf(a, b, prefix)
+	}
+}
+
+// enter marks the pointers or maps as being diffed, and returns false when
+// they already are (a cycle). The shared values that are not cycles (e.g: two
+// fields with the same pointer) are diffed on every path.
+func (d *differ) enter(a, b reflect.Value) bool {
+	v := visit{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+	if d.visited[v] {
+		return false
+	}
+	d.visited[v] = true
+
+	return true
+}
+
+func (d *differ) leave(a, b reflect.Value) {
+	delete(d.visited, visit{a: a.Pointer(), b: b.Pointer(), typ: a.Type()})
+}
+
+func (d *differ) diffLeaf(a, b reflect.Value, prefix string) {
+	// The values of unexported embedded structs can't be compared.
+	if !a.CanInterface() {
+		return
+	}
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		d.paths = append(d.paths, prefix)
+	}
+}
+
+type diffField struct {
+	index int
+	// name is empty for the embedded structs, their fields are promoted.
+	name string
+}
+
+// diffFields returns the compared fields of the struct type.
+func diffFields(t reflect.Type) []diffField {
+	var fields []diffField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case tag == "-":
+			continue
+		case tag != "":
+			fields = append(fields, diffField{index: i, name: tag})
+		case f.Anonymous && f.Type.Kind() == reflect.Struct:
+			fields = append(fields, diffField{index: i})
+		case f.IsExported():
+			fields = append(fields, diffField{index: i, name: strings.ToLower(f.Name)})
+		}
+	}
+
+	return fields
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// matchesPaths returns if any of the paths matches any of the patterns, the
+// patterns are matched by `.` segment using `path.Match` (e.g: `server.*`
+// matches `server.tls` but not `server.tls.cert`). A path also matches the
+// patterns of its children (e.g: `server` matches `server.*.cert`) and the
+// patterns without wildcards of its parents (e.g: `server.tls.cert` matches
+// `server`).
+func matchesPaths(patterns, paths []string) bool {
+	for _, p := range paths {
+		for _, pattern := range patterns {
+			if matchesPath(pattern, p) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchesPath(pattern, p string) bool {
+	// The whole configuration changed.
+	if p == "" {
+		return true
+	}
+
+	patternSegs := strings.Split(pattern, ".")
+	pathSegs := strings.Split(p, ".")
+	if len(pathSegs) > len(patternSegs) && strings.ContainsAny(pattern, `*?[\`) {
+		return false
+	}
+	for i := range min(len(patternSegs), len(pathSegs)) {
+		if ok, _ := path.Match(patternSegs[i], pathSegs[i]); !ok {
+			return false
+		}
+	}
+
+	return true
+}
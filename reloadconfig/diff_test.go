@@ -0,0 +1,187 @@
+package reloadconfig_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload/reloadconfig"
+)
+
+type diffTLS struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+type diffServer struct {
+	Addr    string        `json:"addr"`
+	Timeout time.Duration `json:"timeout"`
+	TLS     *diffTLS      `json:"tls,omitempty"`
+}
+
+type diffCommon struct {
+	Name string `json:"name"`
+}
+
+type diffConfig struct {
+	diffCommon
+	Server   diffServer         `json:"server"`
+	Limits   map[string]float64 `json:"limits"`
+	Backends []string           `json:"backends"`
+	Since    time.Time          `json:"since"`
+	Debug    bool
+	Ignored  string `json:"-"`
+	internal string
+}
+
+func TestDiff(t *testing.T) {
+	base := func() diffConfig {
+		return diffConfig{
+			diffCommon: diffCommon{Name: "a"},
+			Server:     diffServer{Addr: ":8080", Timeout: time.Second, TLS: &diffTLS{Cert: "c1", Key: "k1"}},
+			Limits:     map[string]float64{"qps": 10, "burst": 20},
+			Backends:   []string{"b1", "b2"},
+			Since:      time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+		}
+	}
+
+	tests := map[string]struct {
+		change   func(c *diffConfig)
+		expPaths []string
+	}{
+		"Equal configurations should not have changes.": {
+			change: func(c *diffConfig) {},
+		},
+
+		"Changed nested fields should be reported by their json path.": {
+			change: func(c *diffConfig) {
+				c.Server.TLS.Cert = "c2"
+				c.Server.Timeout = time.Minute
+			},
+			expPaths: []string{"server.timeout", "server.tls.cert"},
+		},
+
+		"A removed nested struct should be reported as a whole.": {
+			change:   func(c *diffConfig) { c.Server.TLS = nil },
+			expPaths: []string{"server.tls"},
+		},
+
+		"Map changes should be reported by key.": {
+			change: func(c *diffConfig) {
+				c.Limits["qps"] = 100
+				delete(c.Limits, "burst")
+				c.Limits["conns"] = 5
+			},
+			expPaths: []string{"limits.burst", "limits.conns", "limits.qps"},
+		},
+
+		"Slices and opaque structs should be compared as a whole.": {
+			change: func(c *diffConfig) {
+				c.Backends = append(c.Backends, "b3")
+				c.Since = c.Since.Add(time.Hour)
+			},
+			expPaths: []string{"backends", "since"},
+		},
+
+		"Embedded struct fields should be promoted and untagged fields lowercased.": {
+			change: func(c *diffConfig) {
+				c.Name = "b"
+				c.Debug = true
+			},
+			expPaths: []string{"debug", "name"},
+		},
+
+		"Ignored and unexported fields should not be reported.": {
+			change: func(c *diffConfig) {
+				c.Ignored = "x"
+				c.internal = "x"
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			old, new := base(), base()
+			test.change(&new)
+
+			// Execute.
+			gotPaths := reloadconfig.Diff(old, new)
+
+			// Check.
+			assert.Equal(test.expPaths, gotPaths)
+		})
+	}
+}
+
+type diffNode struct {
+	Name string    `json:"name"`
+	Next *diffNode `json:"next"`
+}
+
+type diffShared struct {
+	Primary   *diffTLS `json:"primary"`
+	Secondary *diffTLS `json:"secondary"`
+}
+
+func TestDiffCycles(t *testing.T) {
+	tests := map[string]struct {
+		old      func() any
+		new      func() any
+		expPaths []string
+	}{
+		"Equal self-referencing configurations should not have changes.": {
+			old: func() any {
+				n := &diffNode{Name: "a"}
+				n.Next = n
+				return n
+			},
+			new: func() any {
+				n := &diffNode{Name: "a"}
+				n.Next = n
+				return n
+			},
+		},
+
+		"Changed self-referencing configurations should be reported once.": {
+			old: func() any {
+				n := &diffNode{Name: "a"}
+				n.Next = n
+				return n
+			},
+			new: func() any {
+				n := &diffNode{Name: "b"}
+				n.Next = n
+				return n
+			},
+			expPaths: []string{"name"},
+		},
+
+		"Shared values that are not cycles should be reported on every path.": {
+			old: func() any {
+				tls := &diffTLS{Cert: "c1"}
+				return diffShared{Primary: tls, Secondary: tls}
+			},
+			new: func() any {
+				tls := &diffTLS{Cert: "c2"}
+				return diffShared{Primary: tls, Secondary: tls}
+			},
+			expPaths: []string{"primary.cert", "secondary.cert"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Execute.
+			gotPaths := reloadconfig.Diff(test.old(), test.new())
+
+			// Check.
+			assert.Equal(test.expPaths, gotPaths)
+		})
+	}
+}
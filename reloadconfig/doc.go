@@ -0,0 +1,9 @@
+// Package reloadconfig has the configuration loading integrations of the reload
+// mechanism.
+//
+// The Loader is usually paired with a `reload.FileNotifier` watching the
+// configuration file, so any change decodes, validates and swaps the
+// configuration. FSLoader and FSLayer load the configuration from an `fs.FS`
+// instead (e.g: an `embed.FS`). The format decoders that need dependencies are
+// on their own packages (`reloadyaml`, `reloadtoml` and `reloadhcl`).
+package reloadconfig
@@ -0,0 +1,511 @@
+package reloadconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// Layer is a configuration source of the LayeredLoader.
+type Layer struct {
+	// Name is the name of the layer used on the reports (e.g: `file`).
+	Name string
+	// Load returns the layer values as a tree of maps, the keys are the
+	// configuration field names (JSON names) and they are case insensitive.
+	Load func(ctx context.Context) (map[string]any, error)
+}
+
+// DefaultsLayer returns a layer with the values of the v configuration.
+func DefaultsLayer(v any) Layer {
+	return Layer{
+		Name: "defaults",
+		Load: func(_ context.Context) (map[string]any, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			m := map[string]any{}
+			err = json.Unmarshal(b, &m)
+			if err != nil {
+				return nil, err
+			}
+			return m, nil
+		},
+	}
+}
+
+// FileLayer returns a layer that reads the values from a file decoded with
+// the decoder (e.g: JSONDecoder, `reloadyaml.Decoder`), the decoder needs to
+// support decoding into a map.
+func FileLayer(path string, dec Decoder) Layer {
+	return fsLayer(os.DirFS(filepath.Dir(path)), filepath.Base(path), path, dec)
+}
+
+// FSLayer returns a layer that reads the values from the name file of the file
+// system (e.g: an `embed.FS`, or a `fstest.MapFS` on the tests) decoded with
+// the decoder, see FileLayer.
+func FSLayer(fsys fs.FS, name string, dec Decoder) Layer {
+	return fsLayer(fsys, name, name, dec)
+}
+
+func fsLayer(fsys fs.FS, name, path string, dec Decoder) Layer {
+	return Layer{
+		Name: "file",
+		Load: func(_ context.Context) (map[string]any, error) {
+			data, err := readFile(fsys, name, path)
+			if err != nil {
+				return nil, err
+			}
+			m := map[string]any{}
+			err = dec(data, &m)
+			if err != nil {
+				return nil, fmt.Errorf("could not decode %q: %w", path, err)
+			}
+			return m, nil
+		},
+	}
+}
+
+// EnvLayer returns a layer that reads the values from the env vars with the
+// prefix, the nested fields are separated with `__` (e.g: with `APP` prefix,
+// `APP_SERVER__PORT` sets `server.port`).
+func EnvLayer(prefix string) Layer {
+	prefix = strings.ToLower(prefix) + "_"
+	return Layer{
+		Name: "env",
+		Load: func(_ context.Context) (map[string]any, error) {
+			m := map[string]any{}
+			for _, env := range os.Environ() {
+				k, v, _ := strings.Cut(env, "=")
+				k = strings.ToLower(k)
+				if !strings.HasPrefix(k, prefix) || k == prefix {
+					continue
+				}
+				setPath(m, strings.Split(strings.TrimPrefix(k, prefix), "__"), v)
+			}
+			return m, nil
+		},
+	}
+}
+
+// FlagLayer returns a layer with the values of the flags that have been set,
+// the nested fields are separated with `.` (e.g: `--server.port`).
+func FlagLayer(fs *flag.FlagSet) Layer {
+	return Layer{
+		Name: "flags",
+		Load: func(_ context.Context) (map[string]any, error) {
+			m := map[string]any{}
+			fs.Visit(func(f *flag.Flag) {
+				setPath(m, strings.Split(f.Name, "."), f.Value.String())
+			})
+			return m, nil
+		},
+	}
+}
+
+// Change is a configuration value changed on a reload.
+type Change struct {
+	// Key is the path of the value (e.g: `server.port`).
+	Key string
+	// Layer is the name of the layer that supplied the new value, or the
+	// previous one if the value was removed.
+	Layer string
+	// Old is the previous value, nil if it's a new value.
+	Old any
+	// New is the new value, nil if the value was removed.
+	New any
+}
+
+// LayeredLoaderConfig is the configuration of the LayeredLoader.
+type LayeredLoaderConfig[T any] struct {
+	// Layers are the configuration sources ordered by precedence, the values
+	// of the later layers override the previous ones (e.g: defaults < file <
+	// env < flags).
+	Layers []Layer
+	// Validate validates the configuration before it's swapped, optional.
+	Validate func(ctx context.Context, cfg T) error
+	// OnChange is called with the changed values after every reload that
+	// changes the configuration, optional.
+	OnChange func(ctx context.Context, changes []Change)
+}
+
+func (c *LayeredLoaderConfig[T]) defaults() error {
+	if len(c.Layers) == 0 {
+		return fmt.Errorf("at least one layer is required")
+	}
+
+	for i, l := range c.Layers {
+		if l.Load == nil {
+			return fmt.Errorf("layer %d load function is required", i)
+		}
+	}
+
+	if c.OnChange == nil {
+		c.OnChange = func(context.Context, []Change) {}
+	}
+
+	return nil
+}
+
+// LayeredLoader is a reload.Reloader that composes the configuration from
+// multiple layers with precedence, and produces a single typed configuration
+// snapshot on every reload that is swapped atomically, the users should get the
+// configuration using Get on every use instead of storing it.
+//
+// The string values (e.g: env vars, flags) are converted to the types of the
+// configuration fields (numbers, booleans and `time.Duration`).
+//
+// To debug the configuration, Sources has the layer that supplied every value,
+// and the changes of every reload are reported with OnChange. The reloaders
+// that need the previous configuration can be registered with WithChange.
+//
+// If any layer can't be loaded or the configuration is invalid, the reload
+// fails and the previous configuration is kept.
+type LayeredLoader[T any] struct {
+	cfg     LayeredLoaderConfig[T]
+	current atomic.Pointer[layeredSnapshot[T]]
+	last    atomic.Pointer[configChange[T]]
+}
+
+type layeredSnapshot[T any] struct {
+	config  T
+	values  map[string]any
+	sources map[string]string
+}
+
+// NewLayeredLoader returns a new LayeredLoader, the configuration is loaded on
+// the creation.
+func NewLayeredLoader[T any](ctx context.Context, cfg LayeredLoaderConfig[T]) (*LayeredLoader[T], error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	l := &LayeredLoader[T]{cfg: cfg}
+	err = l.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Get returns the current configuration.
+func (l *LayeredLoader[T]) Get() T {
+	return l.current.Load().config
+}
+
+// Sources returns the name of the layer that supplied every value of the
+// current configuration by key (e.g: `server.port`).
+func (l *LayeredLoader[T]) Sources() map[string]string {
+	sources := l.current.Load().sources
+	res := make(map[string]string, len(sources))
+	for k, v := range sources {
+		res[k] = v
+	}
+
+	return res
+}
+
+// Reload satisfies reload.Reloader interface.
+func (l *LayeredLoader[T]) Reload(ctx context.Context, _ string) error {
+	next, err := l.build(ctx)
+	if err != nil {
+		return err
+	}
+
+	prev := l.current.Swap(next)
+	if prev == nil {
+		l.last.Store(newConfigChange(nil, next.config))
+		return nil
+	}
+
+	l.last.Store(newConfigChange(&prev.config, next.config))
+	changes := diff(prev, next)
+	if len(changes) > 0 {
+		l.cfg.OnChange(ctx, changes)
+	}
+
+	return nil
+}
+
+// WithChange returns a reload.Reloader that calls the change reloader with the
+// configuration it last applied successfully and the current configuration of
+// the loader, see Loader.WithChange.
+func (l *LayeredLoader[T]) WithChange(r ChangeReloader[T]) reload.Reloader {
+	return changeReloader[T]{applied: newAppliedConfig(&l.last), r: r}
+}
+
+// ChangedPaths returns the configuration paths changed on the last reload of
+// the loader, see Loader.ChangedPaths.
+func (l *LayeredLoader[T]) ChangedPaths() []string {
+	return changedPaths(&l.last)
+}
+
+// WithInterest returns a reload.Reloader that reloads r only when the paths of
+// interest changed since the configuration r last applied successfully, see
+// Loader.WithInterest.
+func (l *LayeredLoader[T]) WithInterest(r reload.Reloader, patterns ...string) reload.Reloader {
+	return interestReloader[T]{applied: newAppliedConfig(&l.last), patterns: patterns, r: r}
+}
+
+// Validate satisfies reload.Validator interface, it loads, merges and
+// validates the layers without swapping the configuration.
+func (l *LayeredLoader[T]) Validate(ctx context.Context, _ string) error {
+	_, err := l.build(ctx)
+	return err
+}
+
+// build loads, merges, decodes and validates the layers.
+func (l *LayeredLoader[T]) build(ctx context.Context) (*layeredSnapshot[T], error) {
+	merged := map[string]any{}
+	sources := map[string]string{}
+	for _, layer := range l.cfg.Layers {
+		values, err := layer.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not load %q layer: %w", layer.Name, err)
+		}
+
+		values = lowerKeys(values).(map[string]any)
+		mergeValues(merged, values)
+		for k := range flatten(values) {
+			// Overridden nested values are supplied by the new layer.
+			for sk := range sources {
+				if strings.HasPrefix(sk, k+".") {
+					delete(sources, sk)
+				}
+			}
+			sources[k] = layer.Name
+		}
+	}
+
+	var c T
+	coerced := coerce(merged, reflect.TypeOf(c))
+	b, err := json.Marshal(coerced)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode configuration: %w", err)
+	}
+	err = JSONDecoder(b, &c)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode configuration: %w", err)
+	}
+
+	if l.cfg.Validate != nil {
+		err := l.cfg.Validate(ctx, c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	return &layeredSnapshot[T]{config: c, values: flatten(coerced.(map[string]any)), sources: sources}, nil
+}
+
+// setPath sets the value on the key path of the tree of maps.
+func setPath(m map[string]any, path []string, v any) {
+	for _, k := range path[:len(path)-1] {
+		next, ok := m[k].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[k] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = v
+}
+
+// mergeValues merges the src tree of maps into dst, the src values override
+// the dst ones.
+func mergeValues(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcOK := v.(map[string]any)
+		dstMap, dstOK := dst[k].(map[string]any)
+		if srcOK && dstOK {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		if srcOK {
+			// Don't share the maps between the layers and the result.
+			dstMap = map[string]any{}
+			mergeValues(dstMap, srcMap)
+			v = dstMap
+		}
+		dst[k] = v
+	}
+}
+
+func lowerKeys(v any) any {
+	switch tv := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(tv))
+		for k, v := range tv {
+			res[strings.ToLower(k)] = lowerKeys(v)
+		}
+		return res
+	case []any:
+		res := make([]any, len(tv))
+		for i, v := range tv {
+			res[i] = lowerKeys(v)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// flatten returns the leaf values of a tree of maps by key path.
+func flatten(m map[string]any) map[string]any {
+	res := map[string]any{}
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			if vm, ok := v.(map[string]any); ok && len(vm) > 0 {
+				walk(k, vm)
+				continue
+			}
+			res[k] = v
+		}
+	}
+	walk("", m)
+
+	return res
+}
+
+func diff[T any](prev, next *layeredSnapshot[T]) []Change {
+	var changes []Change
+	for k, v := range next.values {
+		old, ok := prev.values[k]
+		if ok && reflect.DeepEqual(old, v) {
+			continue
+		}
+		changes = append(changes, Change{Key: k, Layer: next.sources[k], Old: old, New: v})
+	}
+	for k, v := range prev.values {
+		if _, ok := next.values[k]; !ok {
+			changes = append(changes, Change{Key: k, Layer: prev.sources[k], Old: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+
+	return changes
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// coerce converts the string values of the tree to the type of the t
+// configuration fields, the values that can't be converted are kept so the
+// decoding reports the error.
+func coerce(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch tv := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(tv))
+		for k, v := range tv {
+			ft, ok := fieldType(t, k)
+			if !ok {
+				res[k] = v
+				continue
+			}
+			res[k] = coerce(v, ft)
+		}
+		return res
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return v
+		}
+		res := make([]any, len(tv))
+		for i, v := range tv {
+			res[i] = coerce(v, t.Elem())
+		}
+		return res
+	case string:
+		return coerceString(tv, t)
+	default:
+		return v
+	}
+}
+
+func coerceString(s string, t reflect.Type) any {
+	if t == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return s
+		}
+		return int64(d)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if n := json.Number(s); isNumber(n) {
+			return n
+		}
+	case reflect.Slice:
+		// Comma separated lists (e.g: `a,b,c`).
+		if t.Elem().Kind() != reflect.Uint8 {
+			var res []any
+			for _, item := range strings.Split(s, ",") {
+				res = append(res, coerceString(strings.TrimSpace(item), t.Elem()))
+			}
+			return res
+		}
+	}
+
+	return s
+}
+
+func isNumber(n json.Number) bool {
+	dec := json.NewDecoder(bytes.NewReader([]byte(n)))
+	var f float64
+	return dec.Decode(&f) == nil && !dec.More()
+}
+
+// fieldType returns the type of the field or map value for the key.
+func fieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem(), true
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Name
+			if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" {
+				if tag == "-" {
+					continue
+				}
+				name = tag
+			}
+			if strings.EqualFold(name, key) {
+				return f.Type, true
+			}
+		}
+	}
+
+	return nil, false
+}
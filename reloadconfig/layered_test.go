@@ -0,0 +1,180 @@
+package reloadconfig_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadconfig"
+)
+
+type testLayeredConfig struct {
+	Server struct {
+		Host    string        `json:"host"`
+		Port    int           `json:"port"`
+		Timeout time.Duration `json:"timeout"`
+	} `json:"server"`
+	Debug bool     `json:"debug"`
+	Tags  []string `json:"tags"`
+}
+
+func TestLayeredLoader(t *testing.T) {
+	defaults := testLayeredConfig{}
+	defaults.Server.Host = "localhost"
+	defaults.Server.Port = 8080
+	defaults.Server.Timeout = time.Second
+
+	tests := map[string]struct {
+		file       string
+		env        map[string]string
+		flags      []string
+		expConfig  func() testLayeredConfig
+		expSources map[string]string
+		expErr     bool
+	}{
+		"Without overrides the defaults should be used.": {
+			file: `{}`,
+			expConfig: func() testLayeredConfig {
+				return defaults
+			},
+			expSources: map[string]string{
+				"server.host":    "defaults",
+				"server.port":    "defaults",
+				"server.timeout": "defaults",
+				"debug":          "defaults",
+				"tags":           "defaults",
+			},
+		},
+
+		"The later layers should override the previous ones.": {
+			file:  `{"server":{"host":"file.local","port":9000},"debug":true}`,
+			env:   map[string]string{"TEST_SERVER__PORT": "9001", "TEST_TAGS": "a, b"},
+			flags: []string{"--server.port=9002", "--server.timeout=5s"},
+			expConfig: func() testLayeredConfig {
+				c := defaults
+				c.Server.Host = "file.local"
+				c.Server.Port = 9002
+				c.Server.Timeout = 5 * time.Second
+				c.Debug = true
+				c.Tags = []string{"a", "b"}
+				return c
+			},
+			expSources: map[string]string{
+				"server.host":    "file",
+				"server.port":    "flags",
+				"server.timeout": "flags",
+				"debug":          "file",
+				"tags":           "env",
+			},
+		},
+
+		"Values that can't be converted should fail.": {
+			file:   `{}`,
+			env:    map[string]string{"TEST_SERVER__PORT": "http"},
+			expErr: true,
+		},
+
+		"Unknown fields should fail.": {
+			file:   `{"other":1}`,
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(os.WriteFile(path, []byte(test.file), 0o600))
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.String("server.port", "", "")
+			fs.String("server.timeout", "", "")
+			require.NoError(fs.Parse(test.flags))
+
+			l, err := reloadconfig.NewLayeredLoader(context.TODO(), reloadconfig.LayeredLoaderConfig[testLayeredConfig]{
+				Layers: []reloadconfig.Layer{
+					reloadconfig.DefaultsLayer(defaults),
+					reloadconfig.FileLayer(path, reloadconfig.JSONDecoder),
+					reloadconfig.EnvLayer("TEST"),
+					reloadconfig.FlagLayer(fs),
+				},
+			})
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expConfig(), l.Get())
+				assert.Equal(test.expSources, l.Sources())
+			}
+		})
+	}
+}
+
+func TestLayeredLoaderChanges(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	defaults := testLayeredConfig{}
+	defaults.Server.Port = 8080
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(os.WriteFile(path, []byte(`{"server":{"host":"a"}}`), 0o600))
+
+	var gotChanges []reloadconfig.Change
+	l, err := reloadconfig.NewLayeredLoader(context.TODO(), reloadconfig.LayeredLoaderConfig[testLayeredConfig]{
+		Layers: []reloadconfig.Layer{
+			reloadconfig.DefaultsLayer(defaults),
+			reloadconfig.FileLayer(path, reloadconfig.JSONDecoder),
+			reloadconfig.EnvLayer("TEST"),
+		},
+		OnChange: func(_ context.Context, changes []reloadconfig.Change) { gotChanges = changes },
+	})
+	require.NoError(err)
+	assert.Nil(gotChanges)
+
+	// Change the file and env values.
+	require.NoError(os.WriteFile(path, []byte(`{"server":{"host":"b"}}`), 0o600))
+	t.Setenv("TEST_DEBUG", "true")
+	require.NoError(l.Reload(context.TODO(), ""))
+
+	expChanges := []reloadconfig.Change{
+		{Key: "debug", Layer: "env", Old: false, New: true},
+		{Key: "server.host", Layer: "file", Old: "a", New: "b"},
+	}
+	assert.Equal(expChanges, gotChanges)
+
+	// A failed reload should keep the previous configuration.
+	require.NoError(os.WriteFile(path, []byte(`{"server":`), 0o600))
+	assert.Error(l.Reload(context.TODO(), ""))
+	assert.Equal("b", l.Get().Server.Host)
+	assert.True(l.Get().Debug)
+}
+
+func TestFSLayer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	fsys := fstest.MapFS{"app.json": {Data: []byte(`{"server":{"port":9090}}`)}}
+
+	// Execute.
+	l, err := reloadconfig.NewLayeredLoader(context.TODO(), reloadconfig.LayeredLoaderConfig[testLayeredConfig]{
+		Layers: []reloadconfig.Layer{reloadconfig.FSLayer(fsys, "app.json", reloadconfig.JSONDecoder)},
+	})
+
+	// Check.
+	require.NoError(err)
+	assert.Equal(9090, l.Get().Server.Port)
+	assert.Equal(map[string]string{"server.port": "file"}, l.Sources())
+}
@@ -0,0 +1,234 @@
+package reloadconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// Decoder decodes the raw configuration into v (e.g: `json.Unmarshal`).
+type Decoder func(data []byte, v any) error
+
+// JSONDecoder is a Decoder for JSON, the unknown fields are rejected.
+func JSONDecoder(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// FileLoader returns a configuration loader that reads the configuration from a file.
+func FileLoader(path string) func(ctx context.Context) ([]byte, error) {
+	return fsLoader(os.DirFS(filepath.Dir(path)), filepath.Base(path), path)
+}
+
+// FSLoader returns a configuration loader that reads the configuration from the
+// name file of the file system (e.g: an `embed.FS`, or a `fstest.MapFS` on the
+// tests).
+func FSLoader(fsys fs.FS, name string) func(ctx context.Context) ([]byte, error) {
+	return fsLoader(fsys, name, name)
+}
+
+func fsLoader(fsys fs.FS, name, path string) func(ctx context.Context) ([]byte, error) {
+	return func(_ context.Context) ([]byte, error) {
+		return readFile(fsys, name, path)
+	}
+}
+
+// readFile reads the name file of the file system, the errors have the path of
+// the file instead of the name (e.g: the full path of the on-disk files).
+func readFile(fsys fs.FS, name, path string) ([]byte, error) {
+	data, err := fs.ReadFile(fsys, name)
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		pathErr.Path = path
+	}
+
+	return data, err
+}
+
+// LoaderConfig is the configuration of the Loader.
+type LoaderConfig[T any] struct {
+	// Load returns the raw configuration, it will be called on the creation
+	// and on every reload. FileLoader or FSLoader can be used.
+	Load func(ctx context.Context) ([]byte, error)
+	// Decoder decodes the raw configuration.
+	// By default JSONDecoder.
+	Decoder Decoder
+	// Defaults returns the default configuration, the raw configuration is
+	// decoded on top of it.
+	// By default the zero value.
+	Defaults func() T
+	// Validate validates the decoded configuration before it's swapped,
+	// optional.
+	Validate func(ctx context.Context, cfg T) error
+	// Store stores the applied raw configurations by manager generation, so
+	// `reload.Manager.RollbackTo` can revert to them, optional.
+	Store SnapshotStore
+}
+
+func (c *LoaderConfig[T]) defaults() error {
+	if c.Load == nil {
+		return fmt.Errorf("load function is required")
+	}
+
+	if c.Decoder == nil {
+		c.Decoder = JSONDecoder
+	}
+
+	if c.Defaults == nil {
+		c.Defaults = func() T {
+			var zero T
+			return zero
+		}
+	}
+
+	return nil
+}
+
+// Loader is a reload.Reloader that loads, decodes and validates a typed
+// configuration on every reload and swaps it atomically, the users should get
+// the configuration using Get on every use instead of storing it.
+//
+// If the configuration can't be loaded, decoded or is invalid, the reload
+// fails and the previous configuration is kept.
+//
+// With a snapshot store, the rollback reloads apply the stored configuration
+// of the rollback generation instead of loading it.
+//
+// The reloaders that need the previous configuration too can be registered
+// with WithChange, and the ones that only depend on part of the configuration
+// with WithInterest.
+type Loader[T any] struct {
+	cfg     LoaderConfig[T]
+	current atomic.Pointer[T]
+	last    atomic.Pointer[configChange[T]]
+}
+
+// NewLoader returns a new Loader, the configuration is loaded on the creation.
+func NewLoader[T any](ctx context.Context, cfg LoaderConfig[T]) (*Loader[T], error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	l := &Loader[T]{cfg: cfg}
+	err = l.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Get returns the current configuration.
+func (l *Loader[T]) Get() T {
+	return *l.current.Load()
+}
+
+// Reload satisfies reload.Reloader interface.
+func (l *Loader[T]) Reload(ctx context.Context, _ string) error {
+	data, c, err := l.decode(ctx)
+	if err != nil {
+		return err
+	}
+
+	if l.cfg.Store != nil {
+		// Outside of a manager (e.g: on creation) it's the initial configuration.
+		generation, _ := reload.GenerationFromContext(ctx)
+		err := l.cfg.Store.Save(ctx, Snapshot{Generation: generation, Data: data, At: time.Now()})
+		if err != nil {
+			return fmt.Errorf("could not store configuration snapshot: %w", err)
+		}
+	}
+
+	prev := l.current.Swap(&c)
+	l.last.Store(newConfigChange(prev, c))
+
+	return nil
+}
+
+// WithChange returns a reload.Reloader that calls the change reloader with the
+// configuration it last applied successfully (the current one when it's
+// created) and the current configuration of the loader, so the reloads it
+// failed or was skipped on are not lost. It needs to be added to the manager
+// with a later priority than the loader, and it's called even if the
+// configuration didn't change.
+func (l *Loader[T]) WithChange(r ChangeReloader[T]) reload.Reloader {
+	return changeReloader[T]{applied: newAppliedConfig(&l.last), r: r}
+}
+
+// ChangedPaths returns the configuration paths changed on the last reload of
+// the loader (see Diff).
+func (l *Loader[T]) ChangedPaths() []string {
+	return changedPaths(&l.last)
+}
+
+// WithInterest returns a reload.Reloader that reloads r only when a
+// configuration path that matches any of the patterns changed since the
+// configuration r last applied successfully (the current one when it's
+// created). The patterns are matched by `.` segment using `path.Match` (e.g:
+// `server.tls.*`), a path also matches the patterns of its children (e.g:
+// `server` matches `server.*.cert`) and the patterns without wildcards of its
+// parents (e.g: `server.tls.cert` matches `server`). Like WithChange, it needs
+// to be added to the manager with a later priority than the loader.
+func (l *Loader[T]) WithInterest(r reload.Reloader, patterns ...string) reload.Reloader {
+	return interestReloader[T]{applied: newAppliedConfig(&l.last), patterns: patterns, r: r}
+}
+
+// Validate satisfies reload.Validator interface, it loads, decodes and
+// validates the configuration without swapping it.
+func (l *Loader[T]) Validate(ctx context.Context, _ string) error {
+	_, _, err := l.decode(ctx)
+	return err
+}
+
+// decode loads, decodes and validates the configuration.
+func (l *Loader[T]) decode(ctx context.Context) ([]byte, T, error) {
+	c := l.cfg.Defaults()
+	data, err := l.load(ctx)
+	if err != nil {
+		return nil, c, err
+	}
+
+	err = l.cfg.Decoder(data, &c)
+	if err != nil {
+		return nil, c, fmt.Errorf("could not decode configuration: %w", err)
+	}
+
+	if l.cfg.Validate != nil {
+		err := l.cfg.Validate(ctx, c)
+		if err != nil {
+			return nil, c, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	return data, c, nil
+}
+
+func (l *Loader[T]) load(ctx context.Context) ([]byte, error) {
+	if l.cfg.Store != nil {
+		if generation, ok := reload.RollbackGenerationFromContext(ctx); ok {
+			s, err := l.cfg.Store.Get(ctx, generation)
+			if err != nil {
+				return nil, fmt.Errorf("could not get configuration snapshot: %w", err)
+			}
+			return s.Data, nil
+		}
+	}
+
+	data, err := l.cfg.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	return data, nil
+}
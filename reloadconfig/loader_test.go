@@ -0,0 +1,203 @@
+package reloadconfig_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadconfig"
+)
+
+type testConfig struct {
+	Name    string `json:"name"`
+	Workers int    `json:"workers"`
+}
+
+func TestLoader(t *testing.T) {
+	tests := map[string]struct {
+		initial   string
+		reloaded  string
+		validate  func(ctx context.Context, c testConfig) error
+		expConfig testConfig
+		expErr    bool
+	}{
+		"A valid configuration should be decoded on top of the defaults.": {
+			initial:   `{"name":"a"}`,
+			reloaded:  `{"name":"b"}`,
+			expConfig: testConfig{Name: "b", Workers: 4},
+		},
+
+		"Invalid syntax should fail and keep the previous configuration.": {
+			initial:   `{"name":"a"}`,
+			reloaded:  `{"name":`,
+			expConfig: testConfig{Name: "a", Workers: 4},
+			expErr:    true,
+		},
+
+		"Unknown fields should fail and keep the previous configuration.": {
+			initial:   `{"name":"a"}`,
+			reloaded:  `{"name":"b","other":1}`,
+			expConfig: testConfig{Name: "a", Workers: 4},
+			expErr:    true,
+		},
+
+		"A configuration that fails the validation should keep the previous configuration.": {
+			initial:  `{"name":"a"}`,
+			reloaded: `{"name":"b","workers":0}`,
+			validate: func(ctx context.Context, c testConfig) error {
+				if c.Workers <= 0 {
+					return fmt.Errorf("workers must be positive")
+				}
+				return nil
+			},
+			expConfig: testConfig{Name: "a", Workers: 4},
+			expErr:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(os.WriteFile(path, []byte(test.initial), 0o600))
+			l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+				Load:     reloadconfig.FileLoader(path),
+				Defaults: func() testConfig { return testConfig{Workers: 4} },
+				Validate: test.validate,
+			})
+			require.NoError(err)
+
+			// Execute.
+			require.NoError(os.WriteFile(path, []byte(test.reloaded), 0o600))
+			err = l.Reload(context.TODO(), "test")
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expConfig, l.Get())
+		})
+	}
+}
+
+func TestFSLoader(t *testing.T) {
+	tests := map[string]struct {
+		fsys      fstest.MapFS
+		expConfig testConfig
+		expErr    string
+	}{
+		"The configuration should be loaded from the file system.": {
+			fsys:      fstest.MapFS{"config/app.json": {Data: []byte(`{"name":"a"}`)}},
+			expConfig: testConfig{Name: "a", Workers: 4},
+		},
+
+		"A missing file should fail.": {
+			fsys:   fstest.MapFS{},
+			expErr: "could not load configuration: open config/app.json: file does not exist",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Execute.
+			l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+				Load:     reloadconfig.FSLoader(test.fsys, "config/app.json"),
+				Defaults: func() testConfig { return testConfig{Workers: 4} },
+			})
+
+			// Check.
+			if test.expErr != "" {
+				assert.EqualError(err, test.expErr)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(test.expConfig, l.Get())
+		})
+	}
+}
+
+func TestFileLoaderMissingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	// Execute.
+	_, err := reloadconfig.FileLoader(path)(context.TODO())
+
+	// Check.
+	assert.ErrorIs(err, fs.ErrNotExist)
+	assert.ErrorContains(err, path)
+}
+
+func TestNewLoaderInvalid(t *testing.T) {
+	_, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+		Load: func(ctx context.Context) ([]byte, error) { return nil, fmt.Errorf("something") },
+	})
+	assert.Error(t, err)
+}
+
+func TestLoaderValidate(t *testing.T) {
+	tests := map[string]struct {
+		reloaded string
+		expErr   bool
+	}{
+		"A valid configuration should not be swapped.": {
+			reloaded: `{"name":"b"}`,
+		},
+
+		"An invalid configuration should fail.": {
+			reloaded: `{"name":"b","workers":0}`,
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(os.WriteFile(path, []byte(`{"name":"a"}`), 0o600))
+			l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+				Load:     reloadconfig.FileLoader(path),
+				Defaults: func() testConfig { return testConfig{Workers: 4} },
+				Validate: func(ctx context.Context, c testConfig) error {
+					if c.Workers <= 0 {
+						return fmt.Errorf("workers must be positive")
+					}
+					return nil
+				},
+			})
+			require.NoError(err)
+
+			// Execute.
+			require.NoError(os.WriteFile(path, []byte(test.reloaded), 0o600))
+			err = l.Validate(context.TODO(), "test")
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(testConfig{Name: "a", Workers: 4}, l.Get())
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package reloadconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSnapshotNotFound is returned when a snapshot is not on the store.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Snapshot is an applied raw configuration.
+type Snapshot struct {
+	// Generation is the manager generation that applied the configuration.
+	Generation uint64
+	// Data is the raw configuration.
+	Data []byte
+	// At is when the configuration was applied.
+	At time.Time
+}
+
+// SnapshotStore stores the last applied configuration snapshots so the
+// loaders can roll back to them.
+type SnapshotStore interface {
+	// Save stores the snapshot, replacing the one with the same generation if
+	// any, and removes the old snapshots that exceed the store size.
+	Save(ctx context.Context, s Snapshot) error
+	// Get returns the latest snapshot at or below the generation, as the
+	// configuration is kept on the generations without a snapshot (e.g: the
+	// reloads of other pipelines), or ErrSnapshotNotFound.
+	Get(ctx context.Context, generation uint64) (Snapshot, error)
+}
+
+type memorySnapshotStore struct {
+	size      int
+	mu        sync.Mutex
+	snapshots []Snapshot // Sorted by generation.
+}
+
+// NewMemorySnapshotStore returns a SnapshotStore that keeps the last size
+// snapshots in memory.
+func NewMemorySnapshotStore(size int) SnapshotStore {
+	return &memorySnapshotStore{size: max(size, 1)}
+}
+
+func (m *memorySnapshotStore) Save(_ context.Context, s Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.Data = append([]byte(nil), s.Data...)
+	i := sort.Search(len(m.snapshots), func(i int) bool { return m.snapshots[i].Generation >= s.Generation })
+	switch {
+	case i < len(m.snapshots) && m.snapshots[i].Generation == s.Generation:
+		m.snapshots[i] = s
+	default:
+		m.snapshots = append(m.snapshots, Snapshot{})
+		copy(m.snapshots[i+1:], m.snapshots[i:])
+		m.snapshots[i] = s
+	}
+
+	if len(m.snapshots) > m.size {
+		m.snapshots = m.snapshots[len(m.snapshots)-m.size:]
+	}
+
+	return nil
+}
+
+func (m *memorySnapshotStore) Get(_ context.Context, generation uint64) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := sort.Search(len(m.snapshots), func(i int) bool { return m.snapshots[i].Generation > generation })
+	if i == 0 {
+		return Snapshot{}, fmt.Errorf("generation %d: %w", generation, ErrSnapshotNotFound)
+	}
+
+	s := m.snapshots[i-1]
+	s.Data = append([]byte(nil), s.Data...)
+
+	return s, nil
+}
+
+const snapshotFileExt = ".snapshot"
+
+type diskSnapshotStore struct {
+	dir  string
+	size int
+	mu   sync.Mutex
+}
+
+// NewDiskSnapshotStore returns a SnapshotStore that keeps the last size
+// snapshots as files in the directory (one file per generation) instead of
+// memory. The directory will be created if missing.
+//
+// The generations start again on every process, so the snapshots of the
+// previous processes on the directory are removed on creation. The directory
+// should not be shared by multiple processes or stores.
+func NewDiskSnapshotStore(dir string, size int) (SnapshotStore, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("could not create snapshot directory: %w", err)
+	}
+
+	d := &diskSnapshotStore{dir: dir, size: max(size, 1)}
+	generations, err := d.generations()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range generations {
+		err := os.Remove(d.path(g))
+		if err != nil {
+			return nil, fmt.Errorf("could not remove previous snapshot: %w", err)
+		}
+	}
+
+	return d, nil
+}
+
+func (d *diskSnapshotStore) Save(_ context.Context, s Snapshot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Write atomically so a crash doesn't leave a partial snapshot.
+	path := d.path(s.Generation)
+	tmp := path + ".tmp"
+	err := os.WriteFile(tmp, s.Data, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not write snapshot: %w", err)
+	}
+	if !s.At.IsZero() {
+		_ = os.Chtimes(tmp, s.At, s.At)
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("could not write snapshot: %w", err)
+	}
+
+	generations, err := d.generations()
+	if err != nil {
+		return err
+	}
+	for len(generations) > d.size {
+		err := os.Remove(d.path(generations[0]))
+		if err != nil {
+			return fmt.Errorf("could not remove old snapshot: %w", err)
+		}
+		generations = generations[1:]
+	}
+
+	return nil
+}
+
+func (d *diskSnapshotStore) Get(_ context.Context, generation uint64) (Snapshot, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	generations, err := d.generations()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	i := sort.Search(len(generations), func(i int) bool { return generations[i] > generation })
+	if i == 0 {
+		return Snapshot{}, fmt.Errorf("generation %d: %w", generation, ErrSnapshotNotFound)
+	}
+
+	path := d.path(generations[i-1])
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Snapshot{}, fmt.Errorf("generation %d: %w", generation, ErrSnapshotNotFound)
+		}
+		return Snapshot{}, fmt.Errorf("could not read snapshot: %w", err)
+	}
+
+	s := Snapshot{Generation: generations[i-1], Data: data}
+	if info, err := os.Stat(path); err == nil {
+		s.At = info.ModTime()
+	}
+
+	return s, nil
+}
+
+func (d *diskSnapshotStore) path(generation uint64) string {
+	return filepath.Join(d.dir, strconv.FormatUint(generation, 10)+snapshotFileExt)
+}
+
+// generations returns the stored generations sorted.
+func (d *diskSnapshotStore) generations() ([]uint64, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not list snapshots: %w", err)
+	}
+
+	var generations []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), snapshotFileExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		g, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		generations = append(generations, g)
+	}
+	sort.Slice(generations, func(i, j int) bool { return generations[i] < generations[j] })
+
+	return generations, nil
+}
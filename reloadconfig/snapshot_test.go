@@ -0,0 +1,121 @@
+package reloadconfig_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadconfig"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	tests := map[string]struct {
+		store func(t *testing.T) reloadconfig.SnapshotStore
+	}{
+		"Memory store.": {
+			store: func(t *testing.T) reloadconfig.SnapshotStore {
+				return reloadconfig.NewMemorySnapshotStore(2)
+			},
+		},
+
+		"Disk store.": {
+			store: func(t *testing.T) reloadconfig.SnapshotStore {
+				s, err := reloadconfig.NewDiskSnapshotStore(t.TempDir(), 2)
+				require.NoError(t, err)
+				return s
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			s := test.store(t)
+			at := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+			for i, data := range []string{"a", "b", "c", "c2"} {
+				g := min(uint64(i), 2)
+				require.NoError(s.Save(context.TODO(), reloadconfig.Snapshot{Generation: g, Data: []byte(data), At: at}))
+			}
+
+			// The oldest snapshots should be removed.
+			_, err := s.Get(context.TODO(), 0)
+			assert.ErrorIs(err, reloadconfig.ErrSnapshotNotFound)
+
+			got, err := s.Get(context.TODO(), 1)
+			require.NoError(err)
+			assert.Equal("b", string(got.Data))
+			assert.True(at.Equal(got.At))
+
+			// The snapshots of the same generation should be replaced.
+			got, err = s.Get(context.TODO(), 2)
+			require.NoError(err)
+			assert.Equal("c2", string(got.Data))
+
+			// The generations without snapshot should get the previous one.
+			got, err = s.Get(context.TODO(), 5)
+			require.NoError(err)
+			assert.Equal(uint64(2), got.Generation)
+			assert.Equal("c2", string(got.Data))
+		})
+	}
+}
+
+func TestDiskSnapshotStorePreviousProcess(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	dir := t.TempDir()
+	s, err := reloadconfig.NewDiskSnapshotStore(dir, 2)
+	require.NoError(err)
+	require.NoError(s.Save(context.TODO(), reloadconfig.Snapshot{Generation: 1, Data: []byte("a")}))
+
+	// Execute.
+	s, err = reloadconfig.NewDiskSnapshotStore(dir, 2)
+	require.NoError(err)
+
+	// Check.
+	_, err = s.Get(context.TODO(), 1)
+	assert.ErrorIs(err, reloadconfig.ErrSnapshotNotFound)
+}
+
+func TestLoaderRollback(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	data := `{"name":"a"}`
+	l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+		Load:  func(context.Context) ([]byte, error) { return []byte(data), nil },
+		Store: reloadconfig.NewMemorySnapshotStore(10),
+	})
+	require.NoError(err)
+
+	m := reload.NewManager()
+	m.Add(0, l)
+
+	// Execute.
+	data = `{"name":"b"}`
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{}))
+	data = `{"name":"c"}`
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{}))
+
+	// Check.
+	assert.Equal("c", l.Get().Name)
+
+	require.NoError(m.RollbackTo(context.TODO(), 1))
+	assert.Equal("b", l.Get().Name)
+
+	require.NoError(m.RollbackTo(context.TODO(), 0))
+	assert.Equal("a", l.Get().Name)
+
+	// The rollbacks are new generations.
+	require.NoError(m.RollbackTo(context.TODO(), 3))
+	assert.Equal("b", l.Get().Name)
+}
@@ -0,0 +1,2 @@
+// Package reloadflag has the command-line flags integrations of the reload mechanism.
+package reloadflag
@@ -0,0 +1,275 @@
+package reloadflag
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/slok/reload"
+)
+
+// Flag is a flag of a parsed flag set.
+type Flag struct {
+	// Name is the name of the flag.
+	Name string
+	// Default is the default value of the flag.
+	Default string
+	// Value is the value set on the command line, only if Set.
+	Value string
+	// Set is true when the flag has been set on the command line.
+	Set bool
+}
+
+// FlagSet returns the flags of a parsed flag set, StdFlagSet and PFlagSet
+// adapt the most common flag sets, other flag libraries (e.g: kingpin) can be
+// adapted with a function.
+type FlagSet func() []Flag
+
+// StdFlagSet adapts a parsed `flag.FlagSet`.
+func StdFlagSet(fs *flag.FlagSet) FlagSet {
+	return func() []Flag {
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		var flags []Flag
+		fs.VisitAll(func(f *flag.Flag) {
+			flags = append(flags, Flag{Name: f.Name, Default: f.DefValue, Value: f.Value.String(), Set: set[f.Name]})
+		})
+		return flags
+	}
+}
+
+// PFlagSet adapts a parsed `pflag.FlagSet`.
+func PFlagSet(fs *pflag.FlagSet) FlagSet {
+	return func() []Flag {
+		var flags []Flag
+		fs.VisitAll(func(f *pflag.Flag) {
+			flags = append(flags, Flag{Name: f.Name, Default: f.DefValue, Value: f.Value.String(), Set: f.Changed})
+		})
+		return flags
+	}
+}
+
+// ReloaderConfig is the configuration of the Reloader.
+type ReloaderConfig struct {
+	// FlagSet is the parsed flag set.
+	FlagSet FlagSet
+	// File is a flags file with a `name=value` flag per line, the empty lines
+	// and the lines starting with `#` are ignored, optional.
+	File string
+	// EnvPrefix is the prefix of the env vars of the flags, the env var of
+	// a flag is the uppercased name with `-` and `.` replaced by `_` (e.g:
+	// with `APP` prefix, `APP_LOG_LEVEL` sets `log-level`), optional.
+	EnvPrefix string
+}
+
+func (c *ReloaderConfig) defaults() error {
+	if c.FlagSet == nil {
+		return fmt.Errorf("flag set is required")
+	}
+
+	return nil
+}
+
+// Reloader is a reload.Reloader that resolves the values of the flags again
+// on every reload from the flags file and the env vars, so the flags can be
+// changed without restarting.
+//
+// The precedence is default < file < env < command line, so the flags set on
+// the command line can't be changed by a reload.
+//
+// The flag set variables are not changed, the reloadable flags are bound to
+// reload.Value holders (see Bind), that are updated atomically only if all the
+// bound flags are valid, otherwise the reload fails and the previous values are
+// kept.
+type Reloader struct {
+	cfg   ReloaderConfig
+	flags map[string]Flag
+
+	mu       sync.Mutex
+	values   map[string]string
+	bindings map[string][]binding
+}
+
+type binding interface {
+	// prepare parses the value and returns the function to set it.
+	prepare(s string) (set func(), err error)
+}
+
+// NewReloader returns a new Reloader, the flag values are resolved on the creation.
+func NewReloader(ctx context.Context, cfg ReloaderConfig) (*Reloader, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	r := &Reloader{
+		cfg:      cfg,
+		flags:    map[string]Flag{},
+		bindings: map[string][]binding{},
+	}
+	for _, f := range cfg.FlagSet() {
+		r.flags[f.Name] = f
+	}
+
+	err = r.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload satisfies reload.Reloader interface.
+func (r *Reloader) Reload(_ context.Context, _ string) error {
+	values, err := r.resolve()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Parse all the values before setting any of them.
+	var sets []func()
+	var errs []error
+	for name, bs := range r.bindings {
+		for _, b := range bs {
+			set, err := b.prepare(values[name])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %q flag value: %w", name, err))
+				continue
+			}
+			sets = append(sets, set)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, set := range sets {
+		set()
+	}
+	r.values = values
+
+	return nil
+}
+
+// resolve returns the values of all the flags.
+func (r *Reloader) resolve() (map[string]string, error) {
+	values := make(map[string]string, len(r.flags))
+	for name, f := range r.flags {
+		values[name] = f.Default
+	}
+
+	if r.cfg.File != "" {
+		data, err := os.ReadFile(r.cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("could not read flags file: %w", err)
+		}
+
+		s := bufio.NewScanner(bytes.NewReader(data))
+		for n := 1; s.Scan(); n++ {
+			line := strings.TrimSpace(s.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			name, value, ok := strings.Cut(line, "=")
+			name = strings.TrimPrefix(strings.TrimSpace(name), "--")
+			if !ok {
+				return nil, fmt.Errorf("invalid flags file line %d: missing value", n)
+			}
+			if _, ok := r.flags[name]; !ok {
+				return nil, fmt.Errorf("invalid flags file line %d: unknown %q flag", n, name)
+			}
+			values[name] = strings.TrimSpace(value)
+		}
+	}
+
+	if r.cfg.EnvPrefix != "" {
+		replacer := strings.NewReplacer("-", "_", ".", "_")
+		for name := range r.flags {
+			env := strings.ToUpper(r.cfg.EnvPrefix + "_" + replacer.Replace(name))
+			if v, ok := os.LookupEnv(env); ok {
+				values[name] = v
+			}
+		}
+	}
+
+	for name, f := range r.flags {
+		if f.Set {
+			values[name] = f.Value
+		}
+	}
+
+	return values, nil
+}
+
+type valueBinding[T any] struct {
+	value *reload.Value[T]
+	parse func(string) (T, error)
+}
+
+func (v valueBinding[T]) prepare(s string) (func(), error) {
+	n, err := v.parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return func() { v.value.Set(n) }, nil
+}
+
+// Bind returns a reload.Value holder of the flag that is updated on every
+// reload, the flag value is parsed with the parse function.
+func Bind[T any](r *Reloader, name string, parse func(string) (T, error)) (*reload.Value[T], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.flags[name]; !ok {
+		return nil, fmt.Errorf("unknown %q flag", name)
+	}
+
+	n, err := parse(r.values[name])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q flag value: %w", name, err)
+	}
+
+	v := reload.NewValue(n)
+	r.bindings[name] = append(r.bindings[name], valueBinding[T]{value: v, parse: parse})
+
+	return v, nil
+}
+
+// String binds a string flag, see Bind.
+func String(r *Reloader, name string) (*reload.Value[string], error) {
+	return Bind(r, name, func(s string) (string, error) { return s, nil })
+}
+
+// Int binds an int flag, see Bind.
+func Int(r *Reloader, name string) (*reload.Value[int], error) {
+	return Bind(r, name, strconv.Atoi)
+}
+
+// Float64 binds a float64 flag, see Bind.
+func Float64(r *Reloader, name string) (*reload.Value[float64], error) {
+	return Bind(r, name, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+}
+
+// Bool binds a bool flag, see Bind.
+func Bool(r *Reloader, name string) (*reload.Value[bool], error) {
+	return Bind(r, name, strconv.ParseBool)
+}
+
+// Duration binds a `time.Duration` flag, see Bind.
+func Duration(r *Reloader, name string) (*reload.Value[time.Duration], error) {
+	return Bind(r, name, time.ParseDuration)
+}
@@ -0,0 +1,98 @@
+package reloadflag_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadflag"
+)
+
+func TestReloader(t *testing.T) {
+	tests := map[string]struct {
+		flagSet func(args []string) reloadflag.FlagSet
+	}{
+		"Std flag set.": {
+			flagSet: func(args []string) reloadflag.FlagSet {
+				fs := flag.NewFlagSet("test", flag.ContinueOnError)
+				fs.String("log-level", "info", "")
+				fs.Int("workers", 4, "")
+				fs.Duration("timeout", time.Second, "")
+				_ = fs.Parse(args)
+				return reloadflag.StdFlagSet(fs)
+			},
+		},
+
+		"pflag flag set.": {
+			flagSet: func(args []string) reloadflag.FlagSet {
+				fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+				fs.String("log-level", "info", "")
+				fs.Int("workers", 4, "")
+				fs.Duration("timeout", time.Second, "")
+				_ = fs.Parse(args)
+				return reloadflag.PFlagSet(fs)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			path := filepath.Join(t.TempDir(), "flags")
+			require.NoError(os.WriteFile(path, []byte("# Flags.\nlog-level=debug\nworkers=8\n"), 0o600))
+			t.Setenv("TEST_WORKERS", "16")
+
+			r, err := reloadflag.NewReloader(context.TODO(), reloadflag.ReloaderConfig{
+				FlagSet:   test.flagSet([]string{"--timeout=5s"}),
+				File:      path,
+				EnvPrefix: "TEST",
+			})
+			require.NoError(err)
+			logLevel, err := reloadflag.String(r, "log-level")
+			require.NoError(err)
+			workers, err := reloadflag.Int(r, "workers")
+			require.NoError(err)
+			timeout, err := reloadflag.Duration(r, "timeout")
+			require.NoError(err)
+
+			// The env should override the file and the command line the env.
+			assert.Equal("debug", logLevel.Get())
+			assert.Equal(16, workers.Get())
+			assert.Equal(5*time.Second, timeout.Get())
+
+			// Reload with changes.
+			require.NoError(os.WriteFile(path, []byte("log-level=warn\ntimeout=1m\n"), 0o600))
+			t.Setenv("TEST_WORKERS", "32")
+			require.NoError(r.Reload(context.TODO(), ""))
+			assert.Equal("warn", logLevel.Get())
+			assert.Equal(32, workers.Get())
+			assert.Equal(5*time.Second, timeout.Get())
+
+			// Invalid values should not change any value.
+			require.NoError(os.WriteFile(path, []byte("log-level=error\n"), 0o600))
+			t.Setenv("TEST_WORKERS", "many")
+			assert.Error(r.Reload(context.TODO(), ""))
+			assert.Equal("warn", logLevel.Get())
+			assert.Equal(32, workers.Get())
+
+			// Unknown flags should fail.
+			require.NoError(os.WriteFile(path, []byte("other=1\n"), 0o600))
+			t.Setenv("TEST_WORKERS", "1")
+			assert.Error(r.Reload(context.TODO(), ""))
+			assert.Equal("warn", logLevel.Get())
+
+			_, err = reloadflag.Int(r, "other")
+			assert.Error(err)
+		})
+	}
+}
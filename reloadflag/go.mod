@@ -0,0 +1,18 @@
+module github.com/slok/reload/reloadflag
+
+go 1.23
+
+require (
+	github.com/slok/reload v0.0.0
+	github.com/spf13/pflag v1.0.5
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/slok/reload => ../
@@ -0,0 +1,3 @@
+// Package reloadfsnotify has the filesystem events (inotify, kqueue...)
+// integrations of the reload mechanism.
+package reloadfsnotify
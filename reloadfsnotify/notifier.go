@@ -0,0 +1,409 @@
+package reloadfsnotify
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/slok/reload"
+)
+
+// ErrClosed is returned by the Notifier when it has been closed.
+var ErrClosed = errors.New("notifier closed")
+
+// Mode is how the Notifier detects the file changes.
+type Mode string
+
+const (
+	// ModeAuto probes the filesystem events on creation, and uses ModeEvents
+	// if they are delivered or ModePolling if not (e.g: NFS, FUSE or some bind
+	// mounts). When the probe can't be done (e.g: read-only directories),
+	// ModeHybrid is used.
+	ModeAuto Mode = "auto"
+	// ModeEvents uses the filesystem events.
+	ModeEvents Mode = "events"
+	// ModePolling checks the files periodically.
+	ModePolling Mode = "polling"
+	// ModeHybrid uses the filesystem events supplemented with polling, so the
+	// changes without events are detected on the next poll.
+	ModeHybrid Mode = "hybrid"
+)
+
+// NotifierConfig is the configuration of the Notifier.
+type NotifierConfig struct {
+	// Paths are the files that will be watched.
+	Paths []string
+	// TriggerID is the ID used on the triggers.
+	// By default `file`.
+	TriggerID string
+	// Mode is how the file changes are detected.
+	// By default ModeAuto.
+	Mode Mode
+	// PollInterval is the interval used to check for file changes when
+	// polling.
+	// By default 1s.
+	PollInterval time.Duration
+	// ProbeTimeout is the maximum time waiting for the probe event of
+	// ModeAuto.
+	// By default 1s.
+	ProbeTimeout time.Duration
+	// Clock is the clock of the poll interval.
+	// By default reload.RealClock.
+	Clock reload.Clock
+}
+
+func (c *NotifierConfig) defaults() error {
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("at least one path is required")
+	}
+
+	if c.TriggerID == "" {
+		c.TriggerID = "file"
+	}
+
+	switch c.Mode {
+	case "":
+		c.Mode = ModeAuto
+	case ModeAuto, ModeEvents, ModePolling, ModeHybrid:
+	default:
+		return fmt.Errorf("unknown %q mode", c.Mode)
+	}
+
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+
+	if c.ProbeTimeout <= 0 {
+		c.ProbeTimeout = time.Second
+	}
+
+	if c.Clock == nil {
+		c.Clock = reload.RealClock
+	}
+
+	return nil
+}
+
+// fileState is the state of a watched file. The content hash detects the
+// changes that keep the size and the modification time (e.g: coarse mtime
+// filesystems or the atomic replaces that preserve the mtime).
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime int64
+	hash    [sha256.Size]byte
+}
+
+// Notifier is a reload.Notifier that triggers a reload when any of the watched
+// files change (created, modified or removed). It prefers the filesystem
+// events and falls back to polling on the filesystems that don't deliver them
+// (see Mode), so the reloads work on any storage. The files replaced by the
+// editors and config management tools (e.g: written to a temporary file and
+// renamed) keep being watched.
+//
+// The returned trigger has the paths of the files that changed, so
+// reloaders can get them using reload.TriggerEventFromContext.
+type Notifier struct {
+	cfg     NotifierConfig
+	mode    Mode
+	watcher *fsnotify.Watcher
+	state   map[string]fileState
+	watched map[string]bool
+}
+
+// NewNotifier returns a new Notifier. The state of the files is taken when
+// created, so any change after this will trigger a reload.
+func NewNotifier(cfg NotifierConfig) (*Notifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	paths := make([]string, 0, len(cfg.Paths))
+	for _, p := range cfg.Paths {
+		paths = append(paths, filepath.Clean(p))
+	}
+	cfg.Paths = paths
+
+	n := &Notifier{cfg: cfg, mode: cfg.Mode, state: map[string]fileState{}, watched: map[string]bool{}}
+	if n.mode == ModeAuto {
+		n.mode = probeMode(cfg.Paths, cfg.ProbeTimeout)
+	}
+
+	for _, p := range cfg.Paths {
+		st, err := statFile(p)
+		if err != nil {
+			return nil, err
+		}
+		n.state[p] = st
+	}
+
+	if n.mode != ModePolling {
+		err := n.watch()
+		if err != nil {
+			_ = n.Close()
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// watch starts watching the directories of the files and the files.
+//
+// The directories are watched so the editors and config management tools
+// that replace the files (write to a temporary file and rename it, or remove
+// and create it again) are detected, as the file watches are dropped with the
+// replaced files. The files are watched too, so the changes of the symlinks
+// targets out of the directories are detected.
+func (n *Notifier) watch() error {
+	var err error
+	n.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("could not create watcher: %w", err)
+	}
+
+	for _, d := range parentDirs(n.cfg.Paths) {
+		err := n.watcher.Add(d)
+		if err != nil {
+			return fmt.Errorf("could not watch %q directory: %w", d, err)
+		}
+	}
+
+	return n.watchFiles()
+}
+
+// watchFiles watches the existing files that are not watched, the missing
+// files are watched when they are created again.
+func (n *Notifier) watchFiles() error {
+	for _, p := range n.cfg.Paths {
+		if n.watched[p] || !n.state[p].exists {
+			continue
+		}
+
+		err := n.watcher.Add(p)
+		if err != nil {
+			// Removed after the check.
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("could not watch %q file: %w", p, err)
+		}
+		n.watched[p] = true
+	}
+
+	return nil
+}
+
+// unwatchReplaced stops watching a file that has been removed or renamed, so
+// it's watched again once created.
+func (n *Notifier) unwatchReplaced(e fsnotify.Event) {
+	p := filepath.Clean(e.Name)
+	if !n.watched[p] || !(e.Has(fsnotify.Remove) || e.Has(fsnotify.Rename)) {
+		return
+	}
+
+	_ = n.watcher.Remove(p)
+	delete(n.watched, p)
+}
+
+// Mode returns the mode used to detect the file changes, the probed one on
+// ModeAuto.
+func (n *Notifier) Mode() Mode { return n.mode }
+
+// Notify satisfies reload.Notifier interface.
+func (n *Notifier) Notify(ctx context.Context) (string, error) {
+	t, err := n.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (n *Notifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	var pollC <-chan time.Time
+	if n.mode == ModePolling || n.mode == ModeHybrid {
+		t := n.cfg.Clock.NewTicker(n.cfg.PollInterval)
+		defer t.Stop()
+		pollC = t.C()
+	}
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if n.watcher != nil {
+		events = n.watcher.Events
+		errs = n.watcher.Errors
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return reload.TriggerEvent{}, ctx.Err()
+		case err, ok := <-errs:
+			if !ok {
+				return reload.TriggerEvent{}, ErrClosed
+			}
+			return reload.TriggerEvent{}, fmt.Errorf("watcher failed: %w", err)
+		case e, ok := <-events:
+			if !ok {
+				return reload.TriggerEvent{}, ErrClosed
+			}
+			n.unwatchReplaced(e)
+		case <-pollC:
+		}
+
+		changed, err := n.changedPaths()
+		if err != nil {
+			return reload.TriggerEvent{}, err
+		}
+
+		if n.watcher != nil {
+			err := n.watchFiles()
+			if err != nil {
+				return reload.TriggerEvent{}, err
+			}
+		}
+
+		if len(changed) > 0 {
+			return reload.TriggerEvent{ID: n.cfg.TriggerID, Paths: changed}, nil
+		}
+	}
+}
+
+// Close stops watching the files.
+func (n *Notifier) Close() error {
+	if n.watcher == nil {
+		return nil
+	}
+
+	return n.watcher.Close()
+}
+
+// changedPaths returns the paths that changed since the last check, in the
+// same order they were configured. The events are only used as a hint to
+// check the files, so the duplicated events don't trigger twice.
+func (n *Notifier) changedPaths() ([]string, error) {
+	var changed []string
+	for _, p := range n.cfg.Paths {
+		st, err := statFile(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if st != n.state[p] {
+			n.state[p] = st
+			changed = append(changed, p)
+		}
+	}
+
+	return changed, nil
+}
+
+// probeMode returns the mode for the paths, checking if the filesystem events
+// are delivered on the directories of the paths.
+func probeMode(paths []string, timeout time.Duration) Mode {
+	for _, d := range parentDirs(paths) {
+		delivered, err := probeDir(d, timeout)
+		if err != nil {
+			return ModeHybrid
+		}
+		if !delivered {
+			return ModePolling
+		}
+	}
+
+	return ModeEvents
+}
+
+// parentDirs returns the sorted directories of the paths.
+func parentDirs(paths []string) []string {
+	set := map[string]struct{}{}
+	for _, p := range paths {
+		set[filepath.Dir(p)] = struct{}{}
+	}
+	dirs := make([]string, 0, len(set))
+	for d := range set {
+		dirs = append(dirs, d)
+	}
+	slices.Sort(dirs)
+
+	return dirs
+}
+
+// probeDir returns if the filesystem events are delivered on the directory,
+// writing a temporary probe file.
+func probeDir(dir string, timeout time.Duration) (bool, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return false, err
+	}
+	defer w.Close()
+
+	err = w.Add(dir)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := os.CreateTemp(dir, ".reload-probe-*")
+	if err != nil {
+		return false, err
+	}
+	probe := f.Name()
+	defer os.Remove(probe)
+	_, err = f.WriteString("probe")
+	closeErr := f.Close()
+	if err := errors.Join(err, closeErr); err != nil {
+		return false, err
+	}
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	for {
+		select {
+		case e := <-w.Events:
+			if filepath.Clean(e.Name) == filepath.Clean(probe) {
+				return true, nil
+			}
+		case err := <-w.Errors:
+			return false, err
+		case <-t.C:
+			return false, nil
+		}
+	}
+}
+
+func statFile(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fileState{}, nil
+		}
+		return fileState{}, fmt.Errorf("could not stat %q file: %w", path, err)
+	}
+
+	st := fileState{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime().UnixNano(),
+	}
+	if !info.Mode().IsRegular() {
+		return st, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fileState{}, nil
+		}
+		return fileState{}, fmt.Errorf("could not read %q file: %w", path, err)
+	}
+	st.hash = sha256.Sum256(data)
+
+	return st, nil
+}
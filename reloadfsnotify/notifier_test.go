@@ -0,0 +1,188 @@
+package reloadfsnotify_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadfsnotify"
+)
+
+func TestNotifier(t *testing.T) {
+	tests := map[string]struct {
+		mode     reloadfsnotify.Mode
+		change   func(t *testing.T, dir string)
+		expMode  reloadfsnotify.Mode
+		expPaths []string
+	}{
+		"A modified file should trigger with its path using the events.": {
+			mode: reloadfsnotify.ModeEvents,
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte("changed-content"), 0o600))
+			},
+			expMode:  reloadfsnotify.ModeEvents,
+			expPaths: []string{"b.json"},
+		},
+
+		"A removed file should trigger with its path using the events.": {
+			mode: reloadfsnotify.ModeEvents,
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.Remove(filepath.Join(dir, "a.json")))
+			},
+			expMode:  reloadfsnotify.ModeEvents,
+			expPaths: []string{"a.json"},
+		},
+
+		"A modified file should trigger with its path using polling.": {
+			mode: reloadfsnotify.ModePolling,
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte("changed-content"), 0o600))
+			},
+			expMode:  reloadfsnotify.ModePolling,
+			expPaths: []string{"a.json"},
+		},
+
+		"A file modified keeping its size and modification time should trigger using polling.": {
+			mode: reloadfsnotify.ModePolling,
+			change: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "a.json")
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(path, []byte("z.json"), 0o600))
+				require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+			},
+			expMode:  reloadfsnotify.ModePolling,
+			expPaths: []string{"a.json"},
+		},
+
+		"A modified file should trigger with its path using the events and polling.": {
+			mode: reloadfsnotify.ModeHybrid,
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte("changed-content"), 0o600))
+			},
+			expMode:  reloadfsnotify.ModeHybrid,
+			expPaths: []string{"a.json"},
+		},
+
+		"The events should be used when the filesystem delivers them.": {
+			mode: reloadfsnotify.ModeAuto,
+			change: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte("changed-content"), 0o600))
+			},
+			expMode:  reloadfsnotify.ModeEvents,
+			expPaths: []string{"a.json"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			dir := t.TempDir()
+			paths := []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")}
+			for _, p := range paths {
+				require.NoError(os.WriteFile(p, []byte(filepath.Base(p)), 0o600))
+			}
+			n, err := reloadfsnotify.NewNotifier(reloadfsnotify.NotifierConfig{
+				Paths:        paths,
+				Mode:         test.mode,
+				PollInterval: 5 * time.Millisecond,
+			})
+			require.NoError(err)
+			defer n.Close()
+
+			// Execute.
+			test.change(t, dir)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			gotTrigger, err := n.NotifyTrigger(ctx)
+
+			// Check.
+			require.NoError(err)
+			assert.Equal(test.expMode, n.Mode())
+			expPaths := []string{}
+			for _, p := range test.expPaths {
+				expPaths = append(expPaths, filepath.Join(dir, p))
+			}
+			assert.Equal(reload.TriggerEvent{ID: "file", Paths: expPaths}, gotTrigger)
+		})
+	}
+}
+
+func TestNotifierClosed(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "a.json")
+	require.NoError(os.WriteFile(path, []byte("content"), 0o600))
+	n, err := reloadfsnotify.NewNotifier(reloadfsnotify.NotifierConfig{Paths: []string{path}, Mode: reloadfsnotify.ModeEvents})
+	require.NoError(err)
+	require.NoError(n.Close())
+
+	_, err = n.Notify(context.TODO())
+
+	assert.ErrorIs(err, reloadfsnotify.ErrClosed)
+}
+
+func TestNotifierInvalidConfig(t *testing.T) {
+	_, err := reloadfsnotify.NewNotifier(reloadfsnotify.NotifierConfig{Paths: []string{"a.json"}, Mode: "unknown"})
+
+	assert.Error(t, err)
+}
+
+// savePatterns are the ways the editors and config management tools save the
+// files.
+var savePatterns = map[string]func(t *testing.T, path, content string){
+	"in place": func(t *testing.T, path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	},
+	"atomic write": func(t *testing.T, path, content string) {
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0o600))
+		require.NoError(t, os.Rename(tmp, path))
+	},
+	"vim backup": func(t *testing.T, path, content string) {
+		backup := path + "~"
+		require.NoError(t, os.Rename(path, backup))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		require.NoError(t, os.Remove(backup))
+	},
+	"remove and create": func(t *testing.T, path, content string) {
+		require.NoError(t, os.Remove(path))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	},
+}
+
+func TestNotifierSavePatterns(t *testing.T) {
+	for name, save := range savePatterns {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(os.WriteFile(path, []byte("v0"), 0o600))
+			n, err := reloadfsnotify.NewNotifier(reloadfsnotify.NotifierConfig{Paths: []string{path}, Mode: reloadfsnotify.ModeEvents})
+			require.NoError(err)
+			defer n.Close()
+
+			// Execute and check every save triggers.
+			for i, content := range []string{"v11", "v222", "v3333"} {
+				save(t, path, content)
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				got, err := n.NotifyTrigger(ctx)
+				cancel()
+				require.NoError(err, "save %d", i)
+				assert.Equal([]string{path}, got.Paths, "save %d", i)
+			}
+		})
+	}
+}
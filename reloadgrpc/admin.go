@@ -0,0 +1,234 @@
+package reloadgrpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/slok/reload"
+)
+
+// AdminServiceName is the gRPC admin service name.
+const AdminServiceName = "reload.v1.Admin"
+
+// AdminServerConfig is the configuration of the AdminServer.
+type AdminServerConfig struct {
+	// Token is the token required to use the admin service as a bearer token
+	// (see BearerTokenAuthorizer).
+	Token string
+	// Authorizer authorizes the calls in addition to the token (e.g: a custom
+	// one with AdminAuthorizerFunc). If neither the token nor the authorizer
+	// are set, the service will not be protected. The TLS client
+	// certificates are verified by the gRPC server transport credentials.
+	Authorizer AdminAuthorizer
+	// BufferSize is the number of events buffered for every watcher, the
+	// watchers that don't keep up are disconnected with `RESOURCE_EXHAUSTED`
+	// so they don't block the reloads.
+	// By default 100.
+	BufferSize int
+}
+
+func (c *AdminServerConfig) defaults() error {
+	if c.BufferSize <= 0 {
+		c.BufferSize = 100
+	}
+
+	return nil
+}
+
+// AdminServer is the gRPC admin service of the reload mechanism, it's a
+// reload.Subscriber that needs to be subscribed to the manager (e.g: using
+// `Manager.Subscribe`) and registered on the gRPC server with
+// RegisterAdminServer.
+//
+// The service (`reload.v1.Admin`) uses the protobuf well-known types, so no
+// generated code is required:
+//
+//   - `WatchEvents(google.protobuf.Empty) returns (stream google.protobuf.Struct)`:
+//     Streams the manager lifecycle events once the call starts, every event
+//     is the JSON representation of reload.MarshalEventJSON. See WatchEvents
+//     for the client.
+//
+// The calls can be protected with a bearer token or a custom authorizer (see
+// AdminServerConfig.Authorizer), the unauthorized calls fail with
+// `UNAUTHENTICATED`.
+type AdminServer struct {
+	cfg         AdminServerConfig
+	authorizers []AdminAuthorizer
+	events      *reload.EventFanout
+}
+
+var _ reload.Subscriber = &AdminServer{}
+
+// NewAdminServer returns a new AdminServer.
+func NewAdminServer(cfg AdminServerConfig) (*AdminServer, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	a := &AdminServer{cfg: cfg, events: reload.NewEventFanout(cfg.BufferSize)}
+	if cfg.Token != "" {
+		a.authorizers = append(a.authorizers, BearerTokenAuthorizer(cfg.Token))
+	}
+	if cfg.Authorizer != nil {
+		a.authorizers = append(a.authorizers, cfg.Authorizer)
+	}
+
+	return a, nil
+}
+
+// RegisterAdminServer registers the admin service on the gRPC server.
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv *AdminServer) {
+	s.RegisterService(&adminServiceDesc, srv)
+}
+
+// HandleEvent satisfies reload.Subscriber interface.
+func (a *AdminServer) HandleEvent(ctx context.Context, e reload.Event) {
+	a.events.HandleEvent(ctx, e)
+}
+
+// authorize authorizes the call with all the authorizers.
+func (a *AdminServer) authorize(ctx context.Context) error {
+	for _, authz := range a.authorizers {
+		err := authz.Authorize(ctx)
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (a *AdminServer) watchEvents(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	err := a.authorize(ctx)
+	if err != nil {
+		return err
+	}
+
+	watcher := a.events.Watch()
+	defer watcher.Stop()
+
+	// Let the client know the events are being watched.
+	err = stream.SendHeader(metadata.MD{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-watcher.Lagging():
+			return status.Error(codes.ResourceExhausted, "watcher didn't keep up with the events")
+		case e := <-watcher.Events():
+			msg, err := eventStruct(e.Event)
+			if err != nil {
+				continue
+			}
+			err = stream.SendMsg(msg)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// eventStruct returns the event JSON representation as a struct message.
+func eventStruct(e reload.Event) (*structpb.Struct, error) {
+	data, err := reload.MarshalEventJSON(e)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &structpb.Struct{}
+	err = protojson.Unmarshal(data, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// adminService is the handler type of the admin service.
+type adminService interface {
+	watchEvents(stream grpc.ServerStream) error
+}
+
+var adminServiceDesc = grpc.ServiceDesc{
+	ServiceName: AdminServiceName,
+	HandlerType: (*adminService)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			ServerStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				err := stream.RecvMsg(&emptypb.Empty{})
+				if err != nil {
+					return err
+				}
+				return srv.(adminService).watchEvents(stream)
+			},
+		},
+	},
+	Metadata: "reload/v1/admin.proto",
+}
+
+// EventStream is a stream of manager lifecycle events of a remote admin
+// service.
+type EventStream struct {
+	stream grpc.ClientStream
+}
+
+// WatchEvents starts watching the lifecycle events of the manager of a remote
+// admin service (see AdminServer), until the context is cancelled. It returns
+// once the server is watching, so the events emitted after it returns are
+// received.
+func WatchEvents(ctx context.Context, cc grpc.ClientConnInterface, opts ...grpc.CallOption) (*EventStream, error) {
+	stream, err := cc.NewStream(ctx, &adminServiceDesc.Streams[0], "/"+AdminServiceName+"/WatchEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stream.SendMsg(&emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = stream.CloseSend()
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait until the events are being watched, the calls that end without
+	// header (e.g: unauthorized) return the call status.
+	md, err := stream.Header()
+	if err != nil {
+		return nil, err
+	}
+	if md == nil {
+		return nil, stream.RecvMsg(&structpb.Struct{})
+	}
+
+	return &EventStream{stream: stream}, nil
+}
+
+// Recv blocks until the next event is received and returns its JSON
+// representation (see reload.MarshalEventJSON).
+func (e *EventStream) Recv() ([]byte, error) {
+	msg := &structpb.Struct{}
+	err := e.stream.RecvMsg(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return protojson.Marshal(msg)
+}
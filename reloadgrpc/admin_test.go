@@ -0,0 +1,162 @@
+package reloadgrpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadgrpc"
+)
+
+func newTestAdminServer(t *testing.T, srv *reloadgrpc.AdminServer) *bufconn.Listener {
+	l := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	reloadgrpc.RegisterAdminServer(s, srv)
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	return l
+}
+
+func TestAdminServerWatchEvents(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	srv, err := reloadgrpc.NewAdminServer(reloadgrpc.AdminServerConfig{})
+	require.NoError(err)
+	m := reload.NewManager()
+	m.Subscribe(srv)
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }), reload.WithReloaderName("config"))
+	conn, err := dialTestServer(newTestAdminServer(t, srv))
+	require.NoError(err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := reloadgrpc.WatchEvents(ctx, conn)
+	require.NoError(err)
+
+	// Execute.
+	_ = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	var got []string
+	for len(got) == 0 || got[len(got)-1] != "reload_finished" {
+		data, err := stream.Recv()
+		require.NoError(err)
+		var e struct {
+			Type      string `json:"type"`
+			TriggerID string `json:"trigger_id"`
+			Reloader  string `json:"reloader"`
+			Error     string `json:"error"`
+		}
+		require.NoError(json.Unmarshal(data, &e))
+		assert.Equal("t1", e.TriggerID)
+		if e.Type == "reloader_finished" {
+			assert.Equal("config", e.Reloader)
+			assert.Equal("something", e.Error)
+		}
+		got = append(got, e.Type)
+	}
+	assert.Equal([]string{"trigger_received", "reload_started", "group_started", "reloader_finished", "group_finished", "reload_finished"}, got)
+}
+
+func TestAdminServerWatchEventsLagging(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	srv, err := reloadgrpc.NewAdminServer(reloadgrpc.AdminServerConfig{BufferSize: 1})
+	require.NoError(err)
+	conn, err := dialTestServer(newTestAdminServer(t, srv))
+	require.NoError(err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := reloadgrpc.WatchEvents(ctx, conn)
+	require.NoError(err)
+
+	// Execute.
+	for i := 0; i < 1000; i++ {
+		srv.HandleEvent(context.TODO(), reload.Event{Type: reload.EventTriggerReceived, Trigger: reload.TriggerEvent{ID: "t1"}})
+	}
+
+	// Check.
+	for {
+		_, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+	assert.Equal(codes.ResourceExhausted, status.Code(err))
+}
+
+func TestAdminServerAuthorization(t *testing.T) {
+	tests := map[string]struct {
+		cfg     reloadgrpc.AdminServerConfig
+		auth    string
+		expCode codes.Code
+	}{
+		"Without token nor authorizer the calls should be authorized.": {
+			expCode: codes.OK,
+		},
+
+		"A valid bearer token should be authorized.": {
+			cfg:     reloadgrpc.AdminServerConfig{Token: "secret"},
+			auth:    "Bearer secret",
+			expCode: codes.OK,
+		},
+
+		"An invalid bearer token should not be authorized.": {
+			cfg:     reloadgrpc.AdminServerConfig{Token: "secret"},
+			auth:    "Bearer nope",
+			expCode: codes.Unauthenticated,
+		},
+
+		"The token and the authorizer should be required.": {
+			cfg: reloadgrpc.AdminServerConfig{
+				Token:      "secret",
+				Authorizer: reloadgrpc.AdminAuthorizerFunc(func(ctx context.Context) error { return fmt.Errorf("something") }),
+			},
+			auth:    "Bearer secret",
+			expCode: codes.Unauthenticated,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			srv, err := reloadgrpc.NewAdminServer(test.cfg)
+			require.NoError(err)
+			conn, err := dialTestServer(newTestAdminServer(t, srv))
+			require.NoError(err)
+			defer conn.Close()
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.auth != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", test.auth)
+			}
+			_, err = reloadgrpc.WatchEvents(ctx, conn)
+
+			// Check.
+			assert.Equal(test.expCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package reloadgrpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// AdminAuthorizer authorizes the calls of the admin service, the unauthorized
+// calls fail with `UNAUTHENTICATED` and the error message.
+type AdminAuthorizer interface {
+	Authorize(ctx context.Context) error
+}
+
+// AdminAuthorizerFunc is a helper to create admin authorizers from functions.
+type AdminAuthorizerFunc func(ctx context.Context) error
+
+// Authorize satisfies AdminAuthorizer interface.
+func (f AdminAuthorizerFunc) Authorize(ctx context.Context) error { return f(ctx) }
+
+// BearerTokenAuthorizer returns an AdminAuthorizer that requires the token as
+// a bearer token on the call metadata (`authorization: Bearer <token>`).
+func BearerTokenAuthorizer(token string) AdminAuthorizer {
+	expected := []byte("Bearer " + token)
+	return AdminAuthorizerFunc(func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var auth string
+		if v := md.Get("authorization"); len(v) > 0 {
+			auth = v[0]
+		}
+		if subtle.ConstantTimeCompare([]byte(auth), expected) != 1 {
+			return errors.New("invalid token")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,130 @@
+package reloadgrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// ClientConnConfig is the configuration of the ClientConn.
+type ClientConnConfig struct {
+	// Dial returns a new gRPC client connection, it will be called on the
+	// creation and on every reload, so it should get the latest target,
+	// credentials and service configuration.
+	Dial func(ctx context.Context) (*grpc.ClientConn, error)
+	// DrainPeriod is the time that the replaced connection will be kept open
+	// so the in-flight RPCs can finish.
+	// By default 30s.
+	DrainPeriod time.Duration
+}
+
+func (c *ClientConnConfig) defaults() error {
+	if c.Dial == nil {
+		return fmt.Errorf("dial function is required")
+	}
+
+	if c.DrainPeriod <= 0 {
+		c.DrainPeriod = 30 * time.Second
+	}
+
+	return nil
+}
+
+// ClientConn is a gRPC client connection that is re-dialed on every reload,
+// it satisfies `grpc.ClientConnInterface` so it can be used to create the gRPC
+// clients, and reload.Reloader.
+//
+// The underlying connection is swapped atomically, the new RPCs will use the
+// new connection and the replaced connection is closed after the drain period.
+type ClientConn struct {
+	cfg     ClientConnConfig
+	current atomic.Pointer[grpc.ClientConn]
+
+	mu       sync.Mutex
+	draining map[*grpc.ClientConn]*time.Timer
+	closed   bool
+}
+
+// NewClientConn returns a new ClientConn.
+func NewClientConn(ctx context.Context, cfg ClientConnConfig) (*ClientConn, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	cc, err := cfg.Dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial: %w", err)
+	}
+
+	c := &ClientConn{
+		cfg:      cfg,
+		draining: map[*grpc.ClientConn]*time.Timer{},
+	}
+	c.current.Store(cc)
+
+	return c, nil
+}
+
+// Invoke satisfies grpc.ClientConnInterface interface.
+func (c *ClientConn) Invoke(ctx context.Context, method string, args any, reply any, opts ...grpc.CallOption) error {
+	return c.current.Load().Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream satisfies grpc.ClientConnInterface interface.
+func (c *ClientConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.current.Load().NewStream(ctx, desc, method, opts...)
+}
+
+// Conn returns the current underlying connection.
+func (c *ClientConn) Conn() *grpc.ClientConn {
+	return c.current.Load()
+}
+
+// Reload satisfies reload.Reloader interface.
+func (c *ClientConn) Reload(ctx context.Context, _ string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return fmt.Errorf("client connection is closed")
+	}
+
+	cc, err := c.cfg.Dial(ctx)
+	if err != nil {
+		return fmt.Errorf("could not dial: %w", err)
+	}
+
+	old := c.current.Swap(cc)
+	c.draining[old] = time.AfterFunc(c.cfg.DrainPeriod, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if _, ok := c.draining[old]; !ok {
+			return
+		}
+		delete(c.draining, old)
+		_ = old.Close()
+	})
+
+	return nil
+}
+
+// Close closes the current connection and the ones being drained.
+func (c *ClientConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for cc, t := range c.draining {
+		t.Stop()
+		_ = cc.Close()
+		delete(c.draining, cc)
+	}
+
+	return c.current.Load().Close()
+}
@@ -0,0 +1,80 @@
+package reloadgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/slok/reload/reloadgrpc"
+)
+
+// newTestServer starts a gRPC server with a health service that returns the status.
+func newTestServer(t *testing.T, status healthpb.HealthCheckResponse_ServingStatus) *bufconn.Listener {
+	l := bufconn.Listen(1024 * 1024)
+	hs := health.NewServer()
+	hs.SetServingStatus("", status)
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	return l
+}
+
+func dialTestServer(l *bufconn.Listener) (*grpc.ClientConn, error) {
+	return grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+}
+
+func TestClientConn(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	listeners := []*bufconn.Listener{
+		newTestServer(t, healthpb.HealthCheckResponse_SERVING),
+		newTestServer(t, healthpb.HealthCheckResponse_NOT_SERVING),
+	}
+	var dialed []*grpc.ClientConn
+	cc, err := reloadgrpc.NewClientConn(context.TODO(), reloadgrpc.ClientConnConfig{
+		DrainPeriod: 20 * time.Millisecond,
+		Dial: func(ctx context.Context) (*grpc.ClientConn, error) {
+			c, err := dialTestServer(listeners[len(dialed)])
+			dialed = append(dialed, c)
+			return c, err
+		},
+	})
+	require.NoError(err)
+	defer cc.Close()
+	client := healthpb.NewHealthClient(cc)
+
+	// Before the reload the first server should be used.
+	resp, err := client.Check(context.TODO(), &healthpb.HealthCheckRequest{})
+	require.NoError(err)
+	assert.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// After the reload the second server should be used.
+	require.NoError(cc.Reload(context.TODO(), "test"))
+	resp, err = client.Check(context.TODO(), &healthpb.HealthCheckRequest{})
+	require.NoError(err)
+	assert.Equal(healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	// The old connection should be closed after the drain period.
+	assert.NotEqual(connectivity.Shutdown, dialed[0].GetState())
+	assert.Eventually(func() bool {
+		return dialed[0].GetState() == connectivity.Shutdown
+	}, time.Second, 5*time.Millisecond)
+	assert.NotEqual(connectivity.Shutdown, dialed[1].GetState())
+}
@@ -0,0 +1,2 @@
+// Package reloadgrpc has the gRPC integrations of the reload mechanism.
+package reloadgrpc
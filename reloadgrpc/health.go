@@ -0,0 +1,119 @@
+package reloadgrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/slok/reload"
+)
+
+// HealthServer knows how to set the serving status of the gRPC health
+// services, `*health.Server` satisfies it.
+type HealthServer interface {
+	SetServingStatus(service string, servingStatus healthpb.HealthCheckResponse_ServingStatus)
+}
+
+// HealthSubscriberConfig is the configuration of the HealthSubscriber.
+type HealthSubscriberConfig struct {
+	// Server is the gRPC health server that will be updated.
+	Server HealthServer
+	// Services are the health services that will be updated.
+	// By default the server overall health (`""`).
+	Services []string
+	// InProgressThreshold is the time a reload can be in progress before the
+	// services are set as not serving.
+	// By default 10s.
+	InProgressThreshold time.Duration
+}
+
+func (c *HealthSubscriberConfig) defaults() error {
+	if c.Server == nil {
+		return fmt.Errorf("server is required")
+	}
+
+	if len(c.Services) == 0 {
+		c.Services = []string{""}
+	}
+
+	if c.InProgressThreshold <= 0 {
+		c.InProgressThreshold = 10 * time.Second
+	}
+
+	return nil
+}
+
+// HealthSubscriber is a reload.Subscriber that sets the services of a gRPC
+// health server as `NOT_SERVING` while the reload is failing or is in progress
+// more than a threshold, so the load balancers shift the traffic away during
+// broken reloads. The services are set as `SERVING` again when a reload succeeds.
+//
+// The subscriber doesn't set the initial status of the services.
+type HealthSubscriber struct {
+	cfg HealthSubscriberConfig
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+var _ reload.Subscriber = &HealthSubscriber{}
+
+// NewHealthSubscriber returns a new HealthSubscriber.
+func NewHealthSubscriber(cfg HealthSubscriberConfig) (*HealthSubscriber, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &HealthSubscriber{cfg: cfg}, nil
+}
+
+// HandleEvent satisfies reload.Subscriber interface.
+func (h *HealthSubscriber) HandleEvent(_ context.Context, e reload.Event) {
+	switch e.Type {
+	case reload.EventReloadStarted:
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		h.stopTimer()
+		var t *time.Timer
+		t = time.AfterFunc(h.cfg.InProgressThreshold, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			// The reload finished while firing.
+			if h.timer != t {
+				return
+			}
+			h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+		})
+		h.timer = t
+
+	case reload.EventReloadFinished:
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		h.stopTimer()
+		if e.Err != nil {
+			h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+			return
+		}
+		h.setStatus(healthpb.HealthCheckResponse_SERVING)
+	}
+}
+
+func (h *HealthSubscriber) stopTimer() {
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+}
+
+func (h *HealthSubscriber) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, s := range h.cfg.Services {
+		h.cfg.Server.SetServingStatus(s, status)
+	}
+}
@@ -0,0 +1,96 @@
+package reloadgrpc_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadgrpc"
+)
+
+func TestHealthSubscriber(t *testing.T) {
+	tests := map[string]struct {
+		events    []reload.Event
+		wait      time.Duration
+		expStatus healthpb.HealthCheckResponse_ServingStatus
+	}{
+		"A reload in progress under the threshold should keep serving.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadStarted},
+			},
+			expStatus: healthpb.HealthCheckResponse_SERVING,
+		},
+
+		"A reload in progress over the threshold should not be serving.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadStarted},
+			},
+			wait:      100 * time.Millisecond,
+			expStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+
+		"A failed reload should not be serving.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadStarted},
+				{Type: reload.EventReloadFinished, Err: fmt.Errorf("something")},
+			},
+			expStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+
+		"A successful reload after a failed reload should be serving again.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadStarted},
+				{Type: reload.EventReloadFinished, Err: fmt.Errorf("something")},
+				{Type: reload.EventReloadStarted},
+				{Type: reload.EventReloadFinished},
+			},
+			expStatus: healthpb.HealthCheckResponse_SERVING,
+		},
+
+		"A successful reload should stop the in progress threshold.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadStarted},
+				{Type: reload.EventReloadFinished},
+			},
+			wait:      100 * time.Millisecond,
+			expStatus: healthpb.HealthCheckResponse_SERVING,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			srv := health.NewServer()
+			srv.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+			s, err := reloadgrpc.NewHealthSubscriber(reloadgrpc.HealthSubscriberConfig{
+				Server:              srv,
+				Services:            []string{"", "svc"},
+				InProgressThreshold: 50 * time.Millisecond,
+			})
+			require.NoError(err)
+
+			// Execute.
+			for _, e := range test.events {
+				s.HandleEvent(context.TODO(), e)
+			}
+			time.Sleep(test.wait)
+
+			// Check.
+			for _, svc := range []string{"", "svc"} {
+				resp, err := srv.Check(context.TODO(), &healthpb.HealthCheckRequest{Service: svc})
+				require.NoError(err)
+				assert.Equal(test.expStatus, resp.Status, svc)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package reloadgrpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/internal/backoff"
+)
+
+// Snapshot is a configuration snapshot pushed by the control plane.
+type Snapshot struct {
+	// Version is the version of the snapshot, used as the trigger ID.
+	Version string
+	// Nonce is the nonce of the snapshot response (xDS style), if any.
+	Nonce string
+}
+
+// ConfigStream is a long-lived stream of configuration snapshots, normally an
+// adapter of a generated gRPC client stream.
+type ConfigStream interface {
+	// Recv blocks until the control plane pushes a new snapshot.
+	Recv() (Snapshot, error)
+}
+
+// ResumeInfo is the information of the latest received snapshot, used to
+// resume the stream on reconnections.
+type ResumeInfo struct {
+	Version string
+	Nonce   string
+}
+
+// StreamOpener knows how to open a config stream with the control plane, on
+// reconnections it will receive the latest received snapshot information so
+// the stream can be resumed (e.g: sending the version and nonce on the
+// initial request). On the first connection the resume information is empty.
+type StreamOpener func(ctx context.Context, resume ResumeInfo) (ConfigStream, error)
+
+// StreamNotifierConfig is the configuration of the StreamNotifier.
+type StreamNotifierConfig struct {
+	// Open opens the config stream.
+	Open StreamOpener
+	// MinBackoff is the initial wait time before reconnecting.
+	// By default 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum wait time before reconnecting.
+	// By default 30s.
+	MaxBackoff time.Duration
+	// IsPermanent returns true if the error should not be retried, the
+	// notifier will end with an error.
+	// By default InvalidArgument, Unauthenticated, PermissionDenied and
+	// Unimplemented gRPC status codes are permanent.
+	IsPermanent func(err error) bool
+}
+
+func (c *StreamNotifierConfig) defaults() error {
+	if c.Open == nil {
+		return fmt.Errorf("stream opener is required")
+	}
+
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 250 * time.Millisecond
+	}
+
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 30 * time.Second
+	}
+
+	if c.MaxBackoff < c.MinBackoff {
+		return fmt.Errorf("max backoff can't be lower than min backoff")
+	}
+
+	if c.IsPermanent == nil {
+		c.IsPermanent = isPermanentGRPCError
+	}
+
+	return nil
+}
+
+func isPermanentGRPCError(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied, codes.Unimplemented:
+		return true
+	}
+
+	return false
+}
+
+// StreamNotifier is a reload.Notifier that holds a long-lived stream with a
+// control plane (xDS style) and triggers a reload every time the control
+// plane pushes a new configuration snapshot.
+//
+// The trigger ID is the snapshot version, and the version and nonce are set
+// on the trigger metadata (`version` and `nonce` keys).
+//
+// When the stream breaks, the notifier will reconnect with exponential backoff
+// resuming from the latest received snapshot.
+//
+// The stream is bound to the context of the Notify call that opened it.
+type StreamNotifier struct {
+	cfg       StreamNotifierConfig
+	stream    ConfigStream
+	streamCtx context.Context
+	resume    ResumeInfo
+	backoff   *backoff.Backoff
+}
+
+// NewStreamNotifier returns a new StreamNotifier.
+func NewStreamNotifier(cfg StreamNotifierConfig) (*StreamNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &StreamNotifier{
+		cfg:     cfg,
+		backoff: backoff.New(cfg.MinBackoff, cfg.MaxBackoff),
+	}, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (s *StreamNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := s.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (s *StreamNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	for {
+		if ctx.Err() != nil {
+			return reload.TriggerEvent{}, ctx.Err()
+		}
+
+		// Connect if required.
+		if s.stream == nil {
+			stream, err := s.cfg.Open(ctx, s.resume)
+			if err != nil {
+				if s.cfg.IsPermanent(err) {
+					return reload.TriggerEvent{}, fmt.Errorf("could not open config stream: %w", err)
+				}
+				s.backoff.Wait(ctx)
+				continue
+			}
+			s.stream = stream
+			s.streamCtx = ctx
+		}
+
+		snapshot, err := s.stream.Recv()
+		if err != nil {
+			streamCtx := s.streamCtx
+			s.stream = nil
+			s.streamCtx = nil
+
+			if s.cfg.IsPermanent(err) {
+				return reload.TriggerEvent{}, fmt.Errorf("config stream failed: %w", err)
+			}
+
+			// If the stream broke because of its context, reconnect right away.
+			if streamCtx.Err() == nil {
+				s.backoff.Wait(ctx)
+			}
+			continue
+		}
+
+		s.backoff.Reset()
+		s.resume = ResumeInfo{Version: snapshot.Version, Nonce: snapshot.Nonce}
+
+		return reload.TriggerEvent{
+			ID: snapshot.Version,
+			Metadata: map[string]string{
+				"version": snapshot.Version,
+				"nonce":   snapshot.Nonce,
+			},
+		}, nil
+	}
+}
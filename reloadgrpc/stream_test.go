@@ -0,0 +1,157 @@
+package reloadgrpc_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadgrpc"
+)
+
+type testStreamResult struct {
+	snapshot reloadgrpc.Snapshot
+	err      error
+}
+
+type testStream struct {
+	results []testStreamResult
+}
+
+func (t *testStream) Recv() (reloadgrpc.Snapshot, error) {
+	if len(t.results) == 0 {
+		return reloadgrpc.Snapshot{}, status.Error(codes.Unavailable, "stream ended")
+	}
+	r := t.results[0]
+	t.results = t.results[1:]
+	return r.snapshot, r.err
+}
+
+func TestStreamNotifier(t *testing.T) {
+	tests := map[string]struct {
+		streams     func() []*testStream
+		openErrs    []error
+		expTriggers []reload.TriggerEvent
+		expResumes  []reloadgrpc.ResumeInfo
+		expErr      bool
+	}{
+		"Each pushed snapshot should trigger with the version and nonce.": {
+			streams: func() []*testStream {
+				return []*testStream{{results: []testStreamResult{
+					{snapshot: reloadgrpc.Snapshot{Version: "v1", Nonce: "n1"}},
+					{snapshot: reloadgrpc.Snapshot{Version: "v2", Nonce: "n2"}},
+				}}}
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "v1", Metadata: map[string]string{"version": "v1", "nonce": "n1"}},
+				{ID: "v2", Metadata: map[string]string{"version": "v2", "nonce": "n2"}},
+			},
+			expResumes: []reloadgrpc.ResumeInfo{{}},
+		},
+
+		"A broken stream should reconnect resuming from the latest snapshot.": {
+			streams: func() []*testStream {
+				return []*testStream{
+					{results: []testStreamResult{
+						{snapshot: reloadgrpc.Snapshot{Version: "v1", Nonce: "n1"}},
+						{err: status.Error(codes.Unavailable, "something")},
+					}},
+					{results: []testStreamResult{
+						{snapshot: reloadgrpc.Snapshot{Version: "v2", Nonce: "n2"}},
+					}},
+				}
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "v1", Metadata: map[string]string{"version": "v1", "nonce": "n1"}},
+				{ID: "v2", Metadata: map[string]string{"version": "v2", "nonce": "n2"}},
+			},
+			expResumes: []reloadgrpc.ResumeInfo{{}, {Version: "v1", Nonce: "n1"}},
+		},
+
+		"A failed connection should be retried.": {
+			streams: func() []*testStream {
+				return []*testStream{{results: []testStreamResult{
+					{snapshot: reloadgrpc.Snapshot{Version: "v1", Nonce: "n1"}},
+				}}}
+			},
+			openErrs: []error{status.Error(codes.Unavailable, "something"), fmt.Errorf("something")},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "v1", Metadata: map[string]string{"version": "v1", "nonce": "n1"}},
+			},
+			expResumes: []reloadgrpc.ResumeInfo{{}, {}, {}},
+		},
+
+		"A permanent connection error should end with an error.": {
+			streams:    func() []*testStream { return nil },
+			openErrs:   []error{status.Error(codes.PermissionDenied, "something")},
+			expResumes: []reloadgrpc.ResumeInfo{{}},
+			expErr:     true,
+		},
+
+		"A permanent stream error should end with an error.": {
+			streams: func() []*testStream {
+				return []*testStream{{results: []testStreamResult{
+					{err: status.Error(codes.Unimplemented, "something")},
+				}}}
+			},
+			expResumes: []reloadgrpc.ResumeInfo{{}},
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			streams := test.streams()
+			openErrs := test.openErrs
+			var gotResumes []reloadgrpc.ResumeInfo
+			n, err := reloadgrpc.NewStreamNotifier(reloadgrpc.StreamNotifierConfig{
+				MinBackoff: time.Millisecond,
+				MaxBackoff: 5 * time.Millisecond,
+				Open: func(ctx context.Context, resume reloadgrpc.ResumeInfo) (reloadgrpc.ConfigStream, error) {
+					gotResumes = append(gotResumes, resume)
+					if len(openErrs) > 0 {
+						err := openErrs[0]
+						openErrs = openErrs[1:]
+						return nil, err
+					}
+					if len(streams) == 0 {
+						<-ctx.Done()
+						return nil, ctx.Err()
+					}
+					s := streams[0]
+					streams = streams[1:]
+					return s, nil
+				},
+			})
+			require.NoError(err)
+
+			// Execute.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			var gotTriggers []reload.TriggerEvent
+			for range test.expTriggers {
+				tr, err := n.NotifyTrigger(ctx)
+				require.NoError(err)
+				gotTriggers = append(gotTriggers, tr)
+			}
+			if test.expErr {
+				_, err := n.NotifyTrigger(ctx)
+				assert.Error(err)
+			}
+
+			// Check.
+			assert.Equal(test.expTriggers, gotTriggers)
+			assert.Equal(test.expResumes, gotResumes)
+		})
+	}
+}
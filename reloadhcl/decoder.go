@@ -0,0 +1,15 @@
+package reloadhcl
+
+import (
+	"github.com/hashicorp/hcl/v2/hclsimple"
+
+	"github.com/slok/reload/reloadconfig"
+)
+
+var _ reloadconfig.Decoder = Decoder
+
+// Decoder is a reloadconfig.Decoder for HCL, the configuration type needs the
+// `hcl` struct tags (see `gohcl`). The unknown fields are rejected.
+func Decoder(data []byte, v any) error {
+	return hclsimple.Decode("config.hcl", data, nil, v)
+}
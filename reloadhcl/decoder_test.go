@@ -0,0 +1,69 @@
+package reloadhcl_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload/reloadhcl"
+)
+
+type testServer struct {
+	Name string `hcl:"name,label"`
+	Port int    `hcl:"port"`
+}
+
+type testConfig struct {
+	Name    string       `hcl:"name"`
+	Workers int          `hcl:"workers,optional"`
+	Servers []testServer `hcl:"server,block"`
+}
+
+func TestDecoder(t *testing.T) {
+	tests := map[string]struct {
+		data      string
+		expConfig testConfig
+		expErr    bool
+	}{
+		"A valid configuration should be decoded on top of the defaults.": {
+			data: `
+name = "a"
+
+server "http" {
+  port = 8080
+}
+`,
+			expConfig: testConfig{Name: "a", Workers: 4, Servers: []testServer{{Name: "http", Port: 8080}}},
+		},
+
+		"Unknown fields should fail.": {
+			data:   "name = \"a\"\nother = 1\n",
+			expErr: true,
+		},
+
+		"Missing required fields should fail.": {
+			data:   "workers = 1\n",
+			expErr: true,
+		},
+
+		"Invalid syntax should fail.": {
+			data:   "name = \n",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			c := testConfig{Workers: 4}
+			err := reloadhcl.Decoder([]byte(test.data), &c)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expConfig, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package reloadhcl has the HCL integrations of the reload mechanism.
+package reloadhcl
@@ -0,0 +1,732 @@
+package reloadhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// AdminReasonMetadataKey is the trigger metadata key where the reason of the
+// admin triggers is set.
+const AdminReasonMetadataKey = "reason"
+
+// AdminRequesterMetadataKey is the trigger metadata key where the requester
+// of the admin triggers is set, the identity returned by the authorizers (see
+// AdminAuthorizer).
+const AdminRequesterMetadataKey = "requester"
+
+const adminMaxPayloadSize = 1 << 20
+
+// AdminHandlerConfig is the configuration of the AdminHandler.
+type AdminHandlerConfig struct {
+	// Token is the token required to use the admin endpoints as a bearer
+	// token (see BearerTokenAuthorizer).
+	Token string
+	// Authorizer authorizes the requests in addition to the token (e.g:
+	// ClientCertAuthorizer or a custom one with AdminAuthorizerFunc). If
+	// neither the token nor the authorizer are set, the endpoints will not be
+	// protected.
+	Authorizer AdminAuthorizer
+	// HistorySize is the number of finished reloads that will be kept on the
+	// history.
+	// By default 50.
+	HistorySize int
+	// EventsBufferSize is the number of lifecycle events buffered for every
+	// event stream request, the requests that don't keep up are closed so
+	// they don't block the reloads.
+	// By default 100.
+	EventsBufferSize int
+	// Trigger is used to trigger the reloads synchronously (e.g:
+	// `Manager.TriggerReload`), the trigger endpoint waits for the reload and
+	// responds with `409` if other reload is in progress. If not set, the
+	// handler needs to be registered on the manager as a notifier and the
+	// reloads are queued.
+	Trigger func(ctx context.Context, t reload.TriggerEvent) error
+	// Rollback is used to roll back to a previous generation (e.g:
+	// `Manager.RollbackTo`), if not set the rollback endpoint is disabled.
+	Rollback func(ctx context.Context, generation uint64) error
+	// ResetCircuitBreaker is used to close the circuit breaker of a reloader
+	// (e.g: `Manager.ResetCircuitBreaker`), if not set the reset endpoint is
+	// disabled.
+	ResetCircuitBreaker func(ctx context.Context, reloader string) error
+	// EnableReloader and DisableReloader are used to enable and disable a
+	// reloader at runtime (e.g: `Manager.EnableReloader` and
+	// `Manager.DisableReloader`), if not set the reloader endpoints are
+	// disabled.
+	EnableReloader  func(ctx context.Context, reloader string) error
+	DisableReloader func(ctx context.Context, reloader string) error
+	// Approve and Reject are used to decide on the trigger pending approval
+	// with the approval request token (e.g: `Manager.Approve` and
+	// `Manager.Reject`), if not set the approval endpoints are disabled.
+	Approve func(ctx context.Context, token string) error
+	Reject  func(ctx context.Context, token string) error
+	// Validate is used to validate the configuration of a trigger without
+	// reloading (e.g: `Manager.Validate`), if not set the validate endpoint is
+	// disabled.
+	Validate func(ctx context.Context, t reload.TriggerEvent) ([]reload.ReloaderReport, error)
+	// Metrics is the handler of the metrics endpoint (e.g: `promhttp.Handler()`),
+	// if not set the metrics endpoint is disabled.
+	Metrics http.Handler
+}
+
+func (c *AdminHandlerConfig) defaults() error {
+	if c.HistorySize <= 0 {
+		c.HistorySize = 50
+	}
+
+	if c.EventsBufferSize <= 0 {
+		c.EventsBufferSize = 100
+	}
+
+	return nil
+}
+
+// AdminHandler is an `http.Handler` with the admin endpoints to trigger and
+// inspect the reloads (e.g: using `reloadctl`). It's a reload.Notifier that
+// needs to be registered on the manager and a reload.Subscriber that needs
+// to be registered using `reload.WithSubscriber`.
+//
+// The endpoints are relative to where the handler is mounted:
+//
+//   - `POST /trigger`: Triggers a reload, the body is a JSON object with the
+//     optional `id`, `reason`, `keys`, `metadata` and `tags` (the tag
+//     expression that selects the reloaders) fields. The requester is the
+//     identity returned by the authorizers. See AdminHandlerConfig.Trigger to
+//     wait for the reload.
+//   - `POST /rollback`: Rolls back to a previous generation and waits for
+//     the reload, the body is a JSON object with the `generation` field. See
+//     AdminHandlerConfig.Rollback.
+//   - `POST /circuit-breaker/reset`: Closes the circuit breaker of a reloader,
+//     the body is a JSON object with the `reloader` field. See
+//     AdminHandlerConfig.ResetCircuitBreaker.
+//   - `POST /reloaders/enable` and `POST /reloaders/disable`: Enables or
+//     disables a reloader at runtime, the body is a JSON object with the
+//     `reloader` field. See AdminHandlerConfig.EnableReloader and
+//     AdminHandlerConfig.DisableReloader.
+//   - `POST /approve` and `POST /reject`: Approves or rejects the reload of
+//     the trigger pending approval, the body is a JSON object with the
+//     `token` field of the approval request (see the pending approval on the
+//     status). See AdminHandlerConfig.Approve and AdminHandlerConfig.Reject.
+//   - `POST /validate`: Validates the configuration of a trigger without
+//     reloading (dry-run), the body is the same as the trigger endpoint. It
+//     responds with `422` if the validation fails. See
+//     AdminHandlerConfig.Validate.
+//   - `GET /status`: The reloads in progress (if any), the trigger pending
+//     approval (if any) and the last finished reload.
+//   - `GET /history`: The last finished reloads, newest first, the `limit`
+//     query parameter limits the number of reloads.
+//   - `GET /events`: Streams the lifecycle events as server-sent events (e.g:
+//     `curl -N`), the event data is the JSON of reload.MarshalEventJSON. The
+//     `types` query parameter filters the events by comma separated types.
+//   - `GET /metrics`: The metrics. See AdminHandlerConfig.Metrics.
+//
+// The responses are JSON, including the errors (e.g: `{"error": "invalid
+// token"}`), except the events stream and the metrics that are served by the
+// metrics handler.
+//
+// The endpoints can be protected with a bearer token, TLS client certificates
+// or a custom authorizer (see AdminHandlerConfig.Authorizer), and it can be
+// served over a unix socket to only allow local access.
+//
+// If multiple triggers are received while the manager is busy, only the latest
+// one will be triggered.
+type AdminHandler struct {
+	cfg         AdminHandlerConfig
+	c           chan reload.TriggerEvent
+	mux         *http.ServeMux
+	authorizers []AdminAuthorizer
+	events      *reload.EventFanout
+
+	mu sync.Mutex
+	// inFlight are the reloads in progress by pipelines, the reloads of
+	// different pipelines run concurrently.
+	inFlight map[string]AdminReload
+	pending  *AdminApproval
+	history  []AdminReload
+}
+
+var (
+	_ reload.TriggerNotifier = &AdminHandler{}
+	_ reload.Subscriber      = &AdminHandler{}
+)
+
+// NewAdminHandler returns a new AdminHandler.
+func NewAdminHandler(cfg AdminHandlerConfig) (*AdminHandler, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	a := &AdminHandler{
+		cfg:      cfg,
+		c:        make(chan reload.TriggerEvent, 1),
+		mux:      http.NewServeMux(),
+		events:   reload.NewEventFanout(cfg.EventsBufferSize),
+		inFlight: map[string]AdminReload{},
+	}
+	if cfg.Token != "" {
+		a.authorizers = append(a.authorizers, BearerTokenAuthorizer(cfg.Token))
+	}
+	if cfg.Authorizer != nil {
+		a.authorizers = append(a.authorizers, cfg.Authorizer)
+	}
+	a.mux.HandleFunc("POST /trigger", a.handleTrigger)
+	a.mux.HandleFunc("POST /rollback", a.handleRollback)
+	a.mux.HandleFunc("POST /circuit-breaker/reset", a.handleResetCircuitBreaker)
+	a.mux.HandleFunc("POST /reloaders/enable", a.handleToggleReloader(cfg.EnableReloader))
+	a.mux.HandleFunc("POST /reloaders/disable", a.handleToggleReloader(cfg.DisableReloader))
+	a.mux.HandleFunc("POST /approve", a.handleApproval(cfg.Approve))
+	a.mux.HandleFunc("POST /reject", a.handleApproval(cfg.Reject))
+	a.mux.HandleFunc("POST /validate", a.handleValidate)
+	a.mux.HandleFunc("GET /status", a.handleStatus)
+	a.mux.HandleFunc("GET /history", a.handleHistory)
+	a.mux.HandleFunc("GET /events", a.handleEvents)
+	a.mux.HandleFunc("GET /metrics", a.handleMetrics)
+
+	return a, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (a *AdminHandler) Notify(ctx context.Context) (string, error) {
+	t, err := a.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (a *AdminHandler) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	select {
+	case <-ctx.Done():
+		return reload.TriggerEvent{}, ctx.Err()
+	case t := <-a.c:
+		return t, nil
+	}
+}
+
+// AdminReload is a reload reported by the admin endpoints.
+type AdminReload struct {
+	TriggerID       string            `json:"trigger_id"`
+	TriggerSource   string            `json:"trigger_source,omitempty"`
+	Reason          string            `json:"reason,omitempty"`
+	Requester       string            `json:"requester,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	StartedAt       time.Time         `json:"started_at"`
+	FinishedAt      *time.Time        `json:"finished_at,omitempty"`
+	DurationSeconds float64           `json:"duration_seconds,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// AdminApproval is a trigger pending approval reported by the admin endpoints.
+type AdminApproval struct {
+	Token         string            `json:"token"`
+	TriggerID     string            `json:"trigger_id"`
+	TriggerSource string            `json:"trigger_source,omitempty"`
+	Reason        string            `json:"reason,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Since         time.Time         `json:"since"`
+}
+
+// AdminStatus is the reload status reported by the admin endpoints.
+type AdminStatus struct {
+	InProgress bool `json:"in_progress"`
+	// Current is the oldest reload in progress.
+	Current *AdminReload `json:"current,omitempty"`
+	// Reloads are the reloads in progress, oldest first, the reloads of
+	// different pipelines run concurrently (see reload.WithPipeline).
+	Reloads         []AdminReload  `json:"reloads,omitempty"`
+	PendingApproval *AdminApproval `json:"pending_approval,omitempty"`
+	Last            *AdminReload   `json:"last,omitempty"`
+}
+
+// AdminTriggerRequest is the request of the admin trigger endpoint.
+type AdminTriggerRequest struct {
+	ID       string            `json:"id,omitempty"`
+	Reason   string            `json:"reason,omitempty"`
+	Keys     []string          `json:"keys,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Tags is the tag expression that selects the reloaders (see
+	// reload.TriggerEvent.TagSelector).
+	Tags string `json:"tags,omitempty"`
+}
+
+// AdminValidateResponse is the response of the admin validate endpoint.
+type AdminValidateResponse struct {
+	ID        string                `json:"id"`
+	Valid     bool                  `json:"valid"`
+	Error     string                `json:"error,omitempty"`
+	Reloaders []AdminReloaderReport `json:"reloaders"`
+}
+
+// AdminReloaderReport is the result of a reloader reported by the admin
+// endpoints.
+type AdminReloaderReport struct {
+	Name            string                `json:"name"`
+	Priority        int                   `json:"priority"`
+	Status          reload.ReloaderStatus `json:"status"`
+	DurationSeconds float64               `json:"duration_seconds,omitempty"`
+	Error           string                `json:"error,omitempty"`
+}
+
+// AdminRollbackRequest is the request of the admin rollback endpoint.
+type AdminRollbackRequest struct {
+	Generation uint64 `json:"generation"`
+}
+
+// AdminResetCircuitBreakerRequest is the request of the admin circuit breaker
+// reset endpoint.
+type AdminResetCircuitBreakerRequest struct {
+	Reloader string `json:"reloader"`
+}
+
+// AdminResetCircuitBreakerResponse is the response of the admin circuit
+// breaker reset endpoint.
+type AdminResetCircuitBreakerResponse struct {
+	Reloader string `json:"reloader"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AdminReloaderToggleRequest is the request of the admin reloader enable and
+// disable endpoints.
+type AdminReloaderToggleRequest struct {
+	Reloader string `json:"reloader"`
+}
+
+// AdminReloaderToggleResponse is the response of the admin reloader enable and
+// disable endpoints.
+type AdminReloaderToggleResponse struct {
+	Reloader string `json:"reloader"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AdminApprovalRequest is the request of the admin approve and reject
+// endpoints.
+type AdminApprovalRequest struct {
+	Token string `json:"token"`
+}
+
+// AdminApprovalResponse is the response of the admin approve and reject
+// endpoints.
+type AdminApprovalResponse struct {
+	Token string `json:"token"`
+	Error string `json:"error,omitempty"`
+}
+
+// AdminTriggerResponse is the response of the admin trigger and rollback
+// endpoints.
+type AdminTriggerResponse struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+	// InProgressTriggerID and InProgressStartedAt are the in-flight reload
+	// information when the reload could not start because other reload is in
+	// progress.
+	InProgressTriggerID string     `json:"in_progress_trigger_id,omitempty"`
+	InProgressStartedAt *time.Time `json:"in_progress_started_at,omitempty"`
+}
+
+// HandleEvent satisfies reload.Subscriber interface.
+func (a *AdminHandler) HandleEvent(ctx context.Context, e reload.Event) {
+	a.events.HandleEvent(ctx, e)
+
+	switch e.Type {
+	case reload.EventReloadStarted, reload.EventReloadFinished:
+	case reload.EventApprovalPending, reload.EventApprovalApproved, reload.EventApprovalRejected:
+		a.handleApprovalEvent(e)
+		return
+	default:
+		return
+	}
+
+	r := AdminReload{
+		TriggerID:     e.Trigger.ID,
+		TriggerSource: e.Trigger.Source,
+		Reason:        e.Trigger.Reason,
+		Requester:     e.Trigger.Metadata[AdminRequesterMetadataKey],
+		Metadata:      e.Trigger.Metadata,
+		StartedAt:     e.Time.Add(-e.Duration).UTC(),
+	}
+	if r.Reason == "" {
+		r.Reason = e.Trigger.Metadata[AdminReasonMetadataKey]
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := strings.Join(e.Pipelines, ",")
+	if e.Type == reload.EventReloadStarted {
+		a.inFlight[key] = r
+		return
+	}
+
+	finishedAt := e.Time.UTC()
+	r.FinishedAt = &finishedAt
+	r.DurationSeconds = e.Duration.Seconds()
+	if e.Err != nil {
+		r.Error = e.Err.Error()
+	}
+	delete(a.inFlight, key)
+
+	a.history = append(a.history, r)
+	if len(a.history) > a.cfg.HistorySize {
+		a.history = a.history[1:]
+	}
+}
+
+func (a *AdminHandler) handleApprovalEvent(e reload.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if e.Type != reload.EventApprovalPending {
+		a.pending = nil
+		return
+	}
+
+	a.pending = &AdminApproval{
+		Token:         e.ApprovalToken,
+		TriggerID:     e.Trigger.ID,
+		TriggerSource: e.Trigger.Source,
+		Reason:        e.Trigger.Reason,
+		Metadata:      e.Trigger.Metadata,
+		Since:         e.Time.UTC(),
+	}
+}
+
+// ServeHTTP satisfies http.Handler interface.
+func (a *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var identity string
+	for _, authz := range a.authorizers {
+		id, err := authz.Authorize(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if identity == "" {
+			identity = id
+		}
+	}
+	if identity != "" {
+		r = r.WithContext(context.WithValue(r.Context(), identityContextKey{}, identity))
+	}
+
+	a.mux.ServeHTTP(w, r)
+}
+
+// decodeTrigger decodes the trigger of the trigger request body, the request
+// fields are optional. The requester is the authorized identity, never the
+// body.
+func decodeTrigger(r *http.Request) (reload.TriggerEvent, error) {
+	var req AdminTriggerRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, adminMaxPayloadSize)).Decode(&req)
+	if err != nil && err != io.EOF {
+		return reload.TriggerEvent{}, err
+	}
+
+	_, err = reload.ParseTagExpression(req.Tags)
+	if err != nil {
+		return reload.TriggerEvent{}, err
+	}
+
+	t := reload.TriggerEvent{ID: req.ID, Reason: req.Reason, Keys: req.Keys, TagSelector: req.Tags}
+	if t.ID == "" {
+		t.ID = randomID()
+	}
+	requester := requestIdentity(r)
+	if len(req.Metadata) > 0 || req.Reason != "" || requester != "" {
+		t.Metadata = make(map[string]string, len(req.Metadata)+2)
+		for k, v := range req.Metadata {
+			// The clients can't set the reserved metadata (e.g: barrier URLs),
+			// except the signature ones checked by the notifier verifiers,
+			// nor claim a requester.
+			if k == AdminRequesterMetadataKey || (strings.HasPrefix(k, reload.ReservedMetadataPrefix) && !isSignatureMetadataKey(k)) {
+				continue
+			}
+			t.Metadata[k] = v
+		}
+		if req.Reason != "" {
+			t.Metadata[AdminReasonMetadataKey] = req.Reason
+		}
+		if requester != "" {
+			t.Metadata[AdminRequesterMetadataKey] = requester
+		}
+	}
+
+	return t, nil
+}
+
+func (a *AdminHandler) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	t, err := decodeTrigger(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid trigger")
+		return
+	}
+
+	if a.cfg.Trigger == nil {
+		a.trigger(t)
+		writeJSON(w, http.StatusAccepted, AdminTriggerResponse{ID: t.ID})
+		return
+	}
+
+	err = a.cfg.Trigger(r.Context(), t)
+	if err != nil {
+		writeTriggerError(w, t.ID, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AdminTriggerResponse{ID: t.ID})
+}
+
+func (a *AdminHandler) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if a.cfg.Validate == nil {
+		writeError(w, http.StatusNotImplemented, "validation not enabled")
+		return
+	}
+
+	t, err := decodeTrigger(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid trigger")
+		return
+	}
+
+	reports, err := a.cfg.Validate(r.Context(), t)
+	resp := AdminValidateResponse{ID: t.ID, Valid: err == nil, Reloaders: make([]AdminReloaderReport, 0, len(reports))}
+	for _, rr := range reports {
+		ar := AdminReloaderReport{Name: rr.Name, Priority: rr.Priority, Status: rr.Status, DurationSeconds: rr.Duration.Seconds()}
+		if rr.Err != nil {
+			ar.Error = rr.Err.Error()
+		}
+		resp.Reloaders = append(resp.Reloaders, ar)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *AdminHandler) handleRollback(w http.ResponseWriter, r *http.Request) {
+	if a.cfg.Rollback == nil {
+		writeError(w, http.StatusNotImplemented, "rollback not enabled")
+		return
+	}
+
+	var req AdminRollbackRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, adminMaxPayloadSize)).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid rollback")
+		return
+	}
+
+	id := "rollback-" + strconv.FormatUint(req.Generation, 10)
+	err = a.cfg.Rollback(r.Context(), req.Generation)
+	if err != nil {
+		if errors.Is(err, reload.ErrUnknownGeneration) {
+			writeJSON(w, http.StatusNotFound, AdminTriggerResponse{ID: id, Error: err.Error()})
+			return
+		}
+		writeTriggerError(w, id, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AdminTriggerResponse{ID: id})
+}
+
+func (a *AdminHandler) handleResetCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	if a.cfg.ResetCircuitBreaker == nil {
+		writeError(w, http.StatusNotImplemented, "circuit breaker reset not enabled")
+		return
+	}
+
+	var req AdminResetCircuitBreakerRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, adminMaxPayloadSize)).Decode(&req)
+	if err != nil || req.Reloader == "" {
+		writeError(w, http.StatusBadRequest, "invalid circuit breaker reset")
+		return
+	}
+
+	err = a.cfg.ResetCircuitBreaker(r.Context(), req.Reloader)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, reload.ErrUnknownCircuitBreaker) {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, AdminResetCircuitBreakerResponse{Reloader: req.Reloader, Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AdminResetCircuitBreakerResponse{Reloader: req.Reloader})
+}
+
+func (a *AdminHandler) handleToggleReloader(toggle func(ctx context.Context, reloader string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if toggle == nil {
+			writeError(w, http.StatusNotImplemented, "reloader toggles not enabled")
+			return
+		}
+
+		var req AdminReloaderToggleRequest
+		err := json.NewDecoder(io.LimitReader(r.Body, adminMaxPayloadSize)).Decode(&req)
+		if err != nil || req.Reloader == "" {
+			writeError(w, http.StatusBadRequest, "invalid reloader toggle")
+			return
+		}
+
+		err = toggle(r.Context(), req.Reloader)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, reload.ErrUnknownReloader) {
+				status = http.StatusNotFound
+			}
+			writeJSON(w, status, AdminReloaderToggleResponse{Reloader: req.Reloader, Error: err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, AdminReloaderToggleResponse{Reloader: req.Reloader})
+	}
+}
+
+func (a *AdminHandler) handleApproval(decide func(ctx context.Context, token string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if decide == nil {
+			writeError(w, http.StatusNotImplemented, "approval not enabled")
+			return
+		}
+
+		var req AdminApprovalRequest
+		err := json.NewDecoder(io.LimitReader(r.Body, adminMaxPayloadSize)).Decode(&req)
+		if err != nil || req.Token == "" {
+			writeError(w, http.StatusBadRequest, "invalid approval")
+			return
+		}
+
+		err = decide(r.Context(), req.Token)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, reload.ErrUnknownApproval) {
+				status = http.StatusNotFound
+			}
+			writeJSON(w, status, AdminApprovalResponse{Token: req.Token, Error: err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, AdminApprovalResponse{Token: req.Token})
+	}
+}
+
+// writeTriggerError writes the error of a synchronous reload.
+func writeTriggerError(w http.ResponseWriter, id string, err error) {
+	resp := AdminTriggerResponse{ID: id, Error: err.Error()}
+	var inProgressErr *reload.ReloadInProgressError
+	if errors.As(err, &inProgressErr) {
+		startedAt := inProgressErr.StartedAt.UTC()
+		resp.InProgressTriggerID = inProgressErr.TriggerID
+		resp.InProgressStartedAt = &startedAt
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, resp)
+}
+
+func (a *AdminHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	status := AdminStatus{InProgress: len(a.inFlight) > 0}
+	for _, r := range a.inFlight {
+		status.Reloads = append(status.Reloads, r)
+	}
+	sort.Slice(status.Reloads, func(i, j int) bool { return status.Reloads[i].StartedAt.Before(status.Reloads[j].StartedAt) })
+	if len(status.Reloads) > 0 {
+		c := status.Reloads[0]
+		status.Current = &c
+	}
+	if a.pending != nil {
+		p := *a.pending
+		status.PendingApproval = &p
+	}
+	if len(a.history) > 0 {
+		l := a.history[len(a.history)-1]
+		status.Last = &l
+	}
+	a.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (a *AdminHandler) handleHistory(w http.ResponseWriter, r *http.Request) {
+	limit := a.cfg.HistorySize
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	a.mu.Lock()
+	history := make([]AdminReload, 0, min(limit, len(a.history)))
+	for i := len(a.history) - 1; i >= 0 && len(history) < limit; i-- {
+		history = append(history, a.history[i])
+	}
+	a.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (a *AdminHandler) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if a.cfg.Metrics == nil {
+		writeError(w, http.StatusNotImplemented, "metrics not enabled")
+		return
+	}
+
+	a.cfg.Metrics.ServeHTTP(w, r)
+}
+
+// trigger sends the trigger replacing the pending one, if any.
+func (a *AdminHandler) trigger(t reload.TriggerEvent) {
+	for {
+		select {
+		case a.c <- t:
+			return
+		default:
+		}
+
+		// Drop the pending trigger.
+		select {
+		case <-a.c:
+		default:
+		}
+	}
+}
+
+// AdminErrorResponse is the response of the admin endpoints on invalid
+// requests.
+type AdminErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, AdminErrorResponse{Error: msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func isSignatureMetadataKey(k string) bool {
+	switch k {
+	case reload.TriggerSignatureMetadataKey, reload.TriggerSignedAtMetadataKey, reload.TriggerNonceMetadataKey:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,725 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func TestAdminHandlerInspect(t *testing.T) {
+	at := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	t1 := reload.TriggerEvent{ID: "t1", Source: "admin", Metadata: map[string]string{"reason": "deploy-123", "requester": "alice"}}
+	t2 := reload.TriggerEvent{ID: "t2", Source: "file"}
+	t3 := reload.TriggerEvent{ID: "t3", Source: "signal"}
+	t4 := reload.TriggerEvent{ID: "t4", Source: "file", Reason: "config changed"}
+
+	tests := map[string]struct {
+		events    []reload.Event
+		path      string
+		expStatus int
+		expBody   string
+	}{
+		"Status without reloads.": {
+			path:      "/status",
+			expStatus: http.StatusOK,
+			expBody:   `{"in_progress":false}`,
+		},
+
+		"Status with a reload in progress.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadStarted, Trigger: t1, Time: at},
+				{Type: reload.EventReloadFinished, Trigger: t1, Time: at.Add(time.Second), Duration: time.Second, Err: fmt.Errorf("something")},
+				{Type: reload.EventReloadStarted, Trigger: t2, Time: at.Add(time.Minute)},
+			},
+			path:      "/status",
+			expStatus: http.StatusOK,
+			expBody: `{"in_progress":true,` +
+				`"current":{"trigger_id":"t2","trigger_source":"file","started_at":"2021-07-19T10:01:00Z"},` +
+				`"reloads":[{"trigger_id":"t2","trigger_source":"file","started_at":"2021-07-19T10:01:00Z"}],` +
+				`"last":{"trigger_id":"t1","trigger_source":"admin","reason":"deploy-123","requester":"alice","metadata":{"reason":"deploy-123","requester":"alice"},"started_at":"2021-07-19T10:00:00Z","finished_at":"2021-07-19T10:00:01Z","duration_seconds":1,"error":"something"}}`,
+		},
+
+		"Status with concurrent reloads of different pipelines.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadStarted, Trigger: t2, Time: at, Pipelines: []string{"config"}},
+				{Type: reload.EventReloadStarted, Trigger: t3, Time: at.Add(time.Second), Pipelines: []string{"certs"}},
+				{Type: reload.EventReloadStarted, Trigger: t4, Time: at.Add(2 * time.Second), Pipelines: []string{"flags"}},
+				{Type: reload.EventReloadFinished, Trigger: t2, Time: at.Add(3 * time.Second), Duration: 3 * time.Second, Pipelines: []string{"config"}},
+			},
+			path:      "/status",
+			expStatus: http.StatusOK,
+			expBody: `{"in_progress":true,` +
+				`"current":{"trigger_id":"t3","trigger_source":"signal","started_at":"2021-07-19T10:00:01Z"},` +
+				`"reloads":[{"trigger_id":"t3","trigger_source":"signal","started_at":"2021-07-19T10:00:01Z"},{"trigger_id":"t4","trigger_source":"file","reason":"config changed","started_at":"2021-07-19T10:00:02Z"}],` +
+				`"last":{"trigger_id":"t2","trigger_source":"file","started_at":"2021-07-19T10:00:00Z","finished_at":"2021-07-19T10:00:03Z","duration_seconds":3}}`,
+		},
+
+		"History should return the newest reloads first.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadFinished, Trigger: t1, Time: at, Duration: time.Second},
+				{Type: reload.EventReloadFinished, Trigger: t2, Time: at.Add(time.Minute), Duration: time.Second},
+				{Type: reload.EventReloadFinished, Trigger: t3, Time: at.Add(2 * time.Minute), Duration: time.Second},
+			},
+			path:      "/history?limit=2",
+			expStatus: http.StatusOK,
+			expBody: `[` +
+				`{"trigger_id":"t3","trigger_source":"signal","started_at":"2021-07-19T10:01:59Z","finished_at":"2021-07-19T10:02:00Z","duration_seconds":1},` +
+				`{"trigger_id":"t2","trigger_source":"file","started_at":"2021-07-19T10:00:59Z","finished_at":"2021-07-19T10:01:00Z","duration_seconds":1}]`,
+		},
+
+		"History should have the trigger reason.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadFinished, Trigger: t4, Time: at, Duration: time.Second},
+			},
+			path:      "/history",
+			expStatus: http.StatusOK,
+			expBody: `[` +
+				`{"trigger_id":"t4","trigger_source":"file","reason":"config changed","started_at":"2021-07-19T09:59:59Z","finished_at":"2021-07-19T10:00:00Z","duration_seconds":1}]`,
+		},
+
+		"History should be limited to the history size.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadFinished, Trigger: t1, Time: at, Duration: time.Second},
+				{Type: reload.EventReloadFinished, Trigger: t2, Time: at.Add(time.Minute), Duration: time.Second},
+				{Type: reload.EventReloadFinished, Trigger: t3, Time: at.Add(2 * time.Minute), Duration: time.Second},
+			},
+			path:      "/history",
+			expStatus: http.StatusOK,
+			expBody: `[` +
+				`{"trigger_id":"t3","trigger_source":"signal","started_at":"2021-07-19T10:01:59Z","finished_at":"2021-07-19T10:02:00Z","duration_seconds":1},` +
+				`{"trigger_id":"t2","trigger_source":"file","started_at":"2021-07-19T10:00:59Z","finished_at":"2021-07-19T10:01:00Z","duration_seconds":1}]`,
+		},
+
+		"An invalid history limit should fail.": {
+			path:      "/history?limit=nope",
+			expStatus: http.StatusBadRequest,
+			expBody:   `{"error":"invalid limit"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{HistorySize: 2})
+			require.NoError(err)
+			for _, e := range test.events {
+				h.HandleEvent(context.TODO(), e)
+			}
+
+			// Execute.
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			assert.Equal(test.expBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestAdminHandlerTrigger(t *testing.T) {
+	alice := reloadhttp.AdminAuthorizerFunc(func(r *http.Request) (string, error) { return "alice", nil })
+
+	tests := map[string]struct {
+		token      string
+		authorizer reloadhttp.AdminAuthorizer
+		auth       string
+		body       string
+		expStatus  int
+		expTrigger *reload.TriggerEvent
+	}{
+		"A trigger should trigger a reload with the authorized requester.": {
+			authorizer: alice,
+			body:       `{"id":"t1","reason":"deploy-123","keys":["a"],"metadata":{"team":"ops","requester":"mallory"}}`,
+			expStatus:  http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "t1", Reason: "deploy-123", Keys: []string{"a"}, Metadata: map[string]string{"reason": "deploy-123", "requester": "alice", "team": "ops"}},
+		},
+
+		"A trigger should not set the requester claimed by the client.": {
+			body:       `{"id":"t1","requester":"mallory","metadata":{"team":"ops","requester":"mallory"}}`,
+			expStatus:  http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "t1", Metadata: map[string]string{"team": "ops"}},
+		},
+
+		"A trigger should not set the reserved metadata.": {
+			body:       `{"id":"t1","metadata":{"team":"ops","reload.barrier.ack-url":"http://evil","reload.rollback.generation":"1","reload.signature":"c2ln","reload.nonce":"n1"}}`,
+			expStatus:  http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "t1", Metadata: map[string]string{"team": "ops", "reload.signature": "c2ln", "reload.nonce": "n1"}},
+		},
+
+		"A trigger without body should trigger a reload.": {
+			expStatus:  http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{},
+		},
+
+		"A trigger with an invalid token should fail.": {
+			token:     "secret",
+			auth:      "Bearer other",
+			body:      `{"id":"t1"}`,
+			expStatus: http.StatusUnauthorized,
+		},
+
+		"A trigger with a valid token should trigger a reload.": {
+			token:      "secret",
+			auth:       "Bearer secret",
+			body:       `{"id":"t1"}`,
+			expStatus:  http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "t1"},
+		},
+
+		"A trigger with tags should select the reloaders.": {
+			body:       `{"id":"t1","tags":"tls && !expensive"}`,
+			expStatus:  http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "t1", TagSelector: "tls && !expensive"},
+		},
+
+		"An invalid trigger should fail.": {
+			body:      `{`,
+			expStatus: http.StatusBadRequest,
+		},
+
+		"A trigger with invalid tags should fail.": {
+			body:      `{"id":"t1","tags":"tls &&"}`,
+			expStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{Token: test.token, Authorizer: test.authorizer})
+			require.NoError(err)
+
+			// Execute.
+			r := httptest.NewRequest(http.MethodPost, "/trigger", strings.NewReader(test.body))
+			r.Header.Set("Authorization", test.auth)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			got := receivedTriggers(h)
+			if test.expTrigger == nil {
+				assert.Empty(got)
+				return
+			}
+			require.Len(got, 1)
+			if test.expTrigger.ID == "" {
+				assert.NotEmpty(got[0].ID)
+				test.expTrigger.ID = got[0].ID
+			}
+			assert.Equal(*test.expTrigger, got[0])
+			assert.JSONEq(`{"id":"`+got[0].ID+`"}`, w.Body.String())
+		})
+	}
+}
+
+func TestAdminHandlerSyncTrigger(t *testing.T) {
+	startedAt := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		triggerErr error
+		expStatus  int
+		expBody    string
+	}{
+		"A successful reload should respond with ok.": {
+			expStatus: http.StatusOK,
+			expBody:   `{"id":"t1"}`,
+		},
+
+		"A reload in progress should respond with a conflict.": {
+			triggerErr: fmt.Errorf("something: %w", &reload.ReloadInProgressError{TriggerID: "t0", StartedAt: startedAt}),
+			expStatus:  http.StatusConflict,
+			expBody:    `{"id":"t1","error":"something: reload in progress: trigger \"t0\" started at 2021-07-19T10:00:00Z","in_progress_trigger_id":"t0","in_progress_started_at":"2021-07-19T10:00:00Z"}`,
+		},
+
+		"A failed reload should respond with an error.": {
+			triggerErr: fmt.Errorf("something"),
+			expStatus:  http.StatusInternalServerError,
+			expBody:    `{"id":"t1","error":"something"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var gotTrigger reload.TriggerEvent
+			h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{
+				Trigger: func(ctx context.Context, t reload.TriggerEvent) error {
+					gotTrigger = t
+					return test.triggerErr
+				},
+			})
+			require.NoError(err)
+
+			// Execute.
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/trigger", strings.NewReader(`{"id":"t1"}`)))
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			assert.JSONEq(test.expBody, w.Body.String())
+			assert.Equal(reload.TriggerEvent{ID: "t1"}, gotTrigger)
+			assert.Empty(receivedTriggers(h))
+		})
+	}
+}
+
+func TestAdminHandlerRollback(t *testing.T) {
+	tests := map[string]struct {
+		disabled    bool
+		body        string
+		rollbackErr error
+		expStatus   int
+		expBody     string
+		expRollback uint64
+	}{
+		"A successful rollback should respond with ok.": {
+			body:        `{"generation":3}`,
+			expStatus:   http.StatusOK,
+			expBody:     `{"id":"rollback-3"}`,
+			expRollback: 3,
+		},
+
+		"An unknown generation should respond with not found.": {
+			body:        `{"generation":3}`,
+			rollbackErr: fmt.Errorf("something: %w", reload.ErrUnknownGeneration),
+			expStatus:   http.StatusNotFound,
+			expBody:     `{"id":"rollback-3","error":"something: unknown generation"}`,
+			expRollback: 3,
+		},
+
+		"A failed rollback should respond with an error.": {
+			body:        `{"generation":3}`,
+			rollbackErr: fmt.Errorf("something"),
+			expStatus:   http.StatusInternalServerError,
+			expBody:     `{"id":"rollback-3","error":"something"}`,
+			expRollback: 3,
+		},
+
+		"An invalid request should respond with bad request.": {
+			body:      `{"generation":"3"}`,
+			expStatus: http.StatusBadRequest,
+		},
+
+		"Without rollback function it should respond with not implemented.": {
+			disabled:  true,
+			body:      `{"generation":3}`,
+			expStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var gotRollback uint64
+			cfg := reloadhttp.AdminHandlerConfig{}
+			if !test.disabled {
+				cfg.Rollback = func(ctx context.Context, generation uint64) error {
+					gotRollback = generation
+					return test.rollbackErr
+				}
+			}
+			h, err := reloadhttp.NewAdminHandler(cfg)
+			require.NoError(err)
+
+			// Execute.
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rollback", strings.NewReader(test.body)))
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			if test.expBody != "" {
+				assert.JSONEq(test.expBody, w.Body.String())
+			}
+			assert.Equal(test.expRollback, gotRollback)
+		})
+	}
+}
+
+func TestAdminHandlerResetCircuitBreaker(t *testing.T) {
+	tests := map[string]struct {
+		disabled    bool
+		body        string
+		resetErr    error
+		expStatus   int
+		expBody     string
+		expReloader string
+	}{
+		"A successful reset should respond with ok.": {
+			body:        `{"reloader":"config"}`,
+			expStatus:   http.StatusOK,
+			expBody:     `{"reloader":"config"}`,
+			expReloader: "config",
+		},
+
+		"An unknown circuit breaker should respond with not found.": {
+			body:        `{"reloader":"config"}`,
+			resetErr:    fmt.Errorf("something: %w", reload.ErrUnknownCircuitBreaker),
+			expStatus:   http.StatusNotFound,
+			expBody:     `{"reloader":"config","error":"something: unknown circuit breaker"}`,
+			expReloader: "config",
+		},
+
+		"A failed reset should respond with an error.": {
+			body:        `{"reloader":"config"}`,
+			resetErr:    fmt.Errorf("something"),
+			expStatus:   http.StatusInternalServerError,
+			expBody:     `{"reloader":"config","error":"something"}`,
+			expReloader: "config",
+		},
+
+		"A request without reloader should respond with bad request.": {
+			body:      `{}`,
+			expStatus: http.StatusBadRequest,
+		},
+
+		"Without reset function it should respond with not implemented.": {
+			disabled:  true,
+			body:      `{"reloader":"config"}`,
+			expStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var gotReloader string
+			cfg := reloadhttp.AdminHandlerConfig{}
+			if !test.disabled {
+				cfg.ResetCircuitBreaker = func(ctx context.Context, reloader string) error {
+					gotReloader = reloader
+					return test.resetErr
+				}
+			}
+			h, err := reloadhttp.NewAdminHandler(cfg)
+			require.NoError(err)
+
+			// Execute.
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/circuit-breaker/reset", strings.NewReader(test.body)))
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			if test.expBody != "" {
+				assert.JSONEq(test.expBody, w.Body.String())
+			}
+			assert.Equal(test.expReloader, gotReloader)
+		})
+	}
+}
+
+func TestAdminHandlerToggleReloader(t *testing.T) {
+	tests := map[string]struct {
+		disabled    bool
+		path        string
+		body        string
+		toggleErr   error
+		expStatus   int
+		expBody     string
+		expToggle   string
+		expReloader string
+	}{
+		"A successful enable should respond with ok.": {
+			path:        "/reloaders/enable",
+			body:        `{"reloader":"config"}`,
+			expStatus:   http.StatusOK,
+			expBody:     `{"reloader":"config"}`,
+			expToggle:   "enable",
+			expReloader: "config",
+		},
+
+		"A successful disable should respond with ok.": {
+			path:        "/reloaders/disable",
+			body:        `{"reloader":"config"}`,
+			expStatus:   http.StatusOK,
+			expBody:     `{"reloader":"config"}`,
+			expToggle:   "disable",
+			expReloader: "config",
+		},
+
+		"An unknown reloader should respond with not found.": {
+			path:        "/reloaders/disable",
+			body:        `{"reloader":"config"}`,
+			toggleErr:   fmt.Errorf("something: %w", reload.ErrUnknownReloader),
+			expStatus:   http.StatusNotFound,
+			expBody:     `{"reloader":"config","error":"something: unknown reloader"}`,
+			expToggle:   "disable",
+			expReloader: "config",
+		},
+
+		"A failed toggle should respond with an error.": {
+			path:        "/reloaders/enable",
+			body:        `{"reloader":"config"}`,
+			toggleErr:   fmt.Errorf("something"),
+			expStatus:   http.StatusInternalServerError,
+			expBody:     `{"reloader":"config","error":"something"}`,
+			expToggle:   "enable",
+			expReloader: "config",
+		},
+
+		"A request without reloader should respond with bad request.": {
+			path:      "/reloaders/disable",
+			body:      `{}`,
+			expStatus: http.StatusBadRequest,
+		},
+
+		"Without toggle functions it should respond with not implemented.": {
+			disabled:  true,
+			path:      "/reloaders/disable",
+			body:      `{"reloader":"config"}`,
+			expStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var gotToggle, gotReloader string
+			cfg := reloadhttp.AdminHandlerConfig{}
+			if !test.disabled {
+				cfg.EnableReloader = func(ctx context.Context, reloader string) error {
+					gotToggle, gotReloader = "enable", reloader
+					return test.toggleErr
+				}
+				cfg.DisableReloader = func(ctx context.Context, reloader string) error {
+					gotToggle, gotReloader = "disable", reloader
+					return test.toggleErr
+				}
+			}
+			h, err := reloadhttp.NewAdminHandler(cfg)
+			require.NoError(err)
+
+			// Execute.
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body)))
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			if test.expBody != "" {
+				assert.JSONEq(test.expBody, w.Body.String())
+			}
+			assert.Equal(test.expToggle, gotToggle)
+			assert.Equal(test.expReloader, gotReloader)
+		})
+	}
+}
+
+func TestAdminHandlerApproval(t *testing.T) {
+	tests := map[string]struct {
+		disabled  bool
+		path      string
+		body      string
+		decideErr error
+		expStatus int
+		expBody   string
+		expCall   string
+	}{
+		"A successful approval should respond with ok.": {
+			path:      "/approve",
+			body:      `{"token":"tk1"}`,
+			expStatus: http.StatusOK,
+			expBody:   `{"token":"tk1"}`,
+			expCall:   "approve tk1",
+		},
+
+		"A successful rejection should respond with ok.": {
+			path:      "/reject",
+			body:      `{"token":"tk1"}`,
+			expStatus: http.StatusOK,
+			expBody:   `{"token":"tk1"}`,
+			expCall:   "reject tk1",
+		},
+
+		"An unknown approval should respond with not found.": {
+			path:      "/approve",
+			body:      `{"token":"tk1"}`,
+			decideErr: fmt.Errorf("something: %w", reload.ErrUnknownApproval),
+			expStatus: http.StatusNotFound,
+			expBody:   `{"token":"tk1","error":"something: unknown approval"}`,
+			expCall:   "approve tk1",
+		},
+
+		"A failed approval should respond with an error.": {
+			path:      "/reject",
+			body:      `{"token":"tk1"}`,
+			decideErr: fmt.Errorf("something"),
+			expStatus: http.StatusInternalServerError,
+			expBody:   `{"token":"tk1","error":"something"}`,
+			expCall:   "reject tk1",
+		},
+
+		"A request without token should respond with bad request.": {
+			path:      "/approve",
+			body:      `{}`,
+			expStatus: http.StatusBadRequest,
+		},
+
+		"Without approval functions it should respond with not implemented.": {
+			disabled:  true,
+			path:      "/approve",
+			body:      `{"token":"tk1"}`,
+			expStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var gotCall string
+			cfg := reloadhttp.AdminHandlerConfig{}
+			if !test.disabled {
+				cfg.Approve = func(ctx context.Context, token string) error {
+					gotCall = "approve " + token
+					return test.decideErr
+				}
+				cfg.Reject = func(ctx context.Context, token string) error {
+					gotCall = "reject " + token
+					return test.decideErr
+				}
+			}
+			h, err := reloadhttp.NewAdminHandler(cfg)
+			require.NoError(err)
+
+			// Execute.
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body)))
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			if test.expBody != "" {
+				assert.JSONEq(test.expBody, w.Body.String())
+			}
+			assert.Equal(test.expCall, gotCall)
+		})
+	}
+}
+
+func TestAdminHandlerValidate(t *testing.T) {
+	tests := map[string]struct {
+		disabled    bool
+		body        string
+		validateErr error
+		expStatus   int
+		expBody     string
+		expTrigger  reload.TriggerEvent
+	}{
+		"A valid configuration should respond with the reloaders validation.": {
+			body:       `{"id":"t1","keys":["a"]}`,
+			expStatus:  http.StatusOK,
+			expBody:    `{"id":"t1","valid":true,"reloaders":[{"name":"config","priority":0,"status":"succeeded","duration_seconds":1}]}`,
+			expTrigger: reload.TriggerEvent{ID: "t1", Keys: []string{"a"}},
+		},
+
+		"An invalid configuration should respond with unprocessable entity.": {
+			body:        `{"id":"t1"}`,
+			validateErr: fmt.Errorf("something"),
+			expStatus:   http.StatusUnprocessableEntity,
+			expBody:     `{"id":"t1","valid":false,"error":"something","reloaders":[{"name":"config","priority":0,"status":"failed","duration_seconds":1,"error":"something"}]}`,
+			expTrigger:  reload.TriggerEvent{ID: "t1"},
+		},
+
+		"An invalid request should respond with bad request.": {
+			body:      `{`,
+			expStatus: http.StatusBadRequest,
+			expBody:   `{"error":"invalid trigger"}`,
+		},
+
+		"Without validate function it should respond with not implemented.": {
+			disabled:  true,
+			body:      `{"id":"t1"}`,
+			expStatus: http.StatusNotImplemented,
+			expBody:   `{"error":"validation not enabled"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var gotTrigger reload.TriggerEvent
+			cfg := reloadhttp.AdminHandlerConfig{}
+			if !test.disabled {
+				cfg.Validate = func(ctx context.Context, t reload.TriggerEvent) ([]reload.ReloaderReport, error) {
+					gotTrigger = t
+					r := reload.ReloaderReport{Name: "config", Status: reload.ReloaderSucceeded, Duration: time.Second}
+					if test.validateErr != nil {
+						r.Status = reload.ReloaderFailed
+						r.Err = test.validateErr
+					}
+					return []reload.ReloaderReport{r}, test.validateErr
+				}
+			}
+			h, err := reloadhttp.NewAdminHandler(cfg)
+			require.NoError(err)
+
+			// Execute.
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(test.body)))
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			assert.JSONEq(test.expBody, w.Body.String())
+			assert.Equal(test.expTrigger, gotTrigger)
+		})
+	}
+}
+
+func TestAdminHandlerPendingApprovalStatus(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{})
+	require.NoError(err)
+	status := func() string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+		return w.Body.String()
+	}
+
+	// A pending approval should be on the status.
+	h.HandleEvent(context.TODO(), reload.Event{
+		Type:          reload.EventApprovalPending,
+		Time:          time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC),
+		Trigger:       reload.TriggerEvent{ID: "t1", Source: "git"},
+		ApprovalToken: "tk1",
+	})
+	assert.JSONEq(`{"in_progress":false,"pending_approval":{"token":"tk1","trigger_id":"t1","trigger_source":"git","since":"2021-07-19T10:00:00Z"}}`, status())
+
+	// Once decided it should be removed.
+	h.HandleEvent(context.TODO(), reload.Event{Type: reload.EventApprovalApproved, Trigger: reload.TriggerEvent{ID: "t1", Source: "git"}})
+	assert.JSONEq(`{"in_progress":false}`, status())
+}
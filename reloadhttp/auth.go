@@ -0,0 +1,124 @@
+package reloadhttp
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+)
+
+// AdminAuthorizer authorizes the requests of the admin endpoints, the
+// unauthorized requests are rejected with `401` and the error message.
+//
+// The returned identity of the requester (e.g: the client certificate name)
+// is set as the requester of the admin triggers (see
+// AdminRequesterMetadataKey), empty if the authorizer doesn't identify the
+// requesters.
+type AdminAuthorizer interface {
+	Authorize(r *http.Request) (identity string, err error)
+}
+
+// AdminAuthorizerFunc is a helper to create admin authorizers from functions.
+type AdminAuthorizerFunc func(r *http.Request) (identity string, err error)
+
+// Authorize satisfies AdminAuthorizer interface.
+func (f AdminAuthorizerFunc) Authorize(r *http.Request) (string, error) { return f(r) }
+
+type identityContextKey struct{}
+
+// requestIdentity returns the identity of the authorized requester.
+func requestIdentity(r *http.Request) string {
+	identity, _ := r.Context().Value(identityContextKey{}).(string)
+	return identity
+}
+
+// BearerTokenAuthorizer returns an AdminAuthorizer that requires the token as
+// a bearer token (`Authorization: Bearer <token>`). The token doesn't identify
+// the requesters.
+func BearerTokenAuthorizer(token string) AdminAuthorizer {
+	expected := []byte("Bearer " + token)
+	return AdminAuthorizerFunc(func(r *http.Request) (string, error) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			return "", errors.New("invalid token")
+		}
+		return "", nil
+	})
+}
+
+// ClientCertAuthorizerConfig is the configuration of ClientCertAuthorizer.
+type ClientCertAuthorizerConfig struct {
+	// Roots are the CAs used to verify the client certificates, for the
+	// servers that request the client certificates without verifying them
+	// (e.g: `tls.RequestClientCert`). Use a func to get them from a reloadable
+	// pool (e.g: `reloadtls.CertPool.Get`).
+	// By default the client certificates need to be verified by the TLS
+	// server (e.g: `tls.RequireAndVerifyClientCert`).
+	Roots func() *x509.CertPool
+	// AllowedNames are the `path.Match` patterns of the client certificate
+	// names (common name, DNS and URI SANs) that are authorized.
+	// By default all the verified client certificates are authorized.
+	AllowedNames []string
+}
+
+// ClientCertAuthorizer returns an AdminAuthorizer that requires a verified
+// TLS client certificate (mTLS), optionally with an allowed name. The identity
+// is the allowed name of the certificate, or its first name (common name or
+// SAN) when all the names are allowed.
+func ClientCertAuthorizer(cfg ClientCertAuthorizerConfig) AdminAuthorizer {
+	return AdminAuthorizerFunc(func(r *http.Request) (string, error) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			return "", errors.New("missing client certificate")
+		}
+		cert := r.TLS.PeerCertificates[0]
+
+		if cfg.Roots != nil {
+			opts := x509.VerifyOptions{
+				Roots:         cfg.Roots(),
+				Intermediates: x509.NewCertPool(),
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}
+			for _, c := range r.TLS.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			_, err := cert.Verify(opts)
+			if err != nil {
+				return "", fmt.Errorf("invalid client certificate: %w", err)
+			}
+		} else if len(r.TLS.VerifiedChains) == 0 {
+			return "", errors.New("unverified client certificate")
+		}
+
+		names := certNames(cert)
+		if len(cfg.AllowedNames) == 0 {
+			if len(names) == 0 {
+				return "", nil
+			}
+			return names[0], nil
+		}
+		for _, name := range names {
+			for _, pattern := range cfg.AllowedNames {
+				if ok, _ := path.Match(pattern, name); ok {
+					return name, nil
+				}
+			}
+		}
+
+		return "", fmt.Errorf("client certificate %q not allowed", cert.Subject.CommonName)
+	})
+}
+
+// certNames returns the common name and SANs of the certificate.
+func certNames(cert *x509.Certificate) []string {
+	names := make([]string, 0, 1+len(cert.DNSNames)+len(cert.URIs))
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+
+	return names
+}
@@ -0,0 +1,227 @@
+package reloadhttp_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadhttp"
+)
+
+// newTestCert returns a new certificate signed by the parent, self-signed
+// CA if the parent is nil.
+func newTestCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tpl.IsCA = true
+		tpl.KeyUsage = x509.KeyUsageCertSign
+		tpl.BasicConstraintsValid = true
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func TestAdminHandlerAuthorization(t *testing.T) {
+	ca, caKey := newTestCA(t)
+	otherCA, otherCAKey := newTestCA(t)
+	client, _ := newTestCert(t, "deployer", ca, caKey)
+	untrusted, _ := newTestCert(t, "deployer", otherCA, otherCAKey)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	tests := map[string]struct {
+		cfg       reloadhttp.AdminHandlerConfig
+		auth      string
+		tls       *tls.ConnectionState
+		expStatus int
+		expBody   string
+	}{
+		"Without token nor authorizer the requests should be authorized.": {
+			expStatus: http.StatusOK,
+		},
+
+		"A valid bearer token should be authorized.": {
+			cfg:       reloadhttp.AdminHandlerConfig{Token: "secret"},
+			auth:      "Bearer secret",
+			expStatus: http.StatusOK,
+		},
+
+		"An invalid bearer token should not be authorized.": {
+			cfg:       reloadhttp.AdminHandlerConfig{Token: "secret"},
+			auth:      "Bearer nope",
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"invalid token"}`,
+		},
+
+		"A custom authorizer error should not be authorized.": {
+			cfg: reloadhttp.AdminHandlerConfig{Authorizer: reloadhttp.AdminAuthorizerFunc(func(r *http.Request) (string, error) {
+				return "", fmt.Errorf("something")
+			})},
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"something"}`,
+		},
+
+		"The token and the authorizer should be required.": {
+			cfg: reloadhttp.AdminHandlerConfig{
+				Token:      "secret",
+				Authorizer: reloadhttp.AdminAuthorizerFunc(func(r *http.Request) (string, error) { return "", fmt.Errorf("something") }),
+			},
+			auth:      "Bearer secret",
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"something"}`,
+		},
+
+		"A request without client certificate should not be authorized.": {
+			cfg:       reloadhttp.AdminHandlerConfig{Authorizer: reloadhttp.ClientCertAuthorizer(reloadhttp.ClientCertAuthorizerConfig{})},
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"missing client certificate"}`,
+		},
+
+		"A client certificate verified by the TLS server should be authorized.": {
+			cfg:       reloadhttp.AdminHandlerConfig{Authorizer: reloadhttp.ClientCertAuthorizer(reloadhttp.ClientCertAuthorizerConfig{})},
+			tls:       &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}, VerifiedChains: [][]*x509.Certificate{{client, ca}}},
+			expStatus: http.StatusOK,
+		},
+
+		"A client certificate not verified by the TLS server should not be authorized.": {
+			cfg:       reloadhttp.AdminHandlerConfig{Authorizer: reloadhttp.ClientCertAuthorizer(reloadhttp.ClientCertAuthorizerConfig{})},
+			tls:       &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}},
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"unverified client certificate"}`,
+		},
+
+		"A client certificate signed by the roots should be authorized.": {
+			cfg: reloadhttp.AdminHandlerConfig{Authorizer: reloadhttp.ClientCertAuthorizer(reloadhttp.ClientCertAuthorizerConfig{
+				Roots: func() *x509.CertPool { return roots },
+			})},
+			tls:       &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}},
+			expStatus: http.StatusOK,
+		},
+
+		"A client certificate not signed by the roots should not be authorized.": {
+			cfg: reloadhttp.AdminHandlerConfig{Authorizer: reloadhttp.ClientCertAuthorizer(reloadhttp.ClientCertAuthorizerConfig{
+				Roots: func() *x509.CertPool { return roots },
+			})},
+			tls:       &tls.ConnectionState{PeerCertificates: []*x509.Certificate{untrusted}},
+			expStatus: http.StatusUnauthorized,
+		},
+
+		"A client certificate with an allowed name should be authorized.": {
+			cfg: reloadhttp.AdminHandlerConfig{Authorizer: reloadhttp.ClientCertAuthorizer(reloadhttp.ClientCertAuthorizerConfig{
+				Roots:        func() *x509.CertPool { return roots },
+				AllowedNames: []string{"deploy*"},
+			})},
+			tls:       &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}},
+			expStatus: http.StatusOK,
+		},
+
+		"A client certificate without an allowed name should not be authorized.": {
+			cfg: reloadhttp.AdminHandlerConfig{Authorizer: reloadhttp.ClientCertAuthorizer(reloadhttp.ClientCertAuthorizerConfig{
+				Roots:        func() *x509.CertPool { return roots },
+				AllowedNames: []string{"admin"},
+			})},
+			tls:       &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}},
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"client certificate \"deployer\" not allowed"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			h, err := reloadhttp.NewAdminHandler(test.cfg)
+			require.NoError(err)
+
+			// Execute.
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/status", nil)
+			r.TLS = test.tls
+			if test.auth != "" {
+				r.Header.Set("Authorization", test.auth)
+			}
+			h.ServeHTTP(w, r)
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			if test.expBody != "" {
+				assert.JSONEq(test.expBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func newTestCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	return newTestCert(t, "ca", nil, nil)
+}
+
+func TestClientCertAuthorizerIdentity(t *testing.T) {
+	ca, caKey := newTestCA(t)
+	client, _ := newTestCert(t, "deployer", ca, caKey)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	tests := map[string]struct {
+		allowedNames []string
+		expIdentity  string
+	}{
+		"Without allowed names the identity should be the certificate name.": {
+			expIdentity: "deployer",
+		},
+
+		"With allowed names the identity should be the allowed name.": {
+			allowedNames: []string{"admin", "deploy*"},
+			expIdentity:  "deployer",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			authz := reloadhttp.ClientCertAuthorizer(reloadhttp.ClientCertAuthorizerConfig{
+				Roots:        func() *x509.CertPool { return roots },
+				AllowedNames: test.allowedNames,
+			})
+			r := httptest.NewRequest(http.MethodPost, "/trigger", nil)
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+
+			// Execute.
+			gotIdentity, err := authz.Authorize(r)
+
+			// Check.
+			require.NoError(err)
+			assert.Equal(test.expIdentity, gotIdentity)
+		})
+	}
+}
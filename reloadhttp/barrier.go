@@ -0,0 +1,318 @@
+package reloadhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// BarrierAckURLMetadataKey is the trigger metadata key used to propagate the
+// URL where the reload acknowledgments need to be sent.
+const BarrierAckURLMetadataKey = "reload.barrier.ack-url"
+
+// BarrierAckSignatureMetadataKey is the trigger metadata key used to propagate
+// the signature of the acknowledgments URL, when the barrier has a token.
+const BarrierAckSignatureMetadataKey = "reload.barrier.ack-signature"
+
+const barrierMaxPayloadSize = 1 << 16
+
+// BarrierConfig is the configuration of the Barrier.
+type BarrierConfig struct {
+	// AckURL is the URL where the instance serves the Barrier handler, it's
+	// sent to the peers on the trigger metadata so they know where to send the
+	// acknowledgments.
+	AckURL string
+	// Identity is the identity of the instance on the acknowledgments, it
+	// should be unique on all the peers.
+	// By default the hostname.
+	Identity string
+	// Client is the HTTP client used to send the acknowledgments.
+	// By default a client with a 5s timeout.
+	Client *http.Client
+	// Token is a shared token between the peers, if set it will be sent on
+	// the acknowledgments and required on the received ones, and the
+	// acknowledgments URLs will be signed with it.
+	Token string
+	// PeerAckURLs are the acknowledgments URLs of the peers where the
+	// acknowledgments can be sent, besides AckURL. The acknowledgments are
+	// only sent to these URLs, or to the URLs signed with the Token by a peer,
+	// so the triggers can't send them (and the token) anywhere.
+	PeerAckURLs []string
+	// Timeout is the maximum time Wait will wait for the acknowledgments.
+	// By default 1m.
+	Timeout time.Duration
+}
+
+func (c *BarrierConfig) defaults() error {
+	if c.AckURL == "" {
+		return fmt.Errorf("ack URL is required")
+	}
+
+	if c.Identity == "" {
+		id, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("identity is required, could not get the hostname: %w", err)
+		}
+		c.Identity = id
+	}
+
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = time.Minute
+	}
+
+	return nil
+}
+
+// BarrierAck is the acknowledgment of a peer that completed a reload.
+type BarrierAck struct {
+	// Identity is the identity of the peer.
+	Identity string `json:"identity"`
+	// Error is the error of the peer reload, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Barrier is a coordination primitive where the instance that triggers a
+// reload waits until the peers acknowledge completing their own reload, to
+// implement "all replicas on new config before cutover" workflows.
+//
+// It's a reload.Subscriber that sends the acknowledgment when the reload of a
+// barrier trigger finishes, so it needs to be registered on the manager using
+// `reload.WithSubscriber`, and an HTTP handler that receives the
+// acknowledgments.
+//
+// The triggers are prepared with Prepare and propagated to the peers with any
+// notifier that keeps the metadata (e.g: BroadcastNotifier), then Wait blocks
+// until the acknowledgments are received:
+//
+//	t := barrier.Prepare(reload.TriggerEvent{})
+//	_ = broadcaster.Trigger(ctx, t)
+//	acks, err := barrier.Wait(ctx, t.ID, replicas)
+type Barrier struct {
+	cfg BarrierConfig
+
+	mu      sync.Mutex
+	pending map[string]*barrierWait
+}
+
+type barrierWait struct {
+	acks    map[string]BarrierAck
+	changed chan struct{}
+}
+
+var _ reload.Subscriber = &Barrier{}
+
+// NewBarrier returns a new Barrier.
+func NewBarrier(cfg BarrierConfig) (*Barrier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Barrier{
+		cfg:     cfg,
+		pending: map[string]*barrierWait{},
+	}, nil
+}
+
+// Prepare prepares a trigger to be acknowledged by the peers that reload it,
+// the acknowledgments will be accepted until Wait returns. If the trigger
+// doesn't have an ID, a random one will be used.
+func (b *Barrier) Prepare(t reload.TriggerEvent) reload.TriggerEvent {
+	if t.ID == "" {
+		t.ID = randomID()
+	}
+
+	md := make(map[string]string, len(t.Metadata)+2)
+	for k, v := range t.Metadata {
+		md[k] = v
+	}
+	md[BarrierAckURLMetadataKey] = b.cfg.AckURL
+	if b.cfg.Token != "" {
+		md[BarrierAckSignatureMetadataKey] = b.sign(t.ID, b.cfg.AckURL)
+	}
+	t.Metadata = md
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.pending[t.ID]; !ok {
+		b.pending[t.ID] = &barrierWait{acks: map[string]BarrierAck{}, changed: make(chan struct{})}
+	}
+
+	return t
+}
+
+// Wait waits until n peers acknowledge the reload of a prepared trigger, the
+// instance itself counts as a peer if it reloads the trigger. It returns the
+// received acknowledgments.
+//
+// If a peer reload fails, or n acknowledgments are not received before the
+// context is cancelled or the timeout, an error will be returned.
+func (b *Barrier) Wait(ctx context.Context, id string, n int) ([]BarrierAck, error) {
+	b.mu.Lock()
+	w, ok := b.pending[id]
+	b.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("trigger %q is not prepared", id)
+	}
+	defer func() {
+		b.mu.Lock()
+		delete(b.pending, id)
+		b.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.Timeout)
+	defer cancel()
+
+	for {
+		b.mu.Lock()
+		acks := make([]BarrierAck, 0, len(w.acks))
+		for _, ack := range w.acks {
+			acks = append(acks, ack)
+		}
+		changed := w.changed
+		b.mu.Unlock()
+
+		for _, ack := range acks {
+			if ack.Error != "" {
+				return acks, fmt.Errorf("peer %q reload failed: %s", ack.Identity, ack.Error)
+			}
+		}
+
+		if len(acks) >= n {
+			return acks, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return acks, fmt.Errorf("%d of %d peers acknowledged: %w", len(acks), n, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// HandleEvent satisfies reload.Subscriber interface.
+func (b *Barrier) HandleEvent(_ context.Context, e reload.Event) {
+	if e.Type != reload.EventReloadFinished {
+		return
+	}
+
+	url := e.Trigger.Metadata[BarrierAckURLMetadataKey]
+	if url == "" || !b.allowed(e.Trigger, url) {
+		return
+	}
+
+	p := barrierPayload{ID: e.Trigger.ID, BarrierAck: BarrierAck{Identity: b.cfg.Identity}}
+	if e.Err != nil {
+		p.Error = e.Err.Error()
+	}
+
+	// Subscribers need to be fast, if the acknowledgment can't be sent the
+	// waiting instance will time out.
+	go func() { _ = b.send(context.Background(), url, p) }()
+}
+
+// allowed returns if the acknowledgment of the trigger can be sent to the URL.
+func (b *Barrier) allowed(t reload.TriggerEvent, url string) bool {
+	if url == b.cfg.AckURL || slices.Contains(b.cfg.PeerAckURLs, url) {
+		return true
+	}
+
+	if b.cfg.Token == "" {
+		return false
+	}
+	sig := t.Metadata[BarrierAckSignatureMetadataKey]
+	return hmac.Equal([]byte(sig), []byte(b.sign(t.ID, url)))
+}
+
+// sign returns the signature of the acknowledgments URL of a trigger.
+func (b *Barrier) sign(id, url string) string {
+	mac := hmac.New(sha256.New, []byte(b.cfg.Token))
+	mac.Write([]byte(id + "\n" + url))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type barrierPayload struct {
+	ID string `json:"id"`
+	BarrierAck
+}
+
+// ServeHTTP satisfies http.Handler interface.
+func (b *Barrier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if b.cfg.Token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+b.cfg.Token)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	var p barrierPayload
+	err := json.NewDecoder(io.LimitReader(r.Body, barrierMaxPayloadSize)).Decode(&p)
+	if err != nil || p.ID == "" || p.Identity == "" {
+		http.Error(w, "invalid acknowledgment", http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wt, ok := b.pending[p.ID]
+	if !ok {
+		http.Error(w, "unknown trigger", http.StatusNotFound)
+		return
+	}
+
+	wt.acks[p.Identity] = p.BarrierAck
+	close(wt.changed)
+	wt.changed = make(chan struct{})
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (b *Barrier) send(ctx context.Context, url string, p barrierPayload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("could not encode acknowledgment: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.cfg.Token)
+	}
+
+	resp, err := b.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected %d status code", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func newTestBarrier(t *testing.T, identity string) *reloadhttp.Barrier {
+	var b *reloadhttp.Barrier
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { b.ServeHTTP(w, r) }))
+	t.Cleanup(srv.Close)
+
+	b, err := reloadhttp.NewBarrier(reloadhttp.BarrierConfig{
+		AckURL:   srv.URL,
+		Identity: identity,
+		Token:    "secret",
+		Timeout:  200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	return b
+}
+
+func TestBarrier(t *testing.T) {
+	tests := map[string]struct {
+		peerErrs []error
+		waitFor  int
+		expAcks  []reloadhttp.BarrierAck
+		expErr   bool
+	}{
+		"Waiting should finish when all the peers acknowledge the reload.": {
+			peerErrs: []error{nil, nil, nil},
+			waitFor:  3,
+			expAcks: []reloadhttp.BarrierAck{
+				{Identity: "replica-0"},
+				{Identity: "replica-1"},
+				{Identity: "replica-2"},
+			},
+		},
+
+		"Waiting should fail when a peer reload fails.": {
+			peerErrs: []error{fmt.Errorf("something")},
+			waitFor:  1,
+			expAcks: []reloadhttp.BarrierAck{
+				{Identity: "replica-0", Error: "something"},
+			},
+			expErr: true,
+		},
+
+		"Waiting should time out when not enough peers acknowledge the reload.": {
+			peerErrs: []error{nil, nil},
+			waitFor:  3,
+			expAcks: []reloadhttp.BarrierAck{
+				{Identity: "replica-0"},
+				{Identity: "replica-1"},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			origin := newTestBarrier(t, "origin")
+			tr := origin.Prepare(reload.TriggerEvent{ID: "deploy-123", Metadata: map[string]string{"reason": "deploy"}})
+
+			// Execute.
+			for i, err := range test.peerErrs {
+				peer := newTestBarrier(t, fmt.Sprintf("replica-%d", i))
+				peer.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadStarted, Trigger: tr})
+				peer.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadFinished, Trigger: tr, Err: err})
+			}
+			acks, err := origin.Wait(context.TODO(), tr.ID, test.waitFor)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			sort.Slice(acks, func(i, j int) bool { return acks[i].Identity < acks[j].Identity })
+			assert.Equal(test.expAcks, acks)
+			assert.Equal("deploy", tr.Metadata["reason"])
+		})
+	}
+}
+
+func TestBarrierWaitNotPrepared(t *testing.T) {
+	b := newTestBarrier(t, "origin")
+	_, err := b.Wait(context.TODO(), "unknown", 1)
+	assert.Error(t, err)
+}
+
+func TestBarrierInvalidAck(t *testing.T) {
+	b, err := reloadhttp.NewBarrier(reloadhttp.BarrierConfig{AckURL: "http://127.0.0.1", Token: "secret"})
+	require.NoError(t, err)
+	tr := b.Prepare(reload.TriggerEvent{})
+
+	tests := map[string]struct {
+		auth      string
+		body      string
+		expStatus int
+	}{
+		"Invalid token.":   {auth: "Bearer other", body: `{"id":"` + tr.ID + `","identity":"a"}`, expStatus: http.StatusUnauthorized},
+		"Invalid payload.": {auth: "Bearer secret", body: `{"id":"` + tr.ID + `"}`, expStatus: http.StatusBadRequest},
+		"Unknown trigger.": {auth: "Bearer secret", body: `{"id":"unknown","identity":"a"}`, expStatus: http.StatusNotFound},
+		"Valid.":           {auth: "Bearer secret", body: `{"id":"` + tr.ID + `","identity":"a"}`, expStatus: http.StatusAccepted},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			r.Header.Set("Authorization", test.auth)
+			w := httptest.NewRecorder()
+			b.ServeHTTP(w, r)
+			assert.Equal(t, test.expStatus, w.Code)
+		})
+	}
+}
+
+func TestBarrierAckURL(t *testing.T) {
+	tests := map[string]struct {
+		token       string
+		peerAckURLs func(url string) []string
+		metadata    func(origin *reloadhttp.Barrier, url string) map[string]string
+		expAck      bool
+	}{
+		"An URL signed by a peer should be acknowledged.": {
+			token: "secret",
+			metadata: func(origin *reloadhttp.Barrier, url string) map[string]string {
+				return origin.Prepare(reload.TriggerEvent{ID: "t1"}).Metadata
+			},
+			expAck: true,
+		},
+
+		"An unsigned URL should not be acknowledged.": {
+			token: "secret",
+			metadata: func(origin *reloadhttp.Barrier, url string) map[string]string {
+				return map[string]string{reloadhttp.BarrierAckURLMetadataKey: url}
+			},
+		},
+
+		"An URL with an invalid signature should not be acknowledged.": {
+			token: "secret",
+			metadata: func(origin *reloadhttp.Barrier, url string) map[string]string {
+				return map[string]string{
+					reloadhttp.BarrierAckURLMetadataKey:       url,
+					reloadhttp.BarrierAckSignatureMetadataKey: "0123",
+				}
+			},
+		},
+
+		"A configured peer URL should be acknowledged without token.": {
+			peerAckURLs: func(url string) []string { return []string{url} },
+			metadata: func(origin *reloadhttp.Barrier, url string) map[string]string {
+				return map[string]string{reloadhttp.BarrierAckURLMetadataKey: url}
+			},
+			expAck: true,
+		},
+
+		"An URL that is not configured should not be acknowledged without token.": {
+			metadata: func(origin *reloadhttp.Barrier, url string) map[string]string {
+				return origin.Prepare(reload.TriggerEvent{ID: "t1"}).Metadata
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			acked := make(chan struct{}, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				acked <- struct{}{}
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer srv.Close()
+
+			origin, err := reloadhttp.NewBarrier(reloadhttp.BarrierConfig{AckURL: srv.URL, Identity: "origin", Token: test.token})
+			require.NoError(err)
+			peerCfg := reloadhttp.BarrierConfig{AckURL: "http://127.0.0.1:1", Identity: "peer", Token: test.token}
+			if test.peerAckURLs != nil {
+				peerCfg.PeerAckURLs = test.peerAckURLs(srv.URL)
+			}
+			peer, err := reloadhttp.NewBarrier(peerCfg)
+			require.NoError(err)
+
+			// Execute.
+			tr := reload.TriggerEvent{ID: "t1", Metadata: test.metadata(origin, srv.URL)}
+			peer.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadFinished, Trigger: tr})
+
+			// Check.
+			gotAck := false
+			select {
+			case <-acked:
+				gotAck = true
+			case <-time.After(100 * time.Millisecond):
+			}
+			assert.Equal(test.expAck, gotAck)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package reloadhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+const broadcastMaxPayloadSize = 1 << 20
+
+// BroadcastNotifierConfig is the configuration of the BroadcastNotifier.
+type BroadcastNotifierConfig struct {
+	// Peers returns the URLs where the peers serve their BroadcastNotifier
+	// handler, it's called on every broadcast so it can use service discovery
+	// (e.g: resolving a headless service). The URL of the instance itself can
+	// be returned, it will be ignored by the deduplication.
+	Peers func(ctx context.Context) ([]string, error)
+	// Client is the HTTP client used to broadcast the triggers.
+	// By default a client with a 5s timeout.
+	Client *http.Client
+	// Token is a shared token between the peers, if set it will be sent on
+	// the broadcasts and required on the received ones.
+	Token string
+	// MaxSeen is the number of trigger IDs that will be remembered to
+	// deduplicate the broadcasts.
+	// By default 1024.
+	MaxSeen int
+}
+
+func (c *BroadcastNotifierConfig) defaults() error {
+	if c.Peers == nil {
+		return fmt.Errorf("peers function is required")
+	}
+
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	if c.MaxSeen <= 0 {
+		c.MaxSeen = 1024
+	}
+
+	return nil
+}
+
+// BroadcastNotifier is a reload.Notifier that fans out the triggers to the
+// peer instances over HTTP, so the whole fleet reloads together. It's an HTTP
+// handler that receives the peer broadcasts.
+//
+// When an instance receives a trigger, using Trigger (e.g: from its admin
+// endpoint) or from a peer, it triggers its own reload and rebroadcasts it to
+// all the peers. The triggers are deduplicated by their ID so rebroadcasts
+// don't loop, this means trigger IDs need to be unique (e.g: `deploy-123`).
+//
+// If multiple triggers are received while the manager is busy, only the latest
+// one will be triggered.
+type BroadcastNotifier struct {
+	cfg BroadcastNotifierConfig
+	c   chan reload.TriggerEvent
+
+	mu       sync.Mutex
+	seen     map[string]struct{}
+	seenList []string
+}
+
+// NewBroadcastNotifier returns a new BroadcastNotifier.
+func NewBroadcastNotifier(cfg BroadcastNotifierConfig) (*BroadcastNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &BroadcastNotifier{
+		cfg:  cfg,
+		c:    make(chan reload.TriggerEvent, 1),
+		seen: map[string]struct{}{},
+	}, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (b *BroadcastNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := b.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (b *BroadcastNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	select {
+	case <-ctx.Done():
+		return reload.TriggerEvent{}, ctx.Err()
+	case t := <-b.c:
+		return t, nil
+	}
+}
+
+// Trigger triggers a reload on the instance and broadcasts it to all the
+// peers. If the trigger doesn't have an ID, a random one will be used.
+//
+// The local reload is always triggered, the returned error has the peers that
+// could not receive the broadcast.
+func (b *BroadcastNotifier) Trigger(ctx context.Context, t reload.TriggerEvent) error {
+	if t.ID == "" {
+		t.ID = randomID()
+	}
+
+	if !b.markSeen(t.ID) {
+		return nil
+	}
+	b.trigger(t)
+
+	return b.broadcast(ctx, t)
+}
+
+type broadcastPayload struct {
+	ID       string            `json:"id"`
+	Paths    []string          `json:"paths,omitempty"`
+	Keys     []string          `json:"keys,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ServeHTTP satisfies http.Handler interface.
+func (b *BroadcastNotifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if b.cfg.Token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+b.cfg.Token)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	var p broadcastPayload
+	err := json.NewDecoder(io.LimitReader(r.Body, broadcastMaxPayloadSize)).Decode(&p)
+	if err != nil || p.ID == "" {
+		http.Error(w, "invalid trigger", http.StatusBadRequest)
+		return
+	}
+
+	// Already received.
+	if !b.markSeen(p.ID) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	t := reload.TriggerEvent{ID: p.ID, Paths: p.Paths, Keys: p.Keys, Metadata: p.Metadata}
+	b.trigger(t)
+
+	// Rebroadcast in background so the peers that didn't receive it from the
+	// origin get it, the broadcast errors can't be handled by the sender.
+	go func() { _ = b.broadcast(context.Background(), t) }()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (b *BroadcastNotifier) broadcast(ctx context.Context, t reload.TriggerEvent) error {
+	peers, err := b.cfg.Peers(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get peers: %w", err)
+	}
+
+	body, err := json.Marshal(broadcastPayload{ID: t.ID, Paths: t.Paths, Keys: t.Keys, Metadata: t.Metadata})
+	if err != nil {
+		return fmt.Errorf("could not encode trigger: %w", err)
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := b.send(ctx, peer, body)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("could not broadcast to %q peer: %w", peer, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (b *BroadcastNotifier) send(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.cfg.Token)
+	}
+
+	resp, err := b.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected %d status code", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// markSeen marks the trigger ID as seen, returns false if it was already seen.
+func (b *BroadcastNotifier) markSeen(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.seen[id]; ok {
+		return false
+	}
+
+	b.seen[id] = struct{}{}
+	b.seenList = append(b.seenList, id)
+	if len(b.seenList) > b.cfg.MaxSeen {
+		delete(b.seen, b.seenList[0])
+		b.seenList = b.seenList[1:]
+	}
+
+	return true
+}
+
+// trigger sends the trigger replacing the pending one, if any.
+func (b *BroadcastNotifier) trigger(t reload.TriggerEvent) {
+	for {
+		select {
+		case b.c <- t:
+			return
+		default:
+		}
+
+		// Drop the pending trigger.
+		select {
+		case <-b.c:
+		default:
+		}
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
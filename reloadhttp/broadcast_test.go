@@ -0,0 +1,138 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+// newTestFleet returns n broadcast notifiers that have all the fleet as peers.
+func newTestFleet(t *testing.T, n int, tokens []string) []*reloadhttp.BroadcastNotifier {
+	var urls []string
+	notifiers := make([]*reloadhttp.BroadcastNotifier, n)
+	for i := range n {
+		bn, err := reloadhttp.NewBroadcastNotifier(reloadhttp.BroadcastNotifierConfig{
+			Peers: func(ctx context.Context) ([]string, error) { return urls, nil },
+			Token: tokens[i],
+		})
+		require.NoError(t, err)
+		srv := httptest.NewServer(bn)
+		t.Cleanup(srv.Close)
+		urls = append(urls, srv.URL)
+		notifiers[i] = bn
+	}
+
+	return notifiers
+}
+
+// receivedTriggers returns the triggers received by the notifier until no more
+// triggers are received.
+func receivedTriggers(n reload.TriggerNotifier) []reload.TriggerEvent {
+	var got []reload.TriggerEvent
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		t, err := n.NotifyTrigger(ctx)
+		cancel()
+		if err != nil {
+			return got
+		}
+		got = append(got, t)
+	}
+}
+
+func TestBroadcastNotifier(t *testing.T) {
+	tests := map[string]struct {
+		tokens      []string
+		trigger     reload.TriggerEvent
+		expTriggers [][]reload.TriggerEvent
+		expErr      bool
+	}{
+		"A trigger should be broadcasted to all the fleet once.": {
+			tokens:  []string{"", "", ""},
+			trigger: reload.TriggerEvent{ID: "deploy-123", Keys: []string{"a"}, Metadata: map[string]string{"reason": "deploy"}},
+			expTriggers: [][]reload.TriggerEvent{
+				{{ID: "deploy-123", Keys: []string{"a"}, Metadata: map[string]string{"reason": "deploy"}}},
+				{{ID: "deploy-123", Keys: []string{"a"}, Metadata: map[string]string{"reason": "deploy"}}},
+				{{ID: "deploy-123", Keys: []string{"a"}, Metadata: map[string]string{"reason": "deploy"}}},
+			},
+		},
+
+		"Peers with an invalid token should not receive the trigger.": {
+			tokens:  []string{"secret", "secret", "other"},
+			trigger: reload.TriggerEvent{ID: "deploy-123"},
+			expTriggers: [][]reload.TriggerEvent{
+				{{ID: "deploy-123"}},
+				{{ID: "deploy-123"}},
+				nil,
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			fleet := newTestFleet(t, len(test.tokens), test.tokens)
+
+			// Execute.
+			err := fleet[0].Trigger(context.TODO(), test.trigger)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			for i, n := range fleet {
+				assert.Equal(test.expTriggers[i], receivedTriggers(n), "instance %d", i)
+			}
+		})
+	}
+}
+
+func TestBroadcastNotifierDeduplication(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	fleet := newTestFleet(t, 2, []string{"", ""})
+
+	// Execute.
+	require.NoError(fleet[0].Trigger(context.TODO(), reload.TriggerEvent{ID: "t1"}))
+	require.NoError(fleet[1].Trigger(context.TODO(), reload.TriggerEvent{ID: "t1"}))
+	require.NoError(fleet[1].Trigger(context.TODO(), reload.TriggerEvent{}))
+
+	// Check.
+	got0 := receivedTriggers(fleet[0])
+	got1 := receivedTriggers(fleet[1])
+	require.Len(got0, 1)
+	require.Len(got1, 1)
+	assert.NotEqual("t1", got0[0].ID)
+	assert.Equal(got0[0].ID, got1[0].ID)
+}
+
+func TestBroadcastNotifierInvalidRequest(t *testing.T) {
+	bn, err := reloadhttp.NewBroadcastNotifier(reloadhttp.BroadcastNotifierConfig{
+		Peers: func(ctx context.Context) ([]string, error) { return nil, nil },
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	bn.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	bn.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,143 @@
+package reloadhttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ClientSettings are the settings of an HTTP client.
+type ClientSettings struct {
+	// Transport is the transport of the client (proxy, TLS, connection pool...).
+	// By default a clone of `http.DefaultTransport`.
+	Transport http.RoundTripper
+	// Timeout is the same as `http.Client.Timeout`, the time limit of the
+	// requests including reading the response body.
+	// By default no timeout.
+	Timeout time.Duration
+}
+
+// ClientReloaderConfig is the configuration of the ClientReloader.
+type ClientReloaderConfig struct {
+	// Settings returns the client settings, it will be called on every reload.
+	Settings func(ctx context.Context) (ClientSettings, error)
+}
+
+func (c *ClientReloaderConfig) defaults() error {
+	if c.Settings == nil {
+		return fmt.Errorf("settings function is required")
+	}
+
+	return nil
+}
+
+// ClientReloader is a reload.Reloader that swaps the transport and timeout of
+// an HTTP client on every reload.
+//
+// The client is created by the reloader and is the same during all its life,
+// so the users can store it. The requests in-flight when the settings are
+// swapped will end using the old settings, and the idle connections of the
+// old transport are closed.
+type ClientReloader struct {
+	cfg       ClientReloaderConfig
+	client    *http.Client
+	transport *swapTransport
+}
+
+// NewClientReloader returns a new ClientReloader, the client will be configured
+// with the current settings.
+func NewClientReloader(ctx context.Context, cfg ClientReloaderConfig) (*ClientReloader, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	s, err := cfg.Settings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get client settings: %w", err)
+	}
+
+	t := &swapTransport{}
+	t.current.Store(clientSettingsDefaults(s))
+
+	return &ClientReloader{
+		cfg:       cfg,
+		client:    &http.Client{Transport: t},
+		transport: t,
+	}, nil
+}
+
+// Client returns the HTTP client.
+func (c *ClientReloader) Client() *http.Client {
+	return c.client
+}
+
+// Reload satisfies reload.Reloader interface.
+func (c *ClientReloader) Reload(ctx context.Context, _ string) error {
+	s, err := c.cfg.Settings(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get client settings: %w", err)
+	}
+
+	old := c.transport.current.Swap(clientSettingsDefaults(s))
+	closeIdleConnections(old.Transport)
+
+	return nil
+}
+
+func clientSettingsDefaults(s ClientSettings) *ClientSettings {
+	if s.Transport == nil {
+		s.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	return &s
+}
+
+func closeIdleConnections(rt http.RoundTripper) {
+	type closeIdler interface{ CloseIdleConnections() }
+	if ci, ok := rt.(closeIdler); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// swapTransport is a transport that delegates on swappable settings.
+type swapTransport struct {
+	current atomic.Pointer[ClientSettings]
+}
+
+func (s *swapTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	settings := s.current.Load()
+	if settings.Timeout <= 0 {
+		return settings.Transport.RoundTrip(r)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), settings.Timeout)
+	resp, err := settings.Transport.RoundTrip(r.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The timeout includes reading the body.
+	resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+func (s *swapTransport) CloseIdleConnections() {
+	closeIdleConnections(s.current.Load().Transport)
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelBody) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,137 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadhttp"
+)
+
+type testRoundTripper struct {
+	name string
+	idle int
+}
+
+func (t *testRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	select {
+	case <-r.Context().Done():
+		return nil, r.Context().Err()
+	case <-time.After(10 * time.Millisecond):
+	}
+	_, _ = rec.WriteString(t.name)
+	return rec.Result(), nil
+}
+
+func (t *testRoundTripper) CloseIdleConnections() { t.idle++ }
+
+func TestClientReloader(t *testing.T) {
+	tests := map[string]struct {
+		settings []reloadhttp.ClientSettings
+		expBody  string
+		expErr   bool
+	}{
+		"The client should use the initial settings.": {
+			settings: []reloadhttp.ClientSettings{
+				{Transport: &testRoundTripper{name: "t1"}},
+			},
+			expBody: "t1",
+		},
+
+		"The client should use the reloaded settings.": {
+			settings: []reloadhttp.ClientSettings{
+				{Transport: &testRoundTripper{name: "t1"}},
+				{Transport: &testRoundTripper{name: "t2"}},
+			},
+			expBody: "t2",
+		},
+
+		"The client should use the reloaded timeout.": {
+			settings: []reloadhttp.ClientSettings{
+				{Transport: &testRoundTripper{name: "t1"}},
+				{Transport: &testRoundTripper{name: "t2"}, Timeout: time.Millisecond},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			settings := test.settings
+			r, err := reloadhttp.NewClientReloader(context.TODO(), reloadhttp.ClientReloaderConfig{
+				Settings: func(ctx context.Context) (reloadhttp.ClientSettings, error) {
+					if len(settings) == 0 {
+						return reloadhttp.ClientSettings{}, fmt.Errorf("no more settings")
+					}
+					s := settings[0]
+					settings = settings[1:]
+					return s, nil
+				},
+			})
+			require.NoError(err)
+			client := r.Client()
+
+			// Execute.
+			for range test.settings[1:] {
+				require.NoError(r.Reload(context.TODO(), "test"))
+			}
+			resp, err := client.Get("http://test/")
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(err)
+			assert.Equal(test.expBody, string(body))
+
+			// The replaced transports should have their idle connections closed.
+			for _, s := range test.settings[:len(test.settings)-1] {
+				assert.Equal(1, s.Transport.(*testRoundTripper).idle)
+			}
+		})
+	}
+}
+
+func TestClientReloaderReloadError(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	calls := 0
+	r, err := reloadhttp.NewClientReloader(context.TODO(), reloadhttp.ClientReloaderConfig{
+		Settings: func(ctx context.Context) (reloadhttp.ClientSettings, error) {
+			calls++
+			if calls > 1 {
+				return reloadhttp.ClientSettings{}, fmt.Errorf("something")
+			}
+			return reloadhttp.ClientSettings{Transport: &testRoundTripper{name: "t1"}}, nil
+		},
+	})
+	require.NoError(err)
+
+	err = r.Reload(context.TODO(), "test")
+	assert.Error(err)
+
+	// The client should keep the previous settings.
+	resp, err := r.Client().Get("http://test/")
+	require.NoError(err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(err)
+	assert.Equal("t1", string(body))
+}
@@ -0,0 +1,188 @@
+package reloadhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/slok/reload/internal/backoff"
+)
+
+// ConfigFetcherConfig is the configuration of the ConfigFetcher.
+type ConfigFetcherConfig struct {
+	// URL is the configuration endpoint.
+	URL string
+	// Header are additional headers sent on the requests (e.g: authorization).
+	Header http.Header
+	// Client is the HTTP client used for the requests.
+	// By default `http.DefaultClient`.
+	Client *http.Client
+	// Timeout is the time limit of every request attempt.
+	// By default 10s.
+	Timeout time.Duration
+	// Retries is the number of retries after a failed attempt, the network
+	// errors, 429 and 5xx responses are retried.
+	// By default 3, use a negative number to disable the retries.
+	Retries int
+	// MinBackoff is the initial wait time before retrying.
+	// By default 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum wait time before retrying.
+	// By default 5s.
+	MaxBackoff time.Duration
+	// StaleWhileError will return the last fetched configuration when the
+	// endpoint can't be fetched, instead of failing.
+	StaleWhileError bool
+	// OnStale is called with the fetch error when the last fetched
+	// configuration is returned because of StaleWhileError, optional.
+	OnStale func(ctx context.Context, err error)
+}
+
+func (c *ConfigFetcherConfig) defaults() error {
+	if c.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	if c.Retries == 0 {
+		c.Retries = 3
+	}
+
+	if c.Retries < 0 {
+		c.Retries = 0
+	}
+
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 250 * time.Millisecond
+	}
+
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 5 * time.Second
+	}
+
+	if c.MaxBackoff < c.MinBackoff {
+		return fmt.Errorf("max backoff can't be lower than min backoff")
+	}
+
+	if c.OnStale == nil {
+		c.OnStale = func(context.Context, error) {}
+	}
+
+	return nil
+}
+
+// ConfigFetcher fetches the raw configuration from an HTTP endpoint, its Load
+// method can be used as the `reloadconfig` loader so the configuration is
+// fetched on every reload.
+//
+// The fetched configuration is cached with its `ETag`, and the next requests
+// use `If-None-Match` so the endpoint can answer with `304 Not Modified`.
+type ConfigFetcher struct {
+	cfg ConfigFetcherConfig
+
+	mu   sync.Mutex
+	body []byte
+	etag string
+}
+
+// NewConfigFetcher returns a new ConfigFetcher.
+func NewConfigFetcher(cfg ConfigFetcherConfig) (*ConfigFetcher, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &ConfigFetcher{cfg: cfg}, nil
+}
+
+// Load fetches the configuration from the endpoint retrying the failed
+// attempts. The returned configuration is a copy, so the callers can modify
+// it without altering the cache.
+func (c *ConfigFetcher) Load(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := backoff.New(c.cfg.MinBackoff, c.cfg.MaxBackoff)
+	var err error
+	for attempt := 0; attempt <= c.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			b.Wait(ctx)
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+
+		var retry bool
+		retry, err = c.fetch(ctx)
+		if err == nil {
+			return bytes.Clone(c.body), nil
+		}
+		if !retry {
+			break
+		}
+	}
+
+	if c.cfg.StaleWhileError && c.body != nil {
+		c.cfg.OnStale(ctx, err)
+		return bytes.Clone(c.body), nil
+	}
+
+	return nil, err
+}
+
+// fetch fetches the configuration and updates the cache, it returns if the
+// failure can be retried.
+func (c *ConfigFetcher) fetch(ctx context.Context) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.URL, nil)
+	if err != nil {
+		return false, fmt.Errorf("could not create request: %w", err)
+	}
+	for k, vs := range c.cfg.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if c.etag != "" && c.body != nil {
+		req.Header.Set("If-None-Match", c.etag)
+	}
+
+	resp, err := c.cfg.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("could not fetch configuration: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && c.body != nil:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("could not fetch configuration: unexpected status code %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("could not fetch configuration: unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, fmt.Errorf("could not read configuration: %w", err)
+	}
+
+	c.body = body
+	c.etag = resp.Header.Get("ETag")
+
+	return false, nil
+}
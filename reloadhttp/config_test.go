@@ -0,0 +1,193 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadhttp"
+)
+
+type testConfigResponse struct {
+	status int
+	etag   string
+	body   string
+}
+
+func TestConfigFetcher(t *testing.T) {
+	tests := map[string]struct {
+		responses    []testConfigResponse
+		loads        int
+		stale        bool
+		expBody      string
+		expErr       bool
+		expRequests  int
+		expIfNoMatch string
+		expStale     bool
+	}{
+		"A fetched configuration should be returned.": {
+			responses:   []testConfigResponse{{status: 200, body: "a"}},
+			loads:       1,
+			expBody:     "a",
+			expRequests: 1,
+		},
+
+		"A not modified configuration should return the cached one.": {
+			responses: []testConfigResponse{
+				{status: 200, etag: `"v1"`, body: "a"},
+				{status: 304},
+			},
+			loads:        2,
+			expBody:      "a",
+			expRequests:  2,
+			expIfNoMatch: `"v1"`,
+		},
+
+		"Server errors should be retried.": {
+			responses: []testConfigResponse{
+				{status: 500},
+				{status: 429},
+				{status: 200, body: "a"},
+			},
+			loads:       1,
+			expBody:     "a",
+			expRequests: 3,
+		},
+
+		"Client errors should not be retried.": {
+			responses:   []testConfigResponse{{status: 404}},
+			loads:       1,
+			expErr:      true,
+			expRequests: 1,
+		},
+
+		"Exhausted retries should fail.": {
+			responses: []testConfigResponse{
+				{status: 200, body: "a"},
+				{status: 500}, {status: 500}, {status: 500}, {status: 500},
+			},
+			loads:       2,
+			expErr:      true,
+			expRequests: 5,
+		},
+
+		"Exhausted retries with stale while error should return the last configuration.": {
+			responses: []testConfigResponse{
+				{status: 200, body: "a"},
+				{status: 500}, {status: 500}, {status: 500}, {status: 500},
+			},
+			loads:       2,
+			stale:       true,
+			expBody:     "a",
+			expRequests: 5,
+			expStale:    true,
+		},
+
+		"Stale while error without a previous configuration should fail.": {
+			responses:   []testConfigResponse{{status: 404}},
+			loads:       1,
+			stale:       true,
+			expErr:      true,
+			expRequests: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var mu sync.Mutex
+			requests := 0
+			ifNoneMatch := ""
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				resp := test.responses[min(requests, len(test.responses)-1)]
+				requests++
+				if v := r.Header.Get("If-None-Match"); v != "" {
+					ifNoneMatch = v
+				}
+				if resp.etag != "" {
+					w.Header().Set("ETag", resp.etag)
+				}
+				w.WriteHeader(resp.status)
+				_, _ = w.Write([]byte(resp.body))
+			}))
+			defer srv.Close()
+
+			gotStale := false
+			f, err := reloadhttp.NewConfigFetcher(reloadhttp.ConfigFetcherConfig{
+				URL:             srv.URL,
+				MinBackoff:      time.Millisecond,
+				MaxBackoff:      time.Millisecond,
+				StaleWhileError: test.stale,
+				OnStale:         func(context.Context, error) { gotStale = true },
+			})
+			require.NoError(err)
+
+			var body []byte
+			for range test.loads {
+				body, err = f.Load(context.TODO())
+			}
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expBody, string(body))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(test.expRequests, requests)
+			assert.Equal(test.expIfNoMatch, ifNoneMatch)
+			assert.Equal(test.expStale, gotStale)
+		})
+	}
+}
+
+func TestConfigFetcherLoadCopyAndCancel(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("a"))
+	}))
+	defer srv.Close()
+	f, err := reloadhttp.NewConfigFetcher(reloadhttp.ConfigFetcherConfig{URL: srv.URL, StaleWhileError: true})
+	require.NoError(err)
+
+	// Execute.
+	body, err := f.Load(context.TODO())
+	require.NoError(err)
+	body[0] = 'b'
+	cached, err := f.Load(context.TODO())
+	require.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stale, staleErr := f.Load(ctx)
+
+	// Check.
+	assert.Equal("a", string(cached), "the cache should not be modified by the callers")
+	assert.NoError(staleErr)
+	assert.Equal("a", string(stale))
+
+	f, err = reloadhttp.NewConfigFetcher(reloadhttp.ConfigFetcherConfig{URL: srv.URL})
+	require.NoError(err)
+	body, err = f.Load(ctx)
+	assert.ErrorIs(err, context.Canceled)
+	assert.Nil(body)
+}
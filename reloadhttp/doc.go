@@ -0,0 +1,2 @@
+// Package reloadhttp has the HTTP integrations of the reload mechanism.
+package reloadhttp
@@ -0,0 +1,53 @@
+package reloadhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/slok/reload"
+)
+
+func (a *AdminHandler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	var types []reload.EventType
+	if t := r.URL.Query().Get("types"); t != "" {
+		for _, typ := range strings.Split(t, ",") {
+			types = append(types, reload.EventType(strings.TrimSpace(typ)))
+		}
+	}
+
+	watcher := a.events.Watch(types...)
+	defer watcher.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-watcher.Lagging():
+			// Let the client reconnect.
+			return
+		case e := <-watcher.Events():
+			data, err := reload.MarshalEventJSON(e.Event)
+			if err != nil {
+				continue
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Event.Type, data)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
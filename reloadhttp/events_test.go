@@ -0,0 +1,91 @@
+package reloadhttp_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func TestAdminHandlerEvents(t *testing.T) {
+	at := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	events := []reload.Event{
+		{Type: reload.EventReloadStarted, Trigger: reload.TriggerEvent{ID: "t1", Source: "file"}, Time: at},
+		{Type: reload.EventReloaderFinished, Trigger: reload.TriggerEvent{ID: "t1", Source: "file"}, Time: at, Reloader: "config", Duration: time.Second},
+		{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "t1", Source: "file"}, Time: at, Duration: time.Second},
+	}
+
+	tests := map[string]struct {
+		query     string
+		expStream string
+	}{
+		"All the events should be streamed.": {
+			expStream: "id: 1\n" +
+				"event: reload_started\n" +
+				`data: {"type":"reload_started","time":"2021-07-19T10:00:00Z","trigger_id":"t1","trigger_source":"file"}` + "\n\n" +
+				"id: 2\n" +
+				"event: reloader_finished\n" +
+				`data: {"type":"reloader_finished","time":"2021-07-19T10:00:00Z","trigger_id":"t1","trigger_source":"file","priority":0,"reloader":"config","duration_seconds":1}` + "\n\n" +
+				"id: 3\n" +
+				"event: reload_finished\n" +
+				`data: {"type":"reload_finished","time":"2021-07-19T10:00:00Z","trigger_id":"t1","trigger_source":"file","duration_seconds":1}` + "\n\n",
+		},
+
+		"The events should be filtered by type.": {
+			query: "?types=reload_started,reload_finished",
+			expStream: "id: 1\n" +
+				"event: reload_started\n" +
+				`data: {"type":"reload_started","time":"2021-07-19T10:00:00Z","trigger_id":"t1","trigger_source":"file"}` + "\n\n" +
+				"id: 3\n" +
+				"event: reload_finished\n" +
+				`data: {"type":"reload_finished","time":"2021-07-19T10:00:00Z","trigger_id":"t1","trigger_source":"file","duration_seconds":1}` + "\n\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			h, err := reloadhttp.NewAdminHandler(reloadhttp.AdminHandlerConfig{})
+			require.NoError(err)
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events"+test.query, nil)
+			require.NoError(err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(err)
+			defer resp.Body.Close()
+
+			// Execute.
+			for _, e := range events {
+				h.HandleEvent(context.TODO(), e)
+			}
+
+			// Check.
+			assert.Equal(http.StatusOK, resp.StatusCode)
+			assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+			var got strings.Builder
+			r := bufio.NewReader(resp.Body)
+			for got.Len() < len(test.expStream) {
+				line, err := r.ReadString('\n')
+				require.NoError(err)
+				got.WriteString(line)
+			}
+			assert.Equal(test.expStream, got.String())
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package reloadhttp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/slok/reload"
+)
+
+const (
+	gitHubEventPush    = "push"
+	gitHubEventRelease = "release"
+	gitHubEventPing    = "ping"
+
+	// GitHub webhooks payloads are capped to 25MB.
+	gitHubMaxPayloadSize = 25 << 20
+)
+
+// GitHubWebhookNotifierConfig is the configuration of the GitHubWebhookNotifier.
+type GitHubWebhookNotifierConfig struct {
+	// Secret is the webhook secret used to validate the `X-Hub-Signature-256` header.
+	Secret string
+	// Branches are the branches that will trigger a reload on push events.
+	// By default all the branches.
+	Branches []string
+	// Paths are glob patterns (`path.Match` format) of the changed files that
+	// will trigger a reload on push events.
+	// By default any change.
+	Paths []string
+	// DisableRelease will ignore the release events.
+	DisableRelease bool
+}
+
+func (c *GitHubWebhookNotifierConfig) defaults() error {
+	if c.Secret == "" {
+		return fmt.Errorf("secret is required")
+	}
+
+	for _, p := range c.Paths {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid %q path pattern: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+// GitHubWebhookNotifier is an HTTP handler and a reload.Notifier that triggers
+// a reload when GitHub push or release webhooks are received.
+//
+// Push events trigger with the commit SHA as the trigger ID and the changed
+// files as the trigger paths. Release events trigger with the tag name as the
+// trigger ID.
+//
+// If multiple webhooks are received while the manager is busy, only the
+// latest one will be triggered.
+type GitHubWebhookNotifier struct {
+	cfg GitHubWebhookNotifierConfig
+	c   chan reload.TriggerEvent
+}
+
+// NewGitHubWebhookNotifier returns a new GitHubWebhookNotifier.
+func NewGitHubWebhookNotifier(cfg GitHubWebhookNotifierConfig) (*GitHubWebhookNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &GitHubWebhookNotifier{
+		cfg: cfg,
+		c:   make(chan reload.TriggerEvent, 1),
+	}, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (g *GitHubWebhookNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := g.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (g *GitHubWebhookNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	select {
+	case <-ctx.Done():
+		return reload.TriggerEvent{}, ctx.Err()
+	case t := <-g.c:
+		return t, nil
+	}
+}
+
+// ServeHTTP satisfies http.Handler interface.
+func (g *GitHubWebhookNotifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, gitHubMaxPayloadSize))
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+
+	if !g.validSignature(r.Header.Get("X-Hub-Signature-256"), body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var t *reload.TriggerEvent
+	switch r.Header.Get("X-GitHub-Event") {
+	case gitHubEventPing:
+		w.WriteHeader(http.StatusOK)
+		return
+	case gitHubEventPush:
+		t, err = g.pushTrigger(body)
+	case gitHubEventRelease:
+		if !g.cfg.DisableRelease {
+			t, err = g.releaseTrigger(body)
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Ignored event.
+	if t == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	g.trigger(*t)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (g *GitHubWebhookNotifier) validSignature(signature string, body []byte) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(g.cfg.Secret))
+	_, _ = mac.Write(body)
+
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// trigger sends the trigger replacing the pending one, if any.
+func (g *GitHubWebhookNotifier) trigger(t reload.TriggerEvent) {
+	for {
+		select {
+		case g.c <- t:
+			return
+		default:
+		}
+
+		// Drop the pending trigger.
+		select {
+		case <-g.c:
+		default:
+		}
+	}
+}
+
+type gitHubPushPayload struct {
+	Ref     string `json:"ref"`
+	After   string `json:"after"`
+	Deleted bool   `json:"deleted"`
+	Commits []struct {
+		Added    []string `json:"added"`
+		Removed  []string `json:"removed"`
+		Modified []string `json:"modified"`
+	} `json:"commits"`
+}
+
+func (g *GitHubWebhookNotifier) pushTrigger(body []byte) (*reload.TriggerEvent, error) {
+	var p gitHubPushPayload
+	err := json.Unmarshal(body, &p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid push payload: %w", err)
+	}
+
+	if p.Deleted {
+		return nil, nil
+	}
+
+	if len(g.cfg.Branches) > 0 {
+		branch, ok := strings.CutPrefix(p.Ref, "refs/heads/")
+		if !ok || !slices.Contains(g.cfg.Branches, branch) {
+			return nil, nil
+		}
+	}
+
+	changed := map[string]struct{}{}
+	for _, c := range p.Commits {
+		for _, files := range [][]string{c.Added, c.Removed, c.Modified} {
+			for _, f := range files {
+				if g.matchPath(f) {
+					changed[f] = struct{}{}
+				}
+			}
+		}
+	}
+
+	if len(g.cfg.Paths) > 0 && len(changed) == 0 {
+		return nil, nil
+	}
+
+	paths := make([]string, 0, len(changed))
+	for f := range changed {
+		paths = append(paths, f)
+	}
+	sort.Strings(paths)
+
+	return &reload.TriggerEvent{ID: p.After, Paths: paths}, nil
+}
+
+func (g *GitHubWebhookNotifier) matchPath(f string) bool {
+	if len(g.cfg.Paths) == 0 {
+		return true
+	}
+
+	for _, p := range g.cfg.Paths {
+		if ok, _ := path.Match(p, f); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type gitHubReleasePayload struct {
+	Action  string `json:"action"`
+	Release struct {
+		TagName string `json:"tag_name"`
+	} `json:"release"`
+}
+
+func (g *GitHubWebhookNotifier) releaseTrigger(body []byte) (*reload.TriggerEvent, error) {
+	var p gitHubReleasePayload
+	err := json.Unmarshal(body, &p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release payload: %w", err)
+	}
+
+	if p.Action != "published" {
+		return nil, nil
+	}
+
+	return &reload.TriggerEvent{ID: p.Release.TagName}, nil
+}
@@ -0,0 +1,194 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func signGitHub(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestGitHubWebhookNotifier(t *testing.T) {
+	const pushBody = `{
+	"ref": "refs/heads/main",
+	"after": "1234567890abcdef",
+	"commits": [
+		{"added": ["config/app.yaml"], "removed": [], "modified": ["README.md"]},
+		{"added": [], "removed": ["config/old.yaml"], "modified": ["config/app.yaml"]}
+	]
+}`
+
+	tests := map[string]struct {
+		config     reloadhttp.GitHubWebhookNotifierConfig
+		event      string
+		body       string
+		signature  func(body string) string
+		expCode    int
+		expTrigger *reload.TriggerEvent
+	}{
+		"An invalid signature should be rejected.": {
+			config:    reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"},
+			event:     "push",
+			body:      pushBody,
+			signature: func(body string) string { return signGitHub("wrong", body) },
+			expCode:   http.StatusUnauthorized,
+		},
+
+		"A missing signature should be rejected.": {
+			config:    reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"},
+			event:     "push",
+			body:      pushBody,
+			signature: func(body string) string { return "" },
+			expCode:   http.StatusUnauthorized,
+		},
+
+		"A ping should be accepted without triggering.": {
+			config:  reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"},
+			event:   "ping",
+			body:    `{}`,
+			expCode: http.StatusOK,
+		},
+
+		"A push should trigger with the commit SHA and the changed files.": {
+			config:     reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"},
+			event:      "push",
+			body:       pushBody,
+			expCode:    http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "1234567890abcdef", Paths: []string{"README.md", "config/app.yaml", "config/old.yaml"}},
+		},
+
+		"A push on a not configured branch should be ignored.": {
+			config:  reloadhttp.GitHubWebhookNotifierConfig{Secret: "test", Branches: []string{"production"}},
+			event:   "push",
+			body:    pushBody,
+			expCode: http.StatusNoContent,
+		},
+
+		"A push on a configured branch should trigger.": {
+			config:     reloadhttp.GitHubWebhookNotifierConfig{Secret: "test", Branches: []string{"production", "main"}},
+			event:      "push",
+			body:       pushBody,
+			expCode:    http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "1234567890abcdef", Paths: []string{"README.md", "config/app.yaml", "config/old.yaml"}},
+		},
+
+		"A push should trigger only with the files matching the paths.": {
+			config:     reloadhttp.GitHubWebhookNotifierConfig{Secret: "test", Paths: []string{"config/*.yaml"}},
+			event:      "push",
+			body:       pushBody,
+			expCode:    http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "1234567890abcdef", Paths: []string{"config/app.yaml", "config/old.yaml"}},
+		},
+
+		"A push without files matching the paths should be ignored.": {
+			config:  reloadhttp.GitHubWebhookNotifierConfig{Secret: "test", Paths: []string{"deploy/*"}},
+			event:   "push",
+			body:    pushBody,
+			expCode: http.StatusNoContent,
+		},
+
+		"A branch deletion push should be ignored.": {
+			config:  reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"},
+			event:   "push",
+			body:    `{"ref": "refs/heads/main", "deleted": true}`,
+			expCode: http.StatusNoContent,
+		},
+
+		"A published release should trigger with the tag.": {
+			config:     reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"},
+			event:      "release",
+			body:       `{"action": "published", "release": {"tag_name": "v1.2.3"}}`,
+			expCode:    http.StatusAccepted,
+			expTrigger: &reload.TriggerEvent{ID: "v1.2.3"},
+		},
+
+		"A not published release should be ignored.": {
+			config:  reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"},
+			event:   "release",
+			body:    `{"action": "created", "release": {"tag_name": "v1.2.3"}}`,
+			expCode: http.StatusNoContent,
+		},
+
+		"Releases should be ignored if disabled.": {
+			config:  reloadhttp.GitHubWebhookNotifierConfig{Secret: "test", DisableRelease: true},
+			event:   "release",
+			body:    `{"action": "published", "release": {"tag_name": "v1.2.3"}}`,
+			expCode: http.StatusNoContent,
+		},
+
+		"An invalid payload should fail.": {
+			config:  reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"},
+			event:   "push",
+			body:    `{`,
+			expCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			n, err := reloadhttp.NewGitHubWebhookNotifier(test.config)
+			require.NoError(err)
+
+			signature := signGitHub(test.config.Secret, test.body)
+			if test.signature != nil {
+				signature = test.signature(test.body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			req.Header.Set("X-GitHub-Event", test.event)
+			req.Header.Set("X-Hub-Signature-256", signature)
+			rec := httptest.NewRecorder()
+			n.ServeHTTP(rec, req)
+
+			assert.Equal(test.expCode, rec.Code)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			gotTrigger, err := n.NotifyTrigger(ctx)
+			if test.expTrigger != nil {
+				require.NoError(err)
+				assert.Equal(*test.expTrigger, gotTrigger)
+			} else {
+				assert.Error(err)
+			}
+		})
+	}
+}
+
+func TestGitHubWebhookNotifierLatestWins(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	n, err := reloadhttp.NewGitHubWebhookNotifier(reloadhttp.GitHubWebhookNotifierConfig{Secret: "test"})
+	require.NoError(err)
+
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		body := `{"action": "published", "release": {"tag_name": "` + tag + `"}}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", "release")
+		req.Header.Set("X-Hub-Signature-256", signGitHub("test", body))
+		n.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	id, err := n.Notify(context.Background())
+	require.NoError(err)
+	assert.Equal("v3", id)
+}
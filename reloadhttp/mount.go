@@ -0,0 +1,70 @@
+package reloadhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/slok/reload"
+)
+
+// MountConfig is the configuration of Mount.
+type MountConfig struct {
+	// Token is the token required to use the endpoints as a bearer token.
+	Token string
+	// Authorizer authorizes the requests in addition to the token. If neither
+	// the token nor the authorizer are set, the endpoints will not be
+	// protected. See AdminHandlerConfig.Authorizer.
+	Authorizer AdminAuthorizer
+	// HistorySize is the number of finished reloads that will be kept on the
+	// history.
+	// By default 50.
+	HistorySize int
+	// Metrics is the handler of the metrics endpoint (e.g: `promhttp.Handler()`),
+	// if not set the metrics endpoint is disabled.
+	Metrics http.Handler
+}
+
+// Mount registers the admin endpoints of the manager on an existing mux under
+// the prefix (e.g: `/reload`), so the servers of the applications get the admin
+// endpoints with a single call. See AdminHandler for the endpoints.
+//
+// The reloads are triggered synchronously using the manager and the handler is
+// subscribed to the manager events, so it doesn't need to be registered on the
+// manager.
+func Mount(mux *http.ServeMux, prefix string, m *reload.Manager, cfg MountConfig) (*AdminHandler, error) {
+	if mux == nil {
+		return nil, fmt.Errorf("mux is required")
+	}
+	if m == nil {
+		return nil, fmt.Errorf("manager is required")
+	}
+
+	a, err := NewAdminHandler(AdminHandlerConfig{
+		Token:               cfg.Token,
+		Authorizer:          cfg.Authorizer,
+		HistorySize:         cfg.HistorySize,
+		Trigger:             m.TriggerReload,
+		Rollback:            m.RollbackTo,
+		ResetCircuitBreaker: m.ResetCircuitBreaker,
+		EnableReloader:      m.EnableReloader,
+		DisableReloader:     m.DisableReloader,
+		Approve:             m.Approve,
+		Reject:              m.Reject,
+		Validate:            m.Validate,
+		Metrics:             cfg.Metrics,
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.Subscribe(a)
+
+	prefix = "/" + strings.Trim(prefix, "/")
+	if prefix == "/" {
+		mux.Handle("/", a)
+		return a, nil
+	}
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, a))
+
+	return a, nil
+}
@@ -0,0 +1,125 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func TestMount(t *testing.T) {
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("reload_in_progress 0\n"))
+	})
+
+	tests := map[string]struct {
+		prefix    string
+		cfg       reloadhttp.MountConfig
+		method    string
+		path      string
+		auth      string
+		expStatus int
+		expBody   string
+		expInBody string
+		expReload bool
+	}{
+		"A trigger under the prefix should reload synchronously.": {
+			prefix:    "/reload/",
+			method:    http.MethodPost,
+			path:      "/reload/trigger",
+			expStatus: http.StatusOK,
+			expBody:   `{"id":"t1"}`,
+			expReload: true,
+		},
+
+		"The history under the prefix should have the triggered reloads.": {
+			prefix:    "reload",
+			method:    http.MethodGet,
+			path:      "/reload/history",
+			expStatus: http.StatusOK,
+			expInBody: `"trigger_id":"t0"`,
+		},
+
+		"The metrics should be served by the metrics handler.": {
+			prefix:    "/reload",
+			cfg:       reloadhttp.MountConfig{Metrics: metrics},
+			method:    http.MethodGet,
+			path:      "/reload/metrics",
+			expStatus: http.StatusOK,
+			expBody:   "reload_in_progress 0",
+		},
+
+		"The metrics without metrics handler should respond with a JSON error.": {
+			prefix:    "/reload",
+			method:    http.MethodGet,
+			path:      "/reload/metrics",
+			expStatus: http.StatusNotImplemented,
+			expBody:   `{"error":"metrics not enabled"}`,
+		},
+
+		"An empty prefix should mount the endpoints on the root.": {
+			method:    http.MethodPost,
+			path:      "/trigger",
+			expStatus: http.StatusOK,
+			expBody:   `{"id":"t1"}`,
+			expReload: true,
+		},
+
+		"An invalid token should respond with a JSON error.": {
+			prefix:    "/reload",
+			cfg:       reloadhttp.MountConfig{Token: "secret"},
+			method:    http.MethodPost,
+			path:      "/reload/trigger",
+			auth:      "Bearer nope",
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"invalid token"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var reloads []string
+			m := reload.NewManager()
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				reloads = append(reloads, id)
+				return nil
+			}))
+			mux := http.NewServeMux()
+			_, err := reloadhttp.Mount(mux, test.prefix, &m, test.cfg)
+			require.NoError(err)
+			require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t0"}))
+
+			// Execute.
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(test.method, test.path, strings.NewReader(`{"id":"t1"}`))
+			if test.auth != "" {
+				r.Header.Set("Authorization", test.auth)
+			}
+			mux.ServeHTTP(w, r)
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			if test.expInBody != "" {
+				assert.Contains(w.Body.String(), test.expInBody)
+			} else {
+				assert.Equal(test.expBody, strings.TrimSpace(w.Body.String()))
+			}
+			expReloads := []string{"t0"}
+			if test.expReload {
+				expReloads = append(expReloads, "t1")
+			}
+			assert.Equal(expReloads, reloads)
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package reloadhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/internal/backoff"
+)
+
+// OutcomeFormat is the payload format of an outcome endpoint.
+type OutcomeFormat string
+
+const (
+	// OutcomeFormatJSON sends the OutcomeSummary as JSON.
+	OutcomeFormatJSON OutcomeFormat = "json"
+	// OutcomeFormatSlack sends a Slack incoming webhook message with the
+	// summary as text.
+	OutcomeFormatSlack OutcomeFormat = "slack"
+)
+
+// OutcomeEndpoint is an endpoint where the OutcomeSink posts the reload
+// outcomes.
+type OutcomeEndpoint struct {
+	// URL is the endpoint URL.
+	URL string
+	// Format is the payload format.
+	// By default OutcomeFormatJSON.
+	Format OutcomeFormat
+	// Header are additional headers sent on the requests (e.g: authorization).
+	Header http.Header
+}
+
+// OutcomeSinkConfig is the configuration of the OutcomeSink.
+type OutcomeSinkConfig struct {
+	// Endpoints are the endpoints where the outcomes are posted.
+	Endpoints []OutcomeEndpoint
+	// OnlyFailures will only post the outcomes of the failed reloads.
+	OnlyFailures bool
+	// Client is the HTTP client used for the requests.
+	// By default `http.DefaultClient`.
+	Client *http.Client
+	// Timeout is the time limit of every request attempt.
+	// By default 10s.
+	Timeout time.Duration
+	// Retries is the number of retries after a failed attempt, the network
+	// errors, 429 and 5xx responses are retried.
+	// By default 3, use a negative number to disable the retries.
+	Retries int
+	// MinBackoff is the initial wait time before retrying.
+	// By default 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum wait time before retrying.
+	// By default 5s.
+	MaxBackoff time.Duration
+	// OnError is called when an outcome could not be posted to an endpoint,
+	// optional.
+	OnError func(ctx context.Context, url string, err error)
+}
+
+func (c *OutcomeSinkConfig) defaults() error {
+	if len(c.Endpoints) == 0 {
+		return fmt.Errorf("at least one endpoint is required")
+	}
+
+	for i, e := range c.Endpoints {
+		if e.URL == "" {
+			return fmt.Errorf("endpoint %d url is required", i)
+		}
+
+		switch e.Format {
+		case "":
+			c.Endpoints[i].Format = OutcomeFormatJSON
+		case OutcomeFormatJSON, OutcomeFormatSlack:
+		default:
+			return fmt.Errorf("unknown %q format", e.Format)
+		}
+	}
+
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	if c.Retries == 0 {
+		c.Retries = 3
+	}
+
+	if c.Retries < 0 {
+		c.Retries = 0
+	}
+
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 250 * time.Millisecond
+	}
+
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 5 * time.Second
+	}
+
+	if c.MaxBackoff < c.MinBackoff {
+		return fmt.Errorf("max backoff can't be lower than min backoff")
+	}
+
+	if c.OnError == nil {
+		c.OnError = func(context.Context, string, error) {}
+	}
+
+	return nil
+}
+
+// OutcomeSummary is the summary of a reload posted by the OutcomeSink.
+type OutcomeSummary struct {
+	TriggerID       string                `json:"trigger_id"`
+	TriggerSource   string                `json:"trigger_source"`
+	TriggerReason   string                `json:"trigger_reason,omitempty"`
+	TriggerMetadata map[string]string     `json:"trigger_metadata,omitempty"`
+	Outcome         reload.AuditOutcome   `json:"outcome"`
+	Time            time.Time             `json:"time"`
+	DurationSeconds float64               `json:"duration_seconds"`
+	Error           string                `json:"error,omitempty"`
+	FailedReloaders []OutcomeReloaderFail `json:"failed_reloaders,omitempty"`
+}
+
+// OutcomeReloaderFail is a failed reloader of an OutcomeSummary.
+type OutcomeReloaderFail struct {
+	Name     string                `json:"name"`
+	Priority int                   `json:"priority"`
+	Status   reload.ReloaderStatus `json:"status"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// OutcomeSink is a reload.Subscriber that posts a summary of every reload
+// outcome (see OutcomeSummary) to the configured endpoints (e.g: a Slack
+// incoming webhook or a generic endpoint), so the teams are alerted about the
+// failed reloads without scraping the logs.
+//
+// The outcomes are posted in background so the reloads are not blocked, and
+// the failed requests are retried with exponential backoff. Use Wait to wait
+// for the in-flight outcomes (e.g: on shutdown).
+//
+// It's also a reload.AlertSink, to post only the alerts of a reload.Alerter
+// (failures, recoveries and repeated skips) don't subscribe it to the manager.
+type OutcomeSink struct {
+	cfg OutcomeSinkConfig
+	wg  sync.WaitGroup
+}
+
+var (
+	_ reload.Subscriber = &OutcomeSink{}
+	_ reload.AlertSink  = &OutcomeSink{}
+)
+
+// NewOutcomeSink returns a new OutcomeSink.
+func NewOutcomeSink(cfg OutcomeSinkConfig) (*OutcomeSink, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &OutcomeSink{cfg: cfg}, nil
+}
+
+// HandleEvent satisfies reload.Subscriber interface.
+func (o *OutcomeSink) HandleEvent(ctx context.Context, e reload.Event) {
+	if e.Type != reload.EventReloadFinished || (o.cfg.OnlyFailures && e.Err == nil) {
+		return
+	}
+
+	s := newOutcomeSummary(e)
+	o.postAll(ctx, s, s.slackText())
+}
+
+// SendAlert satisfies reload.AlertSink interface, the alerts (see
+// OutcomeAlert) are posted in background like the outcomes so the errors are
+// reported with OnError.
+func (o *OutcomeSink) SendAlert(ctx context.Context, a reload.Alert) error {
+	oa := newOutcomeAlert(a)
+	o.postAll(ctx, oa, oa.slackText())
+	return nil
+}
+
+// postAll posts the payload to all the endpoints in background.
+func (o *OutcomeSink) postAll(ctx context.Context, payload any, slackText string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, ep := range o.cfg.Endpoints {
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+			var p any = payload
+			if ep.Format == OutcomeFormatSlack {
+				p = map[string]string{"text": slackText}
+			}
+			err := o.post(ctx, ep, p)
+			if err != nil {
+				o.cfg.OnError(ctx, ep.URL, err)
+			}
+		}()
+	}
+}
+
+// Wait waits until the in-flight outcomes have been posted.
+func (o *OutcomeSink) Wait() {
+	o.wg.Wait()
+}
+
+func newOutcomeSummary(e reload.Event) OutcomeSummary {
+	s := OutcomeSummary{
+		TriggerID:       e.Trigger.ID,
+		TriggerSource:   e.Trigger.Source,
+		TriggerReason:   e.Trigger.Reason,
+		TriggerMetadata: e.Trigger.Metadata,
+		Outcome:         reload.AuditOutcomeSuccess,
+		Time:            e.Time.UTC(),
+		DurationSeconds: e.Duration.Seconds(),
+	}
+
+	if e.Err != nil {
+		s.Outcome = reload.AuditOutcomeFailure
+		s.Error = e.Err.Error()
+
+		var rErr *reload.ReloadError
+		if errors.As(e.Err, &rErr) {
+			s.FailedReloaders = newOutcomeReloaderFails(rErr.Failed())
+		}
+	}
+
+	return s
+}
+
+func newOutcomeReloaderFails(reports []reload.ReloaderReport) []OutcomeReloaderFail {
+	var fails []OutcomeReloaderFail
+	for _, r := range reports {
+		f := OutcomeReloaderFail{Name: r.Name, Priority: r.Priority, Status: r.Status}
+		if r.Err != nil {
+			f.Error = r.Err.Error()
+		}
+		fails = append(fails, f)
+	}
+
+	return fails
+}
+
+// OutcomeAlert is an alert posted by the OutcomeSink (see reload.Alerter).
+type OutcomeAlert struct {
+	Alert           reload.AlertKind      `json:"alert"`
+	TriggerID       string                `json:"trigger_id"`
+	TriggerSource   string                `json:"trigger_source"`
+	TriggerReason   string                `json:"trigger_reason,omitempty"`
+	TriggerMetadata map[string]string     `json:"trigger_metadata,omitempty"`
+	Time            time.Time             `json:"time"`
+	Error           string                `json:"error,omitempty"`
+	FailedReloaders []OutcomeReloaderFail `json:"failed_reloaders,omitempty"`
+	Failures        int                   `json:"failures,omitempty"`
+	Skips           int                   `json:"skips,omitempty"`
+}
+
+func newOutcomeAlert(a reload.Alert) OutcomeAlert {
+	oa := OutcomeAlert{
+		Alert:           a.Kind,
+		TriggerID:       a.Trigger.ID,
+		TriggerSource:   a.Trigger.Source,
+		TriggerReason:   a.Trigger.Reason,
+		TriggerMetadata: a.Trigger.Metadata,
+		Time:            a.Time.UTC(),
+		FailedReloaders: newOutcomeReloaderFails(a.FailedReloaders),
+		Failures:        a.Failures,
+		Skips:           a.Skips,
+	}
+	if a.Err != nil {
+		oa.Error = a.Err.Error()
+	}
+
+	return oa
+}
+
+// slackText returns the alert as a Slack message text.
+func (a OutcomeAlert) slackText() string {
+	var b strings.Builder
+	switch a.Alert {
+	case reload.AlertReloadFailed:
+		fmt.Fprintf(&b, ":rotating_light: Reload `%s` (%s) failed (%d consecutive failures): %s", a.TriggerID, a.TriggerSource, a.Failures, a.Error)
+		for _, r := range a.FailedReloaders {
+			fmt.Fprintf(&b, "\n• `%s` %s: %s", r.Name, r.Status, r.Error)
+		}
+	case reload.AlertReloadsSkipped:
+		fmt.Fprintf(&b, ":warning: %d consecutive reloads skipped, last `%s` (%s)", a.Skips, a.TriggerID, a.TriggerSource)
+	default:
+		fmt.Fprintf(&b, ":white_check_mark: Reloads recovered with `%s` (%s)", a.TriggerID, a.TriggerSource)
+	}
+	if a.TriggerReason != "" {
+		fmt.Fprintf(&b, "\nReason: %s", a.TriggerReason)
+	}
+
+	return b.String()
+}
+
+// slackText returns the summary as a Slack message text.
+func (s OutcomeSummary) slackText() string {
+	var b strings.Builder
+	duration := time.Duration(s.DurationSeconds * float64(time.Second)).Round(time.Millisecond)
+	if s.Outcome == reload.AuditOutcomeSuccess {
+		fmt.Fprintf(&b, ":white_check_mark: Reload `%s` (%s) succeeded in %s", s.TriggerID, s.TriggerSource, duration)
+	} else {
+		fmt.Fprintf(&b, ":x: Reload `%s` (%s) failed in %s: %s", s.TriggerID, s.TriggerSource, duration, s.Error)
+		for _, r := range s.FailedReloaders {
+			fmt.Fprintf(&b, "\n• `%s` %s: %s", r.Name, r.Status, r.Error)
+		}
+	}
+	if s.TriggerReason != "" {
+		fmt.Fprintf(&b, "\nReason: %s", s.TriggerReason)
+	}
+
+	return b.String()
+}
+
+// post posts the payload to the endpoint retrying the failed attempts.
+func (o *OutcomeSink) post(ctx context.Context, ep OutcomeEndpoint, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not encode outcome: %w", err)
+	}
+
+	b := backoff.New(o.cfg.MinBackoff, o.cfg.MaxBackoff)
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			b.Wait(ctx)
+		}
+
+		var retry bool
+		retry, err = o.send(ctx, ep, body)
+		if err == nil || !retry || attempt >= o.cfg.Retries {
+			return err
+		}
+	}
+}
+
+// send sends the outcome to the endpoint, it returns if the failure can be
+// retried.
+func (o *OutcomeSink) send(ctx context.Context, ep OutcomeEndpoint, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, o.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("could not create request: %w", err)
+	}
+	for k, vs := range ep.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.cfg.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("could not post outcome: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("could not post outcome: unexpected status code %d", resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return false, fmt.Errorf("could not post outcome: unexpected status code %d", resp.StatusCode)
+	}
+
+	return false, nil
+}
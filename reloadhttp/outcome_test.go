@@ -0,0 +1,201 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func TestOutcomeSink(t *testing.T) {
+	tests := map[string]struct {
+		format       reloadhttp.OutcomeFormat
+		onlyFailures bool
+		reloadErr    error
+		statusCodes  []int
+		expBodies    []map[string]any
+		expErr       bool
+	}{
+		"A successful reload should be posted.": {
+			expBodies: []map[string]any{{
+				"trigger_id":     "t1",
+				"trigger_source": "manual",
+				"outcome":        "success",
+			}},
+		},
+
+		"A failed reload should be posted with the failed reloaders.": {
+			reloadErr: fmt.Errorf("something"),
+			expBodies: []map[string]any{{
+				"trigger_id":     "t1",
+				"trigger_source": "manual",
+				"outcome":        "failure",
+				"error":          `reload "t1" failed: group (priority 0): reloader "config" (1/1): something`,
+				"failed_reloaders": []any{map[string]any{
+					"name":     "config",
+					"priority": float64(0),
+					"status":   "failed",
+					"error":    "something",
+				}},
+			}},
+		},
+
+		"A successful reload should not be posted when only the failures are.": {
+			onlyFailures: true,
+		},
+
+		"The failed posts should be retried.": {
+			statusCodes: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			expBodies: []map[string]any{
+				{"trigger_id": "t1", "trigger_source": "manual", "outcome": "success"},
+				{"trigger_id": "t1", "trigger_source": "manual", "outcome": "success"},
+				{"trigger_id": "t1", "trigger_source": "manual", "outcome": "success"},
+			},
+		},
+
+		"The client errors should not be retried.": {
+			statusCodes: []int{http.StatusBadRequest, http.StatusOK},
+			expBodies: []map[string]any{
+				{"trigger_id": "t1", "trigger_source": "manual", "outcome": "success"},
+			},
+			expErr: true,
+		},
+
+		"A failed reload should be posted as a Slack message.": {
+			format:    reloadhttp.OutcomeFormatSlack,
+			reloadErr: fmt.Errorf("something"),
+			expBodies: []map[string]any{{
+				"text": ":x: Reload `t1` (manual) failed in 0s: reload \"t1\" failed: group (priority 0): reloader \"config\" (1/1): something\n• `config` failed: something",
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var mu sync.Mutex
+			var gotBodies []map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				data, _ := io.ReadAll(r.Body)
+				var body map[string]any
+				_ = json.Unmarshal(data, &body)
+				// Remove the non deterministic fields.
+				delete(body, "time")
+				delete(body, "duration_seconds")
+				gotBodies = append(gotBodies, body)
+
+				if len(test.statusCodes) >= len(gotBodies) {
+					w.WriteHeader(test.statusCodes[len(gotBodies)-1])
+				}
+			}))
+			defer srv.Close()
+
+			var gotErrs []error
+			sink, err := reloadhttp.NewOutcomeSink(reloadhttp.OutcomeSinkConfig{
+				Endpoints:    []reloadhttp.OutcomeEndpoint{{URL: srv.URL, Format: test.format}},
+				OnlyFailures: test.onlyFailures,
+				MinBackoff:   time.Millisecond,
+				MaxBackoff:   time.Millisecond,
+				OnError:      func(ctx context.Context, url string, err error) { gotErrs = append(gotErrs, err) },
+			})
+			require.NoError(err)
+
+			m := reload.NewManager(reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+				// Make the duration deterministic.
+				e.Duration = 0
+				sink.HandleEvent(ctx, e)
+			})))
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return test.reloadErr }), reload.WithReloaderName("config"))
+
+			// Execute.
+			_ = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+			sink.Wait()
+
+			// Check.
+			assert.Equal(test.expBodies, gotBodies)
+			assert.Equal(test.expErr, len(gotErrs) > 0)
+		})
+	}
+}
+
+func TestOutcomeSinkSendAlert(t *testing.T) {
+	tests := map[string]struct {
+		format  reloadhttp.OutcomeFormat
+		alert   reload.Alert
+		expBody map[string]any
+	}{
+		"A failed reload alert should be posted.": {
+			alert: reload.Alert{
+				Kind:            reload.AlertReloadFailed,
+				Trigger:         reload.TriggerEvent{ID: "t1", Source: "file"},
+				Err:             fmt.Errorf("something"),
+				FailedReloaders: []reload.ReloaderReport{{Name: "config", Status: reload.ReloaderFailed, Err: fmt.Errorf("something")}},
+				Failures:        2,
+			},
+			expBody: map[string]any{
+				"alert":          "reload_failed",
+				"trigger_id":     "t1",
+				"trigger_source": "file",
+				"error":          "something",
+				"failures":       float64(2),
+				"failed_reloaders": []any{map[string]any{
+					"name":     "config",
+					"priority": float64(0),
+					"status":   "failed",
+					"error":    "something",
+				}},
+			},
+		},
+
+		"A skipped reloads alert should be posted as a Slack message.": {
+			format:  reloadhttp.OutcomeFormatSlack,
+			alert:   reload.Alert{Kind: reload.AlertReloadsSkipped, Trigger: reload.TriggerEvent{ID: "t1", Source: "file"}, Skips: 3},
+			expBody: map[string]any{"text": ":warning: 3 consecutive reloads skipped, last `t1` (file)"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var gotBody map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&gotBody)
+				delete(gotBody, "time")
+			}))
+			defer srv.Close()
+
+			sink, err := reloadhttp.NewOutcomeSink(reloadhttp.OutcomeSinkConfig{
+				Endpoints: []reloadhttp.OutcomeEndpoint{{URL: srv.URL, Format: test.format}},
+			})
+			require.NoError(err)
+
+			// Execute.
+			err = sink.SendAlert(context.TODO(), test.alert)
+			sink.Wait()
+
+			// Check.
+			require.NoError(err)
+			assert.Equal(test.expBody, gotBody)
+		})
+	}
+}
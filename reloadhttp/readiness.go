@@ -0,0 +1,101 @@
+package reloadhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// ReadinessHandlerConfig is the configuration of the ReadinessHandler.
+type ReadinessHandlerConfig struct {
+	// FailOpen makes the handler report ready even when the last reload failed,
+	// the failure is still reported on the body. Useful when the previous
+	// configuration is safe to keep serving.
+	// By default false (fail-closed).
+	FailOpen bool
+}
+
+func (c *ReadinessHandlerConfig) defaults() error {
+	return nil
+}
+
+// ReadinessHandler is an `http.Handler` suitable for Kubernetes readiness probes
+// that reflects the reload state, it's a reload.Subscriber so it needs to be
+// registered on the manager using `reload.WithSubscriber`.
+//
+// It responds with `503` when the last reload failed (unless fail-open is
+// enabled) and `200` otherwise. The body has the last trigger and error as
+// JSON for debugging.
+type ReadinessHandler struct {
+	cfg ReadinessHandlerConfig
+
+	mu      sync.Mutex
+	trigger *reload.TriggerEvent
+	at      time.Time
+	err     error
+}
+
+var _ reload.Subscriber = &ReadinessHandler{}
+
+// NewReadinessHandler returns a new ReadinessHandler.
+func NewReadinessHandler(cfg ReadinessHandlerConfig) (*ReadinessHandler, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &ReadinessHandler{cfg: cfg}, nil
+}
+
+// HandleEvent satisfies reload.Subscriber interface.
+func (h *ReadinessHandler) HandleEvent(_ context.Context, e reload.Event) {
+	if e.Type != reload.EventReloadFinished {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := e.Trigger
+	h.trigger = &t
+	h.at = e.Time
+	h.err = e.Err
+}
+
+type readinessResponse struct {
+	Ready         bool       `json:"ready"`
+	TriggerID     string     `json:"trigger_id,omitempty"`
+	TriggerSource string     `json:"trigger_source,omitempty"`
+	ReloadedAt    *time.Time `json:"reloaded_at,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// ServeHTTP satisfies http.Handler interface.
+func (h *ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	resp := readinessResponse{Ready: h.err == nil || h.cfg.FailOpen}
+	if h.trigger != nil {
+		at := h.at.UTC()
+		resp.TriggerID = h.trigger.ID
+		resp.TriggerSource = h.trigger.Source
+		resp.ReloadedAt = &at
+	}
+	if h.err != nil {
+		resp.Error = h.err.Error()
+	}
+	h.mu.Unlock()
+
+	status := http.StatusOK
+	if !resp.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
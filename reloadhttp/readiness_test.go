@@ -0,0 +1,90 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	at := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	trigger := reload.TriggerEvent{ID: "test-id", Source: "file"}
+
+	tests := map[string]struct {
+		cfg       reloadhttp.ReadinessHandlerConfig
+		events    []reload.Event
+		expStatus int
+		expBody   string
+	}{
+		"Without reloads it should be ready.": {
+			expStatus: http.StatusOK,
+			expBody:   `{"ready":true}`,
+		},
+
+		"A successful reload should be ready.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadStarted, Trigger: trigger, Time: at},
+				{Type: reload.EventReloadFinished, Trigger: trigger, Time: at},
+			},
+			expStatus: http.StatusOK,
+			expBody:   `{"ready":true,"trigger_id":"test-id","trigger_source":"file","reloaded_at":"2021-07-19T10:00:00Z"}`,
+		},
+
+		"A failed reload should not be ready.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadFinished, Trigger: trigger, Time: at, Err: fmt.Errorf("something")},
+			},
+			expStatus: http.StatusServiceUnavailable,
+			expBody:   `{"ready":false,"trigger_id":"test-id","trigger_source":"file","reloaded_at":"2021-07-19T10:00:00Z","error":"something"}`,
+		},
+
+		"A failed reload with fail-open should be ready.": {
+			cfg: reloadhttp.ReadinessHandlerConfig{FailOpen: true},
+			events: []reload.Event{
+				{Type: reload.EventReloadFinished, Trigger: trigger, Time: at, Err: fmt.Errorf("something")},
+			},
+			expStatus: http.StatusOK,
+			expBody:   `{"ready":true,"trigger_id":"test-id","trigger_source":"file","reloaded_at":"2021-07-19T10:00:00Z","error":"something"}`,
+		},
+
+		"A successful reload after a failed reload should be ready.": {
+			events: []reload.Event{
+				{Type: reload.EventReloadFinished, Trigger: trigger, Time: at, Err: fmt.Errorf("something")},
+				{Type: reload.EventReloadFinished, Trigger: reload.TriggerEvent{ID: "test-id2", Source: "sighup"}, Time: at},
+			},
+			expStatus: http.StatusOK,
+			expBody:   `{"ready":true,"trigger_id":"test-id2","trigger_source":"sighup","reloaded_at":"2021-07-19T10:00:00Z"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			h, err := reloadhttp.NewReadinessHandler(test.cfg)
+			require.NoError(err)
+
+			// Execute.
+			for _, e := range test.events {
+				h.HandleEvent(context.TODO(), e)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			// Check.
+			assert.Equal(test.expStatus, w.Code)
+			assert.JSONEq(test.expBody, w.Body.String())
+		})
+	}
+}
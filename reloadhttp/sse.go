@@ -0,0 +1,271 @@
+package reloadhttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/internal/backoff"
+)
+
+// SSENotifierConfig is the configuration of the SSENotifier.
+type SSENotifierConfig struct {
+	// URL is the server-sent events stream endpoint.
+	URL string
+	// Header are additional headers sent on the stream requests (e.g: authorization).
+	Header http.Header
+	// Client is the HTTP client used for the stream requests, it should not
+	// have a timeout as the stream is long lived.
+	// By default a new client without timeout.
+	Client *http.Client
+	// Events are the event types that will trigger a reload.
+	// By default all the events.
+	Events []string
+	// TriggerID is the ID used on the triggers of the events without ID.
+	// By default `sse`.
+	TriggerID string
+	// MinBackoff is the initial wait time before reconnecting, the server can
+	// change it using the `retry` field but never below MinBackoff nor above
+	// MaxBackoff.
+	// By default 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum wait time before reconnecting.
+	// By default 30s.
+	MaxBackoff time.Duration
+}
+
+func (c *SSENotifierConfig) defaults() error {
+	if c.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	if c.Client == nil {
+		c.Client = &http.Client{}
+	}
+
+	if c.TriggerID == "" {
+		c.TriggerID = "sse"
+	}
+
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 250 * time.Millisecond
+	}
+
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 30 * time.Second
+	}
+
+	if c.MaxBackoff < c.MinBackoff {
+		return fmt.Errorf("max backoff can't be lower than min backoff")
+	}
+
+	return nil
+}
+
+// SSENotifier is a reload.Notifier that consumes a server-sent events stream
+// of configuration updates and triggers a reload for each received event.
+//
+// The event ID is used as the trigger ID (or the configured trigger ID if the
+// event doesn't have one), and the event type, ID and data are set on the
+// trigger metadata (`event`, `id` and `data` keys).
+//
+// When the stream breaks, the notifier will reconnect with exponential backoff
+// resuming the stream with the `Last-Event-ID` header.
+//
+// The stream is bound to the context of the Notify call that opened it.
+type SSENotifier struct {
+	cfg         SSENotifierConfig
+	body        io.ReadCloser
+	reader      *bufio.Reader
+	lastEventID string
+	idBuffer    string // Event ID being read, set as the last event ID on dispatch.
+	backoff     *backoff.Backoff
+}
+
+// NewSSENotifier returns a new SSENotifier.
+func NewSSENotifier(cfg SSENotifierConfig) (*SSENotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &SSENotifier{
+		cfg:     cfg,
+		backoff: backoff.New(cfg.MinBackoff, cfg.MaxBackoff),
+	}, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (s *SSENotifier) Notify(ctx context.Context) (string, error) {
+	t, err := s.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (s *SSENotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	for {
+		if ctx.Err() != nil {
+			s.close()
+			return reload.TriggerEvent{}, ctx.Err()
+		}
+
+		// Connect if required.
+		if s.body == nil {
+			err := s.connect(ctx)
+			if err != nil {
+				if errors.Is(err, errSSEPermanent) {
+					return reload.TriggerEvent{}, err
+				}
+				s.backoff.Wait(ctx)
+				continue
+			}
+		}
+
+		ev, err := s.readEvent()
+		if err != nil {
+			s.close()
+			s.backoff.Wait(ctx)
+			continue
+		}
+		s.backoff.Reset()
+
+		if len(s.cfg.Events) > 0 && !slices.Contains(s.cfg.Events, ev.event) {
+			continue
+		}
+
+		id := ev.id
+		if id == "" {
+			id = s.cfg.TriggerID
+		}
+
+		return reload.TriggerEvent{
+			ID: id,
+			Metadata: map[string]string{
+				"event": ev.event,
+				"id":    ev.id,
+				"data":  ev.data,
+			},
+		}, nil
+	}
+}
+
+var errSSEPermanent = errors.New("permanent server-sent events stream error")
+
+func (s *SSENotifier) connect(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.URL, nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w: %w", errSSEPermanent, err)
+	}
+	for k, vs := range s.cfg.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not connect: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	// The server asks the clients to stop reconnecting with a 204.
+	case resp.StatusCode == http.StatusNoContent,
+		resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout:
+		resp.Body.Close()
+		return fmt.Errorf("%w: unexpected %d status code", errSSEPermanent, resp.StatusCode)
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("unexpected %d status code", resp.StatusCode)
+	}
+
+	s.body = resp.Body
+	s.reader = bufio.NewReader(resp.Body)
+	s.idBuffer = s.lastEventID
+	return nil
+}
+
+func (s *SSENotifier) close() {
+	if s.body == nil {
+		return
+	}
+
+	_ = s.body.Close()
+	s.body = nil
+	s.reader = nil
+}
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvent reads the stream until an event is dispatched, following the
+// server-sent events specification.
+func (s *SSENotifier) readEvent() (sseEvent, error) {
+	var ev sseEvent
+	var data []string
+	hasData := false
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return sseEvent{}, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		// Dispatch the event.
+		if line == "" {
+			if !hasData {
+				ev = sseEvent{}
+				continue
+			}
+			ev.data = strings.Join(data, "\n")
+			if ev.event == "" {
+				ev.event = "message"
+			}
+			s.lastEventID = s.idBuffer
+			ev.id = s.lastEventID
+			return ev, nil
+		}
+
+		// Comment.
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.event = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				s.idBuffer = value
+			}
+		case "retry":
+			ms, err := strconv.Atoi(value)
+			if err == nil && ms >= 0 {
+				// Don't let the server make us reconnect in a busy loop.
+				retry := min(max(time.Duration(ms)*time.Millisecond, s.cfg.MinBackoff), s.cfg.MaxBackoff)
+				s.backoff = backoff.New(retry, s.cfg.MaxBackoff)
+			}
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package reloadhttp_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadhttp"
+)
+
+func TestSSENotifier(t *testing.T) {
+	tests := map[string]struct {
+		config      reloadhttp.SSENotifierConfig
+		responses   []string // Each response is a connection, the connection is closed after writing it.
+		expTriggers []reload.TriggerEvent
+		expLastIDs  []string
+	}{
+		"Each event should trigger a reload.": {
+			responses: []string{
+				": comment\n\nid: 1\nevent: patch\ndata: {\"a\": 1}\n\nid: 2\ndata: line1\ndata: line2\n\n",
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "1", Metadata: map[string]string{"event": "patch", "id": "1", "data": `{"a": 1}`}},
+				{ID: "2", Metadata: map[string]string{"event": "message", "id": "2", "data": "line1\nline2"}},
+			},
+			expLastIDs: []string{""},
+		},
+
+		"Events without ID should use the configured trigger ID.": {
+			config: reloadhttp.SSENotifierConfig{TriggerID: "flags"},
+			responses: []string{
+				"data: something\n\n",
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "flags", Metadata: map[string]string{"event": "message", "id": "", "data": "something"}},
+			},
+			expLastIDs: []string{""},
+		},
+
+		"Only the configured events should trigger a reload.": {
+			config: reloadhttp.SSENotifierConfig{Events: []string{"patch"}},
+			responses: []string{
+				"id: 1\nevent: ping\ndata: ping\n\nid: 2\nevent: patch\ndata: something\n\n",
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "2", Metadata: map[string]string{"event": "patch", "id": "2", "data": "something"}},
+			},
+			expLastIDs: []string{""},
+		},
+
+		"A broken stream should reconnect with the last event ID.": {
+			responses: []string{
+				"id: 1\ndata: a\n\nid: 2\ndata: b\n\nid: 3\ndata: incomplete",
+				"id: 3\ndata: c\n\n",
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "1", Metadata: map[string]string{"event": "message", "id": "1", "data": "a"}},
+				{ID: "2", Metadata: map[string]string{"event": "message", "id": "2", "data": "b"}},
+				{ID: "3", Metadata: map[string]string{"event": "message", "id": "3", "data": "c"}},
+			},
+			expLastIDs: []string{"", "2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var mu sync.Mutex
+			var gotLastIDs []string
+			responses := test.responses
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				gotLastIDs = append(gotLastIDs, r.Header.Get("Last-Event-ID"))
+				if len(responses) == 0 {
+					mu.Unlock()
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				resp := responses[0]
+				responses = responses[1:]
+				mu.Unlock()
+
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = fmt.Fprint(w, resp)
+			}))
+			defer srv.Close()
+
+			cfg := test.config
+			cfg.URL = srv.URL
+			cfg.MinBackoff = time.Millisecond
+			cfg.MaxBackoff = 5 * time.Millisecond
+			n, err := reloadhttp.NewSSENotifier(cfg)
+			require.NoError(err)
+
+			// Execute.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			var gotTriggers []reload.TriggerEvent
+			for range test.expTriggers {
+				tr, err := n.NotifyTrigger(ctx)
+				require.NoError(err)
+				gotTriggers = append(gotTriggers, tr)
+			}
+
+			// Check.
+			assert.Equal(test.expTriggers, gotTriggers)
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(test.expLastIDs, gotLastIDs)
+		})
+	}
+}
+
+func TestSSENotifierPermanentError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n, err := reloadhttp.NewSSENotifier(reloadhttp.SSENotifierConfig{URL: srv.URL})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = n.Notify(ctx)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSSENotifierRetryBelowMinBackoff(t *testing.T) {
+	// Prepare.
+	var mu sync.Mutex
+	connections := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		connections++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "retry: 0\n\n")
+	}))
+	defer srv.Close()
+
+	n, err := reloadhttp.NewSSENotifier(reloadhttp.SSENotifierConfig{
+		URL:        srv.URL,
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// Execute.
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+	_, _ = n.NotifyTrigger(ctx)
+
+	// Check.
+	mu.Lock()
+	defer mu.Unlock()
+	assert.LessOrEqual(t, connections, 5)
+}
+
+func TestSSENotifierWithLiveness(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	var mu sync.Mutex
+	connections := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		connections++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := range 3 {
+			_, _ = fmt.Fprintf(w, "id: %d\ndata: something\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	n, err := reloadhttp.NewSSENotifier(reloadhttp.SSENotifierConfig{URL: srv.URL, MinBackoff: time.Second})
+	require.NoError(err)
+	reloaded := make(chan string, 3)
+	m := reload.NewManager()
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		reloaded <- id
+		return nil
+	}))
+	m.On(n, reload.WithNotifierLiveness(reload.NotifierLiveness{Period: time.Minute}))
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	var gotIDs []string
+	for range 3 {
+		select {
+		case id := <-reloaded:
+			gotIDs = append(gotIDs, id)
+		case <-time.After(500 * time.Millisecond):
+			require.Fail("event was not reloaded")
+		}
+	}
+	cancel()
+
+	// Check: the stream is kept between the triggers.
+	assert.NoError(<-runErr)
+	assert.Equal([]string{"0", "1", "2"}, gotIDs)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(1, connections)
+}
@@ -0,0 +1,2 @@
+// Package reloadjsonschema has the JSON Schema integrations of the reload mechanism.
+package reloadjsonschema
@@ -0,0 +1,18 @@
+module github.com/slok/reload/reloadjsonschema
+
+go 1.23
+
+require (
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	github.com/slok/reload v0.0.0
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/slok/reload => ../
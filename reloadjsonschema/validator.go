@@ -0,0 +1,117 @@
+package reloadjsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// FieldError is a JSON schema validation error of a configuration field.
+type FieldError struct {
+	// Field is the path of the offending field (e.g: `server.port`), empty
+	// for the configuration root.
+	Field string
+	// Message is the validation error message.
+	Message string
+}
+
+func (f FieldError) String() string {
+	if f.Field == "" {
+		return f.Message
+	}
+	return f.Field + ": " + f.Message
+}
+
+// ValidationError is the error returned when the configuration doesn't
+// satisfy the JSON schema, it has every offending field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.String())
+	}
+
+	return "schema validation failed: " + strings.Join(msgs, "; ")
+}
+
+// NewValidator returns a configuration validator that validates the JSON
+// representation of the configuration with the JSON schema, it can be used as
+// the `reloadconfig` loaders validation so it runs on every reload before the
+// reloaders get the new configuration.
+//
+// The schema is compiled on the creation, the invalid configurations return
+// a *ValidationError.
+func NewValidator[T any](schema []byte) (func(ctx context.Context, cfg T) error, error) {
+	s, err := jsonschema.CompileString("config.schema.json", string(schema))
+	if err != nil {
+		return nil, fmt.Errorf("could not compile JSON schema: %w", err)
+	}
+
+	return func(_ context.Context, cfg T) error {
+		b, err := json.Marshal(cfg)
+		if err != nil {
+			return fmt.Errorf("could not encode configuration: %w", err)
+		}
+
+		var v any
+		err = json.Unmarshal(b, &v)
+		if err != nil {
+			return fmt.Errorf("could not decode configuration: %w", err)
+		}
+
+		err = s.Validate(v)
+		if err != nil {
+			var verr *jsonschema.ValidationError
+			if !errors.As(err, &verr) {
+				return err
+			}
+			return newValidationError(verr)
+		}
+
+		return nil
+	}, nil
+}
+
+func newValidationError(verr *jsonschema.ValidationError) *ValidationError {
+	// Only the leaf errors point at the offending fields.
+	var fields []FieldError
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			fields = append(fields, FieldError{Field: fieldPath(e.InstanceLocation), Message: e.Message})
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(verr)
+
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+
+	return &ValidationError{Fields: fields}
+}
+
+// fieldPath converts a JSON pointer (e.g: `/server/port`) to a field path
+// (e.g: `server.port`).
+func fieldPath(ptr string) string {
+	if ptr == "" {
+		return ""
+	}
+
+	parts := strings.Split(strings.TrimPrefix(ptr, "/"), "/")
+	for i, p := range parts {
+		p = strings.ReplaceAll(p, "~1", "/")
+		parts[i] = strings.ReplaceAll(p, "~0", "~")
+	}
+
+	return strings.Join(parts, ".")
+}
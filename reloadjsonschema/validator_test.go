@@ -0,0 +1,114 @@
+package reloadjsonschema_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadconfig"
+	"github.com/slok/reload/reloadjsonschema"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["name"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"server": {
+			"type": "object",
+			"properties": {
+				"port": {"type": "integer", "minimum": 1, "maximum": 65535}
+			}
+		},
+		"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}}
+	}
+}`
+
+type testConfig struct {
+	Name   string `json:"name,omitempty"`
+	Server struct {
+		Port int `json:"port"`
+	} `json:"server"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+func TestValidator(t *testing.T) {
+	tests := map[string]struct {
+		config    func() testConfig
+		expFields []reloadjsonschema.FieldError
+		expErr    bool
+	}{
+		"A valid configuration should not fail.": {
+			config: func() testConfig {
+				c := testConfig{Name: "a", Tags: []string{"x"}}
+				c.Server.Port = 8080
+				return c
+			},
+		},
+
+		"An invalid configuration should fail with the offending fields.": {
+			config: func() testConfig {
+				c := testConfig{Tags: []string{"ok", "NOK"}}
+				c.Server.Port = 0
+				return c
+			},
+			expFields: []reloadjsonschema.FieldError{
+				{Field: "", Message: "missing properties: 'name'"},
+				{Field: "server.port", Message: "must be >= 1 but found 0"},
+				{Field: "tags.1", Message: "does not match pattern '^[a-z]+$'"},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			validate, err := reloadjsonschema.NewValidator[testConfig]([]byte(testSchema))
+			require.NoError(err)
+
+			err = validate(context.TODO(), test.config())
+
+			if test.expErr {
+				var verr *reloadjsonschema.ValidationError
+				if assert.True(errors.As(err, &verr)) {
+					assert.Equal(test.expFields, verr.Fields)
+				}
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestValidatorInvalidSchema(t *testing.T) {
+	_, err := reloadjsonschema.NewValidator[testConfig]([]byte(`{"type": 1}`))
+	assert.Error(t, err)
+}
+
+func TestValidatorLoader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	validate, err := reloadjsonschema.NewValidator[testConfig]([]byte(testSchema))
+	require.NoError(err)
+
+	data := `{"name":"a","server":{"port":8080}}`
+	l, err := reloadconfig.NewLoader(context.TODO(), reloadconfig.LoaderConfig[testConfig]{
+		Load:     func(context.Context) ([]byte, error) { return []byte(data), nil },
+		Validate: validate,
+	})
+	require.NoError(err)
+
+	// An invalid configuration should not be swapped.
+	data = `{"name":"b","server":{"port":70000}}`
+	err = l.Reload(context.TODO(), "")
+	var verr *reloadjsonschema.ValidationError
+	assert.True(errors.As(err, &verr))
+	assert.Equal("a", l.Get().Name)
+}
@@ -0,0 +1,2 @@
+// Package reloadjwt has the JWT signing and verification keys integrations of the reload mechanism.
+package reloadjwt
@@ -0,0 +1,183 @@
+package reloadjwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Key is a JSON Web Key.
+type Key struct {
+	// ID is the key ID (`kid`).
+	ID string
+	// Algorithm is the algorithm of the key (`alg`), if any.
+	Algorithm string
+	// Use is the use of the key (`use`), if any.
+	Use string
+	// Public is the public key (`*rsa.PublicKey`, `*ecdsa.PublicKey` or `ed25519.PublicKey`).
+	Public crypto.PublicKey
+	// Private is the private key, only when the JWK has the private key material.
+	Private crypto.Signer
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA.
+	N string `json:"n"`
+	E string `json:"e"`
+	P string `json:"p"`
+	Q string `json:"q"`
+	// EC and OKP.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// Private.
+	D string `json:"d"`
+}
+
+// parseJWKS parses a JSON Web Key Set document.
+func parseJWKS(data []byte) ([]Key, error) {
+	var set jwks
+	err := json.Unmarshal(data, &set)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode JWKS: %w", err)
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+	for i, k := range set.Keys {
+		key, err := parseJWK(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %d JWK (%q kid): %w", i, k.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func parseJWK(k jwk) (Key, error) {
+	key := Key{ID: k.Kid, Algorithm: k.Alg, Use: k.Use}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid e: %w", err)
+		}
+		pub := &rsa.PublicKey{N: n, E: int(e.Int64())}
+		key.Public = pub
+
+		if k.D != "" {
+			d, err := decodeBigInt(k.D)
+			if err != nil {
+				return Key{}, fmt.Errorf("invalid d: %w", err)
+			}
+			p, err := decodeBigInt(k.P)
+			if err != nil {
+				return Key{}, fmt.Errorf("invalid p: %w", err)
+			}
+			q, err := decodeBigInt(k.Q)
+			if err != nil {
+				return Key{}, fmt.Errorf("invalid q: %w", err)
+			}
+			priv := &rsa.PrivateKey{PublicKey: *pub, D: d, Primes: []*big.Int{p, q}}
+			err = priv.Validate()
+			if err != nil {
+				return Key{}, fmt.Errorf("invalid RSA private key: %w", err)
+			}
+			priv.Precompute()
+			key.Private = priv
+		}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return Key{}, fmt.Errorf("unsupported %q curve", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return Key{}, fmt.Errorf("point is not on the curve")
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		key.Public = pub
+
+		if k.D != "" {
+			d, err := decodeBigInt(k.D)
+			if err != nil {
+				return Key{}, fmt.Errorf("invalid d: %w", err)
+			}
+			key.Private = &ecdsa.PrivateKey{PublicKey: *pub, D: d}
+		}
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return Key{}, fmt.Errorf("unsupported %q curve", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return Key{}, fmt.Errorf("invalid x")
+		}
+		key.Public = ed25519.PublicKey(x)
+
+		if k.D != "" {
+			d, err := base64.RawURLEncoding.DecodeString(k.D)
+			if err != nil || len(d) != ed25519.SeedSize {
+				return Key{}, fmt.Errorf("invalid d")
+			}
+			priv := ed25519.NewKeyFromSeed(d)
+			if !priv.Public().(ed25519.PublicKey).Equal(key.Public) {
+				return Key{}, fmt.Errorf("private key doesn't match the public key")
+			}
+			key.Private = priv
+		}
+
+	default:
+		return Key{}, fmt.Errorf("unsupported %q key type", k.Kty)
+	}
+
+	return key, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,211 @@
+package reloadjwt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySetConfig is the configuration of the KeySet.
+type KeySetConfig struct {
+	// Load returns the JWKS document, it will be called on the creation and
+	// on every reload. FileLoader, FSLoader and URLLoader can be used.
+	Load func(ctx context.Context) ([]byte, error)
+	// Overlap is the time the keys removed from the JWKS keep validating, so
+	// the tokens signed with the previous keys are valid during the rotation.
+	// It should be greater than the tokens lifetime.
+	// By default 1h.
+	Overlap time.Duration
+}
+
+func (c *KeySetConfig) defaults() error {
+	if c.Load == nil {
+		return fmt.Errorf("load function is required")
+	}
+
+	if c.Overlap <= 0 {
+		c.Overlap = time.Hour
+	}
+
+	return nil
+}
+
+// FileLoader returns a JWKS loader that reads the JWKS from a file.
+func FileLoader(path string) func(ctx context.Context) ([]byte, error) {
+	return func(_ context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// FSLoader returns a JWKS loader that reads the JWKS from the name file of
+// the file system (e.g: an `embed.FS`, or a `fstest.MapFS` on the tests).
+func FSLoader(fsys fs.FS, name string) func(ctx context.Context) ([]byte, error) {
+	return func(_ context.Context) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
+
+// URLLoader returns a JWKS loader that gets the JWKS from a URL, if the client
+// is nil `http.DefaultClient` will be used.
+func URLLoader(client *http.Client, url string) func(ctx context.Context) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected %d status code", resp.StatusCode)
+		}
+
+		return io.ReadAll(resp.Body)
+	}
+}
+
+type retiredKey struct {
+	key       Key
+	expiresAt time.Time
+}
+
+// KeySet is a reload.Reloader that reloads a JSON Web Key Set (JWKS) on
+// every reload and swaps the keys atomically, it can be used as the key
+// provider of the JWT signers and verifiers.
+//
+// The keys removed from the JWKS are retired: they keep validating during the
+// overlap period, so the tokens signed with them don't become invalid as soon
+// as the keys rotate. The retired keys are not used to sign.
+//
+// Supports RSA, EC (P-256, P-384, P-521) and Ed25519 (OKP) keys.
+type KeySet struct {
+	cfg KeySetConfig
+
+	mu      sync.RWMutex
+	keys    []Key
+	retired map[string]retiredKey
+}
+
+// NewKeySet returns a new KeySet, the keys are loaded on the creation.
+func NewKeySet(ctx context.Context, cfg KeySetConfig) (*KeySet, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	k := &KeySet{
+		cfg:     cfg,
+		retired: map[string]retiredKey{},
+	}
+	err = k.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Key returns the verification key with the ID, including the retired keys
+// in the overlap period.
+func (k *KeySet) Key(id string) (Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+
+	r, ok := k.retired[id]
+	if !ok || !time.Now().Before(r.expiresAt) {
+		return Key{}, false
+	}
+
+	return r.key, true
+}
+
+// Keys returns the current keys of the JWKS, without the retired keys.
+func (k *KeySet) Keys() []Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]Key, len(k.keys))
+	copy(keys, k.keys)
+
+	return keys
+}
+
+// SigningKey returns the key that should be used to sign, the first key of
+// the JWKS with the private key and a signature use (`sig` or none).
+func (k *KeySet) SigningKey() (Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.Private != nil && (key.Use == "" || key.Use == "sig") {
+			return key, true
+		}
+	}
+
+	return Key{}, false
+}
+
+// Reload satisfies reload.Reloader interface.
+func (k *KeySet) Reload(ctx context.Context, _ string) error {
+	data, err := k.cfg.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load JWKS: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS doesn't have keys")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	current := map[string]bool{}
+	for _, key := range keys {
+		current[key.ID] = true
+		delete(k.retired, key.ID)
+	}
+
+	// Retire the removed keys.
+	for _, key := range k.keys {
+		if !current[key.ID] {
+			k.retired[key.ID] = retiredKey{key: key, expiresAt: now.Add(k.cfg.Overlap)}
+		}
+	}
+
+	// Forget the expired keys.
+	for id, r := range k.retired {
+		if !now.Before(r.expiresAt) {
+			delete(k.retired, id)
+		}
+	}
+
+	k.keys = keys
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package reloadjwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadjwt"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func b64Int(i *big.Int) string { return b64(i.Bytes()) }
+
+func ed25519JWK(t *testing.T, kid string, private bool) map[string]string {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	k := map[string]string{"kty": "OKP", "crv": "Ed25519", "kid": kid, "x": b64(pub)}
+	if private {
+		k["d"] = b64(priv.Seed())
+	}
+	return k
+}
+
+func ecJWK(t *testing.T, kid string) map[string]string {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return map[string]string{
+		"kty": "EC", "crv": "P-256", "kid": kid, "use": "sig",
+		"x": b64Int(priv.X), "y": b64Int(priv.Y), "d": b64Int(priv.D),
+	}
+}
+
+func rsaJWK(t *testing.T, kid string) map[string]string {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "alg": "RS256",
+		"n": b64Int(priv.N), "e": b64Int(big.NewInt(int64(priv.E))),
+		"d": b64Int(priv.D), "p": b64Int(priv.Primes[0]), "q": b64Int(priv.Primes[1]),
+	}
+}
+
+func jwksJSON(t *testing.T, keys ...map[string]string) []byte {
+	b, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+	return b
+}
+
+func TestKeySet(t *testing.T) {
+	k1 := ed25519JWK(t, "k1", true)
+	k2 := ed25519JWK(t, "k2", true)
+	k3 := ed25519JWK(t, "k3", false)
+
+	tests := map[string]struct {
+		initial      []byte
+		reloaded     []byte
+		expErr       bool
+		expKeys      []string
+		expVerify    []string
+		expNotVerify []string
+		expSigning   string
+	}{
+		"The initial keys should be used.": {
+			initial:    jwksJSON(t, k1),
+			reloaded:   jwksJSON(t, k1),
+			expKeys:    []string{"k1"},
+			expVerify:  []string{"k1"},
+			expSigning: "k1",
+		},
+
+		"Rotating the keys should keep the removed keys validating during the overlap.": {
+			initial:    jwksJSON(t, k1),
+			reloaded:   jwksJSON(t, k2, k1),
+			expKeys:    []string{"k2", "k1"},
+			expVerify:  []string{"k1", "k2"},
+			expSigning: "k2",
+		},
+
+		"A removed key should be retired and not used to sign.": {
+			initial:    jwksJSON(t, k1),
+			reloaded:   jwksJSON(t, k3, k2),
+			expKeys:    []string{"k3", "k2"},
+			expVerify:  []string{"k1", "k2", "k3"},
+			expSigning: "k2",
+		},
+
+		"An invalid JWKS should keep the previous keys.": {
+			initial:      jwksJSON(t, k1),
+			reloaded:     []byte(`{"keys": [{"kty": "OKP", "crv": "Ed25519", "kid": "k2", "x": "invalid"}]}`),
+			expErr:       true,
+			expKeys:      []string{"k1"},
+			expVerify:    []string{"k1"},
+			expNotVerify: []string{"k2"},
+			expSigning:   "k1",
+		},
+
+		"An empty JWKS should keep the previous keys.": {
+			initial:    jwksJSON(t, k1),
+			reloaded:   jwksJSON(t),
+			expErr:     true,
+			expKeys:    []string{"k1"},
+			expVerify:  []string{"k1"},
+			expSigning: "k1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			require.NoError(os.WriteFile(path, test.initial, 0o600))
+			ks, err := reloadjwt.NewKeySet(context.TODO(), reloadjwt.KeySetConfig{
+				Load: reloadjwt.FileLoader(path),
+			})
+			require.NoError(err)
+
+			// Execute.
+			require.NoError(os.WriteFile(path, test.reloaded, 0o600))
+			err = ks.Reload(context.TODO(), "test")
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			var gotKeys []string
+			for _, k := range ks.Keys() {
+				gotKeys = append(gotKeys, k.ID)
+			}
+			assert.Equal(test.expKeys, gotKeys)
+
+			for _, id := range test.expVerify {
+				_, ok := ks.Key(id)
+				assert.True(ok, id)
+			}
+			for _, id := range test.expNotVerify {
+				_, ok := ks.Key(id)
+				assert.False(ok, id)
+			}
+
+			sk, ok := ks.SigningKey()
+			require.True(ok)
+			assert.Equal(test.expSigning, sk.ID)
+		})
+	}
+}
+
+func TestKeySetOverlapExpiration(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	data := jwksJSON(t, ed25519JWK(t, "k1", false))
+	ks, err := reloadjwt.NewKeySet(context.TODO(), reloadjwt.KeySetConfig{
+		Load:    func(ctx context.Context) ([]byte, error) { return data, nil },
+		Overlap: 50 * time.Millisecond,
+	})
+	require.NoError(err)
+
+	// After rotating, the previous key should be valid during the overlap.
+	data = jwksJSON(t, ed25519JWK(t, "k2", false))
+	require.NoError(ks.Reload(context.TODO(), "test"))
+	_, ok := ks.Key("k1")
+	assert.True(ok)
+
+	// After the overlap, the previous key should not be valid.
+	time.Sleep(100 * time.Millisecond)
+	_, ok = ks.Key("k1")
+	assert.False(ok)
+	_, ok = ks.Key("k2")
+	assert.True(ok)
+}
+
+func TestKeySetKeyTypes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	data := jwksJSON(t, rsaJWK(t, "rsa"), ecJWK(t, "ec"), ed25519JWK(t, "okp", true))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	// Execute.
+	ks, err := reloadjwt.NewKeySet(context.TODO(), reloadjwt.KeySetConfig{
+		Load: reloadjwt.URLLoader(nil, srv.URL),
+	})
+	require.NoError(err)
+
+	// Check.
+	rsaKey, ok := ks.Key("rsa")
+	require.True(ok)
+	assert.IsType(&rsa.PublicKey{}, rsaKey.Public)
+	assert.IsType(&rsa.PrivateKey{}, rsaKey.Private)
+	assert.Equal("RS256", rsaKey.Algorithm)
+
+	ecKey, ok := ks.Key("ec")
+	require.True(ok)
+	assert.IsType(&ecdsa.PublicKey{}, ecKey.Public)
+	assert.IsType(&ecdsa.PrivateKey{}, ecKey.Private)
+
+	okpKey, ok := ks.Key("okp")
+	require.True(ok)
+	assert.IsType(ed25519.PublicKey{}, okpKey.Public)
+	assert.IsType(ed25519.PrivateKey{}, okpKey.Private)
+
+	// The signing keys should be able to sign.
+	_, err = ecKey.Private.Sign(rand.Reader, make([]byte, 32), nil)
+	assert.NoError(err)
+}
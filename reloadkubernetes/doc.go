@@ -0,0 +1,2 @@
+// Package reloadkubernetes has the Kubernetes integrations of the reload mechanism.
+package reloadkubernetes
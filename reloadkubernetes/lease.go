@@ -0,0 +1,363 @@
+package reloadkubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	microTimeFormat   = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// LeaseElectorConfig is the configuration of the LeaseElector.
+type LeaseElectorConfig struct {
+	// Name is the name of the Kubernetes lease.
+	Name string
+	// Namespace is the namespace of the Kubernetes lease.
+	// By default the namespace of the pod service account.
+	Namespace string
+	// Identity is the identity of the process on the lease, it should be
+	// unique on all the replicas.
+	// By default the hostname (the pod name).
+	Identity string
+	// APIServer is the URL of the Kubernetes API server.
+	// By default the in-cluster API server.
+	APIServer string
+	// HTTPClient is the client used to call the Kubernetes API server.
+	// By default a client that trusts the pod service account CA.
+	HTTPClient *http.Client
+	// Token returns the bearer token used to call the Kubernetes API server,
+	// it's called on every request so the rotated tokens are used.
+	// By default the pod service account token.
+	Token func() (string, error)
+	// LeaseDuration is the duration the non leaders wait until they can take
+	// the leadership from a leader that doesn't renew the lease.
+	// By default 15s.
+	LeaseDuration time.Duration
+	// RetryPeriod is the interval used to try acquiring or renewing the lease.
+	// By default 2s.
+	RetryPeriod time.Duration
+}
+
+func (c *LeaseElectorConfig) defaults() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if c.Namespace == "" {
+		ns, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return fmt.Errorf("namespace is required, could not get the service account namespace: %w", err)
+		}
+		c.Namespace = strings.TrimSpace(string(ns))
+	}
+
+	if c.Identity == "" {
+		id, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("identity is required, could not get the hostname: %w", err)
+		}
+		c.Identity = id
+	}
+
+	if c.APIServer == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return fmt.Errorf("API server is required, not running in a Kubernetes cluster")
+		}
+		c.APIServer = "https://" + net.JoinHostPort(host, port)
+	}
+
+	if c.HTTPClient == nil {
+		ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+		if err != nil {
+			return fmt.Errorf("HTTP client is required, could not get the service account CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		c.HTTPClient = &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			},
+		}
+	}
+
+	if c.Token == nil {
+		c.Token = func() (string, error) {
+			t, err := os.ReadFile(serviceAccountDir + "/token")
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(t)), nil
+		}
+	}
+
+	if c.LeaseDuration <= 0 {
+		c.LeaseDuration = 15 * time.Second
+	}
+
+	if c.RetryPeriod <= 0 {
+		c.RetryPeriod = 2 * time.Second
+	}
+
+	return nil
+}
+
+// LeaseElector is a reload.LeaderChecker that uses a Kubernetes lease
+// (`coordination.k8s.io/v1`) to elect a leader between the replicas.
+//
+// The service account needs `get`, `create` and `update` permissions on the
+// lease.
+type LeaseElector struct {
+	cfg LeaseElectorConfig
+
+	mu sync.Mutex
+	// renewedAt is when the lease was last renewed by us, zero if not the leader.
+	renewedAt time.Time
+	// observed is the last observed lease and when it was observed, used to
+	// know when the lease expired without depending on the clocks of other
+	// replicas.
+	observed   leaseSpec
+	observedAt time.Time
+}
+
+var _ reload.LeaderChecker = &LeaseElector{}
+
+// NewLeaseElector returns a new LeaseElector, the election starts with Run.
+func NewLeaseElector(cfg LeaseElectorConfig) (*LeaseElector, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &LeaseElector{cfg: cfg}, nil
+}
+
+// IsLeader satisfies reload.LeaderChecker interface.
+func (l *LeaseElector) IsLeader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return !l.renewedAt.IsZero() && time.Since(l.renewedAt) < l.cfg.LeaseDuration
+}
+
+// Run runs the election until the context is cancelled, trying to acquire or
+// renew the lease every retry period. When stopped, the lease is released if
+// held, so other replica can take the leadership without waiting.
+func (l *LeaseElector) Run(ctx context.Context) error {
+	t := time.NewTicker(l.cfg.RetryPeriod)
+	defer t.Stop()
+
+	for {
+		// Errors are retried on the next period, if the lease can't be renewed
+		// the leadership will be lost when it expires.
+		_ = l.tryAcquireOrRenew(ctx)
+
+		select {
+		case <-ctx.Done():
+			if l.IsLeader() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), l.cfg.RetryPeriod)
+				defer cancel()
+				_ = l.release(releaseCtx)
+			}
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
+
+type lease struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		ResourceVersion string `json:"resourceVersion,omitempty"`
+	} `json:"metadata"`
+	Spec leaseSpec `json:"spec"`
+}
+
+func (l *LeaseElector) tryAcquireOrRenew(ctx context.Context) error {
+	now := time.Now()
+	current, found, err := l.getLease(ctx)
+	if err != nil {
+		return err
+	}
+
+	newLease := lease{APIVersion: "coordination.k8s.io/v1", Kind: "Lease"}
+	newLease.Metadata.Name = l.cfg.Name
+	newLease.Metadata.Namespace = l.cfg.Namespace
+	newLease.Spec = leaseSpec{
+		HolderIdentity:       l.cfg.Identity,
+		LeaseDurationSeconds: int(l.cfg.LeaseDuration.Seconds()),
+		AcquireTime:          now.UTC().Format(microTimeFormat),
+		RenewTime:            now.UTC().Format(microTimeFormat),
+	}
+
+	if !found {
+		err := l.writeLease(ctx, http.MethodPost, newLease)
+		if err != nil {
+			return err
+		}
+		l.setRenewed(now, newLease.Spec)
+		return nil
+	}
+
+	l.mu.Lock()
+	if current.Spec != l.observed {
+		l.observed = current.Spec
+		l.observedAt = now
+	}
+	observedAt := l.observedAt
+	l.mu.Unlock()
+
+	holder := current.Spec.HolderIdentity
+	if holder != "" && holder != l.cfg.Identity && now.Before(observedAt.Add(l.cfg.LeaseDuration)) {
+		l.mu.Lock()
+		l.renewedAt = time.Time{}
+		l.mu.Unlock()
+		return nil
+	}
+
+	newLease.Metadata.ResourceVersion = current.Metadata.ResourceVersion
+	newLease.Spec.LeaseTransitions = current.Spec.LeaseTransitions
+	if holder == l.cfg.Identity {
+		newLease.Spec.AcquireTime = current.Spec.AcquireTime
+	} else {
+		newLease.Spec.LeaseTransitions++
+	}
+
+	err = l.writeLease(ctx, http.MethodPut, newLease)
+	if err != nil {
+		return err
+	}
+	l.setRenewed(now, newLease.Spec)
+
+	return nil
+}
+
+func (l *LeaseElector) release(ctx context.Context) error {
+	current, found, err := l.getLease(ctx)
+	if err != nil || !found || current.Spec.HolderIdentity != l.cfg.Identity {
+		return err
+	}
+
+	current.Spec.HolderIdentity = ""
+	current.Spec.LeaseDurationSeconds = 1
+	current.Spec.RenewTime = time.Now().UTC().Format(microTimeFormat)
+	err = l.writeLease(ctx, http.MethodPut, current)
+	if err != nil {
+		return err
+	}
+	l.setRenewed(time.Time{}, current.Spec)
+
+	return nil
+}
+
+func (l *LeaseElector) setRenewed(at time.Time, spec leaseSpec) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.renewedAt = at
+	l.observed = spec
+	l.observedAt = time.Now()
+}
+
+func (l *LeaseElector) leasesURL() string {
+	return fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", strings.TrimSuffix(l.cfg.APIServer, "/"), l.cfg.Namespace)
+}
+
+func (l *LeaseElector) getLease(ctx context.Context) (lease, bool, error) {
+	resp, err := l.do(ctx, http.MethodGet, l.leasesURL()+"/"+l.cfg.Name, nil)
+	if err != nil {
+		return lease{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return lease{}, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return lease{}, false, fmt.Errorf("could not get lease: unexpected %d status code", resp.StatusCode)
+	}
+
+	var ls lease
+	err = json.NewDecoder(resp.Body).Decode(&ls)
+	if err != nil {
+		return lease{}, false, fmt.Errorf("could not decode lease: %w", err)
+	}
+
+	return ls, true, nil
+}
+
+// writeLease creates (POST) or updates (PUT) the lease, the updates are
+// rejected by the API server if the lease changed since it was read.
+func (l *LeaseElector) writeLease(ctx context.Context, method string, ls lease) error {
+	body, err := json.Marshal(ls)
+	if err != nil {
+		return fmt.Errorf("could not encode lease: %w", err)
+	}
+
+	url := l.leasesURL()
+	if method == http.MethodPut {
+		url += "/" + l.cfg.Name
+	}
+
+	resp, err := l.do(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("could not write lease: unexpected %d status code", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (l *LeaseElector) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	token, err := l.cfg.Token()
+	if err != nil {
+		return nil, fmt.Errorf("could not get token: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return l.cfg.HTTPClient.Do(req)
+}
@@ -0,0 +1,175 @@
+package reloadkubernetes_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadkubernetes"
+)
+
+const testLeasePath = "/apis/coordination.k8s.io/v1/namespaces/test-ns/leases"
+
+// testLeaseAPI is a fake Kubernetes API server that only knows about leases.
+type testLeaseAPI struct {
+	mu      sync.Mutex
+	lease   map[string]any
+	version int
+}
+
+func (t *testLeaseAPI) holder() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.lease == nil {
+		return ""
+	}
+	h, _ := t.lease["spec"].(map[string]any)["holderIdentity"].(string)
+	return h
+}
+
+func (t *testLeaseAPI) setHolder(holder string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.version++
+	t.lease = map[string]any{
+		"metadata": map[string]any{"name": "test", "namespace": "test-ns", "resourceVersion": strconv.Itoa(t.version)},
+		"spec":     map[string]any{"holderIdentity": holder, "leaseDurationSeconds": 1},
+	}
+}
+
+func (t *testLeaseAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == testLeasePath+"/test":
+		if t.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(t.lease)
+
+	case r.Method == http.MethodPost && r.URL.Path == testLeasePath:
+		if t.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		t.store(w, r, http.StatusCreated)
+
+	case r.Method == http.MethodPut && r.URL.Path == testLeasePath+"/test":
+		var l map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&l)
+		gotVersion := l["metadata"].(map[string]any)["resourceVersion"]
+		if t.lease == nil || gotVersion != t.lease["metadata"].(map[string]any)["resourceVersion"] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		t.version++
+		l["metadata"].(map[string]any)["resourceVersion"] = strconv.Itoa(t.version)
+		t.lease = l
+		_ = json.NewEncoder(w).Encode(l)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (t *testLeaseAPI) store(w http.ResponseWriter, r *http.Request, status int) {
+	var l map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&l)
+	t.version++
+	l["metadata"].(map[string]any)["resourceVersion"] = strconv.Itoa(t.version)
+	t.lease = l
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(l)
+}
+
+func newTestElector(t *testing.T, url, identity string) *reloadkubernetes.LeaseElector {
+	e, err := reloadkubernetes.NewLeaseElector(reloadkubernetes.LeaseElectorConfig{
+		Name:          "test",
+		Namespace:     "test-ns",
+		Identity:      identity,
+		APIServer:     url,
+		HTTPClient:    http.DefaultClient,
+		Token:         func() (string, error) { return "test-token", nil },
+		LeaseDuration: 200 * time.Millisecond,
+		RetryPeriod:   10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	return e
+}
+
+func TestLeaseElector(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	api := &testLeaseAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	e1 := newTestElector(t, srv.URL, "replica-1")
+	e2 := newTestElector(t, srv.URL, "replica-2")
+
+	// Only one replica should be the leader.
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	run1 := make(chan error)
+	go func() { run1 <- e1.Run(ctx1) }()
+	time.Sleep(50 * time.Millisecond)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	go func() { _ = e2.Run(ctx2) }()
+	time.Sleep(50 * time.Millisecond)
+	assert.True(e1.IsLeader())
+	assert.False(e2.IsLeader())
+	assert.Equal("replica-1", api.holder())
+
+	// When the leader stops, it should release the lease and the other replica
+	// should take the leadership.
+	cancel1()
+	assert.NoError(<-run1)
+	time.Sleep(50 * time.Millisecond)
+	assert.False(e1.IsLeader())
+	assert.True(e2.IsLeader())
+	assert.Equal("replica-2", api.holder())
+}
+
+func TestLeaseElectorExpiredLease(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	api := &testLeaseAPI{}
+	api.setHolder("dead-replica")
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	e := newTestElector(t, srv.URL, "replica-1")
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = e.Run(ctx) }()
+
+	// While the lease is not expired, the lease should be respected.
+	time.Sleep(100 * time.Millisecond)
+	assert.False(e.IsLeader())
+	assert.Equal("dead-replica", api.holder())
+
+	// Once the lease is not renewed for the lease duration, it should be taken.
+	time.Sleep(200 * time.Millisecond)
+	assert.True(e.IsLeader())
+	assert.Equal("replica-1", api.holder())
+}
@@ -0,0 +1,98 @@
+package reloadkubernetes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// LeaseLock is a reload.DistributedLocker that uses a Kubernetes lease to
+// serialize the reloads between the replicas, the lock is held while the lease
+// is held.
+//
+// The service account needs `get`, `create` and `update` permissions on the
+// lease.
+type LeaseLock struct {
+	cfg LeaseElectorConfig
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ reload.DistributedLocker = &LeaseLock{}
+
+// NewLeaseLock returns a new LeaseLock.
+func NewLeaseLock(cfg LeaseElectorConfig) (*LeaseLock, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &LeaseLock{cfg: cfg}, nil
+}
+
+// Lock satisfies reload.DistributedLocker interface.
+func (l *LeaseLock) Lock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil {
+		return fmt.Errorf("lock already acquired")
+	}
+
+	elector, err := NewLeaseElector(l.cfg)
+	if err != nil {
+		return err
+	}
+
+	// The election runs (renewing the lease) until unlocked.
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = elector.Run(runCtx)
+	}()
+
+	t := time.NewTicker(l.cfg.RetryPeriod / 2)
+	defer t.Stop()
+	for !elector.IsLeader() {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	l.cancel = cancel
+	l.done = done
+
+	return nil
+}
+
+// Unlock satisfies reload.DistributedLocker interface.
+func (l *LeaseLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel == nil {
+		return fmt.Errorf("lock not acquired")
+	}
+
+	// Stopping the election releases the lease.
+	l.cancel()
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	l.cancel = nil
+	l.done = nil
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package reloadkubernetes_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadkubernetes"
+)
+
+func newTestLock(t *testing.T, url, identity string) *reloadkubernetes.LeaseLock {
+	l, err := reloadkubernetes.NewLeaseLock(reloadkubernetes.LeaseElectorConfig{
+		Name:          "test",
+		Namespace:     "test-ns",
+		Identity:      identity,
+		APIServer:     url,
+		HTTPClient:    http.DefaultClient,
+		Token:         func() (string, error) { return "test-token", nil },
+		LeaseDuration: 200 * time.Millisecond,
+		RetryPeriod:   10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	return l
+}
+
+func TestLeaseLock(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	api := &testLeaseAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	locks := []*reloadkubernetes.LeaseLock{
+		newTestLock(t, srv.URL, "replica-1"),
+		newTestLock(t, srv.URL, "replica-2"),
+	}
+
+	// Execute.
+	var mu sync.Mutex
+	running, overlapped := 0, false
+	var wg sync.WaitGroup
+	for _, l := range locks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(l.Lock(context.TODO()))
+			mu.Lock()
+			running++
+			overlapped = overlapped || running > 1
+			mu.Unlock()
+
+			time.Sleep(50 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			assert.NoError(l.Unlock(context.TODO()))
+		}()
+	}
+	wg.Wait()
+
+	// Check.
+	assert.False(overlapped)
+	assert.Equal("", api.holder())
+}
+
+func TestLeaseLockCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	api := &testLeaseAPI{}
+	api.setHolder("other-replica")
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	l := newTestLock(t, srv.URL, "replica-1")
+
+	// Execute.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := l.Lock(ctx)
+
+	// Check.
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Equal("other-replica", api.holder())
+	assert.Error(l.Unlock(context.TODO()))
+}
@@ -0,0 +1,65 @@
+package reloadlog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/slok/reload"
+)
+
+// AlertSink is a reload.AlertSink that logs the alerts, the failed reloads at
+// error level, the skipped reloads at warn level and the recoveries at info
+// level.
+type AlertSink struct {
+	logger *slog.Logger
+}
+
+var _ reload.AlertSink = &AlertSink{}
+
+// NewAlertSink returns a new AlertSink that logs on the logger, if nil
+// `slog.Default()` is used.
+func NewAlertSink(logger *slog.Logger) *AlertSink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &AlertSink{logger: logger}
+}
+
+// SendAlert satisfies reload.AlertSink interface.
+func (a *AlertSink) SendAlert(ctx context.Context, al reload.Alert) error {
+	attrs := []slog.Attr{
+		slog.String("alert", string(al.Kind)),
+		slog.String("trigger_id", al.Trigger.ID),
+		slog.String("trigger_source", al.Trigger.Source),
+	}
+	if al.Trigger.Reason != "" {
+		attrs = append(attrs, slog.String("trigger_reason", al.Trigger.Reason))
+	}
+
+	level := slog.LevelInfo
+	msg := "reloads recovered"
+	switch al.Kind {
+	case reload.AlertReloadFailed:
+		level = slog.LevelError
+		msg = "reload failed"
+		attrs = append(attrs, slog.Int("failures", al.Failures), slog.String("error", al.Err.Error()))
+		failed := make([]string, 0, len(al.FailedReloaders))
+		for _, r := range al.FailedReloaders {
+			failed = append(failed, r.Name)
+		}
+		if len(failed) > 0 {
+			attrs = append(attrs, slog.Any("failed_reloaders", failed))
+		}
+	case reload.AlertReloadsSkipped:
+		level = slog.LevelWarn
+		msg = "reloads skipped"
+		attrs = append(attrs, slog.Int("skips", al.Skips))
+	default:
+		attrs = append(attrs, slog.Int("failures", al.Failures), slog.Int("skips", al.Skips))
+	}
+
+	a.logger.LogAttrs(ctx, level, msg, attrs...)
+
+	return nil
+}
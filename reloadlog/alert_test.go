@@ -0,0 +1,74 @@
+package reloadlog_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadlog"
+)
+
+func TestAlertSink(t *testing.T) {
+	tests := map[string]struct {
+		alert  reload.Alert
+		expLog string
+	}{
+		"A failed reload alert should be logged as an error.": {
+			alert: reload.Alert{
+				Kind:            reload.AlertReloadFailed,
+				Trigger:         reload.TriggerEvent{ID: "t1", Source: "file"},
+				Err:             fmt.Errorf("something"),
+				FailedReloaders: []reload.ReloaderReport{{Name: "config"}},
+				Failures:        2,
+			},
+			expLog: `level=ERROR msg="reload failed" alert=reload_failed trigger_id=t1 trigger_source=file failures=2 error=something failed_reloaders=[config]` + "\n",
+		},
+
+		"A skipped reloads alert should be logged as a warning.": {
+			alert:  reload.Alert{Kind: reload.AlertReloadsSkipped, Trigger: reload.TriggerEvent{ID: "t1", Source: "file"}, Skips: 3},
+			expLog: `level=WARN msg="reloads skipped" alert=reloads_skipped trigger_id=t1 trigger_source=file skips=3` + "\n",
+		},
+
+		"A recovered alert should be logged as info.": {
+			alert:  reload.Alert{Kind: reload.AlertReloadRecovered, Trigger: reload.TriggerEvent{ID: "t1", Source: "file"}, Failures: 1},
+			expLog: `level=INFO msg="reloads recovered" alert=reload_recovered trigger_id=t1 trigger_source=file failures=1 skips=0` + "\n",
+		},
+
+		"The trigger reason should be logged.": {
+			alert:  reload.Alert{Kind: reload.AlertReloadsSkipped, Trigger: reload.TriggerEvent{ID: "t1", Source: "file", Reason: "config changed"}, Skips: 3},
+			expLog: `level=WARN msg="reloads skipped" alert=reloads_skipped trigger_id=t1 trigger_source=file trigger_reason="config changed" skips=3` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var b bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+			s := reloadlog.NewAlertSink(logger)
+
+			// Execute.
+			err := s.SendAlert(context.TODO(), test.alert)
+
+			// Check.
+			require.NoError(err)
+			assert.Equal(test.expLog, b.String())
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package reloadlog has the logging integrations of the reload mechanism.
+package reloadlog
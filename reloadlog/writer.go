@@ -0,0 +1,117 @@
+package reloadlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ReopenWriterConfig is the configuration of the ReopenWriter.
+type ReopenWriterConfig struct {
+	// Path is the path of the log file.
+	Path string
+	// Perm is the permission used when the file is created.
+	// By default 0644.
+	Perm os.FileMode
+}
+
+func (c *ReopenWriterConfig) defaults() error {
+	if c.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	if c.Perm == 0 {
+		c.Perm = 0o644
+	}
+
+	return nil
+}
+
+// ReopenWriter is an `io.Writer` that writes to a log file and a reload.Reloader
+// that reopens the file on every reload, the classic behavior required by
+// logrotate (move the file and send a SIGHUP to the process).
+//
+// The file is swapped atomically, the writes are never lost: they are written
+// on the old file or on the new one.
+type ReopenWriter struct {
+	cfg ReopenWriterConfig
+
+	mu     sync.Mutex
+	f      *os.File
+	closed bool
+}
+
+// NewReopenWriter returns a new ReopenWriter, the file is opened on the creation.
+func NewReopenWriter(cfg ReopenWriterConfig) (*ReopenWriter, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	f, err := openLogFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReopenWriter{cfg: cfg, f: f}, nil
+}
+
+// Write satisfies io.Writer interface.
+func (r *ReopenWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	return r.f.Write(p)
+}
+
+// Reload satisfies reload.Reloader interface.
+func (r *ReopenWriter) Reload(_ context.Context, _ string) error {
+	f, err := openLogFile(r.cfg)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		_ = f.Close()
+		return fmt.Errorf("writer is closed")
+	}
+	old := r.f
+	r.f = f
+	r.mu.Unlock()
+
+	err = old.Close()
+	if err != nil {
+		return fmt.Errorf("could not close old log file: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (r *ReopenWriter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	return r.f.Close()
+}
+
+func openLogFile(cfg ReopenWriterConfig) (*os.File, error) {
+	f, err := os.OpenFile(cfg.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, cfg.Perm)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %q log file: %w", cfg.Path, err)
+	}
+
+	return f, nil
+}
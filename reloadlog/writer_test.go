@@ -0,0 +1,97 @@
+package reloadlog_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadlog"
+)
+
+func TestReopenWriter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := reloadlog.NewReopenWriter(reloadlog.ReopenWriterConfig{Path: path})
+	require.NoError(err)
+	defer w.Close()
+
+	// Execute.
+	_, err = fmt.Fprintln(w, "before")
+	require.NoError(err)
+	require.NoError(os.Rename(path, path+".1")) // Rotate.
+	_, err = fmt.Fprintln(w, "rotated")
+	require.NoError(err)
+	require.NoError(w.Reload(context.TODO(), "test"))
+	_, err = fmt.Fprintln(w, "after")
+	require.NoError(err)
+
+	// Check.
+	rotated, err := os.ReadFile(path + ".1")
+	require.NoError(err)
+	assert.Equal("before\nrotated\n", string(rotated))
+	current, err := os.ReadFile(path)
+	require.NoError(err)
+	assert.Equal("after\n", string(current))
+}
+
+func TestReopenWriterConcurrentWrites(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := reloadlog.NewReopenWriter(reloadlog.ReopenWriterConfig{Path: path})
+	require.NoError(err)
+
+	// Execute.
+	const writers, writes = 5, 100
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range writes {
+				_, err := w.Write([]byte("line\n"))
+				assert.NoError(err)
+			}
+		}()
+	}
+	for i := range 10 {
+		require.NoError(os.Rename(path, fmt.Sprintf("%s.%d", path, i)))
+		require.NoError(w.Reload(context.TODO(), "test"))
+	}
+	wg.Wait()
+	require.NoError(w.Close())
+
+	// Check no write has been lost.
+	files, err := filepath.Glob(path + "*")
+	require.NoError(err)
+	total := 0
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		require.NoError(err)
+		total += strings.Count(string(b), "line\n")
+	}
+	assert.Equal(writers*writes, total)
+
+	// Once closed, it should not write nor reload.
+	_, err = w.Write([]byte("line\n"))
+	assert.Error(err)
+	assert.Error(w.Reload(context.TODO(), "test"))
+}
+
+func TestNewReopenWriterInvalid(t *testing.T) {
+	_, err := reloadlog.NewReopenWriter(reloadlog.ReopenWriterConfig{})
+	assert.Error(t, err)
+}
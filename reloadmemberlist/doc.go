@@ -0,0 +1,2 @@
+// Package reloadmemberlist has the memberlist (gossip) integrations of the reload mechanism.
+package reloadmemberlist
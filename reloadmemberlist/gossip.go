@@ -0,0 +1,295 @@
+package reloadmemberlist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+
+	"github.com/slok/reload"
+)
+
+// GossipNotifierConfig is the configuration of the GossipNotifier.
+type GossipNotifierConfig struct {
+	// Memberlist is the memberlist configuration (e.g: name, bind address,
+	// encryption keys), the delegate will be replaced.
+	// By default `memberlist.DefaultLANConfig()` with the logs discarded.
+	Memberlist *memberlist.Config
+	// Join are the addresses of the cluster members to join on the creation,
+	// if empty, the node will start a new cluster.
+	Join []string
+	// MaxSeen is the number of trigger IDs that will be remembered to
+	// deduplicate the gossiped triggers.
+	// By default 1024.
+	MaxSeen int
+}
+
+func (c *GossipNotifierConfig) defaults() error {
+	if c.Memberlist == nil {
+		c.Memberlist = memberlist.DefaultLANConfig()
+		c.Memberlist.Logger = log.New(io.Discard, "", 0)
+	}
+
+	if c.MaxSeen <= 0 {
+		c.MaxSeen = 1024
+	}
+
+	return nil
+}
+
+// GossipNotifier is a reload.Notifier that gossips the reload triggers across
+// a cluster using memberlist, for fleets without a central message bus.
+//
+// The triggers are gossiped with eventual delivery, and the nodes exchange the
+// latest trigger on the periodic push/pull state syncs (anti-entropy), so the
+// nodes that missed a trigger (e.g: partitioned) will reload eventually. The
+// nodes that join the cluster don't reload, as they start with the latest
+// configuration.
+//
+// Trigger IDs need to be unique as they are used to deduplicate the triggers.
+//
+// If multiple triggers are received while the manager is busy, only the latest
+// one will be triggered.
+type GossipNotifier struct {
+	cfg   GossipNotifierConfig
+	ml    atomic.Pointer[memberlist.Memberlist]
+	queue *memberlist.TransmitLimitedQueue
+	c     chan reload.TriggerEvent
+
+	mu       sync.Mutex
+	seen     map[string]struct{}
+	seenList []string
+	// clock is a Lamport clock used to know the latest trigger of the cluster.
+	clock  uint64
+	latest gossipTrigger
+}
+
+// NewGossipNotifier returns a new GossipNotifier that joins the cluster.
+func NewGossipNotifier(cfg GossipNotifierConfig) (*GossipNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	g := &GossipNotifier{
+		cfg:  cfg,
+		c:    make(chan reload.TriggerEvent, 1),
+		seen: map[string]struct{}{},
+	}
+	g.queue = &memberlist.TransmitLimitedQueue{
+		NumNodes: func() int {
+			ml := g.ml.Load()
+			if ml == nil {
+				return 1
+			}
+			return ml.NumMembers()
+		},
+		RetransmitMult: cfg.Memberlist.RetransmitMult,
+	}
+	cfg.Memberlist.Delegate = gossipDelegate{g: g}
+
+	ml, err := memberlist.Create(cfg.Memberlist)
+	if err != nil {
+		return nil, fmt.Errorf("could not create memberlist: %w", err)
+	}
+	g.ml.Store(ml)
+
+	if len(cfg.Join) > 0 {
+		_, err := ml.Join(cfg.Join)
+		if err != nil {
+			_ = ml.Shutdown()
+			return nil, fmt.Errorf("could not join cluster: %w", err)
+		}
+	}
+
+	return g, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (g *GossipNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := g.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (g *GossipNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	select {
+	case <-ctx.Done():
+		return reload.TriggerEvent{}, ctx.Err()
+	case t := <-g.c:
+		return t, nil
+	}
+}
+
+// Trigger triggers a reload on the node and gossips it to the cluster. If the
+// trigger doesn't have an ID, a random one will be used.
+func (g *GossipNotifier) Trigger(_ context.Context, t reload.TriggerEvent) error {
+	if t.ID == "" {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		t.ID = hex.EncodeToString(b)
+	}
+
+	g.mu.Lock()
+	g.clock++
+	gt := gossipTrigger{ID: t.ID, Version: g.clock, Paths: t.Paths, Keys: t.Keys, Metadata: t.Metadata}
+	g.mu.Unlock()
+
+	g.receive(gt, true)
+
+	return nil
+}
+
+// Members returns the alive members of the cluster.
+func (g *GossipNotifier) Members() []*memberlist.Node {
+	return g.ml.Load().Members()
+}
+
+// Leave leaves the cluster gracefully and stops the node.
+func (g *GossipNotifier) Leave(timeout time.Duration) error {
+	ml := g.ml.Load()
+	err := ml.Leave(timeout)
+	if err != nil {
+		return fmt.Errorf("could not leave cluster: %w", err)
+	}
+
+	return ml.Shutdown()
+}
+
+type gossipTrigger struct {
+	ID       string            `json:"id"`
+	Version  uint64            `json:"version"`
+	Paths    []string          `json:"paths,omitempty"`
+	Keys     []string          `json:"keys,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// receive handles a trigger, if it's new (and required) it's triggered locally
+// and gossiped.
+func (g *GossipNotifier) receive(t gossipTrigger, trigger bool) {
+	g.mu.Lock()
+	if _, ok := g.seen[t.ID]; ok {
+		g.mu.Unlock()
+		return
+	}
+	g.markSeenLocked(t.ID)
+	g.clock = max(g.clock, t.Version)
+	if t.Version > g.latest.Version {
+		g.latest = t
+	}
+	g.mu.Unlock()
+
+	if !trigger {
+		return
+	}
+
+	msg, err := json.Marshal(t)
+	if err == nil {
+		g.queue.QueueBroadcast(gossipBroadcast{id: t.ID, msg: msg})
+	}
+	g.trigger(reload.TriggerEvent{ID: t.ID, Paths: t.Paths, Keys: t.Keys, Metadata: t.Metadata})
+}
+
+func (g *GossipNotifier) markSeenLocked(id string) {
+	g.seen[id] = struct{}{}
+	g.seenList = append(g.seenList, id)
+	if len(g.seenList) > g.cfg.MaxSeen {
+		delete(g.seen, g.seenList[0])
+		g.seenList = g.seenList[1:]
+	}
+}
+
+// trigger sends the trigger replacing the pending one, if any.
+func (g *GossipNotifier) trigger(t reload.TriggerEvent) {
+	for {
+		select {
+		case g.c <- t:
+			return
+		default:
+		}
+
+		// Drop the pending trigger.
+		select {
+		case <-g.c:
+		default:
+		}
+	}
+}
+
+// gossipDelegate is the memberlist delegate, it's a different type so the
+// memberlist methods are not exposed on the notifier.
+type gossipDelegate struct {
+	g *GossipNotifier
+}
+
+func (d gossipDelegate) NodeMeta(limit int) []byte { return nil }
+
+func (d gossipDelegate) NotifyMsg(msg []byte) {
+	var t gossipTrigger
+	err := json.Unmarshal(msg, &t)
+	if err != nil || t.ID == "" {
+		return
+	}
+
+	d.g.receive(t, true)
+}
+
+func (d gossipDelegate) GetBroadcasts(overhead, limit int) [][]byte {
+	return d.g.queue.GetBroadcasts(overhead, limit)
+}
+
+func (d gossipDelegate) LocalState(join bool) []byte {
+	d.g.mu.Lock()
+	defer d.g.mu.Unlock()
+
+	if d.g.latest.ID == "" {
+		return nil
+	}
+
+	b, _ := json.Marshal(d.g.latest)
+	return b
+}
+
+func (d gossipDelegate) MergeRemoteState(buf []byte, join bool) {
+	if len(buf) == 0 {
+		return
+	}
+
+	var t gossipTrigger
+	err := json.Unmarshal(buf, &t)
+	if err != nil || t.ID == "" {
+		return
+	}
+
+	d.g.mu.Lock()
+	newer := t.Version > d.g.latest.Version
+	d.g.mu.Unlock()
+	if !newer {
+		return
+	}
+
+	// The joining nodes already have the latest configuration.
+	d.g.receive(t, !join)
+}
+
+type gossipBroadcast struct {
+	id  string
+	msg []byte
+}
+
+func (b gossipBroadcast) Invalidates(other memberlist.Broadcast) bool {
+	o, ok := other.(gossipBroadcast)
+	return ok && o.id == b.id
+}
+
+func (b gossipBroadcast) Message() []byte { return b.msg }
+
+func (b gossipBroadcast) Finished() {}
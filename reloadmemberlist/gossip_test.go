@@ -0,0 +1,116 @@
+package reloadmemberlist_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadmemberlist"
+)
+
+func newTestNode(t *testing.T, name string, join []string, mod func(c *memberlist.Config)) *reloadmemberlist.GossipNotifier {
+	cfg := memberlist.DefaultLocalConfig()
+	cfg.Name = name
+	cfg.BindAddr = "127.0.0.1"
+	cfg.BindPort = 0
+	cfg.GossipInterval = 10 * time.Millisecond
+	cfg.Logger = log.New(io.Discard, "", 0)
+	if mod != nil {
+		mod(cfg)
+	}
+
+	n, err := reloadmemberlist.NewGossipNotifier(reloadmemberlist.GossipNotifierConfig{
+		Memberlist: cfg,
+		Join:       join,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = n.Leave(100 * time.Millisecond) })
+
+	return n
+}
+
+func nodeAddr(n *reloadmemberlist.GossipNotifier, name string) string {
+	for _, m := range n.Members() {
+		if m.Name == name {
+			return m.Address()
+		}
+	}
+	return ""
+}
+
+// waitTrigger waits for a trigger on the notifier.
+func waitTrigger(n reload.TriggerNotifier, timeout time.Duration) (reload.TriggerEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return n.NotifyTrigger(ctx)
+}
+
+func TestGossipNotifier(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	n0 := newTestNode(t, "node-0", nil, nil)
+	addr := nodeAddr(n0, "node-0")
+	nodes := []*reloadmemberlist.GossipNotifier{n0}
+	for i := 1; i < 4; i++ {
+		nodes = append(nodes, newTestNode(t, "node-"+strconv.Itoa(i), []string{addr}, nil))
+	}
+
+	// Execute.
+	exp := reload.TriggerEvent{ID: "deploy-123", Keys: []string{"a"}, Metadata: map[string]string{"reason": "deploy"}}
+	require.NoError(nodes[2].Trigger(context.TODO(), exp))
+
+	// Check all the nodes reload only once.
+	for i, n := range nodes {
+		got, err := waitTrigger(n, 2*time.Second)
+		require.NoError(err, fmt.Sprintf("node-%d", i))
+		assert.Equal(exp, got)
+	}
+	time.Sleep(100 * time.Millisecond)
+	for _, n := range nodes {
+		_, err := waitTrigger(n, 10*time.Millisecond)
+		assert.Error(err)
+	}
+
+	// A node joining after the trigger should not reload.
+	late := newTestNode(t, "node-late", []string{addr}, nil)
+	_, err := waitTrigger(late, 200*time.Millisecond)
+	assert.Error(err)
+}
+
+func TestGossipNotifierAntiEntropy(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	// The first node doesn't gossip, so the trigger can only be received by
+	// the push/pull state syncs.
+	n0 := newTestNode(t, "node-0", nil, func(c *memberlist.Config) {
+		c.GossipNodes = 0
+		c.PushPullInterval = 50 * time.Millisecond
+	})
+	n1 := newTestNode(t, "node-1", []string{nodeAddr(n0, "node-0")}, func(c *memberlist.Config) {
+		c.PushPullInterval = 50 * time.Millisecond
+	})
+
+	// Execute.
+	require.NoError(n0.Trigger(context.TODO(), reload.TriggerEvent{ID: "t1"}))
+
+	// Check.
+	got, err := waitTrigger(n0, time.Second)
+	require.NoError(err)
+	assert.Equal("t1", got.ID)
+	got, err = waitTrigger(n1, 2*time.Second)
+	require.NoError(err)
+	assert.Equal("t1", got.ID)
+}
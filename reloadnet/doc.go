@@ -0,0 +1,2 @@
+// Package reloadnet has the network integrations of the reload mechanism.
+package reloadnet
@@ -0,0 +1,141 @@
+package reloadnet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// ListenerSettings are the settings of a listener.
+type ListenerSettings struct {
+	// Network is the listener network (e.g: `tcp`, `unix`).
+	// By default `tcp`.
+	Network string
+	// Address is the listener address.
+	Address string
+	// ReusePort sets `SO_REUSEPORT` on the listener sockets, so the new listener
+	// can be bound to the same address while the old one is still accepting,
+	// without an accept gap. Only supported on Linux and BSD systems.
+	ReusePort bool
+}
+
+func (s *ListenerSettings) defaults() {
+	if s.Network == "" {
+		s.Network = "tcp"
+	}
+}
+
+// ListenerReloaderConfig is the configuration of the ListenerReloader.
+type ListenerReloaderConfig struct {
+	// Settings returns the listener settings, it will be called on the creation
+	// and on every reload.
+	Settings func(ctx context.Context) (ListenerSettings, error)
+	// Serve receives every new listener, it should start accepting on it
+	// (e.g: `go srv.Serve(l)`) and return. The old listener will be closed
+	// after this.
+	Serve func(l net.Listener) error
+}
+
+func (c *ListenerReloaderConfig) defaults() error {
+	if c.Settings == nil {
+		return fmt.Errorf("settings function is required")
+	}
+
+	if c.Serve == nil {
+		return fmt.Errorf("serve function is required")
+	}
+
+	return nil
+}
+
+// ListenerReloader is a reload.Reloader that rebinds a listener when the
+// listener settings change.
+//
+// The new listener is opened first and handed to the consumer, and only then
+// the old one is closed. If the new listener can't be opened or served, the
+// old one keeps serving.
+type ListenerReloader struct {
+	cfg ListenerReloaderConfig
+
+	mu       sync.Mutex
+	settings ListenerSettings
+	listener net.Listener
+}
+
+// NewListenerReloader returns a new ListenerReloader, the initial listener is
+// opened and served on the creation.
+func NewListenerReloader(ctx context.Context, cfg ListenerReloaderConfig) (*ListenerReloader, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	l := &ListenerReloader{cfg: cfg}
+	err = l.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Listener returns the current listener.
+func (l *ListenerReloader) Listener() net.Listener {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.listener
+}
+
+// Reload satisfies reload.Reloader interface.
+func (l *ListenerReloader) Reload(ctx context.Context, _ string) error {
+	s, err := l.cfg.Settings(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get listener settings: %w", err)
+	}
+	s.defaults()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.listener != nil && s == l.settings {
+		return nil
+	}
+
+	lc := net.ListenConfig{}
+	if s.ReusePort {
+		lc.Control = reusePortControl
+	}
+	nl, err := lc.Listen(ctx, s.Network, s.Address)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s %q: %w", s.Network, s.Address, err)
+	}
+
+	err = l.cfg.Serve(nl)
+	if err != nil {
+		_ = nl.Close()
+		return fmt.Errorf("could not serve listener: %w", err)
+	}
+
+	old := l.listener
+	l.listener = nl
+	l.settings = s
+
+	if old != nil {
+		err := old.Close()
+		if err != nil {
+			return fmt.Errorf("could not close old listener: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the current listener.
+func (l *ListenerReloader) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.listener.Close()
+}
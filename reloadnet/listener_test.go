@@ -0,0 +1,148 @@
+package reloadnet_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadnet"
+)
+
+func TestListenerReloader(t *testing.T) {
+	tests := map[string]struct {
+		settings  []reloadnet.ListenerSettings
+		serveErr  error
+		expRebind bool
+		expErr    bool
+	}{
+		"The same settings should not rebind the listener.": {
+			settings: []reloadnet.ListenerSettings{
+				{Address: "127.0.0.1:0"},
+				{Address: "127.0.0.1:0"},
+			},
+		},
+
+		"New settings should rebind the listener.": {
+			settings: []reloadnet.ListenerSettings{
+				{Address: "127.0.0.1:0"},
+				{Address: "localhost:0"},
+			},
+			expRebind: true,
+		},
+
+		"If the new listener can't be served, the old one should be kept.": {
+			settings: []reloadnet.ListenerSettings{
+				{Address: "127.0.0.1:0"},
+				{Address: "localhost:0"},
+			},
+			serveErr: fmt.Errorf("something"),
+			expErr:   true,
+		},
+
+		"If the new listener can't be opened, the old one should be kept.": {
+			settings: []reloadnet.ListenerSettings{
+				{Address: "127.0.0.1:0"},
+				{Address: "invalid"},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			settings := test.settings
+			served := 0
+			r, err := reloadnet.NewListenerReloader(context.TODO(), reloadnet.ListenerReloaderConfig{
+				Settings: func(ctx context.Context) (reloadnet.ListenerSettings, error) {
+					s := settings[0]
+					settings = settings[1:]
+					return s, nil
+				},
+				Serve: func(l net.Listener) error {
+					served++
+					if served > 1 && test.serveErr != nil {
+						return test.serveErr
+					}
+					return nil
+				},
+			})
+			require.NoError(err)
+			defer r.Close()
+			old := r.Listener()
+
+			// Execute.
+			err = r.Reload(context.TODO(), "test")
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			if test.expRebind {
+				assert.NotSame(old, r.Listener())
+				_, err := net.Dial("tcp", old.Addr().String())
+				assert.Error(err, "old listener should be closed")
+			} else {
+				assert.Same(old, r.Listener())
+			}
+			conn, err := net.Dial("tcp", r.Listener().Addr().String())
+			require.NoError(err)
+			conn.Close()
+		})
+	}
+}
+
+func TestListenerReloaderReusePort(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_REUSEPORT rebind is only tested on Linux")
+	}
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	version := "v1"
+	settings := reloadnet.ListenerSettings{Address: "127.0.0.1:0", ReusePort: true}
+	r, err := reloadnet.NewListenerReloader(context.TODO(), reloadnet.ListenerReloaderConfig{
+		Settings: func(ctx context.Context) (reloadnet.ListenerSettings, error) { return settings, nil },
+		Serve: func(l net.Listener) error {
+			v := version
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, v)
+			})}
+			go func() { _ = srv.Serve(l) }()
+			return nil
+		},
+	})
+	require.NoError(err)
+	defer r.Close()
+
+	// Execute.
+	// Rebind on the same port while the old listener is open, only possible
+	// with SO_REUSEPORT on both sockets.
+	addr := r.Listener().Addr().String()
+	version = "v2"
+	settings = reloadnet.ListenerSettings{Address: addr, ReusePort: true}
+	require.NoError(r.Reload(context.TODO(), "test"))
+
+	// Check.
+	resp, err := http.Get("http://" + addr)
+	require.NoError(err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(err)
+	assert.Equal("v2", string(body))
+}
@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package reloadnet
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func reusePortControl(_, _ string, _ syscall.RawConn) error {
+	return fmt.Errorf("SO_REUSEPORT is not supported on this platform")
+}
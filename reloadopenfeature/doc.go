@@ -0,0 +1,2 @@
+// Package reloadopenfeature has the OpenFeature integrations of the reload mechanism.
+package reloadopenfeature
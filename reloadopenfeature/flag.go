@@ -0,0 +1,171 @@
+package reloadopenfeature
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/open-feature/go-sdk/openfeature"
+
+	"github.com/slok/reload"
+)
+
+// EventHandlers knows how to register OpenFeature event handlers,
+// `*openfeature.Client` satisfies this interface.
+type EventHandlers interface {
+	AddHandler(eventType openfeature.EventType, callback openfeature.EventCallback)
+	RemoveHandler(eventType openfeature.EventType, callback openfeature.EventCallback)
+}
+
+// FlagNotifierConfig is the configuration of the FlagNotifier.
+type FlagNotifierConfig struct {
+	// EventHandlers is where the notifier will listen to the provider events.
+	// By default the OpenFeature global API (all the providers).
+	EventHandlers EventHandlers
+	// Keys are the flag keys that will trigger a reload when they change.
+	// By default any flag.
+	Keys []string
+	// TriggerID is the ID used on the triggers.
+	// By default `openfeature`.
+	TriggerID string
+}
+
+func (c *FlagNotifierConfig) defaults() error {
+	if c.EventHandlers == nil {
+		c.EventHandlers = apiEventHandlers{}
+	}
+
+	if c.TriggerID == "" {
+		c.TriggerID = "openfeature"
+	}
+
+	return nil
+}
+
+// FlagNotifier is a reload.Notifier that triggers a reload when an OpenFeature
+// provider emits a configuration change event, so flag changes can trigger
+// reloads.
+//
+// The changed flag keys are set as the trigger keys, and the provider name and
+// event message on the trigger metadata (`provider` and `message` keys).
+// If the provider doesn't inform about the changed flags, the event always
+// triggers a reload, even if the notifier is filtering by flag keys.
+//
+// If multiple changes happen while the manager is busy, they are merged into
+// a single trigger.
+//
+// Close should be called to stop listening to the provider events.
+type FlagNotifier struct {
+	cfg      FlagNotifierConfig
+	callback openfeature.EventCallback
+	ready    chan struct{}
+
+	mu      sync.Mutex
+	pending *reload.TriggerEvent
+}
+
+// NewFlagNotifier returns a new FlagNotifier, it starts listening to the
+// provider events.
+func NewFlagNotifier(cfg FlagNotifierConfig) (*FlagNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	f := &FlagNotifier{
+		cfg:   cfg,
+		ready: make(chan struct{}, 1),
+	}
+	callback := f.handleEvent
+	f.callback = &callback
+	cfg.EventHandlers.AddHandler(openfeature.ProviderConfigChange, f.callback)
+
+	return f, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (f *FlagNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := f.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (f *FlagNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return reload.TriggerEvent{}, ctx.Err()
+		case <-f.ready:
+		}
+
+		f.mu.Lock()
+		t := f.pending
+		f.pending = nil
+		f.mu.Unlock()
+
+		if t != nil {
+			return *t, nil
+		}
+	}
+}
+
+// Close stops listening to the provider events.
+func (f *FlagNotifier) Close() error {
+	f.cfg.EventHandlers.RemoveHandler(openfeature.ProviderConfigChange, f.callback)
+	return nil
+}
+
+func (f *FlagNotifier) handleEvent(e openfeature.EventDetails) {
+	keys := e.FlagChanges
+	if len(f.cfg.Keys) > 0 && len(keys) > 0 {
+		keys = slices.DeleteFunc(slices.Clone(keys), func(k string) bool { return !slices.Contains(f.cfg.Keys, k) })
+		if len(keys) == 0 {
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.pending == nil {
+		f.pending = &reload.TriggerEvent{ID: f.cfg.TriggerID}
+	} else if len(f.pending.Keys) == 0 {
+		// Unknown changes on the pending trigger, we can't know what changed.
+		keys = nil
+	}
+
+	// Merge with the pending trigger.
+	switch {
+	case len(keys) == 0:
+		f.pending.Keys = nil
+	default:
+		for _, k := range keys {
+			if !slices.Contains(f.pending.Keys, k) {
+				f.pending.Keys = append(f.pending.Keys, k)
+			}
+		}
+		sort.Strings(f.pending.Keys)
+	}
+	f.pending.Metadata = map[string]string{
+		"provider": e.ProviderName,
+		"message":  e.Message,
+	}
+
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
+
+// apiEventHandlers registers the handlers on the OpenFeature global API.
+type apiEventHandlers struct{}
+
+func (apiEventHandlers) AddHandler(eventType openfeature.EventType, callback openfeature.EventCallback) {
+	openfeature.AddHandler(eventType, callback)
+}
+
+func (apiEventHandlers) RemoveHandler(eventType openfeature.EventType, callback openfeature.EventCallback) {
+	openfeature.RemoveHandler(eventType, callback)
+}
@@ -0,0 +1,109 @@
+package reloadopenfeature_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadopenfeature"
+)
+
+type testEventHandlers struct {
+	callbacks map[openfeature.EventType]openfeature.EventCallback
+}
+
+func (t *testEventHandlers) AddHandler(eventType openfeature.EventType, callback openfeature.EventCallback) {
+	t.callbacks[eventType] = callback
+}
+
+func (t *testEventHandlers) RemoveHandler(eventType openfeature.EventType, callback openfeature.EventCallback) {
+	delete(t.callbacks, eventType)
+}
+
+func (t *testEventHandlers) emit(provider string, flags ...string) {
+	cb, ok := t.callbacks[openfeature.ProviderConfigChange]
+	if !ok {
+		return
+	}
+	(*cb)(openfeature.EventDetails{
+		ProviderName: provider,
+		ProviderEventDetails: openfeature.ProviderEventDetails{
+			Message:     "changed",
+			FlagChanges: flags,
+		},
+	})
+}
+
+func TestFlagNotifier(t *testing.T) {
+	tests := map[string]struct {
+		keys       []string
+		events     [][]string
+		expTrigger *reload.TriggerEvent
+	}{
+		"A flag change should trigger with the changed flags.": {
+			events:     [][]string{{"flag-b", "flag-a"}},
+			expTrigger: &reload.TriggerEvent{ID: "openfeature", Keys: []string{"flag-a", "flag-b"}, Metadata: map[string]string{"provider": "test", "message": "changed"}},
+		},
+
+		"Multiple flag changes should be merged into a single trigger.": {
+			events:     [][]string{{"flag-a"}, {"flag-c", "flag-a"}},
+			expTrigger: &reload.TriggerEvent{ID: "openfeature", Keys: []string{"flag-a", "flag-c"}, Metadata: map[string]string{"provider": "test", "message": "changed"}},
+		},
+
+		"Changes of not filtered flags should not trigger.": {
+			keys:   []string{"flag-a"},
+			events: [][]string{{"flag-b"}},
+		},
+
+		"Changes of filtered flags should trigger with only the filtered flags.": {
+			keys:       []string{"flag-a"},
+			events:     [][]string{{"flag-b", "flag-a"}},
+			expTrigger: &reload.TriggerEvent{ID: "openfeature", Keys: []string{"flag-a"}, Metadata: map[string]string{"provider": "test", "message": "changed"}},
+		},
+
+		"Changes without flag information should always trigger.": {
+			keys:       []string{"flag-a"},
+			events:     [][]string{{"flag-a"}, {}},
+			expTrigger: &reload.TriggerEvent{ID: "openfeature", Metadata: map[string]string{"provider": "test", "message": "changed"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			eh := &testEventHandlers{callbacks: map[openfeature.EventType]openfeature.EventCallback{}}
+			n, err := reloadopenfeature.NewFlagNotifier(reloadopenfeature.FlagNotifierConfig{
+				EventHandlers: eh,
+				Keys:          test.keys,
+			})
+			require.NoError(err)
+
+			// Execute.
+			for _, flags := range test.events {
+				eh.emit("test", flags...)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			gotTrigger, err := n.NotifyTrigger(ctx)
+
+			// Check.
+			if test.expTrigger != nil {
+				require.NoError(err)
+				assert.Equal(*test.expTrigger, gotTrigger)
+			} else {
+				assert.ErrorIs(err, context.DeadlineExceeded)
+			}
+
+			require.NoError(n.Close())
+			assert.Empty(eh.callbacks)
+		})
+	}
+}
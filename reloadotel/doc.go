@@ -0,0 +1,2 @@
+// Package reloadotel has the OpenTelemetry integrations of the reload mechanism.
+package reloadotel
@@ -0,0 +1,203 @@
+package reloadotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/slok/reload"
+)
+
+const meterName = "github.com/slok/reload/reloadotel"
+
+// RecorderConfig is the configuration of the Recorder.
+type RecorderConfig struct {
+	// MeterProvider is the meter provider used to create the metrics.
+	// By default the global meter provider.
+	MeterProvider metric.MeterProvider
+	// DurationBuckets are the explicit bucket boundaries of the duration
+	// histograms, in seconds.
+	// By default the SDK default boundaries.
+	DurationBuckets []float64
+}
+
+func (c *RecorderConfig) defaults() error {
+	if c.MeterProvider == nil {
+		c.MeterProvider = otel.GetMeterProvider()
+	}
+
+	return nil
+}
+
+// Recorder is a reload.MetricsRecorder that records the metrics with
+// OpenTelemetry, so they can be exported with OTLP or any other exporter of
+// the meter provider.
+type Recorder struct {
+	reloads           metric.Int64Counter
+	reloadDuration    metric.Float64Histogram
+	reloadsInProgress metric.Int64UpDownCounter
+	reloaderDuration  metric.Float64Histogram
+	lastSuccess       metric.Float64Gauge
+	configGeneration  metric.Int64Gauge
+	reloadFailures    metric.Int64Counter
+	receivedTriggers  metric.Int64Counter
+	queuedTriggers    metric.Int64Counter
+	coalescedTriggers metric.Int64Counter
+	droppedTriggers   metric.Int64Counter
+	abandoned         metric.Int64UpDownCounter
+	circuitOpen       metric.Int64Gauge
+	notifierStale     metric.Int64Gauge
+
+	lastSuccessNanos atomic.Int64
+}
+
+var _ reload.MetricsRecorder = &Recorder{}
+
+// NewRecorder returns a new Recorder with the metrics created on the meter
+// provider.
+//
+// The initial configuration is loaded by the application before the manager
+// starts, so the creation of the recorder is taken as the first successful
+// reload (generation 0).
+func NewRecorder(cfg RecorderConfig) (*Recorder, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	meter := cfg.MeterProvider.Meter(meterName)
+	var histogramOpts []metric.Float64HistogramOption
+	if len(cfg.DurationBuckets) > 0 {
+		histogramOpts = append(histogramOpts, metric.WithExplicitBucketBoundaries(cfg.DurationBuckets...))
+	}
+
+	r := &Recorder{}
+	var errs [15]error
+	r.reloads, errs[0] = meter.Int64Counter("reload.reloads",
+		metric.WithDescription("The number of reload processes."))
+	r.reloadDuration, errs[1] = meter.Float64Histogram("reload.duration",
+		append([]metric.Float64HistogramOption{metric.WithDescription("The duration of the reload processes."), metric.WithUnit("s")}, histogramOpts...)...)
+	r.reloadsInProgress, errs[2] = meter.Int64UpDownCounter("reload.in_progress",
+		metric.WithDescription("The number of reload processes in progress."))
+	r.reloaderDuration, errs[3] = meter.Float64Histogram("reload.reloader.duration",
+		append([]metric.Float64HistogramOption{metric.WithDescription("The duration of the reloaders reload."), metric.WithUnit("s")}, histogramOpts...)...)
+	r.lastSuccess, errs[4] = meter.Float64Gauge("reload.last_success.timestamp",
+		metric.WithDescription("The timestamp of the last successful reload."), metric.WithUnit("s"))
+	r.configGeneration, errs[5] = meter.Int64Gauge("reload.config.generation",
+		metric.WithDescription("The current config generation, the generation of the last successful reload."))
+	r.reloadFailures, errs[6] = meter.Int64Counter("reload.failures",
+		metric.WithDescription("The number of failed reloads by trigger source."))
+	r.droppedTriggers, errs[7] = meter.Int64Counter("reload.dropped_triggers",
+		metric.WithDescription("The number of dropped triggers by trigger source and reason."))
+	_, errs[8] = meter.Float64ObservableGauge("reload.since_last_success",
+		metric.WithDescription("The time since the last successful reload."), metric.WithUnit("s"),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			o.Observe(time.Since(time.Unix(0, r.lastSuccessNanos.Load())).Seconds())
+			return nil
+		}))
+	r.receivedTriggers, errs[9] = meter.Int64Counter("reload.received_triggers",
+		metric.WithDescription("The number of received triggers by trigger source."))
+	r.queuedTriggers, errs[10] = meter.Int64Counter("reload.queued_triggers",
+		metric.WithDescription("The number of triggers that waited for the previous reload by trigger source."))
+	r.coalescedTriggers, errs[11] = meter.Int64Counter("reload.coalesced_triggers",
+		metric.WithDescription("The number of triggers replaced by newer ones by trigger source."))
+	r.abandoned, errs[12] = meter.Int64UpDownCounter("reload.reloader.abandoned",
+		metric.WithDescription("The number of reloaders that exceeded their timeout and didn't return yet."))
+	r.circuitOpen, errs[13] = meter.Int64Gauge("reload.reloader.circuit_open",
+		metric.WithDescription("If the reloader circuit breaker is open (1) or closed (0)."))
+	r.notifierStale, errs[14] = meter.Int64Gauge("reload.notifier.stale",
+		metric.WithDescription("If the notifier is stale (1) or alive (0)."))
+	err = errors.Join(errs[:]...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create metrics: %w", err)
+	}
+	r.setLastSuccess(context.Background(), 0, time.Now())
+
+	return r, nil
+}
+
+// ObserveReloadDuration satisfies reload.MetricsRecorder interface.
+func (r *Recorder) ObserveReloadDuration(ctx context.Context, source string, success bool, duration time.Duration) {
+	attrs := metric.WithAttributes(attribute.String("source", source), attribute.Bool("success", success))
+	r.reloads.Add(ctx, 1, attrs)
+	r.reloadDuration.Record(ctx, duration.Seconds(), attrs)
+}
+
+// AddReloadsInProgress satisfies reload.MetricsRecorder interface.
+func (r *Recorder) AddReloadsInProgress(ctx context.Context, delta int) {
+	r.reloadsInProgress.Add(ctx, int64(delta))
+}
+
+// ObserveReloaderDuration satisfies reload.MetricsRecorder interface.
+func (r *Recorder) ObserveReloaderDuration(ctx context.Context, reloader string, priority int, success bool, duration time.Duration) {
+	r.reloaderDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("reloader", reloader),
+		attribute.Int("priority", priority),
+		attribute.Bool("success", success),
+	))
+}
+
+// SetLastSuccessfulReload satisfies reload.MetricsRecorder interface.
+func (r *Recorder) SetLastSuccessfulReload(ctx context.Context, generation uint64, at time.Time) {
+	r.setLastSuccess(ctx, generation, at)
+}
+
+// IncReloadFailure satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncReloadFailure(ctx context.Context, source string) {
+	r.reloadFailures.Add(ctx, 1, metric.WithAttributes(attribute.String("source", source)))
+}
+
+// IncTriggerReceived satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncTriggerReceived(ctx context.Context, source string) {
+	r.receivedTriggers.Add(ctx, 1, metric.WithAttributes(attribute.String("source", source)))
+}
+
+// IncQueuedTrigger satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncQueuedTrigger(ctx context.Context, source string) {
+	r.queuedTriggers.Add(ctx, 1, metric.WithAttributes(attribute.String("source", source)))
+}
+
+// IncCoalescedTrigger satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncCoalescedTrigger(ctx context.Context, source string) {
+	r.coalescedTriggers.Add(ctx, 1, metric.WithAttributes(attribute.String("source", source)))
+}
+
+// IncDroppedTrigger satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncDroppedTrigger(ctx context.Context, source string, reason reload.DropReason) {
+	r.droppedTriggers.Add(ctx, 1, metric.WithAttributes(attribute.String("source", source), attribute.String("reason", string(reason))))
+}
+
+// AddAbandonedReloaders satisfies reload.MetricsRecorder interface.
+func (r *Recorder) AddAbandonedReloaders(ctx context.Context, reloader string, delta int) {
+	r.abandoned.Add(ctx, int64(delta), metric.WithAttributes(attribute.String("reloader", reloader)))
+}
+
+// SetCircuitOpen satisfies reload.MetricsRecorder interface.
+func (r *Recorder) SetCircuitOpen(ctx context.Context, reloader string, open bool) {
+	var v int64
+	if open {
+		v = 1
+	}
+	r.circuitOpen.Record(ctx, v, metric.WithAttributes(attribute.String("reloader", reloader)))
+}
+
+// SetNotifierStale satisfies reload.MetricsRecorder interface.
+func (r *Recorder) SetNotifierStale(ctx context.Context, notifier string, stale bool) {
+	var v int64
+	if stale {
+		v = 1
+	}
+	r.notifierStale.Record(ctx, v, metric.WithAttributes(attribute.String("notifier", notifier)))
+}
+
+func (r *Recorder) setLastSuccess(ctx context.Context, generation uint64, at time.Time) {
+	r.lastSuccessNanos.Store(at.UnixNano())
+	r.lastSuccess.Record(ctx, float64(at.UnixNano())/1e9)
+	r.configGeneration.Record(ctx, int64(generation))
+}
@@ -0,0 +1,143 @@
+package reloadotel_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadotel"
+)
+
+// collect returns the collected metrics by name.
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.TODO(), &rm))
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	return metrics
+}
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	reader := sdkmetric.NewManualReader()
+	r, err := reloadotel.NewRecorder(reloadotel.RecorderConfig{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(err)
+
+	// Execute.
+	ctx := context.TODO()
+	r.AddReloadsInProgress(ctx, 1)
+	r.ObserveReloaderDuration(ctx, "config", 0, true, 100*time.Millisecond)
+	r.ObserveReloadDuration(ctx, "file", true, 200*time.Millisecond)
+	r.SetLastSuccessfulReload(ctx, 1, time.Now().Add(-time.Minute))
+	r.AddReloadsInProgress(ctx, -1)
+	r.AddReloadsInProgress(ctx, 1)
+	r.ObserveReloadDuration(ctx, "file", false, time.Second)
+	r.IncReloadFailure(ctx, "file")
+	r.IncTriggerReceived(ctx, "webhook")
+	r.IncTriggerReceived(ctx, "webhook")
+	r.IncQueuedTrigger(ctx, "webhook")
+	r.IncCoalescedTrigger(ctx, "webhook")
+	r.IncDroppedTrigger(ctx, "webhook", reload.DropReasonReloadInProgress)
+	r.AddAbandonedReloaders(ctx, "config", 1)
+	r.SetCircuitOpen(ctx, "config", true)
+	r.SetNotifierStale(ctx, "watch", true)
+
+	// Check.
+	metrics := collect(t, reader)
+	successAttrs := attribute.NewSet(attribute.String("source", "file"), attribute.Bool("success", true))
+	failAttrs := attribute.NewSet(attribute.String("source", "file"), attribute.Bool("success", false))
+
+	reloads := metrics["reload.reloads"].(metricdata.Sum[int64])
+	assert.ElementsMatch([]metricdata.DataPoint[int64]{
+		{Attributes: successAttrs, Value: 1},
+		{Attributes: failAttrs, Value: 1},
+	}, normalize(reloads.DataPoints))
+
+	duration := metrics["reload.duration"].(metricdata.Histogram[float64])
+	assert.Len(duration.DataPoints, 2)
+
+	inProgress := metrics["reload.in_progress"].(metricdata.Sum[int64])
+	require.Len(inProgress.DataPoints, 1)
+	assert.Equal(int64(1), inProgress.DataPoints[0].Value)
+
+	reloaderDuration := metrics["reload.reloader.duration"].(metricdata.Histogram[float64])
+	require.Len(reloaderDuration.DataPoints, 1)
+	assert.Equal(attribute.NewSet(
+		attribute.String("reloader", "config"),
+		attribute.Int("priority", 0),
+		attribute.Bool("success", true),
+	), reloaderDuration.DataPoints[0].Attributes)
+	assert.InDelta(0.1, reloaderDuration.DataPoints[0].Sum, 0.001)
+
+	generation := metrics["reload.config.generation"].(metricdata.Gauge[int64])
+	require.Len(generation.DataPoints, 1)
+	assert.Equal(int64(1), generation.DataPoints[0].Value)
+
+	sinceLastSuccess := metrics["reload.since_last_success"].(metricdata.Gauge[float64])
+	require.Len(sinceLastSuccess.DataPoints, 1)
+	assert.InDelta(60, sinceLastSuccess.DataPoints[0].Value, 5)
+
+	failures := metrics["reload.failures"].(metricdata.Sum[int64])
+	assert.Equal([]metricdata.DataPoint[int64]{
+		{Attributes: attribute.NewSet(attribute.String("source", "file")), Value: 1},
+	}, normalize(failures.DataPoints))
+
+	webhookAttrs := attribute.NewSet(attribute.String("source", "webhook"))
+	received := metrics["reload.received_triggers"].(metricdata.Sum[int64])
+	assert.Equal([]metricdata.DataPoint[int64]{{Attributes: webhookAttrs, Value: 2}}, normalize(received.DataPoints))
+
+	queued := metrics["reload.queued_triggers"].(metricdata.Sum[int64])
+	assert.Equal([]metricdata.DataPoint[int64]{{Attributes: webhookAttrs, Value: 1}}, normalize(queued.DataPoints))
+
+	coalesced := metrics["reload.coalesced_triggers"].(metricdata.Sum[int64])
+	assert.Equal([]metricdata.DataPoint[int64]{{Attributes: webhookAttrs, Value: 1}}, normalize(coalesced.DataPoints))
+
+	abandoned := metrics["reload.reloader.abandoned"].(metricdata.Sum[int64])
+	assert.Equal([]metricdata.DataPoint[int64]{
+		{Attributes: attribute.NewSet(attribute.String("reloader", "config")), Value: 1},
+	}, normalize(abandoned.DataPoints))
+
+	circuitOpen := metrics["reload.reloader.circuit_open"].(metricdata.Gauge[int64])
+	assert.Equal([]metricdata.DataPoint[int64]{
+		{Attributes: attribute.NewSet(attribute.String("reloader", "config")), Value: 1},
+	}, normalize(circuitOpen.DataPoints))
+
+	notifierStale := metrics["reload.notifier.stale"].(metricdata.Gauge[int64])
+	assert.Equal([]metricdata.DataPoint[int64]{
+		{Attributes: attribute.NewSet(attribute.String("notifier", "watch")), Value: 1},
+	}, normalize(notifierStale.DataPoints))
+
+	dropped := metrics["reload.dropped_triggers"].(metricdata.Sum[int64])
+	assert.Equal([]metricdata.DataPoint[int64]{
+		{Attributes: attribute.NewSet(attribute.String("source", "webhook"), attribute.String("reason", "reload_in_progress")), Value: 1},
+	}, normalize(dropped.DataPoints))
+}
+
+// normalize removes the non deterministic fields of the data points.
+func normalize(dps []metricdata.DataPoint[int64]) []metricdata.DataPoint[int64] {
+	for i := range dps {
+		dps[i].StartTime = time.Time{}
+		dps[i].Time = time.Time{}
+		dps[i].Exemplars = nil
+	}
+
+	return dps
+}
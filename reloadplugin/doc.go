@@ -0,0 +1,13 @@
+// Package reloadplugin has the Go plugin integrations of the reload mechanism.
+//
+// Go plugins have important constraints that should be known before using them:
+//
+//   - Plugins are only supported on Linux, FreeBSD and macOS, with cgo enabled.
+//     On other platforms loading a plugin always fails.
+//   - A loaded plugin can't be unloaded, the old versions stay in memory
+//     (quarantined) until the process ends.
+//   - The same path can't be loaded twice, Go returns the already loaded plugin,
+//     so every version must be built on a different path (e.g: `plugin-v2.so`).
+//   - The plugin and the host must be built with the same Go version and the
+//     same versions of the shared dependencies.
+package reloadplugin
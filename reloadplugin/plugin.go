@@ -0,0 +1,147 @@
+package reloadplugin
+
+import (
+	"context"
+	"fmt"
+	"plugin"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ReloaderConfig is the configuration of the Reloader.
+type ReloaderConfig[T any] struct {
+	// Path returns the path of the plugin version that should be loaded, it
+	// will be called on the creation and on every reload.
+	Path func(ctx context.Context) (string, error)
+	// Symbol is the exported symbol of the plugin that satisfies T, it can be a
+	// function, or a variable of T type.
+	Symbol string
+	// Verify is an optional verification of the loaded plugin (e.g: check other
+	// exported symbols or a version), if it fails the plugin will not be used.
+	Verify func(p *plugin.Plugin) error
+}
+
+func (c *ReloaderConfig[T]) defaults() error {
+	if c.Path == nil {
+		return fmt.Errorf("path function is required")
+	}
+
+	if c.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+
+	return nil
+}
+
+// Reloader is a reload.Reloader that loads a new version of a Go plugin on
+// every reload, verifies its exported symbol and swaps it atomically, the users
+// should get the plugin implementation using Get on every use.
+//
+// If the path didn't change, the reload doesn't load anything. The replaced
+// versions are quarantined: they can't be unloaded nor loaded again.
+type Reloader[T any] struct {
+	cfg     ReloaderConfig[T]
+	current atomic.Pointer[T]
+
+	mu          sync.Mutex
+	path        string
+	quarantined []string
+}
+
+// NewReloader returns a new Reloader, the plugin is loaded on the creation.
+func NewReloader[T any](ctx context.Context, cfg ReloaderConfig[T]) (*Reloader[T], error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	r := &Reloader[T]{cfg: cfg}
+	err = r.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Get returns the current plugin implementation.
+func (r *Reloader[T]) Get() T {
+	return *r.current.Load()
+}
+
+// Quarantined returns the paths of the replaced plugin versions.
+func (r *Reloader[T]) Quarantined() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.quarantined)
+}
+
+// Reload satisfies reload.Reloader interface.
+func (r *Reloader[T]) Reload(ctx context.Context, _ string) error {
+	path, err := r.cfg.Path(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get plugin path: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if path == r.path {
+		return nil
+	}
+
+	if slices.Contains(r.quarantined, path) {
+		return fmt.Errorf("%q plugin is quarantined, a new version requires a new path", path)
+	}
+
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open %q plugin: %w", path, err)
+	}
+
+	impl, err := r.implementation(p)
+	if err != nil {
+		// Once opened it can't be opened again.
+		r.quarantined = append(r.quarantined, path)
+		return fmt.Errorf("invalid %q plugin: %w", path, err)
+	}
+
+	r.current.Store(&impl)
+	if r.path != "" {
+		r.quarantined = append(r.quarantined, r.path)
+	}
+	r.path = path
+
+	return nil
+}
+
+func (r *Reloader[T]) implementation(p *plugin.Plugin) (T, error) {
+	var empty T
+
+	sym, err := p.Lookup(r.cfg.Symbol)
+	if err != nil {
+		return empty, fmt.Errorf("could not get %q symbol: %w", r.cfg.Symbol, err)
+	}
+
+	// Variables are exported as pointers.
+	var impl T
+	switch v := sym.(type) {
+	case T:
+		impl = v
+	case *T:
+		impl = *v
+	default:
+		return empty, fmt.Errorf("%q symbol has an invalid %T type", r.cfg.Symbol, sym)
+	}
+
+	if r.cfg.Verify != nil {
+		err := r.cfg.Verify(p)
+		if err != nil {
+			return empty, fmt.Errorf("verification failed: %w", err)
+		}
+	}
+
+	return impl, nil
+}
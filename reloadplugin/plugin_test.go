@@ -0,0 +1,58 @@
+package reloadplugin_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload/reloadplugin"
+)
+
+type Greeter interface {
+	Greet(name string) string
+}
+
+func TestNewReloader(t *testing.T) {
+	tests := map[string]struct {
+		cfg    reloadplugin.ReloaderConfig[Greeter]
+		expErr bool
+	}{
+		"A missing path function should fail.": {
+			cfg:    reloadplugin.ReloaderConfig[Greeter]{Symbol: "Greeter"},
+			expErr: true,
+		},
+
+		"A missing symbol should fail.": {
+			cfg: reloadplugin.ReloaderConfig[Greeter]{
+				Path: func(ctx context.Context) (string, error) { return "greeter.so", nil },
+			},
+			expErr: true,
+		},
+
+		"A missing plugin should fail.": {
+			cfg: reloadplugin.ReloaderConfig[Greeter]{
+				Symbol: "Greeter",
+				Path: func(ctx context.Context) (string, error) {
+					return filepath.Join(t.TempDir(), "missing.so"), nil
+				},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, err := reloadplugin.NewReloader(context.TODO(), test.cfg)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package reloadpostgres has the PostgreSQL integrations of the reload mechanism.
+package reloadpostgres
@@ -0,0 +1,189 @@
+package reloadpostgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/internal/backoff"
+)
+
+// Conn is the PostgreSQL connection used to listen for notifications,
+// `*pgx.Conn` satisfies this interface.
+type Conn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+// ListenNotifierConfig is the configuration of the ListenNotifier.
+type ListenNotifierConfig struct {
+	// ConnString is the PostgreSQL connection string, used when Connect is not set.
+	ConnString string
+	// Connect returns a new connection, it will be used on the first connection
+	// and on every reconnection.
+	// By default a new pgx connection using ConnString.
+	Connect func(ctx context.Context) (Conn, error)
+	// Channel is the notification channel that will be listened.
+	Channel string
+	// MinBackoff is the initial wait time before reconnecting.
+	// By default 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum wait time before reconnecting.
+	// By default 30s.
+	MaxBackoff time.Duration
+}
+
+func (c *ListenNotifierConfig) defaults() error {
+	if c.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+
+	if c.Connect == nil {
+		if c.ConnString == "" {
+			return fmt.Errorf("connection string or connect function is required")
+		}
+		connString := c.ConnString
+		c.Connect = func(ctx context.Context) (Conn, error) { return pgx.Connect(ctx, connString) }
+	}
+
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 250 * time.Millisecond
+	}
+
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 30 * time.Second
+	}
+
+	if c.MaxBackoff < c.MinBackoff {
+		return fmt.Errorf("max backoff can't be lower than min backoff")
+	}
+
+	return nil
+}
+
+// ListenNotifier is a reload.Notifier that issues `LISTEN {channel}` on a
+// PostgreSQL connection and triggers a reload for every `NOTIFY` received on
+// the channel.
+//
+// The notification payload is used as the trigger ID (the channel name if the
+// payload is empty), and the channel, payload and the notifying backend PID are
+// set on the trigger metadata (`channel`, `payload` and `pid` keys).
+//
+// When the connection breaks, the notifier will reconnect with exponential
+// backoff and listen to the channel again. Notifications sent while
+// disconnected are lost, so it's a good practice to reload on reconnections;
+// the notifier does it triggering with the `reconnected` metadata set to `true`.
+type ListenNotifier struct {
+	cfg       ListenNotifierConfig
+	conn      Conn
+	backoff   *backoff.Backoff
+	connected bool // Has been connected at least once.
+}
+
+// NewListenNotifier returns a new ListenNotifier.
+func NewListenNotifier(cfg ListenNotifierConfig) (*ListenNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &ListenNotifier{
+		cfg:     cfg,
+		backoff: backoff.New(cfg.MinBackoff, cfg.MaxBackoff),
+	}, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (l *ListenNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := l.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (l *ListenNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	for {
+		if ctx.Err() != nil {
+			return reload.TriggerEvent{}, ctx.Err()
+		}
+
+		// Connect and listen if required.
+		if l.conn == nil {
+			err := l.listen(ctx)
+			if err != nil {
+				l.backoff.Wait(ctx)
+				continue
+			}
+
+			// Notifications could be lost while we were disconnected.
+			if l.connected {
+				return reload.TriggerEvent{
+					ID:       l.cfg.Channel,
+					Metadata: map[string]string{"channel": l.cfg.Channel, "reconnected": "true"},
+				}, nil
+			}
+			l.connected = true
+		}
+
+		n, err := l.conn.WaitForNotification(ctx)
+		if err != nil {
+			l.close()
+			if ctx.Err() != nil {
+				return reload.TriggerEvent{}, ctx.Err()
+			}
+			l.backoff.Wait(ctx)
+			continue
+		}
+
+		l.backoff.Reset()
+		id := n.Payload
+		if id == "" {
+			id = n.Channel
+		}
+
+		return reload.TriggerEvent{
+			ID: id,
+			Metadata: map[string]string{
+				"channel": n.Channel,
+				"payload": n.Payload,
+				"pid":     strconv.FormatUint(uint64(n.PID), 10),
+			},
+		}, nil
+	}
+}
+
+func (l *ListenNotifier) listen(ctx context.Context) error {
+	conn, err := l.cfg.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("could not connect: %w", err)
+	}
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.cfg.Channel}.Sanitize())
+	if err != nil {
+		_ = conn.Close(context.Background())
+		return fmt.Errorf("could not listen on %q channel: %w", l.cfg.Channel, err)
+	}
+
+	l.conn = conn
+	return nil
+}
+
+func (l *ListenNotifier) close() {
+	if l.conn == nil {
+		return
+	}
+
+	_ = l.conn.Close(context.Background())
+	l.conn = nil
+}
+
+// Close closes the connection, if any.
+func (l *ListenNotifier) Close() error {
+	l.close()
+	return nil
+}
@@ -0,0 +1,172 @@
+package reloadpostgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadpostgres"
+)
+
+type testConnResult struct {
+	notification *pgconn.Notification
+	err          error
+}
+
+type testConn struct {
+	execs   []string
+	results []testConnResult
+	closed  bool
+}
+
+func (t *testConn) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	t.execs = append(t.execs, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (t *testConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	if len(t.results) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	r := t.results[0]
+	t.results = t.results[1:]
+	return r.notification, r.err
+}
+
+func (t *testConn) Close(ctx context.Context) error {
+	t.closed = true
+	return nil
+}
+
+func TestListenNotifier(t *testing.T) {
+	tests := map[string]struct {
+		channel     string
+		conns       func() []*testConn
+		connectErrs []error
+		expTriggers []reload.TriggerEvent
+		expExecs    []string
+	}{
+		"Each notification should trigger with the payload.": {
+			channel: "config",
+			conns: func() []*testConn {
+				return []*testConn{{results: []testConnResult{
+					{notification: &pgconn.Notification{PID: 42, Channel: "config", Payload: "v1"}},
+					{notification: &pgconn.Notification{PID: 43, Channel: "config", Payload: ""}},
+				}}}
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "v1", Metadata: map[string]string{"channel": "config", "payload": "v1", "pid": "42"}},
+				{ID: "config", Metadata: map[string]string{"channel": "config", "payload": "", "pid": "43"}},
+			},
+			expExecs: []string{`LISTEN "config"`},
+		},
+
+		"The channel should be quoted.": {
+			channel: `my"channel`,
+			conns: func() []*testConn {
+				return []*testConn{{results: []testConnResult{
+					{notification: &pgconn.Notification{PID: 42, Channel: `my"channel`, Payload: "v1"}},
+				}}}
+			},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "v1", Metadata: map[string]string{"channel": `my"channel`, "payload": "v1", "pid": "42"}},
+			},
+			expExecs: []string{`LISTEN "my""channel"`},
+		},
+
+		"A broken connection should reconnect, listen again and trigger.": {
+			channel: "config",
+			conns: func() []*testConn {
+				return []*testConn{
+					{results: []testConnResult{
+						{notification: &pgconn.Notification{PID: 42, Channel: "config", Payload: "v1"}},
+						{err: fmt.Errorf("something")},
+					}},
+					{results: []testConnResult{
+						{notification: &pgconn.Notification{PID: 42, Channel: "config", Payload: "v2"}},
+					}},
+				}
+			},
+			connectErrs: []error{nil, fmt.Errorf("something")},
+			expTriggers: []reload.TriggerEvent{
+				{ID: "v1", Metadata: map[string]string{"channel": "config", "payload": "v1", "pid": "42"}},
+				{ID: "config", Metadata: map[string]string{"channel": "config", "reconnected": "true"}},
+				{ID: "v2", Metadata: map[string]string{"channel": "config", "payload": "v2", "pid": "42"}},
+			},
+			expExecs: []string{`LISTEN "config"`, `LISTEN "config"`},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			conns := test.conns()
+			allConns := conns
+			connectErrs := test.connectErrs
+			n, err := reloadpostgres.NewListenNotifier(reloadpostgres.ListenNotifierConfig{
+				Channel:    test.channel,
+				MinBackoff: time.Millisecond,
+				MaxBackoff: 5 * time.Millisecond,
+				Connect: func(ctx context.Context) (reloadpostgres.Conn, error) {
+					if len(connectErrs) > 0 {
+						err := connectErrs[0]
+						connectErrs = connectErrs[1:]
+						if err != nil {
+							return nil, err
+						}
+					}
+					c := conns[0]
+					conns = conns[1:]
+					return c, nil
+				},
+			})
+			require.NoError(err)
+
+			// Execute.
+			var gotTriggers []reload.TriggerEvent
+			for range test.expTriggers {
+				te, err := n.NotifyTrigger(context.Background())
+				require.NoError(err)
+				gotTriggers = append(gotTriggers, te)
+			}
+			require.NoError(n.Close())
+
+			// Check.
+			assert.Equal(test.expTriggers, gotTriggers)
+			var gotExecs []string
+			for _, c := range allConns {
+				gotExecs = append(gotExecs, c.execs...)
+				assert.True(c.closed)
+			}
+			assert.Equal(test.expExecs, gotExecs)
+		})
+	}
+}
+
+func TestListenNotifierContextCancel(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	conn := &testConn{}
+	n, err := reloadpostgres.NewListenNotifier(reloadpostgres.ListenNotifierConfig{
+		Channel: "config",
+		Connect: func(ctx context.Context) (reloadpostgres.Conn, error) { return conn, nil },
+	})
+	require.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = n.Notify(ctx)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.True(conn.closed)
+}
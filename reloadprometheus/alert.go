@@ -0,0 +1,103 @@
+package reloadprometheus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/slok/reload"
+)
+
+// AlertSinkConfig is the configuration of the AlertSink.
+type AlertSinkConfig struct {
+	// Registerer is the registerer where the metrics will be registered.
+	// By default `prometheus.DefaultRegisterer`.
+	Registerer prometheus.Registerer
+	// Prefix is the prefix (namespace) of the metrics, if any.
+	Prefix string
+}
+
+func (c *AlertSinkConfig) defaults() error {
+	if c.Registerer == nil {
+		c.Registerer = prometheus.DefaultRegisterer
+	}
+
+	return nil
+}
+
+// AlertSink is a reload.AlertSink that exposes the alerts as alert-style
+// metrics, so Prometheus alerting rules can fire on them:
+//
+//   - `reload_alert_firing{alert}`: If the `reload_failed` or `reloads_skipped`
+//     alert is firing (1) or resolved (0). They are resolved by the recovery.
+//   - `reload_consecutive_failures`: The number of consecutive failed reloads.
+//   - `reload_alerts_total{alert}`: The total number of sent alerts by kind.
+type AlertSink struct {
+	firing              *prometheus.GaugeVec
+	consecutiveFailures prometheus.Gauge
+	alerts              *prometheus.CounterVec
+}
+
+var _ reload.AlertSink = &AlertSink{}
+
+// NewAlertSink returns a new AlertSink with the metrics registered.
+func NewAlertSink(cfg AlertSinkConfig) (*AlertSink, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	a := &AlertSink{
+		firing: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "alert_firing",
+			Help:      "If the reload alert is firing (1) or resolved (0).",
+		}, []string{"alert"}),
+		consecutiveFailures: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "consecutive_failures",
+			Help:      "The number of consecutive failed reloads.",
+		}),
+		alerts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "alerts_total",
+			Help:      "The total number of reload alerts by kind.",
+		}, []string{"alert"}),
+	}
+
+	for _, c := range []prometheus.Collector{a.firing, a.consecutiveFailures, a.alerts} {
+		err := cfg.Registerer.Register(c)
+		if err != nil {
+			return nil, fmt.Errorf("could not register metrics: %w", err)
+		}
+	}
+
+	// Start resolved.
+	a.firing.WithLabelValues(string(reload.AlertReloadFailed)).Set(0)
+	a.firing.WithLabelValues(string(reload.AlertReloadsSkipped)).Set(0)
+
+	return a, nil
+}
+
+// SendAlert satisfies reload.AlertSink interface.
+func (a *AlertSink) SendAlert(_ context.Context, al reload.Alert) error {
+	a.alerts.WithLabelValues(string(al.Kind)).Inc()
+
+	switch al.Kind {
+	case reload.AlertReloadFailed:
+		a.firing.WithLabelValues(string(reload.AlertReloadFailed)).Set(1)
+		a.consecutiveFailures.Set(float64(al.Failures))
+	case reload.AlertReloadsSkipped:
+		a.firing.WithLabelValues(string(reload.AlertReloadsSkipped)).Set(1)
+	case reload.AlertReloadRecovered:
+		a.firing.WithLabelValues(string(reload.AlertReloadFailed)).Set(0)
+		a.firing.WithLabelValues(string(reload.AlertReloadsSkipped)).Set(0)
+		a.consecutiveFailures.Set(0)
+	}
+
+	return nil
+}
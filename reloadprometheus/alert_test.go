@@ -0,0 +1,98 @@
+package reloadprometheus_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadprometheus"
+)
+
+func TestAlertSink(t *testing.T) {
+	tests := map[string]struct {
+		alerts     []reload.Alert
+		expMetrics string
+	}{
+		"Without alerts the alerts should be resolved.": {
+			expMetrics: `
+# HELP reload_alert_firing If the reload alert is firing (1) or resolved (0).
+# TYPE reload_alert_firing gauge
+reload_alert_firing{alert="reload_failed"} 0
+reload_alert_firing{alert="reloads_skipped"} 0
+# HELP reload_consecutive_failures The number of consecutive failed reloads.
+# TYPE reload_consecutive_failures gauge
+reload_consecutive_failures 0
+`,
+		},
+
+		"The failed and skipped alerts should fire.": {
+			alerts: []reload.Alert{
+				{Kind: reload.AlertReloadFailed, Failures: 1},
+				{Kind: reload.AlertReloadFailed, Failures: 2},
+				{Kind: reload.AlertReloadsSkipped, Skips: 3},
+			},
+			expMetrics: `
+# HELP reload_alert_firing If the reload alert is firing (1) or resolved (0).
+# TYPE reload_alert_firing gauge
+reload_alert_firing{alert="reload_failed"} 1
+reload_alert_firing{alert="reloads_skipped"} 1
+# HELP reload_alerts_total The total number of reload alerts by kind.
+# TYPE reload_alerts_total counter
+reload_alerts_total{alert="reload_failed"} 2
+reload_alerts_total{alert="reloads_skipped"} 1
+# HELP reload_consecutive_failures The number of consecutive failed reloads.
+# TYPE reload_consecutive_failures gauge
+reload_consecutive_failures 2
+`,
+		},
+
+		"The recovery should resolve the alerts.": {
+			alerts: []reload.Alert{
+				{Kind: reload.AlertReloadFailed, Failures: 1},
+				{Kind: reload.AlertReloadsSkipped, Skips: 3},
+				{Kind: reload.AlertReloadRecovered, Failures: 1, Skips: 3},
+			},
+			expMetrics: `
+# HELP reload_alert_firing If the reload alert is firing (1) or resolved (0).
+# TYPE reload_alert_firing gauge
+reload_alert_firing{alert="reload_failed"} 0
+reload_alert_firing{alert="reloads_skipped"} 0
+# HELP reload_alerts_total The total number of reload alerts by kind.
+# TYPE reload_alerts_total counter
+reload_alerts_total{alert="reload_failed"} 1
+reload_alerts_total{alert="reload_recovered"} 1
+reload_alerts_total{alert="reloads_skipped"} 1
+# HELP reload_consecutive_failures The number of consecutive failed reloads.
+# TYPE reload_consecutive_failures gauge
+reload_consecutive_failures 0
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			reg := prometheus.NewRegistry()
+			s, err := reloadprometheus.NewAlertSink(reloadprometheus.AlertSinkConfig{Registerer: reg})
+			require.NoError(err)
+
+			// Execute.
+			for _, a := range test.alerts {
+				require.NoError(s.SendAlert(context.TODO(), a))
+			}
+
+			// Check.
+			err = testutil.GatherAndCompare(reg, strings.NewReader(test.expMetrics))
+			assert.NoError(err)
+		})
+	}
+}
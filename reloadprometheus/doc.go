@@ -0,0 +1,2 @@
+// Package reloadprometheus has the Prometheus metrics integrations of the reload mechanism.
+package reloadprometheus
@@ -0,0 +1,297 @@
+package reloadprometheus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/slok/reload"
+)
+
+// RecorderConfig is the configuration of the Recorder.
+type RecorderConfig struct {
+	// Registerer is the registerer where the metrics will be registered.
+	// By default `prometheus.DefaultRegisterer`.
+	Registerer prometheus.Registerer
+	// Prefix is the prefix (namespace) of the metrics, if any.
+	Prefix string
+	// DurationBuckets are the buckets of the duration histograms.
+	// By default `prometheus.DefBuckets`.
+	DurationBuckets []float64
+}
+
+func (c *RecorderConfig) defaults() error {
+	if c.Registerer == nil {
+		c.Registerer = prometheus.DefaultRegisterer
+	}
+
+	if len(c.DurationBuckets) == 0 {
+		c.DurationBuckets = prometheus.DefBuckets
+	}
+
+	return nil
+}
+
+// Recorder is a reload.MetricsRecorder that records the metrics on Prometheus.
+type Recorder struct {
+	reloadDuration      *prometheus.HistogramVec
+	reloadsInProgress   prometheus.Gauge
+	reloaderDuration    *prometheus.HistogramVec
+	lastSuccess         prometheus.Gauge
+	secondsSinceSuccess prometheus.GaugeFunc
+	configGeneration    prometheus.Gauge
+	reloadFailures      *prometheus.CounterVec
+	receivedTriggers    *prometheus.CounterVec
+	queuedTriggers      *prometheus.CounterVec
+	coalescedTriggers   *prometheus.CounterVec
+	droppedTriggers     *prometheus.CounterVec
+	abandonedReloaders  *prometheus.GaugeVec
+	circuitOpen         *prometheus.GaugeVec
+	notifierStale       *prometheus.GaugeVec
+
+	lastSuccessNanos atomic.Int64
+}
+
+var _ reload.MetricsRecorder = &Recorder{}
+
+// NewRecorder returns a new Recorder with the metrics registered.
+//
+// The initial configuration is loaded by the application before the manager
+// starts, so the creation of the recorder is taken as the first successful
+// reload (generation 0).
+func NewRecorder(cfg RecorderConfig) (*Recorder, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	r := &Recorder{
+		reloadDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "duration_seconds",
+			Help:      "The duration of the reload processes.",
+			Buckets:   cfg.DurationBuckets,
+		}, []string{"source", "success"}),
+		reloadsInProgress: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "in_progress",
+			Help:      "The number of reload processes in progress.",
+		}),
+		reloaderDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "reloader_duration_seconds",
+			Help:      "The duration of the reloaders reload.",
+			Buckets:   cfg.DurationBuckets,
+		}, []string{"reloader", "priority", "success"}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "The timestamp of the last successful reload.",
+		}),
+		configGeneration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "config_generation",
+			Help:      "The current config generation, the generation of the last successful reload.",
+		}),
+		reloadFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "failures_total",
+			Help:      "The total number of failed reloads by trigger source.",
+		}, []string{"source"}),
+		receivedTriggers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "received_triggers_total",
+			Help:      "The total number of received triggers by trigger source.",
+		}, []string{"source"}),
+		queuedTriggers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "queued_triggers_total",
+			Help:      "The total number of triggers that waited for the previous reload by trigger source.",
+		}, []string{"source"}),
+		coalescedTriggers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "coalesced_triggers_total",
+			Help:      "The total number of triggers replaced by newer ones by trigger source.",
+		}, []string{"source"}),
+		droppedTriggers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "dropped_triggers_total",
+			Help:      "The total number of dropped triggers by trigger source and reason.",
+		}, []string{"source", "reason"}),
+		abandonedReloaders: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "abandoned_reloaders",
+			Help:      "The number of reloaders that exceeded their timeout and didn't return yet.",
+		}, []string{"reloader"}),
+		circuitOpen: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "reloader_circuit_open",
+			Help:      "If the reloader circuit breaker is open (1) or closed (0).",
+		}, []string{"reloader"}),
+		notifierStale: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.Prefix,
+			Subsystem: "reload",
+			Name:      "notifier_stale",
+			Help:      "If the notifier is stale (1) or alive (0).",
+		}, []string{"notifier"}),
+	}
+	r.secondsSinceSuccess = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: cfg.Prefix,
+		Subsystem: "reload",
+		Name:      "seconds_since_last_success",
+		Help:      "The seconds since the last successful reload.",
+	}, func() float64 {
+		return time.Since(time.Unix(0, r.lastSuccessNanos.Load())).Seconds()
+	})
+	r.setLastSuccess(0, time.Now())
+
+	for _, c := range []prometheus.Collector{
+		r.reloadDuration,
+		r.reloadsInProgress,
+		r.reloaderDuration,
+		r.lastSuccess,
+		r.secondsSinceSuccess,
+		r.configGeneration,
+		r.reloadFailures,
+		r.receivedTriggers,
+		r.queuedTriggers,
+		r.coalescedTriggers,
+		r.droppedTriggers,
+		r.abandonedReloaders,
+		r.circuitOpen,
+		r.notifierStale,
+	} {
+		err := cfg.Registerer.Register(c)
+		if err != nil {
+			return nil, fmt.Errorf("could not register metrics: %w", err)
+		}
+	}
+
+	return r, nil
+}
+
+// ObserveReloadDuration satisfies reload.MetricsRecorder interface. The
+// trigger ID and reason of the reload are added as an exemplar, when they fit.
+func (r *Recorder) ObserveReloadDuration(ctx context.Context, source string, success bool, duration time.Duration) {
+	o := r.reloadDuration.WithLabelValues(source, strconv.FormatBool(success))
+	eo, ok := o.(prometheus.ExemplarObserver)
+	if !ok {
+		o.Observe(duration.Seconds())
+		return
+	}
+
+	exemplar, ok := triggerExemplar(ctx)
+	if !ok {
+		o.Observe(duration.Seconds())
+		return
+	}
+	eo.ObserveWithExemplar(duration.Seconds(), exemplar)
+}
+
+// triggerExemplar returns the exemplar labels of the context trigger, the
+// reason is left out when the labels don't fit in an exemplar.
+func triggerExemplar(ctx context.Context) (prometheus.Labels, bool) {
+	t, ok := reload.TriggerEventFromContext(ctx)
+	if !ok || t.ID == "" || !utf8.ValidString(t.ID) {
+		return nil, false
+	}
+
+	runes := utf8.RuneCountInString("trigger_id") + utf8.RuneCountInString(t.ID)
+	if runes > prometheus.ExemplarMaxRunes {
+		return nil, false
+	}
+	labels := prometheus.Labels{"trigger_id": t.ID}
+
+	runes += utf8.RuneCountInString("reason") + utf8.RuneCountInString(t.Reason)
+	if t.Reason != "" && utf8.ValidString(t.Reason) && runes <= prometheus.ExemplarMaxRunes {
+		labels["reason"] = t.Reason
+	}
+
+	return labels, true
+}
+
+// AddReloadsInProgress satisfies reload.MetricsRecorder interface.
+func (r *Recorder) AddReloadsInProgress(_ context.Context, delta int) {
+	r.reloadsInProgress.Add(float64(delta))
+}
+
+// ObserveReloaderDuration satisfies reload.MetricsRecorder interface.
+func (r *Recorder) ObserveReloaderDuration(_ context.Context, reloader string, priority int, success bool, duration time.Duration) {
+	r.reloaderDuration.WithLabelValues(reloader, strconv.Itoa(priority), strconv.FormatBool(success)).Observe(duration.Seconds())
+}
+
+// SetLastSuccessfulReload satisfies reload.MetricsRecorder interface.
+func (r *Recorder) SetLastSuccessfulReload(_ context.Context, generation uint64, at time.Time) {
+	r.setLastSuccess(generation, at)
+}
+
+// IncReloadFailure satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncReloadFailure(_ context.Context, source string) {
+	r.reloadFailures.WithLabelValues(source).Inc()
+}
+
+// IncTriggerReceived satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncTriggerReceived(_ context.Context, source string) {
+	r.receivedTriggers.WithLabelValues(source).Inc()
+}
+
+// IncQueuedTrigger satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncQueuedTrigger(_ context.Context, source string) {
+	r.queuedTriggers.WithLabelValues(source).Inc()
+}
+
+// IncCoalescedTrigger satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncCoalescedTrigger(_ context.Context, source string) {
+	r.coalescedTriggers.WithLabelValues(source).Inc()
+}
+
+// IncDroppedTrigger satisfies reload.MetricsRecorder interface.
+func (r *Recorder) IncDroppedTrigger(_ context.Context, source string, reason reload.DropReason) {
+	r.droppedTriggers.WithLabelValues(source, string(reason)).Inc()
+}
+
+// AddAbandonedReloaders satisfies reload.MetricsRecorder interface.
+func (r *Recorder) AddAbandonedReloaders(_ context.Context, reloader string, delta int) {
+	r.abandonedReloaders.WithLabelValues(reloader).Add(float64(delta))
+}
+
+// SetCircuitOpen satisfies reload.MetricsRecorder interface.
+func (r *Recorder) SetCircuitOpen(_ context.Context, reloader string, open bool) {
+	v := 0.0
+	if open {
+		v = 1
+	}
+	r.circuitOpen.WithLabelValues(reloader).Set(v)
+}
+
+// SetNotifierStale satisfies reload.MetricsRecorder interface.
+func (r *Recorder) SetNotifierStale(_ context.Context, notifier string, stale bool) {
+	v := 0.0
+	if stale {
+		v = 1
+	}
+	r.notifierStale.WithLabelValues(notifier).Set(v)
+}
+
+func (r *Recorder) setLastSuccess(generation uint64, at time.Time) {
+	r.lastSuccessNanos.Store(at.UnixNano())
+	r.lastSuccess.Set(float64(at.UnixNano()) / 1e9)
+	r.configGeneration.Set(float64(generation))
+}
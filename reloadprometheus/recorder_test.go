@@ -0,0 +1,267 @@
+package reloadprometheus_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadprometheus"
+)
+
+func TestRecorder(t *testing.T) {
+	tests := map[string]struct {
+		cfg        reloadprometheus.RecorderConfig
+		record     func(r *reloadprometheus.Recorder)
+		expMetrics string
+		expNames   []string
+	}{
+		"The reload durations and in progress reloads should be recorded.": {
+			cfg: reloadprometheus.RecorderConfig{DurationBuckets: []float64{1}},
+			record: func(r *reloadprometheus.Recorder) {
+				r.AddReloadsInProgress(context.TODO(), 1)
+				r.AddReloadsInProgress(context.TODO(), 1)
+				r.AddReloadsInProgress(context.TODO(), -1)
+				r.ObserveReloadDuration(context.TODO(), "file", true, 100*time.Millisecond)
+			},
+			expNames: []string{"reload_duration_seconds", "reload_in_progress"},
+			expMetrics: `
+# HELP reload_duration_seconds The duration of the reload processes.
+# TYPE reload_duration_seconds histogram
+reload_duration_seconds_bucket{source="file",success="true",le="1"} 1
+reload_duration_seconds_bucket{source="file",success="true",le="+Inf"} 1
+reload_duration_seconds_sum{source="file",success="true"} 0.1
+reload_duration_seconds_count{source="file",success="true"} 1
+# HELP reload_in_progress The number of reload processes in progress.
+# TYPE reload_in_progress gauge
+reload_in_progress 1
+`,
+		},
+
+		"The reloader durations should be recorded.": {
+			cfg: reloadprometheus.RecorderConfig{DurationBuckets: []float64{1}},
+			record: func(r *reloadprometheus.Recorder) {
+				r.ObserveReloaderDuration(context.TODO(), "config", 0, true, 100*time.Millisecond)
+				r.ObserveReloaderDuration(context.TODO(), "config", 0, true, 2*time.Second)
+				r.ObserveReloaderDuration(context.TODO(), "server", 10, false, 500*time.Millisecond)
+			},
+			expNames: []string{"reload_reloader_duration_seconds"},
+			expMetrics: `
+# HELP reload_reloader_duration_seconds The duration of the reloaders reload.
+# TYPE reload_reloader_duration_seconds histogram
+reload_reloader_duration_seconds_bucket{priority="0",reloader="config",success="true",le="1"} 1
+reload_reloader_duration_seconds_bucket{priority="0",reloader="config",success="true",le="+Inf"} 2
+reload_reloader_duration_seconds_sum{priority="0",reloader="config",success="true"} 2.1
+reload_reloader_duration_seconds_count{priority="0",reloader="config",success="true"} 2
+reload_reloader_duration_seconds_bucket{priority="10",reloader="server",success="false",le="1"} 1
+reload_reloader_duration_seconds_bucket{priority="10",reloader="server",success="false",le="+Inf"} 1
+reload_reloader_duration_seconds_sum{priority="10",reloader="server",success="false"} 0.5
+reload_reloader_duration_seconds_count{priority="10",reloader="server",success="false"} 1
+`,
+		},
+
+		"The last successful reload should be recorded.": {
+			record: func(r *reloadprometheus.Recorder) {
+				r.SetLastSuccessfulReload(context.TODO(), 1, time.Unix(1700000000, 0))
+				r.SetLastSuccessfulReload(context.TODO(), 2, time.Unix(1700000042, 500000000))
+			},
+			expNames: []string{"reload_last_success_timestamp_seconds", "reload_config_generation"},
+			expMetrics: `
+# HELP reload_config_generation The current config generation, the generation of the last successful reload.
+# TYPE reload_config_generation gauge
+reload_config_generation 2
+# HELP reload_last_success_timestamp_seconds The timestamp of the last successful reload.
+# TYPE reload_last_success_timestamp_seconds gauge
+reload_last_success_timestamp_seconds 1.7000000425e+09
+`,
+		},
+
+		"The failures and dropped triggers should be recorded by source.": {
+			record: func(r *reloadprometheus.Recorder) {
+				r.IncReloadFailure(context.TODO(), "file")
+				r.IncReloadFailure(context.TODO(), "file")
+				r.IncReloadFailure(context.TODO(), "sighup")
+				r.IncDroppedTrigger(context.TODO(), "webhook", reload.DropReasonReloadInProgress)
+				r.IncDroppedTrigger(context.TODO(), "file", reload.DropReasonStale)
+			},
+			expNames: []string{"reload_failures_total", "reload_dropped_triggers_total"},
+			expMetrics: `
+# HELP reload_dropped_triggers_total The total number of dropped triggers by trigger source and reason.
+# TYPE reload_dropped_triggers_total counter
+reload_dropped_triggers_total{reason="reload_in_progress",source="webhook"} 1
+reload_dropped_triggers_total{reason="stale",source="file"} 1
+# HELP reload_failures_total The total number of failed reloads by trigger source.
+# TYPE reload_failures_total counter
+reload_failures_total{source="file"} 2
+reload_failures_total{source="sighup"} 1
+`,
+		},
+
+		"The received, queued and coalesced triggers should be recorded by source.": {
+			record: func(r *reloadprometheus.Recorder) {
+				r.IncTriggerReceived(context.TODO(), "file")
+				r.IncTriggerReceived(context.TODO(), "file")
+				r.IncTriggerReceived(context.TODO(), "sighup")
+				r.IncQueuedTrigger(context.TODO(), "file")
+				r.IncCoalescedTrigger(context.TODO(), "file")
+			},
+			expNames: []string{"reload_received_triggers_total", "reload_queued_triggers_total", "reload_coalesced_triggers_total"},
+			expMetrics: `
+# HELP reload_coalesced_triggers_total The total number of triggers replaced by newer ones by trigger source.
+# TYPE reload_coalesced_triggers_total counter
+reload_coalesced_triggers_total{source="file"} 1
+# HELP reload_queued_triggers_total The total number of triggers that waited for the previous reload by trigger source.
+# TYPE reload_queued_triggers_total counter
+reload_queued_triggers_total{source="file"} 1
+# HELP reload_received_triggers_total The total number of received triggers by trigger source.
+# TYPE reload_received_triggers_total counter
+reload_received_triggers_total{source="file"} 2
+reload_received_triggers_total{source="sighup"} 1
+`,
+		},
+
+		"The abandoned reloaders should be recorded by reloader.": {
+			record: func(r *reloadprometheus.Recorder) {
+				r.AddAbandonedReloaders(context.TODO(), "config", 1)
+				r.AddAbandonedReloaders(context.TODO(), "config", 1)
+				r.AddAbandonedReloaders(context.TODO(), "tls", 1)
+				r.AddAbandonedReloaders(context.TODO(), "tls", -1)
+			},
+			expNames: []string{"reload_abandoned_reloaders"},
+			expMetrics: `
+# HELP reload_abandoned_reloaders The number of reloaders that exceeded their timeout and didn't return yet.
+# TYPE reload_abandoned_reloaders gauge
+reload_abandoned_reloaders{reloader="config"} 2
+reload_abandoned_reloaders{reloader="tls"} 0
+`,
+		},
+
+		"The circuit breaker state should be recorded by reloader.": {
+			record: func(r *reloadprometheus.Recorder) {
+				r.SetCircuitOpen(context.TODO(), "config", true)
+				r.SetCircuitOpen(context.TODO(), "tls", true)
+				r.SetCircuitOpen(context.TODO(), "tls", false)
+			},
+			expNames: []string{"reload_reloader_circuit_open"},
+			expMetrics: `
+# HELP reload_reloader_circuit_open If the reloader circuit breaker is open (1) or closed (0).
+# TYPE reload_reloader_circuit_open gauge
+reload_reloader_circuit_open{reloader="config"} 1
+reload_reloader_circuit_open{reloader="tls"} 0
+`,
+		},
+
+		"The notifier stale state should be recorded by notifier.": {
+			record: func(r *reloadprometheus.Recorder) {
+				r.SetNotifierStale(context.TODO(), "watch", true)
+				r.SetNotifierStale(context.TODO(), "sighup", true)
+				r.SetNotifierStale(context.TODO(), "sighup", false)
+			},
+			expNames: []string{"reload_notifier_stale"},
+			expMetrics: `
+# HELP reload_notifier_stale If the notifier is stale (1) or alive (0).
+# TYPE reload_notifier_stale gauge
+reload_notifier_stale{notifier="sighup"} 0
+reload_notifier_stale{notifier="watch"} 1
+`,
+		},
+
+		"The prefix should be used on the metrics.": {
+			cfg: reloadprometheus.RecorderConfig{Prefix: "myapp", DurationBuckets: []float64{1}},
+			record: func(r *reloadprometheus.Recorder) {
+				r.ObserveReloaderDuration(context.TODO(), "config", 0, true, 100*time.Millisecond)
+			},
+			expNames: []string{"myapp_reload_reloader_duration_seconds"},
+			expMetrics: `
+# HELP myapp_reload_reloader_duration_seconds The duration of the reloaders reload.
+# TYPE myapp_reload_reloader_duration_seconds histogram
+myapp_reload_reloader_duration_seconds_bucket{priority="0",reloader="config",success="true",le="1"} 1
+myapp_reload_reloader_duration_seconds_bucket{priority="0",reloader="config",success="true",le="+Inf"} 1
+myapp_reload_reloader_duration_seconds_sum{priority="0",reloader="config",success="true"} 0.1
+myapp_reload_reloader_duration_seconds_count{priority="0",reloader="config",success="true"} 1
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			reg := prometheus.NewRegistry()
+			test.cfg.Registerer = reg
+			r, err := reloadprometheus.NewRecorder(test.cfg)
+			require.NoError(err)
+
+			// Execute.
+			test.record(r)
+
+			// Check.
+			err = testutil.GatherAndCompare(reg, strings.NewReader(test.expMetrics), test.expNames...)
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestRecorderSecondsSinceLastSuccess(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	reg := prometheus.NewRegistry()
+	r, err := reloadprometheus.NewRecorder(reloadprometheus.RecorderConfig{Registerer: reg})
+	require.NoError(err)
+
+	// Execute.
+	r.SetLastSuccessfulReload(context.TODO(), 1, time.Now().Add(-time.Minute))
+
+	// Check.
+	mfs, err := reg.Gather()
+	require.NoError(err)
+	var got float64
+	for _, mf := range mfs {
+		if mf.GetName() == "reload_seconds_since_last_success" {
+			got = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	assert.InDelta(60, got, 5)
+}
+
+func TestRecorderReloadExemplar(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	reg := prometheus.NewRegistry()
+	r, err := reloadprometheus.NewRecorder(reloadprometheus.RecorderConfig{Registerer: reg, DurationBuckets: []float64{1}})
+	require.NoError(err)
+	m := reload.NewManager(reload.WithMetricsRecorder(r))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }))
+
+	// Execute.
+	err = m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1", Reason: "deploy-123"})
+	require.NoError(err)
+
+	// Check.
+	mfs, err := reg.Gather()
+	require.NoError(err)
+	got := map[string]string{}
+	for _, mf := range mfs {
+		if mf.GetName() != "reload_duration_seconds" {
+			continue
+		}
+		for _, b := range mf.GetMetric()[0].GetHistogram().GetBucket() {
+			for _, l := range b.GetExemplar().GetLabel() {
+				got[l.GetName()] = l.GetValue()
+			}
+		}
+	}
+	assert.Equal(map[string]string{"trigger_id": "t1", "reason": "deploy-123"}, got)
+}
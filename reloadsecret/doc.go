@@ -0,0 +1,2 @@
+// Package reloadsecret has the secrets rotation integrations of the reload mechanism.
+package reloadsecret
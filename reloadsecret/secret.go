@@ -0,0 +1,148 @@
+package reloadsecret
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/slok/reload"
+)
+
+// SecretConfig is the configuration of the Secret.
+type SecretConfig struct {
+	// Load returns the secret material, it will be called on the creation and
+	// on every reload. The returned buffer is owned by the Secret (it will be
+	// zeroed when replaced). FileLoader or FSLoader can be used.
+	Load func(ctx context.Context) ([]byte, error)
+}
+
+func (c *SecretConfig) defaults() error {
+	if c.Load == nil {
+		return fmt.Errorf("load function is required")
+	}
+
+	return nil
+}
+
+// FileLoader returns a secret loader that reads the secret from a file.
+func FileLoader(path string) func(ctx context.Context) ([]byte, error) {
+	return func(_ context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// FSLoader returns a secret loader that reads the secret from the name file of
+// the file system (e.g: an `embed.FS`, or a `fstest.MapFS` on the tests).
+func FSLoader(fsys fs.FS, name string) func(ctx context.Context) ([]byte, error) {
+	return func(_ context.Context) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
+
+// Secret is a reload.Reloader designed for secrets, it loads the new secret
+// material on every reload and swaps it atomically.
+//
+// When the secret is replaced, the old buffer is zeroed (best-effort, Go can
+// have copied it, e.g: on conversions to string). To make this safe, the secret
+// should only be accessed with Use, and not retained after it.
+//
+// The dependents of the secret (e.g: database pools, API clients) can be
+// notified using Dependent reloaders on later priority groups.
+type Secret struct {
+	cfg SecretConfig
+
+	mu      sync.RWMutex
+	value   []byte
+	version uint64
+}
+
+// NewSecret returns a new Secret, the secret is loaded on the creation.
+func NewSecret(ctx context.Context, cfg SecretConfig) (*Secret, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	s := &Secret{cfg: cfg}
+	err = s.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Use calls f with the current secret, the secret can't be retained after f
+// returns as it could be zeroed.
+func (s *Secret) Use(f func(secret []byte) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return f(s.value)
+}
+
+// Version returns the version of the secret, it's increased every time the
+// secret changes.
+func (s *Secret) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.version
+}
+
+// Reload satisfies reload.Reloader interface.
+func (s *Secret) Reload(ctx context.Context, _ string) error {
+	value, err := s.cfg.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load secret: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Same secret, don't replace it.
+	if s.value != nil && bytes.Equal(s.value, value) {
+		zero(value)
+		return nil
+	}
+
+	old := s.value
+	s.value = value
+	s.version++
+	zero(old)
+
+	return nil
+}
+
+// Dependent returns a reloader that calls f only when the secret changed
+// since the dependent was created or the last successful call, it should be
+// added on a later priority group than the secret.
+func (s *Secret) Dependent(f func(ctx context.Context) error) reload.Reloader {
+	var mu sync.Mutex
+	seen := s.Version()
+
+	return reload.ReloaderFunc(func(ctx context.Context, _ string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		version := s.Version()
+		if version == seen {
+			return nil
+		}
+
+		err := f(ctx)
+		if err != nil {
+			return err
+		}
+		seen = version
+
+		return nil
+	})
+}
+
+func zero(b []byte) {
+	clear(b)
+}
@@ -0,0 +1,150 @@
+package reloadsecret_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadsecret"
+)
+
+func TestSecret(t *testing.T) {
+	tests := map[string]struct {
+		secrets       []string
+		loadErr       error
+		expSecret     string
+		expVersion    uint64
+		expZeroed     bool
+		expDependents int
+		expErr        bool
+	}{
+		"A new secret should be swapped and the old one zeroed.": {
+			secrets:       []string{"s1", "s2"},
+			expSecret:     "s2",
+			expVersion:    2,
+			expZeroed:     true,
+			expDependents: 1,
+		},
+
+		"The same secret should not be swapped.": {
+			secrets:       []string{"s1", "s1"},
+			expSecret:     "s1",
+			expVersion:    1,
+			expDependents: 0,
+		},
+
+		"An error loading the secret should keep the previous secret.": {
+			secrets:       []string{"s1"},
+			loadErr:       fmt.Errorf("something"),
+			expSecret:     "s1",
+			expVersion:    1,
+			expDependents: 0,
+			expErr:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var loaded [][]byte
+			s, err := reloadsecret.NewSecret(context.TODO(), reloadsecret.SecretConfig{
+				Load: func(ctx context.Context) ([]byte, error) {
+					if len(loaded) == len(test.secrets) {
+						return nil, test.loadErr
+					}
+					b := []byte(test.secrets[len(loaded)])
+					loaded = append(loaded, b)
+					return b, nil
+				},
+			})
+			require.NoError(err)
+
+			gotDependents := 0
+			dep := s.Dependent(func(ctx context.Context) error {
+				gotDependents++
+				return nil
+			})
+
+			// Execute.
+			err = s.Reload(context.TODO(), "test")
+			require.NoError(dep.Reload(context.TODO(), "test"))
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			var got string
+			require.NoError(s.Use(func(secret []byte) error {
+				got = string(secret)
+				return nil
+			}))
+			assert.Equal(test.expSecret, got)
+			assert.Equal(test.expVersion, s.Version())
+			assert.Equal(test.expDependents, gotDependents)
+			if test.expZeroed {
+				assert.Equal(make([]byte, len(loaded[0])), loaded[0])
+			}
+		})
+	}
+}
+
+func TestSecretDependentRetry(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	secret := "s1"
+	s, err := reloadsecret.NewSecret(context.TODO(), reloadsecret.SecretConfig{
+		Load: func(ctx context.Context) ([]byte, error) { return []byte(secret), nil },
+	})
+	require.NoError(err)
+
+	var depErr error
+	calls := 0
+	dep := s.Dependent(func(ctx context.Context) error {
+		calls++
+		return depErr
+	})
+
+	// A failed dependent should be called again on the next reload.
+	secret = "s2"
+	depErr = fmt.Errorf("something")
+	require.NoError(s.Reload(context.TODO(), "test"))
+	assert.Error(dep.Reload(context.TODO(), "test"))
+	depErr = nil
+	assert.NoError(dep.Reload(context.TODO(), "test"))
+
+	// Once succeeded, it should not be called until the secret changes.
+	assert.NoError(dep.Reload(context.TODO(), "test"))
+	assert.Equal(2, calls)
+}
+
+func TestSecretFSLoader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	fsys := fstest.MapFS{"secrets/token": {Data: []byte("s1")}}
+	s, err := reloadsecret.NewSecret(context.TODO(), reloadsecret.SecretConfig{Load: reloadsecret.FSLoader(fsys, "secrets/token")})
+	require.NoError(err)
+
+	// Execute.
+	fsys["secrets/token"] = &fstest.MapFile{Data: []byte("s2")}
+	err = s.Reload(context.TODO(), "test")
+
+	// Check.
+	require.NoError(err)
+	require.NoError(s.Use(func(secret []byte) error {
+		assert.Equal("s2", string(secret))
+		return nil
+	}))
+}
@@ -0,0 +1,301 @@
+package reloadsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// DBSettings are the settings of a database connection pool.
+type DBSettings struct {
+	// DriverName is the registered database driver name.
+	DriverName string
+	// DSN is the data source name, changing it (e.g: credentials rotation)
+	// will swap the connector used to create new connections.
+	DSN string
+	// MaxOpenConns is the same as `sql.DB.SetMaxOpenConns`.
+	MaxOpenConns int
+	// MaxIdleConns is the same as `sql.DB.SetMaxIdleConns`, use a negative
+	// value to not keep idle connections.
+	// By default 2 (same as `database/sql`).
+	MaxIdleConns int
+	// ConnMaxLifetime is the same as `sql.DB.SetConnMaxLifetime`.
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is the same as `sql.DB.SetConnMaxIdleTime`.
+	ConnMaxIdleTime time.Duration
+}
+
+// DBReloaderConfig is the configuration of the DBReloader.
+type DBReloaderConfig struct {
+	// Settings returns the database settings, it will be called on every reload.
+	Settings func(ctx context.Context) (DBSettings, error)
+	// Connector returns the connector for the settings, it will be called when
+	// the driver name or the DSN change.
+	// By default the connector of the registered driver.
+	Connector func(ctx context.Context, s DBSettings) (driver.Connector, error)
+	// DrainPeriod is the time after a connector swap where the connections
+	// are not reused, so the connections of the old connector are closed
+	// when returned to the pool. The old connectors that implement
+	// `io.Closer` are closed when it ends.
+	// By default 30s.
+	DrainPeriod time.Duration
+	// Clock is the clock of the drain period.
+	// By default `reload.RealClock`.
+	Clock reload.Clock
+}
+
+func (c *DBReloaderConfig) defaults() error {
+	if c.Settings == nil {
+		return fmt.Errorf("settings function is required")
+	}
+
+	if c.Connector == nil {
+		c.Connector = driverConnector
+	}
+
+	if c.DrainPeriod <= 0 {
+		c.DrainPeriod = 30 * time.Second
+	}
+
+	if c.Clock == nil {
+		c.Clock = reload.RealClock
+	}
+
+	return nil
+}
+
+// DBReloader is a reload.Reloader that reconfigures a `*sql.DB` on every reload,
+// the DB is created by the reloader and is the same during all its life,
+// so the users can store it.
+//
+// On reload:
+//
+//   - The pool settings are applied on the DB.
+//   - If the driver or the DSN changed, a new connector is created and checked
+//     connecting to the database. Then is swapped so the new connections use it.
+//
+// After a swap, the DB doesn't keep idle connections during the drain period
+// so the connections made with the old connector are closed once they are
+// released. Connections that are held longer than the drain period are
+// reused, use ConnMaxLifetime to limit them. When the drain period ends, the
+// old connectors that implement `io.Closer` are closed.
+type DBReloader struct {
+	cfg       DBReloaderConfig
+	db        *sql.DB
+	connector *swapConnector
+
+	mu        sync.Mutex
+	settings  DBSettings
+	drainStop chan struct{}      // Not nil while draining.
+	draining  []driver.Connector // Old connectors closed when the drain ends.
+}
+
+// NewDBReloader returns a new DBReloader, the DB will be configured with the
+// current settings.
+func NewDBReloader(ctx context.Context, cfg DBReloaderConfig) (*DBReloader, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	s, err := cfg.Settings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get database settings: %w", err)
+	}
+
+	c, err := newCheckedConnector(ctx, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &swapConnector{}
+	sc.current.Store(&c)
+	db := sql.OpenDB(sc)
+	applyPoolSettings(db, s)
+
+	return &DBReloader{
+		cfg:       cfg,
+		db:        db,
+		connector: sc,
+		settings:  s,
+	}, nil
+}
+
+// DB returns the database.
+func (d *DBReloader) DB() *sql.DB {
+	return d.db
+}
+
+// Reload satisfies reload.Reloader interface.
+func (d *DBReloader) Reload(ctx context.Context, _ string) error {
+	s, err := d.cfg.Settings(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get database settings: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if s.DriverName != d.settings.DriverName || s.DSN != d.settings.DSN {
+		c, err := newCheckedConnector(ctx, d.cfg, s)
+		if err != nil {
+			return err
+		}
+		old := d.connector.current.Swap(&c)
+		d.drain(*old)
+	}
+
+	d.settings = s
+	applyPoolSettings(d.db, s)
+
+	// While draining we don't keep idle connections.
+	if d.drainStop != nil {
+		d.db.SetMaxIdleConns(0)
+	}
+
+	return nil
+}
+
+// Close stops the reloader and closes the DB, and the old connectors that are
+// draining.
+func (d *DBReloader) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.drainStop != nil {
+		close(d.drainStop)
+		d.drainStop = nil
+	}
+
+	err := d.db.Close()
+	closeConnectors(d.draining)
+	d.draining = nil
+
+	return err
+}
+
+// drain starts the drain period of the old connector, when it ends the idle
+// connections setting is restored and the old connectors are closed.
+func (d *DBReloader) drain(old driver.Connector) {
+	if d.drainStop != nil {
+		close(d.drainStop)
+	}
+	d.draining = append(d.draining, old)
+
+	stop := make(chan struct{})
+	d.drainStop = stop
+	timer := d.cfg.Clock.NewTimer(d.cfg.DrainPeriod)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-stop:
+			return
+		case <-timer.C():
+		}
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		// Drain already stopped or restarted.
+		if d.drainStop != stop {
+			return
+		}
+		d.drainStop = nil
+		d.db.SetMaxIdleConns(maxIdleConns(d.settings))
+		closeConnectors(d.draining)
+		d.draining = nil
+	}()
+}
+
+// closeConnectors closes the connectors that implement `io.Closer`, the same as
+// `sql.DB.Close` does with its connector.
+func closeConnectors(cs []driver.Connector) {
+	for _, c := range cs {
+		if cl, ok := c.(io.Closer); ok {
+			_ = cl.Close()
+		}
+	}
+}
+
+func applyPoolSettings(db *sql.DB, s DBSettings) {
+	db.SetMaxOpenConns(s.MaxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns(s))
+	db.SetConnMaxLifetime(s.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(s.ConnMaxIdleTime)
+}
+
+func maxIdleConns(s DBSettings) int {
+	if s.MaxIdleConns == 0 {
+		return 2
+	}
+	return s.MaxIdleConns
+}
+
+// newCheckedConnector returns a new connector, it checks the connector can
+// connect to the database.
+func newCheckedConnector(ctx context.Context, cfg DBReloaderConfig, s DBSettings) (driver.Connector, error) {
+	c, err := cfg.Connector(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("could not create database connector: %w", err)
+	}
+
+	conn, err := c.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to database: %w", err)
+	}
+	_ = conn.Close()
+
+	return c, nil
+}
+
+// swapConnector is a connector that delegates on a connector that can be swapped.
+type swapConnector struct {
+	current atomic.Pointer[driver.Connector]
+}
+
+func (s *swapConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return (*s.current.Load()).Connect(ctx)
+}
+
+func (s *swapConnector) Driver() driver.Driver {
+	return (*s.current.Load()).Driver()
+}
+
+// Close closes the current connector when the DB is closed, if it implements
+// `io.Closer`.
+func (s *swapConnector) Close() error {
+	if c, ok := (*s.current.Load()).(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func driverConnector(_ context.Context, s DBSettings) (driver.Connector, error) {
+	db, err := sql.Open(s.DriverName, s.DSN)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	_ = db.Close()
+
+	if dc, ok := drv.(driver.DriverContext); ok {
+		return dc.OpenConnector(s.DSN)
+	}
+
+	return dsnConnector{dsn: s.DSN, driver: drv}, nil
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (d dsnConnector) Connect(_ context.Context) (driver.Conn, error) { return d.driver.Open(d.dsn) }
+func (d dsnConnector) Driver() driver.Driver                          { return d.driver }
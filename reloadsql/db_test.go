@@ -0,0 +1,220 @@
+package reloadsql_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadsql"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestDBReloader(t *testing.T) {
+	tests := map[string]struct {
+		settings      []reloadsql.DBSettings
+		connectorErr  error
+		expMaxOpen    int
+		expConnectors []string
+		expOpened     []string
+		expErr        bool
+	}{
+		"Changing the pool settings should reconfigure the same connector.": {
+			settings: []reloadsql.DBSettings{
+				{DriverName: "test", DSN: "dsn1", MaxOpenConns: 5},
+				{DriverName: "test", DSN: "dsn1", MaxOpenConns: 10},
+			},
+			expMaxOpen:    10,
+			expConnectors: []string{"dsn1"},
+			expOpened:     []string{"dsn1", "dsn1"},
+		},
+
+		"Changing the DSN should swap the connector.": {
+			settings: []reloadsql.DBSettings{
+				{DriverName: "test", DSN: "dsn1", MaxOpenConns: 5},
+				{DriverName: "test", DSN: "dsn2", MaxOpenConns: 5},
+			},
+			expMaxOpen:    5,
+			expConnectors: []string{"dsn1", "dsn2"},
+			expOpened:     []string{"dsn1", "dsn2", "dsn2"},
+		},
+
+		"If the new connector fails, the previous settings should be kept.": {
+			settings: []reloadsql.DBSettings{
+				{DriverName: "test", DSN: "dsn1", MaxOpenConns: 5},
+				{DriverName: "test", DSN: "dsn2", MaxOpenConns: 10},
+			},
+			connectorErr:  fmt.Errorf("something"),
+			expMaxOpen:    5,
+			expConnectors: []string{"dsn1", "dsn2"},
+			expOpened:     []string{"dsn1", "dsn1"},
+			expErr:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			d := &testDriver{}
+			d.setRows(func(query string) ([]string, [][]driver.Value, error) {
+				return []string{"n"}, [][]driver.Value{{int64(1)}}, nil
+			})
+			settings := test.settings
+			var gotConnectors []string
+			r, err := reloadsql.NewDBReloader(context.TODO(), reloadsql.DBReloaderConfig{
+				DrainPeriod: time.Hour,
+				Settings: func(ctx context.Context) (reloadsql.DBSettings, error) {
+					s := settings[0]
+					settings = settings[1:]
+					return s, nil
+				},
+				Connector: func(ctx context.Context, s reloadsql.DBSettings) (driver.Connector, error) {
+					gotConnectors = append(gotConnectors, s.DSN)
+					if len(gotConnectors) > 1 && test.connectorErr != nil {
+						return nil, test.connectorErr
+					}
+					return testConnector{d: d, dsn: s.DSN}, nil
+				},
+			})
+			require.NoError(err)
+			defer r.Close()
+			db := r.DB()
+
+			// Execute.
+			err = r.Reload(context.TODO(), "test")
+			var n int
+			require.NoError(db.QueryRowContext(context.TODO(), "SELECT 1").Scan(&n))
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Same(db, r.DB())
+			assert.Equal(test.expMaxOpen, db.Stats().MaxOpenConnections)
+			assert.Equal(test.expConnectors, gotConnectors)
+			assert.Equal(test.expOpened, d.openedNames())
+		})
+	}
+}
+
+func TestDBReloaderDrain(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	d := &testDriver{}
+	d.setRows(func(query string) ([]string, [][]driver.Value, error) {
+		return []string{"n"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	clock := reloadtest.NewClock(time.Now())
+	closed := &closedConnectors{}
+	dsn := "dsn1"
+	r, err := reloadsql.NewDBReloader(context.TODO(), reloadsql.DBReloaderConfig{
+		DrainPeriod: time.Minute,
+		Clock:       clock,
+		Settings: func(ctx context.Context) (reloadsql.DBSettings, error) {
+			return reloadsql.DBSettings{DriverName: "test", DSN: dsn}, nil
+		},
+		Connector: func(ctx context.Context, s reloadsql.DBSettings) (driver.Connector, error) {
+			return closerConnector{testConnector: testConnector{d: d, dsn: s.DSN}, closed: closed}, nil
+		},
+	})
+	require.NoError(err)
+	db := r.DB()
+	query := func() {
+		var n int
+		require.NoError(db.QueryRowContext(context.TODO(), "SELECT 1").Scan(&n))
+	}
+
+	// An idle connection should be kept before the swap.
+	query()
+	assert.Equal(1, db.Stats().Idle)
+
+	// While draining, the connections should not be kept.
+	dsn = "dsn2"
+	require.NoError(r.Reload(context.TODO(), "test"))
+	assert.Equal(0, db.Stats().Idle)
+	query()
+	assert.Equal(0, db.Stats().Idle)
+	assert.Empty(closed.names())
+
+	// After the drain period, the idle connections should be kept again and
+	// the old connector closed.
+	require.True(clock.WaitWaiters(1, time.Second))
+	clock.Advance(time.Minute)
+	assert.Eventually(func() bool { return len(closed.names()) == 1 }, time.Second, time.Millisecond)
+	query()
+	assert.Equal(1, db.Stats().Idle)
+	assert.Equal([]string{"dsn1", "dsn1", "dsn2", "dsn2", "dsn2"}, d.openedNames())
+	assert.Equal([]string{"dsn1"}, closed.names())
+
+	// Closing should close the current connector.
+	require.NoError(r.Close())
+	assert.Equal([]string{"dsn1", "dsn2"}, closed.names())
+}
+
+func TestDBReloaderCloseWhileDraining(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	d := &testDriver{}
+	clock := reloadtest.NewClock(time.Now())
+	closed := &closedConnectors{}
+	dsn := "dsn1"
+	r, err := reloadsql.NewDBReloader(context.TODO(), reloadsql.DBReloaderConfig{
+		Clock: clock,
+		Settings: func(ctx context.Context) (reloadsql.DBSettings, error) {
+			return reloadsql.DBSettings{DriverName: "test", DSN: dsn}, nil
+		},
+		Connector: func(ctx context.Context, s reloadsql.DBSettings) (driver.Connector, error) {
+			return closerConnector{testConnector: testConnector{d: d, dsn: s.DSN}, closed: closed}, nil
+		},
+	})
+	require.NoError(err)
+	dsn = "dsn2"
+	require.NoError(r.Reload(context.TODO(), "test"))
+	dsn = "dsn3"
+	require.NoError(r.Reload(context.TODO(), "test"))
+
+	// Execute.
+	err = r.Close()
+
+	// Check.
+	require.NoError(err)
+	assert.ElementsMatch([]string{"dsn1", "dsn2", "dsn3"}, closed.names())
+}
+
+type closedConnectors struct {
+	mu  sync.Mutex
+	dsn []string
+}
+
+func (c *closedConnectors) names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.dsn...)
+}
+
+// closerConnector is a testConnector that records when it's closed.
+type closerConnector struct {
+	testConnector
+	closed *closedConnectors
+}
+
+func (c closerConnector) Close() error {
+	c.closed.mu.Lock()
+	defer c.closed.mu.Unlock()
+	c.closed.dsn = append(c.closed.dsn, c.dsn)
+	return nil
+}
@@ -0,0 +1,2 @@
+// Package reloadsql has the `database/sql` integrations of the reload mechanism.
+package reloadsql
@@ -0,0 +1,102 @@
+package reloadsql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// testDriver is a fake SQL driver that returns the rows from a function.
+type testDriver struct {
+	mu     sync.Mutex
+	rows   func(query string) ([]string, [][]driver.Value, error)
+	opened []string     // Names of the opened connections.
+	conns  atomic.Int64 // Open connections.
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	d.opened = append(d.opened, name)
+	d.mu.Unlock()
+	d.conns.Add(1)
+	return &testConn{d: d}, nil
+}
+
+func (d *testDriver) openedNames() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.opened...)
+}
+
+func (d *testDriver) setRows(f func(query string) ([]string, [][]driver.Value, error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rows = f
+}
+
+type testConn struct{ d *testDriver }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{c: c, query: query}, nil
+}
+
+func (c *testConn) Close() error {
+	c.d.conns.Add(-1)
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("not supported") }
+
+type testStmt struct {
+	c     *testConn
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("not supported")
+}
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.mu.Lock()
+	f := s.c.d.rows
+	s.c.d.mu.Unlock()
+
+	cols, values, err := f(s.query)
+	if err != nil {
+		return nil, err
+	}
+	return &testRows{cols: cols, values: values}, nil
+}
+
+type testRows struct {
+	cols   []string
+	values [][]driver.Value
+}
+
+func (r *testRows) Columns() []string { return r.cols }
+func (r *testRows) Close() error      { return nil }
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+type testConnector struct {
+	d   *testDriver
+	dsn string
+}
+
+func (c testConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.dsn) }
+func (c testConnector) Driver() driver.Driver                        { return c.d }
+
+func newTestDB(d *testDriver) *sql.DB {
+	return sql.OpenDB(testConnector{d: d})
+}
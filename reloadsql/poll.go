@@ -0,0 +1,173 @@
+package reloadsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// PollNotifierConfig is the configuration of the PollNotifier.
+type PollNotifierConfig struct {
+	// DB is the database where the query will be executed.
+	DB *sql.DB
+	// Query is the query that will be executed on every interval, when the
+	// result changes a reload will be triggered (e.g: `SELECT max(updated_at) FROM settings`).
+	Query string
+	// Args are the arguments of the query.
+	Args []any
+	// Interval is the interval between queries.
+	// By default 30s.
+	Interval time.Duration
+	// TriggerID is the ID used on the triggers.
+	// By default `sql`.
+	TriggerID string
+	// FailOnError will end the notifier with an error when the query fails,
+	// by default the failed queries are ignored and retried on the next interval.
+	FailOnError bool
+	// Clock is the clock of the interval.
+	// By default `reload.RealClock`.
+	Clock reload.Clock
+}
+
+func (c *PollNotifierConfig) defaults() error {
+	if c.DB == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	if c.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+
+	if c.Interval <= 0 {
+		c.Interval = 30 * time.Second
+	}
+
+	if c.TriggerID == "" {
+		c.TriggerID = "sql"
+	}
+
+	if c.Clock == nil {
+		c.Clock = reload.RealClock
+	}
+
+	return nil
+}
+
+// PollNotifier is a reload.Notifier that runs a query on an interval and
+// triggers a reload when the result changes, so database backed configuration
+// can trigger reloads without database triggers or extensions.
+//
+// Only the first row of the result is used. The first time the notifier is
+// called, it will get the current result as the base for the next changes.
+// The result is set on the trigger metadata (`result` key).
+type PollNotifier struct {
+	cfg         PollNotifierConfig
+	last        string
+	initialized bool
+}
+
+// NewPollNotifier returns a new PollNotifier.
+func NewPollNotifier(cfg PollNotifierConfig) (*PollNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &PollNotifier{cfg: cfg}, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (p *PollNotifier) Notify(ctx context.Context) (string, error) {
+	t, err := p.NotifyTrigger(ctx)
+	return t.ID, err
+}
+
+// NotifyTrigger satisfies reload.TriggerNotifier interface.
+func (p *PollNotifier) NotifyTrigger(ctx context.Context) (reload.TriggerEvent, error) {
+	if !p.initialized {
+		res, err := p.query(ctx)
+		if err != nil && p.cfg.FailOnError {
+			return reload.TriggerEvent{}, err
+		}
+		if err == nil {
+			p.last = res
+			p.initialized = true
+		}
+	}
+
+	t := p.cfg.Clock.NewTicker(p.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return reload.TriggerEvent{}, ctx.Err()
+		case <-t.C():
+		}
+
+		res, err := p.query(ctx)
+		if err != nil {
+			if p.cfg.FailOnError {
+				return reload.TriggerEvent{}, err
+			}
+			continue
+		}
+
+		// If we could not get the base result, use this one.
+		if !p.initialized {
+			p.last = res
+			p.initialized = true
+			continue
+		}
+
+		if res != p.last {
+			p.last = res
+			return reload.TriggerEvent{
+				ID:       p.cfg.TriggerID,
+				Metadata: map[string]string{"result": res},
+			}, nil
+		}
+	}
+}
+
+// query executes the query and returns the first row encoded as a string.
+func (p *PollNotifier) query(ctx context.Context) (string, error) {
+	rows, err := p.cfg.DB.QueryContext(ctx, p.cfg.Query, p.cfg.Args...)
+	if err != nil {
+		return "", fmt.Errorf("could not execute query: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("could not get query columns: %w", err)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", fmt.Errorf("could not read query result: %w", err)
+		}
+		return "", nil
+	}
+
+	values := make([]sql.RawBytes, len(cols))
+	dest := make([]any, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	err = rows.Scan(dest...)
+	if err != nil {
+		return "", fmt.Errorf("could not scan query result: %w", err)
+	}
+
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		res = append(res, string(v))
+	}
+
+	return strings.Join(res, ","), nil
+}
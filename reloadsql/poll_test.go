@@ -0,0 +1,102 @@
+package reloadsql_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadsql"
+)
+
+func TestPollNotifier(t *testing.T) {
+	tests := map[string]struct {
+		results     []any // string or error.
+		failOnError bool
+		expTrigger  *reload.TriggerEvent
+		expErr      bool
+	}{
+		"If the result doesn't change it should not trigger.": {
+			results: []any{"2021-07-19", "2021-07-19", "2021-07-19"},
+		},
+
+		"If the result changes it should trigger.": {
+			results:    []any{"2021-07-19", "2021-07-19", "2021-07-20"},
+			expTrigger: &reload.TriggerEvent{ID: "sql", Metadata: map[string]string{"result": "2021-07-20,42"}},
+		},
+
+		"If the query fails it should be ignored by default.": {
+			results:    []any{"2021-07-19", fmt.Errorf("something"), "2021-07-20"},
+			expTrigger: &reload.TriggerEvent{ID: "sql", Metadata: map[string]string{"result": "2021-07-20,42"}},
+		},
+
+		"If the first query fails, the next one should be used as the base.": {
+			results: []any{fmt.Errorf("something"), "2021-07-19", "2021-07-19"},
+		},
+
+		"If the query fails and it's configured to fail, it should end with an error.": {
+			results:     []any{"2021-07-19", fmt.Errorf("something")},
+			failOnError: true,
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			results := make(chan any, len(test.results))
+			for _, r := range test.results {
+				results <- r
+			}
+			d := &testDriver{}
+			d.setRows(func(query string) ([]string, [][]driver.Value, error) {
+				if query != "SELECT max(updated_at), 42 FROM settings" {
+					return nil, nil, fmt.Errorf("wrong query")
+				}
+				var r any
+				select {
+				case r = <-results:
+				default:
+					r = fmt.Errorf("no more results")
+				}
+				if err, ok := r.(error); ok {
+					return nil, nil, err
+				}
+				return []string{"max", "n"}, [][]driver.Value{{r, int64(42)}}, nil
+			})
+
+			n, err := reloadsql.NewPollNotifier(reloadsql.PollNotifierConfig{
+				DB:          newTestDB(d),
+				Query:       "SELECT max(updated_at), 42 FROM settings",
+				Interval:    5 * time.Millisecond,
+				FailOnError: test.failOnError,
+			})
+			require.NoError(err)
+
+			// Execute.
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			gotTrigger, err := n.NotifyTrigger(ctx)
+
+			// Check.
+			switch {
+			case test.expErr:
+				assert.Error(err)
+				assert.NotErrorIs(err, context.DeadlineExceeded)
+			case test.expTrigger != nil:
+				require.NoError(err)
+				assert.Equal(*test.expTrigger, gotTrigger)
+			default:
+				assert.ErrorIs(err, context.DeadlineExceeded)
+			}
+		})
+	}
+}
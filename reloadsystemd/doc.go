@@ -0,0 +1,2 @@
+// Package reloadsystemd has the systemd integrations of the reload mechanism.
+package reloadsystemd
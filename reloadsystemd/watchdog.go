@@ -0,0 +1,172 @@
+package reloadsystemd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// Notify sends a state to the systemd notify socket (`sd_notify`), e.g:
+// `WATCHDOG=1`. If the socket is empty it will use `NOTIFY_SOCKET` env var,
+// and if it's not set (not running under systemd) it does nothing.
+func Notify(socket, state string) error {
+	if socket == "" {
+		socket = os.Getenv("NOTIFY_SOCKET")
+	}
+	if socket == "" {
+		return nil
+	}
+
+	// Abstract namespace sockets.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("could not connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		return fmt.Errorf("could not notify systemd: %w", err)
+	}
+
+	return nil
+}
+
+// WatchdogConfig is the configuration of the Watchdog.
+type WatchdogConfig struct {
+	// Socket is the systemd notify socket.
+	// By default `NOTIFY_SOCKET` env var.
+	Socket string
+	// Interval is the interval of the watchdog pings while reloading.
+	// By default half of the `WATCHDOG_USEC` env var (set by systemd
+	// when `WatchdogSec` is configured).
+	Interval time.Duration
+	// OnError is called when a ping fails, optional.
+	OnError func(err error)
+	// Clock is the clock of the pings interval.
+	// By default `reload.RealClock`.
+	Clock reload.Clock
+}
+
+func (c *WatchdogConfig) defaults() error {
+	if c.Socket == "" {
+		c.Socket = os.Getenv("NOTIFY_SOCKET")
+	}
+
+	if c.Interval <= 0 {
+		if usec := os.Getenv("WATCHDOG_USEC"); usec != "" {
+			n, err := strconv.ParseInt(usec, 10, 64)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid WATCHDOG_USEC %q", usec)
+			}
+			c.Interval = time.Duration(n) * time.Microsecond / 2
+		}
+	}
+
+	if c.OnError == nil {
+		c.OnError = func(error) {}
+	}
+
+	if c.Clock == nil {
+		c.Clock = reload.RealClock
+	}
+
+	return nil
+}
+
+// Watchdog is a reload.Subscriber that sends systemd watchdog pings
+// (`WATCHDOG=1`) while a reload is in progress, so services with `WatchdogSec`
+// configured are not killed by systemd in the middle of a long reload when the
+// regular application pings are blocked.
+//
+// If the service is not running under systemd or the watchdog is not enabled,
+// it does nothing.
+type Watchdog struct {
+	cfg     WatchdogConfig
+	enabled bool
+
+	mu       sync.Mutex
+	inFlight int
+	stop     chan struct{}
+}
+
+var _ reload.Subscriber = &Watchdog{}
+
+// NewWatchdog returns a new Watchdog.
+func NewWatchdog(cfg WatchdogConfig) (*Watchdog, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Watchdog{
+		cfg:     cfg,
+		enabled: cfg.Socket != "" && cfg.Interval > 0,
+	}, nil
+}
+
+// WithWatchdog returns a manager option that registers a Watchdog.
+func WithWatchdog(cfg WatchdogConfig) (reload.ManagerOption, error) {
+	w, err := NewWatchdog(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return reload.WithSubscriber(w), nil
+}
+
+// HandleEvent satisfies reload.Subscriber interface.
+func (w *Watchdog) HandleEvent(_ context.Context, e reload.Event) {
+	if !w.enabled {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch e.Type {
+	case reload.EventReloadStarted:
+		if w.inFlight == 0 {
+			w.stop = make(chan struct{})
+			go w.keepalive(w.stop)
+		}
+		w.inFlight++
+	case reload.EventReloadFinished:
+		if w.inFlight == 0 {
+			return
+		}
+		w.inFlight--
+		if w.inFlight == 0 {
+			close(w.stop)
+		}
+	}
+}
+
+// keepalive pings the watchdog until stopped.
+func (w *Watchdog) keepalive(stop chan struct{}) {
+	t := w.cfg.Clock.NewTicker(w.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		err := Notify(w.cfg.Socket, "WATCHDOG=1")
+		if err != nil {
+			w.cfg.OnError(err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-t.C():
+		}
+	}
+}
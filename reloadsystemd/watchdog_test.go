@@ -0,0 +1,97 @@
+package reloadsystemd_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadsystemd"
+	"github.com/slok/reload/reloadtest"
+)
+
+// readNotify reads the notify socket states until the timeout.
+func readNotify(t *testing.T, conn *net.UnixConn, timeout time.Duration) []string {
+	var states []string
+	buf := make([]byte, 1024)
+	for {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(timeout)))
+		n, err := conn.Read(buf)
+		if err != nil {
+			return states
+		}
+		states = append(states, string(buf[:n]))
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(err)
+	defer conn.Close()
+
+	clock := reloadtest.NewClock(time.Now())
+	w, err := reloadsystemd.NewWatchdog(reloadsystemd.WatchdogConfig{
+		Socket:   socket,
+		Interval: 5 * time.Second,
+		Clock:    clock,
+		OnError:  func(err error) { assert.NoError(err) },
+	})
+	require.NoError(err)
+
+	// Without reloads there should not be pings.
+	assert.Empty(readNotify(t, conn, 50*time.Millisecond))
+
+	// While reloading it should ping on every interval.
+	w.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadStarted})
+	require.True(clock.WaitWaiters(1, time.Second))
+	assert.Equal([]string{"WATCHDOG=1"}, readNotify(t, conn, 50*time.Millisecond))
+	clock.Advance(5 * time.Second)
+	assert.Equal([]string{"WATCHDOG=1"}, readNotify(t, conn, 50*time.Millisecond))
+
+	// Once finished it should stop pinging.
+	w.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadFinished})
+	assert.True(waitNoWaiters(clock))
+	clock.Advance(time.Minute)
+	assert.Empty(readNotify(t, conn, 50*time.Millisecond))
+}
+
+// waitNoWaiters waits until the clock has no waiters.
+func waitNoWaiters(clock *reloadtest.Clock) bool {
+	for range 100 {
+		if !clock.WaitWaiters(1, 0) {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return false
+}
+
+func TestWatchdogDisabled(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	t.Setenv("WATCHDOG_USEC", "")
+
+	w, err := reloadsystemd.NewWatchdog(reloadsystemd.WatchdogConfig{})
+	require.NoError(t, err)
+
+	// Should not do anything.
+	w.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadStarted})
+	w.HandleEvent(context.TODO(), reload.Event{Type: reload.EventReloadFinished})
+	assert.NoError(t, reloadsystemd.Notify("", "WATCHDOG=1"))
+}
+
+func TestWatchdogInvalidEnv(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "abc")
+
+	_, err := reloadsystemd.NewWatchdog(reloadsystemd.WatchdogConfig{})
+	assert.Error(t, err)
+}
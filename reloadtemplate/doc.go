@@ -0,0 +1,6 @@
+// Package reloadtemplate has the `html/template` and `text/template` integrations
+// of the reload mechanism.
+//
+// The templates are usually paired with a `reload.FileNotifier` watching the
+// templates directory (`Dirs`), so any template change reparses the templates.
+package reloadtemplate
@@ -0,0 +1,142 @@
+package reloadtemplate
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"sync/atomic"
+	texttemplate "text/template"
+)
+
+// TemplatesConfig is the configuration of the Templates.
+type TemplatesConfig[T any] struct {
+	// Parse parses the template tree, it will be called on the creation and
+	// on every reload.
+	Parse func(ctx context.Context) (T, error)
+}
+
+func (c *TemplatesConfig[T]) defaults() error {
+	if c.Parse == nil {
+		return fmt.Errorf("parse function is required")
+	}
+
+	return nil
+}
+
+// Templates is a reload.Reloader that reparses a template tree on every
+// reload and swaps it atomically, the users should get the templates using
+// Get on every use instead of storing them.
+//
+// If the templates can't be parsed, the reload fails and the previous
+// templates are kept.
+//
+// It can be used with any template type, but NewHTML and NewText
+// are ready to be used with `html/template` and `text/template`.
+type Templates[T any] struct {
+	cfg     TemplatesConfig[T]
+	current atomic.Pointer[T]
+}
+
+// NewTemplates returns new Templates, the templates are parsed on the creation.
+func NewTemplates[T any](ctx context.Context, cfg TemplatesConfig[T]) (*Templates[T], error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	t := &Templates[T]{cfg: cfg}
+	err = t.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Get returns the current templates.
+func (t *Templates[T]) Get() T {
+	return *t.current.Load()
+}
+
+// Reload satisfies reload.Reloader interface.
+func (t *Templates[T]) Reload(ctx context.Context, _ string) error {
+	tpl, err := t.cfg.Parse(ctx)
+	if err != nil {
+		return fmt.Errorf("could not parse templates: %w", err)
+	}
+	t.current.Store(&tpl)
+
+	return nil
+}
+
+// Config is the configuration of the `html/template` and `text/template` templates.
+type Config struct {
+	// Patterns are the glob patterns (`filepath.Glob` format) of the template files.
+	Patterns []string
+	// Name is the name of the root template.
+	// By default `root`.
+	Name string
+	// Funcs are the functions available on the templates.
+	Funcs map[string]any
+	// LeftDelim and RightDelim are the template action delimiters.
+	// By default `{{` and `}}`.
+	LeftDelim, RightDelim string
+}
+
+func (c *Config) defaults() error {
+	if len(c.Patterns) == 0 {
+		return fmt.Errorf("at least one pattern is required")
+	}
+
+	if c.Name == "" {
+		c.Name = "root"
+	}
+
+	return nil
+}
+
+// NewHTML returns new `html/template` templates that are reparsed from the
+// template files on every reload.
+func NewHTML(ctx context.Context, cfg Config) (*Templates[*htmltemplate.Template], error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return NewTemplates(ctx, TemplatesConfig[*htmltemplate.Template]{
+		Parse: func(_ context.Context) (*htmltemplate.Template, error) {
+			t := htmltemplate.New(cfg.Name).Delims(cfg.LeftDelim, cfg.RightDelim).Funcs(cfg.Funcs)
+			for _, p := range cfg.Patterns {
+				var err error
+				t, err = t.ParseGlob(p)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return t, nil
+		},
+	})
+}
+
+// NewText returns new `text/template` templates that are reparsed from the
+// template files on every reload.
+func NewText(ctx context.Context, cfg Config) (*Templates[*texttemplate.Template], error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return NewTemplates(ctx, TemplatesConfig[*texttemplate.Template]{
+		Parse: func(_ context.Context) (*texttemplate.Template, error) {
+			t := texttemplate.New(cfg.Name).Delims(cfg.LeftDelim, cfg.RightDelim).Funcs(cfg.Funcs)
+			for _, p := range cfg.Patterns {
+				var err error
+				t, err = t.ParseGlob(p)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return t, nil
+		},
+	})
+}
@@ -0,0 +1,99 @@
+package reloadtemplate_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadtemplate"
+)
+
+func TestHTMLTemplates(t *testing.T) {
+	tests := map[string]struct {
+		initial  string
+		reloaded string
+		expOut   string
+		expErr   bool
+	}{
+		"The reloaded templates should be used after a reload.": {
+			initial:  `{{ define "page" }}<p>{{ . }}</p>{{ end }}`,
+			reloaded: `{{ define "page" }}<h1>{{ upper . }}</h1>{{ end }}`,
+			expOut:   `<h1>&lt;HI&gt;</h1>`,
+		},
+
+		"Invalid templates should keep the previous templates.": {
+			initial:  `{{ define "page" }}<p>{{ . }}</p>{{ end }}`,
+			reloaded: `{{ define "page" }}<p>{{ . }</p>{{ end }}`,
+			expOut:   `<p>&lt;hi&gt;</p>`,
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			dir := t.TempDir()
+			path := filepath.Join(dir, "page.html")
+			require.NoError(os.WriteFile(path, []byte(test.initial), 0o600))
+			tpls, err := reloadtemplate.NewHTML(context.TODO(), reloadtemplate.Config{
+				Patterns: []string{filepath.Join(dir, "*.html")},
+				Funcs:    map[string]any{"upper": strings.ToUpper},
+			})
+			require.NoError(err)
+
+			// Execute.
+			require.NoError(os.WriteFile(path, []byte(test.reloaded), 0o600))
+			err = tpls.Reload(context.TODO(), "test")
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			var b bytes.Buffer
+			require.NoError(tpls.Get().ExecuteTemplate(&b, "page", "<hi>"))
+			assert.Equal(test.expOut, b.String())
+		})
+	}
+}
+
+func TestTextTemplates(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	dir := t.TempDir()
+	require.NoError(os.WriteFile(filepath.Join(dir, "a.tmpl"), []byte(`[[ define "a" ]]a: [[ . ]][[ end ]]`), 0o600))
+	tpls, err := reloadtemplate.NewText(context.TODO(), reloadtemplate.Config{
+		Patterns:   []string{filepath.Join(dir, "*.tmpl")},
+		LeftDelim:  "[[",
+		RightDelim: "]]",
+	})
+	require.NoError(err)
+
+	// A new template file should be available after a reload.
+	require.NoError(os.WriteFile(filepath.Join(dir, "b.tmpl"), []byte(`[[ define "b" ]]b: [[ . ]][[ end ]]`), 0o600))
+	require.NoError(tpls.Reload(context.TODO(), "test"))
+
+	var b bytes.Buffer
+	require.NoError(tpls.Get().ExecuteTemplate(&b, "a", "<hi>"))
+	require.NoError(tpls.Get().ExecuteTemplate(&b, "b", "<hi>"))
+	assert.Equal("a: <hi>b: <hi>", b.String())
+}
+
+func TestNewTemplatesInvalid(t *testing.T) {
+	_, err := reloadtemplate.NewHTML(context.TODO(), reloadtemplate.Config{
+		Patterns: []string{filepath.Join(t.TempDir(), "*.html")},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package reloadtest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// Clock is a reload.Clock for tests that only moves when Advance is called, so
+// the time-based features are deterministic. Safe for concurrent use.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*clockWaiter
+	changed chan struct{} // Closed when the waiters change.
+}
+
+var _ reload.Clock = &Clock{}
+
+type clockWaiter struct {
+	at     time.Time
+	period time.Duration // Only for tickers.
+	c      chan time.Time
+}
+
+// NewClock returns a new Clock set at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now, changed: make(chan struct{})}
+}
+
+// Now satisfies reload.Clock interface.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer satisfies reload.Clock interface.
+func (c *Clock) NewTimer(d time.Duration) reload.Timer {
+	return &clockTimer{clock: c, w: c.addWaiter(d, 0)}
+}
+
+// NewTicker satisfies reload.Clock interface.
+func (c *Clock) NewTicker(d time.Duration) reload.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return &clockTicker{clock: c, w: c.addWaiter(d, d)}
+}
+
+// Advance moves the clock forward and fires the timers and tickers that
+// expire, in expiration order. Like the real tickers, the ticks are dropped if
+// the previous ones have not been received.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	for {
+		sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+		if len(c.waiters) == 0 || c.waiters[0].at.After(end) {
+			break
+		}
+
+		w := c.waiters[0]
+		c.now = w.at
+		select {
+		case w.c <- w.at:
+		default:
+		}
+
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+			continue
+		}
+		c.waiters = c.waiters[1:]
+		c.notifyChanged()
+	}
+	c.now = end
+}
+
+// WaitWaiters blocks until there are at least n active timers and tickers, so
+// the tests can advance the clock once the code under test is waiting on it.
+// Returns false if the timeout (real time) expires.
+func (c *Clock) WaitWaiters(n int, timeout time.Duration) bool {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	for {
+		c.mu.Lock()
+		count, changed := len(c.waiters), c.changed
+		c.mu.Unlock()
+		if count >= n {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-t.C:
+			return false
+		}
+	}
+}
+
+func (c *Clock) addWaiter(d, period time.Duration) *clockWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &clockWaiter{at: c.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	c.notifyChanged()
+
+	return w
+}
+
+// removeWaiter removes the waiter, returns false if it was not active.
+func (c *Clock) removeWaiter(w *clockWaiter) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, cw := range c.waiters {
+		if cw == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.notifyChanged()
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Clock) notifyChanged() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+type clockTimer struct {
+	clock *Clock
+	w     *clockWaiter
+}
+
+func (t *clockTimer) C() <-chan time.Time { return t.w.c }
+func (t *clockTimer) Stop() bool          { return t.clock.removeWaiter(t.w) }
+
+type clockTicker struct {
+	clock *Clock
+	w     *clockWaiter
+}
+
+func (t *clockTicker) C() <-chan time.Time { return t.w.c }
+func (t *clockTicker) Stop()               { t.clock.removeWaiter(t.w) }
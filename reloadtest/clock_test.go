@@ -0,0 +1,55 @@
+package reloadtest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload/reloadtest"
+)
+
+func received(c <-chan time.Time) []time.Time {
+	var res []time.Time
+	for {
+		select {
+		case t := <-c:
+			res = append(res, t)
+		default:
+			return res
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC)
+	c := reloadtest.NewClock(start)
+	timer := c.NewTimer(time.Minute)
+	stopped := c.NewTimer(time.Minute)
+	ticker := c.NewTicker(20 * time.Second)
+	assert.True(c.WaitWaiters(3, time.Second))
+	assert.True(stopped.Stop())
+
+	// Nothing should fire before the time.
+	c.Advance(10 * time.Second)
+	assert.Equal(start.Add(10*time.Second), c.Now())
+	assert.Empty(received(timer.C()))
+	assert.Empty(received(ticker.C()))
+
+	// The ticker ticks should be dropped if not received.
+	c.Advance(50 * time.Second)
+	assert.Equal([]time.Time{start.Add(time.Minute)}, received(timer.C()))
+	assert.Equal([]time.Time{start.Add(20 * time.Second)}, received(ticker.C()))
+	assert.Empty(received(stopped.C()))
+	assert.False(timer.Stop())
+
+	c.Advance(20 * time.Second)
+	assert.Equal([]time.Time{start.Add(80 * time.Second)}, received(ticker.C()))
+
+	ticker.Stop()
+	c.Advance(time.Hour)
+	assert.Empty(received(ticker.C()))
+	assert.False(c.WaitWaiters(1, 10*time.Millisecond))
+}
@@ -0,0 +1,2 @@
+// Package reloadtest has the testing utilities of the reload mechanism.
+package reloadtest
@@ -0,0 +1,172 @@
+package reloadtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/slok/reload"
+)
+
+// Recorder is a reload.Subscriber that records the ordered event trail of the
+// manager, so the tests can check the full reload pipeline execution against
+// an expected trail. Safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	events []reload.Event
+}
+
+var _ reload.Subscriber = &Recorder{}
+
+// NewRecorder returns a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// HandleEvent satisfies reload.Subscriber interface.
+func (r *Recorder) HandleEvent(_ context.Context, e reload.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// Events returns the recorded events.
+func (r *Recorder) Events() []reload.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]reload.Event(nil), r.events...)
+}
+
+// Reset removes the recorded events.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
+
+// Trail returns the recorded events as a trail of lines without the
+// non-deterministic information (times and durations), e.g:
+//
+//	trigger_received id=t1 source=manual
+//	reload_started id=t1
+//	group_started id=t1 priority=0
+//	reloader_finished id=t1 priority=0 reloader=config
+//	group_finished id=t1 priority=0
+//	reload_finished id=t1
+//
+// The errors are added with `err=<message>`. The reloaders of the same group
+// run concurrently, so their consecutive events are sorted by reloader name.
+func (r *Recorder) Trail() []string {
+	events := r.Events()
+
+	// Sort the concurrent reloader events.
+	for i := 0; i < len(events); {
+		j := i
+		for j < len(events) && events[j].Type == reload.EventReloaderFinished {
+			j++
+		}
+		if j == i {
+			i++
+			continue
+		}
+		group := events[i:j]
+		sort.SliceStable(group, func(x, y int) bool { return group[x].Reloader < group[y].Reloader })
+		i = j
+	}
+
+	trail := make([]string, 0, len(events))
+	for _, e := range events {
+		trail = append(trail, TrailLine(e))
+	}
+
+	return trail
+}
+
+// TrailLine returns the trail line of an event, see Recorder.Trail.
+func TrailLine(e reload.Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s id=%s", e.Type, e.Trigger.ID)
+
+	switch e.Type {
+	case reload.EventTriggerReceived, reload.EventTriggerDropped:
+		fmt.Fprintf(&b, " source=%s", e.Trigger.Source)
+	case reload.EventGroupStarted, reload.EventGroupFinished:
+		fmt.Fprintf(&b, " priority=%d", e.Priority)
+	case reload.EventReloaderFinished, reload.EventReloaderSkipped, reload.EventAbandonedReloaderReturned:
+		fmt.Fprintf(&b, " priority=%d reloader=%s", e.Priority, e.Reloader)
+	case reload.EventNotifierQuarantined, reload.EventNotifierReleased,
+		reload.EventNotifierStale, reload.EventNotifierRestarted, reload.EventNotifierAlive:
+		fmt.Fprintf(&b, " notifier=%s", e.Notifier)
+	case reload.EventReloadRetrying:
+		fmt.Fprintf(&b, " attempt=%d", e.Attempt)
+	}
+
+	if e.Err != nil {
+		fmt.Fprintf(&b, " err=%s", e.Err)
+	}
+
+	return b.String()
+}
+
+// AssertTrail checks the recorded trail is the expected one, if not, it fails
+// the test with the diff between them.
+func (r *Recorder) AssertTrail(t testing.TB, exp ...string) bool {
+	t.Helper()
+
+	diff := DiffTrail(exp, r.Trail())
+	if diff != "" {
+		t.Errorf("unexpected event trail (-expected +got):\n%s", diff)
+		return false
+	}
+
+	return true
+}
+
+// DiffTrail returns the line diff between the expected and the got trails
+// (`-` expected lines missing, `+` got lines not expected), empty if they are
+// the same.
+func DiffTrail(exp, got []string) string {
+	// Longest common subsequence table.
+	lcs := make([][]int, len(exp)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(exp) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if exp[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	changed := false
+	i, j := 0, 0
+	for i < len(exp) || j < len(got) {
+		switch {
+		case i < len(exp) && j < len(got) && exp[i] == got[j]:
+			fmt.Fprintf(&b, "  %s\n", exp[i])
+			i++
+			j++
+		case i < len(exp) && (j == len(got) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&b, "- %s\n", exp[i])
+			changed = true
+			i++
+		default:
+			fmt.Fprintf(&b, "+ %s\n", got[j])
+			changed = true
+			j++
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+
+	return b.String()
+}
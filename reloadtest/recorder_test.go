@@ -0,0 +1,87 @@
+package reloadtest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	rec := reloadtest.NewRecorder()
+	noop := reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil })
+	m := reload.NewManager(reload.WithSubscriber(rec))
+	m.Add(0, noop, reload.WithReloaderName("config"))
+	m.Add(0, noop, reload.WithReloaderName("cache"))
+	m.Add(10, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		if id == "t2" {
+			return fmt.Errorf("something")
+		}
+		return nil
+	}), reload.WithReloaderName("server"))
+
+	// Execute.
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"}))
+	require.Error(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t2"}))
+
+	// Check.
+	assert.True(rec.AssertTrail(t,
+		"trigger_received id=t1 source=manual",
+		"reload_started id=t1",
+		"group_started id=t1 priority=0",
+		"reloader_finished id=t1 priority=0 reloader=cache",
+		"reloader_finished id=t1 priority=0 reloader=config",
+		"group_finished id=t1 priority=0",
+		"group_started id=t1 priority=10",
+		"reloader_finished id=t1 priority=10 reloader=server",
+		"group_finished id=t1 priority=10",
+		"reload_finished id=t1",
+		"trigger_received id=t2 source=manual",
+		"reload_started id=t2",
+		"group_started id=t2 priority=0",
+		"reloader_finished id=t2 priority=0 reloader=cache",
+		"reloader_finished id=t2 priority=0 reloader=config",
+		"group_finished id=t2 priority=0",
+		"group_started id=t2 priority=10",
+		"reloader_finished id=t2 priority=10 reloader=server err=something",
+		`group_finished id=t2 priority=10 err=reloader "server" (1/1): something`,
+		`reload_finished id=t2 err=reload "t2" failed: group (priority 10): reloader "server" (1/1): something`,
+	))
+
+	rec.Reset()
+	assert.Empty(rec.Events())
+}
+
+func TestDiffTrail(t *testing.T) {
+	tests := map[string]struct {
+		exp     []string
+		got     []string
+		expDiff string
+	}{
+		"The same trails should not have diff.": {
+			exp: []string{"a", "b"},
+			got: []string{"a", "b"},
+		},
+
+		"Different trails should have the diff.": {
+			exp:     []string{"a", "b", "c"},
+			got:     []string{"a", "x", "c", "d"},
+			expDiff: "  a\n- b\n+ x\n  c\n+ d\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expDiff, reloadtest.DiffTrail(test.exp, test.got))
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package reloadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/slok/reload"
+)
+
+// StressConfig is the configuration of Stress.
+type StressConfig struct {
+	// Triggers is the number of triggers that will be sent.
+	// By default 500.
+	Triggers int
+	// Concurrency is the number of goroutines sending the triggers.
+	// By default 8.
+	Concurrency int
+	// Groups is the number of reloader priority groups.
+	// By default 3.
+	Groups int
+	// ReloadersPerGroup is the number of reloaders of every group.
+	// By default 3.
+	ReloadersPerGroup int
+	// MaxLatency is the maximum random latency of every reloader.
+	// By default 1ms.
+	MaxLatency time.Duration
+	// FailureRate is the probability (0 to 1) of a reloader failing a manual
+	// reload, the notifier triggered reloads don't fail so the manager keeps
+	// running. Use a negative number to disable the failures.
+	// By default 0.05.
+	FailureRate float64
+	// Seed is the seed of the random latencies and failures.
+	// By default a random one.
+	Seed uint64
+	// ManagerOptions are the options used to create the manager.
+	ManagerOptions []reload.ManagerOption
+	// LeakTimeout is the time to wait for the goroutines to end after the
+	// manager stops, before reporting them as leaked.
+	// By default 2s.
+	LeakTimeout time.Duration
+}
+
+func (c *StressConfig) defaults() error {
+	if c.Triggers <= 0 {
+		c.Triggers = 500
+	}
+
+	if c.Concurrency <= 0 {
+		c.Concurrency = 8
+	}
+
+	if c.Groups <= 0 {
+		c.Groups = 3
+	}
+
+	if c.ReloadersPerGroup <= 0 {
+		c.ReloadersPerGroup = 3
+	}
+
+	if c.MaxLatency <= 0 {
+		c.MaxLatency = time.Millisecond
+	}
+
+	if c.FailureRate == 0 {
+		c.FailureRate = 0.05
+	}
+
+	if c.FailureRate > 1 {
+		return fmt.Errorf("failure rate can't be greater than 1")
+	}
+
+	if c.Seed == 0 {
+		c.Seed = rand.Uint64()
+	}
+
+	if c.LeakTimeout <= 0 {
+		c.LeakTimeout = 2 * time.Second
+	}
+
+	return nil
+}
+
+// StressReport is the result of a Stress run.
+type StressReport struct {
+	// Seed is the seed used, to reproduce the run.
+	Seed uint64
+	// Triggers is the number of sent triggers.
+	Triggers int
+	// Reloads is the number of executed reloads (started pipelines).
+	Reloads int
+	// Failures is the number of failed reloads.
+	Failures int
+	// Skipped is the number of skipped triggers (e.g: reload in progress).
+	Skipped int
+}
+
+// Stress hammers a manager with concurrent triggers, half of them sent with
+// `Manager.TriggerReload` and the other half with a notifier while the manager
+// runs, using reloaders with random latencies and failures. It checks the
+// invariants of the reload mechanism and returns an error with the violated
+// ones:
+//
+//   - The reload pipelines never overlap.
+//   - The priority groups run in order, a group only starts once all the
+//     reloaders of the previous groups applied the same trigger.
+//   - The final state is not lost, a final reload is applied by all the
+//     reloaders and the manager generation is the one of the last successful
+//     reload.
+//   - There are no goroutine leaks once the manager stops.
+//
+// It's designed to be run with `-race` (e.g: on the CI of the applications
+// that use custom manager options, notifiers or subscribers).
+func Stress(ctx context.Context, cfg StressConfig) (StressReport, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return StressReport{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	baseGoroutines := runtime.NumGoroutine()
+	s := newStressState(cfg)
+	report, err := s.run(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	// Check goroutine leaks.
+	deadline := time.Now().Add(cfg.LeakTimeout)
+	for runtime.NumGoroutine() > baseGoroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseGoroutines {
+		s.violation("%d goroutines leaked", n-baseGoroutines)
+	}
+
+	return report, s.err()
+}
+
+var errStressFailure = errors.New("stress failure")
+
+type stressState struct {
+	cfg StressConfig
+
+	mu         sync.Mutex
+	violations []error
+	pipeline   string   // Trigger ID of the running pipeline.
+	applied    []string // Last applied trigger ID by reloader index.
+	started    int
+	finished   int
+	failures   int
+	skipped    int
+	// generations are the generations of the reloads by trigger ID, and
+	// generation the newest one of the successful reloads.
+	generations map[string]uint64
+	generation  uint64
+}
+
+func newStressState(cfg StressConfig) *stressState {
+	return &stressState{
+		cfg:         cfg,
+		applied:     make([]string, cfg.Groups*cfg.ReloadersPerGroup),
+		generations: map[string]uint64{},
+	}
+}
+
+func (s *stressState) violation(format string, args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Don't flood with the same violations.
+	if len(s.violations) < 20 {
+		s.violations = append(s.violations, fmt.Errorf(format, args...))
+	}
+}
+
+func (s *stressState) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return errors.Join(s.violations...)
+}
+
+// HandleEvent tracks the pipelines.
+func (s *stressState) HandleEvent(_ context.Context, e reload.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch e.Type {
+	case reload.EventReloadStarted:
+		if s.pipeline != "" {
+			s.violations = append(s.violations, fmt.Errorf("pipeline %q started while %q was running", e.Trigger.ID, s.pipeline))
+		}
+		s.pipeline = e.Trigger.ID
+		s.started++
+	case reload.EventReloadFinished:
+		s.pipeline = ""
+		s.finished++
+		if e.Err != nil {
+			s.failures++
+		} else {
+			s.generation = max(s.generation, s.generations[e.Trigger.ID])
+		}
+	case reload.EventReloadSkipped:
+		s.skipped++
+	}
+}
+
+func (s *stressState) reloader(group, idx int) reload.Reloader {
+	var mu sync.Mutex
+	rnd := rand.New(rand.NewPCG(s.cfg.Seed, uint64(idx)))
+	return reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		mu.Lock()
+		latency := time.Duration(rnd.Int64N(int64(s.cfg.MaxLatency) + 1))
+		fail := rnd.Float64() < s.cfg.FailureRate
+		mu.Unlock()
+
+		// Check the pipeline and the previous groups.
+		s.mu.Lock()
+		if g, ok := reload.GenerationFromContext(ctx); ok {
+			s.generations[id] = g
+		}
+		if s.pipeline != id {
+			s.violations = append(s.violations, fmt.Errorf("reloader %d ran trigger %q while pipeline %q was running", idx, id, s.pipeline))
+		}
+		for i := 0; i < group*s.cfg.ReloadersPerGroup; i++ {
+			if s.applied[i] != id {
+				s.violations = append(s.violations, fmt.Errorf("reloader %d ran trigger %q before reloader %d of a previous group applied it", idx, id, i))
+			}
+		}
+		s.mu.Unlock()
+
+		t := time.NewTimer(latency)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		if t, ok := reload.TriggerEventFromContext(ctx); ok && t.Source == "manual" && fail {
+			return errStressFailure
+		}
+
+		s.mu.Lock()
+		s.applied[idx] = id
+		s.mu.Unlock()
+
+		return nil
+	})
+}
+
+func (s *stressState) run(ctx context.Context) (StressReport, error) {
+	opts := append([]reload.ManagerOption{reload.WithSubscriber(s)}, s.cfg.ManagerOptions...)
+	m := reload.NewManager(opts...)
+	for g := 0; g < s.cfg.Groups; g++ {
+		for r := 0; r < s.cfg.ReloadersPerGroup; r++ {
+			idx := g*s.cfg.ReloadersPerGroup + r
+			m.Add(g, s.reloader(g, idx), reload.WithReloaderName(fmt.Sprintf("stress-%d", idx)))
+		}
+	}
+	notifierC := make(chan string)
+	m.On(reload.NotifierChan(notifierC), reload.WithNotifierName("stress"))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- m.Run(runCtx) }()
+
+	// Send the triggers concurrently.
+	var sent atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < s.cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < s.cfg.Triggers; i += s.cfg.Concurrency {
+				id := fmt.Sprintf("stress-%d", i)
+				sent.Add(1)
+				if i%2 == 0 {
+					err := m.TriggerReload(ctx, reload.TriggerEvent{ID: id})
+					if err != nil && !errors.Is(err, reload.ErrReloadInProgress) && !errors.Is(err, errStressFailure) {
+						s.violation("unexpected trigger error: %s", err)
+					}
+					continue
+				}
+
+				select {
+				case notifierC <- id:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Apply the final state, retrying while other reload is in progress.
+	final := "stress-final"
+	for {
+		err := m.TriggerReload(ctx, reload.TriggerEvent{ID: final, Source: "final"})
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, reload.ErrReloadInProgress) {
+			s.violation("final reload failed: %s", err)
+			break
+		}
+		if ctx.Err() != nil {
+			return StressReport{}, ctx.Err()
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	err := <-runErr
+	if err != nil {
+		s.violation("manager run failed: %s", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, id := range s.applied {
+		if id != final {
+			s.violations = append(s.violations, fmt.Errorf("reloader %d lost the final state, last applied %q", i, id))
+		}
+	}
+	if s.started != s.finished {
+		s.violations = append(s.violations, fmt.Errorf("%d reloads started but %d finished", s.started, s.finished))
+	}
+	if g := m.Status().Generation; g != s.generation {
+		s.violations = append(s.violations, fmt.Errorf("manager generation %d doesn't match the %d generation of the last successful reload", g, s.generation))
+	}
+
+	return StressReport{
+		Seed:     s.cfg.Seed,
+		Triggers: int(sent.Load()),
+		Reloads:  s.started,
+		Failures: s.failures,
+		Skipped:  s.skipped,
+	}, nil
+}
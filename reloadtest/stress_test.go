@@ -0,0 +1,53 @@
+package reloadtest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestStress(t *testing.T) {
+	tests := map[string]struct {
+		cfg reloadtest.StressConfig
+	}{
+		"Default manager should satisfy the invariants.": {
+			cfg: reloadtest.StressConfig{Triggers: 200},
+		},
+
+		"Manager with options should satisfy the invariants.": {
+			cfg: reloadtest.StressConfig{
+				Triggers: 200,
+				ManagerOptions: []reload.ManagerOption{
+					reload.WithOrderedReloaders(),
+					reload.WithStaleTriggerPolicy(reload.StaleTriggerCollapse),
+					reload.WithShutdownGracePeriod(time.Second),
+				},
+			},
+		},
+
+		"Without failures all the reloads should succeed.": {
+			cfg: reloadtest.StressConfig{Triggers: 100, FailureRate: -1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			report, err := reloadtest.Stress(context.TODO(), test.cfg)
+
+			if assert.NoError(err, "seed %d", report.Seed) {
+				assert.Equal(test.cfg.Triggers, report.Triggers)
+				assert.Positive(report.Reloads)
+				if test.cfg.FailureRate < 0 {
+					assert.Zero(report.Failures)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package reloadtls has the TLS integrations of the reload mechanism.
+package reloadtls
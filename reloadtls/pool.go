@@ -0,0 +1,176 @@
+package reloadtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync/atomic"
+)
+
+// CertPoolConfig is the configuration of the CertPool.
+type CertPoolConfig struct {
+	// Paths are the PEM encoded CA bundle files.
+	Paths []string
+	// System will use the system certificate pool as the base pool.
+	System bool
+	// FS is the file system of the paths, the paths are names of the file
+	// system (e.g: an `embed.FS` with the bundled CAs).
+	// By default the OS file system.
+	FS fs.FS
+}
+
+func (c *CertPoolConfig) defaults() error {
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("at least one path is required")
+	}
+
+	return nil
+}
+
+// CertPool is a reload.Reloader that re-reads CA bundle files on every reload
+// and swaps the certificate pool atomically, so the trust anchors can rotate
+// without restarting.
+//
+// A `tls.Config` can't change its pools safely once used, so to use the
+// reloaded pools use:
+//
+//   - Servers (client certificates): ServerConfig.
+//   - Clients (server certificates): ClientConfig.
+//
+// If a bundle can't be read or doesn't have certificates, the reload fails
+// and the previous pool is kept.
+type CertPool struct {
+	cfg     CertPoolConfig
+	current atomic.Pointer[x509.CertPool]
+}
+
+// NewCertPool returns a new CertPool, the bundles are loaded on the creation.
+func NewCertPool(cfg CertPoolConfig) (*CertPool, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	c := &CertPool{cfg: cfg}
+	err = c.Reload(context.Background(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Get returns the current certificate pool.
+func (c *CertPool) Get() *x509.CertPool {
+	return c.current.Load()
+}
+
+// Reload satisfies reload.Reloader interface.
+func (c *CertPool) Reload(_ context.Context, _ string) error {
+	pool := x509.NewCertPool()
+	if c.cfg.System {
+		sp, err := x509.SystemCertPool()
+		if err != nil {
+			return fmt.Errorf("could not load system certificate pool: %w", err)
+		}
+		pool = sp
+	}
+
+	for _, p := range c.cfg.Paths {
+		pem, err := c.readFile(p)
+		if err != nil {
+			return fmt.Errorf("could not read %q CA bundle: %w", p, err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%q CA bundle doesn't have valid certificates", p)
+		}
+	}
+
+	c.current.Store(pool)
+
+	return nil
+}
+
+func (c *CertPool) readFile(path string) ([]byte, error) {
+	if c.cfg.FS == nil {
+		return os.ReadFile(path)
+	}
+
+	return fs.ReadFile(c.cfg.FS, path)
+}
+
+// ServerConfig returns a server TLS configuration based on base (can be nil)
+// that verifies the client certificates with the current pool (`ClientCAs`).
+//
+// The `GetConfigForClient` of base is ignored.
+func (c *CertPool) ServerConfig(base *tls.Config) *tls.Config {
+	if base == nil {
+		base = &tls.Config{}
+	}
+	base = base.Clone()
+	base.GetConfigForClient = nil
+
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := base.Clone()
+			cfg.ClientCAs = c.Get()
+			return cfg, nil
+		},
+	}
+}
+
+// ClientConfig returns a client TLS configuration based on base (can be nil)
+// that verifies the server certificates with the current pool (`RootCAs`).
+//
+// The standard verification is replaced by an equivalent one using the current
+// pool, so the returned configuration has `InsecureSkipVerify` set. Like the
+// standard one, the server name needs to be known (set on base or by the
+// dialer, e.g: `http.Transport`), otherwise the handshake fails.
+func (c *CertPool) ClientConfig(base *tls.Config) *tls.Config {
+	cfg := &tls.Config{}
+	if base != nil {
+		cfg = base.Clone()
+	}
+
+	verifyConnection := cfg.VerifyConnection
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		err := c.verify(cs)
+		if err != nil {
+			return err
+		}
+		if verifyConnection != nil {
+			return verifyConnection(cs)
+		}
+		return nil
+	}
+
+	return cfg
+}
+
+func (c *CertPool) verify(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("server didn't present certificates")
+	}
+
+	// An empty DNS name would skip the hostname verification.
+	if cs.ServerName == "" {
+		return fmt.Errorf("server name is required to verify the server certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         c.Get(),
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
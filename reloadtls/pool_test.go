@@ -0,0 +1,256 @@
+package reloadtls_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload/reloadtls"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// newLeaf returns a new server certificate signed by the CA.
+func (c testCA) newLeaf(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, c.cert, &key.PublicKey, c.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestCertPool(t *testing.T) {
+	ca1 := newTestCA(t, "ca1")
+	ca2 := newTestCA(t, "ca2")
+
+	tests := map[string]struct {
+		initialBundle  []byte
+		reloadedBundle []byte
+		serverCA       testCA
+		expReloadErr   bool
+		expRequestErr  bool
+	}{
+		"A server signed by the CA should be trusted.": {
+			initialBundle:  ca1.pem,
+			reloadedBundle: ca1.pem,
+			serverCA:       ca1,
+		},
+
+		"A server signed by a rotated CA should be trusted after the reload.": {
+			initialBundle:  ca1.pem,
+			reloadedBundle: ca2.pem,
+			serverCA:       ca2,
+		},
+
+		"A server signed by a removed CA should not be trusted after the reload.": {
+			initialBundle:  ca1.pem,
+			reloadedBundle: ca2.pem,
+			serverCA:       ca1,
+			expRequestErr:  true,
+		},
+
+		"An invalid bundle should keep the previous pool.": {
+			initialBundle:  ca1.pem,
+			reloadedBundle: []byte("invalid"),
+			serverCA:       ca1,
+			expReloadErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			srv.TLS = &tls.Config{Certificates: []tls.Certificate{test.serverCA.newLeaf(t)}}
+			srv.StartTLS()
+			defer srv.Close()
+
+			path := filepath.Join(t.TempDir(), "ca.pem")
+			require.NoError(os.WriteFile(path, test.initialBundle, 0o600))
+			pool, err := reloadtls.NewCertPool(reloadtls.CertPoolConfig{Paths: []string{path}})
+			require.NoError(err)
+			client := &http.Client{Transport: &http.Transport{
+				TLSClientConfig: pool.ClientConfig(&tls.Config{ServerName: "localhost"}),
+			}}
+
+			// Execute.
+			require.NoError(os.WriteFile(path, test.reloadedBundle, 0o600))
+			err = pool.Reload(context.TODO(), "test")
+			if test.expReloadErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			resp, err := client.Get(srv.URL)
+
+			// Check.
+			if test.expRequestErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestCertPoolFS(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	ca1 := newTestCA(t, "ca1")
+	ca2 := newTestCA(t, "ca2")
+	verify := func(pool *reloadtls.CertPool, ca testCA) error {
+		leaf, err := x509.ParseCertificate(ca.newLeaf(t).Certificate[0])
+		require.NoError(err)
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: pool.Get(), DNSName: "localhost"})
+		return err
+	}
+	fsys := fstest.MapFS{"certs/ca.pem": {Data: ca1.pem}}
+	pool, err := reloadtls.NewCertPool(reloadtls.CertPoolConfig{Paths: []string{"certs/ca.pem"}, FS: fsys})
+	require.NoError(err)
+	require.NoError(verify(pool, ca1))
+
+	// Execute.
+	fsys["certs/ca.pem"] = &fstest.MapFile{Data: ca2.pem}
+	err = pool.Reload(context.TODO(), "test")
+
+	// Check.
+	require.NoError(err)
+	assert.NoError(verify(pool, ca2))
+	assert.Error(verify(pool, ca1))
+}
+
+func TestCertPoolClientConfigWithoutServerName(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	ca := newTestCA(t, "ca")
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{ca.newLeaf(t)}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(os.WriteFile(path, ca.pem, 0o600))
+	pool, err := reloadtls.NewCertPool(reloadtls.CertPoolConfig{Paths: []string{path}})
+	require.NoError(err)
+
+	// Execute.
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(err)
+	tlsConn := tls.Client(conn, pool.ClientConfig(nil))
+	defer tlsConn.Close()
+	err = tlsConn.Handshake()
+
+	// Check.
+	assert.ErrorContains(err, "server name is required")
+}
+
+func TestCertPoolServerConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	serverCA := newTestCA(t, "server-ca")
+	ca1 := newTestCA(t, "ca1")
+	ca2 := newTestCA(t, "ca2")
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(os.WriteFile(path, ca1.pem, 0o600))
+	pool, err := reloadtls.NewCertPool(reloadtls.CertPoolConfig{Paths: []string{path}})
+	require.NoError(err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = pool.ServerConfig(&tls.Config{
+		Certificates: []tls.Certificate{serverCA.newLeaf(t)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	srv.StartTLS()
+	defer srv.Close()
+
+	serverRoots := x509.NewCertPool()
+	serverRoots.AddCert(serverCA.cert)
+	newClient := func(ca testCA) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			ServerName:   "localhost",
+			RootCAs:      serverRoots,
+			Certificates: []tls.Certificate{ca.newLeaf(t)},
+		}}}
+	}
+
+	// Before the reload, only the clients signed by the first CA should be trusted.
+	resp, err := newClient(ca1).Get(srv.URL)
+	require.NoError(err)
+	resp.Body.Close()
+	_, err = newClient(ca2).Get(srv.URL)
+	assert.Error(err)
+
+	// After the reload, only the clients signed by the second CA should be trusted.
+	require.NoError(os.WriteFile(path, ca2.pem, 0o600))
+	require.NoError(pool.Reload(context.TODO(), "test"))
+	_, err = newClient(ca1).Get(srv.URL)
+	assert.Error(err)
+	resp, err = newClient(ca2).Get(srv.URL)
+	require.NoError(err)
+	resp.Body.Close()
+}
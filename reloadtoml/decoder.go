@@ -0,0 +1,30 @@
+package reloadtoml
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/slok/reload/reloadconfig"
+)
+
+var _ reloadconfig.Decoder = Decoder
+
+// Decoder is a reloadconfig.Decoder for TOML, the unknown fields are rejected.
+func Decoder(data []byte, v any) error {
+	md, err := toml.Decode(string(data), v)
+	if err != nil {
+		return err
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, k := range undecoded {
+			keys = append(keys, k.String())
+		}
+		return fmt.Errorf("unknown fields: %s", strings.Join(keys, ", "))
+	}
+
+	return nil
+}
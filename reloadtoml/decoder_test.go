@@ -0,0 +1,58 @@
+package reloadtoml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload/reloadtoml"
+)
+
+type testConfig struct {
+	Name    string            `toml:"name"`
+	Workers int               `toml:"workers"`
+	Labels  map[string]string `toml:"labels"`
+}
+
+func TestDecoder(t *testing.T) {
+	tests := map[string]struct {
+		data      string
+		expConfig testConfig
+		expErr    bool
+		expErrMsg string
+	}{
+		"A valid configuration should be decoded on top of the defaults.": {
+			data:      "name = \"a\"\n[labels]\nenv = \"prod\"\n",
+			expConfig: testConfig{Name: "a", Workers: 4, Labels: map[string]string{"env": "prod"}},
+		},
+
+		"Unknown fields should fail.": {
+			data:      "name = \"a\"\nother = 1\n[extra]\nx = 1\n",
+			expErr:    true,
+			expErrMsg: "unknown fields: other, extra, extra.x",
+		},
+
+		"Invalid syntax should fail.": {
+			data:   "name = \n",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			c := testConfig{Workers: 4}
+			err := reloadtoml.Decoder([]byte(test.data), &c)
+
+			if test.expErr {
+				assert.Error(err)
+				if test.expErrMsg != "" {
+					assert.EqualError(err, test.expErrMsg)
+				}
+			} else if assert.NoError(err) {
+				assert.Equal(test.expConfig, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package reloadtoml has the TOML integrations of the reload mechanism.
+package reloadtoml
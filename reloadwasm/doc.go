@@ -0,0 +1,2 @@
+// Package reloadwasm has the WebAssembly (wazero) integrations of the reload mechanism.
+package reloadwasm
@@ -0,0 +1,207 @@
+package reloadwasm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// ModuleReloaderConfig is the configuration of the ModuleReloader.
+type ModuleReloaderConfig struct {
+	// Runtime is the wazero runtime where the modules are compiled and
+	// instantiated, it should have the host modules the WASM module imports.
+	Runtime wazero.Runtime
+	// Load returns the WASM binary, it will be called on the creation and on
+	// every reload. FileLoader or FSLoader can be used.
+	Load func(ctx context.Context) ([]byte, error)
+	// ModuleConfig is the configuration used to instantiate the modules, the
+	// module name is always removed so multiple versions can coexist.
+	// By default `wazero.NewModuleConfig()`.
+	ModuleConfig wazero.ModuleConfig
+	// DrainTimeout is the maximum time the replaced instance waits for the
+	// in-flight invocations before being closed.
+	// By default 30s.
+	DrainTimeout time.Duration
+}
+
+func (c *ModuleReloaderConfig) defaults() error {
+	if c.Runtime == nil {
+		return fmt.Errorf("runtime is required")
+	}
+
+	if c.Load == nil {
+		return fmt.Errorf("load function is required")
+	}
+
+	if c.ModuleConfig == nil {
+		c.ModuleConfig = wazero.NewModuleConfig()
+	}
+	c.ModuleConfig = c.ModuleConfig.WithName("")
+
+	if c.DrainTimeout <= 0 {
+		c.DrainTimeout = 30 * time.Second
+	}
+
+	return nil
+}
+
+// FileLoader returns a WASM binary loader that reads the binary from a file.
+func FileLoader(path string) func(ctx context.Context) ([]byte, error) {
+	return func(_ context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// FSLoader returns a WASM binary loader that reads the binary from the name
+// file of the file system (e.g: an `embed.FS`, or a `fstest.MapFS` on the
+// tests).
+func FSLoader(fsys fs.FS, name string) func(ctx context.Context) ([]byte, error) {
+	return func(_ context.Context) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
+
+type instance struct {
+	compiled wazero.CompiledModule
+	module   api.Module
+	hash     [sha256.Size]byte
+	inFlight sync.WaitGroup
+}
+
+func (i *instance) close(ctx context.Context) {
+	_ = i.module.Close(ctx)
+	_ = i.compiled.Close(ctx)
+}
+
+// ModuleReloader is a reload.Reloader that compiles and instantiates a new
+// version of a WASM module on every reload and swaps the instance used by
+// the host atomically. If the binary didn't change the reload doesn't
+// do anything.
+//
+// The instance must be used with Use, so the replaced instance is closed
+// once the in-flight invocations end (or the drain timeout is reached).
+type ModuleReloader struct {
+	cfg    ModuleReloaderConfig
+	drains sync.WaitGroup
+
+	mu      sync.Mutex
+	current *instance
+}
+
+// NewModuleReloader returns a new ModuleReloader, the module is instantiated
+// on the creation.
+func NewModuleReloader(ctx context.Context, cfg ModuleReloaderConfig) (*ModuleReloader, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	m := &ModuleReloader{cfg: cfg}
+	err = m.Reload(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Use calls f with the current module instance, the instance can't be
+// retained after f returns.
+func (m *ModuleReloader) Use(f func(mod api.Module) error) error {
+	m.mu.Lock()
+	inst := m.current
+	if inst == nil {
+		m.mu.Unlock()
+		return fmt.Errorf("module reloader is closed")
+	}
+	inst.inFlight.Add(1)
+	m.mu.Unlock()
+	defer inst.inFlight.Done()
+
+	return f(inst.module)
+}
+
+// Reload satisfies reload.Reloader interface.
+func (m *ModuleReloader) Reload(ctx context.Context, _ string) error {
+	bin, err := m.cfg.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load WASM module: %w", err)
+	}
+	hash := sha256.Sum256(bin)
+
+	m.mu.Lock()
+	current := m.current
+	m.mu.Unlock()
+	if current != nil && bytes.Equal(current.hash[:], hash[:]) {
+		return nil
+	}
+
+	compiled, err := m.cfg.Runtime.CompileModule(ctx, bin)
+	if err != nil {
+		return fmt.Errorf("could not compile WASM module: %w", err)
+	}
+
+	mod, err := m.cfg.Runtime.InstantiateModule(ctx, compiled, m.cfg.ModuleConfig)
+	if err != nil {
+		_ = compiled.Close(ctx)
+		return fmt.Errorf("could not instantiate WASM module: %w", err)
+	}
+
+	m.mu.Lock()
+	old := m.current
+	m.current = &instance{compiled: compiled, module: mod, hash: hash}
+	m.mu.Unlock()
+
+	if old != nil {
+		m.drain(old)
+	}
+
+	return nil
+}
+
+// Close closes the current instance and waits for the replaced instances to be closed.
+func (m *ModuleReloader) Close() error {
+	m.mu.Lock()
+	current := m.current
+	m.current = nil
+	m.mu.Unlock()
+
+	if current != nil {
+		m.drain(current)
+	}
+	m.drains.Wait()
+
+	return nil
+}
+
+// drain closes the instance once the in-flight invocations end or the
+// drain timeout is reached.
+func (m *ModuleReloader) drain(inst *instance) {
+	m.drains.Add(1)
+	go func() {
+		defer m.drains.Done()
+
+		done := make(chan struct{})
+		go func() {
+			inst.inFlight.Wait()
+			close(done)
+		}()
+
+		t := time.NewTimer(m.cfg.DrainTimeout)
+		defer t.Stop()
+		select {
+		case <-done:
+		case <-t.C:
+		}
+
+		inst.close(context.Background())
+	}()
+}
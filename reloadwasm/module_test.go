@@ -0,0 +1,158 @@
+package reloadwasm_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/slok/reload/reloadwasm"
+)
+
+// versionModule returns a WASM module that exports a `version` function
+// returning the version (0-63).
+func versionModule(version byte) []byte {
+	return []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // Magic and version.
+		0x01, 0x05, 0x01, 0x60, 0x00, 0x01, 0x7f, // Type section: func() i32.
+		0x03, 0x02, 0x01, 0x00, // Function section.
+		0x07, 0x0b, 0x01, 0x07, 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x00, 0x00, // Export section.
+		0x0a, 0x06, 0x01, 0x04, 0x00, 0x41, version, 0x0b, // Code section: i32.const version.
+	}
+}
+
+func callVersion(t *testing.T, m *reloadwasm.ModuleReloader) uint64 {
+	var got uint64
+	err := m.Use(func(mod api.Module) error {
+		res, err := mod.ExportedFunction("version").Call(context.TODO())
+		if err != nil {
+			return err
+		}
+		got = res[0]
+		return nil
+	})
+	require.NoError(t, err)
+
+	return got
+}
+
+func TestModuleReloader(t *testing.T) {
+	tests := map[string]struct {
+		binaries   [][]byte
+		expVersion uint64
+		expErr     bool
+	}{
+		"The initial module should be used.": {
+			binaries:   [][]byte{versionModule(1)},
+			expVersion: 1,
+		},
+
+		"The reloaded module should be used after a reload.": {
+			binaries:   [][]byte{versionModule(1), versionModule(2)},
+			expVersion: 2,
+		},
+
+		"The same module should be reloaded.": {
+			binaries:   [][]byte{versionModule(1), versionModule(1)},
+			expVersion: 1,
+		},
+
+		"An invalid module should keep the previous module.": {
+			binaries:   [][]byte{versionModule(1), []byte("invalid")},
+			expVersion: 1,
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			ctx := context.Background()
+			rt := wazero.NewRuntime(ctx)
+			defer rt.Close(ctx)
+			binaries := test.binaries
+			m, err := reloadwasm.NewModuleReloader(ctx, reloadwasm.ModuleReloaderConfig{
+				Runtime: rt,
+				Load: func(ctx context.Context) ([]byte, error) {
+					if len(binaries) == 0 {
+						return nil, fmt.Errorf("no more binaries")
+					}
+					b := binaries[0]
+					binaries = binaries[1:]
+					return b, nil
+				},
+			})
+			require.NoError(err)
+			defer m.Close()
+
+			// Execute.
+			var reloadErr error
+			for range test.binaries[1:] {
+				reloadErr = m.Reload(ctx, "test")
+			}
+
+			// Check.
+			if test.expErr {
+				assert.Error(reloadErr)
+			} else {
+				assert.NoError(reloadErr)
+			}
+			assert.Equal(test.expVersion, callVersion(t, m))
+		})
+	}
+}
+
+func TestModuleReloaderDrain(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	ctx := context.Background()
+	rt := wazero.NewRuntime(ctx)
+	defer rt.Close(ctx)
+	version := byte(1)
+	m, err := reloadwasm.NewModuleReloader(ctx, reloadwasm.ModuleReloaderConfig{
+		Runtime: rt,
+		Load:    func(ctx context.Context) ([]byte, error) { return versionModule(version), nil },
+	})
+	require.NoError(err)
+
+	// Start an invocation that is in-flight during the reload.
+	inUse := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var inFlightErr error
+	go func() {
+		defer wg.Done()
+		inFlightErr = m.Use(func(mod api.Module) error {
+			close(inUse)
+			<-release
+			_, err := mod.ExportedFunction("version").Call(ctx)
+			return err
+		})
+	}()
+	<-inUse
+
+	// Execute.
+	version = 2
+	require.NoError(m.Reload(ctx, "test"))
+	assert.Equal(uint64(2), callVersion(t, m))
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// Check.
+	assert.NoError(inFlightErr)
+	require.NoError(m.Close())
+	assert.Error(m.Use(func(mod api.Module) error { return nil }))
+}
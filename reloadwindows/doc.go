@@ -0,0 +1,9 @@
+// Package reloadwindows has notifiers to trigger reloads on Windows services.
+//
+// Windows doesn't have the SIGHUP signal, instead, the services receive the
+// `SERVICE_CONTROL_PARAMCHANGE` control (e.g: `sc.exe control {service} paramchange`)
+// to reload their configuration. This package converts these controls and
+// optionally named events into reload triggers.
+//
+// The notifiers are only available on Windows.
+package reloadwindows
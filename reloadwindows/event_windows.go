@@ -0,0 +1,102 @@
+//go:build windows
+
+package reloadwindows
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// EventNotifierConfig is the configuration of the EventNotifier.
+type EventNotifierConfig struct {
+	// Name is the name of the Windows event object (e.g: `Global\\myapp-reload`).
+	Name string
+	// TriggerID is the ID used on the triggers.
+	// By default `event`.
+	TriggerID string
+	// PollInterval is the maximum time waiting for the event before checking
+	// if the context has been cancelled.
+	// By default 250ms.
+	PollInterval time.Duration
+}
+
+func (c *EventNotifierConfig) defaults() error {
+	if c.Name == "" {
+		return fmt.Errorf("event name is required")
+	}
+
+	if c.TriggerID == "" {
+		c.TriggerID = "event"
+	}
+
+	if c.PollInterval <= 0 {
+		c.PollInterval = 250 * time.Millisecond
+	}
+
+	return nil
+}
+
+// EventNotifier is a reload.Notifier that triggers a reload every time a
+// named Windows event object is signaled (e.g: using `SetEvent` from
+// another process).
+type EventNotifier struct {
+	cfg    EventNotifierConfig
+	handle windows.Handle
+}
+
+// NewEventNotifier returns a new EventNotifier, it will create the named
+// event (or open it if already exists).
+func NewEventNotifier(cfg EventNotifierConfig) (*EventNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	name, err := windows.UTF16PtrFromString(cfg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event name: %w", err)
+	}
+
+	// Auto reset event, so each signal is a single trigger.
+	h, err := windows.CreateEvent(nil, 0, 0, name)
+	if err != nil {
+		return nil, fmt.Errorf("could not create %q event: %w", cfg.Name, err)
+	}
+
+	return &EventNotifier{
+		cfg:    cfg,
+		handle: h,
+	}, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+func (e *EventNotifier) Notify(ctx context.Context) (string, error) {
+	waitMS := uint32(e.cfg.PollInterval.Milliseconds())
+	for {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		ev, err := windows.WaitForSingleObject(e.handle, waitMS)
+		if err != nil {
+			return "", fmt.Errorf("could not wait for %q event: %w", e.cfg.Name, err)
+		}
+
+		switch ev {
+		case windows.WAIT_OBJECT_0:
+			return e.cfg.TriggerID, nil
+		case uint32(windows.WAIT_TIMEOUT):
+			continue
+		default:
+			return "", fmt.Errorf("unexpected wait result on %q event: %d", e.cfg.Name, ev)
+		}
+	}
+}
+
+// Close closes the event handle.
+func (e *EventNotifier) Close() error {
+	return windows.CloseHandle(e.handle)
+}
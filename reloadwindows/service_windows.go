@@ -0,0 +1,131 @@
+//go:build windows
+
+package reloadwindows
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// ServiceNotifierConfig is the configuration of the ServiceNotifier.
+type ServiceNotifierConfig struct {
+	// TriggerID is the ID used on the triggers.
+	// By default `paramchange`.
+	TriggerID string
+}
+
+func (c *ServiceNotifierConfig) defaults() error {
+	if c.TriggerID == "" {
+		c.TriggerID = "paramchange"
+	}
+
+	return nil
+}
+
+// ServiceNotifier is a reload.Notifier that triggers a reload every time the
+// Windows service receives a `SERVICE_CONTROL_PARAMCHANGE` control.
+//
+// The service handler needs to be wrapped with the notifier Handler so it
+// can intercept the controls.
+type ServiceNotifier struct {
+	cfg ServiceNotifierConfig
+	c   chan struct{}
+}
+
+// NewServiceNotifier returns a new ServiceNotifier.
+func NewServiceNotifier(cfg ServiceNotifierConfig) (*ServiceNotifier, error) {
+	_ = cfg.defaults()
+
+	return &ServiceNotifier{
+		cfg: cfg,
+		c:   make(chan struct{}, 1),
+	}, nil
+}
+
+// Notify satisfies reload.Notifier interface.
+//
+// Param change controls received while a reload is pending are collapsed
+// into a single trigger.
+func (s *ServiceNotifier) Notify(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-s.c:
+		return s.cfg.TriggerID, nil
+	}
+}
+
+// Handler wraps a service handler, the returned handler will accept the
+// param change controls and convert them into reload triggers, the rest of
+// the controls will be forwarded to the wrapped handler.
+func (s *ServiceNotifier) Handler(h svc.Handler) svc.Handler {
+	return serviceHandler{notifier: s, handler: h}
+}
+
+func (s *ServiceNotifier) trigger() {
+	select {
+	case s.c <- struct{}{}:
+	default: // Already a trigger pending.
+	}
+}
+
+type serviceHandler struct {
+	notifier *ServiceNotifier
+	handler  svc.Handler
+}
+
+func (s serviceHandler) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	handlerR := make(chan svc.ChangeRequest)
+	handlerChanges := make(chan svc.Status)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	// Forward the status changes of the handler accepting always the param change controls.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case st := <-handlerChanges:
+				if st.State == svc.Running {
+					st.Accepts |= svc.AcceptParamChange
+				}
+				changes <- st
+			}
+		}
+	}()
+
+	// Intercept the param change controls and forward the rest to the handler.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case c := <-r:
+				if c.Cmd == svc.ParamChange {
+					s.notifier.trigger()
+					changes <- c.CurrentStatus
+					continue
+				}
+
+				select {
+				case <-stop:
+					return
+				case handlerR <- c:
+				}
+			}
+		}
+	}()
+
+	ssec, errno := s.handler.Execute(args, handlerR, handlerChanges)
+	close(stop)
+	wg.Wait()
+
+	return ssec, errno
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package reloadwindows_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows/svc"
+
+	"github.com/slok/reload/reloadwindows"
+)
+
+type testHandler struct {
+	received chan svc.ChangeRequest
+}
+
+func (t testHandler) Execute(args []string, r <-chan svc.ChangeRequest, s chan<- svc.Status) (bool, uint32) {
+	s <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop}
+	for c := range r {
+		t.received <- c
+		if c.Cmd == svc.Stop {
+			return false, 0
+		}
+	}
+	return false, 0
+}
+
+func TestServiceNotifier(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	n, err := reloadwindows.NewServiceNotifier(reloadwindows.ServiceNotifierConfig{})
+	require.NoError(err)
+	received := make(chan svc.ChangeRequest, 10)
+	h := n.Handler(testHandler{received: received})
+
+	r := make(chan svc.ChangeRequest)
+	changes := make(chan svc.Status, 10)
+	finished := make(chan struct{})
+	go func() {
+		_, _ = h.Execute(nil, r, changes)
+		close(finished)
+	}()
+
+	// The running status should accept param changes.
+	st := <-changes
+	assert.Equal(svc.Running, st.State)
+	assert.Equal(svc.AcceptStop|svc.AcceptParamChange, st.Accepts)
+
+	// Param change should trigger and not reach the handler.
+	r <- svc.ChangeRequest{Cmd: svc.ParamChange, CurrentStatus: st}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	id, err := n.Notify(ctx)
+	require.NoError(err)
+	assert.Equal("paramchange", id)
+	assert.Equal(st, <-changes)
+
+	// Other controls should reach the handler.
+	r <- svc.ChangeRequest{Cmd: svc.Stop}
+	assert.Equal(svc.Stop, (<-received).Cmd)
+	<-finished
+	assert.Len(received, 0)
+}
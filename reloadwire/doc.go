@@ -0,0 +1,2 @@
+// Package reloadwire has the google/wire integrations of the reload mechanism.
+package reloadwire
@@ -0,0 +1,12 @@
+module github.com/slok/reload/reloadwire
+
+go 1.23
+
+require (
+	github.com/google/wire v0.6.0
+	github.com/slok/reload v0.0.0
+)
+
+require golang.org/x/sync v0.8.0 // indirect
+
+replace github.com/slok/reload => ../
@@ -0,0 +1,20 @@
+package reloadwire
+
+import (
+	"github.com/google/wire"
+
+	"github.com/slok/reload"
+)
+
+// ProviderSet provides the *reload.Manager with the `[]reload.Registrations`
+// and `[]reload.ManagerOption` provided by the application, e.g:
+//
+//	func provideRegistrations(cfg *config.Component, srv *server.Component) []reload.Registrations {
+//		return []reload.Registrations{cfg.Registrations(), srv.Registrations()}
+//	}
+//
+//	func initManager() *reload.Manager {
+//		wire.Build(reloadwire.ProviderSet, provideRegistrations, provideManagerOptions, ...)
+//		return nil
+//	}
+var ProviderSet = wire.NewSet(reload.NewManagerWithRegistrations)
@@ -0,0 +1,26 @@
+package reloadyaml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/slok/reload/reloadconfig"
+)
+
+var _ reloadconfig.Decoder = Decoder
+
+// Decoder is a reloadconfig.Decoder for YAML, the unknown fields are rejected.
+func Decoder(data []byte, v any) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err := dec.Decode(v)
+	// Empty documents keep the defaults.
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,58 @@
+package reloadyaml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload/reloadyaml"
+)
+
+type testConfig struct {
+	Name    string            `yaml:"name"`
+	Workers int               `yaml:"workers"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+func TestDecoder(t *testing.T) {
+	tests := map[string]struct {
+		data      string
+		expConfig testConfig
+		expErr    bool
+	}{
+		"A valid configuration should be decoded on top of the defaults.": {
+			data:      "name: a\nlabels:\n  env: prod\n",
+			expConfig: testConfig{Name: "a", Workers: 4, Labels: map[string]string{"env": "prod"}},
+		},
+
+		"An empty configuration should keep the defaults.": {
+			data:      "",
+			expConfig: testConfig{Workers: 4},
+		},
+
+		"Unknown fields should fail.": {
+			data:   "name: a\nother: 1\n",
+			expErr: true,
+		},
+
+		"Invalid syntax should fail.": {
+			data:   "name: [a\n",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			c := testConfig{Workers: 4}
+			err := reloadyaml.Decoder([]byte(test.data), &c)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expConfig, c)
+			}
+		})
+	}
+}
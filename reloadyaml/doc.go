@@ -0,0 +1,2 @@
+// Package reloadyaml has the YAML integrations of the reload mechanism.
+package reloadyaml
@@ -0,0 +1,212 @@
+package reload
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ReloaderStatus is the status of a reloader on a reload process.
+type ReloaderStatus string
+
+const (
+	// ReloaderSucceeded is used when the reloader reload succeeded.
+	ReloaderSucceeded ReloaderStatus = "succeeded"
+	// ReloaderFailed is used when the reloader reload failed.
+	ReloaderFailed ReloaderStatus = "failed"
+	// ReloaderTimedOut is used when the reloader exceeded its timeout (see
+	// WithReloaderTimeout).
+	ReloaderTimedOut ReloaderStatus = "timed_out"
+	// ReloaderCancelled is used when the reloader was cancelled because
+	// another reloader of its group failed (it returned `context.Canceled`).
+	ReloaderCancelled ReloaderStatus = "cancelled"
+	// ReloaderSkipped is used when the reloader was not reloaded, because its
+	// circuit is open (see WithCircuitBreaker) or the reload failed before
+	// reaching it.
+	ReloaderSkipped ReloaderStatus = "skipped"
+	// ReloaderDisabled is used when the reloader was not reloaded because it's
+	// disabled (see Manager.DisableReloader).
+	ReloaderDisabled ReloaderStatus = "disabled"
+)
+
+// ReloaderReport is the result of a reloader on a reload process.
+type ReloaderReport struct {
+	// Name is the reloader name.
+	Name string
+	// Priority is the priority group of the reloader.
+	Priority int
+	// Status is the reloader status.
+	Status ReloaderStatus
+	// Duration is the reloader reload duration, zero if skipped.
+	Duration time.Duration
+	// Err is the reloader error, if failed, timed out or cancelled.
+	Err error
+}
+
+// GroupStatus is the status of a reloader priority group on a reload process.
+type GroupStatus string
+
+const (
+	// GroupCompleted is used when all the group reloaders succeeded.
+	GroupCompleted GroupStatus = "completed"
+	// GroupFailed is used when the group failed.
+	GroupFailed GroupStatus = "failed"
+	// GroupInterrupted is used when the group was cut short by a deadline or a
+	// cancellation (e.g: the reload budget, the caller context or the manager
+	// stop).
+	GroupInterrupted GroupStatus = "interrupted"
+	// GroupSkipped is used when the group was not started because the reload
+	// failed or was interrupted before reaching it.
+	GroupSkipped GroupStatus = "skipped"
+)
+
+// GroupReport is the result of a reloader priority group on a reload process.
+type GroupReport struct {
+	// Priority is the group priority.
+	Priority int
+	// Name is the group name, if any (see WithGroupName).
+	Name string
+	// Status is the group status.
+	Status GroupStatus
+	// Duration is the group reload duration, zero if skipped.
+	Duration time.Duration
+}
+
+// ReloadError is the error of a failed reload process, with the status of
+// every group and reloader of the reload so the callers can render actionable
+// failure summaries and know how much of the process was reloaded. It's
+// returned by Manager.TriggerReload and wrapped by Run, use errors.As to get
+// it.
+type ReloadError struct {
+	// TriggerID is the ID of the trigger of the failed reload.
+	TriggerID string
+	// Groups are the groups of the reload in execution order.
+	Groups []GroupReport
+	// Reloaders are the reloaders of the reload in execution order.
+	Reloaders []ReloaderReport
+
+	err error
+}
+
+func (e *ReloadError) Error() string { return e.err.Error() }
+
+// Unwrap returns the reload error.
+func (e *ReloadError) Unwrap() error { return e.err }
+
+// Failed returns the reloaders that failed or timed out.
+func (e *ReloadError) Failed() []ReloaderReport {
+	var failed []ReloaderReport
+	for _, r := range e.Reloaders {
+		if r.Status == ReloaderFailed || r.Status == ReloaderTimedOut {
+			failed = append(failed, r)
+		}
+	}
+
+	return failed
+}
+
+// Interrupted returns true if the reload was cut short by a deadline or a
+// cancellation, the groups before the interrupted one were completed and the
+// ones after it were skipped.
+func (e *ReloadError) Interrupted() bool {
+	for _, g := range e.Groups {
+		if g.Status == GroupInterrupted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GroupsWithStatus returns the groups with the status.
+func (e *ReloadError) GroupsWithStatus(status GroupStatus) []GroupReport {
+	var groups []GroupReport
+	for _, g := range e.Groups {
+		if g.Status == status {
+			groups = append(groups, g)
+		}
+	}
+
+	return groups
+}
+
+// reloadReport tracks the groups and reloaders results of a reload process,
+// they start as skipped until they are reloaded.
+type reloadReport struct {
+	mu        sync.Mutex
+	groups    []GroupReport
+	reloaders []ReloaderReport
+	// index is the position of the reloaders by group priority and group
+	// position.
+	index map[int]int
+}
+
+func newReloadReport(plan []reloaderGroup, groupNames map[int]string) *reloadReport {
+	r := &reloadReport{index: map[int]int{}}
+	for _, rg := range plan {
+		r.groups = append(r.groups, GroupReport{Priority: rg.priority, Name: groupNames[rg.priority], Status: GroupSkipped})
+		r.index[rg.priority] = len(r.reloaders)
+		for _, rr := range rg.reloaders {
+			r.reloaders = append(r.reloaders, ReloaderReport{Name: rr.name, Priority: rg.priority, Status: ReloaderSkipped})
+		}
+	}
+
+	return r
+}
+
+// record records the result of the reloader at the group position i, cancelled
+// if it was cancelled because another reloader of the group failed.
+func (r *reloadReport) record(priority, i int, duration time.Duration, err error, cancelled bool) {
+	status := ReloaderSucceeded
+	switch {
+	case err != nil && cancelled:
+		status = ReloaderCancelled
+	case errors.Is(err, ErrReloaderTimeout):
+		status = ReloaderTimedOut
+	case err != nil:
+		status = ReloaderFailed
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rr := &r.reloaders[r.index[priority]+i]
+	rr.Status = status
+	rr.Duration = duration
+	rr.Err = err
+}
+
+// disable records the reloader at the group position i as disabled.
+func (r *reloadReport) disable(priority, i int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reloaders[r.index[priority]+i].Status = ReloaderDisabled
+}
+
+// recordGroup records the result of the group at the plan position i.
+func (r *reloadReport) recordGroup(i int, duration time.Duration, interrupted bool, err error) {
+	status := GroupCompleted
+	switch {
+	case interrupted:
+		status = GroupInterrupted
+	case err != nil:
+		status = GroupFailed
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.groups[i].Status = status
+	r.groups[i].Duration = duration
+}
+
+// error returns the reload error with the report.
+func (r *reloadReport) error(t TriggerEvent, err error) *ReloadError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &ReloadError{
+		TriggerID: t.ID,
+		Groups:    append([]GroupReport(nil), r.groups...),
+		Reloaders: append([]ReloaderReport(nil), r.reloaders...),
+		err:       err,
+	}
+}
@@ -0,0 +1,193 @@
+package reload_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerReloadErrorReport(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Now())
+	m := reload.NewManager(reload.WithClock(clock), reload.WithOrderedReloaders())
+	errTest := fmt.Errorf("something")
+	ok := reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil })
+	m.Add(0, ok, reload.WithReloaderName("config"))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		clock.Advance(time.Second)
+		return errTest
+	}), reload.WithReloaderName("tls"))
+	m.Add(0, ok, reload.WithReloaderName("cache"))
+	m.Add(10, ok, reload.WithReloaderName("server"))
+
+	// Execute.
+	err := m.TriggerReload(context.Background(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	var reloadErr *reload.ReloadError
+	require.True(errors.As(err, &reloadErr))
+	assert.ErrorIs(err, errTest)
+	assert.Equal("t1", reloadErr.TriggerID)
+	exp := []reload.ReloaderReport{
+		{Name: "config", Priority: 0, Status: reload.ReloaderSucceeded},
+		{Name: "tls", Priority: 0, Status: reload.ReloaderFailed, Duration: time.Second, Err: errTest},
+		{Name: "cache", Priority: 0, Status: reload.ReloaderSkipped},
+		{Name: "server", Priority: 10, Status: reload.ReloaderSkipped},
+	}
+	assert.Equal(exp, reloadErr.Reloaders)
+	assert.Equal(exp[1:2], reloadErr.Failed())
+	assert.Equal([]reload.GroupReport{
+		{Priority: 0, Status: reload.GroupFailed, Duration: time.Second},
+		{Priority: 10, Status: reload.GroupSkipped},
+	}, reloadErr.Groups)
+	assert.False(reloadErr.Interrupted())
+}
+
+func TestManagerReloadErrorReportInterrupted(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Now())
+	m := reload.NewManager(
+		reload.WithClock(clock),
+		reload.WithReloadBudget(3*time.Second),
+		reload.WithGroupName(10, "servers"),
+	)
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		clock.Advance(time.Second)
+		return nil
+	}), reload.WithReloaderName("config"))
+	serverStarted := make(chan struct{})
+	m.Add(10, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		close(serverStarted)
+		<-ctx.Done()
+		return ctx.Err()
+	}), reload.WithReloaderName("server"))
+	m.Add(20, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("cache"))
+
+	// Execute.
+	errC := make(chan error)
+	go func() { errC <- m.TriggerReload(context.Background(), reload.TriggerEvent{ID: "t1"}) }()
+	<-serverStarted
+	clock.Advance(2 * time.Second)
+
+	// Check.
+	err := <-errC
+	var reloadErr *reload.ReloadError
+	require.True(errors.As(err, &reloadErr))
+	assert.ErrorIs(err, reload.ErrReloadBudgetExceeded)
+	assert.True(reloadErr.Interrupted())
+	assert.Equal([]reload.GroupReport{
+		{Priority: 0, Status: reload.GroupCompleted, Duration: time.Second},
+		{Priority: 10, Name: "servers", Status: reload.GroupInterrupted, Duration: 2 * time.Second},
+		{Priority: 20, Status: reload.GroupSkipped},
+	}, reloadErr.Groups)
+	assert.Equal([]reload.GroupReport{{Priority: 0, Status: reload.GroupCompleted, Duration: time.Second}}, reloadErr.GroupsWithStatus(reload.GroupCompleted))
+}
+
+func TestManagerReloadErrorReportTimeout(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Now())
+	m := reload.NewManager(reload.WithClock(clock))
+	release := make(chan struct{})
+	defer close(release)
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		<-release
+		return nil
+	}), reload.WithReloaderName("stuck"), reload.WithReloaderTimeout(time.Second))
+	m.On(reload.NotifierFunc(func(ctx context.Context) (string, error) { return "t1", nil }))
+
+	// Execute.
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(context.Background()) }()
+	require.True(clock.WaitWaiters(1, time.Second))
+	clock.Advance(time.Second)
+
+	// Check.
+	err := <-runErr
+	var reloadErr *reload.ReloadError
+	require.True(errors.As(err, &reloadErr))
+	require.Len(reloadErr.Reloaders, 1)
+	assert.Equal(reload.ReloaderTimedOut, reloadErr.Reloaders[0].Status)
+	assert.ErrorIs(reloadErr.Reloaders[0].Err, reload.ErrReloaderTimeout)
+}
+
+func TestManagerReloadErrorReportCancelled(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	m := reload.NewManager()
+	errTest := fmt.Errorf("something")
+	started := make(chan struct{})
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		<-started
+		return errTest
+	}), reload.WithReloaderName("config"))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}), reload.WithReloaderName("server"))
+
+	// Execute.
+	err := m.TriggerReload(context.Background(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	var reloadErr *reload.ReloadError
+	require.True(errors.As(err, &reloadErr))
+	var got []reload.ReloaderStatus
+	for _, r := range reloadErr.Reloaders {
+		got = append(got, r.Status)
+	}
+	assert.Equal([]reload.ReloaderStatus{reload.ReloaderFailed, reload.ReloaderCancelled}, got)
+	if assert.Len(reloadErr.Failed(), 1) {
+		assert.Equal("config", reloadErr.Failed()[0].Name)
+	}
+}
+
+func TestManagerReloadErrorReportFailedAfterCancel(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	m := reload.NewManager()
+	started := make(chan struct{})
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		<-started
+		return fmt.Errorf("something")
+	}), reload.WithReloaderName("config"))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		close(started)
+		<-ctx.Done()
+		return fmt.Errorf("other thing")
+	}), reload.WithReloaderName("server"))
+
+	// Execute.
+	err := m.TriggerReload(context.Background(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	var reloadErr *reload.ReloadError
+	require.True(errors.As(err, &reloadErr))
+	var got []reload.ReloaderStatus
+	for _, r := range reloadErr.Reloaders {
+		got = append(got, r.Status)
+	}
+	assert.Equal([]reload.ReloaderStatus{reload.ReloaderFailed, reload.ReloaderFailed}, got)
+	assert.Len(reloadErr.Failed(), 2)
+}
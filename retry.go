@@ -0,0 +1,88 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ReloadRetry is the retry policy of the failed reloads (see WithReloadRetry).
+type ReloadRetry struct {
+	// MaxAttempts is the maximum number of attempts of a reload, including the
+	// first one.
+	// By default 3.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled on every retry.
+	// By default 1s.
+	Backoff time.Duration
+	// MaxBackoff is the maximum wait between retries.
+	// By default 1m.
+	MaxBackoff time.Duration
+	// IsTransient returns true if the reload error is transient and the
+	// reload should be retried, the permanent errors fail on the first
+	// attempt.
+	// By default all the errors are transient except ErrReloadBudgetExceeded.
+	IsTransient func(err error) bool
+}
+
+func (r *ReloadRetry) defaults() {
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = 3
+	}
+
+	if r.Backoff <= 0 {
+		r.Backoff = time.Second
+	}
+
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = time.Minute
+	}
+
+	if r.IsTransient == nil {
+		r.IsTransient = isTransientReloadError
+	}
+}
+
+func isTransientReloadError(err error) bool {
+	return !errors.Is(err, ErrReloadBudgetExceeded)
+}
+
+// retryable returns if a reload attempt that failed with the error should be
+// retried, the reloads that didn't run because other is in progress and the
+// ones interrupted by the manager stop are not retried.
+func (r *ReloadRetry) retryable(ctx context.Context, err error) bool {
+	return err != nil && !errors.Is(err, ErrReloadInProgress) && ctx.Err() == nil && r.IsTransient(err)
+}
+
+// retryReload runs the reload process of the trigger, retrying it with
+// exponential backoff while it fails with a transient error, until the retry
+// attempts are exhausted.
+func (m *Manager) retryReload(ctx context.Context, t TriggerEvent) error {
+	r := m.cfg.reloadRetry
+	if r == nil {
+		return m.reloadGroups(ctx, t, false)
+	}
+
+	err := m.reloadGroups(ctx, t, r.MaxAttempts > 1)
+
+	backoff := r.Backoff
+	for attempt := 2; attempt <= r.MaxAttempts; attempt++ {
+		if !r.retryable(ctx, err) {
+			return err
+		}
+
+		m.emit(ctx, Event{Type: EventReloadRetrying, Trigger: t, Attempt: attempt, Duration: backoff, Err: err})
+		timer := m.cfg.clock.NewTimer(backoff)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+
+		err = m.reloadGroups(ctx, t, attempt < r.MaxAttempts)
+		backoff = min(2*backoff, r.MaxBackoff)
+	}
+
+	return err
+}
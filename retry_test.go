@@ -0,0 +1,147 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerReloadRetry(t *testing.T) {
+	tests := map[string]struct {
+		retry       reload.ReloadRetry
+		failures    int
+		expBackoffs []time.Duration
+		expErr      bool
+		expTrail    []string
+		expRetrying []bool
+	}{
+		"A reload that succeeds on a retry should not fail.": {
+			retry:       reload.ReloadRetry{MaxAttempts: 3, Backoff: time.Second},
+			failures:    2,
+			expBackoffs: []time.Duration{time.Second, 2 * time.Second},
+			expTrail: []string{
+				"reload_started id=t1",
+				"reload_finished id=t1 err=reload \"t1\" failed: group (priority 0): reloader \"reloader-0\" (1/1): something",
+				"reload_retrying id=t1 attempt=2 err=reload \"t1\" failed: group (priority 0): reloader \"reloader-0\" (1/1): something",
+				"reload_started id=t1",
+				"reload_finished id=t1 err=reload \"t1\" failed: group (priority 0): reloader \"reloader-0\" (1/1): something",
+				"reload_retrying id=t1 attempt=3 err=reload \"t1\" failed: group (priority 0): reloader \"reloader-0\" (1/1): something",
+				"reload_started id=t1",
+				"reload_finished id=t1",
+			},
+			expRetrying: []bool{true, true, false},
+		},
+
+		"A reload that fails on every attempt should fail.": {
+			retry:       reload.ReloadRetry{MaxAttempts: 2, Backoff: time.Second},
+			failures:    5,
+			expBackoffs: []time.Duration{time.Second},
+			expErr:      true,
+			expTrail: []string{
+				"reload_started id=t1",
+				"reload_finished id=t1 err=reload \"t1\" failed: group (priority 0): reloader \"reloader-0\" (1/1): something",
+				"reload_retrying id=t1 attempt=2 err=reload \"t1\" failed: group (priority 0): reloader \"reloader-0\" (1/1): something",
+				"reload_started id=t1",
+				"reload_finished id=t1 err=reload \"t1\" failed: group (priority 0): reloader \"reloader-0\" (1/1): something",
+			},
+			expRetrying: []bool{true, false},
+		},
+
+		"A reload that fails with a permanent error should not be retried.": {
+			retry: reload.ReloadRetry{
+				MaxAttempts: 3,
+				Backoff:     time.Second,
+				IsTransient: func(err error) bool { return false },
+			},
+			failures: 5,
+			expErr:   true,
+			expTrail: []string{
+				"reload_started id=t1",
+				"reload_finished id=t1 err=reload \"t1\" failed: group (priority 0): reloader \"reloader-0\" (1/1): something",
+			},
+			expRetrying: []bool{false},
+		},
+
+		"The backoff should be limited by the max backoff.": {
+			retry:       reload.ReloadRetry{MaxAttempts: 4, Backoff: 2 * time.Second, MaxBackoff: 3 * time.Second},
+			failures:    3,
+			expBackoffs: []time.Duration{2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+			rec := reloadtest.NewRecorder()
+			retrying := make(chan time.Duration, 10)
+			var gotRetrying []bool
+			m := reload.NewManager(
+				reload.WithClock(clock),
+				reload.WithReloadRetry(test.retry),
+				reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+					switch e.Type {
+					case reload.EventReloadStarted, reload.EventReloadFinished, reload.EventReloadRetrying:
+						rec.HandleEvent(ctx, e)
+					}
+					if e.Type == reload.EventReloadRetrying {
+						retrying <- e.Duration
+					}
+					if e.Type == reload.EventReloadFinished {
+						gotRetrying = append(gotRetrying, e.Retrying)
+					}
+				})),
+			)
+			calls := 0
+			reloaded := make(chan struct{})
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				calls++
+				if calls <= test.failures {
+					return fmt.Errorf("something")
+				}
+				close(reloaded)
+				return nil
+			}))
+			notifierC := make(chan string, 1)
+			m.On(reload.NotifierChan(notifierC))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			runErr := make(chan error)
+			go func() { runErr <- m.Run(ctx) }()
+			notifierC <- "t1"
+			var gotBackoffs []time.Duration
+			for range test.expBackoffs {
+				backoff := <-retrying
+				gotBackoffs = append(gotBackoffs, backoff)
+				require.True(clock.WaitWaiters(1, time.Second))
+				clock.Advance(backoff)
+			}
+
+			// Check.
+			assert.Equal(test.expBackoffs, gotBackoffs)
+			if test.expErr {
+				assert.Error(<-runErr)
+			} else {
+				<-reloaded
+				cancel()
+				assert.NoError(<-runErr)
+			}
+			if test.expTrail != nil {
+				rec.AssertTrail(t, test.expTrail...)
+				assert.Equal(test.expRetrying, gotRetrying)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// RollbackGenerationMetadataKey is the trigger metadata key where the
+// generation to roll back to is set on the rollback triggers, it's only
+// informative (e.g: for the events), the reloaders get the generation with
+// RollbackGenerationFromContext.
+const RollbackGenerationMetadataKey = "reload.rollback.generation"
+
+// ErrUnknownGeneration is returned when rolling back to a generation that has
+// not been applied yet.
+var ErrUnknownGeneration = errors.New("unknown generation")
+
+// RollbackTo triggers a reload process that reverts the configuration to the
+// one applied on a previous generation (see Status) and waits until it ends,
+// the same as TriggerReload. The generation 0 is the configuration before any
+// reload.
+//
+// The manager doesn't store the configurations, the reloaders that support
+// rollbacks (e.g: `reloadconfig` loaders with a snapshot store) get the
+// generation with RollbackGenerationFromContext and apply its stored
+// configuration instead of loading a new one. The rollback is a regular
+// reload, so if it succeeds it creates a new generation.
+func (m *Manager) RollbackTo(ctx context.Context, generation uint64) error {
+	current := atomic.LoadUint64(&m.generation)
+	if generation > current {
+		return fmt.Errorf("%w %d, current generation is %d", ErrUnknownGeneration, generation, current)
+	}
+
+	g := strconv.FormatUint(generation, 10)
+	ctx = context.WithValue(ctx, rollbackGenerationContextKey, generation)
+	return m.TriggerReload(ctx, TriggerEvent{
+		ID:       "rollback-" + g,
+		Source:   "rollback",
+		Metadata: map[string]string{RollbackGenerationMetadataKey: g},
+	})
+}
+
+// RollbackGenerationFromContext returns the generation to roll back to when
+// the reload process has been triggered by RollbackTo. The triggers with the
+// RollbackGenerationMetadataKey metadata are not rollbacks, so the notifiers
+// can't roll back the configuration.
+func RollbackGenerationFromContext(ctx context.Context) (uint64, bool) {
+	g, ok := ctx.Value(rollbackGenerationContextKey).(uint64)
+	return g, ok
+}
+
+// GenerationFromContext returns the generation that the reload process will
+// create if it succeeds, the manager sets this on the context received by the
+// reloaders so they can version what they apply (e.g: to roll back).
+//
+// Every reload process reserves a unique generation, greater than the previous
+// ones, when it starts, so the concurrent reloads of different pipelines don't
+// share it and the failed reloads leave gaps between the generations.
+func GenerationFromContext(ctx context.Context) (uint64, bool) {
+	g, ok := ctx.Value(generationContextKey).(uint64)
+	return g, ok
+}
+
+func contextWithGeneration(ctx context.Context, g uint64) context.Context {
+	return context.WithValue(ctx, generationContextKey, g)
+}
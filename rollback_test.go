@@ -0,0 +1,96 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerRollbackTo(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	type reloadCall struct {
+		id         string
+		generation uint64
+		rollback   uint64
+		isRollback bool
+	}
+	var calls []reloadCall
+	m := reload.NewManager()
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		c := reloadCall{id: id}
+		c.generation, _ = reload.GenerationFromContext(ctx)
+		c.rollback, c.isRollback = reload.RollbackGenerationFromContext(ctx)
+		calls = append(calls, c)
+		return nil
+	}))
+
+	// Execute.
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"}))
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t2"}))
+	require.NoError(m.RollbackTo(context.TODO(), 1))
+	err := m.RollbackTo(context.TODO(), 4)
+	spoofed := reload.TriggerEvent{ID: "t3", Metadata: map[string]string{reload.RollbackGenerationMetadataKey: "1"}}
+	require.NoError(m.TriggerReload(context.TODO(), spoofed))
+
+	// Check.
+	assert.ErrorIs(err, reload.ErrUnknownGeneration)
+	expCalls := []reloadCall{
+		{id: "t1", generation: 1},
+		{id: "t2", generation: 2},
+		{id: "rollback-1", generation: 3, rollback: 1, isRollback: true},
+		{id: "t3", generation: 4},
+	}
+	assert.Equal(expCalls, calls)
+	assert.Equal(uint64(4), m.Status().Generation)
+}
+
+func TestManagerConcurrentReloadsGeneration(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	generations := make(chan uint64, 3)
+	m := reload.NewManager()
+	started, release := make(chan struct{}), make(chan struct{})
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		g, _ := reload.GenerationFromContext(ctx)
+		generations <- g
+		close(started)
+		<-release
+		return nil
+	}), reload.WithPipeline("certs"), reload.WithTriggerSources("certs"))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		g, _ := reload.GenerationFromContext(ctx)
+		generations <- g
+		if id == "fail" {
+			return fmt.Errorf("something")
+		}
+		return nil
+	}), reload.WithPipeline("logging"), reload.WithTriggerSources("logging"))
+
+	// Execute.
+	slowFinished := make(chan error)
+	go func() {
+		slowFinished <- m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "slow", Source: "certs"})
+	}()
+	<-started
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "fail", Source: "logging"})
+	assert.Error(err)
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1", Source: "logging"}))
+	close(release)
+	require.NoError(<-slowFinished)
+
+	// Check.
+	assert.Equal(uint64(1), <-generations)
+	assert.Equal(uint64(2), <-generations)
+	assert.Equal(uint64(3), <-generations)
+	assert.Equal(uint64(3), m.Status().Generation)
+}
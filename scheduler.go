@@ -0,0 +1,167 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QueuedTrigger is a notifier trigger queued waiting to be reloaded.
+type QueuedTrigger struct {
+	// Trigger is the queued trigger.
+	Trigger TriggerEvent
+	// At is when the trigger was queued.
+	At time.Time
+}
+
+// Schedule is the Scheduler decision of the next reload.
+type Schedule struct {
+	// Next is the index of the queued trigger that will be reloaded next.
+	Next int
+	// Coalesced are the indexes of the queued triggers coalesced into the next
+	// one, they are skipped. The invalid indexes are ignored.
+	Coalesced []int
+}
+
+// Scheduler knows which queued trigger is reloaded next and which ones are
+// coalesced into it (see WithScheduler). The triggers are received in queue
+// order, and the ones not scheduled nor coalesced stay queued for the next
+// decisions.
+type Scheduler interface {
+	Schedule(queued []QueuedTrigger) Schedule
+}
+
+// SchedulerFunc is a helper to create schedulers from functions.
+type SchedulerFunc func(queued []QueuedTrigger) Schedule
+
+// Schedule satisfies Scheduler interface.
+func (s SchedulerFunc) Schedule(queued []QueuedTrigger) Schedule { return s(queued) }
+
+// FIFOScheduler returns a Scheduler that reloads the queued triggers in queue
+// order, without coalescing them.
+func FIFOScheduler() Scheduler {
+	return SchedulerFunc(func([]QueuedTrigger) Schedule { return Schedule{Next: 0} })
+}
+
+// LatestScheduler returns a Scheduler that reloads the latest queued trigger,
+// coalescing the rest into it (like StaleTriggerCollapse).
+func LatestScheduler() Scheduler {
+	return SchedulerFunc(func(queued []QueuedTrigger) Schedule {
+		s := Schedule{Next: len(queued) - 1}
+		for i := range s.Next {
+			s.Coalesced = append(s.Coalesced, i)
+		}
+		return s
+	})
+}
+
+// PriorityScheduler returns a Scheduler that reloads the queued trigger with
+// the highest priority first, in queue order for the same priority, e.g: to
+// prefer the triggers of an admin notifier over the file watch ones:
+//
+//	reload.PriorityScheduler(func(t reload.TriggerEvent) int {
+//		if t.Source == "admin" {
+//			return 1
+//		}
+//		return 0
+//	})
+func PriorityScheduler(priority func(t TriggerEvent) int) Scheduler {
+	return SchedulerFunc(func(queued []QueuedTrigger) Schedule {
+		next, nextPriority := 0, priority(queued[0].Trigger)
+		for i, q := range queued[1:] {
+			p := priority(q.Trigger)
+			if p > nextPriority {
+				next, nextPriority = i+1, p
+			}
+		}
+		return Schedule{Next: next}
+	})
+}
+
+// UrgencyScheduler returns a Scheduler that reloads the urgent triggers first
+// and the background ones last (see TriggerUrgency), in queue order for the
+// same urgency.
+func UrgencyScheduler() Scheduler {
+	return PriorityScheduler(func(t TriggerEvent) int { return t.Urgency.level() })
+}
+
+// nextTrigger returns the next queued notifier signal to reload. Without a
+// scheduler it's the oldest one, otherwise the queued signals are moved to the
+// scheduled ones and the scheduler decides. Returns a zero result when the
+// context ends.
+func (m *Manager) nextTrigger(ctx context.Context, signal <-chan notifierResult, scheduled *[]notifierResult, lastEnd time.Time) (notifierResult, error) {
+	// Without a scheduler, the scheduled signals (e.g: deferred by the reload
+	// window) go first in order.
+	if m.cfg.scheduler == nil && len(*scheduled) > 0 {
+		next := (*scheduled)[0]
+		*scheduled = (*scheduled)[1:]
+		return next, nil
+	}
+
+	if len(*scheduled) == 0 {
+		select {
+		case res := <-signal:
+			if res.Err != nil {
+				return res, fmt.Errorf("notifier failed: %w", res.Err)
+			}
+			if m.cfg.scheduler == nil {
+				return res, nil
+			}
+			*scheduled = append(*scheduled, res)
+		case <-ctx.Done():
+			return notifierResult{}, nil
+		}
+	}
+
+	// Move the queued signals, the scheduled ones are bounded to the queue size.
+drain:
+	for len(*scheduled) < max(cap(signal), 1) {
+		select {
+		case res := <-signal:
+			if res.Err != nil {
+				return res, fmt.Errorf("notifier failed: %w", res.Err)
+			}
+			*scheduled = append(*scheduled, res)
+		default:
+			break drain
+		}
+	}
+
+	queued := make([]QueuedTrigger, 0, len(*scheduled))
+	for _, res := range *scheduled {
+		queued = append(queued, QueuedTrigger{Trigger: res.Trigger, At: res.At})
+	}
+	s := m.cfg.scheduler.Schedule(queued)
+	if s.Next < 0 || s.Next >= len(queued) {
+		s.Next = 0
+	}
+
+	next := (*scheduled)[s.Next]
+	remove := map[int]bool{s.Next: true}
+	for _, i := range s.Coalesced {
+		if i < 0 || i >= len(queued) || remove[i] {
+			continue
+		}
+		remove[i] = true
+
+		// The coalesced triggers are pending until the next one is reloaded.
+		res := (*scheduled)[i]
+		next.Pending = append(next.Pending, res.Pending...)
+		m.receiveTrigger(ctx, &res, lastEnd)
+		m.cfg.metricsRecorder.IncCoalescedTrigger(ctx, res.Trigger.Source)
+		err := m.skipTrigger(ctx, res.Trigger, nil)
+		if err != nil {
+			return next, fmt.Errorf("reload process failed: %w", err)
+		}
+	}
+
+	rest := (*scheduled)[:0]
+	for i, res := range *scheduled {
+		if !remove[i] {
+			rest = append(rest, res)
+		}
+	}
+	*scheduled = rest
+
+	return next, nil
+}
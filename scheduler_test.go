@@ -0,0 +1,156 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerScheduler(t *testing.T) {
+	tests := map[string]struct {
+		scheduler  reload.Scheduler
+		expReloads []string
+		expSkipped []string
+	}{
+		"Without scheduler the queued triggers should be reloaded in order.": {
+			expReloads: []string{"t1", "t2", "t3", "t4"},
+		},
+
+		"The FIFO scheduler should reload the queued triggers in order.": {
+			scheduler:  reload.FIFOScheduler(),
+			expReloads: []string{"t1", "t2", "t3", "t4"},
+		},
+
+		"The latest scheduler should coalesce the queued triggers into the latest one.": {
+			scheduler:  reload.LatestScheduler(),
+			expReloads: []string{"t1", "t4"},
+			expSkipped: []string{"t2", "t3"},
+		},
+
+		"The priority scheduler should reload the high priority triggers first.": {
+			scheduler: reload.PriorityScheduler(func(t reload.TriggerEvent) int {
+				if t.Source == "admin" {
+					return 1
+				}
+				return 0
+			}),
+			expReloads: []string{"t1", "t3", "t2", "t4"},
+		},
+
+		"A custom scheduler should be able to coalesce the triggers of the same source.": {
+			scheduler: reload.SchedulerFunc(func(queued []reload.QueuedTrigger) reload.Schedule {
+				s := reload.Schedule{Next: len(queued) - 1}
+				for i := range s.Next {
+					if queued[i].Trigger.Source == queued[s.Next].Trigger.Source {
+						s.Coalesced = append(s.Coalesced, i)
+					}
+				}
+				return s
+			}),
+			expReloads: []string{"t1", "t4", "t3"},
+			expSkipped: []string{"t2"},
+		},
+
+		"Invalid schedules should be ignored.": {
+			scheduler: reload.SchedulerFunc(func(queued []reload.QueuedTrigger) reload.Schedule {
+				return reload.Schedule{Next: len(queued), Coalesced: []int{-1, 0, len(queued)}}
+			}),
+			expReloads: []string{"t1", "t2", "t3", "t4"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			reloaded := make(chan string, 4)
+			skipped := make(chan string, 4)
+			opts := []reload.ManagerOption{
+				reload.WithTriggerQueue(3, reload.QueueOverflowBlock),
+				reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+					switch e.Type {
+					case reload.EventReloadFinished:
+						reloaded <- e.Trigger.ID
+					case reload.EventReloadSkipped:
+						skipped <- e.Trigger.ID
+					}
+				})),
+			}
+			if test.scheduler != nil {
+				opts = append(opts, reload.WithScheduler(test.scheduler))
+			}
+			m := reload.NewManager(opts...)
+			started, release := make(chan struct{}), make(chan struct{})
+			first := true
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				if first {
+					first = false
+					close(started)
+					<-release
+				}
+				return nil
+			}))
+
+			// The notifiers are ready again once the previous trigger is queued.
+			fileC, fileReady := make(chan string), make(chan struct{})
+			m.On(queuedNotifier(fileC, fileReady), reload.WithNotifierName("file"))
+			adminC, adminReady := make(chan string), make(chan struct{})
+			m.On(queuedNotifier(adminC, adminReady), reload.WithNotifierName("admin"))
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			<-fileReady
+			<-adminReady
+			fileC <- "t1"
+			<-fileReady
+			<-started
+			fileC <- "t2"
+			<-fileReady
+			adminC <- "t3"
+			<-adminReady
+			fileC <- "t4"
+			<-fileReady
+			close(release)
+			var gotReloads []string
+			for range test.expReloads {
+				gotReloads = append(gotReloads, <-reloaded)
+			}
+			close(skipped)
+			var gotSkipped []string
+			for id := range skipped {
+				gotSkipped = append(gotSkipped, id)
+			}
+			cancel()
+
+			// Check.
+			assert.NoError(<-runFinished)
+			assert.Equal(test.expReloads, gotReloads)
+			assert.Equal(test.expSkipped, gotSkipped)
+		})
+	}
+}
+
+// queuedNotifier returns a notifier that signals ready every time it waits for
+// a trigger.
+func queuedNotifier(c <-chan string, ready chan<- struct{}) reload.Notifier {
+	return reload.NotifierFunc(func(ctx context.Context) (string, error) {
+		select {
+		case ready <- struct{}{}:
+		case <-ctx.Done():
+			return "", nil
+		}
+
+		select {
+		case id := <-c:
+			return id, nil
+		case <-ctx.Done():
+			return "", nil
+		}
+	})
+}
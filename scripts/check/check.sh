@@ -3,4 +3,7 @@
 set -o errexit
 set -o nounset
 
-golangci-lint run -E goimports --timeout 3m
\ No newline at end of file
+# The integrations are nested modules, check all of them.
+for dir in $(find . -name go.mod -not -path "./_examples/*" -exec dirname {} \; | sort); do
+    (cd "${dir}" && golangci-lint run -E goimports --timeout 3m)
+done
@@ -3,5 +3,12 @@
 set -o errexit
 set -o nounset
 
-go test -race -coverprofile=.test_coverage.txt ./...
-go tool cover -func=.test_coverage.txt | tail -n1 | awk '{print "Total test coverage: " $3}'
\ No newline at end of file
+# The integrations are nested modules (so their dependencies are not required
+# by the root module), test all of them.
+for dir in $(find . -name go.mod -not -path "./_examples/*" -exec dirname {} \; | sort); do
+    (
+        cd "${dir}"
+        go test -race -coverprofile=.test_coverage.txt ./...
+        go tool cover -func=.test_coverage.txt | tail -n1 | awk -v dir="${dir}" '{print "Total test coverage (" dir "): " $3}'
+    )
+done
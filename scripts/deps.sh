@@ -3,4 +3,7 @@
 set -o errexit
 set -o nounset
 
-go mod tidy
\ No newline at end of file
+# The integrations are nested modules, fix the dependencies of all of them.
+for dir in $(find . -name go.mod -not -path "./_examples/*" -exec dirname {} \; | sort); do
+    (cd "${dir}" && go mod tidy)
+done
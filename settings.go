@@ -0,0 +1,90 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Settings are the manager settings that can be changed while the manager is
+// running (see Manager.UpdateSettings), so the operators can loosen or tighten
+// the reload behavior during an incident without restarting.
+//
+// The initial settings are the ones set with the manager options.
+type Settings struct {
+	// StaleTriggerPolicy is how the triggers queued while reloading are handled
+	// (see WithStaleTriggerPolicy).
+	StaleTriggerPolicy StaleTriggerPolicy
+	// QueueOverflow is the trigger queue overflow policy of the notifiers that
+	// don't set their own (see WithTriggerQueue).
+	QueueOverflow QueueOverflowPolicy
+	// OrderedReloaders runs the reloaders of a group one at a time (see
+	// WithOrderedReloaders).
+	OrderedReloaders bool
+	// ShutdownGracePeriod is the grace period of the in-flight reload when the
+	// manager stops (see WithShutdownGracePeriod).
+	ShutdownGracePeriod time.Duration
+}
+
+func (s Settings) validate() error {
+	switch s.StaleTriggerPolicy {
+	case StaleTriggerKeep, StaleTriggerDrop, StaleTriggerCollapse:
+	default:
+		return fmt.Errorf("unknown stale trigger policy %q", s.StaleTriggerPolicy)
+	}
+
+	switch s.QueueOverflow {
+	case QueueOverflowBlock, QueueOverflowDropNewest, QueueOverflowDropOldest:
+	default:
+		return fmt.Errorf("unknown queue overflow policy %q", s.QueueOverflow)
+	}
+
+	if s.ShutdownGracePeriod < 0 {
+		return fmt.Errorf("shutdown grace period can't be negative")
+	}
+
+	return nil
+}
+
+func newSettings(cfg managerConfig) *atomic.Pointer[Settings] {
+	p := &atomic.Pointer[Settings]{}
+	p.Store(&Settings{
+		StaleTriggerPolicy:  cfg.staleTriggerPolicy,
+		QueueOverflow:       cfg.queueOverflow,
+		OrderedReloaders:    cfg.orderedReloaders,
+		ShutdownGracePeriod: cfg.shutdownGracePeriod,
+	})
+	return p
+}
+
+// Settings returns the current manager settings.
+func (m *Manager) Settings() Settings {
+	return *m.settings.Load()
+}
+
+// UpdateSettings replaces the manager settings, it's safe to call while the
+// manager is running. The in-flight reload keeps the settings it started with
+// and the new ones are used from the next trigger.
+func (m *Manager) UpdateSettings(s Settings) error {
+	if err := s.validate(); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+
+	m.settings.Store(&s)
+	return nil
+}
+
+// SettingsReloader returns a reloader that loads the manager settings and
+// updates them, so the settings can be reloaded like the rest of the
+// configuration (e.g: from the same configuration file).
+func (m *Manager) SettingsReloader(load func(ctx context.Context) (Settings, error)) Reloader {
+	return ReloaderFunc(func(ctx context.Context, id string) error {
+		s, err := load(ctx)
+		if err != nil {
+			return fmt.Errorf("could not load settings: %w", err)
+		}
+
+		return m.UpdateSettings(s)
+	})
+}
@@ -0,0 +1,131 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerUpdateSettings(t *testing.T) {
+	tests := map[string]struct {
+		settings    reload.Settings
+		expErr      bool
+		expSettings reload.Settings
+	}{
+		"Valid settings should be updated.": {
+			settings: reload.Settings{
+				StaleTriggerPolicy:  reload.StaleTriggerCollapse,
+				QueueOverflow:       reload.QueueOverflowDropOldest,
+				OrderedReloaders:    true,
+				ShutdownGracePeriod: time.Minute,
+			},
+			expSettings: reload.Settings{
+				StaleTriggerPolicy:  reload.StaleTriggerCollapse,
+				QueueOverflow:       reload.QueueOverflowDropOldest,
+				OrderedReloaders:    true,
+				ShutdownGracePeriod: time.Minute,
+			},
+		},
+
+		"An unknown stale trigger policy should fail and keep the settings.": {
+			settings: reload.Settings{
+				StaleTriggerPolicy: "something",
+				QueueOverflow:      reload.QueueOverflowBlock,
+			},
+			expErr: true,
+			expSettings: reload.Settings{
+				StaleTriggerPolicy: reload.StaleTriggerDrop,
+				QueueOverflow:      reload.QueueOverflowBlock,
+			},
+		},
+
+		"An unknown queue overflow policy should fail and keep the settings.": {
+			settings: reload.Settings{
+				StaleTriggerPolicy: reload.StaleTriggerKeep,
+				QueueOverflow:      "something",
+			},
+			expErr: true,
+			expSettings: reload.Settings{
+				StaleTriggerPolicy: reload.StaleTriggerDrop,
+				QueueOverflow:      reload.QueueOverflowBlock,
+			},
+		},
+
+		"A negative shutdown grace period should fail and keep the settings.": {
+			settings: reload.Settings{
+				StaleTriggerPolicy:  reload.StaleTriggerKeep,
+				QueueOverflow:       reload.QueueOverflowBlock,
+				ShutdownGracePeriod: -time.Second,
+			},
+			expErr: true,
+			expSettings: reload.Settings{
+				StaleTriggerPolicy: reload.StaleTriggerDrop,
+				QueueOverflow:      reload.QueueOverflowBlock,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := reload.NewManager(reload.WithStaleTriggerPolicy(reload.StaleTriggerDrop))
+			err := m.UpdateSettings(test.settings)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expSettings, m.Settings())
+		})
+	}
+}
+
+func TestManagerSettingsReloader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	var mu sync.Mutex
+	var order []string
+	loadErr := fmt.Errorf("something")
+	settings := reload.Settings{StaleTriggerPolicy: reload.StaleTriggerKeep, QueueOverflow: reload.QueueOverflowBlock, OrderedReloaders: true}
+	m := reload.NewManager()
+	m.Add(0, m.SettingsReloader(func(ctx context.Context) (reload.Settings, error) {
+		if loadErr != nil {
+			return reload.Settings{}, loadErr
+		}
+		return settings, nil
+	}))
+	for i := range 5 {
+		m.Add(10, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, fmt.Sprintf("r%d", i))
+			return nil
+		}))
+	}
+
+	// A failed settings load should keep the settings.
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+	require.ErrorIs(err, loadErr)
+	assert.False(m.Status().OrderedReloaders)
+
+	// The loaded settings should be used from the next reload.
+	loadErr = nil
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t2"}))
+	assert.Equal(settings, m.Settings())
+	assert.True(m.Status().OrderedReloaders)
+
+	order = nil
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t3"}))
+	assert.Equal([]string{"r0", "r1", "r2", "r3", "r4"}, order)
+}
@@ -0,0 +1,116 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// Signal is a platform independent OS signal that can trigger reloads.
+type Signal string
+
+const (
+	// SignalHUP is the SIGHUP signal.
+	SignalHUP Signal = "SIGHUP"
+	// SignalUSR1 is the SIGUSR1 signal.
+	SignalUSR1 Signal = "SIGUSR1"
+	// SignalUSR2 is the SIGUSR2 signal.
+	SignalUSR2 Signal = "SIGUSR2"
+)
+
+// Supported returns true if the signal exists on the current platform.
+func (s Signal) Supported() bool {
+	_, ok := platformSignals[s]
+	return ok
+}
+
+// SignalNotifierConfig is the configuration of the SignalNotifier.
+type SignalNotifierConfig struct {
+	// TriggerIDs maps the signals that will trigger a reload to their trigger
+	// IDs, if the ID is empty, the signal name will be used as the ID.
+	//
+	// The signals that don't exist on the current platform (e.g: SIGUSR1 on
+	// Windows) are ignored.
+	//
+	// By default only SIGHUP.
+	TriggerIDs map[Signal]string
+}
+
+func (c *SignalNotifierConfig) defaults() error {
+	if len(c.TriggerIDs) == 0 {
+		c.TriggerIDs = map[Signal]string{SignalHUP: ""}
+	}
+
+	ids := make(map[Signal]string, len(c.TriggerIDs))
+	for s, id := range c.TriggerIDs {
+		if s != SignalHUP && s != SignalUSR1 && s != SignalUSR2 {
+			return fmt.Errorf("unknown %q signal", s)
+		}
+
+		if id == "" {
+			id = string(s)
+		}
+		ids[s] = id
+	}
+	c.TriggerIDs = ids
+
+	return nil
+}
+
+// SignalNotifier is a notifier that will trigger a reload when the process
+// receives any of the configured OS signals. Each signal can have a different
+// trigger ID, so reloaders can do partial reloads (e.g: SIGUSR1 to reopen
+// log files and SIGHUP for a full configuration reload).
+//
+// If none of the signals exist on the current platform, the notifier will
+// never trigger.
+type SignalNotifier struct {
+	ids map[os.Signal]string
+	c   chan os.Signal
+}
+
+// NewSignalNotifier returns a new SignalNotifier, the signals are captured
+// from the moment is created until is stopped.
+func NewSignalNotifier(cfg SignalNotifierConfig) (*SignalNotifier, error) {
+	err := cfg.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	s := &SignalNotifier{
+		ids: map[os.Signal]string{},
+		c:   make(chan os.Signal, 1),
+	}
+
+	sigs := []os.Signal{}
+	for sig, id := range cfg.TriggerIDs {
+		osSig, ok := platformSignals[sig]
+		if !ok {
+			continue
+		}
+		s.ids[osSig] = id
+		sigs = append(sigs, osSig)
+	}
+
+	if len(sigs) > 0 {
+		signal.Notify(s.c, sigs...)
+	}
+
+	return s, nil
+}
+
+// Notify satisfies Notifier interface.
+func (s *SignalNotifier) Notify(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case sig := <-s.c:
+		return s.ids[sig], nil
+	}
+}
+
+// Stop stops capturing the signals.
+func (s *SignalNotifier) Stop() {
+	signal.Stop(s.c)
+}
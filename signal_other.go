@@ -0,0 +1,11 @@
+//go:build !unix
+
+package reload
+
+import (
+	"os"
+)
+
+// Platforms without unix signals (e.g: Windows) don't support any of the
+// reload signals.
+var platformSignals = map[Signal]os.Signal{}
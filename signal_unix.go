@@ -0,0 +1,14 @@
+//go:build unix
+
+package reload
+
+import (
+	"os"
+	"syscall"
+)
+
+var platformSignals = map[Signal]os.Signal{
+	SignalHUP:  syscall.SIGHUP,
+	SignalUSR1: syscall.SIGUSR1,
+	SignalUSR2: syscall.SIGUSR2,
+}
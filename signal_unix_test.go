@@ -0,0 +1,82 @@
+//go:build unix
+
+package reload_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestSignalNotifier(t *testing.T) {
+	tests := map[string]struct {
+		config reload.SignalNotifierConfig
+		signal syscall.Signal
+		expID  string
+	}{
+		"By default SIGHUP should trigger with the signal name as ID.": {
+			config: reload.SignalNotifierConfig{},
+			signal: syscall.SIGHUP,
+			expID:  "SIGHUP",
+		},
+
+		"SIGUSR1 should trigger with its custom ID.": {
+			config: reload.SignalNotifierConfig{TriggerIDs: map[reload.Signal]string{
+				reload.SignalHUP:  "config",
+				reload.SignalUSR1: "log-reopen",
+				reload.SignalUSR2: "",
+			}},
+			signal: syscall.SIGUSR1,
+			expID:  "log-reopen",
+		},
+
+		"SIGUSR2 without custom ID should trigger with the signal name as ID.": {
+			config: reload.SignalNotifierConfig{TriggerIDs: map[reload.Signal]string{
+				reload.SignalHUP:  "config",
+				reload.SignalUSR1: "log-reopen",
+				reload.SignalUSR2: "",
+			}},
+			signal: syscall.SIGUSR2,
+			expID:  "SIGUSR2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			n, err := reload.NewSignalNotifier(test.config)
+			require.NoError(err)
+			defer n.Stop()
+
+			require.NoError(syscall.Kill(syscall.Getpid(), test.signal))
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			gotID, err := n.Notify(ctx)
+
+			require.NoError(err)
+			assert.Equal(test.expID, gotID)
+		})
+	}
+}
+
+func TestSignalNotifierInvalidSignal(t *testing.T) {
+	_, err := reload.NewSignalNotifier(reload.SignalNotifierConfig{TriggerIDs: map[reload.Signal]string{"SIGKILL": ""}})
+	assert.Error(t, err)
+}
+
+func TestSignalSupported(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(reload.SignalHUP.Supported())
+	assert.True(reload.SignalUSR1.Supported())
+	assert.True(reload.SignalUSR2.Supported())
+	assert.False(reload.Signal("SIGKILL").Supported())
+}
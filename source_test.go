@@ -0,0 +1,102 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerTriggerSources(t *testing.T) {
+	require := require.New(t)
+
+	// Prepare.
+	rec := reloadtest.NewRecorder()
+	noop := reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil })
+	m := reload.NewManager(reload.WithSubscriber(rec))
+	m.Add(0, noop, reload.WithReloaderName("all"))
+	m.Add(0, noop, reload.WithReloaderName("config"), reload.WithTriggerSources("config-*"))
+	m.Add(10, noop, reload.WithReloaderName("secrets"), reload.WithTriggerSources("secrets", "manual"))
+	m.Add(20, noop, reload.WithReloaderName("invalid"), reload.WithTriggerSources("["))
+
+	// Execute.
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1", Source: "config-file"}))
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t2", Source: "secrets"}))
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t3"}))
+
+	// Check.
+	rec.AssertTrail(t,
+		"trigger_received id=t1 source=config-file",
+		"reload_started id=t1",
+		"group_started id=t1 priority=0",
+		"reloader_finished id=t1 priority=0 reloader=all",
+		"reloader_finished id=t1 priority=0 reloader=config",
+		"group_finished id=t1 priority=0",
+		"reload_finished id=t1",
+		"trigger_received id=t2 source=secrets",
+		"reload_started id=t2",
+		"group_started id=t2 priority=0",
+		"reloader_finished id=t2 priority=0 reloader=all",
+		"group_finished id=t2 priority=0",
+		"group_started id=t2 priority=10",
+		"reloader_finished id=t2 priority=10 reloader=secrets",
+		"group_finished id=t2 priority=10",
+		"reload_finished id=t2",
+		"trigger_received id=t3 source=manual",
+		"reload_started id=t3",
+		"group_started id=t3 priority=0",
+		"reloader_finished id=t3 priority=0 reloader=all",
+		"group_finished id=t3 priority=0",
+		"group_started id=t3 priority=10",
+		"reloader_finished id=t3 priority=10 reloader=secrets",
+		"group_finished id=t3 priority=10",
+		"reload_finished id=t3",
+	)
+}
+
+func TestManagerGroupTriggerIDs(t *testing.T) {
+	require := require.New(t)
+
+	// Prepare.
+	rec := reloadtest.NewRecorder()
+	noop := reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil })
+	m := reload.NewManager(
+		reload.WithSubscriber(rec),
+		reload.WithGroupTriggerIDs(0, "logrotate"),
+		reload.WithGroupTriggerIDs(10, "config-*", "tls-*"),
+		reload.WithGroupTriggerIDs(20, "["),
+	)
+	m.Add(0, noop, reload.WithReloaderName("logs"))
+	m.Add(10, noop, reload.WithReloaderName("config"))
+	m.Add(20, noop, reload.WithReloaderName("invalid"))
+	m.Add(30, noop, reload.WithReloaderName("all"))
+
+	// Execute.
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "logrotate"}))
+	require.NoError(m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "tls-1"}))
+
+	// Check.
+	rec.AssertTrail(t,
+		"trigger_received id=logrotate source=manual",
+		"reload_started id=logrotate",
+		"group_started id=logrotate priority=0",
+		"reloader_finished id=logrotate priority=0 reloader=logs",
+		"group_finished id=logrotate priority=0",
+		"group_started id=logrotate priority=30",
+		"reloader_finished id=logrotate priority=30 reloader=all",
+		"group_finished id=logrotate priority=30",
+		"reload_finished id=logrotate",
+		"trigger_received id=tls-1 source=manual",
+		"reload_started id=tls-1",
+		"group_started id=tls-1 priority=10",
+		"reloader_finished id=tls-1 priority=10 reloader=config",
+		"group_finished id=tls-1 priority=10",
+		"group_started id=tls-1 priority=30",
+		"reloader_finished id=tls-1 priority=30 reloader=all",
+		"group_finished id=tls-1 priority=30",
+		"reload_finished id=tls-1",
+	)
+}
@@ -0,0 +1,120 @@
+package reload
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats are the cumulative counters of the manager since it was created, for
+// the apps that surface the reload health without a metrics backend.
+type Stats struct {
+	// ReloadsAttempted is the number of started reload processes, including
+	// the retries.
+	ReloadsAttempted uint64
+	// ReloadsSucceeded is the number of successful reload processes.
+	ReloadsSucceeded uint64
+	// ReloadsFailed is the number of failed reload processes.
+	ReloadsFailed uint64
+	// ReloadsSkipped is the number of skipped reload processes (e.g: in
+	// progress, coalesced or rejected).
+	ReloadsSkipped uint64
+	// TriggersReceived is the number of received triggers.
+	TriggersReceived uint64
+	// TriggersDropped is the number of triggers dropped before being queued.
+	TriggersDropped uint64
+	// Groups are the stats of the reloaded priority groups, in priority order.
+	Groups []GroupStats
+}
+
+// GroupStats are the cumulative counters of a reloader priority group.
+type GroupStats struct {
+	// Priority is the priority of the group.
+	Priority int
+	// Name is the name of the group, if any (see WithGroupName).
+	Name string
+	// Reloads is the number of finished reloads of the group, including the
+	// failed ones.
+	Reloads uint64
+	// MeanDuration is the mean duration of the group reloads.
+	MeanDuration time.Duration
+	// MaxDuration is the maximum duration of the group reloads.
+	MaxDuration time.Duration
+}
+
+// Stats returns the cumulative reload and trigger counters of the manager.
+func (m *Manager) Stats() Stats {
+	s := m.stats.snapshot()
+	for i, g := range s.Groups {
+		s.Groups[i].Name = m.cfg.groupNames[g.Priority]
+	}
+
+	return s
+}
+
+// statsTracker tracks the manager stats from the emitted events.
+type statsTracker struct {
+	mu     sync.Mutex
+	stats  Stats
+	groups map[int]*groupStats
+}
+
+type groupStats struct {
+	reloads uint64
+	total   time.Duration
+	max     time.Duration
+}
+
+func newStatsTracker() *statsTracker {
+	return &statsTracker{groups: map[int]*groupStats{}}
+}
+
+func (s *statsTracker) track(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch e.Type {
+	case EventTriggerReceived:
+		s.stats.TriggersReceived++
+	case EventTriggerDropped:
+		s.stats.TriggersDropped++
+	case EventReloadStarted:
+		s.stats.ReloadsAttempted++
+	case EventReloadSkipped:
+		s.stats.ReloadsSkipped++
+	case EventReloadFinished:
+		if e.Err != nil {
+			s.stats.ReloadsFailed++
+		} else {
+			s.stats.ReloadsSucceeded++
+		}
+	case EventGroupFinished:
+		g, ok := s.groups[e.Priority]
+		if !ok {
+			g = &groupStats{}
+			s.groups[e.Priority] = g
+		}
+		g.reloads++
+		g.total += e.Duration
+		g.max = max(g.max, e.Duration)
+	}
+}
+
+func (s *statsTracker) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Groups = nil
+	for priority, g := range s.groups {
+		stats.Groups = append(stats.Groups, GroupStats{
+			Priority:     priority,
+			Reloads:      g.reloads,
+			MeanDuration: g.total / time.Duration(g.reloads),
+			MaxDuration:  g.max,
+		})
+	}
+	sort.Slice(stats.Groups, func(i, j int) bool { return stats.Groups[i].Priority < stats.Groups[j].Priority })
+
+	return stats
+}
@@ -0,0 +1,70 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerStats(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Now())
+	m := reload.NewManager(
+		reload.WithClock(clock),
+		reload.WithGroupName(0, "config"),
+		reload.WithTriggerFilter(func(t reload.TriggerEvent) bool { return t.ID != "dropped" }),
+	)
+	durations := map[string][]time.Duration{
+		"t1": {2 * time.Second, 1 * time.Second},
+		"t2": {4 * time.Second, 3 * time.Second},
+	}
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		clock.Advance(durations[id][0])
+		return nil
+	}))
+	m.Add(1, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		clock.Advance(durations[id][1])
+		if id == "t2" {
+			return fmt.Errorf("something")
+		}
+		return nil
+	}))
+	notifierC := make(chan string)
+	m.On(reload.NotifierChan(notifierC))
+
+	// Execute.
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(context.Background()) }()
+	notifierC <- "t1"
+	notifierC <- "dropped"
+	notifierC <- "t2"
+
+	// Check.
+	assert.Error(<-runErr)
+	exp := reload.Stats{
+		ReloadsAttempted: 2,
+		ReloadsSucceeded: 1,
+		ReloadsFailed:    1,
+		TriggersReceived: 2,
+		TriggersDropped:  1,
+		Groups: []reload.GroupStats{
+			{Priority: 0, Name: "config", Reloads: 2, MeanDuration: 3 * time.Second, MaxDuration: 4 * time.Second},
+			{Priority: 1, Reloads: 2, MeanDuration: 2 * time.Second, MaxDuration: 3 * time.Second},
+		},
+	}
+	assert.Equal(exp, m.Stats())
+}
+
+func TestManagerStatsEmpty(t *testing.T) {
+	m := reload.NewManager()
+
+	assert.Equal(t, reload.Stats{}, m.Stats())
+}
@@ -0,0 +1,126 @@
+package reload
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Status is the state of the manager.
+type Status struct {
+	// Running is true while the manager is running.
+	Running bool
+	// Reloading is true while a reload process is in progress.
+	Reloading bool
+	// Generation is the generation of the last successful reload (see
+	// GenerationFromContext), 0 before any reload.
+	Generation uint64
+	// OrderedReloaders is true when the reloaders of a group start in
+	// weight and registration order (see WithOrderedReloaders).
+	OrderedReloaders bool
+	// Notifiers are the names of the registered notifiers.
+	Notifiers []string
+	// Plan is the reload execution plan, the reloader groups in execution order.
+	Plan []StatusGroup
+	// AbandonedReloaders are the names of the reloaders that exceeded their
+	// timeout and didn't return yet, once per abandoned reload.
+	AbandonedReloaders []string
+	// OpenCircuits are the names of the reloaders with an open circuit breaker
+	// (see WithCircuitBreaker).
+	OpenCircuits []string
+	// QuarantinedNotifiers are the names of the notifiers quarantined by their
+	// breaker (see WithNotifierBreaker).
+	QuarantinedNotifiers []string
+	// StaleNotifiers are the names of the notifiers flagged as stale by their
+	// liveness check (see WithNotifierLiveness).
+	StaleNotifiers []string
+	// DisabledReloaders are the names of the reloaders disabled at runtime
+	// (see Manager.DisableReloader).
+	DisabledReloaders []string
+	// PendingApproval is the trigger waiting for the approval, if any (see
+	// WithApproval).
+	PendingApproval *StatusApproval
+}
+
+// StatusGroup is a reloader priority group of the execution plan.
+type StatusGroup struct {
+	// Priority is the priority of the group.
+	Priority int
+	// Name is the name of the group, if any (see WithGroupName).
+	Name string
+	// Reloaders are the names of the group reloaders in execution order.
+	Reloaders []string
+}
+
+// Status returns the current state of the manager and its effective reload
+// execution plan.
+func (m *Manager) Status() Status {
+	s := Status{
+		Running:              atomic.LoadUint32(&m.running) == lockedState,
+		Reloading:            m.locks.locked(),
+		Generation:           atomic.LoadUint64(&m.generation),
+		OrderedReloaders:     m.Settings().OrderedReloaders,
+		AbandonedReloaders:   m.abandoned.names(),
+		OpenCircuits:         m.openCircuits(),
+		QuarantinedNotifiers: m.quarantined.names(),
+		StaleNotifiers:       m.stale.names(),
+		DisabledReloaders:    m.toggles.names(),
+		PendingApproval:      m.approvals.status(),
+	}
+
+	for _, n := range m.notifiers {
+		s.Notifiers = append(s.Notifiers, n.name)
+	}
+
+	for _, rg := range m.sortedGroups() {
+		g := StatusGroup{Priority: rg.priority, Name: m.cfg.groupNames[rg.priority]}
+		for _, r := range rg.reloaders {
+			g.Reloaders = append(g.Reloaders, r.name)
+		}
+		s.Plan = append(s.Plan, g)
+	}
+
+	return s
+}
+
+// nameCounter tracks the names of the running processes (e.g: the abandoned
+// reloaders), a name can be added multiple times.
+type nameCounter struct {
+	mu      sync.Mutex
+	running map[string]int
+}
+
+func newNameCounter() *nameCounter {
+	return &nameCounter{running: map[string]int{}}
+}
+
+func (a *nameCounter) add(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running[name]++
+}
+
+func (a *nameCounter) remove(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running[name]--
+	if a.running[name] <= 0 {
+		delete(a.running, name)
+	}
+}
+
+// names returns the sorted names, once per time they have been added.
+func (a *nameCounter) names() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var names []string
+	for name, n := range a.running {
+		for range n {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
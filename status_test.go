@@ -0,0 +1,144 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestManagerStatus(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	reloading := make(chan struct{})
+	release := make(chan struct{})
+	noop := reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil })
+	m := reload.NewManager(reload.WithOrderedReloaders())
+	m.Add(10, noop, reload.WithReloaderName("cache"))
+	m.Add(0, noop, reload.WithReloaderName("config"))
+	m.Add(10, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		if id == "block" {
+			close(reloading)
+			<-release
+		}
+		return nil
+	}), reload.WithReloaderName("http"))
+	m.Add(0, noop)
+	notifierC := make(chan string, 1)
+	m.On(reload.NotifierChan(notifierC), reload.WithNotifierName("admin"))
+
+	expPlan := []reload.StatusGroup{
+		{Priority: 0, Reloaders: []string{"config", "reloader-3"}},
+		{Priority: 10, Reloaders: []string{"cache", "http"}},
+	}
+
+	// Before running.
+	assert.Equal(reload.Status{
+		OrderedReloaders: true,
+		Notifiers:        []string{"admin"},
+		Plan:             expPlan,
+	}, m.Status())
+
+	// While reloading.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	notifierC <- "ok"
+	time.Sleep(10 * time.Millisecond)
+	notifierC <- "block"
+	<-reloading
+	assert.Equal(reload.Status{
+		Running:          true,
+		Reloading:        true,
+		Generation:       1,
+		OrderedReloaders: true,
+		Notifiers:        []string{"admin"},
+		Plan:             expPlan,
+	}, m.Status())
+
+	// After running.
+	close(release)
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	require.NoError(<-runErr)
+	assert.Equal(reload.Status{
+		Generation:       2,
+		OrderedReloaders: true,
+		Notifiers:        []string{"admin"},
+		Plan:             expPlan,
+	}, m.Status())
+}
+
+func TestManagerOrderedReloaders(t *testing.T) {
+	tests := map[string]struct {
+		reloaderErr map[int]error
+		weights     map[int]int
+		expOrder    []string
+		expErr      bool
+	}{
+		"Reloaders should be executed in registration order.": {
+			expOrder: []string{"p0-0", "p0-1", "p0-2", "p0-3", "p0-4", "p1-0", "p1-1", "p1-2", "p1-3", "p1-4"},
+		},
+
+		"Reloaders should be executed in weight order and then registration order.": {
+			weights:  map[int]int{0: 5, 2: 5, 4: -1},
+			expOrder: []string{"p0-4", "p0-1", "p0-3", "p0-0", "p0-2", "p1-4", "p1-1", "p1-3", "p1-0", "p1-2"},
+		},
+
+		"A failed reloader should stop the execution.": {
+			reloaderErr: map[int]error{2: fmt.Errorf("something")},
+			expOrder:    []string{"p0-0", "p0-1", "p0-2"},
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var mu sync.Mutex
+			var gotOrder []string
+			m := reload.NewManager(reload.WithOrderedReloaders())
+			for _, p := range []int{1, 0} {
+				for i := range 5 {
+					name := fmt.Sprintf("p%d-%d", p, i)
+					err := test.reloaderErr[i]
+					if p != 0 {
+						err = nil
+					}
+					m.Add(p, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+						mu.Lock()
+						gotOrder = append(gotOrder, name)
+						mu.Unlock()
+						return err
+					}), reload.WithReloaderWeight(test.weights[i]))
+				}
+			}
+			notifierC := make(chan string, 1)
+			notifierC <- "test-id"
+			m.On(reload.NotifierChan(notifierC))
+
+			// Execute.
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			err := m.Run(ctx)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expOrder, gotOrder)
+		})
+	}
+}
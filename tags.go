@@ -0,0 +1,180 @@
+package reload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidTagExpression is returned when a tag expression can't be parsed.
+var ErrInvalidTagExpression = errors.New("invalid tag expression")
+
+// TagExpression is a boolean expression of reloader tags (see
+// WithReloaderTags) used to select the reloaders of a trigger (see
+// TriggerEvent.TagSelector), e.g: `tls && !expensive` or `(db || cache) &&
+// !expensive`.
+//
+// The tags are combined with `&&`, `||`, `!` and parentheses, with the usual
+// precedence (`!`, then `&&`, then `||`). The tags are made of letters, digits
+// and the `-`, `_`, `.`, `:` and `/` characters. An empty expression matches
+// all the reloaders.
+type TagExpression struct {
+	expr  string
+	match tagMatcher
+}
+
+type tagMatcher func(tags map[string]bool) bool
+
+// ParseTagExpression parses a tag expression.
+func ParseTagExpression(expr string) (TagExpression, error) {
+	if strings.TrimSpace(expr) == "" {
+		return TagExpression{expr: expr}, nil
+	}
+
+	p := &tagParser{expr: expr}
+	match, err := p.parseOr()
+	if err == nil && p.peek() != "" {
+		err = fmt.Errorf("unexpected %q", p.peek())
+	}
+	if err != nil {
+		return TagExpression{}, fmt.Errorf("%w %q: %w", ErrInvalidTagExpression, expr, err)
+	}
+
+	return TagExpression{expr: expr, match: match}, nil
+}
+
+// Match returns if the tags match the expression.
+func (t TagExpression) Match(tags []string) bool {
+	if t.match == nil {
+		return true
+	}
+
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[tag] = true
+	}
+
+	return t.match(set)
+}
+
+// String satisfies fmt.Stringer interface.
+func (t TagExpression) String() string { return t.expr }
+
+// tagParser is a recursive descent parser of the tag expressions.
+type tagParser struct {
+	expr string
+	pos  int
+}
+
+func (p *tagParser) parseOr() (tagMatcher, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tags map[string]bool) bool { return l(tags) || right(tags) }
+	}
+
+	return left, nil
+}
+
+func (p *tagParser) parseAnd() (tagMatcher, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tags map[string]bool) bool { return l(tags) && right(tags) }
+	}
+
+	return left, nil
+}
+
+func (p *tagParser) parseNot() (tagMatcher, error) {
+	switch tok := p.next(); tok {
+	case "!":
+		m, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(tags map[string]bool) bool { return !m(tags) }, nil
+
+	case "(":
+		m, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing %q", ")")
+		}
+		return m, nil
+
+	case "":
+		return nil, fmt.Errorf("unexpected end")
+
+	default:
+		if strings.IndexFunc(tok, func(r rune) bool { return !isTagRune(r) }) >= 0 {
+			return nil, fmt.Errorf("unexpected %q", tok)
+		}
+		return func(tags map[string]bool) bool { return tags[tok] }, nil
+	}
+}
+
+// peek returns the next token without consuming it, empty at the end.
+func (p *tagParser) peek() string {
+	pos := p.pos
+	tok := p.next()
+	p.pos = pos
+
+	return tok
+}
+
+// next consumes the next token, empty at the end.
+func (p *tagParser) next() string {
+	rest := strings.TrimLeftFunc(p.expr[p.pos:], unicode.IsSpace)
+	p.pos = len(p.expr) - len(rest)
+	if rest == "" {
+		return ""
+	}
+
+	for _, op := range []string{"&&", "||", "!", "(", ")"} {
+		if strings.HasPrefix(rest, op) {
+			p.pos += len(op)
+			return op
+		}
+	}
+
+	end := strings.IndexFunc(rest, func(r rune) bool { return !isTagRune(r) })
+	if end == 0 {
+		// Not a tag character, return it so it's reported as unexpected.
+		_, size := utf8.DecodeRuneInString(rest)
+		p.pos += size
+		return rest[:size]
+	}
+	if end < 0 {
+		end = len(rest)
+	}
+	p.pos += end
+
+	return rest[:end]
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.:/", r)
+}
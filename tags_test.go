@@ -0,0 +1,217 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestTagExpression(t *testing.T) {
+	tests := map[string]struct {
+		expr     string
+		tags     []string
+		expMatch bool
+		expErr   bool
+	}{
+		"An empty expression should match all.": {
+			expr:     "",
+			tags:     []string{"tls"},
+			expMatch: true,
+		},
+
+		"An empty expression should match without tags.": {
+			expr:     " ",
+			expMatch: true,
+		},
+
+		"A tag should match the reloaders with the tag.": {
+			expr:     "tls",
+			tags:     []string{"db", "tls"},
+			expMatch: true,
+		},
+
+		"A tag should not match the reloaders without the tag.": {
+			expr:     "tls",
+			tags:     []string{"db"},
+			expMatch: false,
+		},
+
+		"A negated tag should match the reloaders without the tag.": {
+			expr:     "!expensive",
+			expMatch: true,
+		},
+
+		"And should match when both match.": {
+			expr:     "tls && !expensive",
+			tags:     []string{"tls"},
+			expMatch: true,
+		},
+
+		"And should not match when one doesn't match.": {
+			expr:     "tls && !expensive",
+			tags:     []string{"tls", "expensive"},
+			expMatch: false,
+		},
+
+		"Or should match when one matches.": {
+			expr:     "db || cache",
+			tags:     []string{"cache"},
+			expMatch: true,
+		},
+
+		"And should have precedence over or.": {
+			expr:     "db || cache && expensive",
+			tags:     []string{"db"},
+			expMatch: true,
+		},
+
+		"Parentheses should group the expressions.": {
+			expr:     "(db || cache) && expensive",
+			tags:     []string{"db"},
+			expMatch: false,
+		},
+
+		"Tags with special characters should be parsed.": {
+			expr:     "!!team:infra/db-v1.2_x",
+			tags:     []string{"team:infra/db-v1.2_x"},
+			expMatch: true,
+		},
+
+		"A missing operand should fail.": {
+			expr:   "tls &&",
+			expErr: true,
+		},
+
+		"A missing parenthesis should fail.": {
+			expr:   "(tls || db",
+			expErr: true,
+		},
+
+		"An unexpected parenthesis should fail.": {
+			expr:   "tls)",
+			expErr: true,
+		},
+
+		"Missing operators should fail.": {
+			expr:   "tls db",
+			expErr: true,
+		},
+
+		"Invalid characters should fail.": {
+			expr:   "tls & db",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			expr, err := reload.ParseTagExpression(test.expr)
+
+			if test.expErr {
+				assert.ErrorIs(err, reload.ErrInvalidTagExpression)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expMatch, expr.Match(test.tags))
+				assert.Equal(test.expr, expr.String())
+			}
+		})
+	}
+}
+
+func TestManagerTagSelector(t *testing.T) {
+	tests := map[string]struct {
+		selector    string
+		expReloaded []string
+		expErr      bool
+	}{
+		"Without selector all the reloaders should be reloaded.": {
+			expReloaded: []string{"certs", "db", "config"},
+		},
+
+		"The selector should select the reloaders by tag.": {
+			selector:    "tls && !expensive",
+			expReloaded: []string{"certs"},
+		},
+
+		"The groups without selected reloaders should be skipped.": {
+			selector:    "!tls",
+			expReloaded: []string{"config"},
+		},
+
+		"An invalid selector should fail the reload.": {
+			selector: "tls &&",
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			var reloaded []string
+			reloader := func(name string) reload.Reloader {
+				return reload.ReloaderFunc(func(ctx context.Context, id string) error {
+					reloaded = append(reloaded, name)
+					return nil
+				})
+			}
+			var gotEvents []reload.EventType
+			m := reload.NewManager(reload.WithOrderedReloaders(), reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+				if e.Type == reload.EventReloadStarted || e.Type == reload.EventReloadFinished {
+					gotEvents = append(gotEvents, e.Type)
+				}
+			})))
+			m.Add(0, reloader("certs"), reload.WithReloaderName("certs"), reload.WithReloaderTags("tls"))
+			m.Add(0, reloader("db"), reload.WithReloaderName("db"), reload.WithReloaderTags("db", "tls", "expensive"))
+			m.Add(1, reloader("config"), reload.WithReloaderName("config"))
+
+			// Execute.
+			err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1", TagSelector: test.selector})
+
+			// Check.
+			if test.expErr {
+				assert.ErrorIs(err, reload.ErrInvalidTagExpression)
+			} else {
+				require.NoError(err)
+			}
+			assert.Equal(test.expReloaded, reloaded)
+			assert.Equal([]reload.EventType{reload.EventReloadStarted, reload.EventReloadFinished}, gotEvents)
+		})
+	}
+}
+
+func TestManagerInvalidTagSelectorDropped(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	mr := &testMetricsRecorder{}
+	reloaded := make(chan string)
+	m := reload.NewManager(reload.WithMetricsRecorder(mr))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		reloaded <- id
+		return nil
+	}))
+	triggers := make(chan reload.TriggerEvent)
+	m.On(testTriggerNotifier{c: triggers})
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	triggers <- reload.TriggerEvent{ID: "t1", TagSelector: "tls &&"}
+	triggers <- reload.TriggerEvent{ID: "t2"}
+	got := <-reloaded
+	cancel()
+
+	// Check.
+	assert.NoError(<-runErr)
+	assert.Equal("t2", got)
+	assert.Equal(map[reload.DropReason]int{reload.DropReasonInvalid: 1}, mr.droppedTriggers)
+}
@@ -0,0 +1,199 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// TerminationHandlerConfig is the configuration of the TerminationHandler.
+type TerminationHandlerConfig struct {
+	// GracePeriod is the maximum time that in-flight reloads have to finish
+	// once the termination started, it should be lower than the container
+	// termination grace period.
+	// By default 10s.
+	GracePeriod time.Duration
+	// Signals are the OS signals that will start the termination.
+	// By default SIGTERM and interrupt.
+	Signals []os.Signal
+	// Clock is the clock of the grace period.
+	// By default RealClock.
+	Clock Clock
+}
+
+func (c *TerminationHandlerConfig) defaults() error {
+	if c.GracePeriod <= 0 {
+		c.GracePeriod = 10 * time.Second
+	}
+
+	if len(c.Signals) == 0 {
+		c.Signals = []os.Signal{syscall.SIGTERM, os.Interrupt}
+	}
+
+	if c.Clock == nil {
+		c.Clock = RealClock
+	}
+
+	return nil
+}
+
+// TerminationHandler knows how to stop the reload mechanism cleanly when a
+// container (or any process) is being terminated, so reloads don't race with
+// the termination.
+//
+// When the termination starts (e.g: SIGTERM received):
+//
+//   - The notifiers wrapped with Notifier will stop emitting reload triggers.
+//   - The in-flight reloads will have the grace period to finish.
+//   - The context returned by Context will be cancelled so the manager ends.
+//
+// The handler needs to be registered as a subscriber on the manager to know
+// the in-flight reloads.
+type TerminationHandler struct {
+	cfg         TerminationHandlerConfig
+	terminating chan struct{}
+	once        sync.Once
+
+	mu       sync.Mutex
+	inFlight int
+	idle     chan struct{} // Closed when there are no in-flight reloads.
+}
+
+// NewTerminationHandler returns a new TerminationHandler.
+func NewTerminationHandler(cfg TerminationHandlerConfig) (*TerminationHandler, error) {
+	_ = cfg.defaults()
+
+	idle := make(chan struct{})
+	close(idle)
+
+	return &TerminationHandler{
+		cfg:         cfg,
+		terminating: make(chan struct{}),
+		idle:        idle,
+	}, nil
+}
+
+// Terminate starts the termination manually. Safe to be called multiple times.
+func (t *TerminationHandler) Terminate() {
+	t.once.Do(func() { close(t.terminating) })
+}
+
+// Terminating returns a channel that will be closed when the termination starts.
+func (t *TerminationHandler) Terminating() <-chan struct{} {
+	return t.terminating
+}
+
+// Context returns a context that should be used to run the manager. It will
+// listen to the termination signals and will be cancelled when the
+// termination starts and the in-flight reloads finish or the grace period ends.
+func (t *TerminationHandler) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, t.cfg.Signals...)
+
+	go func() {
+		defer signal.Stop(sigC)
+		defer cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigC:
+			t.Terminate()
+		case <-t.terminating:
+		}
+
+		// Wait for the in-flight reloads.
+		t.mu.Lock()
+		idle := t.idle
+		t.mu.Unlock()
+
+		timer := t.cfg.Clock.NewTimer(t.cfg.GracePeriod)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-idle:
+		case <-timer.C():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// HandleEvent satisfies Subscriber interface.
+func (t *TerminationHandler) HandleEvent(_ context.Context, e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch e.Type {
+	case EventReloadStarted:
+		if t.inFlight == 0 {
+			t.idle = make(chan struct{})
+		}
+		t.inFlight++
+	case EventReloadFinished:
+		if t.inFlight == 0 {
+			return
+		}
+		t.inFlight--
+		if t.inFlight == 0 {
+			close(t.idle)
+		}
+	}
+}
+
+// Notifier wraps a notifier so it stops emitting reload triggers once the
+// termination started.
+func (t *TerminationHandler) Notifier(n Notifier) Notifier {
+	return terminationNotifier{handler: t, notifier: n}
+}
+
+type terminationNotifier struct {
+	handler  *TerminationHandler
+	notifier Notifier
+}
+
+func (t terminationNotifier) Notify(ctx context.Context) (string, error) {
+	te, err := t.NotifyTrigger(ctx)
+	return te.ID, err
+}
+
+func (t terminationNotifier) NotifyTrigger(ctx context.Context) (TriggerEvent, error) {
+	if t.terminated() {
+		<-ctx.Done()
+		return TriggerEvent{}, ctx.Err()
+	}
+
+	notifyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-notifyCtx.Done():
+		case <-t.handler.terminating:
+			cancel()
+		}
+	}()
+
+	te, err := notifyTrigger(notifyCtx, t.notifier)
+
+	// If we are terminating, don't trigger anymore and wait until the manager ends.
+	if t.terminated() {
+		<-ctx.Done()
+		return TriggerEvent{}, ctx.Err()
+	}
+
+	return te, err
+}
+
+func (t terminationNotifier) terminated() bool {
+	select {
+	case <-t.handler.terminating:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,112 @@
+package reload_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+)
+
+func TestTerminationHandlerInFlightReloads(t *testing.T) {
+	tests := map[string]struct {
+		gracePeriod      time.Duration
+		reloadDuration   time.Duration
+		expReloadEnded   bool
+		expReloadCtxDone bool
+	}{
+		"In-flight reloads should finish before the manager ends.": {
+			gracePeriod:    time.Second,
+			reloadDuration: 50 * time.Millisecond,
+			expReloadEnded: true,
+		},
+
+		"In-flight reloads should be cancelled after the grace period.": {
+			gracePeriod:      20 * time.Millisecond,
+			reloadDuration:   time.Second,
+			expReloadCtxDone: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			th, err := reload.NewTerminationHandler(reload.TerminationHandlerConfig{GracePeriod: test.gracePeriod})
+			require.NoError(err)
+
+			var reloadEnded, reloadCtxDone atomic.Bool
+			reloadStarted := make(chan struct{})
+			m := reload.NewManager(reload.WithSubscriber(th))
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				close(reloadStarted)
+				select {
+				case <-time.After(test.reloadDuration):
+					reloadEnded.Store(true)
+				case <-ctx.Done():
+					reloadCtxDone.Store(true)
+				}
+				return nil
+			}))
+			notifierC := make(chan string, 1)
+			m.On(th.Notifier(reload.NotifierChan(notifierC)))
+
+			// Execute.
+			ctx, cancel := th.Context(context.Background())
+			defer cancel()
+			runFinished := make(chan error)
+			go func() { runFinished <- m.Run(ctx) }()
+			notifierC <- "test-id"
+			<-reloadStarted
+			th.Terminate()
+
+			// Check.
+			select {
+			case err := <-runFinished:
+				assert.NoError(err)
+			case <-time.After(500 * time.Millisecond):
+				assert.Fail("manager didn't end")
+			}
+			assert.Equal(test.expReloadEnded, reloadEnded.Load())
+			assert.Equal(test.expReloadCtxDone, reloadCtxDone.Load())
+		})
+	}
+}
+
+func TestTerminationHandlerNotifier(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	th, err := reload.NewTerminationHandler(reload.TerminationHandlerConfig{})
+	require.NoError(err)
+	notifierC := make(chan string, 1)
+	n := th.Notifier(reload.NotifierFunc(func(ctx context.Context) (string, error) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case id := <-notifierC:
+			return id, nil
+		}
+	}))
+
+	// Before termination the notifier should trigger.
+	notifierC <- "test-id"
+	id, err := n.Notify(context.Background())
+	require.NoError(err)
+	assert.Equal("test-id", id)
+
+	// After termination the notifier should not trigger.
+	th.Terminate()
+	notifierC <- "test-id2"
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = n.Notify(ctx)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Len(notifierC, 1)
+}
@@ -0,0 +1,49 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrReloaderTimeout is returned when a reloader doesn't finish before its
+// timeout (see WithReloaderTimeout).
+var ErrReloaderTimeout = errors.New("reloader timeout")
+
+// runReloader runs the reloader reload. When the reloader has a timeout and
+// doesn't finish in time, its context is cancelled and the reload fails without
+// waiting for it, the reloader is tracked as abandoned until it returns.
+func (m *Manager) runReloader(ctx context.Context, r registeredReloader, priority int, t TriggerEvent) error {
+	if r.timeout <= 0 {
+		return r.reloader.Reload(ctx, t.ID)
+	}
+
+	reloadCtx, cancel := context.WithCancel(ctx)
+	start := m.cfg.clock.Now()
+	done := make(chan error, 1)
+	go func() { done <- r.reloader.Reload(reloadCtx, t.ID) }()
+
+	timer := m.cfg.clock.NewTimer(r.timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		cancel()
+		return err
+	case <-timer.C():
+		cancel()
+	}
+
+	// Track the abandoned reloader until it returns, if ever.
+	m.abandoned.add(r.name)
+	m.cfg.metricsRecorder.AddAbandonedReloaders(ctx, r.name, 1)
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		err := <-done
+		m.abandoned.remove(r.name)
+		m.cfg.metricsRecorder.AddAbandonedReloaders(ctx, r.name, -1)
+		m.emit(ctx, Event{Type: EventAbandonedReloaderReturned, Trigger: t, Priority: priority, Reloader: r.name, Duration: m.cfg.clock.Now().Sub(start), Err: err})
+	}()
+
+	return fmt.Errorf("%w after %s", ErrReloaderTimeout, r.timeout)
+}
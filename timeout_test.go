@@ -0,0 +1,106 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerReloaderTimeout(t *testing.T) {
+	tests := map[string]struct {
+		returnErr error
+		expTrail  []string
+	}{
+		"An abandoned reloader that returns should be reported.": {
+			expTrail: []string{
+				"trigger_received id=t1 source=manual",
+				"reload_started id=t1",
+				"group_started id=t1 priority=0",
+				"reloader_finished id=t1 priority=0 reloader=stuck err=reloader timeout after 1s",
+				`group_finished id=t1 priority=0 err=reloader "stuck" (1/1): reloader timeout after 1s`,
+				`reload_finished id=t1 err=reload "t1" failed: group (priority 0): reloader "stuck" (1/1): reloader timeout after 1s`,
+				"abandoned_reloader_returned id=t1 priority=0 reloader=stuck",
+			},
+		},
+
+		"An abandoned reloader that returns an error should be reported with the error.": {
+			returnErr: fmt.Errorf("something"),
+			expTrail: []string{
+				"trigger_received id=t1 source=manual",
+				"reload_started id=t1",
+				"group_started id=t1 priority=0",
+				"reloader_finished id=t1 priority=0 reloader=stuck err=reloader timeout after 1s",
+				`group_finished id=t1 priority=0 err=reloader "stuck" (1/1): reloader timeout after 1s`,
+				`reload_finished id=t1 err=reload "t1" failed: group (priority 0): reloader "stuck" (1/1): reloader timeout after 1s`,
+				"abandoned_reloader_returned id=t1 priority=0 reloader=stuck err=something",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Now())
+			rec := reloadtest.NewRecorder()
+			returned := make(chan struct{})
+			m := reload.NewManager(
+				reload.WithClock(clock),
+				reload.WithSubscriber(rec),
+				reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+					if e.Type == reload.EventAbandonedReloaderReturned {
+						close(returned)
+					}
+				})),
+			)
+			release := make(chan struct{})
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				<-release // Ignores the context cancellation.
+				return test.returnErr
+			}), reload.WithReloaderName("stuck"), reload.WithReloaderTimeout(time.Second))
+
+			// Execute.
+			reloadErr := make(chan error)
+			go func() { reloadErr <- m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"}) }()
+			require.True(clock.WaitWaiters(1, time.Second))
+			clock.Advance(time.Second)
+			err := <-reloadErr
+
+			// Check.
+			assert.ErrorIs(err, reload.ErrReloaderTimeout)
+			assert.Equal([]string{"stuck"}, m.Status().AbandonedReloaders)
+
+			close(release)
+			<-returned
+			assert.Empty(m.Status().AbandonedReloaders)
+			rec.AssertTrail(t, test.expTrail...)
+		})
+	}
+}
+
+func TestManagerReloaderTimeoutNotExceeded(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	rec := reloadtest.NewRecorder()
+	m := reload.NewManager(reload.WithSubscriber(rec))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }),
+		reload.WithReloaderName("fast"), reload.WithReloaderTimeout(time.Minute))
+
+	// Execute.
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	assert.EqualError(err, `reload "t1" failed: group (priority 0): reloader "fast" (1/1): something`)
+	assert.NotErrorIs(err, reload.ErrReloaderTimeout)
+	assert.Empty(m.Status().AbandonedReloaders)
+}
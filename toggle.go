@@ -0,0 +1,93 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownReloader is returned when enabling or disabling a reloader that
+// doesn't exist.
+var ErrUnknownReloader = errors.New("unknown reloader")
+
+// reloaderToggles tracks the reloaders disabled at runtime.
+type reloaderToggles struct {
+	mu       sync.RWMutex
+	disabled map[string]bool
+}
+
+func newReloaderToggles() *reloaderToggles {
+	return &reloaderToggles{disabled: map[string]bool{}}
+}
+
+func (r *reloaderToggles) set(name string, disabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if disabled {
+		r.disabled[name] = true
+		return
+	}
+	delete(r.disabled, name)
+}
+
+func (r *reloaderToggles) isDisabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.disabled[name]
+}
+
+// names returns the sorted names of the disabled reloaders.
+func (r *reloaderToggles) names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var names []string
+	for name := range r.disabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// DisableReloader disables the reloaders with the name at runtime, so they are
+// skipped (see EventReloaderSkipped and ReloaderDisabled) on the next reload
+// processes until they are enabled again, e.g: to bypass a known broken
+// reloader during an incident. The disabled reloaders are reported on
+// Status.DisabledReloaders.
+func (m *Manager) DisableReloader(ctx context.Context, reloader string) error {
+	return m.toggleReloader(reloader, true)
+}
+
+// EnableReloader enables the reloaders with the name disabled with
+// DisableReloader, they are reloaded again on the next reload process.
+func (m *Manager) EnableReloader(ctx context.Context, reloader string) error {
+	return m.toggleReloader(reloader, false)
+}
+
+func (m *Manager) toggleReloader(reloader string, disabled bool) error {
+	for _, rg := range m.reloaders {
+		for _, r := range rg.reloaders {
+			if r.name == reloader {
+				m.toggles.set(reloader, disabled)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w %q", ErrUnknownReloader, reloader)
+}
+
+// skipDisabled returns if the reloader needs to be skipped because it's
+// disabled.
+func (m *Manager) skipDisabled(ctx context.Context, r registeredReloader, priority int, t TriggerEvent) bool {
+	if !m.toggles.isDisabled(r.name) {
+		return false
+	}
+
+	m.emit(ctx, Event{Type: EventReloaderSkipped, Trigger: t, Priority: priority, Reloader: r.name})
+	return true
+}
@@ -0,0 +1,98 @@
+package reload_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestManagerToggleReloader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	rec := reloadtest.NewRecorder()
+	m := reload.NewManager(reload.WithSubscriber(rec))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		if id == "t3" {
+			return fmt.Errorf("something")
+		}
+		return nil
+	}), reload.WithReloaderName("config"))
+	m.Add(1, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }), reload.WithReloaderName("broken"))
+	trigger := func(id string) error { return m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: id}) }
+
+	// The broken reloader fails the reloads until disabled.
+	require.Error(trigger("t1"))
+	require.NoError(m.DisableReloader(context.TODO(), "broken"))
+	assert.Equal([]string{"broken"}, m.Status().DisabledReloaders)
+	require.NoError(trigger("t2"))
+
+	// The disabled reloaders are reported.
+	err := trigger("t3")
+	var rErr *reload.ReloadError
+	require.True(errors.As(err, &rErr))
+	assert.Equal([]reload.ReloaderStatus{reload.ReloaderFailed, reload.ReloaderSkipped}, reloaderStatuses(rErr.Reloaders))
+
+	// Once enabled the reloader is reloaded again.
+	require.NoError(m.EnableReloader(context.TODO(), "broken"))
+	assert.Empty(m.Status().DisabledReloaders)
+	require.Error(trigger("t4"))
+
+	// Unknown reloaders can't be toggled.
+	err = m.DisableReloader(context.TODO(), "nope")
+	assert.ErrorIs(err, reload.ErrUnknownReloader)
+	assert.Empty(m.Status().DisabledReloaders)
+
+	// Check.
+	exp := []string{
+		"reloader_finished id=t1 priority=0 reloader=config",
+		"reloader_finished id=t1 priority=1 reloader=broken err=something",
+		"reloader_finished id=t2 priority=0 reloader=config",
+		"reloader_skipped id=t2 priority=1 reloader=broken",
+		"reloader_finished id=t3 priority=0 reloader=config err=something",
+		"reloader_finished id=t4 priority=0 reloader=config",
+		"reloader_finished id=t4 priority=1 reloader=broken err=something",
+	}
+	var got []string
+	for _, e := range rec.Events() {
+		if e.Type == reload.EventReloaderFinished || e.Type == reload.EventReloaderSkipped {
+			got = append(got, reloadtest.TrailLine(e))
+		}
+	}
+	assert.Equal(exp, got)
+}
+
+func TestManagerDisabledReloaderReport(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	m := reload.NewManager()
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("disabled"))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return fmt.Errorf("something") }), reload.WithReloaderName("config"))
+	require.NoError(m.DisableReloader(context.TODO(), "disabled"))
+
+	// Execute.
+	err := m.TriggerReload(context.TODO(), reload.TriggerEvent{ID: "t1"})
+
+	// Check.
+	var rErr *reload.ReloadError
+	require.True(errors.As(err, &rErr))
+	assert.Equal([]reload.ReloaderStatus{reload.ReloaderDisabled, reload.ReloaderFailed}, reloaderStatuses(rErr.Reloaders))
+}
+
+func reloaderStatuses(reports []reload.ReloaderReport) []reload.ReloaderStatus {
+	var statuses []reload.ReloaderStatus
+	for _, r := range reports {
+		statuses = append(statuses, r.Status)
+	}
+	return statuses
+}
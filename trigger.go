@@ -0,0 +1,143 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// ReservedMetadataPrefix is the prefix of the trigger metadata keys reserved
+// for the reload mechanism (e.g: RollbackGenerationMetadataKey), the
+// integrations that receive triggers from untrusted clients remove them.
+const ReservedMetadataPrefix = "reload."
+
+// TriggerEvent is the structured information of a reload trigger.
+//
+// Regular notifiers only return an ID, notifiers that have more information
+// about the trigger (e.g: the files that changed) can implement TriggerNotifier
+// to return this structured event instead.
+type TriggerEvent struct {
+	// ID is the ID of the trigger, it's the same that will receive the reloaders.
+	ID string
+	// Reason is the human readable reason of the trigger (e.g: `deploy-123` or
+	// `certificate renewed`), it's set on the events, audit records, metrics
+	// exemplars and reload errors, so the reloads can be explained from any of
+	// them.
+	Reason string
+	// Source is the name of the notifier that triggered the reload, the manager
+	// sets it based on the notifier registration.
+	Source string
+	// Paths are the paths (e.g: files) that changed and caused the trigger, if any.
+	Paths []string
+	// Keys are the configuration keys (e.g: feature flags) that changed and
+	// caused the trigger, if known.
+	Keys []string
+	// Metadata is additional information of the trigger set by the notifier
+	// (e.g: the version of a pushed configuration).
+	Metadata map[string]string
+	// Urgency is the urgency of the trigger, if empty TriggerUrgencyNormal.
+	Urgency TriggerUrgency
+	// TagSelector is a tag expression (see TagExpression) that selects the
+	// reloaders reloaded by the trigger by their tags (see WithReloaderTags),
+	// e.g: `tls && !expensive`. If empty all the reloaders are reloaded.
+	TagSelector string
+}
+
+// TriggerUrgency is the urgency of a trigger, it's honored by the manager while
+// the trigger waits to be reloaded.
+type TriggerUrgency string
+
+const (
+	// TriggerUrgencyNormal is the urgency of the regular triggers.
+	TriggerUrgencyNormal TriggerUrgency = ""
+	// TriggerUrgencyUrgent triggers (e.g: admin triggers) bypass the notifier
+	// rate limit (see WithNotifierRateLimit) and the reload window (see
+	// WithReloadWindow), and are not dropped when the queue is full with
+	// QueueOverflowDropNewest, the oldest queued trigger is dropped instead.
+	// UrgencyScheduler reloads them before the rest.
+	TriggerUrgencyUrgent TriggerUrgency = "urgent"
+	// TriggerUrgencyBackground triggers (e.g: periodic resyncs) are reloaded
+	// after the rest by UrgencyScheduler.
+	TriggerUrgencyBackground TriggerUrgency = "background"
+)
+
+// level returns the urgency as a comparable level, the higher the more urgent.
+func (t TriggerUrgency) level() int {
+	switch t {
+	case TriggerUrgencyUrgent:
+		return 1
+	case TriggerUrgencyBackground:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// TriggerNotifier is a Notifier that knows how to return structured information
+// of the trigger.
+//
+// The manager will use NotifyTrigger instead of Notify on the notifiers that
+// implement this interface.
+type TriggerNotifier interface {
+	Notifier
+	NotifyTrigger(ctx context.Context) (TriggerEvent, error)
+}
+
+type contextKey int
+
+const (
+	triggerEventContextKey contextKey = iota
+	generationContextKey
+	heartbeatContextKey
+	rollbackGenerationContextKey
+	clockContextKey
+)
+
+// TriggerEventFromContext returns the structured trigger that started the
+// reload process, the manager sets this on the context received by the reloaders.
+func TriggerEventFromContext(ctx context.Context) (TriggerEvent, bool) {
+	t, ok := ctx.Value(triggerEventContextKey).(TriggerEvent)
+	return t, ok
+}
+
+func contextWithTriggerEvent(ctx context.Context, t TriggerEvent) context.Context {
+	return context.WithValue(ctx, triggerEventContextKey, t)
+}
+
+// triggerLabel returns the trigger ID, with the reason if any, to identify the
+// trigger on the errors.
+func triggerLabel(t TriggerEvent) string {
+	if t.Reason == "" {
+		return strconv.Quote(t.ID)
+	}
+
+	return fmt.Sprintf("%q (reason: %s)", t.ID, t.Reason)
+}
+
+// mergeMetadata returns a new metadata with the base metadata overridden by
+// the values of md, it doesn't allocate when there is nothing to merge.
+func mergeMetadata(base, md map[string]string) map[string]string {
+	if len(base) == 0 {
+		return md
+	}
+
+	res := make(map[string]string, len(base)+len(md))
+	for k, v := range base {
+		res[k] = v
+	}
+	for k, v := range md {
+		res[k] = v
+	}
+	return res
+}
+
+// notifyTrigger calls the notifier and returns the structured trigger
+// regardless of the notifier kind.
+func notifyTrigger(ctx context.Context, n Notifier) (TriggerEvent, error) {
+	if tn, ok := n.(TriggerNotifier); ok {
+		return tn.NotifyTrigger(ctx)
+	}
+
+	id, err := n.Notify(ctx)
+	return TriggerEvent{ID: id}, err
+}
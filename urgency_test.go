@@ -0,0 +1,228 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestUrgencyScheduler(t *testing.T) {
+	tests := map[string]struct {
+		urgencies []reload.TriggerUrgency
+		expNext   int
+	}{
+		"The oldest trigger should be scheduled with the same urgency.": {
+			urgencies: []reload.TriggerUrgency{reload.TriggerUrgencyNormal, reload.TriggerUrgencyNormal},
+			expNext:   0,
+		},
+
+		"Urgent triggers should be scheduled first.": {
+			urgencies: []reload.TriggerUrgency{reload.TriggerUrgencyNormal, reload.TriggerUrgencyUrgent, reload.TriggerUrgencyUrgent},
+			expNext:   1,
+		},
+
+		"Background triggers should be scheduled last.": {
+			urgencies: []reload.TriggerUrgency{reload.TriggerUrgencyBackground, reload.TriggerUrgencyNormal},
+			expNext:   1,
+		},
+
+		"Background triggers should be scheduled when only background triggers are queued.": {
+			urgencies: []reload.TriggerUrgency{reload.TriggerUrgencyBackground, reload.TriggerUrgencyBackground},
+			expNext:   0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			var queued []reload.QueuedTrigger
+			for _, u := range test.urgencies {
+				queued = append(queued, reload.QueuedTrigger{Trigger: reload.TriggerEvent{Urgency: u}})
+			}
+
+			// Execute.
+			s := reload.UrgencyScheduler().Schedule(queued)
+
+			// Check.
+			assert.Equal(reload.Schedule{Next: test.expNext}, s)
+		})
+	}
+}
+
+func TestManagerUrgentTriggerRateLimit(t *testing.T) {
+	tests := map[string]struct {
+		urgency reload.TriggerUrgency
+		expIDs  []string
+	}{
+		"Normal triggers exceeding the limit should be dropped.": {
+			urgency: reload.TriggerUrgencyNormal,
+			expIDs:  []string{"t1"},
+		},
+
+		"Background triggers exceeding the limit should be dropped.": {
+			urgency: reload.TriggerUrgencyBackground,
+			expIDs:  []string{"t1"},
+		},
+
+		"Urgent triggers should bypass the limit.": {
+			urgency: reload.TriggerUrgencyUrgent,
+			expIDs:  []string{"t1", "t2", "t3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Now())
+			m := reload.NewManager(reload.WithClock(clock))
+			var gotIDs []string
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotIDs = append(gotIDs, id)
+				return nil
+			}))
+			notifierC := make(chan string)
+			m.On(reload.NotifierChan(notifierC),
+				reload.WithNotifierRateLimit(reload.NotifierRateLimit{Every: time.Minute}),
+				reload.WithNotifierUrgency(test.urgency),
+			)
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runErr := make(chan error)
+			go func() { runErr <- m.Run(ctx) }()
+			for _, id := range []string{"t1", "t2", "t3"} {
+				notifierC <- id
+				time.Sleep(5 * time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			// Check.
+			require.NoError(<-runErr)
+			assert.Equal(test.expIDs, gotIDs)
+		})
+	}
+}
+
+func TestManagerUrgentTriggerReloadWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	rec := reloadtest.NewRecorder()
+	deferred := make(chan struct{}, 1)
+	finished := make(chan struct{})
+	m := reload.NewManager(
+		reload.WithClock(clock),
+		reload.WithReloadWindow(reload.DailyReloadWindow{Start: 2 * time.Hour, End: 5 * time.Hour}),
+		reload.WithSubscriber(rec),
+		reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+			switch e.Type {
+			case reload.EventReloadDeferred:
+				deferred <- struct{}{}
+			case reload.EventReloadFinished:
+				close(finished)
+			}
+		})),
+	)
+	var reloaded time.Time
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		reloaded = clock.Now()
+		return nil
+	}), reload.WithReloaderName("r0"))
+	fileC := make(chan string)
+	m.On(reload.NotifierChan(fileC), reload.WithNotifierName("file"))
+	adminC := make(chan string)
+	m.On(reload.NotifierChan(adminC), reload.WithNotifierName("admin"), reload.WithNotifierUrgency(reload.TriggerUrgencyUrgent))
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	fileC <- "t1"
+	<-deferred
+	adminC <- "t2"
+	<-finished
+	cancel()
+
+	// Check.
+	assert.NoError(<-runErr)
+	assert.Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), reloaded)
+	rec.AssertTrail(t,
+		"trigger_received id=t1 source=file",
+		"reload_deferred id=t1",
+		"trigger_received id=t2 source=admin",
+		"reload_skipped id=t1",
+		"reload_started id=t2",
+		"group_started id=t2 priority=0",
+		"reloader_finished id=t2 priority=0 reloader=r0",
+		"group_finished id=t2 priority=0",
+		"reload_finished id=t2",
+	)
+}
+
+func TestManagerNotifierTrustedUrgency(t *testing.T) {
+	tests := map[string]struct {
+		opts   []reload.NotifierOption
+		expIDs []string
+	}{
+		"The urgency set by an untrusted notifier should be ignored.": {
+			expIDs: []string{"t1"},
+		},
+
+		"The urgency set by an untrusted notifier should not override the registration one.": {
+			opts:   []reload.NotifierOption{reload.WithNotifierUrgency(reload.TriggerUrgencyBackground)},
+			expIDs: []string{"t1"},
+		},
+
+		"The urgency set by a trusted notifier should be honored.": {
+			opts:   []reload.NotifierOption{reload.WithNotifierTrustedUrgency()},
+			expIDs: []string{"t1", "t2", "t3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare.
+			clock := reloadtest.NewClock(time.Now())
+			m := reload.NewManager(reload.WithClock(clock))
+			var gotIDs []string
+			m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+				gotIDs = append(gotIDs, id)
+				return nil
+			}))
+			notifierC := make(chan reload.TriggerEvent)
+			opts := append([]reload.NotifierOption{reload.WithNotifierRateLimit(reload.NotifierRateLimit{Every: time.Minute})}, test.opts...)
+			m.On(testTriggerNotifier{c: notifierC}, opts...)
+
+			// Execute.
+			ctx, cancel := context.WithCancel(context.Background())
+			runErr := make(chan error)
+			go func() { runErr <- m.Run(ctx) }()
+			for _, id := range []string{"t1", "t2", "t3"} {
+				notifierC <- reload.TriggerEvent{ID: id, Urgency: reload.TriggerUrgencyUrgent}
+				time.Sleep(5 * time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			// Check.
+			require.NoError(<-runErr)
+			assert.Equal(test.expIDs, gotIDs)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrValidationFailed is returned by Manager.Validate when any of the
+// reloaders validation fails.
+var ErrValidationFailed = errors.New("validation failed")
+
+// Validator is implemented by the reloaders that can validate the new
+// configuration without applying it (e.g: `reloadconfig` loaders), so the
+// configuration changes can be pre-flighted with Manager.Validate.
+type Validator interface {
+	// Validate validates what the reloader would apply on a reload with the
+	// same trigger, without changing any state.
+	Validate(ctx context.Context, id string) error
+}
+
+// ValidatorFunc is a helper to create validators from functions.
+type ValidatorFunc func(ctx context.Context, id string) error
+
+// Validate satisfies Validator interface.
+func (v ValidatorFunc) Validate(ctx context.Context, id string) error { return v(ctx, id) }
+
+// Validate is a dry-run of a reload process: it runs the validation of the
+// reloaders that would be reloaded by the trigger and implement Validator, in
+// execution order, without reloading them. The trigger source is used to
+// select the reloaders, if empty, `manual` will be used.
+//
+// Validate doesn't change the manager state (no events, metrics, audits or
+// generations), and it runs even if other reload is in progress. The reloaders
+// that don't implement Validator are reported as skipped. If any validation
+// fails it returns an error wrapping ErrValidationFailed, the reports have
+// the result of every validation.
+func (m *Manager) Validate(ctx context.Context, t TriggerEvent) ([]ReloaderReport, error) {
+	if t.Source == "" {
+		t.Source = "manual"
+	}
+	ctx = contextWithTriggerEvent(ctx, t)
+
+	plan, err := m.reloadPlan(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []ReloaderReport
+	var errs []error
+	for _, rg := range plan {
+		for _, r := range rg.reloaders {
+			report := ReloaderReport{Name: r.name, Priority: rg.priority, Status: ReloaderSkipped}
+			v, ok := r.reloader.(Validator)
+			if !ok {
+				reports = append(reports, report)
+				continue
+			}
+
+			start := m.cfg.clock.Now()
+			err := v.Validate(ctx, t.ID)
+			report.Duration = m.cfg.clock.Now().Sub(start)
+			report.Status = ReloaderSucceeded
+			if err != nil {
+				report.Status = ReloaderFailed
+				report.Err = err
+				errs = append(errs, fmt.Errorf("%q reloader: %w", r.name, err))
+			}
+			reports = append(reports, report)
+		}
+	}
+
+	if len(errs) > 0 {
+		return reports, fmt.Errorf("%w: %w", ErrValidationFailed, errors.Join(errs...))
+	}
+
+	return reports, nil
+}
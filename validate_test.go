@@ -0,0 +1,90 @@
+package reload_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+// validatingReloader is a reloader that can be validated.
+type validatingReloader struct {
+	reload.ReloaderFunc
+	reload.ValidatorFunc
+}
+
+func TestManagerValidate(t *testing.T) {
+	tests := map[string]struct {
+		trigger    reload.TriggerEvent
+		validate   func(ctx context.Context, id string) error
+		expReports []reload.ReloaderReport
+		expErr     error
+	}{
+		"A valid configuration should report the validated and skipped reloaders.": {
+			trigger: reload.TriggerEvent{ID: "t1"},
+			validate: func(ctx context.Context, id string) error {
+				return nil
+			},
+			expReports: []reload.ReloaderReport{
+				{Name: "config", Priority: 0, Status: reload.ReloaderSucceeded},
+				{Name: "cache", Priority: 1, Status: reload.ReloaderSkipped},
+			},
+		},
+
+		"An invalid configuration should fail with the reloader validation error.": {
+			trigger: reload.TriggerEvent{ID: "t1"},
+			validate: func(ctx context.Context, id string) error {
+				return fmt.Errorf("something")
+			},
+			expReports: []reload.ReloaderReport{
+				{Name: "config", Priority: 0, Status: reload.ReloaderFailed, Err: fmt.Errorf("something")},
+				{Name: "cache", Priority: 1, Status: reload.ReloaderSkipped},
+			},
+			expErr: reload.ErrValidationFailed,
+		},
+
+		"The validators should receive the trigger.": {
+			trigger: reload.TriggerEvent{ID: "t1", Source: "file"},
+			validate: func(ctx context.Context, id string) error {
+				t, _ := reload.TriggerEventFromContext(ctx)
+				if id != "t1" || t.Source != "file" {
+					return fmt.Errorf("unexpected trigger")
+				}
+				return nil
+			},
+			expReports: []reload.ReloaderReport{
+				{Name: "config", Priority: 0, Status: reload.ReloaderSucceeded},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare.
+			reloads := 0
+			m := reload.NewManager(reload.WithClock(reloadtest.NewClock(time.Now())))
+			m.Add(0, validatingReloader{
+				ReloaderFunc:  func(ctx context.Context, id string) error { reloads++; return nil },
+				ValidatorFunc: test.validate,
+			}, reload.WithReloaderName("config"))
+			m.Add(1, reload.ReloaderFunc(func(ctx context.Context, id string) error { reloads++; return nil }),
+				reload.WithReloaderName("cache"), reload.WithTriggerSources("manual"))
+
+			// Execute.
+			reports, err := m.Validate(context.TODO(), test.trigger)
+
+			// Check.
+			assert.ErrorIs(err, test.expErr)
+			assert.Equal(test.expReports, reports)
+			assert.Zero(reloads)
+			assert.Zero(m.Status().Generation)
+		})
+	}
+}
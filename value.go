@@ -0,0 +1,36 @@
+package reload
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Value is a holder of a reloadable value safe for concurrent use, the
+// reloaders set the new values and the users get the current one on every use
+// instead of storing it.
+type Value[T any] struct {
+	v atomic.Pointer[T]
+}
+
+// NewValue returns a new Value with an initial value.
+func NewValue[T any](v T) *Value[T] {
+	val := &Value[T]{}
+	val.v.Store(&v)
+	return val
+}
+
+// Get returns the current value.
+func (v *Value[T]) Get() T {
+	p := v.v.Load()
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
+// Set sets a new value and returns if the value changed.
+func (v *Value[T]) Set(n T) (changed bool) {
+	old := v.v.Swap(&n)
+	return old == nil || !reflect.DeepEqual(*old, n)
+}
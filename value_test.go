@@ -0,0 +1,22 @@
+package reload_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/reload"
+)
+
+func TestValue(t *testing.T) {
+	assert := assert.New(t)
+
+	var zero reload.Value[[]string]
+	assert.Nil(zero.Get())
+
+	v := reload.NewValue([]string{"a"})
+	assert.Equal([]string{"a"}, v.Get())
+	assert.False(v.Set([]string{"a"}))
+	assert.True(v.Set([]string{"a", "b"}))
+	assert.Equal([]string{"a", "b"}, v.Get())
+}
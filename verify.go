@@ -0,0 +1,226 @@
+package reload
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TriggerSignatureMetadataKey is the trigger metadata key with the trigger
+// signature checked by the signature verifiers.
+const TriggerSignatureMetadataKey = "reload.signature"
+
+// TriggerSignedAtMetadataKey is the trigger metadata key with the signing time
+// (RFC 3339) of the signed triggers, the signature verifiers reject the
+// triggers signed more than TriggerSignatureMaxAge ago.
+const TriggerSignedAtMetadataKey = "reload.signed-at"
+
+// TriggerNonceMetadataKey is the trigger metadata key with the random nonce of
+// the signed triggers, the signature verifiers reject the nonces already seen
+// so the signed triggers can't be replayed.
+const TriggerNonceMetadataKey = "reload.nonce"
+
+// TriggerSignatureMaxAge is the maximum age of the signed triggers accepted by
+// the signature verifiers, the clocks of the signers and the verifiers can't
+// drift more than this.
+const TriggerSignatureMaxAge = 5 * time.Minute
+
+// ErrInvalidTriggerSignature is returned by the signature verifiers when the
+// trigger signature is missing or invalid.
+var ErrInvalidTriggerSignature = errors.New("invalid trigger signature")
+
+// TriggerVerifier verifies the triggers of a notifier before they are queued
+// (see WithNotifierVerifier).
+type TriggerVerifier interface {
+	VerifyTrigger(ctx context.Context, t TriggerEvent) error
+}
+
+// TriggerVerifierFunc is a helper to create trigger verifiers from functions.
+type TriggerVerifierFunc func(ctx context.Context, t TriggerEvent) error
+
+// VerifyTrigger satisfies TriggerVerifier interface.
+func (f TriggerVerifierFunc) VerifyTrigger(ctx context.Context, t TriggerEvent) error {
+	return f(ctx, t)
+}
+
+// TriggerSigningPayload returns the canonical payload of the trigger that is
+// signed: the ID, reason, paths, keys, urgency, tag selector and metadata
+// without the signature (including the signing time and nonce), so the
+// routing and policy of a signed trigger can't be changed. The source is not
+// signed as the manager sets it.
+func TriggerSigningPayload(t TriggerEvent) []byte {
+	md := make(map[string]string, len(t.Metadata))
+	for k, v := range t.Metadata {
+		if k != TriggerSignatureMetadataKey {
+			md[k] = v
+		}
+	}
+
+	// Map keys are sorted by the JSON encoder, so the payload is deterministic.
+	payload, _ := json.Marshal(struct {
+		ID          string            `json:"id"`
+		Reason      string            `json:"reason"`
+		Paths       []string          `json:"paths"`
+		Keys        []string          `json:"keys"`
+		Urgency     TriggerUrgency    `json:"urgency"`
+		TagSelector string            `json:"tag_selector"`
+		Metadata    map[string]string `json:"metadata"`
+	}{ID: t.ID, Reason: t.Reason, Paths: t.Paths, Keys: t.Keys, Urgency: t.Urgency, TagSelector: t.TagSelector, Metadata: md})
+
+	return payload
+}
+
+// withSigningNonce returns a copy of the trigger with the signing time and a
+// random nonce set on its metadata, ready to be signed.
+func withSigningNonce(now time.Time, t TriggerEvent) TriggerEvent {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+
+	md := make(map[string]string, len(t.Metadata)+3)
+	for k, v := range t.Metadata {
+		md[k] = v
+	}
+	md[TriggerSignedAtMetadataKey] = now.UTC().Format(time.RFC3339Nano)
+	md[TriggerNonceMetadataKey] = hex.EncodeToString(nonce)
+	t.Metadata = md
+
+	return t
+}
+
+// withSignature returns the trigger with the signature set on its metadata,
+// the trigger metadata needs to be a copy (see withSigningNonce).
+func withSignature(t TriggerEvent, sig []byte) TriggerEvent {
+	t.Metadata[TriggerSignatureMetadataKey] = base64.StdEncoding.EncodeToString(sig)
+	return t
+}
+
+// triggerSignature returns the decoded signature of the trigger.
+func triggerSignature(t TriggerEvent) ([]byte, error) {
+	s, ok := t.Metadata[TriggerSignatureMetadataKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing signature", ErrInvalidTriggerSignature)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature encoding", ErrInvalidTriggerSignature)
+	}
+
+	return sig, nil
+}
+
+// SignTriggerHMAC returns the trigger signed with HMAC-SHA256 using the key,
+// with the signing time (see ClockFromContext) and a random nonce, to be
+// verified with NewHMACTriggerVerifier.
+func SignTriggerHMAC(ctx context.Context, key []byte, t TriggerEvent) TriggerEvent {
+	t = withSigningNonce(ClockFromContext(ctx).Now(), t)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(TriggerSigningPayload(t))
+	return withSignature(t, mac.Sum(nil))
+}
+
+// NewHMACTriggerVerifier returns a verifier of the triggers signed with
+// HMAC-SHA256 using a shared key (see SignTriggerHMAC). The stale (see
+// TriggerSignatureMaxAge, using the context clock, see ClockFromContext) and
+// already verified signed triggers are rejected.
+func NewHMACTriggerVerifier(key []byte) TriggerVerifier {
+	replays := newReplayGuard()
+	return TriggerVerifierFunc(func(ctx context.Context, t TriggerEvent) error {
+		sig, err := triggerSignature(t)
+		if err != nil {
+			return err
+		}
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write(TriggerSigningPayload(t))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return ErrInvalidTriggerSignature
+		}
+
+		return replays.check(ClockFromContext(ctx).Now(), t)
+	})
+}
+
+// SignTriggerEd25519 returns the trigger signed with the Ed25519 private key,
+// with the signing time (see ClockFromContext) and a random nonce, to be
+// verified with NewEd25519TriggerVerifier.
+func SignTriggerEd25519(ctx context.Context, key ed25519.PrivateKey, t TriggerEvent) TriggerEvent {
+	t = withSigningNonce(ClockFromContext(ctx).Now(), t)
+	return withSignature(t, ed25519.Sign(key, TriggerSigningPayload(t)))
+}
+
+// NewEd25519TriggerVerifier returns a verifier of the triggers signed with the
+// Ed25519 private key of any of the public keys (see SignTriggerEd25519), so
+// the keys can be rotated. The stale (see TriggerSignatureMaxAge, using the
+// context clock, see ClockFromContext) and already verified signed triggers
+// are rejected.
+func NewEd25519TriggerVerifier(keys ...ed25519.PublicKey) TriggerVerifier {
+	replays := newReplayGuard()
+	return TriggerVerifierFunc(func(ctx context.Context, t TriggerEvent) error {
+		sig, err := triggerSignature(t)
+		if err != nil {
+			return err
+		}
+
+		payload := TriggerSigningPayload(t)
+		for _, k := range keys {
+			if ed25519.Verify(k, payload, sig) {
+				return replays.check(ClockFromContext(ctx).Now(), t)
+			}
+		}
+
+		return ErrInvalidTriggerSignature
+	})
+}
+
+// replayGuard rejects the stale and already seen signed triggers, it remembers
+// the nonces until they are stale.
+type replayGuard struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+func newReplayGuard() *replayGuard {
+	return &replayGuard{nonces: map[string]time.Time{}}
+}
+
+// check checks the signing time and nonce of a trigger with a valid signature
+// at now.
+func (r *replayGuard) check(now time.Time, t TriggerEvent) error {
+	signedAt, err := time.Parse(time.RFC3339Nano, t.Metadata[TriggerSignedAtMetadataKey])
+	if err != nil {
+		return fmt.Errorf("%w: missing or invalid signing time", ErrInvalidTriggerSignature)
+	}
+	nonce := t.Metadata[TriggerNonceMetadataKey]
+	if nonce == "" {
+		return fmt.Errorf("%w: missing nonce", ErrInvalidTriggerSignature)
+	}
+
+	if now.Sub(signedAt) > TriggerSignatureMaxAge || signedAt.Sub(now) > TriggerSignatureMaxAge {
+		return fmt.Errorf("%w: stale signature", ErrInvalidTriggerSignature)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n, at := range r.nonces {
+		if now.Sub(at) > TriggerSignatureMaxAge {
+			delete(r.nonces, n)
+		}
+	}
+	if _, ok := r.nonces[nonce]; ok {
+		return fmt.Errorf("%w: replayed signature", ErrInvalidTriggerSignature)
+	}
+	r.nonces[nonce] = signedAt
+
+	return nil
+}
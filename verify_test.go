@@ -0,0 +1,278 @@
+package reload_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestTriggerVerifiers(t *testing.T) {
+	hmacKey := []byte("secret")
+	pub1, priv1, _ := ed25519.GenerateKey(nil)
+	pub2, priv2, _ := ed25519.GenerateKey(nil)
+	_, privOther, _ := ed25519.GenerateKey(nil)
+	trigger := reload.TriggerEvent{ID: "t1", Keys: []string{"a"}, Metadata: map[string]string{"version": "v2"}}
+	replayVerifier := reload.NewHMACTriggerVerifier(hmacKey)
+	signHMAC := func(md map[string]string) reload.TriggerEvent {
+		t := reload.TriggerEvent{ID: "t1", Metadata: md}
+		mac := hmac.New(sha256.New, hmacKey)
+		_, _ = mac.Write(reload.TriggerSigningPayload(t))
+		t.Metadata[reload.TriggerSignatureMetadataKey] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		return t
+	}
+
+	tests := map[string]struct {
+		verifier reload.TriggerVerifier
+		trigger  func() reload.TriggerEvent
+		expErr   bool
+	}{
+		"A trigger signed with the HMAC key should be valid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger:  func() reload.TriggerEvent { return reload.SignTriggerHMAC(context.TODO(), hmacKey, trigger) },
+		},
+
+		"A trigger signed with other HMAC key should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger:  func() reload.TriggerEvent { return reload.SignTriggerHMAC(context.TODO(), []byte("other"), trigger) },
+			expErr:   true,
+		},
+
+		"A tampered HMAC signed trigger should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				t := reload.SignTriggerHMAC(context.TODO(), hmacKey, trigger)
+				t.Metadata["version"] = "v3"
+				return t
+			},
+			expErr: true,
+		},
+
+		"A HMAC signed trigger with a tampered reason should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				t := reload.SignTriggerHMAC(context.TODO(), hmacKey, trigger)
+				t.Reason = "other"
+				return t
+			},
+			expErr: true,
+		},
+
+		"A HMAC signed trigger with a tampered urgency should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				t := reload.SignTriggerHMAC(context.TODO(), hmacKey, trigger)
+				t.Urgency = reload.TriggerUrgencyUrgent
+				return t
+			},
+			expErr: true,
+		},
+
+		"A HMAC signed trigger with a tampered tag selector should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				t := reload.SignTriggerHMAC(context.TODO(), hmacKey, trigger)
+				t.TagSelector = "!expensive"
+				return t
+			},
+			expErr: true,
+		},
+
+		"A HMAC signed trigger with a tampered paths should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				t := reload.SignTriggerHMAC(context.TODO(), hmacKey, trigger)
+				t.Paths = []string{"/other"}
+				return t
+			},
+			expErr: true,
+		},
+
+		"A trigger without signature should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger:  func() reload.TriggerEvent { return trigger },
+			expErr:   true,
+		},
+
+		"A trigger with a malformed signature should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				return reload.TriggerEvent{ID: "t1", Metadata: map[string]string{reload.TriggerSignatureMetadataKey: "%%%"}}
+			},
+			expErr: true,
+		},
+
+		"A replayed signed trigger should be invalid.": {
+			verifier: replayVerifier,
+			trigger: func() reload.TriggerEvent {
+				t := reload.SignTriggerHMAC(context.TODO(), hmacKey, trigger)
+				_ = replayVerifier.VerifyTrigger(context.TODO(), t)
+				return t
+			},
+			expErr: true,
+		},
+
+		"A stale signed trigger should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				return signHMAC(map[string]string{
+					reload.TriggerSignedAtMetadataKey: time.Now().Add(-time.Hour).Format(time.RFC3339Nano),
+					reload.TriggerNonceMetadataKey:    "n1",
+				})
+			},
+			expErr: true,
+		},
+
+		"A signed trigger without signing time should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				return signHMAC(map[string]string{reload.TriggerNonceMetadataKey: "n1"})
+			},
+			expErr: true,
+		},
+
+		"A signed trigger without nonce should be invalid.": {
+			verifier: reload.NewHMACTriggerVerifier(hmacKey),
+			trigger: func() reload.TriggerEvent {
+				return signHMAC(map[string]string{reload.TriggerSignedAtMetadataKey: time.Now().Format(time.RFC3339Nano)})
+			},
+			expErr: true,
+		},
+
+		"A trigger signed with the Ed25519 key should be valid.": {
+			verifier: reload.NewEd25519TriggerVerifier(pub1),
+			trigger:  func() reload.TriggerEvent { return reload.SignTriggerEd25519(context.TODO(), priv1, trigger) },
+		},
+
+		"A trigger signed with any of the Ed25519 keys should be valid.": {
+			verifier: reload.NewEd25519TriggerVerifier(pub1, pub2),
+			trigger:  func() reload.TriggerEvent { return reload.SignTriggerEd25519(context.TODO(), priv2, trigger) },
+		},
+
+		"A trigger signed with an unknown Ed25519 key should be invalid.": {
+			verifier: reload.NewEd25519TriggerVerifier(pub1, pub2),
+			trigger:  func() reload.TriggerEvent { return reload.SignTriggerEd25519(context.TODO(), privOther, trigger) },
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := test.verifier.VerifyTrigger(context.TODO(), test.trigger())
+
+			if test.expErr {
+				assert.ErrorIs(err, reload.ErrInvalidTriggerSignature)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestManagerNotifierVerifier(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	key := []byte("secret")
+	rec := reloadtest.NewRecorder()
+	mr := &testMetricsRecorder{}
+	m := reload.NewManager(reload.WithSubscriber(rec), reload.WithMetricsRecorder(mr))
+	var gotTriggers []reload.TriggerEvent
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		t, _ := reload.TriggerEventFromContext(ctx)
+		gotTriggers = append(gotTriggers, t)
+		return nil
+	}), reload.WithReloaderName("r0"))
+	notifierC := make(chan reload.TriggerEvent)
+	m.On(testTriggerNotifier{c: notifierC},
+		reload.WithNotifierName("webhook"),
+		reload.WithNotifierMetadata(map[string]string{"origin": "github"}),
+		reload.WithNotifierVerifier(reload.NewHMACTriggerVerifier(key)),
+	)
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	notifierC <- reload.TriggerEvent{ID: "spoofed"}
+	signed := reload.SignTriggerHMAC(context.TODO(), key, reload.TriggerEvent{ID: "signed"})
+	notifierC <- signed
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// Check.
+	require.NoError(<-runErr)
+	assert.Equal([]reload.TriggerEvent{{
+		ID:     "signed",
+		Source: "webhook",
+		Metadata: map[string]string{
+			"origin":                           "github",
+			reload.TriggerSignatureMetadataKey: signed.Metadata[reload.TriggerSignatureMetadataKey],
+			reload.TriggerSignedAtMetadataKey:  signed.Metadata[reload.TriggerSignedAtMetadataKey],
+			reload.TriggerNonceMetadataKey:     signed.Metadata[reload.TriggerNonceMetadataKey],
+		},
+	}}, gotTriggers)
+	assert.Equal(map[reload.DropReason]int{reload.DropReasonUnverified: 1}, mr.droppedTriggers)
+	rec.AssertTrail(t,
+		"trigger_dropped id=spoofed source=webhook err=invalid trigger signature: missing signature",
+		"trigger_received id=signed source=webhook",
+		"reload_started id=signed",
+		"group_started id=signed priority=0",
+		"reloader_finished id=signed priority=0 reloader=r0",
+		"group_finished id=signed priority=0",
+		"reload_finished id=signed",
+	)
+}
+
+func TestManagerNotifierVerifierClock(t *testing.T) {
+	require := require.New(t)
+
+	// Prepare.
+	key := []byte("secret")
+	clock := reloadtest.NewClock(time.Date(2021, 7, 19, 10, 0, 0, 0, time.UTC))
+	clockCtx := reload.ContextWithClock(context.Background(), clock)
+	rec := reloadtest.NewRecorder()
+	m := reload.NewManager(reload.WithSubscriber(rec), reload.WithClock(clock))
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error { return nil }), reload.WithReloaderName("r0"))
+	notifierC := make(chan reload.TriggerEvent)
+	m.On(testTriggerNotifier{c: notifierC},
+		reload.WithNotifierName("webhook"),
+		reload.WithNotifierVerifier(reload.NewHMACTriggerVerifier(key)),
+	)
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	stale := reload.SignTriggerHMAC(clockCtx, key, reload.TriggerEvent{ID: "stale"})
+	notifierC <- reload.SignTriggerHMAC(clockCtx, key, reload.TriggerEvent{ID: "signed"})
+	time.Sleep(10 * time.Millisecond)
+	clock.Advance(reload.TriggerSignatureMaxAge + time.Second)
+	notifierC <- stale
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// Check.
+	require.NoError(<-runErr)
+	rec.AssertTrail(t,
+		"trigger_received id=signed source=webhook",
+		"reload_started id=signed",
+		"group_started id=signed priority=0",
+		"reloader_finished id=signed priority=0 reloader=r0",
+		"group_finished id=signed priority=0",
+		"reload_finished id=signed",
+		"trigger_dropped id=stale source=webhook err=invalid trigger signature: stale signature",
+	)
+}
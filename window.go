@@ -0,0 +1,217 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReloadWindow restricts when the notifier triggered reloads can be executed
+// (see WithReloadWindow).
+type ReloadWindow interface {
+	// NextOpen returns t when the reloads can be executed at t, otherwise the
+	// next time the window should be checked, usually when it opens.
+	NextOpen(t time.Time) time.Time
+}
+
+// ReloadWindowFunc returns a ReloadWindow that uses a predicate to know when
+// the reloads can be executed, while closed the predicate is checked again
+// every interval.
+func ReloadWindowFunc(interval time.Duration, open func(t time.Time) bool) ReloadWindow {
+	return reloadWindowFunc{interval: interval, open: open}
+}
+
+type reloadWindowFunc struct {
+	interval time.Duration
+	open     func(t time.Time) bool
+}
+
+func (r reloadWindowFunc) NextOpen(t time.Time) time.Time {
+	if r.open(t) {
+		return t
+	}
+	return t.Add(r.interval)
+}
+
+// DailyReloadWindow is a ReloadWindow open every day (or only on some
+// weekdays) between two times of the day, e.g: from 02:00 to 05:00.
+type DailyReloadWindow struct {
+	// Start is the time of the day when the window opens, as the duration since
+	// midnight.
+	Start time.Duration
+	// End is the time of the day when the window closes, as the duration since
+	// midnight. If it's before Start, the window closes the next day, if it's
+	// the same as Start, the window is open all day.
+	End time.Duration
+	// Weekdays are the days when the window opens, if empty every day.
+	Weekdays []time.Weekday
+	// Location is the time zone of the window, if nil the time zone of the
+	// manager clock.
+	Location *time.Location
+}
+
+func (d DailyReloadWindow) NextOpen(t time.Time) time.Time {
+	if d.Location != nil {
+		t = t.In(d.Location)
+	}
+
+	length := d.End - d.Start
+	if length <= 0 {
+		length += 24 * time.Hour
+	}
+
+	// Start on the previous day as its window can still be open.
+	for day := -1; day <= 7; day++ {
+		midnight := time.Date(t.Year(), t.Month(), t.Day()+day, 0, 0, 0, 0, t.Location())
+		if len(d.Weekdays) > 0 && !slices.Contains(d.Weekdays, midnight.Weekday()) {
+			continue
+		}
+
+		start := midnight.Add(d.Start)
+		end := start.Add(length)
+		switch {
+		case t.Before(start):
+			return start
+		case t.Before(end):
+			return t
+		}
+	}
+
+	// Without weekdays the window is never open, check again in a day.
+	return t.Add(24 * time.Hour)
+}
+
+// waitReloadWindow waits until the reload window opens to reload the trigger,
+// the triggers received meanwhile are coalesced into one pending trigger per
+// route (the latest one, see triggerRoute), so the triggers of other sources,
+// tags or pipelines are not lost. Once the window opens, the first pending
+// trigger is returned and the rest are scheduled after it. The urgent
+// triggers don't wait.
+func (m *Manager) waitReloadWindow(ctx context.Context, signal <-chan notifierResult, scheduled *[]notifierResult, res notifierResult, lastEnd time.Time) (notifierResult, error) {
+	if m.cfg.reloadWindow == nil {
+		return res, nil
+	}
+
+	deferred := []notifierResult{res}
+	emitted := false
+	for {
+		next, ok := m.nextDeferred(deferred)
+		if ok {
+			// Schedule the rest in order, before the already scheduled ones.
+			rest := slices.Delete(slices.Clone(deferred), next, next+1)
+			*scheduled = append(rest, *scheduled...)
+			return deferred[next], nil
+		}
+
+		if !emitted {
+			m.emit(ctx, Event{Type: EventReloadDeferred, Trigger: deferred[len(deferred)-1].Trigger})
+			emitted = true
+		}
+
+		now := m.cfg.clock.Now()
+		t := m.cfg.clock.NewTimer(m.cfg.reloadWindow.NextOpen(now).Sub(now))
+		select {
+		case <-t.C():
+		case queued := <-signal:
+			t.Stop()
+			if queued.Err != nil {
+				return deferred[0], fmt.Errorf("notifier failed: %w", queued.Err)
+			}
+
+			m.receiveTrigger(ctx, &queued, lastEnd)
+			var err error
+			deferred, err = m.coalesceRoute(ctx, deferred, queued)
+			if err != nil {
+				return deferred[0], err
+			}
+			emitted = false
+		case <-ctx.Done():
+			t.Stop()
+			return deferred[0], nil
+		}
+	}
+}
+
+// nextDeferred returns the deferred trigger that can be reloaded now: the
+// first urgent one, or the first one if the reload window is open.
+func (m *Manager) nextDeferred(deferred []notifierResult) (int, bool) {
+	for i, res := range deferred {
+		if res.Trigger.Urgency == TriggerUrgencyUrgent {
+			return i, true
+		}
+	}
+
+	now := m.cfg.clock.Now()
+	if !m.cfg.reloadWindow.NextOpen(now).After(now) {
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// coalesceRoute adds the queued trigger to the pending triggers, replacing
+// (and skipping) the pending trigger of the same route, if any. The replaced
+// trigger paths and keys are merged into the queued one.
+func (m *Manager) coalesceRoute(ctx context.Context, pending []notifierResult, queued notifierResult) ([]notifierResult, error) {
+	route := m.triggerRoute(queued.Trigger)
+	for i, res := range pending {
+		if m.triggerRoute(res.Trigger) != route {
+			continue
+		}
+
+		m.cfg.metricsRecorder.IncCoalescedTrigger(ctx, res.Trigger.Source)
+		err := m.skipTrigger(ctx, res.Trigger, nil)
+		if err != nil {
+			return pending, fmt.Errorf("reload process failed: %w", err)
+		}
+		// The coalesced triggers are pending until the next one is reloaded.
+		queued.Pending = append(queued.Pending, res.Pending...)
+		queued.Trigger.Paths = mergeTargets(res.Trigger.Paths, queued.Trigger.Paths)
+		queued.Trigger.Keys = mergeTargets(res.Trigger.Keys, queued.Trigger.Keys)
+		pending = slices.Delete(pending, i, i+1)
+		break
+	}
+
+	return append(pending, queued), nil
+}
+
+// triggerRoute returns the route of the trigger, the reloaders it reloads, so
+// the triggers of the same route can be coalesced.
+func (m *Manager) triggerRoute(t TriggerEvent) string {
+	plan, err := m.reloadPlan(t)
+	if err != nil {
+		// The invalid triggers are only coalesced with the same invalid ones.
+		return "invalid:" + t.TagSelector
+	}
+
+	var b strings.Builder
+	for _, rg := range plan {
+		fmt.Fprintf(&b, "%d:", rg.priority)
+		for _, r := range rg.reloaders {
+			b.WriteString(r.name)
+			b.WriteByte(',')
+		}
+		b.WriteByte(';')
+	}
+
+	return b.String()
+}
+
+// mergeTargets merges the paths or keys of two triggers, the triggers without
+// them reload everything, so the merge does too.
+func mergeTargets(a, b []string) []string {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+
+	merged := slices.Clone(a)
+	for _, v := range b {
+		if !slices.Contains(merged, v) {
+			merged = append(merged, v)
+		}
+	}
+
+	return merged
+}
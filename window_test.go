@@ -0,0 +1,214 @@
+package reload_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/reload"
+	"github.com/slok/reload/reloadtest"
+)
+
+func TestDailyReloadWindow(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	day := func(d, h, m int) time.Time { return time.Date(2024, 1, d, h, m, 0, 0, time.UTC) }
+
+	tests := map[string]struct {
+		window  reload.DailyReloadWindow
+		t       time.Time
+		expNext time.Time
+	}{
+		"A time inside the window should be open.": {
+			window:  reload.DailyReloadWindow{Start: 2 * time.Hour, End: 5 * time.Hour},
+			t:       day(1, 3, 0),
+			expNext: day(1, 3, 0),
+		},
+
+		"A time before the window should open the same day.": {
+			window:  reload.DailyReloadWindow{Start: 2 * time.Hour, End: 5 * time.Hour},
+			t:       day(1, 1, 0),
+			expNext: day(1, 2, 0),
+		},
+
+		"A time after the window should open the next day.": {
+			window:  reload.DailyReloadWindow{Start: 2 * time.Hour, End: 5 * time.Hour},
+			t:       day(1, 5, 0),
+			expNext: day(2, 2, 0),
+		},
+
+		"A time after midnight inside a window that crosses midnight should be open.": {
+			window:  reload.DailyReloadWindow{Start: 23 * time.Hour, End: time.Hour},
+			t:       day(2, 0, 30),
+			expNext: day(2, 0, 30),
+		},
+
+		"A window with the same start and end should be open all day.": {
+			window:  reload.DailyReloadWindow{Start: 2 * time.Hour, End: 2 * time.Hour},
+			t:       day(1, 1, 0),
+			expNext: day(1, 1, 0),
+		},
+
+		"A time on a day without window should open on the next weekday.": {
+			window:  reload.DailyReloadWindow{Start: 2 * time.Hour, End: 5 * time.Hour, Weekdays: []time.Weekday{time.Saturday}},
+			t:       day(1, 3, 0),
+			expNext: day(6, 2, 0),
+		},
+
+		"The window should use its location.": {
+			window:  reload.DailyReloadWindow{Start: 2 * time.Hour, End: 5 * time.Hour, Location: time.FixedZone("UTC+2", 2*60*60)},
+			t:       day(1, 1, 0),
+			expNext: day(1, 1, 0),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got := test.window.NextOpen(test.t)
+
+			assert.True(test.expNext.Equal(got), "expected %s, got %s", test.expNext, got)
+		})
+	}
+}
+
+func TestManagerReloadWindow(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	rec := reloadtest.NewRecorder()
+	deferred := make(chan struct{}, 2)
+	finished := make(chan struct{})
+	m := reload.NewManager(
+		reload.WithClock(clock),
+		reload.WithReloadWindow(reload.DailyReloadWindow{Start: 2 * time.Hour, End: 5 * time.Hour}),
+		reload.WithSubscriber(rec),
+		reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+			switch e.Type {
+			case reload.EventReloadDeferred:
+				deferred <- struct{}{}
+			case reload.EventReloadFinished:
+				close(finished)
+			}
+		})),
+	)
+	var reloaded time.Time
+	m.Add(0, reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		reloaded = clock.Now()
+		return nil
+	}), reload.WithReloaderName("r0"))
+	notifierC := make(chan string)
+	m.On(reload.NotifierChan(notifierC), reload.WithNotifierName("test"))
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	notifierC <- "t1"
+	<-deferred
+	notifierC <- "t2"
+	<-deferred
+	require.True(clock.WaitWaiters(1, time.Second))
+	clock.Advance(14 * time.Hour)
+	<-finished
+	cancel()
+
+	// Check.
+	assert.NoError(<-runErr)
+	assert.Equal(time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC), reloaded)
+	rec.AssertTrail(t,
+		"trigger_received id=t1 source=test",
+		"reload_deferred id=t1",
+		"trigger_received id=t2 source=test",
+		"reload_skipped id=t1",
+		"reload_deferred id=t2",
+		"reload_started id=t2",
+		"group_started id=t2 priority=0",
+		"reloader_finished id=t2 priority=0 reloader=r0",
+		"group_finished id=t2 priority=0",
+		"reload_finished id=t2",
+	)
+}
+
+func TestManagerReloadWindowRoutes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Prepare.
+	clock := reloadtest.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	rec := reloadtest.NewRecorder()
+	deferred := make(chan struct{}, 3)
+	finished := make(chan struct{}, 2)
+	m := reload.NewManager(
+		reload.WithClock(clock),
+		reload.WithReloadWindow(reload.DailyReloadWindow{Start: 2 * time.Hour, End: 5 * time.Hour}),
+		reload.WithSubscriber(rec),
+		reload.WithSubscriber(reload.SubscriberFunc(func(ctx context.Context, e reload.Event) {
+			switch e.Type {
+			case reload.EventReloadDeferred:
+				deferred <- struct{}{}
+			case reload.EventReloadFinished:
+				finished <- struct{}{}
+			}
+		})),
+	)
+	var gotTriggers []reload.TriggerEvent
+	reloader := reload.ReloaderFunc(func(ctx context.Context, id string) error {
+		t, _ := reload.TriggerEventFromContext(ctx)
+		gotTriggers = append(gotTriggers, t)
+		return nil
+	})
+	m.Add(0, reloader, reload.WithReloaderName("config"), reload.WithTriggerSources("file"))
+	m.Add(0, reloader, reload.WithReloaderName("certs"), reload.WithTriggerSources("certs"))
+	fileC := make(chan reload.TriggerEvent)
+	m.On(testTriggerNotifier{c: fileC}, reload.WithNotifierName("file"))
+	certsC := make(chan reload.TriggerEvent)
+	m.On(testTriggerNotifier{c: certsC}, reload.WithNotifierName("certs"))
+
+	// Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- m.Run(ctx) }()
+	fileC <- reload.TriggerEvent{ID: "t1", Paths: []string{"/a"}}
+	<-deferred
+	certsC <- reload.TriggerEvent{ID: "t2"}
+	<-deferred
+	fileC <- reload.TriggerEvent{ID: "t3", Paths: []string{"/b"}}
+	<-deferred
+	require.True(clock.WaitWaiters(1, time.Second))
+	clock.Advance(14 * time.Hour)
+	<-finished
+	<-finished
+	cancel()
+
+	// Check.
+	assert.NoError(<-runErr)
+	assert.Equal([]reload.TriggerEvent{
+		{ID: "t2", Source: "certs"},
+		{ID: "t3", Source: "file", Paths: []string{"/a", "/b"}},
+	}, gotTriggers)
+	rec.AssertTrail(t,
+		"trigger_received id=t1 source=file",
+		"reload_deferred id=t1",
+		"trigger_received id=t2 source=certs",
+		"reload_deferred id=t2",
+		"trigger_received id=t3 source=file",
+		"reload_skipped id=t1",
+		"reload_deferred id=t3",
+		"reload_started id=t2",
+		"group_started id=t2 priority=0",
+		"reloader_finished id=t2 priority=0 reloader=certs",
+		"group_finished id=t2 priority=0",
+		"reload_finished id=t2",
+		"reload_started id=t3",
+		"group_started id=t3 priority=0",
+		"reloader_finished id=t3 priority=0 reloader=config",
+		"group_finished id=t3 priority=0",
+		"reload_finished id=t3",
+	)
+}